@@ -2225,6 +2225,75 @@ func (r *ProtocolLXD) ConsoleInstance(instanceName string, console api.InstanceC
 	return op, nil
 }
 
+// CaptureInstanceNIC requests that LXD captures the traffic of an instance NIC.
+//
+// The captured traffic is written to args.Output in pcapng format until the capture duration is
+// reached or args.Disconnect is closed.
+func (r *ProtocolLXD) CaptureInstanceNIC(instanceName string, nicName string, capture api.InstanceNICCapturePost, args *InstanceNICCaptureArgs) (Operation, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.HasExtension("instance_nic_capture") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_nic_capture\" API extension")
+	}
+
+	if args == nil || args.Output == nil {
+		return nil, fmt.Errorf("An output must be set")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/nics/%s/capture", path, url.PathEscape(instanceName), url.PathEscape(nicName)), capture, "")
+	if err != nil {
+		return nil, err
+	}
+	opAPI := op.Get()
+
+	// Parse the fds
+	fds := map[string]string{}
+
+	value, ok := opAPI.Metadata["fds"]
+	if ok {
+		values := value.(map[string]any)
+		for k, v := range values {
+			fds[k] = v.(string)
+		}
+	}
+
+	if fds["0"] == "" {
+		return nil, fmt.Errorf("Did not receive a file descriptor for the capture")
+	}
+
+	// Connect to the websocket
+	conn, err := r.GetOperationWebsocket(opAPI.ID, fds["0"])
+	if err != nil {
+		return nil, err
+	}
+
+	// Stop the capture.
+	if args.Disconnect != nil {
+		go func(disconnect <-chan bool) {
+			<-disconnect
+			msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Stopping capture")
+			// We don't care if this fails. This is just for convenience.
+			_ = conn.WriteMessage(websocket.CloseMessage, msg)
+		}(args.Disconnect)
+	}
+
+	// And write the captured traffic to the output
+	go func() {
+		<-shared.WebsocketRecvStream(args.Output, conn)
+		_ = conn.Close()
+
+		if args.DataDone != nil {
+			close(args.DataDone)
+		}
+	}()
+
+	return op, nil
+}
+
 // ConsoleInstanceDynamic requests that LXD attaches to the console device of a
 // instance with the possibility of opening multiple connections to it.
 //
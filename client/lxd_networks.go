@@ -93,6 +93,55 @@ func (r *ProtocolLXD) GetNetworkState(name string) (*api.NetworkState, error) {
 	return &state, nil
 }
 
+// GetNetworkBGPState returns the session state of the BGP peers of the network and the routes learned from them
+func (r *ProtocolLXD) GetNetworkBGPState(name string) (*api.NetworkBGPState, error) {
+	if !r.HasExtension("network_bgp_import") {
+		return nil, fmt.Errorf("The server is missing the required \"network_bgp_import\" API extension")
+	}
+
+	state := api.NetworkBGPState{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/bgp", url.PathEscape(name)), nil, "", &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// GetNetworkAllocations returns a list of the addresses assigned by LXD, optionally filtered
+func (r *ProtocolLXD) GetNetworkAllocations(args *NetworkAllocationsArgs) ([]api.NetworkAllocations, error) {
+	if !r.HasExtension("network_allocations") {
+		return nil, fmt.Errorf("The server is missing the required \"network_allocations\" API extension")
+	}
+
+	v := url.Values{}
+	if args != nil {
+		if args.AllProjects {
+			v.Set("all-projects", "true")
+		}
+
+		if args.Address != "" {
+			v.Set("address", args.Address)
+		}
+
+		if args.Type != "" {
+			v.Set("type", args.Type)
+		}
+	}
+
+	allocations := []api.NetworkAllocations{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/network-allocations?%s", v.Encode()), nil, "", &allocations)
+	if err != nil {
+		return nil, err
+	}
+
+	return allocations, nil
+}
+
 // CreateNetwork defines a new network using the provided Network struct
 func (r *ProtocolLXD) CreateNetwork(network api.NetworksPost) error {
 	if !r.HasExtension("network") {
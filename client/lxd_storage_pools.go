@@ -110,6 +110,39 @@ func (r *ProtocolLXD) DeleteStoragePool(name string) error {
 	return nil
 }
 
+// CheckStoragePool starts a consistency check of a storage pool.
+// The issues found are returned in the "issues" field of the operation metadata.
+func (r *ProtocolLXD) CheckStoragePool(name string, req api.StoragePoolCheckPost) (Operation, error) {
+	if !r.HasExtension("storage_pool_check") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_pool_check\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/storage-pools/%s/check", url.PathEscape(name)), req, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// DeduplicateStoragePool starts a deduplication of the data on a storage pool.
+// The amount of data deduplicated and the disk space freed are returned in the "deduplicated" and
+// "saved" fields of the operation metadata.
+func (r *ProtocolLXD) DeduplicateStoragePool(name string) (Operation, error) {
+	if !r.HasExtension("storage_btrfs_compression_dedup") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_btrfs_compression_dedup\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/storage-pools/%s/deduplicate", url.PathEscape(name)), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
 // GetStoragePoolResources gets the resources available to a given storage pool
 func (r *ProtocolLXD) GetStoragePoolResources(name string) (*api.ResourcesStoragePool, error) {
 	if !r.HasExtension("resources") {
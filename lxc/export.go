@@ -23,6 +23,8 @@ type cmdExport struct {
 	flagInstanceOnly         bool
 	flagOptimizedStorage     bool
 	flagCompressionAlgorithm string
+	flagParent               string
+	flagKeep                 bool
 }
 
 func (c *cmdExport) Command() *cobra.Command {
@@ -33,7 +35,11 @@ func (c *cmdExport) Command() *cobra.Command {
 		`Export instances as backup tarballs.`))
 	cmd.Example = cli.FormatSection("", i18n.G(
 		`lxc export u1 backup0.tar.gz
-    Download a backup tarball of the u1 instance.`))
+    Download a backup tarball of the u1 instance.
+
+lxc export u1 backup0.tar.gz --keep
+lxc export u1 backup1.tar.gz --parent backup0
+    Download a full backup of the u1 instance and keep it on the server, then download an incremental backup against it.`))
 
 	cmd.RunE = c.Run
 	cmd.Flags().BoolVar(&c.flagInstanceOnly, "instance-only", false,
@@ -41,6 +47,8 @@ func (c *cmdExport) Command() *cobra.Command {
 	cmd.Flags().BoolVar(&c.flagOptimizedStorage, "optimized-storage", false,
 		i18n.G("Use storage driver optimized format (can only be restored on a similar pool)"))
 	cmd.Flags().StringVar(&c.flagCompressionAlgorithm, "compression", "", i18n.G("Compression algorithm to use (none for uncompressed)")+"``")
+	cmd.Flags().StringVar(&c.flagParent, "parent", "", i18n.G("Name of the server side backup to make an incremental backup against")+"``")
+	cmd.Flags().BoolVar(&c.flagKeep, "keep", false, i18n.G("Keep the backup on the server so it can be used as the parent of incremental backups"))
 
 	return cmd
 }
@@ -74,6 +82,16 @@ func (c *cmdExport) Run(cmd *cobra.Command, args []string) error {
 		InstanceOnly:         instanceOnly,
 		OptimizedStorage:     c.flagOptimizedStorage,
 		CompressionAlgorithm: c.flagCompressionAlgorithm,
+		Parent:               c.flagParent,
+	}
+
+	if c.flagKeep {
+		// Kept backups don't expire.
+		req.ExpiresAt = time.Time{}
+	}
+
+	if c.flagParent != "" && !d.HasExtension("backup_incremental") {
+		return fmt.Errorf(i18n.G("The server doesn't implement incremental backups"))
 	}
 
 	op, err := d.CreateInstanceBackup(name, req)
@@ -111,6 +129,10 @@ func (c *cmdExport) Run(cmd *cobra.Command, args []string) error {
 		"/1.0/backups/")
 
 	defer func() {
+		if c.flagKeep {
+			return
+		}
+
 		// Delete backup after we're done
 		op, err = d.DeleteInstanceBackup(name, backupName)
 		if err == nil {
@@ -160,6 +182,11 @@ func (c *cmdExport) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Failed to close export file: %w", err)
 	}
 
+	if c.flagKeep {
+		progress.Done(fmt.Sprintf(i18n.G("Backup exported successfully and kept on the server as %q"), backupName))
+		return nil
+	}
+
 	progress.Done(i18n.G("Backup exported successfully!"))
 	return nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -19,7 +20,8 @@ import (
 type cmdImport struct {
 	global *cmdGlobal
 
-	flagStorage string
+	flagStorage     string
+	flagIncremental []string
 }
 
 func (c *cmdImport) Command() *cobra.Command {
@@ -30,10 +32,14 @@ func (c *cmdImport) Command() *cobra.Command {
 		`Import backups of instances including their snapshots.`))
 	cmd.Example = cli.FormatSection("", i18n.G(
 		`lxc import backup0.tar.gz
-    Create a new instance using backup0.tar.gz as the source.`))
+    Create a new instance using backup0.tar.gz as the source.
+
+lxc import backup0.tar.gz --incremental backup1.tar.gz --incremental backup2.tar.gz
+    Create a new instance from the backup0.tar.gz full backup and its incremental backups.`))
 
 	cmd.RunE = c.Run
 	cmd.Flags().StringVarP(&c.flagStorage, "storage", "s", "", i18n.G("Storage pool name")+"``")
+	cmd.Flags().StringArrayVar(&c.flagIncremental, "incremental", nil, i18n.G("Incremental backup file to apply on top of the backup (can be specified multiple times)")+"``")
 
 	return cmd
 }
@@ -73,21 +79,42 @@ func (c *cmdImport) Run(cmd *cobra.Command, args []string) error {
 
 	resource := resources[0]
 
-	var file *os.File
-	if srcFile == "-" {
+	var file io.ReadCloser
+	var fileSize int64
+	if len(c.flagIncremental) > 0 {
+		if srcFile == "-" {
+			return fmt.Errorf(i18n.G("Incremental backups can't be imported from standard input"))
+		}
+
+		// Combine the full backup and its incremental backups into a backup chain.
+		file, fileSize, err = backupChainReader(append([]string{srcFile}, c.flagIncremental...))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+	} else if srcFile == "-" {
+		fstat, err := os.Stdin.Stat()
+		if err != nil {
+			return err
+		}
+
 		file = os.Stdin
+		fileSize = fstat.Size()
 		c.global.flagQuiet = true
 	} else {
-		file, err = os.Open(shared.HostPathFollow(srcFile))
+		f, err := os.Open(shared.HostPathFollow(srcFile))
 		if err != nil {
 			return err
 		}
-		defer func() { _ = file.Close() }()
-	}
+		defer func() { _ = f.Close() }()
 
-	fstat, err := file.Stat()
-	if err != nil {
-		return err
+		fstat, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		file = f
+		fileSize = fstat.Size()
 	}
 
 	progress := utils.ProgressRenderer{
@@ -99,7 +126,7 @@ func (c *cmdImport) Run(cmd *cobra.Command, args []string) error {
 		BackupFile: &ioprogress.ProgressReader{
 			ReadCloser: file,
 			Tracker: &ioprogress.ProgressTracker{
-				Length: fstat.Size(),
+				Length: fileSize,
 				Handler: func(percent int64, speed int64) {
 					progress.UpdateProgress(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
 				},
@@ -205,7 +205,7 @@ func (c *cmdMove) Run(cmd *cobra.Command, args []string) error {
 				return fmt.Errorf(i18n.G("The --mode flag can't be used with --storage"))
 			}
 
-			return moveInstancePool(conf, sourceResource, destResource, c.flagInstanceOnly, c.flagStorage, stateful)
+			return moveInstancePool(conf, sourceResource, destResource, c.flagInstanceOnly, c.flagStorage, c.global.flagQuiet, stateful)
 		}
 	}
 
@@ -330,7 +330,7 @@ func moveClusterInstance(conf *config.Config, sourceResource string, destResourc
 }
 
 // Move an instance between pools using special POST /instances/<name> API.
-func moveInstancePool(conf *config.Config, sourceResource string, destResource string, instanceOnly bool, storage string, stateful bool) error {
+func moveInstancePool(conf *config.Config, sourceResource string, destResource string, instanceOnly bool, storage string, quiet bool, stateful bool) error {
 	// Parse the source.
 	sourceRemote, sourceName, err := conf.ParseRemote(sourceResource)
 	if err != nil {
@@ -373,7 +373,20 @@ func moveInstancePool(conf *config.Config, sourceResource string, destResource s
 		return fmt.Errorf(i18n.G("Migration API failure: %w"), err)
 	}
 
-	err = op.Wait()
+	// Watch the background operation (running virtual machines report the progress of their root disk mirror)
+	progress := utils.ProgressRenderer{
+		Format: i18n.G("Mirroring root disk: %s"),
+		Quiet:  quiet,
+	}
+
+	_, err = op.AddHandler(progress.UpdateOp)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	err = utils.CancelableWait(op, &progress)
+	progress.Done("")
 	if err != nil {
 		return fmt.Errorf(i18n.G("Migration operation failure: %w"), err)
 	}
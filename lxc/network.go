@@ -2,14 +2,18 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxc/utils"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -41,6 +45,10 @@ func (c *cmdNetwork) Command() *cobra.Command {
 	networkAttachProfileCmd := cmdNetworkAttachProfile{global: c.global, network: c}
 	cmd.AddCommand(networkAttachProfileCmd.Command())
 
+	// Capture
+	networkCaptureCmd := cmdNetworkCapture{global: c.global, network: c}
+	cmd.AddCommand(networkCaptureCmd.Command())
+
 	// Create
 	networkCreateCmd := cmdNetworkCreate{global: c.global, network: c}
 	cmd.AddCommand(networkCreateCmd.Command())
@@ -73,6 +81,10 @@ func (c *cmdNetwork) Command() *cobra.Command {
 	networkListCmd := cmdNetworkList{global: c.global, network: c}
 	cmd.AddCommand(networkListCmd.Command())
 
+	// List allocations
+	networkListAllocationsCmd := cmdNetworkListAllocations{global: c.global, network: c}
+	cmd.AddCommand(networkListAllocationsCmd.Command())
+
 	// List leases
 	networkListLeasesCmd := cmdNetworkListLeases{global: c.global, network: c}
 	cmd.AddCommand(networkListLeasesCmd.Command())
@@ -101,6 +113,10 @@ func (c *cmdNetwork) Command() *cobra.Command {
 	networkForwardCmd := cmdNetworkForward{global: c.global}
 	cmd.AddCommand(networkForwardCmd.Command())
 
+	// Load Balancer
+	networkLoadBalancerCmd := cmdNetworkLoadBalancer{global: c.global}
+	cmd.AddCommand(networkLoadBalancerCmd.Command())
+
 	// Peer
 	networkPeerCmd := cmdNetworkPeer{global: c.global}
 	cmd.AddCommand(networkPeerCmd.Command())
@@ -273,6 +289,123 @@ func (c *cmdNetworkAttachProfile) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Capture
+type cmdNetworkCapture struct {
+	global  *cmdGlobal
+	network *cmdNetwork
+
+	flagFilter   string
+	flagDuration int
+	flagOutput   string
+}
+
+func (c *cmdNetworkCapture) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("capture", i18n.G("[<remote>:]<instance> <device name>"))
+	cmd.Short = i18n.G("Capture the traffic of instance network interfaces")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Capture the traffic of instance network interfaces
+
+The traffic is written in pcapng format to the output file or to stdout.
+The capture runs until the duration is reached or until interrupted.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc network capture c1 eth0 --filter "tcp port 80" --duration 60 --output c1.pcapng
+    Captures the HTTP traffic of eth0 in c1 for a minute and saves it to c1.pcapng.
+
+lxc network capture c1 eth0 | wireshark -k -i -
+    Shows the traffic of eth0 in c1 live in Wireshark.`))
+
+	cmd.Flags().StringVar(&c.flagFilter, "filter", "", i18n.G("Packet filter in pcap-filter syntax")+"``")
+	cmd.Flags().IntVar(&c.flagDuration, "duration", 0, i18n.G("Capture duration in seconds")+"``")
+	cmd.Flags().StringVarP(&c.flagOutput, "output", "o", "", i18n.G("File to write the captured traffic to")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkCapture) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	if c.flagDuration < 0 {
+		return fmt.Errorf(i18n.G("Invalid capture duration %d"), c.flagDuration)
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing instance name"))
+	}
+
+	// Setup the output.
+	var output io.Writer
+	if c.flagOutput != "" {
+		file, err := os.Create(c.flagOutput)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = file.Close() }()
+
+		output = file
+	} else {
+		if termios.IsTerminal(getStdoutFd()) {
+			return fmt.Errorf(i18n.G("Refusing to write the captured traffic to a terminal, use --output or a pipe"))
+		}
+
+		output = os.Stdout
+	}
+
+	// Stop the capture when interrupted.
+	disconnect := make(chan bool)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	go func() {
+		_, ok := <-signals
+		if ok {
+			close(disconnect)
+		}
+	}()
+
+	req := api.InstanceNICCapturePost{
+		Filter:   c.flagFilter,
+		Duration: c.flagDuration,
+	}
+
+	captureArgs := lxd.InstanceNICCaptureArgs{
+		Output:     output,
+		DataDone:   make(chan bool),
+		Disconnect: disconnect,
+	}
+
+	op, err := resource.server.CaptureInstanceNIC(resource.name, args[1], req, &captureArgs)
+	if err != nil {
+		return err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	// Wait for all the captured traffic to be written.
+	<-captureArgs.DataDone
+
+	return nil
+}
+
 // Create
 type cmdNetworkCreate struct {
 	global  *cmdGlobal
@@ -865,6 +998,29 @@ func (c *cmdNetworkInfo) Run(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  %s: %s\n", i18n.G("Chassis"), state.OVN.Chassis)
 	}
 
+	// QoS information.
+	if len(state.QoS) > 0 {
+		fmt.Println("")
+		fmt.Println(i18n.G("QoS classes:"))
+		for _, class := range state.QoS {
+			fmt.Printf("  %s:\n", class.Name)
+			fmt.Printf("    %s: %s\n", i18n.G("Rate"), units.GetBitSizeString(class.Rate, 2))
+			fmt.Printf("    %s: %s\n", i18n.G("Ceiling"), units.GetBitSizeString(class.Ceil, 2))
+			if class.Priority != "" {
+				fmt.Printf("    %s: %s\n", i18n.G("Priority"), class.Priority)
+			}
+
+			fmt.Printf("    %s: %s\n", i18n.G("Bytes received"), units.GetByteSizeString(class.Received.Bytes, 2))
+			fmt.Printf("    %s: %s\n", i18n.G("Bytes sent"), units.GetByteSizeString(class.Sent.Bytes, 2))
+			fmt.Printf("    %s: %d\n", i18n.G("Packets received"), class.Received.Packets)
+			fmt.Printf("    %s: %d\n", i18n.G("Packets sent"), class.Sent.Packets)
+			fmt.Printf("    %s: %d\n", i18n.G("Dropped received"), class.Received.Drops)
+			fmt.Printf("    %s: %d\n", i18n.G("Dropped sent"), class.Sent.Drops)
+			fmt.Printf("    %s: %d\n", i18n.G("Overlimits received"), class.Received.Overlimits)
+			fmt.Printf("    %s: %d\n", i18n.G("Overlimits sent"), class.Sent.Overlimits)
+		}
+	}
+
 	return nil
 }
 
@@ -961,6 +1117,110 @@ func (c *cmdNetworkList) Run(cmd *cobra.Command, args []string) error {
 	return utils.RenderTable(c.flagFormat, header, data, networks)
 }
 
+// List allocations
+type cmdNetworkListAllocations struct {
+	global  *cmdGlobal
+	network *cmdNetwork
+
+	flagFormat      string
+	flagAllProjects bool
+	flagAddress     string
+	flagType        string
+}
+
+func (c *cmdNetworkListAllocations) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("list-allocations", i18n.G("[<remote>:]"))
+	cmd.Short = i18n.G("List network allocations in use")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List network allocations in use
+
+Lists the addresses assigned by LXD to instances, networks, network forwards,
+network load balancers and OVN uplink connections.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc network list-allocations --all-projects --address 10.0.0.0/24
+    List the allocations within 10.0.0.0/24 in all projects.
+
+lxc network list-allocations --type instance
+    List the addresses of the instances in the current project.`))
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+	cmd.Flags().BoolVar(&c.flagAllProjects, "all-projects", false, i18n.G("Display network allocations from all projects"))
+	cmd.Flags().StringVar(&c.flagAddress, "address", "", i18n.G("Only show the allocations within this address or subnet")+"``")
+	cmd.Flags().StringVar(&c.flagType, "type", "", i18n.G("Only show the allocations of this type (instance, network, network-forward, network-load-balancer or uplink)")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkListAllocations) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	if c.global.flagProject != "" && c.flagAllProjects {
+		return fmt.Errorf(i18n.G("Can't specify --project with --all-projects"))
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) > 0 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.ParseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	// List the allocations
+	allocations, err := resource.server.GetNetworkAllocations(&lxd.NetworkAllocationsArgs{
+		AllProjects: c.flagAllProjects,
+		Address:     c.flagAddress,
+		Type:        c.flagType,
+	})
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, allocation := range allocations {
+		entry := []string{allocation.UsedBy, allocation.Address, allocation.Type, strconv.FormatBool(allocation.NAT), allocation.Hwaddr}
+		if c.flagAllProjects {
+			entry = append(entry, allocation.Project)
+		}
+
+		if resource.server.IsClustered() {
+			entry = append(entry, allocation.Location)
+		}
+
+		data = append(data, entry)
+	}
+	sort.Sort(utils.ByName(data))
+
+	header := []string{
+		i18n.G("USED BY"),
+		i18n.G("ADDRESS"),
+		i18n.G("TYPE"),
+		i18n.G("NAT"),
+		i18n.G("MAC ADDRESS"),
+	}
+
+	if c.flagAllProjects {
+		header = append(header, i18n.G("PROJECT"))
+	}
+
+	if resource.server.IsClustered() {
+		header = append(header, i18n.G("LOCATION"))
+	}
+
+	return utils.RenderTable(c.flagFormat, header, data, allocations)
+}
+
 // List leases
 type cmdNetworkListLeases struct {
 	global  *cmdGlobal
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/url"
@@ -34,10 +35,22 @@ func (c *cmdStorage) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Manage storage pools and volumes`))
 
+	// Bucket
+	storageBucketCmd := cmdStorageBucket{global: c.global}
+	cmd.AddCommand(storageBucketCmd.Command())
+
+	// Check
+	storageCheckCmd := cmdStorageCheck{global: c.global, storage: c}
+	cmd.AddCommand(storageCheckCmd.Command())
+
 	// Create
 	storageCreateCmd := cmdStorageCreate{global: c.global, storage: c}
 	cmd.AddCommand(storageCreateCmd.Command())
 
+	// Deduplicate
+	storageDeduplicateCmd := cmdStorageDeduplicate{global: c.global, storage: c}
+	cmd.AddCommand(storageDeduplicateCmd.Command())
+
 	// Delete
 	storageDeleteCmd := cmdStorageDelete{global: c.global, storage: c}
 	cmd.AddCommand(storageDeleteCmd.Command())
@@ -80,6 +93,124 @@ func (c *cmdStorage) Command() *cobra.Command {
 	return cmd
 }
 
+// Check
+type cmdStorageCheck struct {
+	global  *cmdGlobal
+	storage *cmdStorage
+
+	flagRepair bool
+	flagFormat string
+}
+
+func (c *cmdStorageCheck) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("check", i18n.G("[<remote>:]<pool>"))
+	cmd.Short = i18n.G("Check storage pools for inconsistencies")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Check storage pools for inconsistencies
+
+Compares the volumes on the storage pool with the volume records in the database and lists
+orphaned volumes, missing volumes, volumes with the wrong size and volumes with the wrong content type.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc storage check default
+    Check the "default" storage pool for inconsistencies.
+
+lxc storage check default --repair
+    Check the "default" storage pool for inconsistencies and attempt to repair them.`))
+
+	cmd.Flags().StringVar(&c.storage.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.Flags().BoolVar(&c.flagRepair, "repair", false, i18n.G("Attempt to repair the issues found"))
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdStorageCheck) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+	client := resource.server
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing pool name"))
+	}
+
+	if c.storage.flagTarget != "" {
+		client = client.UseTarget(c.storage.flagTarget)
+	}
+
+	op, err := client.CheckStoragePool(resource.name, api.StoragePoolCheckPost{Repair: c.flagRepair})
+	if err != nil {
+		return err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	// Decode the issues from the operation metadata.
+	result := api.StoragePoolCheckResult{}
+	metadata, err := json.Marshal(op.Get().Metadata)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(metadata, &result)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Issues) == 0 && c.flagFormat == "table" {
+		if !c.global.flagQuiet {
+			fmt.Printf(i18n.G("No issues found on storage pool %s")+"\n", resource.name)
+		}
+
+		return nil
+	}
+
+	data := [][]string{}
+	for _, issue := range result.Issues {
+		details := []string{issue.Type, issue.Project, issue.VolumeType, issue.Volume, issue.Description}
+
+		if c.flagRepair {
+			repaired := i18n.G("YES")
+			if !issue.Repaired {
+				repaired = fmt.Sprintf(i18n.G("NO (%s)"), issue.RepairError)
+			}
+
+			details = append(details, repaired)
+		}
+
+		data = append(data, details)
+	}
+
+	header := []string{
+		i18n.G("ISSUE"),
+		i18n.G("PROJECT"),
+		i18n.G("TYPE"),
+		i18n.G("NAME"),
+		i18n.G("DESCRIPTION"),
+	}
+
+	if c.flagRepair {
+		header = append(header, i18n.G("REPAIRED"))
+	}
+
+	return utils.RenderTable(c.flagFormat, header, data, result.Issues)
+}
+
 // Create
 type cmdStorageCreate struct {
 	global  *cmdGlobal
@@ -153,6 +284,81 @@ func (c *cmdStorageCreate) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Deduplicate
+type cmdStorageDeduplicate struct {
+	global  *cmdGlobal
+	storage *cmdStorage
+}
+
+func (c *cmdStorageDeduplicate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("deduplicate", i18n.G("[<remote>:]<pool>"))
+	cmd.Short = i18n.G("Deduplicate the data of storage pools")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Deduplicate the data of storage pools
+
+Identical data found in the instance and custom volumes of the storage pool is shared between them.
+This is only supported by the btrfs driver.`))
+
+	cmd.Flags().StringVar(&c.storage.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdStorageDeduplicate) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+	client := resource.server
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing pool name"))
+	}
+
+	if c.storage.flagTarget != "" {
+		client = client.UseTarget(c.storage.flagTarget)
+	}
+
+	op, err := client.DeduplicateStoragePool(resource.name)
+	if err != nil {
+		return err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	// Decode the result from the operation metadata.
+	result := api.StoragePoolDeduplicateResult{}
+	metadata, err := json.Marshal(op.Get().Metadata)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(metadata, &result)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Storage pool %s deduplicated: %s of data shared, %s freed")+"\n", resource.name, units.GetByteSizeStringIEC(result.Deduplicated, 2), units.GetByteSizeStringIEC(result.Saved, 2))
+	}
+
+	return nil
+}
+
 // Delete
 type cmdStorageDelete struct {
 	global  *cmdGlobal
@@ -455,6 +661,8 @@ func (c *cmdStorageInfo) Run(cmd *cobra.Command, args []string) error {
 	descriptionstring := i18n.G("description")
 	totalspacestring := i18n.G("total space")
 	spaceusedstring := i18n.G("space used")
+	spacesavedstring := i18n.G("space saved")
+	spaceprovisionedstring := i18n.G("space provisioned")
 
 	// Initialize the usedby map
 	poolusedby[usedbystring] = map[string][]string{}
@@ -491,6 +699,22 @@ func (c *cmdStorageInfo) Run(cmd *cobra.Command, args []string) error {
 		poolinfo[infostring][spaceusedstring] = units.GetByteSizeStringIEC(int64(res.Space.Used), 2)
 	}
 
+	if res.Space.Saved > 0 {
+		if c.flagBytes {
+			poolinfo[infostring][spacesavedstring] = strconv.FormatUint(res.Space.Saved, 10)
+		} else {
+			poolinfo[infostring][spacesavedstring] = units.GetByteSizeStringIEC(int64(res.Space.Saved), 2)
+		}
+	}
+
+	if res.Space.Provisioned > 0 {
+		if c.flagBytes {
+			poolinfo[infostring][spaceprovisionedstring] = strconv.FormatUint(res.Space.Provisioned, 10)
+		} else {
+			poolinfo[infostring][spaceprovisionedstring] = units.GetByteSizeStringIEC(int64(res.Space.Provisioned), 2)
+		}
+	}
+
 	poolinfodata, err := yaml.Marshal(poolinfo)
 	if err != nil {
 		return err
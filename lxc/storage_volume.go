@@ -1226,6 +1226,25 @@ func (c *cmdStorageVolumeInfo) Run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if volState.IO != nil {
+		fmt.Println(i18n.G("IO:"))
+		fmt.Printf("  %s: %s\n", i18n.G("Bytes read"), units.GetByteSizeStringIEC(int64(volState.IO.ReadBytes), 2))
+		fmt.Printf("  %s: %s\n", i18n.G("Bytes written"), units.GetByteSizeStringIEC(int64(volState.IO.WrittenBytes), 2))
+		fmt.Printf("  %s: %d\n", i18n.G("Reads completed"), volState.IO.ReadsCompleted)
+		fmt.Printf("  %s: %d\n", i18n.G("Writes completed"), volState.IO.WritesCompleted)
+	}
+
+	if volState.Replication != nil {
+		fmt.Println(i18n.G("Replication:"))
+		fmt.Printf("  %s: %s\n", i18n.G("Target"), volState.Replication.Target)
+		if volState.Replication.Lag >= 0 {
+			fmt.Printf("  %s: %s\n", i18n.G("Last sync"), volState.Replication.LastSync.Local().Format(layout))
+			fmt.Printf("  %s: %s\n", i18n.G("Lag"), (time.Duration(volState.Replication.Lag) * time.Second).String())
+		} else {
+			fmt.Printf("  %s: %s\n", i18n.G("Last sync"), i18n.G("never"))
+		}
+	}
+
 	// List snapshots
 	firstSnapshot := true
 	if len(volSnapshots) > 0 {
@@ -2053,6 +2072,8 @@ type cmdStorageVolumeExport struct {
 	flagVolumeOnly           bool
 	flagOptimizedStorage     bool
 	flagCompressionAlgorithm string
+	flagParent               string
+	flagKeep                 bool
 }
 
 func (c *cmdStorageVolumeExport) Command() *cobra.Command {
@@ -2066,6 +2087,8 @@ func (c *cmdStorageVolumeExport) Command() *cobra.Command {
 	cmd.Flags().BoolVar(&c.flagOptimizedStorage, "optimized-storage", false,
 		i18n.G("Use storage driver optimized format (can only be restored on a similar pool)"))
 	cmd.Flags().StringVar(&c.flagCompressionAlgorithm, "compression", "", i18n.G("Define a compression algorithm: for backup or none")+"``")
+	cmd.Flags().StringVar(&c.flagParent, "parent", "", i18n.G("Name of the server side backup to make an incremental backup against")+"``")
+	cmd.Flags().BoolVar(&c.flagKeep, "keep", false, i18n.G("Keep the backup on the server so it can be used as the parent of incremental backups"))
 	cmd.Flags().StringVar(&c.storage.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
 	cmd.RunE = c.Run
 
@@ -2110,6 +2133,16 @@ func (c *cmdStorageVolumeExport) Run(cmd *cobra.Command, args []string) error {
 		VolumeOnly:           volumeOnly,
 		OptimizedStorage:     c.flagOptimizedStorage,
 		CompressionAlgorithm: c.flagCompressionAlgorithm,
+		Parent:               c.flagParent,
+	}
+
+	if c.flagKeep {
+		// Kept backups don't expire.
+		req.ExpiresAt = time.Time{}
+	}
+
+	if c.flagParent != "" && !d.HasExtension("backup_incremental") {
+		return fmt.Errorf(i18n.G("The server doesn't implement incremental backups"))
 	}
 
 	op, err := d.CreateStoragePoolVolumeBackup(name, volName, req)
@@ -2147,6 +2180,10 @@ func (c *cmdStorageVolumeExport) Run(cmd *cobra.Command, args []string) error {
 		"/1.0/backups/")
 
 	defer func() {
+		if c.flagKeep {
+			return
+		}
+
 		// Delete backup after we're done
 		op, err = d.DeleteStoragePoolVolumeBackup(name, volName, backupName)
 		if err == nil {
@@ -2185,6 +2222,11 @@ func (c *cmdStorageVolumeExport) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Failed to fetch storage volume backup file: %w", err)
 	}
 
+	if c.flagKeep {
+		progress.Done(fmt.Sprintf(i18n.G("Backup exported successfully and kept on the server as %q"), backupName))
+		return nil
+	}
+
 	progress.Done(i18n.G("Backup exported successfully!"))
 	return nil
 }
@@ -2194,6 +2236,8 @@ type cmdStorageVolumeImport struct {
 	global        *cmdGlobal
 	storage       *cmdStorage
 	storageVolume *cmdStorageVolume
+
+	flagIncremental []string
 }
 
 func (c *cmdStorageVolumeImport) Command() *cobra.Command {
@@ -2206,6 +2250,7 @@ func (c *cmdStorageVolumeImport) Command() *cobra.Command {
 		`lxc storage volume import default backup0.tar.gz
 		Create a new custom volume using backup0.tar.gz as the source.`))
 	cmd.Flags().StringVar(&c.storage.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.Flags().StringArrayVar(&c.flagIncremental, "incremental", nil, i18n.G("Incremental backup file to apply on top of the backup (can be specified multiple times)")+"``")
 	cmd.RunE = c.Run
 
 	return cmd
@@ -2236,17 +2281,32 @@ func (c *cmdStorageVolumeImport) Run(cmd *cobra.Command, args []string) error {
 		d = d.UseTarget(c.storage.flagTarget)
 	}
 
-	file, err := os.Open(shared.HostPathFollow(args[1]))
-	if err != nil {
-		return err
-	}
-	defer func() { _ = file.Close() }()
+	var file io.ReadCloser
+	var fileSize int64
+	if len(c.flagIncremental) > 0 {
+		// Combine the full backup and its incremental backups into a backup chain.
+		file, fileSize, err = backupChainReader(append([]string{args[1]}, c.flagIncremental...))
+		if err != nil {
+			return err
+		}
+	} else {
+		f, err := os.Open(shared.HostPathFollow(args[1]))
+		if err != nil {
+			return err
+		}
 
-	fstat, err := file.Stat()
-	if err != nil {
-		return err
+		fstat, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+
+		file = f
+		fileSize = fstat.Size()
 	}
 
+	defer func() { _ = file.Close() }()
+
 	volName := ""
 	if len(args) >= 3 {
 		volName = args[2]
@@ -2261,7 +2321,7 @@ func (c *cmdStorageVolumeImport) Run(cmd *cobra.Command, args []string) error {
 		BackupFile: &ioprogress.ProgressReader{
 			ReadCloser: file,
 			Tracker: &ioprogress.ProgressTracker{
-				Length: fstat.Size(),
+				Length: fileSize,
 				Handler: func(percent int64, speed int64) {
 					progress.UpdateProgress(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
 				},
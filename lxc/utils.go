@@ -1,7 +1,9 @@
 package main
 
 import (
+	"archive/tar"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"reflect"
@@ -253,3 +255,74 @@ func getServerSupportedFilters(filters []string, i interface{}) ([]string, []str
 
 	return supportedFilters, unsupportedFilters
 }
+
+// backupChainReader returns a reader for an incremental backup chain made of the given backup files (the full
+// backup first, followed by its incremental backups in order) along with the total size of the chain.
+func backupChainReader(paths []string) (io.ReadCloser, int64, error) {
+	files := make([]*os.File, 0, len(paths))
+	closeFiles := func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}
+
+	var size int64
+	for _, path := range paths {
+		f, err := os.Open(shared.HostPathFollow(path))
+		if err != nil {
+			closeFiles()
+			return nil, -1, err
+		}
+
+		files = append(files, f)
+
+		fstat, err := f.Stat()
+		if err != nil {
+			closeFiles()
+			return nil, -1, err
+		}
+
+		// Each file is stored as a header block followed by its content padded to the block size.
+		size += 512 + ((fstat.Size()+511)/512)*512
+	}
+
+	// The tarball ends with two empty blocks.
+	size += 1024
+
+	r, w := io.Pipe()
+	go func() {
+		defer closeFiles()
+
+		tw := tar.NewWriter(w)
+		for i, f := range files {
+			fstat, err := f.Stat()
+			if err != nil {
+				_ = w.CloseWithError(err)
+				return
+			}
+
+			hdr := &tar.Header{
+				Name:    fmt.Sprintf("chain/%04d", i),
+				Mode:    0600,
+				Size:    fstat.Size(),
+				ModTime: fstat.ModTime(),
+			}
+
+			err = tw.WriteHeader(hdr)
+			if err != nil {
+				_ = w.CloseWithError(err)
+				return
+			}
+
+			_, err = io.Copy(tw, f)
+			if err != nil {
+				_ = w.CloseWithError(err)
+				return
+			}
+		}
+
+		_ = w.CloseWithError(tw.Close())
+	}()
+
+	return r, size, nil
+}
@@ -191,6 +191,7 @@ func lxdSetupUser(uid uint32) error {
 					"features.images":               "true",
 					"features.networks":             "false",
 					"features.profiles":             "true",
+					"features.storage.buckets":      "true",
 					"features.storage.volumes":      "true",
 					"restricted":                    "true",
 					"restricted.containers.nesting": "allow",
@@ -1,16 +1,20 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"net/http/httputil"
 	"net/url"
 	"strings"
 
 	"github.com/gorilla/mux"
 	clusterConfig "github.com/lxc/lxd/lxd/cluster/config"
 	"github.com/lxc/lxd/lxd/cluster/request"
+	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/project"
 	lxdRequest "github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
+	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/shared/logger"
 )
 
@@ -124,6 +128,56 @@ func metricsServer(d *Daemon) *http.Server {
 	return &http.Server{Handler: &lxdHttpServer{r: mux, d: d}}
 }
 
+func storageBucketsServer(d *Daemon) *http.Server {
+	/* Setup the web server */
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-d.setupChan
+
+		// Path style requests are expected, so the bucket name is the first path segment.
+		bucketName := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+		if bucketName == "" {
+			_ = response.NotFound(nil).Render(w)
+			return
+		}
+
+		var bucket *db.StorageBucket
+		err := d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			var err error
+			bucket, err = tx.GetStoragePoolLocalBucket(bucketName)
+			return err
+		})
+		if err != nil {
+			_ = response.SmartError(err).Render(w)
+			return
+		}
+
+		pool, err := storagePools.LoadByName(d.State(), bucket.PoolName)
+		if err != nil {
+			_ = response.SmartError(err).Render(w)
+			return
+		}
+
+		minioProc, err := pool.ActivateBucket(bucket.Project, bucket.Name, nil)
+		if err != nil {
+			_ = response.SmartError(err).Render(w)
+			return
+		}
+
+		target := minioProc.URL()
+		proxy := &httputil.ReverseProxy{
+			Director: func(req *http.Request) {
+				// Keep the original Host header so that the request signature remains valid.
+				req.URL.Scheme = target.Scheme
+				req.URL.Host = target.Host
+			},
+		}
+
+		proxy.ServeHTTP(w, r)
+	})
+
+	return &http.Server{Handler: handler}
+}
+
 type lxdHttpServer struct {
 	r *mux.Router
 	d *Daemon
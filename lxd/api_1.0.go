@@ -53,6 +53,7 @@ var api10 = []APIEndpoint{
 	instanceCmd,
 	instanceConsoleCmd,
 	instanceExecCmd,
+	instanceNICCaptureCmd,
 	instanceFileCmd,
 	instanceLogCmd,
 	instanceLogsCmd,
@@ -71,15 +72,21 @@ var api10 = []APIEndpoint{
 	imageRefreshCmd,
 	imagesCmd,
 	imageSecretCmd,
+	networkAllocationsCmd,
 	networkCmd,
 	networkLeasesCmd,
 	networksCmd,
 	networkStateCmd,
+	networkBGPCmd,
 	networkACLCmd,
 	networkACLsCmd,
 	networkACLLogCmd,
+	networkACLStateCmd,
 	networkForwardCmd,
 	networkForwardsCmd,
+	networkLoadBalancerCmd,
+	networkLoadBalancersCmd,
+	networkLoadBalancerStateCmd,
 	networkPeerCmd,
 	networkPeersCmd,
 	networkZoneCmd,
@@ -95,6 +102,12 @@ var api10 = []APIEndpoint{
 	projectCmd,
 	projectsCmd,
 	projectStateCmd,
+	storagePoolBucketCmd,
+	storagePoolBucketsCmd,
+	storagePoolBucketKeyCmd,
+	storagePoolBucketKeysCmd,
+	storagePoolCheckCmd,
+	storagePoolDeduplicateCmd,
 	storagePoolCmd,
 	storagePoolResourcesCmd,
 	storagePoolsCmd,
@@ -804,6 +817,14 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 	}
 
+	value, ok = nodeChanged["core.storage_buckets_address"]
+	if ok {
+		err := d.endpoints.StorageBucketsUpdateAddress(value, d.endpoints.NetworkCert())
+		if err != nil {
+			return err
+		}
+	}
+
 	value, ok = nodeChanged["storage.backups_volume"]
 	if ok {
 		err := daemonStorageMove(s, "backups", value)
@@ -190,6 +190,26 @@ func metricsGet(d *Daemon, r *http.Request) response.Response {
 				newMetrics[inst.Project()].Merge(instanceMetrics)
 			}(inst)
 		}
+
+		// Add the custom volume replication metrics.
+		replicationMetrics, err := customVolumeReplicationMetrics(d, project)
+		if err != nil {
+			logger.Warn("Failed to get custom volume replication metrics", logger.Ctx{"project": project, "err": err})
+		} else {
+			newMetricsLock.Lock()
+			newMetrics[project].Merge(replicationMetrics)
+			newMetricsLock.Unlock()
+		}
+
+		// Add the network ACL rule metrics.
+		aclMetrics, err := networkACLMetrics(d, project)
+		if err != nil {
+			logger.Warn("Failed to get network ACL metrics", logger.Ctx{"project": project, "err": err})
+		} else {
+			newMetricsLock.Lock()
+			newMetrics[project].Merge(aclMetrics)
+			newMetricsLock.Unlock()
+		}
 	}
 
 	wgInstances.Wait()
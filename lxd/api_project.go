@@ -34,12 +34,12 @@ import (
 )
 
 // projectFeatures are the features available to projects.
-var projectFeatures = []string{"features.images", "features.profiles", "features.storage.volumes", "features.networks"}
+var projectFeatures = []string{"features.images", "features.profiles", "features.storage.volumes", "features.storage.buckets", "features.networks"}
 
 // projectFeaturesDefaults are the features enabled by default on new projects.
 // The features.networks won't be enabled by default until it becomes clear whether it is practical to run OVN on
 // every system.
-var projectFeaturesDefaults = []string{"features.images", "features.profiles", "features.storage.volumes"}
+var projectFeaturesDefaults = []string{"features.images", "features.profiles", "features.storage.volumes", "features.storage.buckets"}
 
 var projectsCmd = APIEndpoint{
 	Path: "projects",
@@ -243,9 +243,15 @@ func projectUsedBy(ctx context.Context, tx *db.ClusterTx, project *cluster.Proje
 		return nil, err
 	}
 
+	buckets, err := tx.GetStorageBucketURIs(project.Name)
+	if err != nil {
+		return nil, err
+	}
+
 	usedBy = append(usedBy, volumes...)
 	usedBy = append(usedBy, networks...)
 	usedBy = append(usedBy, acls...)
+	usedBy = append(usedBy, buckets...)
 
 	return usedBy, nil
 }
@@ -1034,6 +1040,15 @@ func projectIsEmpty(ctx context.Context, project *cluster.Project, tx *db.Cluste
 		return false, nil
 	}
 
+	buckets, err := tx.GetStorageBucketURIs(project.Name)
+	if err != nil {
+		return false, err
+	}
+
+	if len(buckets) > 0 {
+		return false, nil
+	}
+
 	return true, nil
 }
 
@@ -1052,6 +1067,7 @@ func projectValidateConfig(s *state.State, config map[string]string) error {
 		"features.profiles":                    validate.Optional(validate.IsBool),
 		"features.images":                      validate.Optional(validate.IsBool),
 		"features.storage.volumes":             validate.Optional(validate.IsBool),
+		"features.storage.buckets":             validate.Optional(validate.IsBool),
 		"features.networks":                    validate.Optional(validate.IsBool),
 		"images.auto_update_cached":            validate.Optional(validate.IsBool),
 		"images.auto_update_interval":          validate.Optional(validate.IsInt64),
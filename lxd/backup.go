@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"context"
@@ -54,6 +55,27 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		args.OptimizedStorage = false
 	}
 
+	// Find the snapshot that an incremental backup needs to be taken against.
+	baseSnapshot := ""
+	if args.Parent != "" {
+		snapshots, err := sourceInst.Snapshots()
+		if err != nil {
+			return fmt.Errorf("Failed loading instance snapshots: %w", err)
+		}
+
+		snapNames := make([]string, 0, len(snapshots))
+		for _, snap := range snapshots {
+			_, snapName, _ := shared.InstanceGetParentAndSnapshotName(snap.Name())
+			snapNames = append(snapNames, snapName)
+		}
+
+		parentPath := shared.VarPath("backups", "instances", project.Instance(sourceInst.Project(), args.Parent))
+		baseSnapshot, err = backupParentBaseSnapshot(s, parentPath, args.OptimizedStorage, snapNames)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create the database entry.
 	err = s.DB.Cluster.CreateInstanceBackup(args)
 	if err != nil {
@@ -179,7 +201,7 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 
 	// Write index file.
 	l.Debug("Adding backup index file")
-	err = backupWriteIndex(sourceInst, pool, b.OptimizedStorage(), !b.InstanceOnly(), tarWriter)
+	err = backupWriteIndex(sourceInst, pool, b.OptimizedStorage(), !b.InstanceOnly(), b.ParentShortName(), baseSnapshot, tarWriter)
 
 	// Check compression errors.
 	if compressErr != nil {
@@ -191,7 +213,7 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		return fmt.Errorf("Error writing backup index file: %w", err)
 	}
 
-	err = pool.BackupInstance(sourceInst, tarWriter, b.OptimizedStorage(), !b.InstanceOnly(), nil)
+	err = pool.BackupInstance(sourceInst, tarWriter, b.OptimizedStorage(), !b.InstanceOnly(), baseSnapshot, nil)
 	if err != nil {
 		return fmt.Errorf("Backup create: %w", err)
 	}
@@ -224,8 +246,40 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 	return nil
 }
 
+// backupParentBaseSnapshot loads the index of the parent backup tarball at parentPath and returns the name of the
+// snapshot that an incremental backup against it should be based on. The snapshot must still be in snapshots, the
+// current snapshots of the instance or volume being backed up, otherwise a full backup is needed instead.
+func backupParentBaseSnapshot(s *state.State, parentPath string, optimized bool, snapshots []string) (string, error) {
+	f, err := os.Open(parentPath)
+	if err != nil {
+		return "", fmt.Errorf("Failed opening parent backup: %w", err)
+	}
+
+	defer func() { _ = f.Close() }()
+
+	parentInfo, err := backup.GetInfo(f, s.OS, f.Name())
+	if err != nil {
+		return "", fmt.Errorf("Failed reading parent backup: %w", err)
+	}
+
+	if parentInfo.OptimizedStorage != nil && *parentInfo.OptimizedStorage != optimized {
+		return "", fmt.Errorf("Incremental backups must use the same optimized storage setting as their parent")
+	}
+
+	baseSnapshot := parentInfo.LastSnapshot()
+	if baseSnapshot == "" {
+		return "", fmt.Errorf("Parent backup doesn't include any snapshots to base an incremental backup on")
+	}
+
+	if !shared.StringInSlice(baseSnapshot, snapshots) {
+		return "", fmt.Errorf("Snapshot %q the parent backup ends with no longer exists, a full backup is needed instead", baseSnapshot)
+	}
+
+	return baseSnapshot, nil
+}
+
 // backupWriteIndex generates an index.yaml file and then writes it to the root of the backup tarball.
-func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, optimized bool, snapshots bool, tarWriter *instancewriter.InstanceTarWriter) error {
+func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, optimized bool, snapshots bool, parent string, baseSnapshot string, tarWriter *instancewriter.InstanceTarWriter) error {
 	// Indicate whether the driver will include a driver-specific optimized header.
 	poolDriverOptimizedHeader := false
 	if optimized {
@@ -260,6 +314,8 @@ func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, opti
 		OptimizedStorage: &optimized,
 		OptimizedHeader:  &poolDriverOptimizedHeader,
 		Config:           config,
+		Parent:           parent,
+		BaseSnapshot:     baseSnapshot,
 	}
 
 	if snapshots {
@@ -267,6 +323,12 @@ func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, opti
 		for _, s := range config.Snapshots {
 			indexInfo.Snapshots = append(indexInfo.Snapshots, s.Name)
 		}
+
+		// Incremental backups only contain the snapshots taken after the base snapshot.
+		indexInfo.Snapshots, err = backup.SnapshotsAfter(indexInfo.Snapshots, baseSnapshot)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Convert to YAML.
@@ -344,7 +406,7 @@ func pruneExpiredContainerBackups(ctx context.Context, d *Daemon) error {
 			return fmt.Errorf("Error loading instance for deleting backup %q: %w", b.Name, err)
 		}
 
-		instBackup := backup.NewInstanceBackup(d.State(), inst, b.ID, b.Name, b.CreationDate, b.ExpiryDate, b.InstanceOnly, b.OptimizedStorage)
+		instBackup := backup.NewInstanceBackup(d.State(), inst, b.ID, b.Name, b.CreationDate, b.ExpiryDate, b.InstanceOnly, b.OptimizedStorage, b.Parent)
 		err = instBackup.Delete()
 		if err != nil {
 			return fmt.Errorf("Error deleting instance backup %q: %w", b.Name, err)
@@ -373,6 +435,27 @@ func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, project
 		args.OptimizedStorage = false
 	}
 
+	// Find the snapshot that an incremental backup needs to be taken against.
+	baseSnapshot := ""
+	if args.Parent != "" {
+		snapshots, err := s.DB.Cluster.GetLocalStoragePoolVolumeSnapshotsWithType(projectName, volumeName, db.StoragePoolVolumeTypeCustom, pool.ID())
+		if err != nil {
+			return fmt.Errorf("Failed loading volume snapshots: %w", err)
+		}
+
+		snapNames := make([]string, 0, len(snapshots))
+		for _, snap := range snapshots {
+			_, snapName, _ := shared.InstanceGetParentAndSnapshotName(snap.Name)
+			snapNames = append(snapNames, snapName)
+		}
+
+		parentPath := shared.VarPath("backups", "custom", pool.Name(), project.StorageVolume(projectName, args.Parent))
+		baseSnapshot, err = backupParentBaseSnapshot(s, parentPath, args.OptimizedStorage, snapNames)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create the database entry.
 	err = s.DB.Cluster.CreateStoragePoolVolumeBackup(args)
 	if err != nil {
@@ -453,7 +536,12 @@ func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, project
 
 	// Write index file.
 	l.Debug("Adding backup index file")
-	err = volumeBackupWriteIndex(s, projectName, volumeName, pool, backupRow.OptimizedStorage, !backupRow.VolumeOnly, tarWriter)
+	parentName := ""
+	if backupRow.Parent != "" {
+		_, parentName, _ = strings.Cut(backupRow.Parent, "/")
+	}
+
+	err = volumeBackupWriteIndex(s, projectName, volumeName, pool, backupRow.OptimizedStorage, !backupRow.VolumeOnly, parentName, baseSnapshot, tarWriter)
 
 	// Check compression errors.
 	if compressErr != nil {
@@ -465,7 +553,7 @@ func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, project
 		return fmt.Errorf("Error writing backup index file: %w", err)
 	}
 
-	err = pool.BackupCustomVolume(projectName, volumeName, tarWriter, backupRow.OptimizedStorage, !backupRow.VolumeOnly, nil)
+	err = pool.BackupCustomVolume(projectName, volumeName, tarWriter, backupRow.OptimizedStorage, !backupRow.VolumeOnly, baseSnapshot, nil)
 	if err != nil {
 		return fmt.Errorf("Backup create: %w", err)
 	}
@@ -497,7 +585,7 @@ func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, project
 }
 
 // volumeBackupWriteIndex generates an index.yaml file and then writes it to the root of the backup tarball.
-func volumeBackupWriteIndex(s *state.State, projectName string, volumeName string, pool storagePools.Pool, optimized bool, snapshots bool, tarWriter *instancewriter.InstanceTarWriter) error {
+func volumeBackupWriteIndex(s *state.State, projectName string, volumeName string, pool storagePools.Pool, optimized bool, snapshots bool, parent string, baseSnapshot string, tarWriter *instancewriter.InstanceTarWriter) error {
 	// Indicate whether the driver will include a driver-specific optimized header.
 	poolDriverOptimizedHeader := false
 	if optimized {
@@ -517,6 +605,8 @@ func volumeBackupWriteIndex(s *state.State, projectName string, volumeName strin
 		OptimizedHeader:  &poolDriverOptimizedHeader,
 		Type:             backup.TypeCustom,
 		Config:           config,
+		Parent:           parent,
+		BaseSnapshot:     baseSnapshot,
 	}
 
 	if snapshots {
@@ -524,6 +614,12 @@ func volumeBackupWriteIndex(s *state.State, projectName string, volumeName strin
 		for _, s := range config.VolumeSnapshots {
 			indexInfo.Snapshots = append(indexInfo.Snapshots, s.Name)
 		}
+
+		// Incremental backups only contain the snapshots taken after the base snapshot.
+		indexInfo.Snapshots, err = backup.SnapshotsAfter(indexInfo.Snapshots, baseSnapshot)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Convert to YAML.
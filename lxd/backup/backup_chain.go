@@ -0,0 +1,260 @@
+package backup
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/sys"
+)
+
+// ChainPrefix is the path prefix used for the backup files stored inside an incremental backup chain tarball.
+const ChainPrefix = "chain/"
+
+// ChainEntry represents a single backup file of an incremental backup chain.
+type ChainEntry struct {
+	Info *Info
+	Data io.ReadSeeker
+}
+
+// LastSnapshot returns the name of the most recent snapshot contained in the backup chain up to and including
+// this backup. This is the snapshot an incremental backup using this backup as its parent is taken against.
+func (i *Info) LastSnapshot() string {
+	if len(i.Snapshots) > 0 {
+		return i.Snapshots[len(i.Snapshots)-1]
+	}
+
+	return i.BaseSnapshot
+}
+
+// optimized returns whether the backup uses the optimized storage format.
+func (i *Info) optimized() bool {
+	return i.OptimizedStorage != nil && *i.OptimizedStorage
+}
+
+// ChainEntries returns the list of backups that need to be applied (oldest first) to restore the backup.
+// For backups that are not part of an incremental chain a single entry using srcData is returned.
+func (i *Info) ChainEntries(srcData io.ReadSeeker) []ChainEntry {
+	if len(i.Chain) > 0 {
+		return i.Chain
+	}
+
+	return []ChainEntry{{Info: i, Data: srcData}}
+}
+
+// SplitChain extracts the backup files from an incremental backup chain tarball into temporary files inside
+// outputPath. The chain tarball is an uncompressed tarball containing the full backup followed by each of its
+// increments, with their names sorted in the order they need to be applied.
+// If r isn't a backup chain then nil is returned and r is rewound.
+func SplitChain(r io.ReadSeeker, outputPath string) ([]*os.File, error) {
+	_, err := r.Seek(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _, _ = r.Seek(0, 0) }()
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	type chainFile struct {
+		name string
+		file *os.File
+	}
+
+	var files []chainFile
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break // End of archive.
+		}
+
+		if err != nil {
+			if len(files) == 0 {
+				return nil, nil // Not an uncompressed tarball, so not a backup chain.
+			}
+
+			return nil, fmt.Errorf("Error reading backup chain: %w", err)
+		}
+
+		if !strings.HasPrefix(hdr.Name, ChainPrefix) {
+			if len(files) == 0 {
+				return nil, nil // Regular backup tarball.
+			}
+
+			return nil, fmt.Errorf("Unexpected file %q in backup chain", hdr.Name)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		f, err := ioutil.TempFile(outputPath, fmt.Sprintf("%s_chain_", WorkingDirPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("Failed creating temporary file for backup chain: %w", err)
+		}
+
+		revert.Add(func() {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		})
+
+		_, err = io.Copy(f, tr)
+		if err != nil {
+			return nil, fmt.Errorf("Failed extracting %q from backup chain: %w", hdr.Name, err)
+		}
+
+		files = append(files, chainFile{name: hdr.Name, file: f})
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].name < files[j].name })
+
+	result := make([]*os.File, 0, len(files))
+	for _, f := range files {
+		result = append(result, f.file)
+	}
+
+	revert.Success()
+	return result, nil
+}
+
+// NewChainInfo validates that the supplied backups (oldest first) form a valid incremental backup chain and
+// returns the combined backup information. The combined information is based on the last backup in the chain,
+// with its Snapshots field containing all of the snapshots that will exist once the whole chain is restored.
+func NewChainInfo(entries []ChainEntry) (*Info, error) {
+	if len(entries) < 1 {
+		return nil, fmt.Errorf("Backup chain is empty")
+	}
+
+	first := entries[0].Info
+	if first.BaseSnapshot != "" {
+		return nil, fmt.Errorf("First backup in chain must be a full backup")
+	}
+
+	available := make(map[string]struct{})
+	for i, entry := range entries {
+		info := entry.Info
+
+		if i > 0 {
+			prev := entries[i-1].Info
+
+			if info.BaseSnapshot == "" {
+				return nil, fmt.Errorf("Backup %d in chain is not an incremental backup", i)
+			}
+
+			if info.BaseSnapshot != prev.LastSnapshot() {
+				return nil, fmt.Errorf("Backup %d in chain is based on snapshot %q but previous backup ends with %q", i, info.BaseSnapshot, prev.LastSnapshot())
+			}
+
+			if info.Name != first.Name || info.Type != first.Type {
+				return nil, fmt.Errorf("Backup %d in chain is for a different instance or volume", i)
+			}
+
+			if info.optimized() != first.optimized() {
+				return nil, fmt.Errorf("Backups in chain must all be either optimized or non-optimized")
+			}
+
+			if info.optimized() && info.Backend != first.Backend {
+				return nil, fmt.Errorf("Optimized backups in chain must all use the same storage driver")
+			}
+		}
+
+		for _, snapName := range info.Snapshots {
+			available[snapName] = struct{}{}
+		}
+	}
+
+	last := *entries[len(entries)-1].Info
+	last.Chain = entries
+	last.Snapshots = nil
+
+	if last.Config != nil {
+		var snapNames []string
+		if last.Type == TypeCustom {
+			for _, snap := range last.Config.VolumeSnapshots {
+				snapNames = append(snapNames, snap.Name)
+			}
+		} else {
+			for _, snap := range last.Config.Snapshots {
+				snapNames = append(snapNames, snap.Name)
+			}
+		}
+
+		for _, snapName := range snapNames {
+			_, found := available[snapName]
+			if found {
+				last.Snapshots = append(last.Snapshots, snapName)
+			}
+		}
+	}
+
+	return &last, nil
+}
+
+// GetChainInfo splits r if it is an incremental backup chain and returns the combined backup information along
+// with a cleanup function that removes the temporary files. If r isn't a backup chain, nil info is returned.
+func GetChainInfo(r io.ReadSeeker, sysOS *sys.OS, outputPath string) (*Info, revert.Hook, error) {
+	files, err := SplitChain(r, outputPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if files == nil {
+		return nil, func() {}, nil
+	}
+
+	cleanup := func() {
+		for _, f := range files {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+	revert.Add(cleanup)
+
+	entries := make([]ChainEntry, 0, len(files))
+	for _, f := range files {
+		info, err := GetInfo(f, sysOS, f.Name())
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed reading backup chain entry: %w", err)
+		}
+
+		entries = append(entries, ChainEntry{Info: info, Data: f})
+	}
+
+	info, err := NewChainInfo(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revert.Success()
+	return info, cleanup, nil
+}
+
+// SnapshotsAfter returns the snapshots that follow baseSnapshot in the supplied list of snapshot names.
+// If baseSnapshot is empty, all of the snapshots are returned. An error is returned if baseSnapshot is not found.
+func SnapshotsAfter(snapshots []string, baseSnapshot string) ([]string, error) {
+	if baseSnapshot == "" {
+		return snapshots, nil
+	}
+
+	for i, snapName := range snapshots {
+		if snapName == baseSnapshot {
+			return snapshots[i+1:], nil
+		}
+	}
+
+	return nil, fmt.Errorf("Base snapshot %q not found", baseSnapshot)
+}
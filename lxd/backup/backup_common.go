@@ -1,6 +1,7 @@
 package backup
 
 import (
+	"strings"
 	"time"
 
 	"github.com/lxc/lxd/lxd/state"
@@ -18,6 +19,7 @@ type CommonBackup struct {
 	expiryDate           time.Time
 	optimizedStorage     bool
 	compressionAlgorithm string
+	parent               string
 }
 
 // Name returns the name of the backup.
@@ -35,6 +37,22 @@ func (b *CommonBackup) SetCompressionAlgorithm(compression string) {
 	b.compressionAlgorithm = compression
 }
 
+// Parent returns the name of the parent backup for incremental backups (empty for full backups).
+func (b *CommonBackup) Parent() string {
+	return b.parent
+}
+
+// ParentShortName returns the parent backup name without the instance or volume prefix.
+func (b *CommonBackup) ParentShortName() string {
+	if b.parent == "" {
+		return ""
+	}
+
+	_, name, _ := strings.Cut(b.parent, "/")
+
+	return name
+}
+
 // OptimizedStorage returns whether the backup is to be performed using
 // optimization supported by the storage driver.
 func (b *CommonBackup) OptimizedStorage() bool {
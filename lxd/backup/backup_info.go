@@ -49,6 +49,9 @@ type Info struct {
 	OptimizedHeader  *bool          `json:"optimized_header,omitempty" yaml:"optimized_header,omitempty"` // Optional field to handle older optimized backups that don't have this field.
 	Type             Type           `json:"type,omitempty" yaml:"type,omitempty"`                         // Type of backup.
 	Config           *config.Config `json:"config,omitempty" yaml:"config,omitempty"`                     // Equivalent of backup.yaml but embedded in index for quick retrieval.
+	Parent           string         `json:"parent,omitempty" yaml:"parent,omitempty"`                     // Name of the parent backup for incremental backups.
+	BaseSnapshot     string         `json:"base_snapshot,omitempty" yaml:"base_snapshot,omitempty"`       // Snapshot the incremental backup was taken against.
+	Chain            []ChainEntry   `json:"-" yaml:"-"`                                                   // Chain is set during import of incremental backups (oldest first).
 }
 
 // GetInfo extracts backup information from a given ReadSeeker.
@@ -30,7 +30,7 @@ type InstanceBackup struct {
 }
 
 // NewInstanceBackup instantiates a new InstanceBackup struct.
-func NewInstanceBackup(state *state.State, inst Instance, ID int, name string, creationDate time.Time, expiryDate time.Time, instanceOnly bool, optimizedStorage bool) *InstanceBackup {
+func NewInstanceBackup(state *state.State, inst Instance, ID int, name string, creationDate time.Time, expiryDate time.Time, instanceOnly bool, optimizedStorage bool, parent string) *InstanceBackup {
 	return &InstanceBackup{
 		CommonBackup: CommonBackup{
 			state:            state,
@@ -39,6 +39,7 @@ func NewInstanceBackup(state *state.State, inst Instance, ID int, name string, c
 			creationDate:     creationDate,
 			expiryDate:       expiryDate,
 			optimizedStorage: optimizedStorage,
+			parent:           parent,
 		},
 		instance:     inst,
 		instanceOnly: instanceOnly,
@@ -145,5 +146,6 @@ func (b *InstanceBackup) Render() *api.InstanceBackup {
 		InstanceOnly:     b.instanceOnly,
 		ContainerOnly:    b.instanceOnly,
 		OptimizedStorage: b.optimizedStorage,
+		Parent:           b.ParentShortName(),
 	}
 }
@@ -23,7 +23,7 @@ type VolumeBackup struct {
 }
 
 // NewVolumeBackup instantiates a new VolumeBackup struct.
-func NewVolumeBackup(state *state.State, projectName, poolName, volumeName string, ID int, name string, creationDate, expiryDate time.Time, volumeOnly, optimizedStorage bool) *VolumeBackup {
+func NewVolumeBackup(state *state.State, projectName, poolName, volumeName string, ID int, name string, creationDate, expiryDate time.Time, volumeOnly, optimizedStorage bool, parent string) *VolumeBackup {
 	return &VolumeBackup{
 		CommonBackup: CommonBackup{
 			state:            state,
@@ -32,6 +32,7 @@ func NewVolumeBackup(state *state.State, projectName, poolName, volumeName strin
 			creationDate:     creationDate,
 			expiryDate:       expiryDate,
 			optimizedStorage: optimizedStorage,
+			parent:           parent,
 		},
 		projectName: projectName,
 		poolName:    poolName,
@@ -138,5 +139,6 @@ func (b *VolumeBackup) Render() *api.StoragePoolVolumeBackup {
 		ExpiresAt:        b.expiryDate,
 		VolumeOnly:       b.volumeOnly,
 		OptimizedStorage: b.optimizedStorage,
+		Parent:           b.ParentShortName(),
 	}
 }
@@ -0,0 +1,505 @@
+package bgp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	bgpAPI "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/proto"
+)
+
+// PeerPolicy represents the import and export policy applied to a BGP peer.
+type PeerPolicy struct {
+	// Import controls whether routes learned from the peer should be installed.
+	Import bool
+
+	// ImportPrefixes restricts the learned routes to those within the listed subnets (all if empty).
+	ImportPrefixes []net.IPNet
+
+	// ImportCommunities restricts the learned routes to those carrying one of the listed communities (all if empty).
+	ImportCommunities []string
+
+	// ExportPrefixes restricts the advertised routes to those within the listed subnets (all if empty).
+	ExportPrefixes []net.IPNet
+
+	// ExportCommunities lists the communities added to the advertised routes.
+	ExportCommunities []string
+}
+
+// Equal checks whether two policies are identical.
+func (p PeerPolicy) Equal(other PeerPolicy) bool {
+	if p.Import != other.Import {
+		return false
+	}
+
+	subnetsEqual := func(a []net.IPNet, b []net.IPNet) bool {
+		if len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if a[i].String() != b[i].String() {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	stringsEqual := func(a []string, b []string) bool {
+		return strings.Join(a, ",") == strings.Join(b, ",")
+	}
+
+	return subnetsEqual(p.ImportPrefixes, other.ImportPrefixes) &&
+		subnetsEqual(p.ExportPrefixes, other.ExportPrefixes) &&
+		stringsEqual(p.ImportCommunities, other.ImportCommunities) &&
+		stringsEqual(p.ExportCommunities, other.ExportCommunities)
+}
+
+// accepts checks whether a learned route passes the import filters of the policy.
+func (p PeerPolicy) accepts(route Route) bool {
+	if !p.Import {
+		return false
+	}
+
+	if len(p.ImportPrefixes) > 0 && !prefixWithin(route.Prefix, p.ImportPrefixes) {
+		return false
+	}
+
+	if len(p.ImportCommunities) > 0 {
+		found := false
+		for _, community := range route.Communities {
+			for _, wanted := range p.ImportCommunities {
+				if community == wanted {
+					found = true
+					break
+				}
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseCommunity parses a standard BGP community in the ASN:VALUE format.
+func ParseCommunity(value string) (uint32, error) {
+	fields := strings.Split(value, ":")
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("Invalid BGP community %q, must be in the ASN:VALUE format", value)
+	}
+
+	asn, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid ASN in BGP community %q: %w", value, err)
+	}
+
+	id, err := strconv.ParseUint(fields[1], 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid value in BGP community %q: %w", value, err)
+	}
+
+	return uint32(asn<<16 | id), nil
+}
+
+// formatCommunity renders a standard BGP community in the ASN:VALUE format.
+func formatCommunity(community uint32) string {
+	return fmt.Sprintf("%d:%d", community>>16, community&0xffff)
+}
+
+// hasExport checks whether the policy restricts or alters the advertised routes.
+func (p PeerPolicy) hasExport() bool {
+	return len(p.ExportPrefixes) > 0 || len(p.ExportCommunities) > 0
+}
+
+// exports checks whether an advertised prefix passes the export filters of the policy.
+func (p PeerPolicy) exports(prefix net.IPNet) bool {
+	return len(p.ExportPrefixes) == 0 || prefixWithin(prefix, p.ExportPrefixes)
+}
+
+// prefixWithin checks whether the prefix is within one of the subnets (of the same address family).
+func prefixWithin(prefix net.IPNet, subnets []net.IPNet) bool {
+	prefixSize, _ := prefix.Mask.Size()
+	for _, subnet := range subnets {
+		subnetSize, _ := subnet.Mask.Size()
+		if subnet.Contains(prefix.IP) && prefixSize >= subnetSize && (subnet.IP.To4() == nil) == (prefix.IP.To4() == nil) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refreshPolicies re-applies the export policies after the advertised prefixes changed, if any peer policy
+// depends on them.
+func (s *Server) refreshPolicies() error {
+	for _, peer := range s.peers {
+		for _, networkPolicy := range peer.policies {
+			if networkPolicy.policy.hasExport() {
+				return s.applyPolicies()
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyPolicies replaces the global export policy with one reflecting the current peer policies.
+// The policy a network applies to a peer only affects the prefixes of that network, which are matched exactly.
+// Prefixes of other networks are advertised to the peer as is.
+func (s *Server) applyPolicies() error {
+	if s.bgp == nil || s.address == "" {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Detach and remove the current policies.
+	err := s.bgp.SetPolicyAssignment(ctx, &bgpAPI.SetPolicyAssignmentRequest{
+		Assignment: &bgpAPI.PolicyAssignment{
+			Name:          "global",
+			Direction:     bgpAPI.PolicyDirection_EXPORT,
+			Policies:      []*bgpAPI.Policy{},
+			DefaultAction: bgpAPI.RouteAction_ACCEPT,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("Failed resetting BGP export policy: %w", err)
+	}
+
+	for _, policy := range s.policies {
+		err := s.bgp.DeletePolicy(ctx, &bgpAPI.DeletePolicyRequest{Policy: policy, All: true})
+		if err != nil {
+			return fmt.Errorf("Failed removing BGP policy %q: %w", policy.Name, err)
+		}
+	}
+
+	s.policies = nil
+
+	for _, set := range s.definedSets {
+		err := s.bgp.DeleteDefinedSet(ctx, &bgpAPI.DeleteDefinedSetRequest{DefinedSet: set, All: true})
+		if err != nil {
+			return fmt.Errorf("Failed removing BGP defined set %q: %w", set.Name, err)
+		}
+	}
+
+	s.definedSets = nil
+
+	// Group the advertised prefixes by network.
+	networkPrefixes := map[string][]net.IPNet{}
+	for _, path := range s.paths {
+		networkPrefixes[path.network] = append(networkPrefixes[path.network], path.prefix)
+	}
+
+	// Build a policy for every peer which restricts or alters the routes advertised by a network.
+	for _, peer := range s.peers {
+		name := fmt.Sprintf("lxd_peer_%s", peer.address.String())
+
+		// Neighbor sets only hold prefixes.
+		neighbor := fmt.Sprintf("%s/32", peer.address.String())
+		if peer.address.To4() == nil {
+			neighbor = fmt.Sprintf("%s/128", peer.address.String())
+		}
+
+		neighborSet := &bgpAPI.DefinedSet{
+			DefinedType: bgpAPI.DefinedType_NEIGHBOR,
+			Name:        name,
+			List:        []string{neighbor},
+		}
+
+		sets := []*bgpAPI.DefinedSet{neighborSet}
+		statements := []*bgpAPI.Statement{}
+
+		networks := make([]string, 0, len(peer.policies))
+		for network := range peer.policies {
+			networks = append(networks, network)
+		}
+
+		sort.Strings(networks)
+
+		for _, network := range networks {
+			policy := peer.policies[network].policy
+			if !policy.hasExport() {
+				continue
+			}
+
+			var communities *bgpAPI.CommunityAction
+			if len(policy.ExportCommunities) > 0 {
+				communities = &bgpAPI.CommunityAction{
+					Type:        bgpAPI.CommunityAction_ADD,
+					Communities: policy.ExportCommunities,
+				}
+			}
+
+			// Split the prefixes of the network into the advertised and the filtered ones.
+			// Prefix sets can only hold a single address family.
+			prefixes := map[bgpAPI.RouteAction]map[string][]*bgpAPI.Prefix{
+				bgpAPI.RouteAction_ACCEPT: {},
+				bgpAPI.RouteAction_REJECT: {},
+			}
+
+			seen := map[string]bool{}
+			for _, subnet := range networkPrefixes[network] {
+				if seen[subnet.String()] {
+					continue
+				}
+
+				seen[subnet.String()] = true
+
+				action := bgpAPI.RouteAction_REJECT
+				if policy.exports(subnet) {
+					action = bgpAPI.RouteAction_ACCEPT
+				}
+
+				family := "ipv4"
+				if subnet.IP.To4() == nil {
+					family = "ipv6"
+				}
+
+				ones, _ := subnet.Mask.Size()
+				prefixes[action][family] = append(prefixes[action][family], &bgpAPI.Prefix{
+					IpPrefix:      subnet.String(),
+					MaskLengthMin: uint32(ones),
+					MaskLengthMax: uint32(ones),
+				})
+			}
+
+			for _, action := range []bgpAPI.RouteAction{bgpAPI.RouteAction_ACCEPT, bgpAPI.RouteAction_REJECT} {
+				for _, family := range []string{"ipv4", "ipv6"} {
+					if len(prefixes[action][family]) == 0 {
+						continue
+					}
+
+					actionName := "accept"
+					actions := &bgpAPI.Actions{RouteAction: action, Community: communities}
+					if action == bgpAPI.RouteAction_REJECT {
+						actionName = "reject"
+						actions = &bgpAPI.Actions{RouteAction: action}
+					}
+
+					prefixSet := &bgpAPI.DefinedSet{
+						DefinedType: bgpAPI.DefinedType_PREFIX,
+						Name:        fmt.Sprintf("%s_%s_%s_%s", name, network, actionName, family),
+						Prefixes:    prefixes[action][family],
+					}
+
+					sets = append(sets, prefixSet)
+					statements = append(statements, &bgpAPI.Statement{
+						Name: prefixSet.Name,
+						Conditions: &bgpAPI.Conditions{
+							NeighborSet: &bgpAPI.MatchSet{Type: bgpAPI.MatchSet_ANY, Name: neighborSet.Name},
+							PrefixSet:   &bgpAPI.MatchSet{Type: bgpAPI.MatchSet_ANY, Name: prefixSet.Name},
+						},
+						Actions: actions,
+					})
+				}
+			}
+		}
+
+		// Skip peers whose policies don't apply to any advertised prefix.
+		if len(statements) == 0 {
+			continue
+		}
+
+		for _, set := range sets {
+			err := s.bgp.AddDefinedSet(ctx, &bgpAPI.AddDefinedSetRequest{DefinedSet: set})
+			if err != nil {
+				return fmt.Errorf("Failed adding BGP defined set %q: %w", set.Name, err)
+			}
+
+			s.definedSets = append(s.definedSets, set)
+		}
+
+		policy := &bgpAPI.Policy{Name: name, Statements: statements}
+		err := s.bgp.AddPolicy(ctx, &bgpAPI.AddPolicyRequest{Policy: policy})
+		if err != nil {
+			return fmt.Errorf("Failed adding BGP policy %q: %w", name, err)
+		}
+
+		s.policies = append(s.policies, &bgpAPI.Policy{Name: name})
+	}
+
+	// Attach the new policies.
+	if len(s.policies) > 0 {
+		err = s.bgp.SetPolicyAssignment(ctx, &bgpAPI.SetPolicyAssignmentRequest{
+			Assignment: &bgpAPI.PolicyAssignment{
+				Name:          "global",
+				Direction:     bgpAPI.PolicyDirection_EXPORT,
+				Policies:      s.policies,
+				DefaultAction: bgpAPI.RouteAction_ACCEPT,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("Failed applying BGP export policy: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Route represents a route learned from a BGP peer.
+type Route struct {
+	Prefix      net.IPNet
+	NextHop     net.IP
+	Peer        net.IP
+	Communities []string
+
+	// Accepted indicates whether the route passed the import policy of the peer.
+	Accepted bool
+}
+
+// PeerState represents the session state of a BGP peer.
+type PeerState struct {
+	State    string
+	Uptime   time.Duration
+	Received uint64
+}
+
+// ImportedRoutes returns the routes learned from the given peer, checked against the import policy the network
+// applies to the peer.
+func (s *Server) ImportedRoutes(network string, address net.IP) ([]Route, error) {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	routes := []Route{}
+
+	bgpPeer, bgpPeerExists := s.peers[address.String()]
+	if s.bgp == nil || s.address == "" || !bgpPeerExists || bgpPeer.policies[network] == nil {
+		return routes, nil
+	}
+
+	policy := bgpPeer.policies[network].policy
+
+	for _, afi := range []bgpAPI.Family_Afi{bgpAPI.Family_AFI_IP, bgpAPI.Family_AFI_IP6} {
+		var parseErr error
+
+		err := s.bgp.ListPath(context.Background(), &bgpAPI.ListPathRequest{
+			TableType: bgpAPI.TableType_ADJ_IN,
+			Name:      address.String(),
+			Family:    &bgpAPI.Family{Afi: afi, Safi: bgpAPI.Family_SAFI_UNICAST},
+		}, func(d *bgpAPI.Destination) {
+			for _, p := range d.Paths {
+				if p.IsWithdraw {
+					continue
+				}
+
+				route, err := pathToRoute(p)
+				if err != nil {
+					parseErr = err
+					continue
+				}
+
+				route.Peer = address
+				route.Accepted = policy.accepts(*route)
+				routes = append(routes, *route)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if parseErr != nil {
+			return nil, parseErr
+		}
+	}
+
+	return routes, nil
+}
+
+// PeerState returns the session state of the given peer.
+func (s *Server) PeerState(address net.IP) (*PeerState, error) {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := &PeerState{State: "unknown"}
+
+	_, bgpPeerExists := s.peers[address.String()]
+	if !bgpPeerExists {
+		return nil, ErrPeerNotFound
+	}
+
+	if s.bgp == nil || s.address == "" {
+		state.State = "stopped"
+		return state, nil
+	}
+
+	err := s.bgp.ListPeer(context.Background(), &bgpAPI.ListPeerRequest{Address: address.String()}, func(p *bgpAPI.Peer) {
+		if p.State != nil {
+			state.State = strings.ToLower(p.State.SessionState.String())
+		}
+
+		if p.Timers != nil && p.Timers.State != nil && p.Timers.State.Uptime != nil && p.State != nil && p.State.SessionState == bgpAPI.PeerState_ESTABLISHED {
+			state.Uptime = time.Since(p.Timers.State.Uptime.AsTime())
+		}
+
+		for _, afiSafi := range p.AfiSafis {
+			if afiSafi.State != nil {
+				state.Received += afiSafi.State.Received
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// pathToRoute converts a BGP path into a route.
+func pathToRoute(p *bgpAPI.Path) (*Route, error) {
+	nlri, err := p.Nlri.UnmarshalNew()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, ok := nlri.(*bgpAPI.IPAddressPrefix)
+	if !ok {
+		return nil, fmt.Errorf("Unsupported BGP NLRI type %q", p.Nlri.TypeUrl)
+	}
+
+	_, subnet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", prefix.Prefix, prefix.PrefixLen))
+	if err != nil {
+		return nil, err
+	}
+
+	route := &Route{Prefix: *subnet, Communities: []string{}}
+
+	for _, a := range p.Pattrs {
+		var attr proto.Message
+		attr, err = a.UnmarshalNew()
+		if err != nil {
+			return nil, err
+		}
+
+		switch attr := attr.(type) {
+		case *bgpAPI.NextHopAttribute:
+			route.NextHop = net.ParseIP(attr.NextHop)
+
+		case *bgpAPI.MpReachNLRIAttribute:
+			if len(attr.NextHops) > 0 {
+				route.NextHop = net.ParseIP(attr.NextHops[0])
+			}
+
+		case *bgpAPI.CommunitiesAttribute:
+			for _, community := range attr.Communities {
+				route.Communities = append(route.Communities, formatCommunity(community))
+			}
+		}
+	}
+
+	if route.NextHop == nil {
+		return nil, fmt.Errorf("Missing next hop for BGP route %q", route.Prefix.String())
+	}
+
+	return route, nil
+}
@@ -0,0 +1,358 @@
+package bgp
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	bgpAPI "github.com/osrg/gobgp/v3/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseSubnet(t *testing.T, value string) net.IPNet {
+	_, subnet, err := net.ParseCIDR(value)
+	require.NoError(t, err)
+
+	return *subnet
+}
+
+func TestPeerPolicyAccepts(t *testing.T) {
+	route := func(prefix string, communities ...string) Route {
+		return Route{Prefix: mustParseSubnet(t, prefix), Communities: communities}
+	}
+
+	tests := []struct {
+		name   string
+		policy PeerPolicy
+		route  Route
+		accept bool
+	}{
+		{
+			name:   "import disabled",
+			policy: PeerPolicy{},
+			route:  route("10.0.0.0/24"),
+			accept: false,
+		},
+		{
+			name:   "no filters",
+			policy: PeerPolicy{Import: true},
+			route:  route("10.0.0.0/24"),
+			accept: true,
+		},
+		{
+			name:   "within prefix",
+			policy: PeerPolicy{Import: true, ImportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/16")}},
+			route:  route("10.0.1.0/24"),
+			accept: true,
+		},
+		{
+			name:   "same prefix",
+			policy: PeerPolicy{Import: true, ImportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/16")}},
+			route:  route("10.0.0.0/16"),
+			accept: true,
+		},
+		{
+			name:   "wider than prefix",
+			policy: PeerPolicy{Import: true, ImportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/16")}},
+			route:  route("10.0.0.0/8"),
+			accept: false,
+		},
+		{
+			name:   "outside prefix",
+			policy: PeerPolicy{Import: true, ImportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/16")}},
+			route:  route("192.168.0.0/24"),
+			accept: false,
+		},
+		{
+			name:   "second prefix",
+			policy: PeerPolicy{Import: true, ImportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/16"), mustParseSubnet(t, "192.168.0.0/16")}},
+			route:  route("192.168.1.0/24"),
+			accept: true,
+		},
+		{
+			name:   "IPv6 within prefix",
+			policy: PeerPolicy{Import: true, ImportPrefixes: []net.IPNet{mustParseSubnet(t, "2001:db8::/32")}},
+			route:  route("2001:db8:1::/48"),
+			accept: true,
+		},
+		{
+			name:   "IPv4 mapped route against IPv6 prefix",
+			policy: PeerPolicy{Import: true, ImportPrefixes: []net.IPNet{mustParseSubnet(t, "::/0")}},
+			route:  route("10.0.0.0/24"),
+			accept: false,
+		},
+		{
+			name:   "IPv6 route against IPv4 prefix",
+			policy: PeerPolicy{Import: true, ImportPrefixes: []net.IPNet{mustParseSubnet(t, "0.0.0.0/0")}},
+			route:  route("2001:db8::/32"),
+			accept: false,
+		},
+		{
+			name:   "matching community",
+			policy: PeerPolicy{Import: true, ImportCommunities: []string{"65000:100", "65000:200"}},
+			route:  route("10.0.0.0/24", "65000:1", "65000:200"),
+			accept: true,
+		},
+		{
+			name:   "no matching community",
+			policy: PeerPolicy{Import: true, ImportCommunities: []string{"65000:100"}},
+			route:  route("10.0.0.0/24", "65000:1"),
+			accept: false,
+		},
+		{
+			name:   "no community",
+			policy: PeerPolicy{Import: true, ImportCommunities: []string{"65000:100"}},
+			route:  route("10.0.0.0/24"),
+			accept: false,
+		},
+		{
+			name:   "matching prefix without community",
+			policy: PeerPolicy{Import: true, ImportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/8")}, ImportCommunities: []string{"65000:100"}},
+			route:  route("10.0.0.0/24", "65000:1"),
+			accept: false,
+		},
+		{
+			name:   "matching prefix and community",
+			policy: PeerPolicy{Import: true, ImportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/8")}, ImportCommunities: []string{"65000:100"}},
+			route:  route("10.0.0.0/24", "65000:100"),
+			accept: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.accept, test.policy.accepts(test.route))
+		})
+	}
+}
+
+func TestParseCommunity(t *testing.T) {
+	tests := []struct {
+		value     string
+		community uint32
+		err       bool
+	}{
+		{value: "0:0", community: 0},
+		{value: "65000:100", community: 65000<<16 | 100},
+		{value: "65535:65535", community: 0xffffffff},
+		{value: "", err: true},
+		{value: "65000", err: true},
+		{value: "65000:100:1", err: true},
+		{value: "foo:100", err: true},
+		{value: "65000:bar", err: true},
+		{value: "-1:100", err: true},
+		{value: "65536:100", err: true},
+		{value: "65000:65536", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			community, err := ParseCommunity(test.value)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.community, community)
+			assert.Equal(t, test.value, formatCommunity(community))
+		})
+	}
+}
+
+func TestPeerPolicyExports(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy PeerPolicy
+		prefix string
+		export bool
+	}{
+		{name: "no filters", policy: PeerPolicy{}, prefix: "10.0.0.0/24", export: true},
+		{name: "communities only", policy: PeerPolicy{ExportCommunities: []string{"65000:100"}}, prefix: "10.0.0.0/24", export: true},
+		{name: "within prefix", policy: PeerPolicy{ExportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/16")}}, prefix: "10.0.1.0/24", export: true},
+		{name: "wider than prefix", policy: PeerPolicy{ExportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/16")}}, prefix: "10.0.0.0/8", export: false},
+		{name: "outside prefix", policy: PeerPolicy{ExportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/16")}}, prefix: "192.168.0.0/24", export: false},
+		{name: "IPv6 within prefix", policy: PeerPolicy{ExportPrefixes: []net.IPNet{mustParseSubnet(t, "2001:db8::/32")}}, prefix: "2001:db8:1::/48", export: true},
+		{name: "other address family", policy: PeerPolicy{ExportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/16")}}, prefix: "2001:db8::/32", export: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.export, test.policy.exports(mustParseSubnet(t, test.prefix)))
+		})
+	}
+}
+
+func TestAddRemovePeer(t *testing.T) {
+	s := NewServer()
+	address := net.ParseIP("192.0.2.1")
+	policy := PeerPolicy{ExportCommunities: []string{"65000:100"}}
+
+	require.NoError(t, s.AddPeer("network_1", address, 65001, "", policy))
+
+	// Networks can apply different policies to the same peer.
+	require.NoError(t, s.AddPeer("network_2", address, 65001, "", PeerPolicy{Import: true}))
+
+	// But the session settings must match.
+	assert.Error(t, s.AddPeer("network_3", address, 65002, "", policy))
+	assert.Error(t, s.AddPeer("network_3", address, 65001, "secret", policy))
+
+	// And a network must use the same policy for the peer.
+	assert.Error(t, s.AddPeer("network_1", address, 65001, "", PeerPolicy{}))
+	require.NoError(t, s.AddPeer("network_1", address, 65001, "", policy))
+
+	bgpPeer := s.peers[address.String()]
+	assert.Equal(t, 3, bgpPeer.count)
+	require.Len(t, bgpPeer.policies, 2)
+	assert.Equal(t, 2, bgpPeer.policies["network_1"].count)
+	assert.True(t, bgpPeer.policies["network_1"].policy.Equal(policy))
+	assert.True(t, bgpPeer.policies["network_2"].policy.Import)
+
+	// Only the networks using the peer can remove it.
+	assert.Equal(t, ErrPeerNotFound, s.RemovePeer("network_3", address))
+	assert.Equal(t, ErrPeerNotFound, s.RemovePeer("network_1", net.ParseIP("192.0.2.2")))
+
+	// The policy of a network is kept until it no longer uses the peer.
+	require.NoError(t, s.RemovePeer("network_1", address))
+	assert.Contains(t, s.peers[address.String()].policies, "network_1")
+
+	require.NoError(t, s.RemovePeer("network_1", address))
+	assert.NotContains(t, s.peers[address.String()].policies, "network_1")
+	assert.Equal(t, 1, s.peers[address.String()].count)
+
+	// The peer is removed along with its last network.
+	require.NoError(t, s.RemovePeer("network_2", address))
+	assert.Empty(t, s.peers)
+}
+
+func TestApplyPolicies(t *testing.T) {
+	s := NewServer()
+
+	// Nothing to apply while the server isn't running.
+	s.peers["192.0.2.1"] = peer{address: net.ParseIP("192.0.2.1"), policies: map[string]*peerPolicy{
+		"network_1": {policy: PeerPolicy{ExportCommunities: []string{"65000:100"}}, count: 1},
+		"network_2": {policy: PeerPolicy{Import: true}, count: 1},
+	}}
+
+	require.NoError(t, s.AddPrefix(mustParseSubnet(t, "10.0.1.0/24"), net.ParseIP("192.0.2.254"), "network_1", "network_1"))
+	require.NoError(t, s.AddPrefix(mustParseSubnet(t, "10.0.2.0/24"), net.ParseIP("192.0.2.254"), "network_2", "network_2"))
+	assert.Nil(t, s.policies)
+	assert.Nil(t, s.definedSets)
+
+	// Start without listening (port -1).
+	require.NoError(t, s.Start("127.0.0.1:-1", 65000, net.ParseIP("192.0.2.254")))
+	defer func() { _ = s.Stop() }()
+
+	listPolicies := func() map[string]*bgpAPI.Policy {
+		policies := map[string]*bgpAPI.Policy{}
+		err := s.bgp.ListPolicy(context.Background(), &bgpAPI.ListPolicyRequest{}, func(p *bgpAPI.Policy) {
+			policies[p.Name] = p
+		})
+		require.NoError(t, err)
+
+		return policies
+	}
+
+	listAssigned := func() []string {
+		names := []string{}
+		err := s.bgp.ListPolicyAssignment(context.Background(), &bgpAPI.ListPolicyAssignmentRequest{Name: "global", Direction: bgpAPI.PolicyDirection_EXPORT}, func(a *bgpAPI.PolicyAssignment) {
+			for _, p := range a.Policies {
+				names = append(names, p.Name)
+			}
+		})
+		require.NoError(t, err)
+
+		return names
+	}
+
+	definedSet := func(name string) []string {
+		for _, set := range s.definedSets {
+			if set.Name != name {
+				continue
+			}
+
+			prefixes := []string{}
+			for _, prefix := range set.Prefixes {
+				prefixes = append(prefixes, prefix.IpPrefix)
+			}
+
+			return prefixes
+		}
+
+		return nil
+	}
+
+	// Community only policy (applied on start), only tagging the prefixes of its network.
+	policies := listPolicies()
+	require.Contains(t, policies, "lxd_peer_192.0.2.1")
+	require.Len(t, policies["lxd_peer_192.0.2.1"].Statements, 1)
+	statement := policies["lxd_peer_192.0.2.1"].Statements[0]
+	assert.Equal(t, bgpAPI.RouteAction_ACCEPT, statement.Actions.RouteAction)
+	assert.Equal(t, []string{"65000:100"}, statement.Actions.Community.Communities)
+	assert.Equal(t, "lxd_peer_192.0.2.1_network_1_accept_ipv4", statement.Conditions.PrefixSet.Name)
+	assert.Equal(t, []string{"10.0.1.0/24"}, definedSet("lxd_peer_192.0.2.1_network_1_accept_ipv4"))
+	assert.Equal(t, []string{"lxd_peer_192.0.2.1"}, listAssigned())
+	assert.Len(t, s.definedSets, 2)
+
+	// Add a peer restricting the exported prefixes of a network in both address families, a peer whose
+	// network has no prefixes yet and one without export policy.
+	require.NoError(t, s.AddPrefix(mustParseSubnet(t, "2001:db8:1::/48"), net.ParseIP("2001:db8::1"), "network_1", "network_1"))
+	require.NoError(t, s.AddPrefix(mustParseSubnet(t, "192.168.0.0/24"), net.ParseIP("192.0.2.254"), "network_1_forward", "network_1"))
+	s.peers["192.0.2.2"] = peer{address: net.ParseIP("192.0.2.2"), policies: map[string]*peerPolicy{
+		"network_1": {policy: PeerPolicy{ExportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/8"), mustParseSubnet(t, "2001:db8::/32")}}, count: 1},
+	}}
+
+	s.peers["192.0.2.3"] = peer{address: net.ParseIP("192.0.2.3"), policies: map[string]*peerPolicy{
+		"network_3": {policy: PeerPolicy{ExportPrefixes: []net.IPNet{mustParseSubnet(t, "10.0.0.0/8")}}, count: 1},
+	}}
+
+	s.peers["192.0.2.4"] = peer{address: net.ParseIP("192.0.2.4"), policies: map[string]*peerPolicy{
+		"network_1": {policy: PeerPolicy{Import: true}, count: 1},
+	}}
+
+	require.NoError(t, s.applyPolicies())
+
+	policies = listPolicies()
+	assert.Len(t, policies, 2)
+	assert.NotContains(t, policies, "lxd_peer_192.0.2.3")
+	assert.NotContains(t, policies, "lxd_peer_192.0.2.4")
+	require.Contains(t, policies, "lxd_peer_192.0.2.2")
+
+	statements := policies["lxd_peer_192.0.2.2"].Statements
+	require.Len(t, statements, 3)
+	assert.Equal(t, bgpAPI.RouteAction_ACCEPT, statements[0].Actions.RouteAction)
+	assert.Equal(t, "lxd_peer_192.0.2.2_network_1_accept_ipv4", statements[0].Conditions.PrefixSet.Name)
+	assert.Equal(t, bgpAPI.RouteAction_ACCEPT, statements[1].Actions.RouteAction)
+	assert.Equal(t, "lxd_peer_192.0.2.2_network_1_accept_ipv6", statements[1].Conditions.PrefixSet.Name)
+	assert.Equal(t, bgpAPI.RouteAction_REJECT, statements[2].Actions.RouteAction)
+	assert.Equal(t, "lxd_peer_192.0.2.2_network_1_reject_ipv4", statements[2].Conditions.PrefixSet.Name)
+	assert.Equal(t, []string{"10.0.1.0/24"}, definedSet("lxd_peer_192.0.2.2_network_1_accept_ipv4"))
+	assert.Equal(t, []string{"2001:db8:1::/48"}, definedSet("lxd_peer_192.0.2.2_network_1_accept_ipv6"))
+	assert.Equal(t, []string{"192.168.0.0/24"}, definedSet("lxd_peer_192.0.2.2_network_1_reject_ipv4"))
+
+	// The community only policy now also tags the IPv6 prefix of the network.
+	assert.Len(t, policies["lxd_peer_192.0.2.1"].Statements, 2)
+
+	assert.ElementsMatch(t, []string{"lxd_peer_192.0.2.1", "lxd_peer_192.0.2.2"}, listAssigned())
+	assert.Len(t, s.policies, 2)
+	assert.Len(t, s.definedSets, 7)
+
+	// Adding a prefix to a network refreshes the policies of its peers.
+	require.NoError(t, s.AddPrefix(mustParseSubnet(t, "10.0.3.0/24"), net.ParseIP("192.0.2.254"), "network_3", "network_3"))
+	policies = listPolicies()
+	require.Contains(t, policies, "lxd_peer_192.0.2.3")
+	assert.Equal(t, []string{"10.0.3.0/24"}, definedSet("lxd_peer_192.0.2.3_network_3_accept_ipv4"))
+
+	require.NoError(t, s.RemovePrefixByOwner("network_3"))
+	assert.NotContains(t, listPolicies(), "lxd_peer_192.0.2.3")
+
+	// Removing the export policies removes everything.
+	delete(s.peers, "192.0.2.1")
+	delete(s.peers, "192.0.2.2")
+	require.NoError(t, s.applyPolicies())
+	assert.Empty(t, listPolicies())
+	assert.Empty(t, listAssigned())
+	assert.Nil(t, s.policies)
+	assert.Nil(t, s.definedSets)
+}
@@ -28,11 +28,16 @@ type Server struct {
 	paths    map[string]path
 	peers    map[string]peer
 
+	// Export policies currently applied.
+	policies    []*bgpAPI.Policy
+	definedSets []*bgpAPI.DefinedSet
+
 	mu sync.Mutex
 }
 
 type path struct {
 	owner   string
+	network string // Network whose peer policies apply to the path.
 	prefix  net.IPNet
 	nexthop net.IP
 }
@@ -41,9 +46,16 @@ type peer struct {
 	address  net.IP
 	asn      uint32
 	password string
+	policies map[string]*peerPolicy // Policies of the networks using the peer.
 	count    int
 }
 
+// peerPolicy is the policy a network applies to a peer, along with the number of times the network uses the peer.
+type peerPolicy struct {
+	policy PeerPolicy
+	count  int
+}
+
 // NewServer returns a new server instance.
 func NewServer() *Server {
 	// Setup new struct.
@@ -62,18 +74,6 @@ func (s *Server) setup() {
 	// Spawn the BGP goroutines.
 	s.bgp = bgpServer.NewBgpServer()
 	go s.bgp.Serve()
-
-	// Insert any path that's already defined.
-	if len(s.paths) > 0 {
-		// Reset the path list.
-		paths := s.paths
-		s.paths = map[string]path{}
-
-		for _, path := range paths {
-			err := s.addPrefix(path.prefix, path.nexthop, path.owner)
-			logger.Warn("Unable to add prefix to BGP server", logger.Ctx{"prefix": path.prefix.String(), "err": err})
-		}
-	}
 }
 
 // Start sets up the BGP listener.
@@ -129,9 +129,23 @@ func (s *Server) start(address string, asn uint32, routerID net.IP) error {
 		return err
 	}
 
+	// Insert any path that's already defined (paths can only be added once started).
+	if len(s.paths) > 0 {
+		// Reset the path list.
+		paths := s.paths
+		s.paths = map[string]path{}
+
+		for _, path := range paths {
+			err := s.addPrefix(path.prefix, path.nexthop, path.owner, path.network)
+			if err != nil {
+				logger.Warn("Unable to add prefix to BGP server", logger.Ctx{"prefix": path.prefix.String(), "err": err})
+			}
+		}
+	}
+
 	// Add any existing peers.
 	for _, peer := range s.peers {
-		err := s.addPeer(peer.address, peer.asn, peer.password)
+		err := s.addPeerSession(peer.address, peer.asn, peer.password)
 		if err != nil {
 			return err
 		}
@@ -142,6 +156,15 @@ func (s *Server) start(address string, asn uint32, routerID net.IP) error {
 	s.asn = asn
 	s.routerID = routerID
 
+	// Apply the export policies (the policy table is reset on start).
+	s.policies = nil
+	s.definedSets = nil
+
+	err = s.applyPolicies()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -160,9 +183,9 @@ func (s *Server) stop() error {
 		return nil
 	}
 
-	// Remove all the peers (ignore failures).
+	// Remove all the peer sessions, keeping the peers to add them back on start.
 	for _, peer := range s.peers {
-		err := s.removePeer(peer.address)
+		err := s.bgp.DeletePeer(context.Background(), &bgpAPI.DeletePeerRequest{Address: peer.address.String()})
 		if err != nil {
 			return err
 		}
@@ -231,15 +254,21 @@ func (s *Server) reconfigure(address string, asn uint32, routerID net.IP) error
 }
 
 // AddPrefix adds a new prefix to the BGP server.
-func (s *Server) AddPrefix(subnet net.IPNet, nexthop net.IP, owner string) error {
+// The prefix is advertised according to the policies the given network applies to its peers.
+func (s *Server) AddPrefix(subnet net.IPNet, nexthop net.IP, owner string, network string) error {
 	// Locking.
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return s.addPrefix(subnet, nexthop, owner)
+	err := s.addPrefix(subnet, nexthop, owner, network)
+	if err != nil {
+		return err
+	}
+
+	return s.refreshPolicies()
 }
 
-func (s *Server) addPrefix(subnet net.IPNet, nexthop net.IP, owner string) error {
+func (s *Server) addPrefix(subnet net.IPNet, nexthop net.IP, owner string, network string) error {
 	// Prepare the prefix.
 	prefixLen, _ := subnet.Mask.Size()
 	prefix := subnet.IP.String()
@@ -310,6 +339,7 @@ func (s *Server) addPrefix(subnet net.IPNet, nexthop net.IP, owner string) error
 		prefix:  subnet,
 		nexthop: nexthop,
 		owner:   owner,
+		network: network,
 	}
 
 	return nil
@@ -337,7 +367,7 @@ func (s *Server) RemovePrefixByOwner(owner string) error {
 		}
 	}
 
-	return nil
+	return s.refreshPolicies()
 }
 
 // RemovePrefix removes a prefix from the BGP server.
@@ -346,7 +376,12 @@ func (s *Server) RemovePrefix(subnet net.IPNet, nexthop net.IP) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return s.removePrefix(subnet, nexthop)
+	err := s.removePrefix(subnet, nexthop)
+	if err != nil {
+		return err
+	}
+
+	return s.refreshPolicies()
 }
 
 func (s *Server) removePrefix(subnet net.IPNet, nexthop net.IP) error {
@@ -387,16 +422,17 @@ func (s *Server) removePrefixByUUID(pathUUID string) error {
 	return nil
 }
 
-// AddPeer adds a new BGP peer.
-func (s *Server) AddPeer(address net.IP, asn uint32, password string) error {
+// AddPeer adds a new BGP peer used by the given network, with the policy the network applies to it.
+// A peer can be used by several networks, each with its own policy for the prefixes of the network.
+func (s *Server) AddPeer(network string, address net.IP, asn uint32, password string, policy PeerPolicy) error {
 	// Locking.
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return s.addPeer(address, asn, password)
+	return s.addPeer(network, address, asn, password, policy)
 }
 
-func (s *Server) addPeer(address net.IP, asn uint32, password string) error {
+func (s *Server) addPeer(network string, address net.IP, asn uint32, password string, policy PeerPolicy) error {
 	// Look for an existing peer.
 	bgpPeer, bgpPeerExists := s.peers[address.String()]
 	if bgpPeerExists {
@@ -408,12 +444,52 @@ func (s *Server) addPeer(address net.IP, asn uint32, password string) error {
 			return fmt.Errorf("Peer %q already used but with a different password", address)
 		}
 
-		// Re-use the existing entry.
+		networkPolicy := bgpPeer.policies[network]
+		if networkPolicy != nil {
+			if !networkPolicy.policy.Equal(policy) {
+				return fmt.Errorf("Peer %q already used by the network but with a different policy", address)
+			}
+
+			// Re-use the existing entry.
+			networkPolicy.count++
+			bgpPeer.count++
+			s.peers[address.String()] = bgpPeer
+			return nil
+		}
+
+		// Re-use the existing session with the policy of the network.
+		bgpPeer.policies[network] = &peerPolicy{policy: policy, count: 1}
 		bgpPeer.count++
 		s.peers[address.String()] = bgpPeer
-		return nil
+
+		return s.applyPolicies()
+	}
+
+	err := s.addPeerSession(address, asn, password)
+	if err != nil {
+		return err
+	}
+
+	// Add the peer to the list.
+	s.peers[address.String()] = peer{
+		address:  address,
+		asn:      asn,
+		password: password,
+		policies: map[string]*peerPolicy{network: {policy: policy, count: 1}},
+		count:    1,
+	}
+
+	// Refresh the export policies.
+	err = s.applyPolicies()
+	if err != nil {
+		return err
 	}
 
+	return nil
+}
+
+// addPeerSession sets up the BGP session with a peer.
+func (s *Server) addPeerSession(address net.IP, asn uint32, password string) error {
 	// Setup the configuration.
 	n := &bgpAPI.Peer{
 		// Peer information.
@@ -468,35 +544,22 @@ func (s *Server) addPeer(address net.IP, asn uint32, password string) error {
 		}
 	}
 
-	// Add the peer to the list.
-	if bgpPeerExists {
-		bgpPeer.count++
-		s.peers[address.String()] = bgpPeer
-	} else {
-		s.peers[address.String()] = peer{
-			address:  address,
-			asn:      asn,
-			password: password,
-			count:    1,
-		}
-	}
-
 	return nil
 }
 
-// RemovePeer removes a prefix from the BGP server.
-func (s *Server) RemovePeer(address net.IP) error {
+// RemovePeer removes a peer used by the given network from the BGP server.
+func (s *Server) RemovePeer(network string, address net.IP) error {
 	// Locking.
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return s.removePeer(address)
+	return s.removePeer(network, address)
 }
 
-func (s *Server) removePeer(address net.IP) error {
+func (s *Server) removePeer(network string, address net.IP) error {
 	// Find the peer.
 	bgpPeer, bgpPeerExists := s.peers[address.String()]
-	if !bgpPeerExists {
+	if !bgpPeerExists || bgpPeer.policies[network] == nil {
 		return ErrPeerNotFound
 	}
 
@@ -516,6 +579,20 @@ func (s *Server) removePeer(address net.IP) error {
 		// Decrease refcount.
 		bgpPeer.count--
 		s.peers[address.String()] = bgpPeer
+
+		networkPolicy := bgpPeer.policies[network]
+		networkPolicy.count--
+		if networkPolicy.count > 0 {
+			return nil
+		}
+
+		delete(bgpPeer.policies, network)
+	}
+
+	// Refresh the export policies.
+	err := s.applyPolicies()
+	if err != nil {
+		return err
 	}
 
 	return nil
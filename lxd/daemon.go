@@ -21,6 +21,7 @@ import (
 	dqliteclient "github.com/canonical/go-dqlite/client"
 	"github.com/canonical/go-dqlite/driver"
 	"github.com/gorilla/mux"
+	miekgdns "github.com/miekg/dns"
 	"golang.org/x/sys/unix"
 	liblxc "gopkg.in/lxc/go-lxc.v2"
 
@@ -56,6 +57,7 @@ import (
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
 	"github.com/lxc/lxd/lxd/storage/filesystem"
+	"github.com/lxc/lxd/lxd/storage/s3/miniod"
 	"github.com/lxc/lxd/lxd/sys"
 	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/lxd/ucred"
@@ -1092,23 +1094,30 @@ func (d *Daemon) init() error {
 		return fmt.Errorf("Failed to fetch metrics address: %w", err)
 	}
 
+	storageBucketsAddress, err := node.StorageBucketsAddress(d.db.Node)
+	if err != nil {
+		return fmt.Errorf("Failed to fetch storage buckets address: %w", err)
+	}
+
 	if os.Getenv("LISTEN_PID") != "" {
 		d.systemdSocketActivated = true
 	}
 
 	/* Setup the web server */
 	config := &endpoints.Config{
-		Dir:                  d.os.VarDir,
-		UnixSocket:           d.UnixSocket(),
-		Cert:                 networkCert,
-		RestServer:           restServer(d),
-		DevLxdServer:         devLxdServer(d),
-		LocalUnixSocketGroup: d.config.Group,
-		NetworkAddress:       address,
-		ClusterAddress:       clusterAddress,
-		DebugAddress:         debugAddress,
-		MetricsAddress:       metricsAddress,
-		MetricsServer:        metricsServer(d),
+		Dir:                   d.os.VarDir,
+		UnixSocket:            d.UnixSocket(),
+		Cert:                  networkCert,
+		RestServer:            restServer(d),
+		DevLxdServer:          devLxdServer(d),
+		LocalUnixSocketGroup:  d.config.Group,
+		NetworkAddress:        address,
+		ClusterAddress:        clusterAddress,
+		DebugAddress:          debugAddress,
+		MetricsAddress:        metricsAddress,
+		MetricsServer:         metricsServer(d),
+		StorageBucketsAddress: storageBucketsAddress,
+		StorageBucketsServer:  storageBucketsServer(d),
 	}
 	d.endpoints, err = endpoints.Up(config)
 	if err != nil {
@@ -1384,6 +1393,14 @@ func (d *Daemon) init() error {
 		}
 
 		return resp, nil
+	}, func(name string, peer string, prerequisites func(content string) error, updates []miekgdns.RR) error {
+		// Fetch the zone.
+		zone, err := networkZone.LoadByName(d.State(), name)
+		if err != nil {
+			return err
+		}
+
+		return zone.ApplyUpdate(peer, prerequisites, updates)
 	})
 	if dnsAddress != "" {
 		err := d.dns.Start(dnsAddress)
@@ -1570,6 +1587,27 @@ func (d *Daemon) Ready() error {
 		// Take snapshot of custom volumes (minutely check of configurable cron expression)
 		d.tasks.Add(autoCreateCustomVolumeSnapshotsTask(d))
 
+		// Prune instance and custom volume snapshots by retention policy (every 5 minutes, leader only)
+		d.tasks.Add(pruneSnapshotsRetentionTask(d))
+
+		// Replicate custom volumes to remote servers (minutely check of configurable cron expression)
+		d.tasks.Add(autoReplicateCustomVolumesTask(d))
+
+		// Check storage pool fill levels against their thresholds (every 5 minutes)
+		d.tasks.Add(checkStoragePoolsFillTask(d))
+
+		// Health check the backends of network load balancers (every 5 seconds)
+		d.tasks.Add(networkLoadBalancersHealthCheckTask(d))
+
+		// Install the routes learned from BGP peers (every 10 seconds)
+		d.tasks.Add(networkBGPImportTask(d))
+
+		// Adjust the limits of instances with an autoscaling policy (every minute)
+		d.tasks.Add(autoscaleInstancesTask(d))
+
+		// Start, stop and restart instances according to their schedule.* settings (minutely check of configurable cron expressions)
+		d.tasks.Add(instanceSchedulesTask(d))
+
 		// Remove resolved warnings (daily)
 		d.tasks.Add(pruneResolvedWarningsTask(d))
 	}
@@ -1746,6 +1784,9 @@ func (d *Daemon) Stop(ctx context.Context, sig os.Signal) error {
 		trackError(d.endpoints.Down(), "Shutdown endpoints")
 	}
 
+	// Stop any MinIO processes serving local storage buckets.
+	miniod.StopAll()
+
 	if shouldUnmount {
 		logger.Info("Unmounting temporary filesystems")
 
@@ -23,6 +23,7 @@ type InstanceBackup struct {
 	InstanceOnly         bool
 	OptimizedStorage     bool
 	CompressionAlgorithm string
+	Parent               string
 }
 
 // StoragePoolVolumeBackup is a value object holding all db-related details about a storage volume backup.
@@ -35,6 +36,7 @@ type StoragePoolVolumeBackup struct {
 	VolumeOnly           bool
 	OptimizedStorage     bool
 	CompressionAlgorithm string
+	Parent               string
 }
 
 // Returns the ID of the instance backup with the given name.
@@ -62,15 +64,17 @@ func (c *Cluster) GetInstanceBackup(projectName string, name string) (InstanceBa
 	q := `
 SELECT instances_backups.id, instances_backups.instance_id,
        instances_backups.creation_date, instances_backups.expiry_date,
-       instances_backups.container_only, instances_backups.optimized_storage
+       instances_backups.container_only, instances_backups.optimized_storage,
+       COALESCE(parents.name, '')
     FROM instances_backups
     JOIN instances ON instances.id=instances_backups.instance_id
     JOIN projects ON projects.id=instances.project_id
+    LEFT JOIN instances_backups AS parents ON parents.id=instances_backups.parent_id
     WHERE projects.name=? AND instances_backups.name=?
 `
 	arg1 := []any{projectName, name}
 	arg2 := []any{&args.ID, &args.InstanceID, &args.CreationDate,
-		&args.ExpiryDate, &instanceOnlyInt, &optimizedStorageInt}
+		&args.ExpiryDate, &instanceOnlyInt, &optimizedStorageInt, &args.Parent}
 	err := dbQueryRowScan(c, q, arg1, arg2)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -101,15 +105,17 @@ func (c *Cluster) GetInstanceBackupWithID(backupID int) (InstanceBackup, error)
 	q := `
 SELECT instances_backups.name, instances_backups.instance_id,
        instances_backups.creation_date, instances_backups.expiry_date,
-       instances_backups.container_only, instances_backups.optimized_storage
+       instances_backups.container_only, instances_backups.optimized_storage,
+       COALESCE(parents.name, '')
     FROM instances_backups
     JOIN instances ON instances.id=instances_backups.instance_id
     JOIN projects ON projects.id=instances.project_id
+    LEFT JOIN instances_backups AS parents ON parents.id=instances_backups.parent_id
     WHERE instances_backups.id=?
 `
 	arg1 := []any{backupID}
 	arg2 := []any{&args.Name, &args.InstanceID, &args.CreationDate,
-		&args.ExpiryDate, &instanceOnlyInt, &optimizedStorageInt}
+		&args.ExpiryDate, &instanceOnlyInt, &optimizedStorageInt, &args.Parent}
 	err := dbQueryRowScan(c, q, arg1, arg2)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -171,7 +177,19 @@ func (c *Cluster) CreateInstanceBackup(args InstanceBackup) error {
 			optimizedStorageInt = 1
 		}
 
-		str := "INSERT INTO instances_backups (instance_id, name, creation_date, expiry_date, container_only, optimized_storage) VALUES (?, ?, ?, ?, ?, ?)"
+		var parentID any
+		if args.Parent != "" {
+			err := tx.tx.QueryRow("SELECT id FROM instances_backups WHERE instance_id=? AND name=?", args.InstanceID, args.Parent).Scan(&parentID)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					return api.StatusErrorf(http.StatusNotFound, "Parent instance backup not found")
+				}
+
+				return err
+			}
+		}
+
+		str := "INSERT INTO instances_backups (instance_id, name, creation_date, expiry_date, container_only, optimized_storage, parent_id) VALUES (?, ?, ?, ?, ?, ?, ?)"
 		stmt, err := tx.tx.Prepare(str)
 		if err != nil {
 			return err
@@ -179,7 +197,7 @@ func (c *Cluster) CreateInstanceBackup(args InstanceBackup) error {
 		defer func() { _ = stmt.Close() }()
 		result, err := stmt.Exec(args.InstanceID, args.Name,
 			args.CreationDate.Unix(), args.ExpiryDate.Unix(), instanceOnlyInt,
-			optimizedStorageInt)
+			optimizedStorageInt, parentID)
 		if err != nil {
 			return err
 		}
@@ -289,10 +307,12 @@ func (c *Cluster) GetStoragePoolVolumeBackups(projectName string, volumeName str
 		backups.creation_date,
 		backups.expiry_date,
 		backups.volume_only,
-		backups.optimized_storage
+		backups.optimized_storage,
+		COALESCE(parents.name, '')
 	FROM storage_volumes_backups AS backups
 	JOIN storage_volumes ON storage_volumes.id=backups.storage_volume_id
 	JOIN projects ON projects.id=storage_volumes.project_id
+	LEFT JOIN storage_volumes_backups AS parents ON parents.id=backups.parent_id
 	WHERE projects.name=? AND storage_volumes.name=? AND storage_volumes.storage_pool_id=?
 	ORDER BY backups.id
 	`
@@ -304,7 +324,7 @@ func (c *Cluster) GetStoragePoolVolumeBackups(projectName string, volumeName str
 			var b StoragePoolVolumeBackup
 			var expiryTime sql.NullTime
 
-			err := scan(&b.ID, &b.VolumeID, &b.Name, &b.CreationDate, &expiryTime, &b.VolumeOnly, &b.OptimizedStorage)
+			err := scan(&b.ID, &b.VolumeID, &b.Name, &b.CreationDate, &expiryTime, &b.VolumeOnly, &b.OptimizedStorage, &b.Parent)
 			if err != nil {
 				return err
 			}
@@ -364,7 +384,19 @@ func (c *Cluster) CreateStoragePoolVolumeBackup(args StoragePoolVolumeBackup) er
 			optimizedStorageInt = 1
 		}
 
-		str := "INSERT INTO storage_volumes_backups (storage_volume_id, name, creation_date, expiry_date, volume_only, optimized_storage) VALUES (?, ?, ?, ?, ?, ?)"
+		var parentID any
+		if args.Parent != "" {
+			err := tx.tx.QueryRow("SELECT id FROM storage_volumes_backups WHERE storage_volume_id=? AND name=?", args.VolumeID, args.Parent).Scan(&parentID)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					return api.StatusErrorf(http.StatusNotFound, "Parent storage volume backup not found")
+				}
+
+				return err
+			}
+		}
+
+		str := "INSERT INTO storage_volumes_backups (storage_volume_id, name, creation_date, expiry_date, volume_only, optimized_storage, parent_id) VALUES (?, ?, ?, ?, ?, ?, ?)"
 		stmt, err := tx.tx.Prepare(str)
 		if err != nil {
 			return err
@@ -372,7 +404,7 @@ func (c *Cluster) CreateStoragePoolVolumeBackup(args StoragePoolVolumeBackup) er
 		defer func() { _ = stmt.Close() }()
 		result, err := stmt.Exec(args.VolumeID, args.Name,
 			args.CreationDate.Unix(), args.ExpiryDate.Unix(), volumeOnlyInt,
-			optimizedStorageInt)
+			optimizedStorageInt, parentID)
 		if err != nil {
 			return err
 		}
@@ -428,14 +460,16 @@ SELECT
 	backups.creation_date,
 	backups.expiry_date,
 	backups.volume_only,
-	backups.optimized_storage
+	backups.optimized_storage,
+	COALESCE(parents.name, '')
 FROM storage_volumes_backups AS backups
 JOIN storage_volumes ON storage_volumes.id=backups.storage_volume_id
 JOIN projects ON projects.id=storage_volumes.project_id
+LEFT JOIN storage_volumes_backups AS parents ON parents.id=backups.parent_id
 WHERE projects.name=? AND backups.name=?
 `
 	arg1 := []any{projectName, backupName}
-	outfmt := []any{&args.ID, &args.VolumeID, &args.Name, &args.CreationDate, &args.ExpiryDate, &args.VolumeOnly, &args.OptimizedStorage}
+	outfmt := []any{&args.ID, &args.VolumeID, &args.Name, &args.CreationDate, &args.ExpiryDate, &args.VolumeOnly, &args.OptimizedStorage, &args.Parent}
 	err := dbQueryRowScan(c, q, arg1, outfmt)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -459,14 +493,16 @@ SELECT
 	backups.creation_date,
 	backups.expiry_date,
 	backups.volume_only,
-	backups.optimized_storage
+	backups.optimized_storage,
+	COALESCE(parents.name, '')
 FROM storage_volumes_backups AS backups
 JOIN storage_volumes ON storage_volumes.id=backups.storage_volume_id
 JOIN projects ON projects.id=storage_volumes.project_id
+LEFT JOIN storage_volumes_backups AS parents ON parents.id=backups.parent_id
 WHERE backups.id=?
 `
 	arg1 := []any{backupID}
-	outfmt := []any{&args.ID, &args.VolumeID, &args.Name, &args.CreationDate, &args.ExpiryDate, &args.VolumeOnly, &args.OptimizedStorage}
+	outfmt := []any{&args.ID, &args.VolumeID, &args.Name, &args.CreationDate, &args.ExpiryDate, &args.VolumeOnly, &args.OptimizedStorage, &args.Parent}
 	err := dbQueryRowScan(c, q, arg1, outfmt)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -97,6 +97,113 @@ var updates = map[int]schema.Update{
 	58: updateFromV57,
 	59: updateFromV58,
 	60: updateFromV59,
+	61: updateFromV60,
+	62: updateFromV61,
+	63: updateFromV62,
+	64: updateFromV63,
+}
+
+// updateFromV63 creates the networks_load_balancers and networks_load_balancers_config tables.
+func updateFromV63(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE "networks_load_balancers" (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_id INTEGER NOT NULL,
+	node_id INTEGER,
+	listen_address TEXT NOT NULL,
+	description TEXT NOT NULL,
+	backends TEXT NOT NULL,
+	ports TEXT NOT NULL,
+	UNIQUE (network_id, node_id, listen_address),
+	FOREIGN KEY (network_id) REFERENCES "networks" (id) ON DELETE CASCADE,
+	FOREIGN KEY (node_id) REFERENCES "nodes" (id) ON DELETE CASCADE
+);
+
+CREATE TABLE "networks_load_balancers_config" (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_load_balancer_id INTEGER NOT NULL,
+	key VARCHAR(255) NOT NULL,
+	value TEXT,
+	UNIQUE (network_load_balancer_id, key),
+	FOREIGN KEY (network_load_balancer_id) REFERENCES "networks_load_balancers" (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed creating network load balancers tables: %w", err)
+	}
+
+	return nil
+}
+
+func updateFromV62(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE storage_volumes_snapshots ADD COLUMN creation_date DATETIME;`)
+	if err != nil {
+		return fmt.Errorf("Failed adding creation_date column to storage_volumes_snapshots table: %w", err)
+	}
+
+	return nil
+}
+
+func updateFromV61(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+ALTER TABLE instances_backups ADD COLUMN parent_id INTEGER REFERENCES instances_backups (id) ON DELETE SET NULL;
+ALTER TABLE storage_volumes_backups ADD COLUMN parent_id INTEGER REFERENCES storage_volumes_backups (id) ON DELETE SET NULL;
+`)
+	if err != nil {
+		return fmt.Errorf("Failed adding parent_id column to backup tables: %w", err)
+	}
+
+	return nil
+}
+
+func updateFromV60(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE storage_buckets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	name TEXT NOT NULL,
+	storage_pool_id INTEGER NOT NULL,
+	node_id INTEGER,
+	description TEXT NOT NULL,
+	project_id INTEGER NOT NULL,
+	UNIQUE (node_id, name),
+	FOREIGN KEY (storage_pool_id) REFERENCES storage_pools (id) ON DELETE CASCADE,
+	FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX storage_buckets_unique_storage_pool_id_node_id_name ON storage_buckets (storage_pool_id, IFNULL(node_id, -1), name);
+
+CREATE TABLE storage_buckets_config (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	storage_bucket_id INTEGER NOT NULL,
+	key TEXT NOT NULL,
+	value TEXT NOT NULL,
+	UNIQUE (storage_bucket_id, key),
+	FOREIGN KEY (storage_bucket_id) REFERENCES storage_buckets (id) ON DELETE CASCADE
+);
+
+CREATE TABLE storage_buckets_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	storage_bucket_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	access_key TEXT NOT NULL,
+	secret_key TEXT NOT NULL,
+	role TEXT NOT NULL,
+	UNIQUE (storage_bucket_id, name),
+	FOREIGN KEY (storage_bucket_id) REFERENCES storage_buckets (id) ON DELETE CASCADE
+);
+
+INSERT INTO projects_config (project_id, key, value)
+	SELECT projects_config.project_id, 'features.storage.buckets', 'true'
+	FROM projects_config
+	WHERE projects_config.key = 'features.storage.volumes' AND projects_config.value = 'true';
+`)
+	if err != nil {
+		return fmt.Errorf("Failed creating storage bucket tables: %w", err)
+	}
+
+	return nil
 }
 
 func updateFromV59(tx *sql.Tx) error {
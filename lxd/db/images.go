@@ -36,6 +36,36 @@ SELECT images.fingerprint
 	return query.SelectStrings(c.tx, q, c.nodeID)
 }
 
+// GetLocalImagesRefcounts returns the number of projects referencing each of the images available on the local
+// member, indexed by fingerprint.
+func (c *ClusterTx) GetLocalImagesRefcounts() (map[string]int, error) {
+	q := `
+SELECT images.fingerprint, COUNT(images.id)
+  FROM images_nodes
+  JOIN images ON images.id = images_nodes.image_id
+ WHERE node_id = ?
+ GROUP BY images.fingerprint
+`
+	refcounts := map[string]int{}
+	err := c.QueryScan(q, func(scan func(dest ...any) error) error {
+		var fingerprint string
+		var refcount int
+
+		err := scan(&fingerprint, &refcount)
+		if err != nil {
+			return err
+		}
+
+		refcounts[fingerprint] = refcount
+		return nil
+	}, c.nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return refcounts, nil
+}
+
 // GetImageSource returns the image source with the given ID.
 func (c *ClusterTx) GetImageSource(imageID int) (int, api.ImageSource, error) {
 	q := `SELECT id, server, protocol, certificate, alias FROM images_source WHERE image_id=?`
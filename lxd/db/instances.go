@@ -501,6 +501,37 @@ func (c *ClusterTx) UpdateInstanceNode(ctx context.Context, project, oldName str
 	return nil
 }
 
+// UpdateInstanceNodeID changes the cluster member hosting an instance, keeping its name.
+// It's meant to be used once a running instance has been live moved to another cluster member, whose storage
+// already holds the instance volume.
+func (c *ClusterTx) UpdateInstanceNodeID(ctx context.Context, project string, name string, newNode string) error {
+	instanceID, err := cluster.GetInstanceID(ctx, c.tx, project, name)
+	if err != nil {
+		return fmt.Errorf("Failed to get instance's ID: %w", err)
+	}
+
+	node, err := c.GetNodeByName(newNode)
+	if err != nil {
+		return fmt.Errorf("Failed to get new node's info: %w", err)
+	}
+
+	result, err := c.tx.Exec("UPDATE instances SET node_id=? WHERE id=?", node.ID, instanceID)
+	if err != nil {
+		return fmt.Errorf("Failed to update instance's node ID: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to get rows affected by instance update: %w", err)
+	}
+
+	if n != 1 {
+		return fmt.Errorf("Unexpected number of updated rows in instances table: %d", n)
+	}
+
+	return nil
+}
+
 // GetLocalInstancesInProject retuurns all instances of the given type on the local member in the given project.
 // If projectName is empty then all instances in all projects are returned.
 func (c *ClusterTx) GetLocalInstancesInProject(ctx context.Context, filter cluster.InstanceFilter) ([]cluster.Instance, error) {
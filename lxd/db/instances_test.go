@@ -456,6 +456,29 @@ func TestGetInstanceToNodeMap(t *testing.T) {
 		}, result)
 }
 
+// The cluster member hosting an instance can be changed without renaming it.
+func TestUpdateInstanceNodeID(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	nodeID1 := int64(1) // This is the default local member
+
+	_, err := tx.CreateNode("node2", "1.2.3.4:666")
+	require.NoError(t, err)
+
+	addContainer(t, tx, nodeID1, "c1")
+
+	err = tx.UpdateInstanceNodeID(context.TODO(), project.Default, "c1", "node2")
+	require.NoError(t, err)
+
+	result, err := tx.GetProjectInstanceToNodeMap([]string{"default"}, db.InstanceTypeFilter(instancetype.Container))
+	require.NoError(t, err)
+	assert.Equal(t, map[[2]string]string{{project.Default, "c1"}: "node2"}, result)
+
+	err = tx.UpdateInstanceNodeID(context.TODO(), project.Default, "c1", "node3")
+	assert.Error(t, err)
+}
+
 func TestGetInstancePool(t *testing.T) {
 	dbCluster, cleanup := db.NewTestCluster(t)
 	defer cleanup()
@@ -43,6 +43,33 @@ func (c *Cluster) GetNetworkZones(project string) ([]string, error) {
 	return zoneNames, nil
 }
 
+// GetNetworkZoneNames returns the names of the network zones of all projects.
+func (c *Cluster) GetNetworkZoneNames() ([]string, error) {
+	q := `SELECT name FROM networks_zones ORDER BY id`
+
+	var zoneNames []string
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		return tx.QueryScan(q, func(scan func(dest ...any) error) error {
+			var zoneName string
+
+			err := scan(&zoneName)
+			if err != nil {
+				return err
+			}
+
+			zoneNames = append(zoneNames, zoneName)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return zoneNames, nil
+}
+
 // GetNetworkZoneKeys returns a map of key names to keys.
 func (c *Cluster) GetNetworkZoneKeys() (map[string]string, error) {
 	q := `SELECT networks_zones.name, networks_zones_config.key, networks_zones_config.value
@@ -389,6 +416,49 @@ func (c *Cluster) GetNetworkZoneRecord(zone int64, name string) (int64, *api.Net
 	return id, &record, nil
 }
 
+// GetNetworkZoneRecords returns all the records of the network zone with the given ID, indexed by record ID.
+func (c *ClusterTx) GetNetworkZoneRecords(zone int64) (map[int64]*api.NetworkZoneRecord, error) {
+	q := `
+		SELECT networks_zones_records.id, networks_zones_records.name, networks_zones_records.description, networks_zones_records.entries
+		FROM networks_zones_records
+		WHERE networks_zones_records.network_zone_id=?
+	`
+
+	records := map[int64]*api.NetworkZoneRecord{}
+	err := c.QueryScan(q, func(scan func(dest ...any) error) error {
+		var id int64
+		var entries string
+		record := api.NetworkZoneRecord{}
+
+		err := scan(&id, &record.Name, &record.Description, &entries)
+		if err != nil {
+			return err
+		}
+
+		// Decode the JSON record.
+		err = json.Unmarshal([]byte(entries), &record.Entries)
+		if err != nil {
+			return err
+		}
+
+		records[id] = &record
+
+		return nil
+	}, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, record := range records {
+		err = networkZoneRecordConfig(c, id, record)
+		if err != nil {
+			return nil, fmt.Errorf("Failed loading config: %w", err)
+		}
+	}
+
+	return records, nil
+}
+
 // networkZoneRecordConfig populates the config map of the network zone record with the given ID.
 func networkZoneRecordConfig(tx *ClusterTx, id int64, record *api.NetworkZoneRecord) error {
 	q := `
@@ -420,41 +490,48 @@ func networkZoneRecordConfig(tx *ClusterTx, id int64, record *api.NetworkZoneRec
 // CreateNetworkZoneRecord creates a new network zone record.
 func (c *Cluster) CreateNetworkZoneRecord(zone int64, info api.NetworkZoneRecordsPost) (int64, error) {
 	var id int64
-	var err error
 
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		var err error
+
+		id, err = tx.CreateNetworkZoneRecord(zone, info)
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// CreateNetworkZoneRecord creates a new network zone record.
+func (c *ClusterTx) CreateNetworkZoneRecord(zone int64, info api.NetworkZoneRecordsPost) (int64, error) {
 	// Turn the entries into JSON.
 	entries, err := json.Marshal(info.Entries)
 	if err != nil {
 		return -1, err
 	}
 
-	err = c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
-		// Insert a new network zone record.
-		result, err := tx.tx.Exec(`
-			INSERT INTO networks_zones_records (network_zone_id, name, description, entries)
-			VALUES (?, ?, ?, ?)
-		`, zone, info.Name, info.Description, string(entries))
-		if err != nil {
-			return err
-		}
-
-		id, err := result.LastInsertId()
-		if err != nil {
-			return err
-		}
+	// Insert a new network zone record.
+	result, err := c.tx.Exec(`
+		INSERT INTO networks_zones_records (network_zone_id, name, description, entries)
+		VALUES (?, ?, ?, ?)
+	`, zone, info.Name, info.Description, string(entries))
+	if err != nil {
+		return -1, err
+	}
 
-		err = networkZoneRecordConfigAdd(tx.tx, id, info.Config)
-		if err != nil {
-			return err
-		}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
 
-		return nil
-	})
+	err = networkZoneRecordConfigAdd(c.tx, id, info.Config)
 	if err != nil {
-		id = -1
+		return -1, err
 	}
 
-	return id, err
+	return id, nil
 }
 
 // networkzoneConfigAdd inserts Network zone config keys.
@@ -482,40 +559,50 @@ func networkZoneRecordConfigAdd(tx *sql.Tx, id int64, config map[string]string)
 
 // UpdateNetworkZoneRecord updates the network zone record with the given ID.
 func (c *Cluster) UpdateNetworkZoneRecord(id int64, config api.NetworkZoneRecordPut) error {
+	return c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		return tx.UpdateNetworkZoneRecord(id, config)
+	})
+}
+
+// UpdateNetworkZoneRecord updates the network zone record with the given ID.
+func (c *ClusterTx) UpdateNetworkZoneRecord(id int64, config api.NetworkZoneRecordPut) error {
 	// Turn the entries into JSON.
 	entries, err := json.Marshal(config.Entries)
 	if err != nil {
 		return err
 	}
 
-	return c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
-		_, err := tx.tx.Exec(`
-			UPDATE networks_zones_records
-			SET description=?, entries=?
-			WHERE id=?
-		`, config.Description, string(entries), id)
-		if err != nil {
-			return err
-		}
+	_, err = c.tx.Exec(`
+		UPDATE networks_zones_records
+		SET description=?, entries=?
+		WHERE id=?
+	`, config.Description, string(entries), id)
+	if err != nil {
+		return err
+	}
 
-		_, err = tx.tx.Exec("DELETE FROM networks_zones_records_config WHERE network_zone_record_id=?", id)
-		if err != nil {
-			return err
-		}
+	_, err = c.tx.Exec("DELETE FROM networks_zones_records_config WHERE network_zone_record_id=?", id)
+	if err != nil {
+		return err
+	}
 
-		err = networkZoneRecordConfigAdd(tx.tx, id, config.Config)
-		if err != nil {
-			return err
-		}
+	err = networkZoneRecordConfigAdd(c.tx, id, config.Config)
+	if err != nil {
+		return err
+	}
 
-		return nil
-	})
+	return nil
 }
 
 // DeleteNetworkZoneRecord deletes the network zone record.
 func (c *Cluster) DeleteNetworkZoneRecord(id int64) error {
 	return c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
-		_, err := tx.tx.Exec("DELETE FROM networks_zones_records WHERE id=?", id)
-		return err
+		return tx.DeleteNetworkZoneRecord(id)
 	})
 }
+
+// DeleteNetworkZoneRecord deletes the network zone record.
+func (c *ClusterTx) DeleteNetworkZoneRecord(id int64) error {
+	_, err := c.tx.Exec("DELETE FROM networks_zones_records WHERE id=?", id)
+	return err
+}
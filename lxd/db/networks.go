@@ -284,6 +284,40 @@ WHERE networks.id = ? AND networks.state = ?
 	return configs, nil
 }
 
+// GetNetworkMembersConfig returns the node-specific configuration of all
+// members the given network has such config on, keyed by member ID.
+func (c *ClusterTx) GetNetworkMembersConfig(networkID int64) (map[int64]map[string]string, error) {
+	q := `
+SELECT node_id, key, value
+  FROM networks_config
+ WHERE network_id=? AND node_id IS NOT NULL
+`
+
+	configs := map[int64]map[string]string{}
+	err := c.QueryScan(q, func(scan func(dest ...any) error) error {
+		var nodeID int64
+		var key, value string
+
+		err := scan(&nodeID, &key, &value)
+		if err != nil {
+			return err
+		}
+
+		if configs[nodeID] == nil {
+			configs[nodeID] = map[string]string{}
+		}
+
+		configs[nodeID][key] = value
+
+		return nil
+	}, networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
 // CreatePendingNetwork creates a new pending network on the node with the given name.
 func (c *ClusterTx) CreatePendingNetwork(node string, projectName string, name string, netType NetworkType, conf map[string]string) error {
 	// First check if a network with the given name exists, and, if so, that it's in the pending state.
@@ -540,11 +574,12 @@ type NetworkType int
 
 // Network types.
 const (
-	NetworkTypeBridge   NetworkType = iota // Network type bridge.
-	NetworkTypeMacvlan                     // Network type macvlan.
-	NetworkTypeSriov                       // Network type sriov.
-	NetworkTypeOVN                         // Network type ovn.
-	NetworkTypePhysical                    // Network type physical.
+	NetworkTypeBridge    NetworkType = iota // Network type bridge.
+	NetworkTypeMacvlan                      // Network type macvlan.
+	NetworkTypeSriov                        // Network type sriov.
+	NetworkTypeOVN                          // Network type ovn.
+	NetworkTypePhysical                     // Network type physical.
+	NetworkTypeWireguard                    // Network type wireguard.
 )
 
 // NetworkNode represents a network node.
@@ -686,6 +721,8 @@ func networkFillType(network *api.Network, netType NetworkType) {
 		network.Type = "ovn"
 	case NetworkTypePhysical:
 		network.Type = "physical"
+	case NetworkTypeWireguard:
+		network.Type = "wireguard"
 	default:
 		network.Type = "" // Unknown
 	}
@@ -925,4 +962,6 @@ var NodeSpecificNetworkConfig = []string{
 	"bgp.ipv6.nexthop",
 	"bridge.external_interfaces",
 	"parent",
+	"volatile.wireguard.ipv4.address",
+	"volatile.wireguard.public_key",
 }
@@ -77,6 +77,33 @@ func TestCreatePendingNetwork(t *testing.T) {
 	assert.Equal(t, map[string]string{"bridge.external_interfaces": "egg"}, configs["none"])
 }
 
+// The GetNetworkMembersConfig method returns the node-specific config of every member.
+func TestGetNetworkMembersConfig(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	nodeID, err := tx.CreateNode("buzz", "1.2.3.4:666")
+	require.NoError(t, err)
+
+	config := map[string]string{"volatile.wireguard.public_key": "foo"}
+	err = tx.CreatePendingNetwork("none", project.Default, "network1", db.NetworkTypeWireguard, config)
+	require.NoError(t, err)
+
+	config = map[string]string{"volatile.wireguard.public_key": "bar"}
+	err = tx.CreatePendingNetwork("buzz", project.Default, "network1", db.NetworkTypeWireguard, config)
+	require.NoError(t, err)
+
+	networkID, err := tx.GetNetworkID(project.Default, "network1")
+	require.NoError(t, err)
+
+	configs, err := tx.GetNetworkMembersConfig(networkID)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]map[string]string{
+		1:      {"volatile.wireguard.public_key": "foo"},
+		nodeID: {"volatile.wireguard.public_key": "bar"},
+	}, configs)
+}
+
 // If an entry for the given network and node already exists, an error is
 // returned.
 func TestNetworksCreatePending_AlreadyDefined(t *testing.T) {
@@ -67,6 +67,12 @@ const (
 	ClusterMemberRestore
 	CertificateAddToken
 	RemoveOrphanedOperations
+	StoragePoolCheck
+	StoragePoolDeduplicate
+	SnapshotsRetentionPrune
+	CustomVolumeReplicate
+	InstanceNICCapture
+	InstanceScheduledActions
 )
 
 // Description return a human-readable description of the operation type.
@@ -182,6 +188,18 @@ func (t Type) Description() string {
 		return "Restoring cluster member"
 	case RemoveOrphanedOperations:
 		return "Remove orphaned operations"
+	case StoragePoolCheck:
+		return "Checking storage pool"
+	case StoragePoolDeduplicate:
+		return "Deduplicating storage pool"
+	case SnapshotsRetentionPrune:
+		return "Pruning snapshots by retention policy"
+	case CustomVolumeReplicate:
+		return "Replicating custom volumes"
+	case InstanceNICCapture:
+		return "Capturing instance NIC traffic"
+	case InstanceScheduledActions:
+		return "Running scheduled instance actions"
 	default:
 		return "Executing operation"
 	}
@@ -200,6 +218,8 @@ func (t Type) Permission() string {
 		return "operate-containers"
 	case ConsoleShow:
 		return "operate-containers"
+	case InstanceNICCapture:
+		return "operate-containers"
 	case InstanceFreeze:
 		return "operate-containers"
 	case InstanceUnfreeze:
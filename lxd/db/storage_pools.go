@@ -140,6 +140,22 @@ SELECT storage_volumes.name, storage_volumes.type, projects.name, storage_volume
 		}
 	}
 
+	// Get all the storage buckets on this node.
+	bucketFilter := StorageBucketFilter{PoolID: &id}
+	buckets, err := c.GetStoragePoolBuckets(!allNodes, bucketFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bucket := range buckets {
+		u := api.NewURL().Path("1.0", "storage-pools", name, "buckets", bucket.Name).Project(bucket.Project)
+		if allNodes && bucket.Location != "" {
+			u.Target(bucket.Location)
+		}
+
+		usedby = append(usedby, u.String())
+	}
+
 	// Get all the profiles using the storage pool.
 	profiles, err := cluster.GetProfiles(context.TODO(), c.tx, cluster.ProfileFilter{})
 	if err != nil {
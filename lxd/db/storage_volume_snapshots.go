@@ -45,8 +45,8 @@ func (c *Cluster) CreateStorageVolumeSnapshot(project, volumeName, volumeDescrip
 		}
 
 		_, err = tx.tx.Exec(
-			"INSERT INTO storage_volumes_snapshots (id, storage_volume_id, name, description, expiry_date) VALUES (?, ?, ?, ?, ?)",
-			volumeID, parentID, snapshotName, volumeDescription, expiryDate)
+			"INSERT INTO storage_volumes_snapshots (id, storage_volume_id, name, description, expiry_date, creation_date) VALUES (?, ?, ?, ?, ?, ?)",
+			volumeID, parentID, snapshotName, volumeDescription, expiryDate, time.Now().UTC())
 		if err != nil {
 			return fmt.Errorf("Insert volume snapshot: %w", err)
 		}
@@ -212,3 +212,45 @@ func storageVolumeSnapshotExpiryDateUpdate(tx *sql.Tx, volumeID int64, expiryDat
 	_, err := tx.Exec(stmt, expiryDate, volumeID)
 	return err
 }
+
+// GetStorageVolumeSnapshotsWithType returns all snapshots of the storage volumes of the given type across all
+// projects and cluster members, indexed by the ID of their parent volume.
+// The creation date is left to zero for snapshots that were created before it was recorded.
+func (c *ClusterTx) GetStorageVolumeSnapshotsWithType(volumeType int) (map[int64][]StorageVolumeArgs, error) {
+	stmt := `
+SELECT storage_volumes_snapshots.id, storage_volumes.id, storage_volumes.name, storage_volumes_snapshots.name, storage_volumes_snapshots.creation_date, storage_volumes_snapshots.expiry_date, storage_pools.name, projects.name, IFNULL(storage_volumes.node_id, -1)
+FROM storage_volumes_snapshots
+JOIN storage_volumes ON storage_volumes_snapshots.storage_volume_id = storage_volumes.id
+JOIN storage_pools ON storage_volumes.storage_pool_id = storage_pools.id
+JOIN projects ON storage_volumes.project_id = projects.id
+WHERE storage_volumes.type = ?
+ORDER BY storage_volumes_snapshots.id
+`
+
+	result := map[int64][]StorageVolumeArgs{}
+	err := c.QueryScan(stmt, func(scan func(dest ...any) error) error {
+		snap := StorageVolumeArgs{Type: volumeType, Snapshot: true}
+		var volID int64
+		var volName string
+		var snapName string
+		var creationTime sql.NullTime
+		var expiryTime sql.NullTime
+
+		err := scan(&snap.ID, &volID, &volName, &snapName, &creationTime, &expiryTime, &snap.PoolName, &snap.ProjectName, &snap.NodeID)
+		if err != nil {
+			return err
+		}
+
+		snap.Name = volName + shared.SnapshotDelimiter + snapName
+		snap.CreationDate = creationTime.Time // Convert nulls to zero.
+		snap.ExpiryDate = expiryTime.Time     // Convert nulls to zero.
+
+		result[volID] = append(result[volID], snap)
+		return nil
+	}, volumeType)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
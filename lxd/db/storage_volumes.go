@@ -74,6 +74,63 @@ WHERE storage_volumes.type = ?
 	return result, nil
 }
 
+// GetLocalStoragePoolVolumesAllProjects returns all of the volumes (excluding snapshots) of the given storage pool
+// across all projects that are located on the local member, or on all members for remote storage pools.
+func (c *ClusterTx) GetLocalStoragePoolVolumesAllProjects(poolID int64) ([]StorageVolumeArgs, error) {
+	remoteDrivers := StorageRemoteDriverNames()
+
+	stmt := fmt.Sprintf(`
+SELECT storage_volumes.id, storage_volumes.name, storage_volumes.description, storage_volumes.type, storage_volumes.content_type, projects.name, IFNULL(storage_volumes.node_id, -1)
+FROM storage_volumes
+JOIN storage_pools ON storage_pools.id = storage_volumes.storage_pool_id
+JOIN projects ON projects.id = storage_volumes.project_id
+WHERE storage_volumes.storage_pool_id = ?
+AND (storage_volumes.node_id = ? OR storage_volumes.node_id IS NULL AND storage_pools.driver IN %s)
+ORDER BY projects.name, storage_volumes.type, storage_volumes.name
+`, query.Params(len(remoteDrivers)))
+
+	args := []any{poolID, c.nodeID}
+	for _, driver := range remoteDrivers {
+		args = append(args, driver)
+	}
+
+	result := []StorageVolumeArgs{}
+	err := c.QueryScan(stmt, func(scan func(dest ...any) error) error {
+		entry := StorageVolumeArgs{PoolID: poolID}
+		var contentType int
+
+		err := scan(&entry.ID, &entry.Name, &entry.Description, &entry.Type, &contentType, &entry.ProjectName, &entry.NodeID)
+		if err != nil {
+			return err
+		}
+
+		entry.TypeName, err = storagePoolVolumeTypeToName(entry.Type)
+		if err != nil {
+			return err
+		}
+
+		entry.ContentType, err = storagePoolVolumeContentTypeToName(contentType)
+		if err != nil {
+			return err
+		}
+
+		result = append(result, entry)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result {
+		result[i].Config, err = c.storageVolumeConfigGet(result[i].ID, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
 // GetStoragePoolVolumeWithID returns the volume with the given ID.
 func (c *Cluster) GetStoragePoolVolumeWithID(volumeID int) (StorageVolumeArgs, error) {
 	var response StorageVolumeArgs
@@ -455,6 +512,28 @@ func (c *Cluster) UpdateStoragePoolVolume(project, volumeName string, volumeType
 	return err
 }
 
+// UpdateStoragePoolVolumeContentType updates the content type of the storage volume attached to a given storage pool.
+func (c *Cluster) UpdateStoragePoolVolumeContentType(project, volumeName string, volumeType int, poolID int64, contentType int) error {
+	volumeID, _, err := c.GetLocalStoragePoolVolume(project, volumeName, volumeType, poolID)
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(volumeName, shared.SnapshotDelimiter) {
+		return fmt.Errorf("Volume name may not be a snapshot")
+	}
+
+	err = c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		err := storagePoolVolumeReplicateIfCeph(tx.tx, volumeID, project, volumeName, volumeType, poolID, func(volumeID int64) error {
+			_, err := tx.tx.Exec("UPDATE storage_volumes SET content_type=? WHERE id=?", contentType, volumeID)
+			return err
+		})
+		return err
+	})
+
+	return err
+}
+
 // RemoveStoragePoolVolume deletes the storage volume attached to a given storage
 // pool.
 func (c *Cluster) RemoveStoragePoolVolume(project, volumeName string, volumeType int, poolID int64) error {
@@ -510,6 +589,43 @@ func (c *Cluster) RenameStoragePoolVolume(project, oldVolumeName string, newVolu
 	return err
 }
 
+// MoveStoragePoolVolume moves the record of a storage volume on the local member to a different storage pool,
+// replacing its config. The volume keeps its ID so any records referencing it are moved along with it.
+func (c *ClusterTx) MoveStoragePoolVolume(project string, volumeName string, volumeType int, poolID int64, newPoolID int64, volumeConfig map[string]string) error {
+	volumeID, err := c.storagePoolVolumeGetTypeID(project, volumeName, volumeType, poolID, c.nodeID)
+	if err != nil {
+		return err
+	}
+
+	driver, err := c.GetStoragePoolDriver(newPoolID)
+	if err != nil {
+		return err
+	}
+
+	// Volumes on remote storage pools aren't tied to a member.
+	var nodeID any = c.nodeID
+	if shared.StringInSlice(driver, StorageRemoteDriverNames()) {
+		nodeID = nil
+	}
+
+	_, err = c.tx.Exec("UPDATE storage_volumes SET storage_pool_id=?, node_id=? WHERE id=?", newPoolID, nodeID, volumeID)
+	if err != nil {
+		return err
+	}
+
+	err = storageVolumeConfigClear(c.tx, volumeID, false)
+	if err != nil {
+		return err
+	}
+
+	err = storageVolumeConfigAdd(c.tx, volumeID, volumeConfig, false)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // This a convenience to replicate a certain volume change to all nodes if the
 // underlying driver is ceph.
 func storagePoolVolumeReplicateIfCeph(tx *sql.Tx, volumeID int64, project, volumeName string, volumeType int, poolID int64, f func(int64) error) error {
@@ -46,6 +46,35 @@ func TestGetStorageVolumeNodes(t *testing.T) {
 	}, nodes)
 }
 
+// Only the volumes on the local member are returned, across all projects.
+func TestGetLocalStoragePoolVolumesAllProjects(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	nodeID1 := int64(1) // This is the default local member
+
+	nodeID2, err := tx.CreateNode("node2", "1.2.3.4:666")
+	require.NoError(t, err)
+
+	poolID := addPool(t, tx, "pool1")
+	addVolume(t, tx, poolID, nodeID1, "volume1")
+	addVolume(t, tx, poolID, nodeID2, "volume2")
+	addVolume(t, tx, poolID, nodeID1, "volume3")
+
+	volumes, err := tx.GetLocalStoragePoolVolumesAllProjects(poolID)
+	require.NoError(t, err)
+	require.Len(t, volumes, 2)
+
+	for i, name := range []string{"volume1", "volume3"} {
+		assert.Equal(t, name, volumes[i].Name)
+		assert.Equal(t, "default", volumes[i].ProjectName)
+		assert.Equal(t, db.StoragePoolVolumeTypeImage, volumes[i].Type)
+		assert.Equal(t, db.StoragePoolVolumeTypeNameImage, volumes[i].TypeName)
+		assert.Equal(t, db.StoragePoolVolumeContentTypeNameFS, volumes[i].ContentType)
+		assert.Equal(t, nodeID1, volumes[i].NodeID)
+	}
+}
+
 func addPool(t *testing.T, tx *db.ClusterTx, name string) int64 {
 	stmt := `
 INSERT INTO storage_pools(name, driver, description) VALUES (?, 'dir', '')
@@ -66,3 +95,32 @@ INSERT INTO storage_volumes(storage_pool_id, node_id, name, type, project_id, de
 	_, err := tx.Tx().Exec(stmt, poolID, nodeID, name)
 	require.NoError(t, err)
 }
+
+func TestMoveStoragePoolVolume(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	nodeID1 := int64(1) // This is the default local member
+
+	poolID1 := addPool(t, tx, "pool1")
+	poolID2 := addPool(t, tx, "pool2")
+	addVolume(t, tx, poolID1, nodeID1, "volume1")
+
+	err := tx.MoveStoragePoolVolume("default", "volume1", db.StoragePoolVolumeTypeImage, poolID1, poolID2, map[string]string{"size": "10GiB"})
+	require.NoError(t, err)
+
+	volumes, err := tx.GetLocalStoragePoolVolumesAllProjects(poolID1)
+	require.NoError(t, err)
+	assert.Len(t, volumes, 0)
+
+	volumes, err = tx.GetLocalStoragePoolVolumesAllProjects(poolID2)
+	require.NoError(t, err)
+	require.Len(t, volumes, 1)
+	assert.Equal(t, "volume1", volumes[0].Name)
+	assert.Equal(t, nodeID1, volumes[0].NodeID)
+	assert.Equal(t, map[string]string{"size": "10GiB"}, volumes[0].Config)
+
+	// Moving a volume that isn't on the pool fails.
+	err = tx.MoveStoragePoolVolume("default", "volume1", db.StoragePoolVolumeTypeImage, poolID1, poolID2, nil)
+	assert.Error(t, err)
+}
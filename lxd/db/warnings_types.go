@@ -56,6 +56,16 @@ const (
 	WarningInstanceTypeNotOperational
 	//WarningStoragePoolUnvailable represents a storage pool that cannot be initialized on the local server.
 	WarningStoragePoolUnvailable
+	// WarningScheduledSnapshotFailure represents the failure of a scheduled instance or volume snapshot
+	WarningScheduledSnapshotFailure
+	// WarningStorageVolumeReplicationFailure represents the failure of a scheduled custom volume replication
+	WarningStorageVolumeReplicationFailure
+	// WarningStoragePoolFillThreshold represents a storage pool filled beyond its fill_threshold.warning setting
+	WarningStoragePoolFillThreshold
+	// WarningStoragePoolFillCritical represents a storage pool filled beyond its fill_threshold.critical setting
+	WarningStoragePoolFillCritical
+	// WarningScheduledInstanceActionFailure represents the failure of a scheduled instance start, stop or restart
+	WarningScheduledInstanceActionFailure
 )
 
 // WarningTypeNames associates a warning code to its name.
@@ -85,6 +95,11 @@ var WarningTypeNames = map[WarningType]string{
 	WarningInstanceAutostartFailure:               "Failed to autostart instance",
 	WarningInstanceTypeNotOperational:             "Instance type not operational",
 	WarningStoragePoolUnvailable:                  "Storage pool unavailable",
+	WarningScheduledSnapshotFailure:               "Failed to create scheduled snapshot",
+	WarningStorageVolumeReplicationFailure:        "Failed to replicate storage volume",
+	WarningStoragePoolFillThreshold:               "Storage pool fill level above warning threshold",
+	WarningStoragePoolFillCritical:                "Storage pool fill level above critical threshold",
+	WarningScheduledInstanceActionFailure:         "Failed to run scheduled instance action",
 }
 
 // Severity returns the severity of the warning type.
@@ -140,6 +155,16 @@ func (t WarningType) Severity() WarningSeverity {
 		return WarningSeverityLow
 	case WarningStoragePoolUnvailable:
 		return WarningSeverityHigh
+	case WarningScheduledSnapshotFailure:
+		return WarningSeverityLow
+	case WarningStorageVolumeReplicationFailure:
+		return WarningSeverityModerate
+	case WarningStoragePoolFillThreshold:
+		return WarningSeverityModerate
+	case WarningStoragePoolFillCritical:
+		return WarningSeverityHigh
+	case WarningScheduledInstanceActionFailure:
+		return WarningSeverityModerate
 	}
 
 	return WarningSeverityLow
@@ -532,6 +532,11 @@ func bgpAddPrefix(d *deviceCommon, n network.Network, config map[string]string)
 		}
 	}
 
+	bgpNetwork, err := network.BGPPolicyNetwork(d.state, n)
+	if err != nil {
+		return err
+	}
+
 	// Add the prefixes.
 	bgpOwner := fmt.Sprintf("instance_%d_%s", d.inst.ID(), d.name)
 	if config["ipv4.routes.external"] != "" {
@@ -541,7 +546,7 @@ func bgpAddPrefix(d *deviceCommon, n network.Network, config map[string]string)
 				return err
 			}
 
-			err = d.state.BGP.AddPrefix(*prefixNet, nexthopV4, bgpOwner)
+			err = d.state.BGP.AddPrefix(*prefixNet, nexthopV4, bgpOwner, bgpNetwork)
 			if err != nil {
 				return err
 			}
@@ -555,7 +560,7 @@ func bgpAddPrefix(d *deviceCommon, n network.Network, config map[string]string)
 				return err
 			}
 
-			err = d.state.BGP.AddPrefix(*prefixNet, nexthopV6, bgpOwner)
+			err = d.state.BGP.AddPrefix(*prefixNet, nexthopV6, bgpOwner, bgpNetwork)
 			if err != nil {
 				return err
 			}
@@ -55,6 +55,14 @@ const DiskDirectIO = "directio"
 // DiskLoopBacked is used to indicate disk is backed onto a loop device.
 const DiskLoopBacked = "loop"
 
+// DiskIOLimitsMountOpt indicates the mount option prefix used to provide the IO limits of the disk to the QEMU
+// driver. The value is in the format "<read bps>:<write bps>:<read iops>:<write iops>".
+const DiskIOLimitsMountOpt = "ioLimits"
+
+// DiskIOGroupMountOpt indicates the mount option prefix used to provide the QEMU throttle group the IO limits of the
+// disk apply to, shared by the disks attaching the same custom volume.
+const DiskIOGroupMountOpt = "ioGroup"
+
 type diskBlockLimit struct {
 	readBps   int64
 	readIops  int64
@@ -661,9 +669,27 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 	revert := revert.New()
 	defer revert.Fail()
 
+	// Get the IO limits to apply to the disk.
+	limitsOpts, err := d.vmLimitsMountOpts(d.config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split the custom volume IO limits with this instance once started.
+	if d.config["pool"] != "" && !shared.IsRootDiskDevice(d.config) {
+		runConf.PostHooks = append(runConf.PostHooks, func() error {
+			err := d.updateVolumeAttachments()
+			if err != nil {
+				d.logger.Warn("Failed updating IO limits of other instances using the volume", logger.Ctx{"err": err})
+			}
+
+			return nil
+		})
+	}
+
 	if shared.IsRootDiskDevice(d.config) {
 		// Handle previous requests for setting new quotas.
-		err := d.applyDeferredQuota()
+		err = d.applyDeferredQuota()
 		if err != nil {
 			return nil, err
 		}
@@ -672,7 +698,7 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 			{
 				TargetPath: d.config["path"], // Indicator used that this is the root device.
 				DevName:    d.name,
-				Opts:       d.detectVMPoolMountOpts(),
+				Opts:       append(d.detectVMPoolMountOpts(), limitsOpts...),
 			},
 		}
 
@@ -715,11 +741,10 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 				{
 					DevPath: DiskGetRBDFormat(clusterName, userName, fields[0], fields[1]),
 					DevName: d.name,
+					Opts:    limitsOpts,
 				},
 			}
 		} else {
-			var err error
-
 			// Default to block device or image file passthrough first.
 			mount := deviceConfig.MountEntryItem{
 				DevPath: shared.HostPath(d.config["source"]),
@@ -740,6 +765,7 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 						{
 							DevPath: DiskGetRBDFormat(clusterName, userName, d.pool.ToAPI().Config["ceph.osd.pool_name"], d.config["source"]),
 							DevName: d.name,
+							Opts:    limitsOpts,
 						},
 					}
 
@@ -870,6 +896,7 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 
 				// Encode the file descriptor and original srcPath into the DevPath field.
 				mount.DevPath = fmt.Sprintf("%s:%d:%s", DiskFileDescriptorMountPrefix, f.Fd(), mount.DevPath)
+				mount.Opts = append(mount.Opts, limitsOpts...)
 			}
 
 			// Add successfully setup mount config to runConf.
@@ -893,15 +920,17 @@ func (d *disk) postStart() error {
 		return err
 	}
 
+	// Split the custom volume IO limits with this instance.
+	err = d.updateVolumeAttachments()
+	if err != nil {
+		d.logger.Warn("Failed updating IO limits of other instances using the volume", logger.Ctx{"err": err})
+	}
+
 	return nil
 }
 
 // Update applies configuration changes to a started device.
 func (d *disk) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
-	if d.inst.Type() == instancetype.VM && !shared.IsRootDiskDevice(d.config) {
-		return fmt.Errorf("Non-root disks not supported for VMs")
-	}
-
 	if shared.IsRootDiskDevice(d.config) {
 		// Make sure we have a valid root disk device (and only one).
 		expandedDevices := d.inst.ExpandedDevices()
@@ -958,21 +987,44 @@ func (d *disk) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
 		}
 	}
 
-	// Only apply IO limits if instance is container and is running.
-	if isRunning && d.inst.Type() == instancetype.Container {
-		runConf := deviceConfig.RunConfig{}
-		err := d.generateLimits(&runConf)
+	// Only apply IO limits if instance is running.
+	if !isRunning {
+		return nil
+	}
+
+	// Apply the IO limits in place, this also clears removed limits and picks up changes to the limits of the
+	// custom volume attached by the disk.
+	runConf := deviceConfig.RunConfig{}
+
+	if d.inst.Type() == instancetype.VM {
+		if d.config["source"] == diskSourceCloudInit {
+			return nil
+		}
+
+		limitsOpts, err := d.vmLimitsMountOpts(d.config)
 		if err != nil {
 			return err
 		}
 
-		err = d.inst.DeviceEventHandler(&runConf)
+		// Always pass the limits, even if empty, so that removed ones are cleared.
+		if len(limitsOpts) == 0 {
+			limitsOpts = []string{fmt.Sprintf("%s=0:0:0:0", DiskIOLimitsMountOpt)}
+		}
+
+		runConf.Mounts = []deviceConfig.MountEntryItem{
+			{
+				DevName: d.name,
+				Opts:    limitsOpts,
+			},
+		}
+	} else {
+		err := d.generateLimits(&runConf)
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return d.inst.DeviceEventHandler(&runConf)
 }
 
 // applyDeferredQuota attempts to apply the deferred quota specified in the volatile "apply_quota" key if set.
@@ -1050,8 +1102,14 @@ func (d *disk) generateLimits(runConf *deviceConfig.RunConfig) error {
 			continue
 		}
 
-		if dev["limits.read"] != "" || dev["limits.write"] != "" || dev["limits.max"] != "" {
+		limits, err := d.getLimits(dev)
+		if err != nil {
+			return err
+		}
+
+		if limits != (diskBlockLimit{}) {
 			hasDiskLimits = true
+			break
 		}
 	}
 
@@ -1609,6 +1667,12 @@ func (d *disk) postStop() error {
 		return err
 	}
 
+	// Give back the share of the custom volume IO limits used by this instance.
+	err = d.updateVolumeAttachments()
+	if err != nil {
+		d.logger.Warn("Failed updating IO limits of other instances using the volume", logger.Ctx{"err": err})
+	}
+
 	// Check if pool-specific action should be taken to unmount custom volume disks.
 	if d.config["pool"] != "" && d.config["path"] != "/" {
 		// Only custom volumes can be attached currently.
@@ -1671,14 +1735,8 @@ func (d *disk) getDiskLimits() (map[string]diskBlockLimit, error) {
 			continue
 		}
 
-		// Apply max limit
-		if dev["limits.max"] != "" {
-			dev["limits.read"] = dev["limits.max"]
-			dev["limits.write"] = dev["limits.max"]
-		}
-
-		// Parse the user input
-		readBps, readIops, writeBps, writeIops, err := d.parseDiskLimit(dev["limits.read"], dev["limits.write"])
+		// Get the device and volume limits
+		limits, err := d.getLimits(dev)
 		if err != nil {
 			return nil, err
 		}
@@ -1701,7 +1759,7 @@ func (d *disk) getDiskLimits() (map[string]diskBlockLimit, error) {
 		// Get the backing block devices (major:minor)
 		blocks, err := d.getParentBlocks(source)
 		if err != nil {
-			if readBps == 0 && readIops == 0 && writeBps == 0 && writeIops == 0 {
+			if limits == (diskBlockLimit{}) {
 				// If the device doesn't exist, there is no limit to clear so ignore the failure
 				continue
 			} else {
@@ -1709,7 +1767,6 @@ func (d *disk) getDiskLimits() (map[string]diskBlockLimit, error) {
 			}
 		}
 
-		device := diskBlockLimit{readBps: readBps, readIops: readIops, writeBps: writeBps, writeIops: writeIops}
 		for _, block := range blocks {
 			blockStr := ""
 
@@ -1732,7 +1789,7 @@ func (d *disk) getDiskLimits() (map[string]diskBlockLimit, error) {
 			if blockLimits[blockStr] == nil {
 				blockLimits[blockStr] = []diskBlockLimit{}
 			}
-			blockLimits[blockStr] = append(blockLimits[blockStr], device)
+			blockLimits[blockStr] = append(blockLimits[blockStr], limits)
 		}
 	}
 
@@ -1825,6 +1882,274 @@ func (d *disk) parseDiskLimit(readSpeed string, writeSpeed string) (int64, int64
 	return readBps, readIops, writeBps, writeIops, nil
 }
 
+// getLimits returns the IO limits to apply to the disk device with the supplied config. These combine the limits
+// of the device itself with those of the custom volume it attaches (if any), keeping the lower of each.
+func (d *disk) getLimits(devConfig deviceConfig.Device) (diskBlockLimit, error) {
+	readSpeed := devConfig["limits.read"]
+	writeSpeed := devConfig["limits.write"]
+
+	// Apply max limit
+	if devConfig["limits.max"] != "" {
+		readSpeed = devConfig["limits.max"]
+		writeSpeed = devConfig["limits.max"]
+	}
+
+	// Parse the user input
+	readBps, readIops, writeBps, writeIops, err := d.parseDiskLimit(readSpeed, writeSpeed)
+	if err != nil {
+		return diskBlockLimit{}, err
+	}
+
+	volLimits, err := d.getVolumeLimits(devConfig)
+	if err != nil {
+		return diskBlockLimit{}, err
+	}
+
+	lowest := func(devLimit int64, volLimit int64) int64 {
+		if devLimit == 0 || (volLimit > 0 && volLimit < devLimit) {
+			return volLimit
+		}
+
+		return devLimit
+	}
+
+	limits := diskBlockLimit{
+		readBps:   lowest(readBps, volLimits.readBps),
+		readIops:  lowest(readIops, volLimits.readIops),
+		writeBps:  lowest(writeBps, volLimits.writeBps),
+		writeIops: lowest(writeIops, volLimits.writeIops),
+	}
+
+	return limits, nil
+}
+
+// getVolumeLimits returns the IO limits set on the custom volume attached by the disk device with the supplied
+// config. An empty set of limits is returned if the device doesn't attach a custom volume.
+// The volume limits apply to the volume as a whole, so they are split evenly between the instances attaching it.
+func (d *disk) getVolumeLimits(devConfig deviceConfig.Device) (diskBlockLimit, error) {
+	limits := diskBlockLimit{}
+
+	storageProjectName, vol, err := d.loadCustomVolume(devConfig)
+	if err != nil || vol == nil {
+		return limits, err
+	}
+
+	for key, limit := range map[string]*int64{"limits.bandwidth.read": &limits.readBps, "limits.bandwidth.write": &limits.writeBps} {
+		if vol.Config[key] == "" {
+			continue
+		}
+
+		*limit, err = units.ParseByteSizeString(vol.Config[key])
+		if err != nil {
+			return limits, fmt.Errorf("Invalid %q on storage volume %q: %w", key, vol.Name, err)
+		}
+	}
+
+	for key, limit := range map[string]*int64{"limits.iops.read": &limits.readIops, "limits.iops.write": &limits.writeIops} {
+		if vol.Config[key] == "" {
+			continue
+		}
+
+		*limit, err = strconv.ParseInt(vol.Config[key], 10, 64)
+		if err != nil {
+			return limits, fmt.Errorf("Invalid %q on storage volume %q: %w", key, vol.Name, err)
+		}
+	}
+
+	if limits == (diskBlockLimit{}) {
+		return limits, nil
+	}
+
+	attachments, err := d.volumeAttachments(devConfig["pool"], storageProjectName, vol)
+	if err != nil {
+		return limits, err
+	}
+
+	for _, limit := range []*int64{&limits.readBps, &limits.writeBps, &limits.readIops, &limits.writeIops} {
+		if *limit == 0 {
+			continue
+		}
+
+		// Round down so the total never exceeds the volume limit, but never clear the limit.
+		*limit = *limit / attachments
+		if *limit == 0 {
+			*limit = 1
+		}
+	}
+
+	return limits, nil
+}
+
+// loadCustomVolume returns the storage project and the custom volume attached by the disk device with the
+// supplied config. A nil volume is returned if the device doesn't attach a custom volume.
+func (d *disk) loadCustomVolume(devConfig deviceConfig.Device) (string, *api.StorageVolume, error) {
+	if devConfig["pool"] == "" || devConfig["source"] == "" || shared.IsRootDiskDevice(devConfig) {
+		return "", nil, nil
+	}
+
+	volumeName := devConfig["source"]
+	fields := strings.SplitN(volumeName, "/", 2)
+	if len(fields) == 2 {
+		if fields[0] != db.StoragePoolVolumeTypeNameCustom {
+			return "", nil, nil
+		}
+
+		volumeName = fields[1]
+	}
+
+	storageProjectName, err := project.StorageVolumeProject(d.state.DB.Cluster, d.inst.Project(), db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return "", nil, err
+	}
+
+	poolID, err := d.state.DB.Cluster.GetStoragePoolID(devConfig["pool"])
+	if err != nil {
+		return "", nil, err
+	}
+
+	_, vol, err := d.state.DB.Cluster.GetLocalStoragePoolVolume(storageProjectName, volumeName, db.StoragePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return "", nil, fmt.Errorf("Failed loading storage volume %q: %w", volumeName, err)
+	}
+
+	return storageProjectName, vol, nil
+}
+
+// volumeAttachments returns the number of instances sharing the IO limits of the custom volume. This is the
+// instance itself, the other running instances of this member attaching the volume and all instances of other
+// members attaching it (whose running state isn't known here).
+func (d *disk) volumeAttachments(poolName string, projectName string, vol *api.StorageVolume) (int64, error) {
+	attachments := int64(1)
+	instances := []db.InstanceArgs{}
+	instProfiles := [][]api.Profile{}
+
+	err := storagePools.VolumeUsedByInstanceDevices(d.state, poolName, projectName, vol, true, func(dbInst db.InstanceArgs, project api.Project, profiles []api.Profile, usedByDevices []string) error {
+		if dbInst.Project == d.inst.Project() && dbInst.Name == d.inst.Name() {
+			return nil
+		}
+
+		if dbInst.Node != d.state.ServerName {
+			attachments++
+			return nil
+		}
+
+		instances = append(instances, dbInst)
+		instProfiles = append(instProfiles, profiles)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, dbInst := range instances {
+		inst, err := instance.Load(d.state, dbInst, instProfiles[i])
+		if err != nil {
+			return 0, err
+		}
+
+		if inst.IsRunning() {
+			attachments++
+		}
+	}
+
+	return attachments, nil
+}
+
+// updateVolumeAttachments re-applies the IO limits of the other running instances attaching the same custom
+// volume as the disk, so that the volume limits are split again once the disk has been started or stopped.
+func (d *disk) updateVolumeAttachments() error {
+	storageProjectName, vol, err := d.loadCustomVolume(d.config)
+	if err != nil || vol == nil {
+		return err
+	}
+
+	hasLimits := false
+	for _, key := range []string{"limits.bandwidth.read", "limits.bandwidth.write", "limits.iops.read", "limits.iops.write"} {
+		if vol.Config[key] != "" {
+			hasLimits = true
+			break
+		}
+	}
+
+	if !hasLimits {
+		return nil
+	}
+
+	instances := []db.InstanceArgs{}
+	instProfiles := [][]api.Profile{}
+	usedBy := [][]string{}
+
+	err = storagePools.VolumeUsedByInstanceDevices(d.state, d.config["pool"], storageProjectName, vol, true, func(dbInst db.InstanceArgs, project api.Project, profiles []api.Profile, usedByDevices []string) error {
+		if dbInst.Node != d.state.ServerName || (dbInst.Project == d.inst.Project() && dbInst.Name == d.inst.Name()) {
+			return nil
+		}
+
+		instances = append(instances, dbInst)
+		instProfiles = append(instProfiles, profiles)
+		usedBy = append(usedBy, usedByDevices)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, dbInst := range instances {
+		inst, err := instance.Load(d.state, dbInst, instProfiles[i])
+		if err != nil {
+			return err
+		}
+
+		if !inst.IsRunning() {
+			continue
+		}
+
+		for _, devName := range usedBy[i] {
+			err = inst.ReloadDevice(devName)
+			if err != nil {
+				d.logger.Warn("Failed applying IO limits to instance device", logger.Ctx{"instance": inst.Name(), "project": inst.Project(), "device": devName, "err": err})
+			}
+		}
+	}
+
+	return nil
+}
+
+// vmLimitsMountOpts returns the mount options used to pass the disk IO limits to the QEMU driver, if any.
+// The disks attaching a custom volume with IO limits share a throttle group named after the volume so that the
+// volume limits apply to all of them together. The group then uses the limits of the disk it was last applied from.
+func (d *disk) vmLimitsMountOpts(devConfig deviceConfig.Device) ([]string, error) {
+	limits, err := d.getLimits(devConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if limits == (diskBlockLimit{}) {
+		return nil, nil
+	}
+
+	opts := []string{fmt.Sprintf("%s=%d:%d:%d:%d", DiskIOLimitsMountOpt, limits.readBps, limits.writeBps, limits.readIops, limits.writeIops)}
+
+	volLimits, err := d.getVolumeLimits(devConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if volLimits != (diskBlockLimit{}) {
+		storageProjectName, err := project.StorageVolumeProject(d.state.DB.Cluster, d.inst.Project(), db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return nil, err
+		}
+
+		volumeName := strings.TrimPrefix(devConfig["source"], fmt.Sprintf("%s/", db.StoragePoolVolumeTypeNameCustom))
+		group := fmt.Sprintf("lxd_%s_%s", devConfig["pool"], project.StorageVolume(storageProjectName, volumeName))
+		opts = append(opts, fmt.Sprintf("%s=%s", DiskIOGroupMountOpt, filesystem.PathNameEncode(group)))
+	}
+
+	return opts, nil
+}
+
 func (d *disk) getParentBlocks(path string) ([]string, error) {
 	var devices []string
 	var dev []string
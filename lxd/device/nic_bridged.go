@@ -41,6 +41,11 @@ type bridgeNetwork interface {
 	UsesDNSMasq() bool
 }
 
+type bridgeQoSNetwork interface {
+	QoSNICSetup(hwaddr string, priority string) error
+	QoSNICClear(hwaddr string) error
+}
+
 type nicBridged struct {
 	deviceCommon
 
@@ -74,6 +79,7 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		"limits.ingress",
 		"limits.egress",
 		"limits.max",
+		"limits.priority",
 		"ipv4.address",
 		"ipv6.address",
 		"ipv4.routes",
@@ -96,8 +102,8 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 			return fmt.Errorf("Specified network is not fully created")
 		}
 
-		if n.Type() != "bridge" {
-			return fmt.Errorf("Specified network must be of type bridge")
+		if !shared.StringInSlice(n.Type(), []string{"bridge", "wireguard"}) {
+			return fmt.Errorf("Specified network must be of type bridge or wireguard")
 		}
 
 		netConfig := n.Config()
@@ -117,7 +123,12 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 				return fmt.Errorf("Device IP address %q not within network %q subnet", d.config["ipv4.address"], n.Name())
 			}
 
+			// WireGuard networks use the gateway address of the member's subnet of the overlay.
 			parentAddress := netConfig["ipv4.address"]
+			if n.Type() == "wireguard" {
+				parentAddress = netConfig["volatile.wireguard.ipv4.address"]
+			}
+
 			if shared.StringInSlice(parentAddress, []string{"", "none"}) {
 				return nil
 			}
@@ -344,6 +355,11 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		return validate.IsNetworkAddressV6(value)
 	}
 
+	// Add DHCP options validation rules.
+	for k, v := range network.DHCPv4OptionsValidationRules() {
+		rules[k] = v
+	}
+
 	// Now run normal validation.
 	err := d.config.Validate(rules)
 	if err != nil {
@@ -469,7 +485,14 @@ func (d *nicBridged) UpdatableFields(oldDevice Type) []string {
 		return []string{}
 	}
 
-	return []string{"limits.ingress", "limits.egress", "limits.max", "ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering"}
+	fields := []string{"limits.ingress", "limits.egress", "limits.max", "limits.priority", "ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering"}
+
+	// DHCP options are applied by rebuilding the dnsmasq entry.
+	for k := range network.DHCPv4OptionsValidationRules() {
+		fields = append(fields, k)
+	}
+
+	return fields
 }
 
 // Add is run when a device is added to a non-snapshot instance whether or not the instance is running.
@@ -560,6 +583,12 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 		return nil, err
 	}
 
+	// Classify the traffic into the QoS class of the network matching the NIC's priority.
+	err = d.setupQoS()
+	if err != nil {
+		return nil, err
+	}
+
 	// Disable IPv6 on host-side veth interface (prevents host-side interface getting link-local address)
 	// which isn't needed because the host-side interface is connected to a bridge.
 	err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/disable_ipv6", saveData["host_name"]), "1")
@@ -625,11 +654,16 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 				return nil, fmt.Errorf("Failed loading network forwards: %w", err)
 			}
 
-			// If br_netfilter is enabled and bridge has forwards, we enable hairpin mode on NIC's
-			// bridge port in case any of the forwards target this NIC and the instance attempts to
-			// connect to the forward's listener. Without hairpin mode on the target of the forward
-			// will not be able to connect to the listener.
-			if len(listenAddresses) > 0 {
+			loadBalancerListenAddresses, err := d.state.DB.Cluster.GetNetworkLoadBalancerListenAddresses(d.network.ID(), true)
+			if err != nil {
+				return nil, fmt.Errorf("Failed loading network load balancers: %w", err)
+			}
+
+			// If br_netfilter is enabled and bridge has forwards or load balancers, we enable hairpin
+			// mode on NIC's bridge port in case any of the forwards target this NIC and the instance
+			// attempts to connect to the forward's listener. Without hairpin mode on the target of the
+			// forward will not be able to connect to the listener.
+			if len(listenAddresses) > 0 || len(loadBalancerListenAddresses) > 0 {
 				link := &ip.Link{Name: saveData["host_name"]}
 				err = link.BridgeLinkSetHairpin(true)
 				if err != nil {
@@ -740,6 +774,11 @@ func (d *nicBridged) Update(oldDevices deviceConfig.Devices, isRunning bool) err
 			return err
 		}
 
+		err = d.setupQoS()
+		if err != nil {
+			return err
+		}
+
 		// Apply and host-side network filters (uses enriched host_name from networkVethFillFromVolatile).
 		r, err := d.setupHostFilters(oldConfig)
 		if err != nil {
@@ -838,6 +877,15 @@ func (d *nicBridged) postStop() error {
 		d.removeFilters(d.config)
 	}
 
+	// Remove the QoS classification of the NIC's traffic.
+	qosNet, ok := d.network.(bridgeQoSNetwork)
+	if ok && d.network.IsManaged() && d.config["hwaddr"] != "" {
+		err := qosNet.QoSNICClear(d.config["hwaddr"])
+		if err != nil {
+			return fmt.Errorf("Failed removing QoS classification: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -870,6 +918,21 @@ func (d *nicBridged) Remove() error {
 	return nil
 }
 
+// setupQoS classifies the NIC's traffic into the QoS class of the managed network matching limits.priority.
+func (d *nicBridged) setupQoS() error {
+	qosNet, ok := d.network.(bridgeQoSNetwork)
+	if !ok || !d.network.IsManaged() || d.config["hwaddr"] == "" {
+		return nil
+	}
+
+	err := qosNet.QoSNICSetup(d.config["hwaddr"], d.config["limits.priority"])
+	if err != nil {
+		return fmt.Errorf("Failed setting up QoS classification: %w", err)
+	}
+
+	return nil
+}
+
 // rebuildDnsmasqEntry rebuilds the dnsmasq host entry if connected to a LXD managed network and reloads dnsmasq.
 func (d *nicBridged) rebuildDnsmasqEntry() error {
 	// Rebuild dnsmasq config if a bridged device has changed and parent is a managed network using dnsmasq.
@@ -918,7 +981,7 @@ func (d *nicBridged) rebuildDnsmasqEntry() error {
 		}
 	}
 
-	err = dnsmasq.UpdateStaticEntry(d.config["parent"], d.inst.Project(), d.inst.Name(), d.Name(), netConfig, d.config["hwaddr"], ipv4Address, ipv6Address)
+	err = dnsmasq.UpdateStaticEntry(d.config["parent"], d.inst.Project(), d.inst.Name(), d.Name(), netConfig, d.config["hwaddr"], ipv4Address, ipv6Address, network.DHCPv4OptionsDnsmasq(d.config))
 	if err != nil {
 		return err
 	}
@@ -1089,6 +1152,7 @@ func (d *nicBridged) setFilters() (err error) {
 			DeviceName:  d.Name(),
 			HostMAC:     mac,
 			Network:     d.network,
+			DHCPOptions: network.DHCPv4OptionsDnsmasq(config),
 		}
 
 		err = dhcpalloc.AllocateTask(opts, func(t *dhcpalloc.Transaction) error {
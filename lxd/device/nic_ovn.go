@@ -185,6 +185,11 @@ func (d *nicOVN) validateConfig(instConf instance.ConfigReader) error {
 
 	rules := nicValidationRules(requiredFields, optionalFields, instConf)
 
+	// Add DHCP options validation rules.
+	for k, v := range network.DHCPv4OptionsValidationRules() {
+		rules[k] = v
+	}
+
 	// Now run normal validation.
 	err = d.config.Validate(rules)
 	if err != nil {
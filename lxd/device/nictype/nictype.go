@@ -31,7 +31,7 @@ func NICType(s *state.State, deviceProjectName string, d deviceConfig.Device) (s
 
 			var nicType string
 			switch netInfo.Type {
-			case "bridge":
+			case "bridge", "wireguard":
 				nicType = "bridged"
 			case "macvlan":
 				nicType = "macvlan"
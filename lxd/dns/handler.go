@@ -28,6 +28,12 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		return
 	}
 
+	// Handle dynamic updates separately.
+	if r.Opcode == dns.OpcodeUpdate {
+		d.serveUpdate(w, r)
+		return
+	}
+
 	// Only allow a single request.
 	if len(r.Question) != 1 {
 		m := new(dns.Msg)
@@ -39,14 +45,9 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		return
 	}
 
-	// Check that it's a supported request type.
+	// Answer regular queries from the zone content.
 	if r.Question[0].Qtype != dns.TypeAXFR && r.Question[0].Qtype != dns.TypeIXFR && r.Question[0].Qtype != dns.TypeSOA {
-		m := new(dns.Msg)
-		m.SetRcode(r, dns.RcodeNotImplemented)
-		err := w.WriteMsg(m)
-		if err != nil {
-			logger.Error("Unable to write message", logger.Ctx{"err": err})
-		}
+		d.serveQuery(w, r)
 		return
 	}
 
@@ -71,6 +72,12 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	// Load the zone.
 	zone, err := d.server.zoneRetriever(name, r.Question[0].Qtype != dns.TypeSOA)
 	if err != nil {
+		// SOA queries for names within a zone are answered from the zone content.
+		if r.Question[0].Qtype == dns.TypeSOA {
+			d.serveQuery(w, r)
+			return
+		}
+
 		// On failure, return NXDOMAIN.
 		m := new(dns.Msg)
 		m.SetRcode(r, dns.RcodeNameError)
@@ -83,6 +90,12 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 
 	// Check access.
 	if !d.isAllowed(zone.Info, ip, r.IsTsig(), w.TsigStatus() == nil) {
+		// SOA queries from other clients are answered like any other query.
+		if r.Question[0].Qtype == dns.TypeSOA {
+			d.serveQuery(w, r)
+			return
+		}
+
 		// On auth failure, return NXDOMAIN to avoid information leaks.
 		m := new(dns.Msg)
 		m.SetRcode(r, dns.RcodeNameError)
@@ -128,6 +141,13 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 }
 
 func (d *dnsHandler) isAllowed(zone api.NetworkZone, ip string, tsig *dns.TSIG, tsigStatus bool) bool {
+	_, ok := d.findPeer(zone, ip, tsig, tsigStatus, false)
+	return ok
+}
+
+// findPeer returns the name of the trusted peer matching the request.
+// If requireKey is true, only peers authenticated through their TSIG key are considered.
+func (d *dnsHandler) findPeer(zone api.NetworkZone, ip string, tsig *dns.TSIG, tsigStatus bool, requireKey bool) (string, bool) {
 	type peer struct {
 		address string
 		key     string
@@ -170,6 +190,11 @@ func (d *dnsHandler) isAllowed(zone api.NetworkZone, ip string, tsig *dns.TSIG,
 			continue
 		}
 
+		if peer.key == "" && requireKey {
+			// Unauthenticated peer.
+			continue
+		}
+
 		if peer.key != "" && (tsig == nil || !tsigStatus) {
 			// Missing or invalid TSIG.
 			continue
@@ -181,8 +206,8 @@ func (d *dnsHandler) isAllowed(zone api.NetworkZone, ip string, tsig *dns.TSIG,
 		}
 
 		// We have a trusted peer.
-		return true
+		return peerName, true
 	}
 
-	return false
+	return "", false
 }
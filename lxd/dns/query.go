@@ -0,0 +1,334 @@
+package dns
+
+import (
+	"container/list"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// zoneCacheTTL is how long a zone is kept in the query cache.
+const zoneCacheTTL = 5 * time.Second
+
+// zoneCacheSize is the maximum number of zones (including recently deleted ones) kept in the query cache.
+const zoneCacheSize = 1024
+
+// zoneCacheEntry represents a cached zone used to answer queries.
+type zoneCacheEntry struct {
+	name    string
+	info    *api.NetworkZone // nil if no zone exists with that name.
+	soa     *dns.SOA
+	records []dns.RR
+	expiry  time.Time
+}
+
+// zoneCache is a cache of zones bounded in size, evicting the least recently used zones first.
+type zoneCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List // Most recently used first.
+}
+
+// newZoneCache returns a new zone cache holding up to size zones.
+func newZoneCache(size int) *zoneCache {
+	return &zoneCache{
+		size:    size,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// get returns the cached zone with the given name or nil if not cached or expired.
+func (c *zoneCache) get(name string, now time.Time) *zoneCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem := c.entries[name]
+	if elem == nil {
+		return nil
+	}
+
+	entry, _ := elem.Value.(*zoneCacheEntry)
+	if !now.Before(entry.expiry) {
+		c.lru.Remove(elem)
+		delete(c.entries, name)
+		return nil
+	}
+
+	c.lru.MoveToFront(elem)
+
+	return entry
+}
+
+// add caches a zone, evicting the least recently used zones if the cache is full.
+func (c *zoneCache) add(entry *zoneCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem := c.entries[entry.name]
+	if elem != nil {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	for c.lru.Len() >= c.size {
+		oldest := c.lru.Back()
+		oldestEntry, _ := oldest.Value.(*zoneCacheEntry)
+		c.lru.Remove(oldest)
+		delete(c.entries, oldestEntry.name)
+	}
+
+	c.entries[entry.name] = c.lru.PushFront(entry)
+}
+
+// remove removes a zone from the cache.
+func (c *zoneCache) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem := c.entries[name]
+	if elem != nil {
+		c.lru.Remove(elem)
+		delete(c.entries, name)
+	}
+}
+
+// zoneNameCache holds the names of all zones, so that the zone a query belongs to can be found without looking up
+// each of its parent names.
+type zoneNameCache struct {
+	mu     sync.Mutex
+	names  map[string]bool
+	expiry time.Time
+}
+
+// queryZoneNames returns the (possibly cached) names of all zones.
+// The returned map must not be modified.
+func (s *Server) queryZoneNames() (map[string]bool, error) {
+	s.zoneNames.mu.Lock()
+	defer s.zoneNames.mu.Unlock()
+
+	now := time.Now()
+	if s.zoneNames.names != nil && now.Before(s.zoneNames.expiry) {
+		return s.zoneNames.names, nil
+	}
+
+	names := map[string]bool{}
+	if s.zoneLister != nil {
+		zoneNames, err := s.zoneLister()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range zoneNames {
+			names[strings.ToLower(strings.TrimSuffix(name, "."))] = true
+		}
+	}
+
+	s.zoneNames.names = names
+	s.zoneNames.expiry = now.Add(zoneCacheTTL)
+
+	return names, nil
+}
+
+// queryZone returns the (possibly cached) zone with the given name.
+// The records are only loaded for zones which have query answering enabled.
+func (s *Server) queryZone(name string) (*zoneCacheEntry, error) {
+	entry := s.cache.get(name, time.Now())
+	if entry != nil {
+		return entry, nil
+	}
+
+	entry = &zoneCacheEntry{name: name, expiry: time.Now().Add(zoneCacheTTL)}
+
+	zone, err := s.zoneRetriever(name, false)
+	if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return nil, err
+	}
+
+	if err == nil {
+		entry.info = &zone.Info
+
+		if shared.IsTrue(zone.Info.Config["dns.query"]) {
+			zone, err = s.zoneRetriever(name, true)
+			if err != nil {
+				return nil, err
+			}
+
+			records, err := zoneRecords(zone.Content)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, rr := range records {
+				soa, ok := rr.(*dns.SOA)
+				if ok {
+					// The zone content starts and ends with the SOA record, only keep one.
+					if entry.soa == nil {
+						entry.soa = soa
+						entry.records = append(entry.records, rr)
+					}
+
+					continue
+				}
+
+				entry.records = append(entry.records, rr)
+			}
+		}
+	}
+
+	s.cache.add(entry)
+
+	return entry, nil
+}
+
+// invalidateZone removes a zone from the query cache.
+func (s *Server) invalidateZone(name string) {
+	s.cache.remove(name)
+}
+
+// serveQuery answers regular queries from the content of the zone.
+func (d dnsHandler) serveQuery(w dns.ResponseWriter, r *dns.Msg) {
+	reply := func(rcode int) {
+		m := new(dns.Msg)
+		m.SetRcode(r, rcode)
+		err := w.WriteMsg(m)
+		if err != nil {
+			logger.Error("Unable to write message", logger.Ctx{"err": err})
+		}
+	}
+
+	question := r.Question[0]
+	if question.Qclass != dns.ClassINET && question.Qclass != dns.ClassANY {
+		reply(dns.RcodeNotImplemented)
+		return
+	}
+
+	// Handle EDNS.
+	size := dns.MinMsgSize
+	opt := r.IsEdns0()
+	if opt != nil {
+		if opt.Version() != 0 {
+			m := new(dns.Msg)
+			m.SetRcode(r, dns.RcodeBadVers)
+			m.SetEdns0(dns.DefaultMsgSize, false)
+			err := w.WriteMsg(m)
+			if err != nil {
+				logger.Error("Unable to write message", logger.Ctx{"err": err})
+			}
+
+			return
+		}
+
+		if int(opt.UDPSize()) > size {
+			size = int(opt.UDPSize())
+		}
+
+		if size > dns.DefaultMsgSize {
+			size = dns.DefaultMsgSize
+		}
+	}
+
+	// Find the closest enclosing zone.
+	qname := strings.ToLower(dns.Fqdn(question.Name))
+	labels := dns.SplitDomainName(qname)
+
+	zoneNames, err := d.server.queryZoneNames()
+	if err != nil {
+		logger.Error("Failed loading DNS zone names", logger.Ctx{"name": qname, "err": err})
+		reply(dns.RcodeServerFailure)
+		return
+	}
+
+	var zone *zoneCacheEntry
+	for i := range labels {
+		name := strings.Join(labels[i:], ".")
+		if !zoneNames[name] {
+			continue
+		}
+
+		entry, err := d.server.queryZone(name)
+		if err != nil {
+			logger.Error("Failed loading DNS zone", logger.Ctx{"name": qname, "err": err})
+			reply(dns.RcodeServerFailure)
+			return
+		}
+
+		// The zone may have been deleted since the names were listed.
+		if entry.info != nil {
+			zone = entry
+		}
+
+		break
+	}
+
+	// Only answer for zones which have query answering enabled.
+	if zone == nil || !shared.IsTrue(zone.info.Config["dns.query"]) {
+		reply(dns.RcodeRefused)
+		return
+	}
+
+	// Prepare the response.
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+
+	nameExists := false
+	for _, rr := range zone.records {
+		hdr := rr.Header()
+		owner := strings.ToLower(hdr.Name)
+
+		if owner != qname {
+			// Names with records below them exist (empty non-terminals).
+			if dns.IsSubDomain(qname, owner) {
+				nameExists = true
+			}
+
+			continue
+		}
+
+		nameExists = true
+
+		if hdr.Rrtype == question.Qtype || question.Qtype == dns.TypeANY || hdr.Rrtype == dns.TypeCNAME {
+			m.Answer = append(m.Answer, rr)
+		}
+	}
+
+	// Negative responses include the SOA record.
+	if len(m.Answer) == 0 {
+		if !nameExists {
+			m.Rcode = dns.RcodeNameError
+		}
+
+		if zone.soa != nil {
+			soa := dns.Copy(zone.soa).(*dns.SOA)
+			if soa.Minttl < soa.Hdr.Ttl {
+				soa.Hdr.Ttl = soa.Minttl
+			}
+
+			m.Ns = append(m.Ns, soa)
+		}
+	}
+
+	if opt != nil {
+		m.SetEdns0(dns.DefaultMsgSize, false)
+	}
+
+	// Truncate the response to fit in the UDP payload size.
+	if w.LocalAddr().Network() == "udp" {
+		m.Truncate(size)
+	}
+
+	err = w.WriteMsg(m)
+	if err != nil {
+		logger.Error("Unable to write message", logger.Ctx{"err": err})
+	}
+}
@@ -0,0 +1,213 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestZoneCache(t *testing.T) {
+	now := time.Now()
+	expiry := now.Add(zoneCacheTTL)
+
+	cache := newZoneCache(2)
+	cache.add(&zoneCacheEntry{name: "a.example.net.", expiry: expiry})
+	cache.add(&zoneCacheEntry{name: "b.example.net.", expiry: expiry})
+
+	// Using a zone makes it the most recently used one.
+	assert.NotNil(t, cache.get("a.example.net.", now))
+
+	// Adding a zone to a full cache evicts the least recently used one.
+	cache.add(&zoneCacheEntry{name: "c.example.net.", expiry: expiry})
+	assert.Nil(t, cache.get("b.example.net.", now))
+	assert.NotNil(t, cache.get("a.example.net.", now))
+	assert.NotNil(t, cache.get("c.example.net.", now))
+	assert.Len(t, cache.entries, 2)
+	assert.Equal(t, 2, cache.lru.Len())
+
+	// Replacing a cached zone doesn't evict any other.
+	cache.add(&zoneCacheEntry{name: "a.example.net.", expiry: expiry.Add(time.Second)})
+	assert.NotNil(t, cache.get("c.example.net.", now))
+	assert.Equal(t, expiry.Add(time.Second), cache.get("a.example.net.", now).expiry)
+
+	// Expired zones are dropped.
+	assert.Nil(t, cache.get("c.example.net.", expiry))
+	assert.Len(t, cache.entries, 1)
+
+	// Invalidated zones are dropped.
+	cache.remove("a.example.net.")
+	assert.Nil(t, cache.get("a.example.net.", now))
+	assert.Empty(t, cache.entries)
+	assert.Equal(t, 0, cache.lru.Len())
+}
+
+// testResponseWriter is a dns.ResponseWriter recording the written message.
+type testResponseWriter struct {
+	network string
+	msg     *dns.Msg
+}
+
+func (w *testResponseWriter) LocalAddr() net.Addr {
+	if w.network == "tcp" {
+		return &net.TCPAddr{IP: net.ParseIP("192.0.2.53"), Port: 53}
+	}
+
+	return &net.UDPAddr{IP: net.ParseIP("192.0.2.53"), Port: 53}
+}
+
+func (w *testResponseWriter) RemoteAddr() net.Addr {
+	return &net.UDPAddr{IP: net.ParseIP("192.0.2.100"), Port: 12345}
+}
+
+func (w *testResponseWriter) WriteMsg(m *dns.Msg) error {
+	w.msg = m
+	return nil
+}
+
+func (w *testResponseWriter) Write(b []byte) (int, error) {
+	return 0, fmt.Errorf("Not supported")
+}
+
+func (w *testResponseWriter) Close() error {
+	return nil
+}
+
+func (w *testResponseWriter) TsigStatus() error {
+	return nil
+}
+
+func (w *testResponseWriter) TsigTimersOnly(bool) {
+}
+
+func (w *testResponseWriter) Hijack() {
+}
+
+func TestServeQuery(t *testing.T) {
+	var txt strings.Builder
+	for i := 0; i < 20; i++ {
+		txt.WriteString(fmt.Sprintf("big.example.net. 300 IN TXT \"%s\"\n", strings.Repeat("x", 100)))
+	}
+
+	zones := map[string]*Zone{
+		"example.net": {
+			Info: api.NetworkZone{Name: "example.net", NetworkZonePut: api.NetworkZonePut{Config: map[string]string{"dns.query": "true"}}},
+			Content: `example.net. 3600 IN SOA ns1.example.net. hostmaster.example.net. 1 120 60 86400 30
+foo.example.net. 300 IN A 192.0.2.1
+foo.example.net. 300 IN AAAA 2001:db8::1
+www.example.net. 300 IN CNAME foo.example.net.
+a.b.example.net. 300 IN A 192.0.2.2
+` + txt.String() + `example.net. 3600 IN SOA ns1.example.net. hostmaster.example.net. 1 120 60 86400 30`,
+		},
+		"private.example.net": {
+			Info: api.NetworkZone{Name: "private.example.net", NetworkZonePut: api.NetworkZonePut{Config: map[string]string{}}},
+		},
+	}
+
+	var retrieved []string
+	server := &Server{
+		cache: newZoneCache(zoneCacheSize),
+		zoneLister: func() ([]string, error) {
+			return []string{"example.net", "private.example.net", "deleted.example.net"}, nil
+		},
+		zoneRetriever: func(name string, full bool) (*Zone, error) {
+			retrieved = append(retrieved, name)
+
+			zone := zones[name]
+			if zone == nil {
+				return nil, api.StatusErrorf(http.StatusNotFound, "Network zone not found")
+			}
+
+			return zone, nil
+		},
+	}
+
+	tests := []struct {
+		name      string
+		qname     string
+		qtype     uint16
+		qclass    uint16
+		network   string
+		edns      int // EDNS UDP size, 0 for no EDNS and -1 for an unsupported EDNS version.
+		rcode     int
+		answers   int
+		soa       bool
+		truncated bool
+		retrieved []string
+	}{
+		{name: "Existing record", qname: "foo.example.net.", qtype: dns.TypeA, rcode: dns.RcodeSuccess, answers: 1, retrieved: []string{"example.net", "example.net"}},
+		{name: "Name case is ignored", qname: "FOO.Example.NET.", qtype: dns.TypeAAAA, rcode: dns.RcodeSuccess, answers: 1},
+		{name: "ANY query", qname: "foo.example.net.", qtype: dns.TypeANY, rcode: dns.RcodeSuccess, answers: 2},
+		{name: "CNAME", qname: "www.example.net.", qtype: dns.TypeA, rcode: dns.RcodeSuccess, answers: 1},
+		{name: "NODATA", qname: "foo.example.net.", qtype: dns.TypeMX, rcode: dns.RcodeSuccess, soa: true},
+		{name: "Empty non-terminal", qname: "b.example.net.", qtype: dns.TypeA, rcode: dns.RcodeSuccess, soa: true},
+		{name: "NXDOMAIN", qname: "bar.example.net.", qtype: dns.TypeA, rcode: dns.RcodeNameError, soa: true},
+		{name: "NXDOMAIN below a record", qname: "x.foo.example.net.", qtype: dns.TypeA, rcode: dns.RcodeNameError, soa: true},
+		{name: "Zone without query answering", qname: "foo.private.example.net.", qtype: dns.TypeA, rcode: dns.RcodeRefused, retrieved: []string{"private.example.net"}},
+		{name: "Deleted zone", qname: "foo.deleted.example.net.", qtype: dns.TypeA, rcode: dns.RcodeRefused, retrieved: []string{"deleted.example.net"}},
+		{name: "Unknown zone", qname: "foo.example.com.", qtype: dns.TypeA, rcode: dns.RcodeRefused, retrieved: []string{}},
+		{name: "Unsupported class", qname: "foo.example.net.", qtype: dns.TypeA, qclass: dns.ClassCHAOS, rcode: dns.RcodeNotImplemented, retrieved: []string{}},
+		{name: "Unsupported EDNS version", qname: "foo.example.net.", qtype: dns.TypeA, edns: -1, rcode: dns.RcodeBadVers, retrieved: []string{}},
+		{name: "Truncated UDP response", qname: "big.example.net.", qtype: dns.TypeTXT, rcode: dns.RcodeSuccess, truncated: true},
+		{name: "EDNS UDP response", qname: "big.example.net.", qtype: dns.TypeTXT, edns: 4096, rcode: dns.RcodeSuccess, answers: 20},
+		{name: "TCP response", qname: "big.example.net.", qtype: dns.TypeTXT, network: "tcp", rcode: dns.RcodeSuccess, answers: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retrieved = []string{}
+			server.cache = newZoneCache(zoneCacheSize)
+
+			r := new(dns.Msg)
+			r.SetQuestion(tt.qname, tt.qtype)
+			if tt.qclass != 0 {
+				r.Question[0].Qclass = tt.qclass
+			}
+
+			if tt.edns > 0 {
+				r.SetEdns0(uint16(tt.edns), false)
+			} else if tt.edns < 0 {
+				r.SetEdns0(dns.DefaultMsgSize, false)
+				r.IsEdns0().SetVersion(1)
+			}
+
+			w := &testResponseWriter{network: tt.network}
+			dnsHandler{server: server}.serveQuery(w, r)
+			require.NotNil(t, w.msg)
+
+			assert.Equal(t, tt.rcode, w.msg.Rcode)
+			assert.Equal(t, tt.truncated, w.msg.Truncated)
+
+			if !tt.truncated {
+				assert.Len(t, w.msg.Answer, tt.answers)
+			}
+
+			if tt.soa {
+				require.Len(t, w.msg.Ns, 1)
+				soa, ok := w.msg.Ns[0].(*dns.SOA)
+				require.True(t, ok)
+
+				// Negative answers are cached for the minimum TTL of the SOA record.
+				assert.Equal(t, uint32(30), soa.Hdr.Ttl)
+			} else {
+				assert.Empty(t, w.msg.Ns)
+			}
+
+			if tt.edns != 0 {
+				assert.NotNil(t, w.msg.IsEdns0())
+			}
+
+			if tt.retrieved != nil {
+				assert.Equal(t, tt.retrieved, retrieved)
+			}
+		})
+	}
+}
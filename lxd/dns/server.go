@@ -14,6 +14,14 @@ import (
 // ZoneRetriever is a function which fetches a DNS zone.
 type ZoneRetriever func(name string, full bool) (*Zone, error)
 
+// ZoneLister is a function which returns the names of all DNS zones.
+type ZoneLister func() ([]string, error)
+
+// ZoneUpdater is a function which applies RFC 2136 update records from a peer to a DNS zone.
+// The prerequisites function must be called with the zone content the update is applied to and the update
+// must be rejected with its error if it fails.
+type ZoneUpdater func(name string, peer string, prerequisites func(content string) error, updates []dns.RR) error
+
 // Server represents a DNS server instance.
 type Server struct {
 	tcpDNS *dns.Server
@@ -22,17 +30,29 @@ type Server struct {
 	// External dependencies.
 	db            *db.Cluster
 	zoneRetriever ZoneRetriever
+	zoneUpdater   ZoneUpdater
+	zoneLister    ZoneLister
 
 	// Internal state (to handle reconfiguration).
 	address string
 
 	mu sync.Mutex
+
+	// Cache of the zones used to answer queries.
+	cache *zoneCache
+
+	// Cache of the names of all zones, used to find the zone of a query.
+	zoneNames zoneNameCache
 }
 
 // NewServer returns a new server instance.
-func NewServer(db *db.Cluster, retriever ZoneRetriever) *Server {
+func NewServer(db *db.Cluster, retriever ZoneRetriever, updater ZoneUpdater) *Server {
 	// Setup new struct.
-	s := &Server{db: db, zoneRetriever: retriever}
+	s := &Server{db: db, zoneRetriever: retriever, zoneUpdater: updater, cache: newZoneCache(zoneCacheSize)}
+	if db != nil {
+		s.zoneLister = db.GetNetworkZoneNames
+	}
+
 	return s
 }
 
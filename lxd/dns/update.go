@@ -0,0 +1,271 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// serveUpdate handles RFC 2136 dynamic update requests.
+func (d dnsHandler) serveUpdate(w dns.ResponseWriter, r *dns.Msg) {
+	reply := func(rcode int) {
+		m := new(dns.Msg)
+		m.SetRcode(r, rcode)
+
+		tsig := r.IsTsig()
+		if tsig != nil && w.TsigStatus() == nil {
+			m.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+		}
+
+		err := w.WriteMsg(m)
+		if err != nil {
+			logger.Error("Unable to write message", logger.Ctx{"err": err})
+		}
+	}
+
+	// Check if we're ready to apply updates.
+	if d.server.zoneUpdater == nil {
+		reply(dns.RcodeServerFailure)
+		return
+	}
+
+	// The zone section must contain a single SOA entry.
+	if len(r.Question) != 1 || r.Question[0].Qtype != dns.TypeSOA || r.Question[0].Qclass != dns.ClassINET {
+		reply(dns.RcodeFormatError)
+		return
+	}
+
+	// Extract the request information.
+	name := strings.TrimSuffix(r.Question[0].Name, ".")
+	ip, _, err := net.SplitHostPort(w.RemoteAddr().String())
+	if err != nil {
+		reply(dns.RcodeServerFailure)
+		return
+	}
+
+	// Load the zone.
+	zone, err := d.server.zoneRetriever(name, true)
+	if err != nil {
+		reply(dns.RcodeNotAuth)
+		return
+	}
+
+	// Only peers authenticated through their TSIG key may apply updates.
+	peer, ok := d.findPeer(zone.Info, ip, r.IsTsig(), w.TsigStatus() == nil, true)
+	if !ok {
+		reply(dns.RcodeRefused)
+		return
+	}
+
+	// Pre-scan the update section.
+	zoneName := dns.Fqdn(name)
+	for _, rr := range r.Ns {
+		rcode := updatePrescan(zoneName, rr)
+		if rcode != dns.RcodeSuccess {
+			reply(rcode)
+			return
+		}
+	}
+
+	// Apply the update, checking the prerequisites against the zone content it is applied to.
+	prerequisites := func(content string) error {
+		records, err := zoneRecords(content)
+		if err != nil {
+			return fmt.Errorf("Bad DNS record in zone %q: %w", name, err)
+		}
+
+		rcode := updatePrerequisites(zoneName, records, r.Answer)
+		if rcode != dns.RcodeSuccess {
+			return updateRcodeError(rcode)
+		}
+
+		return nil
+	}
+
+	err = d.server.zoneUpdater(name, peer, prerequisites, r.Ns)
+	if err != nil {
+		var rcodeErr updateRcodeError
+		if errors.As(err, &rcodeErr) {
+			reply(int(rcodeErr))
+			return
+		}
+
+		logger.Warn("Failed applying DNS update", logger.Ctx{"zone": name, "peer": peer, "err": err})
+
+		if api.StatusErrorCheck(err, http.StatusForbidden) {
+			reply(dns.RcodeRefused)
+		} else {
+			reply(dns.RcodeServerFailure)
+		}
+
+		return
+	}
+
+	// Make sure the changes are visible to queries straight away.
+	d.server.invalidateZone(strings.ToLower(name))
+
+	reply(dns.RcodeSuccess)
+}
+
+// updateRcodeError is returned when an update is rejected with a specific response code.
+type updateRcodeError int
+
+// Error returns the response code the update is rejected with.
+func (e updateRcodeError) Error() string {
+	return fmt.Sprintf("DNS update rejected with %s", dns.RcodeToString[int(e)])
+}
+
+// updatePrescan validates a single record of the update section (RFC 2136 section 3.4.1).
+func updatePrescan(zoneName string, rr dns.RR) int {
+	hdr := rr.Header()
+
+	if !dns.IsSubDomain(zoneName, dns.Fqdn(hdr.Name)) {
+		return dns.RcodeNotZone
+	}
+
+	switch hdr.Class {
+	case dns.ClassINET:
+		if hdr.Rrtype == dns.TypeANY || hdr.Rrtype == dns.TypeAXFR || hdr.Rrtype == dns.TypeIXFR {
+			return dns.RcodeFormatError
+		}
+
+	case dns.ClassANY:
+		if hdr.Ttl != 0 || rrValue(rr) != "" || hdr.Rrtype == dns.TypeAXFR || hdr.Rrtype == dns.TypeIXFR {
+			return dns.RcodeFormatError
+		}
+
+	case dns.ClassNONE:
+		if hdr.Ttl != 0 || hdr.Rrtype == dns.TypeANY || hdr.Rrtype == dns.TypeAXFR || hdr.Rrtype == dns.TypeIXFR {
+			return dns.RcodeFormatError
+		}
+
+	default:
+		return dns.RcodeFormatError
+	}
+
+	return dns.RcodeSuccess
+}
+
+// updatePrerequisites checks the prerequisite section of an update (RFC 2136 section 3.2).
+func updatePrerequisites(zoneName string, records []dns.RR, prereqs []dns.RR) int {
+	// Index the existing records.
+	names := map[string]struct{}{}
+	rrsets := map[string]map[string]struct{}{}
+	for _, rr := range records {
+		hdr := rr.Header()
+		owner := strings.ToLower(dns.Fqdn(hdr.Name))
+		key := owner + "/" + dns.TypeToString[hdr.Rrtype]
+
+		names[owner] = struct{}{}
+		if rrsets[key] == nil {
+			rrsets[key] = map[string]struct{}{}
+		}
+
+		rrsets[key][rrValue(rr)] = struct{}{}
+	}
+
+	// Check the prerequisites.
+	expected := map[string]map[string]struct{}{}
+	for _, rr := range prereqs {
+		hdr := rr.Header()
+		owner := strings.ToLower(dns.Fqdn(hdr.Name))
+		key := owner + "/" + dns.TypeToString[hdr.Rrtype]
+
+		if hdr.Ttl != 0 {
+			return dns.RcodeFormatError
+		}
+
+		if !dns.IsSubDomain(zoneName, owner) {
+			return dns.RcodeNotZone
+		}
+
+		_, nameInUse := names[owner]
+		_, rrsetExists := rrsets[key]
+
+		switch hdr.Class {
+		case dns.ClassANY:
+			if rrValue(rr) != "" {
+				return dns.RcodeFormatError
+			}
+
+			if hdr.Rrtype == dns.TypeANY && !nameInUse {
+				return dns.RcodeNameError
+			} else if hdr.Rrtype != dns.TypeANY && !rrsetExists {
+				return dns.RcodeNXRrset
+			}
+
+		case dns.ClassNONE:
+			if rrValue(rr) != "" {
+				return dns.RcodeFormatError
+			}
+
+			if hdr.Rrtype == dns.TypeANY && nameInUse {
+				return dns.RcodeYXDomain
+			} else if hdr.Rrtype != dns.TypeANY && rrsetExists {
+				return dns.RcodeYXRrset
+			}
+
+		case dns.ClassINET:
+			// Value dependent checks are done once the full RRset is known.
+			if expected[key] == nil {
+				expected[key] = map[string]struct{}{}
+			}
+
+			expected[key][rrValue(rr)] = struct{}{}
+
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+
+	// Check that the value dependent RRsets match exactly.
+	for key, values := range expected {
+		if len(rrsets[key]) != len(values) {
+			return dns.RcodeNXRrset
+		}
+
+		for value := range values {
+			_, ok := rrsets[key][value]
+			if !ok {
+				return dns.RcodeNXRrset
+			}
+		}
+	}
+
+	return dns.RcodeSuccess
+}
+
+// zoneRecords parses the zone content into a list of records.
+func zoneRecords(content string) ([]dns.RR, error) {
+	records := []dns.RR{}
+
+	zoneRR := dns.NewZoneParser(strings.NewReader(content), "", "")
+	for {
+		rr, ok := zoneRR.Next()
+		if !ok {
+			err := zoneRR.Err()
+			if err != nil {
+				return nil, err
+			}
+
+			break
+		}
+
+		records = append(records, rr)
+	}
+
+	return records, nil
+}
+
+// rrValue returns the data part of a record.
+func rrValue(rr dns.RR) string {
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
@@ -0,0 +1,94 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRR(t *testing.T, s string, class uint16) dns.RR {
+	rr, err := dns.NewRR(s)
+	require.NoError(t, err)
+
+	rr.Header().Class = class
+
+	return rr
+}
+
+func TestUpdatePrescan(t *testing.T) {
+	tests := []struct {
+		name  string
+		rr    string
+		class uint16
+		rcode int
+	}{
+		{"Add record", "foo.example.net. 300 IN A 192.0.2.1", dns.ClassINET, dns.RcodeSuccess},
+		{"Add record outside zone", "foo.example.com. 300 IN A 192.0.2.1", dns.ClassINET, dns.RcodeNotZone},
+		{"Add ANY record", "foo.example.net. 300 IN ANY", dns.ClassINET, dns.RcodeFormatError},
+		{"Add AXFR record", "foo.example.net. 300 IN AXFR", dns.ClassINET, dns.RcodeFormatError},
+		{"Delete RRset", "foo.example.net. 0 IN A", dns.ClassANY, dns.RcodeSuccess},
+		{"Delete all RRsets", "foo.example.net. 0 IN ANY", dns.ClassANY, dns.RcodeSuccess},
+		{"Delete RRset with TTL", "foo.example.net. 300 IN A", dns.ClassANY, dns.RcodeFormatError},
+		{"Delete RRset with value", "foo.example.net. 0 IN A 192.0.2.1", dns.ClassANY, dns.RcodeFormatError},
+		{"Delete RR", "foo.example.net. 0 IN A 192.0.2.1", dns.ClassNONE, dns.RcodeSuccess},
+		{"Delete RR with TTL", "foo.example.net. 300 IN A 192.0.2.1", dns.ClassNONE, dns.RcodeFormatError},
+		{"Delete ANY RR", "foo.example.net. 0 IN ANY", dns.ClassNONE, dns.RcodeFormatError},
+		{"Bad class", "foo.example.net. 300 IN A 192.0.2.1", dns.ClassCHAOS, dns.RcodeFormatError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.rcode, updatePrescan("example.net.", newTestRR(t, tt.rr, tt.class)))
+		})
+	}
+}
+
+func TestUpdatePrerequisites(t *testing.T) {
+	records, err := zoneRecords(`example.net. 3600 IN SOA ns1.example.net. hostmaster.example.net. 1 120 60 86400 30
+foo.example.net. 300 IN A 192.0.2.1
+foo.example.net. 300 IN A 192.0.2.2
+bar.example.net. 300 IN AAAA 2001:db8::1`)
+	require.NoError(t, err)
+
+	type prereq struct {
+		rr    string
+		class uint16
+	}
+
+	tests := []struct {
+		name    string
+		prereqs []prereq
+		rcode   int
+	}{
+		{"No prerequisites", nil, dns.RcodeSuccess},
+		{"RRset exists", []prereq{{"foo.example.net. 0 IN A", dns.ClassANY}}, dns.RcodeSuccess},
+		{"RRset doesn't exist", []prereq{{"foo.example.net. 0 IN AAAA", dns.ClassANY}}, dns.RcodeNXRrset},
+		{"Name is in use", []prereq{{"bar.example.net. 0 IN ANY", dns.ClassANY}}, dns.RcodeSuccess},
+		{"Name isn't in use", []prereq{{"baz.example.net. 0 IN ANY", dns.ClassANY}}, dns.RcodeNameError},
+		{"RRset doesn't exist as expected", []prereq{{"foo.example.net. 0 IN AAAA", dns.ClassNONE}}, dns.RcodeSuccess},
+		{"RRset exists unexpectedly", []prereq{{"foo.example.net. 0 IN A", dns.ClassNONE}}, dns.RcodeYXRrset},
+		{"Name isn't in use as expected", []prereq{{"baz.example.net. 0 IN ANY", dns.ClassNONE}}, dns.RcodeSuccess},
+		{"Name is in use unexpectedly", []prereq{{"foo.example.net. 0 IN ANY", dns.ClassNONE}}, dns.RcodeYXDomain},
+		{"Name case is ignored", []prereq{{"FOO.example.net. 0 IN A", dns.ClassANY}}, dns.RcodeSuccess},
+		{"RRset values match", []prereq{{"foo.example.net. 0 IN A 192.0.2.2", dns.ClassINET}, {"foo.example.net. 0 IN A 192.0.2.1", dns.ClassINET}}, dns.RcodeSuccess},
+		{"RRset values are missing", []prereq{{"foo.example.net. 0 IN A 192.0.2.1", dns.ClassINET}}, dns.RcodeNXRrset},
+		{"RRset values differ", []prereq{{"foo.example.net. 0 IN A 192.0.2.1", dns.ClassINET}, {"foo.example.net. 0 IN A 192.0.2.3", dns.ClassINET}}, dns.RcodeNXRrset},
+		{"Prerequisite with TTL", []prereq{{"foo.example.net. 300 IN A", dns.ClassANY}}, dns.RcodeFormatError},
+		{"Prerequisite with value", []prereq{{"foo.example.net. 0 IN A 192.0.2.1", dns.ClassANY}}, dns.RcodeFormatError},
+		{"Prerequisite outside zone", []prereq{{"foo.example.com. 0 IN A", dns.ClassANY}}, dns.RcodeNotZone},
+		{"Bad class", []prereq{{"foo.example.net. 0 IN A", dns.ClassCHAOS}}, dns.RcodeFormatError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prereqs := []dns.RR{}
+			for _, p := range tt.prereqs {
+				prereqs = append(prereqs, newTestRR(t, p.rr, p.class))
+			}
+
+			assert.Equal(t, tt.rcode, updatePrerequisites("example.net.", records, prereqs))
+		})
+	}
+}
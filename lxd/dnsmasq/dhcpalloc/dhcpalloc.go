@@ -93,6 +93,7 @@ type Options struct {
 	DeviceName  string
 	HostMAC     net.HardwareAddr
 	Network     Network
+	DHCPOptions []string // Host specific DHCP options in dnsmasq format.
 }
 
 // Transaction is a locked transaction of the dnsmasq config files that allows IP allocations for a host.
@@ -392,7 +393,7 @@ func AllocateTask(opts *Options, f func(*Transaction) error) error {
 		}
 
 		// Write out new dnsmasq static host allocation config file.
-		err = dnsmasq.UpdateStaticEntry(opts.Network.Name(), opts.ProjectName, opts.HostName, opts.DeviceName, opts.Network.Config(), opts.HostMAC.String(), IPv4Str, IPv6Str)
+		err = dnsmasq.UpdateStaticEntry(opts.Network.Name(), opts.ProjectName, opts.HostName, opts.DeviceName, opts.Network.Config(), opts.HostMAC.String(), IPv4Str, IPv6Str, opts.DHCPOptions)
 		if err != nil {
 			return err
 		}
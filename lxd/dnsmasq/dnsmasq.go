@@ -30,9 +30,11 @@ type DHCPAllocation struct {
 var ConfigMutex sync.Mutex
 
 // UpdateStaticEntry writes a single dhcp-host line for a network/instance combination.
-func UpdateStaticEntry(network string, projectName string, instanceName string, deviceName string, netConfig map[string]string, hwaddr string, ipv4Address string, ipv6Address string) error {
+// Any DHCP options supplied are written to the options file of the instance device and only sent to it.
+func UpdateStaticEntry(network string, projectName string, instanceName string, deviceName string, netConfig map[string]string, hwaddr string, ipv4Address string, ipv6Address string, dhcpOptions []string) error {
 	hwaddr = strings.ToLower(hwaddr)
 	line := hwaddr
+	deviceStaticFileName := StaticAllocationFileName(projectName, instanceName, deviceName)
 
 	// Generate the dhcp-host line
 	if ipv4Address != "" {
@@ -47,11 +49,30 @@ func UpdateStaticEntry(network string, projectName string, instanceName string,
 		line += fmt.Sprintf(",%s", project.DNS(projectName, instanceName))
 	}
 
+	if len(dhcpOptions) > 0 {
+		tag := staticAllocationTag(deviceStaticFileName)
+		line += fmt.Sprintf(",set:%s", tag)
+
+		var opts strings.Builder
+		for _, option := range dhcpOptions {
+			fmt.Fprintf(&opts, "tag:%s,%s\n", tag, option)
+		}
+
+		err := ioutil.WriteFile(shared.VarPath("networks", network, "dnsmasq.opts", deviceStaticFileName), []byte(opts.String()), 0644)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := os.Remove(shared.VarPath("networks", network, "dnsmasq.opts", deviceStaticFileName))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
 	if line == hwaddr {
 		return nil
 	}
 
-	deviceStaticFileName := StaticAllocationFileName(projectName, instanceName, deviceName)
 	err := ioutil.WriteFile(shared.VarPath("networks", network, "dnsmasq.hosts", deviceStaticFileName), []byte(line+"\n"), 0644)
 	if err != nil {
 		return err
@@ -68,6 +89,11 @@ func RemoveStaticEntry(network string, projectName string, instanceName string,
 		return err
 	}
 
+	err = os.Remove(shared.VarPath("networks", network, "dnsmasq.opts", deviceStaticFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
 	return nil
 }
 
@@ -132,6 +158,11 @@ func DHCPStaticAllocation(network string, deviceStaticFileName string) (net.Hard
 	for scanner.Scan() {
 		fields := strings.SplitN(scanner.Text(), ",", -1)
 		for _, field := range fields {
+			// Skip the tag used to select the DHCP options of the device.
+			if strings.HasPrefix(field, "set:") {
+				continue
+			}
+
 			// Check if field is IPv4 or IPv6 address.
 			if strings.Count(field, ".") == 3 {
 				IP := net.ParseIP(field)
@@ -267,3 +298,8 @@ func StaticAllocationFileName(projectName string, instanceName string, deviceNam
 
 	return strings.Join([]string{project.Instance(projectName, instanceName), escapedDeviceName}, staticAllocationDeviceSeparator)
 }
+
+// staticAllocationTag returns the dnsmasq tag used to send the DHCP options of an instance device static allocation.
+func staticAllocationTag(deviceStaticFileName string) string {
+	return fmt.Sprintf("lxd-%s", deviceStaticFileName)
+}
@@ -37,6 +37,13 @@ type ACLRule struct {
 	DestinationPort string
 	ICMPType        string
 	ICMPCode        string
+	CounterName     string // Counter label name used to report the hit counters of the rule.
+}
+
+// ACLRuleCounter represents the hit counters of an ACL rule.
+type ACLRuleCounter struct {
+	Packets uint64
+	Bytes   uint64
 }
 
 // AddressForward represents a NAT address forward.
@@ -47,3 +54,17 @@ type AddressForward struct {
 	ListenPorts   []uint64
 	TargetPorts   []uint64
 }
+
+// LoadBalancerBackend represents a target of a NAT load balancer.
+type LoadBalancerBackend struct {
+	TargetAddress net.IP
+	TargetPorts   []uint64
+}
+
+// LoadBalancer represents a NAT load balancer spreading the connections to its listen ports across its backends.
+type LoadBalancer struct {
+	ListenAddress net.IP
+	Protocol      string
+	ListenPorts   []uint64
+	Backends      []LoadBalancerBackend
+}
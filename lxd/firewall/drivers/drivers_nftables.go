@@ -366,6 +366,7 @@ func (d Nftables) NetworkClear(networkName string, _ bool, _ []uint) error {
 		"fwd", "pstrt", "in", "out", // Chains used for network operation rules.
 		"aclin", "aclout", "aclfwd", "acl", // Chains used by ACL rules.
 		"fwdprert", "fwdout", "fwdpstrt", // Chains used by Address Forward rules.
+		"lbprert", "lbout", "lbpstrt", // Chains used by Load Balancer rules.
 	}
 
 	// Remove chains created by network rules.
@@ -714,6 +715,66 @@ func (d Nftables) NetworkApplyACLRules(networkName string, rules []ACLRule) erro
 	return nil
 }
 
+// NetworkACLRuleCounters returns the hit counters of the ACL rules applied to a network, keyed by counter name.
+// The counters of rules generated for both IPv4 and IPv6 are summed up.
+func (d Nftables) NetworkACLRuleCounters(networkName string) (map[string]ACLRuleCounter, error) {
+	chain := fmt.Sprintf("acl%s%s", nftablesChainSeparator, networkName)
+
+	// Dump the chain as JSON. Use -nn flags to avoid doing DNS lookups of IPs mentioned in any rules.
+	output, err := shared.RunCommand("nft", "--json", "-nn", "list", "chain", "inet", nftablesNamespace, chain)
+	if err != nil {
+		return nil, fmt.Errorf("Failed listing nftables chain %q: %w", chain, err)
+	}
+
+	counters, err := nftablesACLRuleCounters(output)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing nftables chain %q: %w", chain, err)
+	}
+
+	return counters, nil
+}
+
+// nftablesACLRuleCounters extracts the counters of the ACL rules from the JSON output of "nft --json list chain",
+// keyed by the rule comment.
+func nftablesACLRuleCounters(output string) (map[string]ACLRuleCounter, error) {
+	// This only extracts the rule comments and counters, see man libnftables-json for more info.
+	v := &struct {
+		Nftables []struct {
+			Rule *struct {
+				Comment string `json:"comment"`
+				Expr    []struct {
+					Counter *ACLRuleCounter `json:"counter"`
+				} `json:"expr"`
+			} `json:"rule"`
+		} `json:"nftables"`
+	}{}
+
+	err := json.Unmarshal([]byte(output), v)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := map[string]ACLRuleCounter{}
+	for _, item := range v.Nftables {
+		if item.Rule == nil || item.Rule.Comment == "" {
+			continue
+		}
+
+		for _, expr := range item.Rule.Expr {
+			if expr.Counter == nil {
+				continue
+			}
+
+			counter := counters[item.Rule.Comment]
+			counter.Packets += expr.Counter.Packets
+			counter.Bytes += expr.Counter.Bytes
+			counters[item.Rule.Comment] = counter
+		}
+	}
+
+	return counters, nil
+}
+
 // aclRuleCriteriaToRules converts an ACL rule into 1 or more nftables rules.
 func (d Nftables) aclRuleCriteriaToRules(networkName string, ipVersion uint, rule *ACLRule) (string, bool, error) {
 	var args []string
@@ -810,6 +871,11 @@ func (d Nftables) aclRuleCriteriaToRules(networkName string, ipVersion uint, rul
 		}
 	}
 
+	// Handle counters.
+	if rule.CounterName != "" {
+		args = append(args, "counter")
+	}
+
 	// Handle logging.
 	if rule.Log {
 		args = append(args, "log")
@@ -828,6 +894,11 @@ func (d Nftables) aclRuleCriteriaToRules(networkName string, ipVersion uint, rul
 
 	args = append(args, action)
 
+	// The rule comment identifies the counter and must come last.
+	if rule.CounterName != "" {
+		args = append(args, "comment", fmt.Sprintf(`"%s"`, rule.CounterName))
+	}
+
 	return strings.Join(args, " "), isPartialRule, nil
 }
 
@@ -1052,3 +1123,101 @@ func (d Nftables) NetworkApplyForwards(networkName string, rules []AddressForwar
 
 	return nil
 }
+
+// NetworkApplyLoadBalancers apply network load balancer rules to firewall.
+func (d Nftables) NetworkApplyLoadBalancers(networkName string, rules []LoadBalancer) error {
+	var dnatRules []map[string]any
+	var snatRules []map[string]any
+
+	for ruleIndex, rule := range rules {
+		if rule.ListenAddress == nil {
+			return fmt.Errorf("Invalid rule %d, listen address is required", ruleIndex)
+		}
+
+		if rule.Protocol == "" || len(rule.ListenPorts) < 1 {
+			return fmt.Errorf("Invalid rule %d, protocol and listen port(s) are required", ruleIndex)
+		}
+
+		ipFamily := "ip"
+		if rule.ListenAddress.To4() == nil {
+			ipFamily = "ip6"
+		}
+
+		backendsLen := len(rule.Backends)
+
+		for i := range rule.ListenPorts {
+			for backendIndex, backend := range rule.Backends {
+				if backend.TargetAddress == nil {
+					return fmt.Errorf("Invalid rule %d, backend target address is required", ruleIndex)
+				}
+
+				targetPort, err := loadBalancerTargetPort(rule, backend, i)
+				if err != nil {
+					return fmt.Errorf("Invalid rule %d: %w", ruleIndex, err)
+				}
+
+				targetAddressStr := backend.TargetAddress.String()
+
+				// Format the destination host/port as appropriate.
+				targetDest := fmt.Sprintf("%s:%d", targetAddressStr, targetPort)
+				if ipFamily == "ip6" {
+					targetDest = fmt.Sprintf("[%s]:%d", targetAddressStr, targetPort)
+				}
+
+				// Each backend picks the connections not taken by the previous ones with a probability of
+				// 1 in the number of remaining backends, which spreads the connections evenly.
+				numgenMod := 0
+				if backendIndex < backendsLen-1 {
+					numgenMod = backendsLen - backendIndex
+				}
+
+				dnatRules = append(dnatRules, map[string]any{
+					"ipFamily":      ipFamily,
+					"protocol":      rule.Protocol,
+					"listenAddress": rule.ListenAddress.String(),
+					"listenPorts":   rule.ListenPorts[i],
+					"targetDest":    targetDest,
+					"numgenMod":     numgenMod,
+				})
+
+				snatRules = append(snatRules, map[string]any{
+					"ipFamily":    ipFamily,
+					"protocol":    rule.Protocol,
+					"targetHost":  targetAddressStr,
+					"targetPorts": targetPort,
+				})
+			}
+		}
+	}
+
+	tplFields := map[string]any{
+		"namespace":      nftablesNamespace,
+		"chainSeparator": nftablesChainSeparator,
+		"chainPrefix":    "lb", // Differentiate from address forwards.
+		"family":         "inet",
+		"label":          networkName,
+		"dnatRules":      dnatRules,
+		"snatRules":      snatRules,
+	}
+
+	// Apply rules or remove chains if no rules generated.
+	if len(dnatRules) > 0 {
+		config := &strings.Builder{}
+		err := nftablesNetProxyNAT.Execute(config, tplFields)
+		if err != nil {
+			return fmt.Errorf("Failed running %q template: %w", nftablesNetProxyNAT.Name(), err)
+		}
+
+		_, err = shared.RunCommand("nft", config.String())
+		if err != nil {
+			return err
+		}
+	} else {
+		err := d.removeChains([]string{"inet"}, networkName, "lbprert", "lbout", "lbpstrt")
+		if err != nil {
+			return fmt.Errorf("Failed clearing nftables load balancer rules for network %q: %w", networkName, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNftables_aclRuleCriteriaToRules(t *testing.T) {
+	d := Nftables{}
+
+	rule := &ACLRule{
+		Direction:       "ingress",
+		Action:          "allow",
+		Log:             true,
+		LogName:         "lxd_acl1",
+		Protocol:        "tcp",
+		DestinationPort: "22",
+		CounterName:     "acl1-ingress-0",
+	}
+
+	nftRule, partial, err := d.aclRuleCriteriaToRules("lxdbr0", 4, rule)
+	assert.NoError(t, err)
+	assert.False(t, partial)
+	assert.Equal(t, `oifname lxdbr0 meta l4proto tcp th dport {22} counter log prefix "lxd_acl1 " accept comment "acl1-ingress-0"`, nftRule)
+
+	rule.Log = false
+	rule.CounterName = ""
+	nftRule, _, err = d.aclRuleCriteriaToRules("lxdbr0", 4, rule)
+	assert.NoError(t, err)
+	assert.Equal(t, `oifname lxdbr0 meta l4proto tcp th dport {22} accept`, nftRule)
+}
+
+func Test_nftablesACLRuleCounters(t *testing.T) {
+	output := `{"nftables": [
+{"metainfo": {"version": "1.0.2", "release_name": "Lester Gooch", "json_schema_version": 1}},
+{"chain": {"family": "inet", "table": "lxd", "name": "aclfwd.lxdbr0", "handle": 12}},
+{"rule": {"family": "inet", "table": "lxd", "chain": "aclfwd.lxdbr0", "handle": 13, "comment": "acl1-ingress-0", "expr": [{"match": {"op": "==", "left": {"meta": {"key": "oifname"}}, "right": "lxdbr0"}}, {"counter": {"packets": 10, "bytes": 840}}, {"accept": null}]}},
+{"rule": {"family": "inet", "table": "lxd", "chain": "aclfwd.lxdbr0", "handle": 14, "comment": "acl1-ingress-0", "expr": [{"counter": {"packets": 2, "bytes": 160}}, {"accept": null}]}},
+{"rule": {"family": "inet", "table": "lxd", "chain": "aclfwd.lxdbr0", "handle": 15, "comment": "acl1-egress-0", "expr": [{"counter": {"packets": 0, "bytes": 0}}, {"drop": null}]}},
+{"rule": {"family": "inet", "table": "lxd", "chain": "aclfwd.lxdbr0", "handle": 16, "expr": [{"counter": {"packets": 5, "bytes": 500}}, {"reject": null}]}}
+]}`
+
+	counters, err := nftablesACLRuleCounters(output)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]ACLRuleCounter{
+		"acl1-ingress-0": {Packets: 12, Bytes: 1000},
+		"acl1-egress-0":  {Packets: 0, Bytes: 0},
+	}, counters)
+
+	_, err = nftablesACLRuleCounters("not json")
+	assert.Error(t, err)
+}
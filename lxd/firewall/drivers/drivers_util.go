@@ -143,3 +143,21 @@ func subnetPrefixHex(ipNet *net.IPNet) (string, error) {
 
 	return hexStr[:ones/4], nil
 }
+
+// loadBalancerTargetPort returns the port of the backend that the listen port at index i of the load balancer
+// rule is forwarded to. A backend without target ports uses the listen ports, a single target port is used for
+// all the listen ports, otherwise the target port at the same index as the listen port is used.
+func loadBalancerTargetPort(rule LoadBalancer, backend LoadBalancerBackend, i int) (uint64, error) {
+	targetPortsLen := len(backend.TargetPorts)
+
+	switch {
+	case targetPortsLen <= 0:
+		return rule.ListenPorts[i], nil
+	case targetPortsLen == 1:
+		return backend.TargetPorts[0], nil
+	case targetPortsLen == len(rule.ListenPorts):
+		return backend.TargetPorts[i], nil
+	}
+
+	return 0, fmt.Errorf("Mismatch between listen port(s) and target port(s) count for backend %q", backend.TargetAddress.String())
+}
@@ -2,6 +2,7 @@ package drivers
 
 import (
 	"log"
+	"net"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -143,3 +144,56 @@ func Test_getOptimisedSNATRanges(t *testing.T) {
 		assert.Equal(t, tt.expected, actual)
 	}
 }
+
+func Test_loadBalancerTargetPort(t *testing.T) {
+	rule := LoadBalancer{
+		ListenAddress: net.ParseIP("192.0.2.1"),
+		Protocol:      "tcp",
+		ListenPorts:   []uint64{80, 81, 82},
+	}
+
+	tests := []struct {
+		name        string
+		targetPorts []uint64
+		expected    []uint64
+		expectedErr bool
+	}{
+		{
+			name:     "No target ports",
+			expected: []uint64{80, 81, 82},
+		},
+		{
+			name:        "Single target port",
+			targetPorts: []uint64{8080},
+			expected:    []uint64{8080, 8080, 8080},
+		},
+		{
+			name:        "Matching target ports",
+			targetPorts: []uint64{8080, 8081, 8082},
+			expected:    []uint64{8080, 8081, 8082},
+		},
+		{
+			name:        "Mismatched target ports",
+			targetPorts: []uint64{8080, 8081},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		backend := LoadBalancerBackend{
+			TargetAddress: net.ParseIP("198.51.100.2"),
+			TargetPorts:   tt.targetPorts,
+		}
+
+		for i := range rule.ListenPorts {
+			actual, err := loadBalancerTargetPort(rule, backend, i)
+			if tt.expectedErr {
+				assert.Error(t, err, tt.name)
+				continue
+			}
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, tt.expected[i], actual, tt.name)
+		}
+	}
+}
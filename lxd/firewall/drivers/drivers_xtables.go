@@ -153,6 +153,94 @@ func (d Xtables) ebtablesInUse() bool {
 	return false
 }
 
+// NetworkApplyLoadBalancers apply network load balancer rules to firewall.
+func (d Xtables) NetworkApplyLoadBalancers(networkName string, rules []LoadBalancer) error {
+	comment := d.networkLoadBalancerIPTablesComment(networkName)
+
+	// Clear any load balancer rules associated to the network.
+	for _, ipVersion := range []uint{4, 6} {
+		err := d.iptablesClear(ipVersion, []string{comment}, "nat")
+		if err != nil {
+			return err
+		}
+	}
+
+	for ruleIndex, rule := range rules {
+		if rule.ListenAddress == nil {
+			return fmt.Errorf("Invalid rule %d, listen address is required", ruleIndex)
+		}
+
+		if rule.Protocol == "" || len(rule.ListenPorts) < 1 {
+			return fmt.Errorf("Invalid rule %d, protocol and listen port(s) are required", ruleIndex)
+		}
+
+		ipVersion := uint(4)
+		if rule.ListenAddress.To4() == nil {
+			ipVersion = 6
+		}
+
+		listenAddressStr := rule.ListenAddress.String()
+		backendsLen := len(rule.Backends)
+
+		for i := range rule.ListenPorts {
+			listenPortStr := fmt.Sprintf("%d", rule.ListenPorts[i])
+
+			// Rules are prepended, so add the backends in reverse order for the first backend to be matched first.
+			for backendIndex := backendsLen - 1; backendIndex >= 0; backendIndex-- {
+				backend := rule.Backends[backendIndex]
+				if backend.TargetAddress == nil {
+					return fmt.Errorf("Invalid rule %d, backend target address is required", ruleIndex)
+				}
+
+				targetPort, err := loadBalancerTargetPort(rule, backend, i)
+				if err != nil {
+					return fmt.Errorf("Invalid rule %d: %w", ruleIndex, err)
+				}
+
+				targetAddressStr := backend.TargetAddress.String()
+				targetPortStr := fmt.Sprintf("%d", targetPort)
+
+				// Format the destination host/port as appropriate.
+				targetDest := fmt.Sprintf("%s:%d", targetAddressStr, targetPort)
+				if ipVersion == 6 {
+					targetDest = fmt.Sprintf("[%s]:%d", targetAddressStr, targetPort)
+				}
+
+				// Each backend picks the connections not taken by the previous ones with a probability of
+				// 1 in the number of remaining backends, which spreads the connections evenly.
+				args := []string{"-p", rule.Protocol, "--destination", listenAddressStr, "--dport", listenPortStr}
+				if backendIndex < backendsLen-1 {
+					probability := 1 / float64(backendsLen-backendIndex)
+					args = append(args, "-m", "statistic", "--mode", "random", "--probability", fmt.Sprintf("%.5f", probability))
+				}
+
+				args = append(args, "-j", "DNAT", "--to-destination", targetDest)
+
+				// outbound <-> instance.
+				err = d.iptablesPrepend(ipVersion, comment, "nat", "PREROUTING", args...)
+				if err != nil {
+					return err
+				}
+
+				// host <-> instance.
+				err = d.iptablesPrepend(ipVersion, comment, "nat", "OUTPUT", args...)
+				if err != nil {
+					return err
+				}
+
+				// instance <-> instance.
+				// Requires instance's bridge port has hairpin mode enabled when br_netfilter is loaded.
+				err = d.iptablesPrepend(ipVersion, comment, "nat", "POSTROUTING", "-p", rule.Protocol, "--source", targetAddressStr, "--destination", targetAddressStr, "--dport", targetPortStr, "-j", "MASQUERADE")
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 // networkIPTablesComment returns the iptables comment that is added to each network related rule.
 func (d Xtables) networkIPTablesComment(networkName string) string {
 	return fmt.Sprintf("LXD network %s", networkName)
@@ -163,6 +251,12 @@ func (d Xtables) networkForwardIPTablesComment(networkName string) string {
 	return fmt.Sprintf("LXD network-forward %s", networkName)
 }
 
+// networkLoadBalancerIPTablesComment returns the iptables comment that is added to each network load balancer
+// related rule.
+func (d Xtables) networkLoadBalancerIPTablesComment(networkName string) string {
+	return fmt.Sprintf("LXD network-load-balancer %s", networkName)
+}
+
 // networkSetupNICFilteringChain creates the NIC filtering chain if it doesn't exist, and adds the jump rules to
 // the INPUT and FORWARD filter chains. Must be called after networkSetupForwardingPolicy so that the rules are
 // prepended before the default fowarding policy rules.
@@ -678,12 +772,10 @@ func (d Xtables) aclRuleCriteriaToArgs(networkName string, ipVersion uint, rule
 		action = "accept"
 	}
 
-	actionArgs := append(args, "-j", strings.ToUpper(action))
-
 	// Handle logging.
 	var logArgs []string
 	if rule.Log {
-		logArgs = append(args, "-j", "LOG")
+		logArgs = append(append([]string{}, args...), "-j", "LOG")
 
 		if rule.LogName != "" {
 			// Add a trailing space to prefix for readability in logs.
@@ -691,9 +783,83 @@ func (d Xtables) aclRuleCriteriaToArgs(networkName string, ipVersion uint, rule
 		}
 	}
 
+	// Handle counters (only on the action rule so that logged packets aren't counted twice).
+	if rule.CounterName != "" {
+		args = append(args, "-m", "comment", "--comment", rule.CounterName)
+	}
+
+	actionArgs := append(args, "-j", strings.ToUpper(action))
+
 	return actionArgs, logArgs, nil
 }
 
+// NetworkACLRuleCounters returns the hit counters of the ACL rules applied to a network, keyed by counter name.
+// The counters of the IPv4 and IPv6 rules are summed up.
+func (d Xtables) NetworkACLRuleCounters(networkName string) (map[string]ACLRuleCounter, error) {
+	chain := fmt.Sprintf("%s_%s", iptablesChainACLFilterPrefix, networkName)
+
+	counters := map[string]ACLRuleCounter{}
+	for _, cmd := range []string{"iptables", "ip6tables"} {
+		// Dump the rules of the chain along with their counters.
+		// E.g. "-A lxd_acl_lxdbr0 -o lxdbr0 -m comment --comment acl1-ingress-0 -j ACCEPT -c 10 840"
+		output, err := shared.RunCommand(cmd, "-w", "-t", "filter", "-S", chain, "-v")
+		if err != nil {
+			return nil, fmt.Errorf("Failed listing %q chain %q in table %q: %w", cmd, chain, "filter", err)
+		}
+
+		err = xtablesACLRuleCounters(output, counters)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return counters, nil
+}
+
+// xtablesACLRuleCounters adds the counters of the ACL rules found in the output of "iptables -S -v" to the
+// counters map, keyed by the rule comment.
+func xtablesACLRuleCounters(output string, counters map[string]ACLRuleCounter) error {
+	var err error
+
+	for _, line := range shared.SplitNTrimSpace(strings.TrimSpace(output), "\n", -1, true) {
+		fields := strings.Fields(line)
+
+		var name string
+		var counter ACLRuleCounter
+		for i := 0; i < len(fields)-1; i++ {
+			switch fields[i] {
+			case "--comment":
+				name = strings.Trim(fields[i+1], `"`)
+			case "-c":
+				if i+2 >= len(fields) {
+					continue
+				}
+
+				counter.Packets, err = strconv.ParseUint(fields[i+1], 10, 64)
+				if err != nil {
+					return fmt.Errorf("Failed parsing packet counter in rule %q: %w", line, err)
+				}
+
+				counter.Bytes, err = strconv.ParseUint(fields[i+2], 10, 64)
+				if err != nil {
+					return fmt.Errorf("Failed parsing byte counter in rule %q: %w", line, err)
+				}
+			}
+		}
+
+		if name == "" {
+			continue
+		}
+
+		total := counters[name]
+		total.Packets += counter.Packets
+		total.Bytes += counter.Bytes
+		counters[name] = total
+	}
+
+	return nil
+}
+
 // aclRuleSubjectToACLMatch converts direction (source/destination) and subject criteria list into xtables args.
 // Returns nil if none of the subjects are appropriate for the ipVersion.
 func (d Xtables) aclRuleSubjectToACLMatch(direction string, ipVersion uint, subjectCriteria ...string) ([]string, error) {
@@ -752,10 +918,11 @@ func (d Xtables) NetworkClear(networkName string, delete bool, ipVersions []uint
 	comments := []string{
 		d.networkIPTablesComment(networkName),
 		d.networkForwardIPTablesComment(networkName),
+		d.networkLoadBalancerIPTablesComment(networkName),
 	}
 
 	for _, ipVersion := range ipVersions {
-		// Clear any rules associated to the network and network address forwards.
+		// Clear any rules associated to the network, network address forwards and load balancers.
 		err := d.iptablesClear(ipVersion, comments, "filter", "mangle", "nat")
 		if err != nil {
 			return err
@@ -0,0 +1,31 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_xtablesACLRuleCounters(t *testing.T) {
+	output := `-N lxd_acl_lxdbr0
+-A lxd_acl_lxdbr0 -o lxdbr0 -p tcp -m tcp --dport 22 -j LOG --log-prefix "lxd_acl1 " -c 3 180
+-A lxd_acl_lxdbr0 -o lxdbr0 -p tcp -m tcp --dport 22 -m comment --comment acl1-ingress-0 -j ACCEPT -c 10 840
+-A lxd_acl_lxdbr0 -i lxdbr0 -m comment --comment "acl1-egress-0" -j DROP -c 0 0
+-A lxd_acl_lxdbr0 -j REJECT --reject-with icmp-port-unreachable -c 5 500`
+
+	counters := map[string]ACLRuleCounter{}
+	err := xtablesACLRuleCounters(output, counters)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]ACLRuleCounter{
+		"acl1-ingress-0": {Packets: 10, Bytes: 840},
+		"acl1-egress-0":  {Packets: 0, Bytes: 0},
+	}, counters)
+
+	// IPv6 rules are added to the IPv4 ones.
+	err = xtablesACLRuleCounters(`-A lxd_acl_lxdbr0 -o lxdbr0 -m comment --comment acl1-ingress-0 -j ACCEPT -c 2 160`, counters)
+	assert.NoError(t, err)
+	assert.Equal(t, ACLRuleCounter{Packets: 12, Bytes: 1000}, counters["acl1-ingress-0"])
+
+	err = xtablesACLRuleCounters(`-A lxd_acl_lxdbr0 -m comment --comment acl1-ingress-0 -j ACCEPT -c x 160`, counters)
+	assert.Error(t, err)
+}
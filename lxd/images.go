@@ -2175,6 +2175,11 @@ func pruneLeftoverImages(d *Daemon) {
 
 		// Check and delete leftovers
 		for _, entry := range entries {
+			// The image chunk store is cleaned up separately.
+			if entry.Name() == "chunks" {
+				continue
+			}
+
 			fp := strings.Split(entry.Name(), ".")[0]
 			if !shared.StringInSlice(fp, images) {
 				err = os.RemoveAll(shared.VarPath("images", entry.Name()))
@@ -2186,6 +2191,11 @@ func pruneLeftoverImages(d *Daemon) {
 			}
 		}
 
+		err = imageChunkStoreGC(d.State())
+		if err != nil {
+			return err
+		}
+
 		return nil
 	}
 
@@ -2243,6 +2253,12 @@ func pruneExpiredImages(ctx context.Context, d *Daemon, op *operations.Operation
 		}
 	}
 
+	// Drop the files that only the pruned images were using from the image chunk store.
+	err = imageChunkStoreGC(d.State())
+	if err != nil {
+		return fmt.Errorf("Unable to clean up image chunk store: %w", err)
+	}
+
 	return nil
 }
 
@@ -2479,6 +2495,12 @@ func imageDelete(d *Daemon, r *http.Request) response.Response {
 		// Remove main image file from disk.
 		imageDeleteFromDisk(imgInfo.Fingerprint)
 
+		// Drop the files that only the deleted image was using from the image chunk store.
+		err = imageChunkStoreGC(d.State())
+		if err != nil {
+			logger.Warn("Failed cleaning up image chunk store", logger.Ctx{"err": err})
+		}
+
 		d.State().Events.SendLifecycle(projectName, lifecycle.ImageDeleted.Event(imgInfo.Fingerprint, projectName, op.Requestor(), nil))
 
 		return nil
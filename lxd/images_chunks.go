@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/storage/chunkstore"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// imageChunkStoreGC removes the images that are no longer referenced by any project of the local member from the
+// image chunk store, along with the file contents only they were using.
+// Images whose files were already removed from disk are also removed, as their database records may only go away
+// after the other cluster members have been notified of the deletion.
+func imageChunkStoreGC(s *state.State) error {
+	store := chunkstore.New(shared.VarPath("images", "chunks"))
+
+	// Build the list of images to keep while the store is locked, so that images added meanwhile are retained.
+	removed, err := store.GC(func(fingerprints []string) ([]string, error) {
+		var refcounts map[string]int
+		err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			var err error
+			refcounts, err = tx.GetLocalImagesRefcounts()
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Failed getting image references: %w", err)
+		}
+
+		keep := make([]string, 0, len(fingerprints))
+		for _, fingerprint := range fingerprints {
+			if refcounts[fingerprint] > 0 && shared.PathExists(shared.VarPath("images", fingerprint)) {
+				keep = append(keep, fingerprint)
+			}
+		}
+
+		return keep, nil
+	})
+	if err != nil {
+		return fmt.Errorf("Failed cleaning up chunk store: %w", err)
+	}
+
+	if removed > 0 {
+		logger.Debug("Removed unused objects from image chunk store", logger.Ctx{"objects": removed})
+	}
+
+	return nil
+}
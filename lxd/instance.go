@@ -23,6 +23,7 @@ import (
 	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/lxd/warnings"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
@@ -539,6 +540,20 @@ func autoCreateContainerSnapshots(ctx context.Context, d *Daemon, instances []in
 			err = c.Snapshot(snapshotName, expiry, false)
 			if err != nil {
 				logger.Error("Error creating snapshots", logger.Ctx{"err": err, "container": c})
+
+				warnErr := d.db.Cluster.UpsertWarningLocalNode(c.Project(), dbCluster.TypeInstance, c.ID(), db.WarningScheduledSnapshotFailure, fmt.Sprintf("%v", err))
+				if warnErr != nil {
+					logger.Warn("Failed to create scheduled snapshot failure warning", logger.Ctx{"err": warnErr, "container": c})
+				}
+
+				ch <- nil
+				return
+			}
+
+			// Resolve any previous warning.
+			warnErr := warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(d.db.Cluster, c.Project(), db.WarningScheduledSnapshotFailure, dbCluster.TypeInstance, c.ID())
+			if warnErr != nil {
+				logger.Warn("Failed to resolve scheduled snapshot failure warning", logger.Ctx{"err": warnErr, "container": c})
 			}
 
 			ch <- nil
@@ -1323,6 +1323,17 @@ func (d *lxc) RegisterDevices() {
 	d.devicesRegister(d)
 }
 
+// ReloadDevice re-applies the unchanged configuration of a device through its Update function.
+// This is used when settings outside of the device config that the device depends on have changed.
+func (d *lxc) ReloadDevice(deviceName string) error {
+	config, ok := d.expandedDevices[deviceName]
+	if !ok {
+		return fmt.Errorf("Device %q not found", deviceName)
+	}
+
+	return d.devicesUpdate(d, nil, nil, deviceConfig.Devices{deviceName: config}, d.expandedDevices, d.IsRunning(), true)
+}
+
 // deviceStart loads a new device and calls its Start() function.
 func (d *lxc) deviceStart(dev device.Device, instanceRunning bool) (*deviceConfig.RunConfig, error) {
 	configCopy := dev.Config()
@@ -3859,7 +3870,7 @@ func (d *lxc) CGroupSet(key string, value string) error {
 // Update applies updated config.
 func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 	// Setup a new operation
-	op, err := operationlock.CreateWaitGet(d.Project(), d.Name(), operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionRestart, operationlock.ActionRestore}, false, false)
+	op, err := operationlock.CreateWaitGet(d.Project(), d.Name(), operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionRestart, operationlock.ActionRestore, operationlock.ActionAutoscale}, false, false)
 	if err != nil {
 		return fmt.Errorf("Failed to create instance update operation: %w", err)
 	}
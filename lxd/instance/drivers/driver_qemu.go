@@ -49,6 +49,7 @@ import (
 	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/metrics"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/resources"
 	"github.com/lxc/lxd/lxd/response"
@@ -93,6 +94,18 @@ const qemuBlockDevIDPrefix = "lxd_"
 // 4 are reserved, and the other 4 can be used for any USB device.
 const qemuSparseUSBPorts = 8
 
+// qemuCPUIDPrefix used as part of the name given to hotplugged vCPUs.
+const qemuCPUIDPrefix = "lxd_cpu"
+
+// qemuMemoryDIMMIDPrefix used as part of the name given to hotplugged memory DIMMs.
+const qemuMemoryDIMMIDPrefix = "lxd_dimm"
+
+// qemuMemoryHotplugSlots is the number of memory slots reserved for hotplug when limits.memory.max is set.
+const qemuMemoryHotplugSlots = 32
+
+// qemuMemoryHotplugAlignment is the size hotplugged memory is rounded up to (the Linux memory block size).
+const qemuMemoryHotplugAlignment = 128 * 1024 * 1024
+
 var errQemuAgentOffline = fmt.Errorf("LXD VM agent isn't currently running")
 
 type monitorHook func(m *qmp.Monitor) error
@@ -299,6 +312,9 @@ type qemu struct {
 	// Do not use these variables directly, instead use their associated get functions so they
 	// will be initialised on demand.
 	architectureName string
+
+	// Set while the VM is started from a live migration by MigrateReceive.
+	migrationReceiveArgs *instance.VMMigrateReceiveArgs
 }
 
 // getAgentClient returns the current agent client handle. To avoid TLS setup each time this
@@ -1211,6 +1227,15 @@ func (d *qemu) Start(stateful bool) error {
 		return err
 	}
 
+	// Forget about memory hotplugged during a previous run unless its state is being restored.
+	if !stateful && (d.localConfig["volatile.memory.base"] != "" || d.localConfig["volatile.memory.hotplugged"] != "") {
+		err = d.VolatileSet(map[string]string{"volatile.memory.base": "", "volatile.memory.hotplugged": ""})
+		if err != nil {
+			op.Done(err)
+			return err
+		}
+	}
+
 	// Define a set of files to open and pass their file descriptors to qemu command.
 	fdFiles := make([]*os.File, 0)
 
@@ -1445,6 +1470,16 @@ func (d *qemu) Start(stateful bool) error {
 		return err
 	}
 
+	// Hotplug the remaining vCPUs if booting with a single one.
+	cpuCount, err := strconv.Atoi(d.expandedConfig["limits.cpu"])
+	if err == nil && d.expandedConfig["limits.cpu.max"] != "" {
+		err = d.setCPUs(monitor, cpuCount)
+		if err != nil {
+			op.Done(err)
+			return err
+		}
+	}
+
 	// Get the list of PIDs from the VM.
 	pids, err := monitor.GetCPUs()
 	if err != nil {
@@ -1512,7 +1547,12 @@ func (d *qemu) Start(stateful bool) error {
 
 	// Restore the state.
 	if stateful {
-		err := d.restoreState(monitor)
+		if d.migrationReceiveArgs != nil {
+			err = d.migrateReceiveState(monitor, d.migrationReceiveArgs)
+		} else {
+			err = d.restoreState(monitor)
+		}
+
 		if err != nil {
 			op.Done(err)
 			return err
@@ -1647,6 +1687,17 @@ func (d *qemu) RegisterDevices() {
 	d.devicesRegister(d)
 }
 
+// ReloadDevice re-applies the unchanged configuration of a device through its Update function.
+// This is used when settings outside of the device config that the device depends on have changed.
+func (d *qemu) ReloadDevice(deviceName string) error {
+	config, ok := d.expandedDevices[deviceName]
+	if !ok {
+		return fmt.Errorf("Device %q not found", deviceName)
+	}
+
+	return d.devicesUpdate(d, nil, nil, deviceConfig.Devices{deviceName: config}, d.expandedDevices, d.IsRunning(), true)
+}
+
 // SaveConfigFile is not used by VMs because the Qemu config file is generated at start up and is not needed
 // after that, so doesn't need to support being regenerated.
 func (d *qemu) SaveConfigFile() error {
@@ -1746,6 +1797,55 @@ func (d *qemu) deviceAttachBlockDevice(deviceName string, configCopy map[string]
 	return nil
 }
 
+// deviceSetBlockLimits applies the IO limits from the supplied mount options (if any) to a disk device.
+// The limits apply to the throttle group from the mount options if set, otherwise to one of the device's own.
+func (d *qemu) deviceSetBlockLimits(m *qmp.Monitor, deviceName string, opts []string) error {
+	escapedDeviceName := filesystem.PathNameEncode(deviceName)
+	deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, escapedDeviceName)
+
+	var limits []int64
+	group := deviceID
+	for _, opt := range opts {
+		value := strings.TrimPrefix(opt, fmt.Sprintf("%s=", device.DiskIOGroupMountOpt))
+		if value != opt {
+			group = value
+			continue
+		}
+
+		value = strings.TrimPrefix(opt, fmt.Sprintf("%s=", device.DiskIOLimitsMountOpt))
+		if value == opt {
+			continue
+		}
+
+		// Expect value in format "<read bps>:<write bps>:<read iops>:<write iops>".
+		fields := strings.Split(value, ":")
+		if len(fields) != 4 {
+			return fmt.Errorf("Unexpected disk IO limits format %q", value)
+		}
+
+		limits = make([]int64, 0, len(fields))
+		for _, field := range fields {
+			limit, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return fmt.Errorf("Invalid disk IO limit %q: %w", field, err)
+			}
+
+			limits = append(limits, limit)
+		}
+	}
+
+	if limits == nil {
+		return nil
+	}
+
+	err := m.SetBlockThrottle(deviceID, group, limits[0], limits[1], limits[2], limits[3])
+	if err != nil {
+		return fmt.Errorf("Failed applying IO limits to disk device %q: %w", deviceName, err)
+	}
+
+	return nil
+}
+
 func (d *qemu) deviceDetachBlockDevice(deviceName string, rawConfig deviceConfig.Device) error {
 	// Check if the agent is running.
 	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
@@ -1788,224 +1888,1267 @@ func (d *qemu) deviceDetachBlockDevice(deviceName string, rawConfig deviceConfig
 	return nil
 }
 
-// deviceAttachNIC live attaches a NIC device to the instance.
-func (d *qemu) deviceAttachNIC(deviceName string, configCopy map[string]string, netIF []deviceConfig.RunConfigItem) error {
-	devName := ""
-	for _, dev := range netIF {
-		if dev.Key == "link" {
-			devName = dev.Value
-			break
-		}
+// RemountConfigDrive moves the config drive share of the running VM onto the config directory of its current
+// instance volume after the volume was moved to another storage pool. The previous bind mount is lazily detached as
+// QEMU keeps the directory it opened for the 9p share at start, and virtiofsd is restarted on the new mount.
+func (d *qemu) RemountConfigDrive() error {
+	if !d.IsRunning() {
+		return fmt.Errorf("Instance is not running")
 	}
 
-	if devName == "" {
-		return fmt.Errorf("Device didn't provide a link property to use")
-	}
+	configMntPath := d.configDriveMountPath()
+	configSockPath, configPIDPath := d.configVirtiofsdPaths()
+	virtiofsd := shared.PathExists(configPIDPath)
 
-	_, qemuBus, err := d.qemuArchConfig(d.architecture)
+	err := device.DiskVMVirtiofsdStop(configSockPath, configPIDPath)
 	if err != nil {
-		return err
+		return fmt.Errorf("Failed stopping virtiofsd for config drive: %w", err)
 	}
 
-	// Check if the agent is running.
-	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
-	if err != nil {
-		return err
+	if filesystem.IsMountPoint(configMntPath) {
+		err = unix.Unmount(configMntPath, unix.MNT_DETACH)
+		if err != nil {
+			return fmt.Errorf("Failed unmounting config drive mount path %q: %w", configMntPath, err)
+		}
 	}
 
-	qemuDev := make(map[string]string)
+	err = device.DiskMount(filepath.Join(d.Path(), "config"), configMntPath, true, false, "", nil, "none")
+	if err != nil {
+		return fmt.Errorf("Failed mounting device mount path %q for config drive: %w", configMntPath, err)
+	}
 
-	// PCIe and PCI require a port device name to hotplug the NIC into.
-	if shared.StringInSlice(qemuBus, []string{"pcie", "pci"}) {
-		pciDevID := qemuPCIDeviceIDStart
+	if virtiofsd {
+		_, unixListener, err := device.DiskVMVirtiofsdStart(d.state.OS.ExecPath, d, configSockPath, configPIDPath, "", configMntPath, nil)
+		if err != nil {
+			return fmt.Errorf("Failed to setup virtiofsd for config drive: %w", err)
+		}
 
-		// Iterate through all the instance devices in the same sorted order as is used when allocating the
-		// boot time devices in order to find the PCI bus slot device we would have used at boot time.
-		// Then attempt to use that same device, assuming it is available.
-		for _, dev := range d.expandedDevices.Sorted() {
-			if dev.Name == deviceName {
-				break // Found our device.
-			}
+		_ = unixListener.Close()
+	}
 
-			pciDevID++
-		}
+	return nil
+}
 
-		pciDeviceName := fmt.Sprintf("%s%d", busDevicePortPrefix, pciDevID)
-		d.logger.Debug("Using PCI bus device to hotplug NIC into", logger.Ctx{"device": deviceName, "port": pciDeviceName})
-		qemuDev["bus"] = pciDeviceName
-		qemuDev["addr"] = "00.0"
+// MirrorRootDisk mirrors the root disk of the running VM onto the disk at diskPath and switches the VM over to it
+// once both are in sync. The switchover function is called just before switching over and the mirror is cancelled
+// (leaving the VM on its original root disk) if it returns an error.
+func (d *qemu) MirrorRootDisk(diskPath string, switchover func() error, op *operations.Operation) error {
+	if !d.IsRunning() {
+		return fmt.Errorf("Instance is not running")
 	}
 
-	cpuCount, err := d.addCPUMemoryConfig(nil)
+	rootDiskName, _, err := shared.GetRootDiskDevice(d.expandedDevices.CloneNative())
 	if err != nil {
 		return err
 	}
 
-	monHook, err := d.addNetDevConfig(cpuCount, qemuBus, qemuDev, nil, netIF)
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
 	if err != nil {
-		return err
+		return fmt.Errorf("Failed to connect to QMP monitor: %w", err)
 	}
 
-	err = monHook(monitor)
+	escapedDeviceName := filesystem.PathNameEncode(rootDiskName)
+	deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, escapedDeviceName)
+	jobID := fmt.Sprintf("%s-mirror", deviceID)
+
+	// The root disk alternates between two block node names each time it is mirrored.
+	nodeNames := []string{d.blockNodeName(escapedDeviceName), d.blockNodeName(fmt.Sprintf("%s-mirror", escapedDeviceName))}
+	fdNames := map[string]string{
+		nodeNames[0]: fmt.Sprintf("%s%s", qemuBlockDevIDPrefix, escapedDeviceName),
+		nodeNames[1]: nodeNames[1],
+	}
+
+	blockNodes, err := monitor.GetBlockNodeNames()
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	nodeName := blockNodes[deviceID]
+	if nodeName == "" {
+		return fmt.Errorf("Failed finding block node of root disk device %q", rootDiskName)
+	}
 
-// deviceStop loads a new device and calls its Stop() function.
-func (d *qemu) deviceStop(dev device.Device, instanceRunning bool, _ string) error {
-	configCopy := dev.Config()
-	l := d.logger.AddContext(logger.Ctx{"device": dev.Name(), "type": configCopy["type"]})
-	l.Debug("Stopping device")
+	// The root disk may also be using another block node after a qcow2 snapshot was taken.
+	targetNodeName := nodeNames[1]
+	if nodeName == nodeNames[1] {
+		targetNodeName = nodeNames[0]
+	}
 
-	if instanceRunning && !dev.CanHotPlug() {
-		return fmt.Errorf("Device cannot be stopped when instance is running")
+	_, ok := fdNames[nodeName]
+	if !ok {
+		fdNames[nodeName] = nodeName
 	}
 
-	runConf, err := dev.Stop()
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Pass the target disk to QEMU.
+	f, err := os.OpenFile(diskPath, unix.O_RDWR, 0)
 	if err != nil {
-		return err
+		return fmt.Errorf("Failed opening target disk %q: %w", diskPath, err)
 	}
 
-	if instanceRunning {
-		// Detach NIC from running instance.
-		if configCopy["type"] == "nic" {
-			err = d.deviceDetachNIC(dev.Name())
-			if err != nil {
-				return err
-			}
-		}
+	defer func() { _ = f.Close() }()
 
-		// Detach USB drom running instance.
-		if configCopy["type"] == "usb" && runConf != nil {
-			for _, usbDev := range runConf.USBDevice {
-				err = d.deviceDetachUSB(usbDev)
-				if err != nil {
-					return err
-				}
-			}
+	info, err := monitor.SendFileWithFDSet(fdNames[targetNodeName], f, false)
+	if err != nil {
+		return fmt.Errorf("Failed sending file descriptor of %q: %w", diskPath, err)
+	}
+
+	revert.Add(func() { _ = monitor.RemoveFDFromFDSet(fdNames[targetNodeName]) })
+
+	blockDev := map[string]any{
+		"aio": "native",
+		"cache": map[string]any{
+			"direct":   true,
+			"no-flush": false,
+		},
+		"discard":   "unmap",
+		"driver":    "file",
+		"filename":  fmt.Sprintf("/dev/fdset/%d", info.ID),
+		"locking":   "off",
+		"node-name": targetNodeName,
+		"read-only": false,
+	}
+
+	if shared.IsBlockdevPath(diskPath) {
+		blockDev["driver"] = "host_device"
+	} else {
+		fsType, err := filesystem.Detect(diskPath)
+		if err != nil {
+			return fmt.Errorf("Failed detecting filesystem type of %q: %w", diskPath, err)
 		}
 
-		// Detach disk from running instance.
-		if configCopy["type"] == "disk" {
-			err = d.deviceDetachBlockDevice(dev.Name(), configCopy)
-			if err != nil {
-				return err
+		// Same as when attaching disks, avoid using direct I/O on ZFS and BTRFS backed image files.
+		if fsType == "zfs" || fsType == "btrfs" {
+			blockDev["aio"] = "threads"
+			blockDev["cache"] = map[string]any{
+				"direct":   false,
+				"no-flush": false,
 			}
 		}
 	}
 
-	if runConf != nil {
-		// Run post stop hooks irrespective of run state of instance.
-		err = d.runHooks(runConf.PostHooks)
-		if err != nil {
-			return err
-		}
+	err = monitor.AddBlockDevice(blockDev, nil)
+	if err != nil {
+		return fmt.Errorf("Failed adding target block device: %w", err)
 	}
 
-	return nil
-}
+	revert.Add(func() { _ = monitor.RemoveBlockDevice(targetNodeName) })
 
-// deviceDetachNIC detaches a NIC device from a running instance.
-func (d *qemu) deviceDetachNIC(deviceName string) error {
-	// Check if the agent is running.
-	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	// Mirror the root disk and wait for the target to be in sync.
+	err = monitor.BlockDevMirror(jobID, nodeName, targetNodeName, "full")
 	if err != nil {
 		return err
 	}
 
-	// pciDeviceExists checks if the deviceID exists as a bridged PCI device.
-	pciDeviceExists := func(deviceID string) (bool, error) {
-		pciDevs, err := monitor.QueryPCI()
-		if err != nil {
-			return false, err
-		}
-
-		for _, pciDev := range pciDevs {
-			for _, bridgeDev := range pciDev.Bridge.Devices {
-				if bridgeDev.DevID == deviceID {
-					return true, nil
-				}
-			}
-		}
+	revert.Add(func() {
+		_ = monitor.BlockJobCancel(jobID)
+		_ = d.waitBlockJob(monitor, jobID, false, nil)
+	})
 
-		return false, nil
+	err = d.waitBlockJob(monitor, jobID, true, op)
+	if err != nil {
+		return err
 	}
 
-	escapedDeviceName := filesystem.PathNameEncode(deviceName)
-	deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, escapedDeviceName)
-	netDevID := fmt.Sprintf("%s%s", qemuNetDevIDPrefix, escapedDeviceName)
-
-	// Request removal of device.
-	err = monitor.RemoveDevice(deviceID)
+	err = switchover()
 	if err != nil {
-		return fmt.Errorf("Failed removing NIC device: %w", err)
+		return err
 	}
 
-	err = monitor.RemoveNIC(netDevID)
+	// Switch the root disk device over to the target and wait for the mirror job to finish.
+	err = monitor.BlockJobComplete(jobID)
 	if err != nil {
 		return err
 	}
 
-	_, qemuBus, err := d.qemuArchConfig(d.architecture)
+	err = d.waitBlockJob(monitor, jobID, false, nil)
 	if err != nil {
 		return err
 	}
 
-	if shared.StringInSlice(qemuBus, []string{"pcie", "pci"}) {
-		// Wait until the device is actually removed (or we timeout waiting).
-		waitDuration := time.Duration(time.Second * time.Duration(10))
-		waitUntil := time.Now().Add(waitDuration)
-		for {
-			devExists, err := pciDeviceExists(deviceID)
-			if err != nil {
-				return fmt.Errorf("Failed getting PCI devices to check for NIC detach: %w", err)
-			}
+	blockNodes, err = monitor.GetBlockNodeNames()
+	if err != nil {
+		return err
+	}
 
-			if !devExists {
-				break
+	if blockNodes[deviceID] != targetNodeName {
+		return fmt.Errorf("Failed switching root disk device %q over to the mirrored disk", rootDiskName)
+	}
 
-			}
+	revert.Success()
 
-			if time.Now().After(waitUntil) {
-				return fmt.Errorf("Failed to detach NIC after %v: %w", waitDuration, err)
-			}
+	// Release the original root disk.
+	err = monitor.RemoveBlockDevice(nodeName)
+	if err != nil {
+		d.logger.Warn("Failed removing original root disk block device", logger.Ctx{"node": nodeName, "err": err})
+	}
 
-			d.logger.Debug("Waiting for NIC device to be detached", logger.Ctx{"device": deviceName})
-			time.Sleep(time.Second * time.Duration(2))
-		}
+	err = monitor.RemoveFDFromFDSet(fdNames[nodeName])
+	if err != nil {
+		d.logger.Warn("Failed removing original root disk file descriptor", logger.Ctx{"node": nodeName, "err": err})
 	}
 
 	return nil
 }
 
-func (d *qemu) monitorPath() string {
-	return filepath.Join(d.LogPath(), "qemu.monitor")
-}
+// waitBlockJob waits for a block job to be ready to complete (if ready is true, failing if the job disappears) or
+// to have finished. The progress of the job is reported in the operation metadata if op isn't nil.
+func (d *qemu) waitBlockJob(monitor *qmp.Monitor, jobID string, ready bool, op *operations.Operation) error {
+	for {
+		jobs, err := monitor.GetBlockJobs()
+		if err != nil {
+			return err
+		}
 
-func (d *qemu) nvramPath() string {
-	return filepath.Join(d.Path(), "qemu.nvram")
-}
+		done, progress, err := qemuBlockJobState(jobs, jobID, ready)
+		if err != nil || done {
+			return err
+		}
 
-func (d *qemu) consolePath() string {
-	return filepath.Join(d.LogPath(), "qemu.console")
-}
+		if op != nil && progress != "" {
+			_ = op.UpdateMetadata(map[string]any{"mirror_progress": progress})
+		}
 
-func (d *qemu) spicePath() string {
-	return filepath.Join(d.LogPath(), "qemu.spice")
+		time.Sleep(1 * time.Second)
+	}
 }
 
-func (d *qemu) spiceCmdlineConfig() string {
-	return fmt.Sprintf("unix=on,disable-ticketing=on,addr=%s", d.spicePath())
-}
+// qemuBlockJobState evaluates the state of block job jobID in jobs. It returns whether the job is ready to complete
+// (if ready is true) or has finished (if ready is false), along with its progress when known. An error is returned
+// if the job failed, stopped on an I/O error or disappeared before being ready.
+func qemuBlockJobState(jobs map[string]qmp.BlockJob, jobID string, ready bool) (bool, string, error) {
+	job, found := jobs[jobID]
+	if !found {
+		if ready {
+			return false, "", fmt.Errorf("Block job %q failed", jobID)
+		}
 
-// generateConfigShare generates the config share directory that will be exported to the VM via
-// a 9P share. Due to the unknown size of templates inside the images this directory is created
-// inside the VM's config volume so that it can be restricted by quota.
-// Requires the instance be mounted before calling this function.
-func (d *qemu) generateConfigShare() error {
+		return true, "", nil
+	}
+
+	if job.Error != "" {
+		return false, "", fmt.Errorf("Block job %q failed: %s", jobID, job.Error)
+	}
+
+	// A job stopped by an I/O error stays paused until resumed or cancelled, so don't wait for it.
+	if job.IOStatus != "" && job.IOStatus != "ok" {
+		return false, "", fmt.Errorf("Block job %q stopped on I/O error (%s)", jobID, job.IOStatus)
+	}
+
+	switch job.Status {
+	case "aborting":
+		// A cancelled job is expected to go away when waiting for it to finish.
+		if ready {
+			return false, "", fmt.Errorf("Block job %q was aborted", jobID)
+		}
+
+		return false, "", nil
+	case "concluded", "null":
+		if ready {
+			return false, "", fmt.Errorf("Block job %q ended before being ready", jobID)
+		}
+
+		return true, "", nil
+	}
+
+	if ready && (job.Ready || job.Status == "ready") {
+		return true, "", nil
+	}
+
+	if job.Len <= 0 {
+		return false, "", nil
+	}
+
+	return false, fmt.Sprintf("%d%%", job.Offset*100/job.Len), nil
+}
+
+// diskImageNodeName returns the block node name to use for the disk image file at path of a disk device.
+// The name is derived from the file's inode so that it doesn't change when the file is moved.
+func (d *qemu) diskImageNodeName(escapedDeviceName string, path string) (string, error) {
+	var stat unix.Stat_t
+	err := unix.Stat(path, &stat)
+	if err != nil {
+		return "", fmt.Errorf("Failed getting info of disk image %q: %w", path, err)
+	}
+
+	return d.blockNodeName(fmt.Sprintf("%s-%d", escapedDeviceName, stat.Ino)), nil
+}
+
+// diskImageBackingBlockDev passes the backing images of a qcow2 disk image (in backing chain order) to QEMU and
+// returns the block node definition of the chain. The settings of the disk image's file block node are reused.
+func (d *qemu) diskImageBackingBlockDev(m *qmp.Monitor, escapedDeviceName string, chain []storageDrivers.DiskImage, fileBlockDev map[string]any) (any, error) {
+	var backing any // No backing image.
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		image := chain[i]
+		if image.Format != "raw" && image.Format != "qcow2" {
+			return nil, fmt.Errorf("Unsupported format %q of disk image %q", image.Format, image.Path)
+		}
+
+		nodeName, err := d.diskImageNodeName(escapedDeviceName, image.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Backing images are opened read-write so that QEMU can reopen them as such when merging images.
+		f, err := os.OpenFile(image.Path, unix.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("Failed opening disk image %q: %w", image.Path, err)
+		}
+
+		info, err := m.SendFileWithFDSet(nodeName, f, false)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Failed sending file descriptor of %q: %w", image.Path, err)
+		}
+
+		file := map[string]any{
+			"aio":      fileBlockDev["aio"],
+			"cache":    fileBlockDev["cache"],
+			"driver":   "file",
+			"filename": fmt.Sprintf("/dev/fdset/%d", info.ID),
+			"locking":  "off",
+		}
+
+		if image.Format == "raw" {
+			file["node-name"] = nodeName
+			backing = file
+			continue
+		}
+
+		backing = map[string]any{
+			"backing":   backing,
+			"cache":     fileBlockDev["cache"],
+			"driver":    "qcow2",
+			"file":      file,
+			"node-name": nodeName,
+		}
+	}
+
+	return backing, nil
+}
+
+// diskImageBlockNode returns the name of the block node using the disk image file at path along with the name of
+// its FD set. The disk image is found by comparing the file with the ones QEMU got through FD sets.
+func (d *qemu) diskImageBlockNode(monitor *qmp.Monitor, path string) (string, string, error) {
+	var stat unix.Stat_t
+	err := unix.Stat(path, &stat)
+	if err != nil {
+		return "", "", fmt.Errorf("Failed getting info of disk image %q: %w", path, err)
+	}
+
+	fdSets, err := monitor.GetFDSets()
+	if err != nil {
+		return "", "", err
+	}
+
+	filename := ""
+	fdName := ""
+	for _, fdSet := range fdSets {
+		for _, fd := range fdSet.FDs {
+			var fdStat unix.Stat_t
+			err := unix.Stat(fmt.Sprintf("/proc/%d/fd/%d", d.InitPID(), fd.FD), &fdStat)
+			if err != nil || fdStat.Dev != stat.Dev || fdStat.Ino != stat.Ino {
+				continue
+			}
+
+			filename = fmt.Sprintf("/dev/fdset/%d", fdSet.ID)
+			fields := strings.SplitN(fd.Opaque, ":", 2)
+			fdName = fields[len(fields)-1]
+		}
+	}
+
+	if filename == "" {
+		return "", "", fmt.Errorf("Disk image %q isn't in use by the instance", path)
+	}
+
+	nodes, err := monitor.GetNamedBlockNodes()
+	if err != nil {
+		return "", "", err
+	}
+
+	// Prefer the format block node over the file block node it uses.
+	nodeName := ""
+	for _, node := range nodes {
+		if node.File == filename && (nodeName == "" || node.Driver != "file") {
+			nodeName = node.NodeName
+		}
+	}
+
+	if nodeName == "" {
+		return "", "", fmt.Errorf("Failed finding block node of disk image %q", path)
+	}
+
+	return nodeName, fdName, nil
+}
+
+// rootDiskBlockDevice connects to the QMP monitor and returns it along with the escaped name, the device ID and
+// the active block node name of the root disk device.
+func (d *qemu) rootDiskBlockDevice() (*qmp.Monitor, string, string, string, error) {
+	if !d.IsRunning() {
+		return nil, "", "", "", fmt.Errorf("Instance is not running")
+	}
+
+	rootDiskName, _, err := shared.GetRootDiskDevice(d.expandedDevices.CloneNative())
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return nil, "", "", "", fmt.Errorf("Failed to connect to QMP monitor: %w", err)
+	}
+
+	escapedDeviceName := filesystem.PathNameEncode(rootDiskName)
+	deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, escapedDeviceName)
+
+	blockNodes, err := monitor.GetBlockNodeNames()
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	nodeName := blockNodes[deviceID]
+	if nodeName == "" {
+		return nil, "", "", "", fmt.Errorf("Failed finding block node of root disk device %q", rootDiskName)
+	}
+
+	return monitor, escapedDeviceName, deviceID, nodeName, nil
+}
+
+// BlockSnapshot makes the (already created) qcow2 disk image at overlayPath the new active layer of the root disk.
+func (d *qemu) BlockSnapshot(overlayPath string) error {
+	monitor, escapedDeviceName, _, nodeName, err := d.rootDiskBlockDevice()
+	if err != nil {
+		return err
+	}
+
+	overlayNodeName, err := d.diskImageNodeName(escapedDeviceName, overlayPath)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	f, err := os.OpenFile(overlayPath, unix.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("Failed opening disk image %q: %w", overlayPath, err)
+	}
+
+	defer func() { _ = f.Close() }()
+
+	info, err := monitor.SendFileWithFDSet(overlayNodeName, f, false)
+	if err != nil {
+		return fmt.Errorf("Failed sending file descriptor of %q: %w", overlayPath, err)
+	}
+
+	revert.Add(func() { _ = monitor.RemoveFDFromFDSet(overlayNodeName) })
+
+	blockDev := map[string]any{
+		"backing":   nil,
+		"discard":   "unmap",
+		"driver":    "qcow2",
+		"node-name": overlayNodeName,
+		"file": map[string]any{
+			"discard":  "unmap",
+			"driver":   "file",
+			"filename": fmt.Sprintf("/dev/fdset/%d", info.ID),
+			"locking":  "off",
+		},
+	}
+
+	err = monitor.AddBlockDevice(blockDev, nil)
+	if err != nil {
+		return fmt.Errorf("Failed adding disk image %q: %w", overlayPath, err)
+	}
+
+	revert.Add(func() { _ = monitor.RemoveBlockDevice(overlayNodeName) })
+
+	err = monitor.BlockDevSnapshot(nodeName, overlayNodeName)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// BlockCommit merges the disk image at topPath of the root disk into the disk image at basePath. The disk image
+// above topPath (if any) then records backingFile as the path of its backing image.
+func (d *qemu) BlockCommit(topPath string, basePath string, backingFile string) error {
+	monitor, _, deviceID, nodeName, err := d.rootDiskBlockDevice()
+	if err != nil {
+		return err
+	}
+
+	topNodeName, topFDName, err := d.diskImageBlockNode(monitor, topPath)
+	if err != nil {
+		return err
+	}
+
+	baseNodeName, _, err := d.diskImageBlockNode(monitor, basePath)
+	if err != nil {
+		return err
+	}
+
+	jobID := fmt.Sprintf("%s-commit", deviceID)
+
+	// Committing the active layer requires switching the device over to the base image once in sync.
+	if topNodeName == nodeName {
+		err = monitor.BlockCommit(jobID, nodeName, topNodeName, baseNodeName, "")
+		if err != nil {
+			return err
+		}
+
+		err = d.waitBlockJob(monitor, jobID, true, nil)
+		if err != nil {
+			return err
+		}
+
+		err = monitor.BlockJobComplete(jobID)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = monitor.BlockCommit(jobID, nodeName, topNodeName, baseNodeName, backingFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = d.waitBlockJob(monitor, jobID, false, nil)
+	if err != nil {
+		return err
+	}
+
+	// Release the merged disk image. Block nodes added along with the root disk are removed by QEMU itself.
+	err = monitor.RemoveBlockDevice(topNodeName)
+	if err != nil {
+		d.logger.Debug("Skipped removing merged disk image block device", logger.Ctx{"node": topNodeName, "err": err})
+	}
+
+	err = monitor.RemoveFDFromFDSet(topFDName)
+	if err != nil {
+		d.logger.Warn("Failed removing merged disk image file descriptor", logger.Ctx{"node": topNodeName, "err": err})
+	}
+
+	return nil
+}
+
+// BlockChangeBackingFile changes the backing image path recorded in the root disk's disk image at path.
+func (d *qemu) BlockChangeBackingFile(path string, backingFile string) error {
+	monitor, _, _, nodeName, err := d.rootDiskBlockDevice()
+	if err != nil {
+		return err
+	}
+
+	imageNodeName, _, err := d.diskImageBlockNode(monitor, path)
+	if err != nil {
+		return err
+	}
+
+	return monitor.ChangeBackingFile(nodeName, imageNodeName, backingFile)
+}
+
+// qemuMigrationNBDExport is the NBD export name of the root disk while receiving a live migrated VM.
+const qemuMigrationNBDExport = "lxd_root"
+
+// MigrateSend live migrates the running VM to a target receiving it with MigrateReceive.
+// Unless the storage is shared with the target, the root disk writes are first redirected to a temporary overlay so
+// that the instance volumes can be transferred by args.StorageSync while the VM keeps running. The writes made to the
+// overlay are then mirrored onto the target root disk over args.DiskConn while the VM state is sent over
+// args.StateConn. Once the target has confirmed it resumed the VM, the overlay is committed back into the root disk
+// and the local VM is resumed, the same as on failure. After a cluster move, the local VM is released instead.
+// With shared storage, the VM state is sent straight to the target which opens the shared volumes alongside the
+// paused local VM, QEMU only letting the side running the VM write to them.
+func (d *qemu) MigrateSend(args instance.VMMigrateSendArgs) error {
+	if !d.IsRunning() {
+		return fmt.Errorf("Instance is not running")
+	}
+
+	if shared.IsFalseOrEmpty(d.expandedConfig["migration.stateful"]) {
+		return fmt.Errorf("Live migration requires migration.stateful to be set to true")
+	}
+
+	release, err := qemuMigrateSendReleases(args)
+	if err != nil {
+		return err
+	}
+
+	sharedStorage := args.DiskConn == nil
+
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return fmt.Errorf("Failed to connect to QMP monitor: %w", err)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	var completeRootDisk func() error
+	if !sharedStorage {
+		completeRootDisk, err = d.migrateSendRootDisk(monitor, args, revert)
+		if err != nil {
+			return err
+		}
+	} else {
+		// The target mounts the config volume while the VM still runs locally, so flush it beforehand.
+		err = filesystem.SyncFS(d.Path())
+		if err != nil {
+			return fmt.Errorf("Failed syncing config volume: %w", err)
+		}
+	}
+
+	// Send the VM state, QEMU pauses the VM once it has been fully transferred.
+	err = monitor.MigrateSetCapabilities(map[string]bool{"auto-converge": true})
+	if err != nil {
+		return err
+	}
+
+	pipeRead, pipeWrite, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = pipeRead.Close() }()
+
+	// The state stream is only ended once the root disk is in sync or, with shared storage, once released.
+	stateDone := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(args.StateConn, pipeRead)
+		stateDone <- err
+	}()
+
+	err = monitor.SendFile("migration", pipeWrite)
+	_ = pipeWrite.Close()
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_ = monitor.MigrateCancel()
+		_ = monitor.Start()
+	})
+
+	err = monitor.Migrate("fd:migration")
+	if err != nil {
+		return fmt.Errorf("Failed sending VM state: %w", err)
+	}
+
+	err = <-stateDone
+	if err != nil {
+		return fmt.Errorf("Failed sending VM state: %w", err)
+	}
+
+	if completeRootDisk != nil {
+		err = completeRootDisk()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = args.StateConn.Close()
+	if err != nil {
+		return fmt.Errorf("Failed sending VM state: %w", err)
+	}
+
+	// Wait for the target to resume the VM. Until then, the local VM is resumed on any failure.
+	err = args.Confirm()
+	if err != nil {
+		return err
+	}
+
+	// The VM now runs on the target. A copy keeps running locally, so resume it on its root disk the same way
+	// as on failure. Remote moves delete the local VM once done.
+	if !release {
+		resume := revert.Clone().Fail
+		revert.Success()
+		resume()
+
+		return nil
+	}
+
+	// After a cluster move, the target took over the database record so the local VM is only released.
+	revert.Success()
+
+	err = d.cleanupMoved()
+	if err != nil {
+		return fmt.Errorf("Failed releasing migrated instance: %w", err)
+	}
+
+	return nil
+}
+
+// qemuMigrateSendReleases returns whether the local VM is released once live migrated by MigrateSend rather than
+// kept running. Only cluster moves release it as the target member takes over its database record, which is also
+// required to use storage shared with the target.
+func qemuMigrateSendReleases(args instance.VMMigrateSendArgs) (bool, error) {
+	if args.DiskConn == nil && !args.ClusterMove {
+		return false, fmt.Errorf("Live migration using shared storage is only supported between cluster members")
+	}
+
+	return args.ClusterMove, nil
+}
+
+// migrateSendRootDisk redirects the root disk writes to a temporary overlay while args.StorageSync transfers the
+// instance volumes, then mirrors the overlay onto the target root disk exported over args.DiskConn. It returns a
+// function completing the mirror once the VM is paused.
+func (d *qemu) migrateSendRootDisk(monitor *qmp.Monitor, args instance.VMMigrateSendArgs, revert *revert.Reverter) (func() error, error) {
+	rootDiskName, _, err := shared.GetRootDiskDevice(d.expandedDevices.CloneNative())
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := storagePools.LoadByInstance(d.state, d)
+	if err != nil {
+		return nil, err
+	}
+
+	diskSize, err := storagePools.InstanceDiskBlockSize(pool, d, args.Op)
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting root disk size: %w", err)
+	}
+
+	escapedDeviceName := filesystem.PathNameEncode(rootDiskName)
+	deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, escapedDeviceName)
+	overlayNodeName := d.blockNodeName(fmt.Sprintf("%s-overlay", escapedDeviceName))
+	nbdNodeName := d.blockNodeName(fmt.Sprintf("%s-nbd", escapedDeviceName))
+	commitJobID := fmt.Sprintf("%s-commit", deviceID)
+	mirrorJobID := fmt.Sprintf("%s-mirror", deviceID)
+
+	blockNodes, err := monitor.GetBlockNodeNames()
+	if err != nil {
+		return nil, err
+	}
+
+	nodeName := blockNodes[deviceID]
+	if nodeName == "" {
+		return nil, fmt.Errorf("Failed finding block node of root disk device %q", rootDiskName)
+	}
+
+	// Create the overlay the root disk writes are redirected to while the instance volumes are transferred.
+	// It is removed from the filesystem straight away as QEMU keeps its own file descriptor open.
+	overlayFile, err := ioutil.TempFile(shared.VarPath("images"), "lxd_migration_")
+	if err != nil {
+		return nil, fmt.Errorf("Failed creating root disk overlay: %w", err)
+	}
+
+	overlayPath := overlayFile.Name()
+	_ = overlayFile.Close()
+	defer func() { _ = os.Remove(overlayPath) }()
+
+	_, err = shared.RunCommand("qemu-img", "create", "-f", "qcow2", overlayPath, fmt.Sprintf("%d", diskSize))
+	if err != nil {
+		return nil, fmt.Errorf("Failed creating root disk overlay: %w", err)
+	}
+
+	overlayFile, err = os.OpenFile(overlayPath, unix.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Failed opening root disk overlay: %w", err)
+	}
+
+	defer func() { _ = overlayFile.Close() }()
+
+	info, err := monitor.SendFileWithFDSet(overlayNodeName, overlayFile, false)
+	if err != nil {
+		return nil, fmt.Errorf("Failed sending file descriptor of root disk overlay: %w", err)
+	}
+
+	revert.Add(func() { _ = monitor.RemoveFDFromFDSet(overlayNodeName) })
+
+	blockDev := map[string]any{
+		"driver":    "qcow2",
+		"node-name": overlayNodeName,
+		"backing":   nil,
+		"file": map[string]any{
+			"driver":   "file",
+			"filename": fmt.Sprintf("/dev/fdset/%d", info.ID),
+		},
+	}
+
+	err = monitor.AddBlockDevice(blockDev, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed adding root disk overlay: %w", err)
+	}
+
+	revert.Add(func() { _ = monitor.RemoveBlockDevice(overlayNodeName) })
+
+	err = monitor.BlockDevSnapshot(nodeName, overlayNodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	revert.Add(func() {
+		err := monitor.BlockCommit(commitJobID, overlayNodeName, "", "", "")
+		if err == nil {
+			err = d.waitBlockJob(monitor, commitJobID, true, nil)
+		}
+
+		if err == nil {
+			err = monitor.BlockJobComplete(commitJobID)
+		}
+
+		if err == nil {
+			err = d.waitBlockJob(monitor, commitJobID, false, nil)
+		}
+
+		if err != nil {
+			d.logger.Error("Failed committing root disk overlay", logger.Ctx{"err": err})
+		}
+	})
+
+	// Transfer the instance volumes while the root disk content is frozen.
+	err = args.StorageSync()
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait for the target to export its root disk before connecting QEMU to it, as the NBD handshake blocks
+	// the QEMU main loop. The first message received is the start of the handshake.
+	greeting := make([]byte, 4096)
+	greetingLen := 0
+	for greetingLen == 0 {
+		greetingLen, err = args.DiskConn.Read(greeting)
+		if err != nil {
+			return nil, fmt.Errorf("Failed waiting for target root disk: %w", err)
+		}
+	}
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Failed creating NBD socket pair: %w", err)
+	}
+
+	qemuNBDFile := os.NewFile(uintptr(fds[0]), "qemu-nbd")
+	defer func() { _ = qemuNBDFile.Close() }()
+
+	nbdFile := os.NewFile(uintptr(fds[1]), "lxd-nbd")
+	nbdConn, err := net.FileConn(nbdFile)
+	_ = nbdFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("Failed setting up NBD connection: %w", err)
+	}
+
+	revert.Add(func() { _ = nbdConn.Close() })
+
+	_, err = nbdConn.Write(greeting[:greetingLen])
+	if err != nil {
+		return nil, fmt.Errorf("Failed forwarding NBD handshake: %w", err)
+	}
+
+	// Proxy the NBD connection over the migration connection. The end of the stream is only signalled to the
+	// target once the mirror has completed.
+	diskDone := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(args.DiskConn, nbdConn)
+		diskDone <- err
+	}()
+
+	go func() {
+		_, _ = io.Copy(nbdConn, args.DiskConn)
+		_ = nbdConn.Close()
+	}()
+
+	err = monitor.SendFile(nbdNodeName, qemuNBDFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed sending file descriptor of NBD connection: %w", err)
+	}
+
+	blockDev = map[string]any{
+		"driver":    "nbd",
+		"node-name": nbdNodeName,
+		"export":    qemuMigrationNBDExport,
+		"server": map[string]any{
+			"type": "fd",
+			"str":  nbdNodeName,
+		},
+	}
+
+	err = monitor.AddBlockDevice(blockDev, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed adding target root disk: %w", err)
+	}
+
+	revert.Add(func() { _ = monitor.RemoveBlockDevice(nbdNodeName) })
+
+	// Mirror the root disk writes made since the overlay was added onto the target and wait for it to be in sync.
+	err = monitor.BlockDevMirror(mirrorJobID, overlayNodeName, nbdNodeName, "top")
+	if err != nil {
+		return nil, err
+	}
+
+	revert.Add(func() {
+		_ = monitor.BlockJobCancel(mirrorJobID)
+		_ = d.waitBlockJob(monitor, mirrorJobID, false, nil)
+	})
+
+	err = d.waitBlockJob(monitor, mirrorJobID, true, args.Op)
+	if err != nil {
+		return nil, err
+	}
+
+	complete := func() error {
+		defer func() { _ = nbdConn.Close() }()
+
+		// The VM is now paused, complete the mirror so the target root disk is fully in sync.
+		err := monitor.BlockJobCancel(mirrorJobID)
+		if err != nil {
+			return err
+		}
+
+		err = d.waitBlockJob(monitor, mirrorJobID, false, nil)
+		if err != nil {
+			return err
+		}
+
+		err = monitor.RemoveBlockDevice(nbdNodeName)
+		if err != nil {
+			return err
+		}
+
+		err = <-diskDone
+		if err != nil {
+			return fmt.Errorf("Failed sending root disk writes: %w", err)
+		}
+
+		err = args.DiskConn.Close()
+		if err != nil {
+			return fmt.Errorf("Failed sending root disk writes: %w", err)
+		}
+
+		return nil
+	}
+
+	return complete, nil
+}
+
+// MigrateReceive starts the VM from a live migration sent by MigrateSend.
+func (d *qemu) MigrateReceive(args instance.VMMigrateReceiveArgs) error {
+	d.migrationReceiveArgs = &args
+	defer func() { d.migrationReceiveArgs = nil }()
+
+	// Although the instance technically isn't considered stateful, we set this to allow starting from the
+	// migration stream.
+	d.stateful = true
+
+	return d.Start(true)
+}
+
+// migrateReceiveState receives the VM state and root disk writes sent by MigrateSend into the QEMU process started
+// in incoming mode. Once done, the VM is left paused.
+func (d *qemu) migrateReceiveState(monitor *qmp.Monitor, args *instance.VMMigrateReceiveArgs) error {
+	// With shared storage, only the VM state is received.
+	diskDone := make(chan error, 1)
+	if args.DiskConn == nil {
+		diskDone <- nil
+	} else {
+		rootDiskName, _, err := shared.GetRootDiskDevice(d.expandedDevices.CloneNative())
+		if err != nil {
+			return err
+		}
+
+		deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, filesystem.PathNameEncode(rootDiskName))
+
+		blockNodes, err := monitor.GetBlockNodeNames()
+		if err != nil {
+			return err
+		}
+
+		nodeName := blockNodes[deviceID]
+		if nodeName == "" {
+			return fmt.Errorf("Failed finding block node of root disk device %q", rootDiskName)
+		}
+
+		// Export the root disk over NBD so the root disk writes made on the source can be mirrored onto it.
+		nbdPath := filepath.Join(d.LogPath(), "qemu.nbd")
+		_ = os.Remove(nbdPath)
+
+		err = monitor.NBDServerStart(nbdPath)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = monitor.NBDServerStop()
+			_ = os.Remove(nbdPath)
+		}()
+
+		err = monitor.NBDServerAdd(nodeName, qemuMigrationNBDExport, true)
+		if err != nil {
+			return err
+		}
+
+		nbdConn, err := net.Dial("unix", nbdPath)
+		if err != nil {
+			return fmt.Errorf("Failed connecting to NBD server: %w", err)
+		}
+
+		defer func() { _ = nbdConn.Close() }()
+
+		// Proxy the migration connection to the NBD server. The source only ends the stream once the mirror has
+		// completed, any other error means the root disk may be missing some writes.
+		go func() {
+			_, err := io.Copy(nbdConn, args.DiskConn)
+			diskDone <- err
+		}()
+
+		go func() {
+			_, _ = io.Copy(args.DiskConn, nbdConn)
+			_ = args.DiskConn.Close()
+		}()
+	}
+
+	// Receive the VM state.
+	pipeRead, pipeWrite, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		_, _ = io.Copy(pipeWrite, args.StateConn)
+		_ = pipeWrite.Close()
+	}()
+
+	err = monitor.SendFile("migration", pipeRead)
+	_ = pipeRead.Close()
+	if err != nil {
+		return err
+	}
+
+	err = monitor.MigrateIncoming("fd:migration")
+	if err != nil {
+		return fmt.Errorf("Failed receiving VM state: %w", err)
+	}
+
+	// Wait for all the root disk writes to be received before the VM is resumed.
+	err = <-diskDone
+	if err != nil {
+		return fmt.Errorf("Failed receiving root disk writes: %w", err)
+	}
+
+	return nil
+}
+
+// deviceAttachNIC live attaches a NIC device to the instance.
+func (d *qemu) deviceAttachNIC(deviceName string, configCopy map[string]string, netIF []deviceConfig.RunConfigItem) error {
+	devName := ""
+	for _, dev := range netIF {
+		if dev.Key == "link" {
+			devName = dev.Value
+			break
+		}
+	}
+
+	if devName == "" {
+		return fmt.Errorf("Device didn't provide a link property to use")
+	}
+
+	_, qemuBus, err := d.qemuArchConfig(d.architecture)
+	if err != nil {
+		return err
+	}
+
+	// Check if the agent is running.
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	qemuDev := make(map[string]string)
+
+	// PCIe and PCI require a port device name to hotplug the NIC into.
+	if shared.StringInSlice(qemuBus, []string{"pcie", "pci"}) {
+		pciDevID := qemuPCIDeviceIDStart
+
+		// Iterate through all the instance devices in the same sorted order as is used when allocating the
+		// boot time devices in order to find the PCI bus slot device we would have used at boot time.
+		// Then attempt to use that same device, assuming it is available.
+		for _, dev := range d.expandedDevices.Sorted() {
+			if dev.Name == deviceName {
+				break // Found our device.
+			}
+
+			pciDevID++
+		}
+
+		pciDeviceName := fmt.Sprintf("%s%d", busDevicePortPrefix, pciDevID)
+		d.logger.Debug("Using PCI bus device to hotplug NIC into", logger.Ctx{"device": deviceName, "port": pciDeviceName})
+		qemuDev["bus"] = pciDeviceName
+		qemuDev["addr"] = "00.0"
+	}
+
+	cpuCount, err := d.addCPUMemoryConfig(nil)
+	if err != nil {
+		return err
+	}
+
+	monHook, err := d.addNetDevConfig(cpuCount, qemuBus, qemuDev, nil, netIF)
+	if err != nil {
+		return err
+	}
+
+	err = monHook(monitor)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// deviceStop loads a new device and calls its Stop() function.
+func (d *qemu) deviceStop(dev device.Device, instanceRunning bool, _ string) error {
+	configCopy := dev.Config()
+	l := d.logger.AddContext(logger.Ctx{"device": dev.Name(), "type": configCopy["type"]})
+	l.Debug("Stopping device")
+
+	if instanceRunning && !dev.CanHotPlug() {
+		return fmt.Errorf("Device cannot be stopped when instance is running")
+	}
+
+	runConf, err := dev.Stop()
+	if err != nil {
+		return err
+	}
+
+	if instanceRunning {
+		// Detach NIC from running instance.
+		if configCopy["type"] == "nic" {
+			err = d.deviceDetachNIC(dev.Name())
+			if err != nil {
+				return err
+			}
+		}
+
+		// Detach USB drom running instance.
+		if configCopy["type"] == "usb" && runConf != nil {
+			for _, usbDev := range runConf.USBDevice {
+				err = d.deviceDetachUSB(usbDev)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Detach disk from running instance.
+		if configCopy["type"] == "disk" {
+			err = d.deviceDetachBlockDevice(dev.Name(), configCopy)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if runConf != nil {
+		// Run post stop hooks irrespective of run state of instance.
+		err = d.runHooks(runConf.PostHooks)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deviceDetachNIC detaches a NIC device from a running instance.
+func (d *qemu) deviceDetachNIC(deviceName string) error {
+	// Check if the agent is running.
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	// pciDeviceExists checks if the deviceID exists as a bridged PCI device.
+	pciDeviceExists := func(deviceID string) (bool, error) {
+		pciDevs, err := monitor.QueryPCI()
+		if err != nil {
+			return false, err
+		}
+
+		for _, pciDev := range pciDevs {
+			for _, bridgeDev := range pciDev.Bridge.Devices {
+				if bridgeDev.DevID == deviceID {
+					return true, nil
+				}
+			}
+		}
+
+		return false, nil
+	}
+
+	escapedDeviceName := filesystem.PathNameEncode(deviceName)
+	deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, escapedDeviceName)
+	netDevID := fmt.Sprintf("%s%s", qemuNetDevIDPrefix, escapedDeviceName)
+
+	// Request removal of device.
+	err = monitor.RemoveDevice(deviceID)
+	if err != nil {
+		return fmt.Errorf("Failed removing NIC device: %w", err)
+	}
+
+	err = monitor.RemoveNIC(netDevID)
+	if err != nil {
+		return err
+	}
+
+	_, qemuBus, err := d.qemuArchConfig(d.architecture)
+	if err != nil {
+		return err
+	}
+
+	if shared.StringInSlice(qemuBus, []string{"pcie", "pci"}) {
+		// Wait until the device is actually removed (or we timeout waiting).
+		waitDuration := time.Duration(time.Second * time.Duration(10))
+		waitUntil := time.Now().Add(waitDuration)
+		for {
+			devExists, err := pciDeviceExists(deviceID)
+			if err != nil {
+				return fmt.Errorf("Failed getting PCI devices to check for NIC detach: %w", err)
+			}
+
+			if !devExists {
+				break
+
+			}
+
+			if time.Now().After(waitUntil) {
+				return fmt.Errorf("Failed to detach NIC after %v: %w", waitDuration, err)
+			}
+
+			d.logger.Debug("Waiting for NIC device to be detached", logger.Ctx{"device": deviceName})
+			time.Sleep(time.Second * time.Duration(2))
+		}
+	}
+
+	return nil
+}
+
+func (d *qemu) monitorPath() string {
+	return filepath.Join(d.LogPath(), "qemu.monitor")
+}
+
+func (d *qemu) nvramPath() string {
+	return filepath.Join(d.Path(), "qemu.nvram")
+}
+
+func (d *qemu) consolePath() string {
+	return filepath.Join(d.LogPath(), "qemu.console")
+}
+
+func (d *qemu) spicePath() string {
+	return filepath.Join(d.LogPath(), "qemu.spice")
+}
+
+func (d *qemu) spiceCmdlineConfig() string {
+	return fmt.Sprintf("unix=on,disable-ticketing=on,addr=%s", d.spicePath())
+}
+
+// generateConfigShare generates the config share directory that will be exported to the VM via
+// a 9P share. Due to the unknown size of templates inside the images this directory is created
+// inside the VM's config volume so that it can be restricted by quota.
+// Requires the instance be mounted before calling this function.
+func (d *qemu) generateConfigShare() error {
 	configDrivePath := filepath.Join(d.Path(), "config")
 
 	// Create config drive dir if doesn't exist, if it does exist, leave it around so we don't regenerate all
@@ -2780,6 +3923,20 @@ func (d *qemu) addCPUMemoryConfig(cfg *[]cfgSection) (int, error) {
 		cpuOpts.cpuCores = cpuCount
 		cpuOpts.cpuThreads = 1
 		hostNodes = []uint64{0}
+
+		// If limits.cpu.max is set, boot with a single vCPU and reserve room for the rest.
+		// The remaining vCPUs are hotplugged before the guest starts running so that they can later be
+		// removed again.
+		cpuMaxCount, err := d.cpuHotplugMax(cpuCount)
+		if err != nil {
+			return -1, err
+		}
+
+		if cpuMaxCount > 0 {
+			cpuOpts.cpuCount = 1
+			cpuOpts.cpuMaxCount = cpuMaxCount
+			cpuOpts.cpuCores = cpuMaxCount
+		}
 	} else {
 		// Expand to a set of CPU identifiers and get the pinning map.
 		nrSockets, nrCores, nrThreads, vcpus, numaNodes, err := d.cpuTopology(cpus)
@@ -2824,6 +3981,7 @@ func (d *qemu) addCPUMemoryConfig(cfg *[]cfgSection) (int, error) {
 		}
 
 		// Prepare context.
+		cpuCount = len(vcpus)
 		cpuOpts.cpuCount = len(vcpus)
 		cpuOpts.cpuSockets = nrSockets
 		cpuOpts.cpuCores = nrCores
@@ -2844,6 +4002,29 @@ func (d *qemu) addCPUMemoryConfig(cfg *[]cfgSection) (int, error) {
 		return -1, fmt.Errorf("limits.memory invalid: %w", err)
 	}
 
+	// When restoring state with hotplugged memory, boot with the original memory size and re-add the
+	// hotplugged DIMMs so that the memory layout matches the one of the saved state.
+	memDIMMSizes := []int64{}
+	if d.localConfig["volatile.memory.base"] != "" {
+		memSizeBytes, err = strconv.ParseInt(d.localConfig["volatile.memory.base"], 10, 64)
+		if err != nil {
+			return -1, fmt.Errorf("volatile.memory.base invalid: %w", err)
+		}
+
+		memDIMMSizes, err = d.memoryHotplugged()
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	var memMaxSizeBytes int64
+	if d.expandedConfig["limits.memory.max"] != "" {
+		memMaxSizeBytes, err = units.ParseByteSizeString(d.expandedConfig["limits.memory.max"])
+		if err != nil {
+			return -1, fmt.Errorf("limits.memory.max invalid: %w", err)
+		}
+	}
+
 	cpuOpts.hugepages = ""
 	if shared.IsTrue(d.expandedConfig["limits.memory.hugepages"]) {
 		hugetlb, err := util.HugepagesPath()
@@ -2860,13 +4041,70 @@ func (d *qemu) addCPUMemoryConfig(cfg *[]cfgSection) (int, error) {
 	memSizeMB = nodeMemory * int64(len(hostNodes))
 	cpuOpts.memory = nodeMemory
 
+	memOpts := qemuMemoryOpts{memSizeMB: memSizeMB}
+
+	// Reserve room for memory hotplug (not supported with hugepages).
+	if d.architecture == osarch.ARCH_64BIT_INTEL_X86 && cpuOpts.hugepages == "" && memMaxSizeBytes > 0 {
+		memOpts.maxMemSizeMB = memMaxSizeBytes / 1024 / 1024
+		memOpts.slots = qemuMemoryHotplugSlots
+	}
+
 	if cfg != nil {
-		*cfg = append(*cfg, qemuMemory(&qemuMemoryOpts{memSizeMB})...)
+		*cfg = append(*cfg, qemuMemory(&memOpts)...)
 		*cfg = append(*cfg, qemuCPU(&cpuOpts)...)
+
+		for i, dimmSize := range memDIMMSizes {
+			*cfg = append(*cfg, qemuMemoryDIMM(&qemuMemoryDIMMOpts{
+				id:        fmt.Sprintf("%s%d", qemuMemoryDIMMIDPrefix, i),
+				memSizeMB: dimmSize / 1024 / 1024,
+			})...)
+		}
 	}
 
 	// Configure the CPU limit.
-	return cpuOpts.cpuCount, nil
+	return cpuCount, nil
+}
+
+// cpuHotplugMax returns the number of vCPU slots to reserve for hotplug from limits.cpu.max or 0 if it isn't set.
+func (d *qemu) cpuHotplugMax(cpuCount int) (int, error) {
+	if d.expandedConfig["limits.cpu.max"] == "" {
+		return 0, nil
+	}
+
+	if d.architecture != osarch.ARCH_64BIT_INTEL_X86 {
+		return 0, fmt.Errorf("vCPU hotplug isn't supported on this architecture")
+	}
+
+	cpuMaxCount, err := strconv.Atoi(d.expandedConfig["limits.cpu.max"])
+	if err != nil {
+		return 0, fmt.Errorf("limits.cpu.max invalid: %w", err)
+	}
+
+	if cpuMaxCount < cpuCount {
+		return 0, fmt.Errorf("limits.cpu.max (%d) must be at least limits.cpu (%d)", cpuMaxCount, cpuCount)
+	}
+
+	return cpuMaxCount, nil
+}
+
+// memoryHotplugged returns the sizes in bytes of the hotplugged memory DIMMs.
+func (d *qemu) memoryHotplugged() ([]int64, error) {
+	sizes := []int64{}
+
+	for _, value := range strings.Split(d.localConfig["volatile.memory.hotplugged"], ",") {
+		if value == "" {
+			continue
+		}
+
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("volatile.memory.hotplugged invalid: %w", err)
+		}
+
+		sizes = append(sizes, size)
+	}
+
+	return sizes, nil
 }
 
 // addFileDescriptor adds a file path to the list of files to open and pass file descriptor to qemu.
@@ -3023,6 +4261,7 @@ func (d *qemu) addDriveConfig(bootIndexes map[string]int, driveConf deviceConfig
 	}
 
 	var isBlockDev bool
+	var diskChain []storageDrivers.DiskImage
 
 	// Handle local disk devices.
 	if !isRBDImage {
@@ -3085,6 +4324,14 @@ func (d *qemu) addDriveConfig(bootIndexes map[string]int, driveConf deviceConfig
 			if strings.HasSuffix(srcDevPath, ".iso") {
 				media = "cdrom"
 			}
+
+			// The root disk can be a qcow2 disk image with backing images (when using qcow2 snapshots).
+			if driveConf.TargetPath == "/" && storageDrivers.IsQcow2DiskPath(srcDevPath) {
+				diskChain, err = storageDrivers.DiskImageBackingChain(srcDevPath)
+				if err != nil {
+					return nil, err
+				}
+			}
 		} else if !shared.StringInSlice(device.DiskDirectIO, driveConf.Opts) {
 			// If drive config indicates we need to use unsafe I/O then use it.
 			d.logger.Warn("Using unsafe cache I/O", logger.Ctx{"device": driveConf.DevName, "devPath": srcDevPath})
@@ -3212,6 +4459,21 @@ func (d *qemu) addDriveConfig(bootIndexes map[string]int, driveConf deviceConfig
 		blockDev["locking"] = "off"
 	}
 
+	// Access qcow2 disk images through a qcow2 block node on top of the file block node.
+	fileBlockDev := blockDev
+	if len(diskChain) > 0 {
+		blockDev = map[string]any{
+			"cache":     fileBlockDev["cache"],
+			"discard":   "unmap",
+			"driver":    "qcow2",
+			"file":      fileBlockDev,
+			"node-name": fileBlockDev["node-name"],
+			"read-only": fileBlockDev["read-only"],
+		}
+
+		delete(fileBlockDev, "node-name")
+	}
+
 	device := map[string]string{
 		"id":      fmt.Sprintf("%s%s", qemuDeviceIDPrefix, escapedDeviceName),
 		"drive":   blockDev["node-name"].(string),
@@ -3235,12 +4497,13 @@ func (d *qemu) addDriveConfig(bootIndexes map[string]int, driveConf deviceConfig
 		revert := revert.New()
 		defer revert.Fail()
 
+		var err error
 		nodeName := fmt.Sprintf("%s%s", qemuBlockDevIDPrefix, escapedDeviceName)
 
 		if isRBDImage {
 			secretID := fmt.Sprintf("pool_%s_%s", blockDev["pool"], blockDev["user"])
 
-			err := m.AddSecret(secretID, rbdSecret)
+			err = m.AddSecret(secretID, rbdSecret)
 			if err != nil {
 				return err
 			}
@@ -3267,14 +4530,26 @@ func (d *qemu) addDriveConfig(bootIndexes map[string]int, driveConf deviceConfig
 				_ = m.RemoveFDFromFDSet(nodeName)
 			})
 
-			blockDev["filename"] = fmt.Sprintf("/dev/fdset/%d", info.ID)
+			fileBlockDev["filename"] = fmt.Sprintf("/dev/fdset/%d", info.ID)
+		}
+
+		if len(diskChain) > 0 {
+			blockDev["backing"], err = d.diskImageBackingBlockDev(m, escapedDeviceName, diskChain[1:], fileBlockDev)
+			if err != nil {
+				return fmt.Errorf("Failed adding backing images for disk device %q: %w", driveConf.DevName, err)
+			}
 		}
 
-		err := m.AddBlockDevice(blockDev, device)
+		err = m.AddBlockDevice(blockDev, device)
 		if err != nil {
 			return fmt.Errorf("Failed adding block device for disk device %q: %w", driveConf.DevName, err)
 		}
 
+		err = d.deviceSetBlockLimits(m, driveConf.DevName, driveConf.Opts)
+		if err != nil {
+			return err
+		}
+
 		revert.Success()
 		return nil
 	}
@@ -4214,7 +5489,7 @@ func (d *qemu) Rename(newName string, applyTemplateTrigger bool) error {
 // Update the instance config.
 func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 	// Setup a new operation.
-	op, err := operationlock.CreateWaitGet(d.Project(), d.Name(), operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionRestart, operationlock.ActionRestore}, false, false)
+	op, err := operationlock.CreateWaitGet(d.Project(), d.Name(), operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionRestart, operationlock.ActionRestore, operationlock.ActionAutoscale}, false, false)
 	if err != nil {
 		return fmt.Errorf("Failed to create instance update operation: %w", err)
 	}
@@ -4438,6 +5713,7 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		// Only certain keys can be changed on a running VM.
 		liveUpdateKeys := []string{
 			"cluster.evacuate",
+			"limits.cpu",
 			"limits.memory",
 			"security.agent.metrics",
 			"security.secureboot",
@@ -4490,7 +5766,12 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		for _, key := range changedConfig {
 			value := d.expandedConfig[key]
 
-			if key == "limits.memory" {
+			if key == "limits.cpu" {
+				err = d.updateCPULimit(oldExpandedConfig[key], value)
+				if err != nil {
+					return fmt.Errorf("Failed updating CPU limit: %w", err)
+				}
+			} else if key == "limits.memory" {
 				err = d.updateMemoryLimit(value)
 				if err != nil {
 					if err != nil {
@@ -4628,7 +5909,200 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 	return nil
 }
 
+// updateCPULimit live updates the VM's number of vCPUs by hotplugging or unplugging them.
+func (d *qemu) updateCPULimit(oldLimit string, newLimit string) error {
+	if oldLimit == "" {
+		oldLimit = "1"
+	}
+
+	if newLimit == "" {
+		newLimit = "1"
+	}
+
+	_, oldErr := strconv.Atoi(oldLimit)
+	newCount, newErr := strconv.Atoi(newLimit)
+	if oldErr != nil || newErr != nil {
+		return fmt.Errorf("Cannot live update CPU pinning")
+	}
+
+	cpuMaxCount, err := d.cpuHotplugMax(newCount)
+	if err != nil {
+		return err
+	}
+
+	if cpuMaxCount == 0 {
+		return fmt.Errorf("Cannot change the number of vCPUs of a running VM without limits.cpu.max")
+	}
+
+	// Connect to the monitor.
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err // The VM isn't running as no monitor socket available.
+	}
+
+	err = d.setCPUs(monitor, newCount)
+	if err != nil {
+		return err
+	}
+
+	// Place all the vCPU threads into a new core scheduling domain.
+	pids, err := monitor.GetCPUs()
+	if err != nil {
+		return err
+	}
+
+	err = d.setCoreSched(pids)
+	if err != nil {
+		return fmt.Errorf("Failed to allocate new core scheduling domain for vCPU threads: %w", err)
+	}
+
+	return nil
+}
+
+// setCPUs hotplugs or unplugs vCPUs until the VM has the requested number of them.
+// vCPUs are always added to the first free slots and removed starting from the last used ones.
+func (d *qemu) setCPUs(monitor *qmp.Monitor, count int) error {
+	cpus, err := monitor.QueryHotpluggableCPUs()
+	if err != nil {
+		return err
+	}
+
+	if count > len(cpus) {
+		return fmt.Errorf("Cannot use more than %d vCPUs", len(cpus))
+	}
+
+	// Sort the slots by topology.
+	topology := []string{"socket-id", "die-id", "core-id", "thread-id"}
+	sort.SliceStable(cpus, func(i, j int) bool {
+		for _, key := range topology {
+			if cpus[i].Props[key] != cpus[j].Props[key] {
+				return cpus[i].Props[key] < cpus[j].Props[key]
+			}
+		}
+
+		return false
+	})
+
+	present := 0
+	for _, cpu := range cpus {
+		if cpu.QOMPath != "" {
+			present++
+		}
+	}
+
+	// Add vCPUs to the first free slots.
+	for i := 0; i < len(cpus) && present < count; i++ {
+		if cpus[i].QOMPath != "" {
+			continue
+		}
+
+		err = monitor.AddCPU(cpus[i], fmt.Sprintf("%s%d", qemuCPUIDPrefix, i))
+		if err != nil {
+			return err
+		}
+
+		present++
+	}
+
+	if present <= count {
+		return nil
+	}
+
+	// Remove vCPUs starting from the last used slots. Only hotplugged vCPUs can be removed.
+	for i := len(cpus) - 1; i >= 0 && present > count; i-- {
+		if !strings.HasPrefix(cpus[i].QOMPath, "/machine/peripheral/") {
+			continue
+		}
+
+		err = monitor.RemoveDevice(filepath.Base(cpus[i].QOMPath))
+		if err != nil {
+			return err
+		}
+
+		present--
+	}
+
+	if present > count {
+		return fmt.Errorf("Cannot remove vCPUs present at boot time")
+	}
+
+	// Removing a vCPU requires the guest to release it, so poll until it's gone.
+	for i := 0; i < 20; i++ {
+		cpus, err = monitor.QueryHotpluggableCPUs()
+		if err != nil {
+			return err
+		}
+
+		present = 0
+		for _, cpu := range cpus {
+			if cpu.QOMPath != "" {
+				present++
+			}
+		}
+
+		if present <= count {
+			return nil
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	return fmt.Errorf("Failed removing vCPUs (currently %d) as the guest didn't release them", present)
+}
+
+// addMemory hotplugs a new memory DIMM large enough to provide the requested extra memory.
+func (d *qemu) addMemory(monitor *qmp.Monitor, baseSizeBytes int64, extraSizeBytes int64) error {
+	if d.architecture != osarch.ARCH_64BIT_INTEL_X86 {
+		return fmt.Errorf("Memory hotplug isn't supported on this architecture")
+	}
+
+	maxSizeBytes, err := units.ParseByteSizeString(d.expandedConfig["limits.memory.max"])
+	if err != nil {
+		return fmt.Errorf("Invalid maximum memory size: %w", err)
+	}
+
+	dimmSizes, err := d.memoryHotplugged()
+	if err != nil {
+		return err
+	}
+
+	if len(dimmSizes) >= qemuMemoryHotplugSlots {
+		return fmt.Errorf("No free memory hotplug slots left")
+	}
+
+	// Round up to the memory block size used by the guest.
+	dimmSizeBytes := ((extraSizeBytes + qemuMemoryHotplugAlignment - 1) / qemuMemoryHotplugAlignment) * qemuMemoryHotplugAlignment
+
+	totalSizeBytes := baseSizeBytes + dimmSizeBytes
+	for _, size := range dimmSizes {
+		totalSizeBytes += size
+	}
+
+	if totalSizeBytes > maxSizeBytes {
+		return fmt.Errorf("Cannot increase memory size beyond limits.memory.max (Maximum size %dMiB, required size %dMiB)", maxSizeBytes/1024/1024, totalSizeBytes/1024/1024)
+	}
+
+	err = monitor.AddMemory(fmt.Sprintf("%s%d", qemuMemoryDIMMIDPrefix, len(dimmSizes)), dimmSizeBytes)
+	if err != nil {
+		return err
+	}
+
+	// Record the hotplugged memory so that it can be recreated when restoring the VM's state.
+	values := []string{}
+	for _, size := range append(dimmSizes, dimmSizeBytes) {
+		values = append(values, strconv.FormatInt(size, 10))
+	}
+
+	changes := map[string]string{"volatile.memory.hotplugged": strings.Join(values, ",")}
+	if d.localConfig["volatile.memory.base"] == "" {
+		changes["volatile.memory.base"] = strconv.FormatInt(baseSizeBytes, 10)
+	}
+
+	return d.VolatileSet(changes)
+}
+
 // updateMemoryLimit live updates the VM's memory limit by reszing the balloon device.
+// If needed and limits.memory.max is set, additional memory is hotplugged first.
 func (d *qemu) updateMemoryLimit(newLimit string) error {
 	if newLimit == "" {
 		return nil
@@ -4657,6 +6131,12 @@ func (d *qemu) updateMemoryLimit(newLimit string) error {
 	}
 	baseSizeMB := baseSizeBytes / 1024 / 1024
 
+	pluggedSizeBytes, err := monitor.GetMemoryHotpluggedBytes()
+	if err != nil {
+		return err
+	}
+	totalSizeMB := baseSizeMB + (pluggedSizeBytes / 1024 / 1024)
+
 	curSizeBytes, err := monitor.GetMemoryBalloonSizeBytes()
 	if err != nil {
 		return err
@@ -4665,8 +6145,16 @@ func (d *qemu) updateMemoryLimit(newLimit string) error {
 
 	if curSizeMB == newSizeMB {
 		return nil
-	} else if baseSizeMB < newSizeMB {
-		return fmt.Errorf("Cannot increase memory size beyond boot time size when VM is running (Boot time size %dMiB, new size %dMiB)", baseSizeMB, newSizeMB)
+	} else if totalSizeMB < newSizeMB {
+		if d.expandedConfig["limits.memory.max"] == "" {
+			return fmt.Errorf("Cannot increase memory size beyond boot time size when VM is running without limits.memory.max (Boot time size %dMiB, new size %dMiB)", baseSizeMB, newSizeMB)
+		}
+
+		// Hotplug the missing memory.
+		err = d.addMemory(monitor, baseSizeBytes, newSizeBytes-(baseSizeBytes+pluggedSizeBytes))
+		if err != nil {
+			return err
+		}
 	}
 
 	// Set effective memory size.
@@ -4777,6 +6265,92 @@ func (d *qemu) cleanup() {
 	_ = os.RemoveAll(d.ShmountsPath())
 }
 
+// cleanupMoved kills the local QEMU process of a VM moved to another cluster member and releases its local
+// resources. Unlike Stop and Delete, it leaves the database record untouched as it now belongs to the VM running on
+// the other member.
+func (d *qemu) cleanupMoved() error {
+	op, err := operationlock.Create(d.Project(), d.Name(), operationlock.ActionStop, false, false)
+	if err != nil {
+		return err
+	}
+
+	// Killing the process doesn't emit a SHUTDOWN event, so the event handler doesn't record the power state.
+	pid, _ := d.pid()
+	if pid > 0 {
+		err = d.killQemuProcess(pid)
+		if err != nil {
+			op.Done(err)
+			return fmt.Errorf("Failed to stop VM process %d: %w", pid, err)
+		}
+	}
+
+	// Clear up the config drive virtiofsd process and mount.
+	err = device.DiskVMVirtiofsdStop(d.configVirtiofsdPaths())
+	if err != nil {
+		d.logger.Warn("Failed cleaning up config drive virtiofsd", logger.Ctx{"err": err})
+	}
+
+	err = d.configDriveMountPathClear()
+	if err != nil {
+		d.logger.Warn("Failed cleaning up config drive mount", logger.Ctx{"err": err})
+	}
+
+	// Stop and remove the devices without saving their volatile config, which now belongs to the other member.
+	volatileSet := func(map[string]string) error { return nil }
+	for _, entry := range d.expandedDevices.Reversed() {
+		configCopy := entry.Config.Clone()
+		if shared.StringInSlice(entry.Config["type"], []string{"nic", "infiniband"}) {
+			configCopy, err = d.FillNetworkDevice(entry.Name, entry.Config)
+			if err != nil {
+				d.logger.Error("Failed loading device", logger.Ctx{"device": entry.Name, "err": err})
+				continue
+			}
+		}
+
+		dev, err := device.New(d, d.state, entry.Name, configCopy, d.deviceVolatileGetFunc(entry.Name), volatileSet)
+		if errors.Is(err, device.ErrUnsupportedDevType) {
+			continue
+		} else if err != nil {
+			d.logger.Error("Failed stop validation for device", logger.Ctx{"device": entry.Name, "err": err})
+		}
+
+		if dev != nil {
+			err = d.deviceStop(dev, false, "")
+			if err != nil {
+				d.logger.Error("Failed to stop device", logger.Ctx{"device": dev.Name(), "err": err})
+			}
+
+			err = dev.Remove()
+			if err != nil {
+				d.logger.Error("Failed to remove device", logger.Ctx{"device": dev.Name(), "err": err})
+			}
+		}
+	}
+
+	_ = os.Remove(d.pidFilePath())
+	_ = os.Remove(d.monitorPath())
+
+	err = d.unmount()
+	if err != nil {
+		err = fmt.Errorf("Failed unmounting instance: %w", err)
+		op.Done(err)
+		return err
+	}
+
+	err = apparmor.InstanceUnload(d.state.OS, d)
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
+	d.cleanup()
+	_ = os.RemoveAll(d.LogPath())
+
+	op.Done(nil)
+
+	return nil
+}
+
 // cleanupDevices performs any needed device cleanup steps when instance is stopped.
 // Must be called before root volume is unmounted.
 func (d *qemu) cleanupDevices() {
@@ -5137,10 +6711,16 @@ func (d *qemu) Export(w io.Writer, properties map[string]string, expiration time
 
 	fPath := fmt.Sprintf("%s/rootfs.img", tmpPath)
 
+	// The root disk can itself be a qcow2 disk image (when using qcow2 snapshots).
+	diskFormat := "raw"
+	if storageDrivers.IsQcow2DiskPath(mountInfo.DiskPath) {
+		diskFormat = "qcow2"
+	}
+
 	// Convert to qcow2 image.
 	cmd := []string{
 		"nice", "-n19", // Run with low priority to reduce CPU impact on other processes.
-		"qemu-img", "convert", "-f", "raw", "-O", "qcow2", "-c",
+		"qemu-img", "convert", "-f", diskFormat, "-O", "qcow2", "-c",
 	}
 
 	revert := revert.New()
@@ -5690,7 +7270,37 @@ func (d *qemu) DeviceEventHandler(runConf *deviceConfig.RunConfig) error {
 		return nil
 	}
 
-	if runConf == nil || len(runConf.Uevents) == 0 {
+	if runConf == nil {
+		return nil
+	}
+
+	// Apply updated IO limits to the disks that are attached as block devices.
+	if len(runConf.Mounts) > 0 {
+		monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+		if err != nil {
+			return fmt.Errorf("Failed to connect to QMP monitor: %w", err)
+		}
+
+		blockDevices, err := monitor.GetBlockNodeNames()
+		if err != nil {
+			return err
+		}
+
+		for _, mount := range runConf.Mounts {
+			deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, filesystem.PathNameEncode(mount.DevName))
+			_, found := blockDevices[deviceID]
+			if !found {
+				continue // Directory shares can't be limited.
+			}
+
+			err = d.deviceSetBlockLimits(monitor, mount.DevName, mount.Opts)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(runConf.Uevents) == 0 {
 		return nil
 	}
 
@@ -96,21 +96,54 @@ func TestQemuConfigTemplates(t *testing.T) {
 			opts     qemuMemoryOpts
 			expected string
 		}{{
-			qemuMemoryOpts{4096},
+			qemuMemoryOpts{memSizeMB: 4096},
 			`# Memory
 			[memory]
 			size = "4096M"`,
 		}, {
-			qemuMemoryOpts{8192},
+			qemuMemoryOpts{memSizeMB: 8192},
 			`# Memory
 			[memory]
 			size = "8192M"`,
+		}, {
+			qemuMemoryOpts{memSizeMB: 4096, maxMemSizeMB: 16384, slots: 32},
+			`# Memory
+			[memory]
+			size = "4096M"
+			slots = "32"
+			maxmem = "16384M"`,
+		}, {
+			qemuMemoryOpts{memSizeMB: 4096, maxMemSizeMB: 4096, slots: 32},
+			`# Memory
+			[memory]
+			size = "4096M"`,
 		}}
 		for _, tc := range testCases {
 			runTest(tc.expected, qemuMemory(&tc.opts))
 		}
 	})
 
+	t.Run("qemu_memory_dimm", func(t *testing.T) {
+		testCases := []struct {
+			opts     qemuMemoryDIMMOpts
+			expected string
+		}{{
+			qemuMemoryDIMMOpts{id: "lxd_dimm0", memSizeMB: 1024},
+			`# Hotplugged memory
+			[object "lxd_dimm0-mem"]
+			qom-type = "memory-backend-memfd"
+			size = "1024M"
+			share = "on"
+
+			[device "lxd_dimm0"]
+			driver = "pc-dimm"
+			memdev = "lxd_dimm0-mem"`,
+		}}
+		for _, tc := range testCases {
+			runTest(tc.expected, qemuMemoryDIMM(&tc.opts))
+		}
+	})
+
 	t.Run("qemu_serial", func(t *testing.T) {
 		testCases := []struct {
 			opts     qemuSerialOpts
@@ -635,6 +668,38 @@ func TestQemuConfigTemplates(t *testing.T) {
 			node-id = "21"
 			core-id = "22"
 			thread-id = "23"`,
+		}, {
+			qemuCPUOpts{
+				architecture:        "x86_64",
+				cpuCount:            1,
+				cpuMaxCount:         16,
+				cpuSockets:          1,
+				cpuCores:            16,
+				cpuThreads:          1,
+				cpuNumaNodes:        []uint64{},
+				cpuNumaMapping:      []qemuNumaEntry{},
+				cpuNumaHostNodes:    []uint64{},
+				hugepages:           "",
+				memory:              1024,
+				qemuMemObjectFormat: "repeated",
+			},
+			`# CPU
+			[smp-opts]
+			cpus = "1"
+			sockets = "1"
+			cores = "16"
+			threads = "1"
+			maxcpus = "16"
+
+			[object "mem0"]
+			qom-type = "memory-backend-memfd"
+			size = "1024M"
+			share = "on"
+
+			[numa]
+			type = "node"
+			nodeid = "0"
+			memdev = "mem0"`,
 		}, {
 			qemuCPUOpts{
 				architecture: "arm64",
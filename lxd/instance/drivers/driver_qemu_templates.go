@@ -101,14 +101,51 @@ func qemuBase(opts *qemuBaseOpts) []cfgSection {
 }
 
 type qemuMemoryOpts struct {
-	memSizeMB int64
+	memSizeMB    int64
+	maxMemSizeMB int64
+	slots        int
 }
 
 func qemuMemory(opts *qemuMemoryOpts) []cfgSection {
+	entries := []cfgEntry{{key: "size", value: fmt.Sprintf("%dM", opts.memSizeMB)}}
+
+	// Reserve room for memory hotplug.
+	if opts.maxMemSizeMB > opts.memSizeMB && opts.slots > 0 {
+		entries = append(entries, []cfgEntry{
+			{key: "slots", value: fmt.Sprintf("%d", opts.slots)},
+			{key: "maxmem", value: fmt.Sprintf("%dM", opts.maxMemSizeMB)},
+		}...)
+	}
+
 	return []cfgSection{{
 		name:    "memory",
 		comment: "Memory",
-		entries: []cfgEntry{{key: "size", value: fmt.Sprintf("%dM", opts.memSizeMB)}},
+		entries: entries,
+	}}
+}
+
+type qemuMemoryDIMMOpts struct {
+	id        string
+	memSizeMB int64
+}
+
+func qemuMemoryDIMM(opts *qemuMemoryDIMMOpts) []cfgSection {
+	backendID := fmt.Sprintf("%s-mem", opts.id)
+
+	return []cfgSection{{
+		name:    fmt.Sprintf(`object "%s"`, backendID),
+		comment: "Hotplugged memory",
+		entries: []cfgEntry{
+			{key: "qom-type", value: "memory-backend-memfd"},
+			{key: "size", value: fmt.Sprintf("%dM", opts.memSizeMB)},
+			{key: "share", value: "on"},
+		},
+	}, {
+		name: fmt.Sprintf(`device "%s"`, opts.id),
+		entries: []cfgEntry{
+			{key: "driver", value: "pc-dimm"},
+			{key: "memdev", value: backendID},
+		},
 	}}
 }
 
@@ -368,6 +405,7 @@ type qemuNumaEntry struct {
 type qemuCPUOpts struct {
 	architecture        string
 	cpuCount            int
+	cpuMaxCount         int
 	cpuSockets          int
 	cpuCores            int
 	cpuThreads          int
@@ -420,6 +458,11 @@ func qemuCPU(opts *qemuCPUOpts) []cfgSection {
 		},
 	}}
 
+	// Reserve room for vCPU hotplug.
+	if opts.cpuMaxCount > opts.cpuCount {
+		sections[0].entries = append(sections[0].entries, cfgEntry{key: "maxcpus", value: fmt.Sprintf("%d", opts.cpuMaxCount)})
+	}
+
 	if opts.architecture != "x86_64" {
 		return sections
 	}
@@ -0,0 +1,174 @@
+package drivers
+
+import (
+	"net"
+	"testing"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/drivers/qmp"
+)
+
+func TestQemuMigrateSendReleases(t *testing.T) {
+	conn, peer := net.Pipe()
+	defer func() { _ = conn.Close() }()
+	defer func() { _ = peer.Close() }()
+
+	tests := []struct {
+		name    string
+		args    instance.VMMigrateSendArgs
+		release bool
+		err     bool
+	}{
+		{
+			name: "copy",
+			args: instance.VMMigrateSendArgs{DiskConn: conn},
+		},
+		{
+			name:    "cluster move",
+			args:    instance.VMMigrateSendArgs{DiskConn: conn, ClusterMove: true},
+			release: true,
+		},
+		{
+			name:    "cluster move with shared storage",
+			args:    instance.VMMigrateSendArgs{ClusterMove: true},
+			release: true,
+		},
+		{
+			name: "copy with shared storage",
+			args: instance.VMMigrateSendArgs{},
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			release, err := qemuMigrateSendReleases(test.args)
+			if test.err {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if release != test.release {
+				t.Errorf("Expected release %v, got %v", test.release, release)
+			}
+		})
+	}
+}
+
+func TestQemuBlockJobState(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      *qmp.BlockJob
+		ready    bool
+		done     bool
+		progress string
+		err      bool
+	}{
+		{
+			name:  "missing while waiting for ready",
+			ready: true,
+			err:   true,
+		},
+		{
+			name: "missing while waiting for finish",
+			done: true,
+		},
+		{
+			name:     "running",
+			job:      &qmp.BlockJob{Status: "running", IOStatus: "ok", Len: 200, Offset: 50},
+			ready:    true,
+			progress: "25%",
+		},
+		{
+			name:  "running without length",
+			job:   &qmp.BlockJob{Status: "running", IOStatus: "ok"},
+			ready: true,
+		},
+		{
+			name:  "ready",
+			job:   &qmp.BlockJob{Status: "ready", IOStatus: "ok", Ready: true, Len: 200, Offset: 200},
+			ready: true,
+			done:  true,
+		},
+		{
+			name:  "ready status only",
+			job:   &qmp.BlockJob{Status: "ready", Len: 200, Offset: 200},
+			ready: true,
+			done:  true,
+		},
+		{
+			name:     "ready while waiting for finish",
+			job:      &qmp.BlockJob{Status: "ready", IOStatus: "ok", Ready: true, Len: 200, Offset: 200},
+			progress: "100%",
+		},
+		{
+			name:  "paused on I/O error",
+			job:   &qmp.BlockJob{Status: "paused", IOStatus: "nospace", Len: 200, Offset: 100},
+			ready: true,
+			err:   true,
+		},
+		{
+			name: "failed",
+			job:  &qmp.BlockJob{Status: "concluded", Error: "Input/output error"},
+			err:  true,
+		},
+		{
+			name:  "aborting while waiting for ready",
+			job:   &qmp.BlockJob{Status: "aborting"},
+			ready: true,
+			err:   true,
+		},
+		{
+			name: "aborting while waiting for finish",
+			job:  &qmp.BlockJob{Status: "aborting"},
+		},
+		{
+			name:  "concluded while waiting for ready",
+			job:   &qmp.BlockJob{Status: "concluded"},
+			ready: true,
+			err:   true,
+		},
+		{
+			name: "concluded while waiting for finish",
+			job:  &qmp.BlockJob{Status: "concluded"},
+			done: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jobs := map[string]qmp.BlockJob{}
+			if test.job != nil {
+				jobs["job"] = *test.job
+			}
+
+			done, progress, err := qemuBlockJobState(jobs, "job", test.ready)
+			if test.err {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if done != test.done {
+				t.Errorf("Expected done %v, got %v", test.done, done)
+			}
+
+			if progress != test.progress {
+				t.Errorf("Expected progress %q, got %q", test.progress, progress)
+			}
+		})
+	}
+}
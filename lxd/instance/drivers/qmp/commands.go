@@ -288,6 +288,23 @@ func (m *Monitor) GetMemorySizeBytes() (int64, error) {
 	return resp.Return.BaseMemory, nil
 }
 
+// GetMemoryHotpluggedBytes returns the size of the hot added memory in bytes.
+func (m *Monitor) GetMemoryHotpluggedBytes() (int64, error) {
+	// Prepare the response.
+	var resp struct {
+		Return struct {
+			PluggedMemory int64 `json:"plugged-memory"`
+		} `json:"return"`
+	}
+
+	err := m.run("query-memory-size-summary", nil, &resp)
+	if err != nil {
+		return -1, err
+	}
+
+	return resp.Return.PluggedMemory, nil
+}
+
 // GetMemoryBalloonSizeBytes returns effective size of the memory in bytes (considering the current balloon size).
 func (m *Monitor) GetMemoryBalloonSizeBytes() (int64, error) {
 	// Prepare the response.
@@ -543,6 +560,372 @@ func (m *Monitor) GetBlockStats() (map[string]BlockStats, error) {
 	return out, nil
 }
 
+// SetBlockThrottle applies IO limits to the throttle group of a block device (a zero value removes the limit).
+// The block devices sharing a throttle group share its limits.
+func (m *Monitor) SetBlockThrottle(deviceID string, group string, readBps int64, writeBps int64, readIops int64, writeIops int64) error {
+	args := map[string]any{
+		"id":      deviceID,
+		"group":   group,
+		"bps":     0,
+		"bps_rd":  readBps,
+		"bps_wr":  writeBps,
+		"iops":    0,
+		"iops_rd": readIops,
+		"iops_wr": writeIops,
+	}
+
+	err := m.run("block_set_io_throttle", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed setting block device IO limits: %w", err)
+	}
+
+	return nil
+}
+
+// GetBlockNodeNames returns the name of the block node attached to each block device, keyed by device ID.
+func (m *Monitor) GetBlockNodeNames() (map[string]string, error) {
+	// Prepare the response
+	var resp struct {
+		Return []struct {
+			QDev     string `json:"qdev"`
+			Inserted struct {
+				NodeName string `json:"node-name"`
+			} `json:"inserted"`
+		} `json:"return"`
+	}
+
+	err := m.run("query-block", nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("Failed querying block devices: %w", err)
+	}
+
+	out := make(map[string]string)
+
+	for _, res := range resp.Return {
+		if res.Inserted.NodeName != "" {
+			out[res.QDev] = res.Inserted.NodeName
+		}
+	}
+
+	return out, nil
+}
+
+// BlockJob represents a running block job.
+type BlockJob struct {
+	Device string `json:"device"`
+	Type   string `json:"type"`
+	Len    int64  `json:"len"`
+	Offset int64  `json:"offset"`
+	Ready  bool   `json:"ready"`
+	Status string `json:"status"`
+
+	// IOStatus is "ok" unless the job was paused because of an I/O error ("failed" or "nospace").
+	IOStatus string `json:"io-status"`
+
+	// Error is set when the job concluded without completing successfully.
+	Error string `json:"error"`
+}
+
+// GetBlockJobs returns the running block jobs, keyed by job ID.
+func (m *Monitor) GetBlockJobs() (map[string]BlockJob, error) {
+	// Prepare the response
+	var resp struct {
+		Return []BlockJob `json:"return"`
+	}
+
+	err := m.run("query-block-jobs", nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("Failed querying block jobs: %w", err)
+	}
+
+	out := make(map[string]BlockJob)
+
+	for _, job := range resp.Return {
+		out[job.Device] = job
+	}
+
+	return out, nil
+}
+
+// BlockDevMirror starts a block job mirroring the content of the device block node onto the target block node.
+// The sync mode is either "full" to copy the whole device or "top" to only copy its top image.
+func (m *Monitor) BlockDevMirror(jobID string, device string, target string, sync string) error {
+	args := map[string]any{
+		"job-id": jobID,
+		"device": device,
+		"target": target,
+		"sync":   sync,
+	}
+
+	err := m.run("blockdev-mirror", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed starting block mirror: %w", err)
+	}
+
+	return nil
+}
+
+// BlockJobComplete completes a block mirror job, switching the device over to the target block node.
+func (m *Monitor) BlockJobComplete(jobID string) error {
+	args := map[string]string{"device": jobID}
+
+	err := m.run("block-job-complete", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed completing block job: %w", err)
+	}
+
+	return nil
+}
+
+// BlockJobCancel cancels a block job, leaving the device on its original block node.
+func (m *Monitor) BlockJobCancel(jobID string) error {
+	args := map[string]string{"device": jobID}
+
+	err := m.run("block-job-cancel", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed cancelling block job: %w", err)
+	}
+
+	return nil
+}
+
+// BlockDevSnapshot makes the overlay block node the new top image of the node, redirecting all its writes to it.
+func (m *Monitor) BlockDevSnapshot(node string, overlay string) error {
+	args := map[string]string{
+		"node":    node,
+		"overlay": overlay,
+	}
+
+	err := m.run("blockdev-snapshot", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed creating block device snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// BlockCommit starts a block job committing the content of the top image of the device block node into its
+// backing image. When top and base are set, the images from top down to (but excluding) base are committed into
+// base instead, and backingFile (if set) is recorded as the backing file of the image above top.
+func (m *Monitor) BlockCommit(jobID string, device string, top string, base string, backingFile string) error {
+	args := map[string]string{
+		"job-id": jobID,
+		"device": device,
+	}
+
+	if top != "" {
+		args["top-node"] = top
+	}
+
+	if base != "" {
+		args["base-node"] = base
+	}
+
+	if backingFile != "" {
+		args["backing-file"] = backingFile
+	}
+
+	err := m.run("block-commit", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed starting block commit: %w", err)
+	}
+
+	return nil
+}
+
+// ChangeBackingFile changes the backing file recorded in the image of the block node (part of the device's chain).
+func (m *Monitor) ChangeBackingFile(device string, node string, backingFile string) error {
+	args := map[string]string{
+		"device":          device,
+		"image-node-name": node,
+		"backing-file":    backingFile,
+	}
+
+	err := m.run("change-backing-file", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed changing backing file: %w", err)
+	}
+
+	return nil
+}
+
+// BlockNode represents a named block node.
+type BlockNode struct {
+	NodeName string `json:"node-name"`
+	Driver   string `json:"drv"`
+	File     string `json:"file"`
+}
+
+// GetNamedBlockNodes returns all the named block nodes.
+func (m *Monitor) GetNamedBlockNodes() ([]BlockNode, error) {
+	// Prepare the response
+	var resp struct {
+		Return []BlockNode `json:"return"`
+	}
+
+	err := m.run("query-named-block-nodes", map[string]bool{"flat": true}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("Failed querying block nodes: %w", err)
+	}
+
+	return resp.Return, nil
+}
+
+// GetFDSets returns the FD sets.
+func (m *Monitor) GetFDSets() ([]FdsetInfo, error) {
+	// Prepare the response
+	var resp struct {
+		Return []FdsetInfo `json:"return"`
+	}
+
+	err := m.run("query-fdsets", nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query fd sets: %w", err)
+	}
+
+	return resp.Return, nil
+}
+
+// NBDServerStart starts the built-in NBD server listening on the unix socket at path.
+func (m *Monitor) NBDServerStart(path string) error {
+	args := map[string]any{
+		"addr": map[string]any{
+			"type": "unix",
+			"data": map[string]string{
+				"path": path,
+			},
+		},
+	}
+
+	err := m.run("nbd-server-start", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed starting NBD server: %w", err)
+	}
+
+	return nil
+}
+
+// NBDServerAdd exports the device block node on the NBD server using name as the export name.
+func (m *Monitor) NBDServerAdd(device string, name string, writable bool) error {
+	args := map[string]any{
+		"device":   device,
+		"name":     name,
+		"writable": writable,
+	}
+
+	err := m.run("nbd-server-add", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed adding NBD export: %w", err)
+	}
+
+	return nil
+}
+
+// NBDServerStop stops the built-in NBD server, removing all of its exports.
+func (m *Monitor) NBDServerStop() error {
+	err := m.run("nbd-server-stop", nil, nil)
+	if err != nil {
+		return fmt.Errorf("Failed stopping NBD server: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateSetCapabilities enables or disables migration capabilities.
+func (m *Monitor) MigrateSetCapabilities(capabilities map[string]bool) error {
+	caps := make([]map[string]any, 0, len(capabilities))
+	for name, state := range capabilities {
+		caps = append(caps, map[string]any{"capability": name, "state": state})
+	}
+
+	args := map[string]any{"capabilities": caps}
+
+	err := m.run("migrate-set-capabilities", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed setting migration capabilities: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateCancel cancels the ongoing migration.
+func (m *Monitor) MigrateCancel() error {
+	return m.run("migrate_cancel", nil, nil)
+}
+
+// HotpluggableCPU represents a vCPU slot, which is populated if its QOM path is set.
+type HotpluggableCPU struct {
+	Type    string         `json:"type"`
+	QOMPath string         `json:"qom-path"`
+	Props   map[string]int `json:"props"`
+}
+
+// QueryHotpluggableCPUs returns the vCPU slots of the VM.
+func (m *Monitor) QueryHotpluggableCPUs() ([]HotpluggableCPU, error) {
+	// Prepare the response.
+	var resp struct {
+		Return []HotpluggableCPU `json:"return"`
+	}
+
+	err := m.run("query-hotpluggable-cpus", nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("Failed querying hotpluggable CPUs: %w", err)
+	}
+
+	return resp.Return, nil
+}
+
+// AddCPU hot adds a vCPU into the given slot using id as its device ID.
+func (m *Monitor) AddCPU(cpu HotpluggableCPU, id string) error {
+	args := map[string]any{
+		"driver": cpu.Type,
+		"id":     id,
+	}
+
+	for k, v := range cpu.Props {
+		args[k] = v
+	}
+
+	err := m.run("device_add", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed adding CPU: %w", err)
+	}
+
+	return nil
+}
+
+// AddMemory hot adds a memory DIMM of the given size using id as its device ID.
+func (m *Monitor) AddMemory(id string, sizeBytes int64) error {
+	backendID := fmt.Sprintf("%s-mem", id)
+
+	backend := map[string]any{
+		"qom-type": "memory-backend-memfd",
+		"id":       backendID,
+		"size":     sizeBytes,
+		"share":    true,
+	}
+
+	err := m.run("object-add", backend, nil)
+	if err != nil {
+		return fmt.Errorf("Failed adding memory backend: %w", err)
+	}
+
+	device := map[string]any{
+		"driver": "pc-dimm",
+		"id":     id,
+		"memdev": backendID,
+	}
+
+	err = m.run("device_add", device, nil)
+	if err != nil {
+		_ = m.run("object-del", map[string]string{"id": backendID}, nil)
+
+		return fmt.Errorf("Failed adding memory device: %w", err)
+	}
+
+	return nil
+}
+
 // AddSecret adds a secret object with the given ID and secret. This function won't return an error
 // if the secret object already exists.
 func (m *Monitor) AddSecret(id string, secret string) error {
@@ -0,0 +1,268 @@
+package instance
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/shared/units"
+)
+
+// AutoscaleDefaultCooldown is the minimum time between two adjustments of an instance's limits.
+const AutoscaleDefaultCooldown = 5 * time.Minute
+
+// AutoscaleDefaultMemoryStep is the amount of memory added or removed on each memory adjustment.
+const AutoscaleDefaultMemoryStep = "256MiB"
+
+// autoscaleDefaultVMMemory is the memory size of virtual machines that don't set limits.memory.
+const autoscaleDefaultVMMemory = "1GiB"
+
+// AutoscalePolicy represents the limits.autoscale.* settings of an instance.
+// A resource is only autoscaled when its maximum is set (CPUMax or MemoryMax greater than 0).
+type AutoscalePolicy struct {
+	CPUMin           int64
+	CPUMax           int64
+	CPUThresholdHigh float64
+	CPUThresholdLow  float64
+
+	MemoryMin           int64
+	MemoryMax           int64
+	MemoryStep          int64
+	MemoryThresholdHigh float64
+	MemoryThresholdLow  float64
+
+	Cooldown time.Duration
+}
+
+// ParseAutoscalePolicy parses and validates the limits.autoscale.* keys of the instance config.
+// Returns nil if autoscaling isn't enabled for any resource.
+func ParseAutoscalePolicy(config map[string]string) (*AutoscalePolicy, error) {
+	if config["limits.autoscale.cpu.max"] == "" && config["limits.autoscale.memory.max"] == "" {
+		for k := range config {
+			if strings.HasPrefix(k, "limits.autoscale.") {
+				return nil, fmt.Errorf("%s requires limits.autoscale.cpu.max or limits.autoscale.memory.max to be set", k)
+			}
+		}
+
+		return nil, nil
+	}
+
+	var err error
+	p := &AutoscalePolicy{}
+
+	getInt := func(key string, defaultValue int64) (int64, error) {
+		if config[key] == "" {
+			return defaultValue, nil
+		}
+
+		value, err := strconv.ParseInt(config[key], 10, 64)
+		if err != nil {
+			return -1, fmt.Errorf("Invalid %s: %w", key, err)
+		}
+
+		return value, nil
+	}
+
+	getSize := func(key string, defaultValue string) (int64, error) {
+		value := config[key]
+		if value == "" {
+			value = defaultValue
+		}
+
+		size, err := units.ParseByteSizeString(value)
+		if err != nil {
+			return -1, fmt.Errorf("Invalid %s: %w", key, err)
+		}
+
+		return size, nil
+	}
+
+	getThresholds := func(prefix string, defaultLow int64, defaultHigh int64) (float64, float64, error) {
+		low, err := getInt(prefix+".threshold.low", defaultLow)
+		if err != nil {
+			return -1, -1, err
+		}
+
+		high, err := getInt(prefix+".threshold.high", defaultHigh)
+		if err != nil {
+			return -1, -1, err
+		}
+
+		if low < 0 || high > 100 || low >= high {
+			return -1, -1, fmt.Errorf("%s.threshold.low and %s.threshold.high must be percentages with the low threshold below the high threshold", prefix, prefix)
+		}
+
+		return float64(low), float64(high), nil
+	}
+
+	if config["limits.autoscale.cpu.max"] != "" {
+		p.CPUMax, err = getInt("limits.autoscale.cpu.max", 0)
+		if err != nil {
+			return nil, err
+		}
+
+		p.CPUMin, err = getInt("limits.autoscale.cpu.min", 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.CPUMin < 1 || p.CPUMin > p.CPUMax {
+			return nil, fmt.Errorf("limits.autoscale.cpu.min must be at least 1 and no greater than limits.autoscale.cpu.max")
+		}
+
+		p.CPUThresholdLow, p.CPUThresholdHigh, err = getThresholds("limits.autoscale.cpu", 20, 80)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only a number of CPUs can be adjusted, not a set of pinned CPUs.
+		if config["limits.cpu"] != "" {
+			_, err = strconv.Atoi(config["limits.cpu"])
+			if err != nil {
+				return nil, fmt.Errorf("limits.autoscale.cpu.max cannot be used with pinned CPUs in limits.cpu")
+			}
+		}
+	} else if config["limits.autoscale.cpu.min"] != "" || config["limits.autoscale.cpu.threshold.low"] != "" || config["limits.autoscale.cpu.threshold.high"] != "" {
+		return nil, fmt.Errorf("CPU autoscaling settings require limits.autoscale.cpu.max to be set")
+	}
+
+	if config["limits.autoscale.memory.max"] != "" {
+		p.MemoryMax, err = getSize("limits.autoscale.memory.max", "")
+		if err != nil {
+			return nil, err
+		}
+
+		p.MemoryStep, err = getSize("limits.autoscale.memory.step", AutoscaleDefaultMemoryStep)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.MemoryStep <= 0 {
+			return nil, fmt.Errorf("limits.autoscale.memory.step must be greater than 0")
+		}
+
+		p.MemoryMin = p.MemoryStep
+		if config["limits.autoscale.memory.min"] != "" {
+			p.MemoryMin, err = getSize("limits.autoscale.memory.min", "")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if p.MemoryMin > p.MemoryMax {
+			return nil, fmt.Errorf("limits.autoscale.memory.min cannot be greater than limits.autoscale.memory.max")
+		}
+
+		p.MemoryThresholdLow, p.MemoryThresholdHigh, err = getThresholds("limits.autoscale.memory", 40, 80)
+		if err != nil {
+			return nil, err
+		}
+	} else if config["limits.autoscale.memory.min"] != "" || config["limits.autoscale.memory.step"] != "" || config["limits.autoscale.memory.threshold.low"] != "" || config["limits.autoscale.memory.threshold.high"] != "" {
+		return nil, fmt.Errorf("Memory autoscaling settings require limits.autoscale.memory.max to be set")
+	}
+
+	cooldown, err := getInt("limits.autoscale.cooldown", int64(AutoscaleDefaultCooldown/time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	if cooldown < 0 {
+		return nil, fmt.Errorf("limits.autoscale.cooldown cannot be negative")
+	}
+
+	p.Cooldown = time.Duration(cooldown) * time.Second
+
+	return p, nil
+}
+
+// NextCPU returns the number of CPUs the instance should have given its current number of CPUs and its CPU
+// usage (in percent of the current number of CPUs). A negative usage means it isn't known, in which case only
+// the bounds are applied. The result is always within the policy bounds.
+func (p *AutoscalePolicy) NextCPU(current int64, usage float64) int64 {
+	next := current
+	if usage >= p.CPUThresholdHigh {
+		next++
+	} else if usage >= 0 && usage <= p.CPUThresholdLow {
+		next--
+	}
+
+	return clampInt64(next, p.CPUMin, p.CPUMax)
+}
+
+// NextMemory returns the memory size in bytes the instance should have given its current memory size and its
+// memory usage (in percent of the current size). A negative usage means it isn't known, in which case only the
+// bounds are applied. The result is always within the policy bounds.
+func (p *AutoscalePolicy) NextMemory(current int64, usage float64) int64 {
+	next := current
+	if usage >= p.MemoryThresholdHigh {
+		next += p.MemoryStep
+	} else if usage >= 0 && usage <= p.MemoryThresholdLow {
+		next -= p.MemoryStep
+	}
+
+	return clampInt64(next, p.MemoryMin, p.MemoryMax)
+}
+
+// AutoscaleCurrentCPUs returns the number of CPUs an instance currently has according to its limits.cpu setting.
+// Without limits.cpu, virtual machines have a single CPU and containers can use all of the hostCPUs.
+// Returns false if limits.cpu pins the instance to a range or set of CPUs, which isn't autoscaled.
+func AutoscaleCurrentCPUs(instType instancetype.Type, limit string, hostCPUs int64) (int64, bool) {
+	if limit == "" {
+		if instType == instancetype.VM {
+			return 1, true
+		}
+
+		return hostCPUs, true
+	}
+
+	cpus, err := strconv.ParseInt(limit, 10, 64)
+	if err != nil {
+		return -1, false
+	}
+
+	return cpus, true
+}
+
+// AutoscaleCurrentMemory returns the memory size in bytes an instance currently has according to its
+// limits.memory setting. Without limits.memory, virtual machines have 1GiB and containers can use all of the
+// memory returned by hostMemory. Returns false if limits.memory is a percentage, which isn't autoscaled.
+func AutoscaleCurrentMemory(instType instancetype.Type, limit string, hostMemory func() (int64, error)) (int64, bool, error) {
+	if strings.HasSuffix(limit, "%") {
+		return -1, false, nil
+	}
+
+	if limit == "" {
+		if instType == instancetype.VM {
+			limit = autoscaleDefaultVMMemory
+		} else {
+			memory, err := hostMemory()
+			if err != nil {
+				return -1, false, fmt.Errorf("Failed getting host memory: %w", err)
+			}
+
+			return memory, true, nil
+		}
+	}
+
+	memory, err := units.ParseByteSizeString(limit)
+	if err != nil {
+		return -1, false, fmt.Errorf("Invalid limits.memory: %w", err)
+	}
+
+	return memory, true, nil
+}
+
+// clampInt64 returns value limited to the range between min and max.
+func clampInt64(value int64, min int64, max int64) int64 {
+	if value < min {
+		return min
+	}
+
+	if value > max {
+		return max
+	}
+
+	return value
+}
@@ -0,0 +1,261 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+)
+
+func TestParseAutoscalePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]string
+		policy *AutoscalePolicy
+		err    bool
+	}{
+		{
+			name:   "disabled",
+			config: map[string]string{"limits.cpu": "2"},
+		},
+		{
+			name:   "settings without maximum",
+			config: map[string]string{"limits.autoscale.cooldown": "60"},
+			err:    true,
+		},
+		{
+			name:   "CPU defaults",
+			config: map[string]string{"limits.autoscale.cpu.max": "4"},
+			policy: &AutoscalePolicy{CPUMin: 1, CPUMax: 4, CPUThresholdLow: 20, CPUThresholdHigh: 80, Cooldown: AutoscaleDefaultCooldown},
+		},
+		{
+			name: "CPU custom",
+			config: map[string]string{
+				"limits.autoscale.cpu.max":            "8",
+				"limits.autoscale.cpu.min":            "2",
+				"limits.autoscale.cpu.threshold.low":  "10",
+				"limits.autoscale.cpu.threshold.high": "90",
+				"limits.autoscale.cooldown":           "60",
+				"limits.cpu":                          "4",
+			},
+			policy: &AutoscalePolicy{CPUMin: 2, CPUMax: 8, CPUThresholdLow: 10, CPUThresholdHigh: 90, Cooldown: time.Minute},
+		},
+		{
+			name:   "CPU invalid maximum",
+			config: map[string]string{"limits.autoscale.cpu.max": "foo"},
+			err:    true,
+		},
+		{
+			name:   "CPU minimum above maximum",
+			config: map[string]string{"limits.autoscale.cpu.max": "2", "limits.autoscale.cpu.min": "4"},
+			err:    true,
+		},
+		{
+			name:   "CPU minimum of zero",
+			config: map[string]string{"limits.autoscale.cpu.max": "2", "limits.autoscale.cpu.min": "0"},
+			err:    true,
+		},
+		{
+			name:   "CPU low threshold above high threshold",
+			config: map[string]string{"limits.autoscale.cpu.max": "2", "limits.autoscale.cpu.threshold.low": "80", "limits.autoscale.cpu.threshold.high": "50"},
+			err:    true,
+		},
+		{
+			name:   "CPU threshold above 100",
+			config: map[string]string{"limits.autoscale.cpu.max": "2", "limits.autoscale.cpu.threshold.high": "120"},
+			err:    true,
+		},
+		{
+			name:   "CPU pinned",
+			config: map[string]string{"limits.autoscale.cpu.max": "4", "limits.cpu": "0-1"},
+			err:    true,
+		},
+		{
+			name:   "CPU settings without maximum",
+			config: map[string]string{"limits.autoscale.memory.max": "1GiB", "limits.autoscale.cpu.min": "2"},
+			err:    true,
+		},
+		{
+			name:   "memory defaults",
+			config: map[string]string{"limits.autoscale.memory.max": "1GiB"},
+			policy: &AutoscalePolicy{MemoryMin: 256 * 1024 * 1024, MemoryMax: 1024 * 1024 * 1024, MemoryStep: 256 * 1024 * 1024, MemoryThresholdLow: 40, MemoryThresholdHigh: 80, Cooldown: AutoscaleDefaultCooldown},
+		},
+		{
+			name: "memory custom",
+			config: map[string]string{
+				"limits.autoscale.memory.max":            "2GiB",
+				"limits.autoscale.memory.min":            "512MiB",
+				"limits.autoscale.memory.step":           "128MiB",
+				"limits.autoscale.memory.threshold.low":  "30",
+				"limits.autoscale.memory.threshold.high": "70",
+			},
+			policy: &AutoscalePolicy{MemoryMin: 512 * 1024 * 1024, MemoryMax: 2 * 1024 * 1024 * 1024, MemoryStep: 128 * 1024 * 1024, MemoryThresholdLow: 30, MemoryThresholdHigh: 70, Cooldown: AutoscaleDefaultCooldown},
+		},
+		{
+			name:   "memory invalid maximum",
+			config: map[string]string{"limits.autoscale.memory.max": "lots"},
+			err:    true,
+		},
+		{
+			name:   "memory zero step",
+			config: map[string]string{"limits.autoscale.memory.max": "1GiB", "limits.autoscale.memory.step": "0"},
+			err:    true,
+		},
+		{
+			name:   "memory minimum above maximum",
+			config: map[string]string{"limits.autoscale.memory.max": "1GiB", "limits.autoscale.memory.min": "2GiB"},
+			err:    true,
+		},
+		{
+			name:   "memory settings without maximum",
+			config: map[string]string{"limits.autoscale.cpu.max": "2", "limits.autoscale.memory.step": "128MiB"},
+			err:    true,
+		},
+		{
+			name:   "negative cooldown",
+			config: map[string]string{"limits.autoscale.cpu.max": "2", "limits.autoscale.cooldown": "-1"},
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := ParseAutoscalePolicy(test.config)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.policy, policy)
+		})
+	}
+}
+
+func TestAutoscalePolicyNextCPU(t *testing.T) {
+	policy := &AutoscalePolicy{CPUMin: 1, CPUMax: 4, CPUThresholdLow: 20, CPUThresholdHigh: 80}
+
+	tests := []struct {
+		name    string
+		current int64
+		usage   float64
+		next    int64
+	}{
+		{name: "high usage", current: 2, usage: 90, next: 3},
+		{name: "high threshold", current: 2, usage: 80, next: 3},
+		{name: "high usage at maximum", current: 4, usage: 100, next: 4},
+		{name: "normal usage", current: 2, usage: 50, next: 2},
+		{name: "low usage", current: 2, usage: 10, next: 1},
+		{name: "low threshold", current: 2, usage: 20, next: 1},
+		{name: "low usage at minimum", current: 1, usage: 0, next: 1},
+		{name: "unknown usage", current: 2, usage: -1, next: 2},
+		{name: "unknown usage above maximum", current: 8, usage: -1, next: 4},
+		{name: "low usage above maximum", current: 8, usage: 0, next: 4},
+		{name: "unknown usage below minimum", current: 0, usage: -1, next: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.next, policy.NextCPU(test.current, test.usage))
+		})
+	}
+}
+
+func TestAutoscalePolicyNextMemory(t *testing.T) {
+	const MiB = 1024 * 1024
+
+	policy := &AutoscalePolicy{MemoryMin: 256 * MiB, MemoryMax: 1024 * MiB, MemoryStep: 256 * MiB, MemoryThresholdLow: 40, MemoryThresholdHigh: 80}
+
+	tests := []struct {
+		name    string
+		current int64
+		usage   float64
+		next    int64
+	}{
+		{name: "high usage", current: 512 * MiB, usage: 90, next: 768 * MiB},
+		{name: "high usage near maximum", current: 900 * MiB, usage: 90, next: 1024 * MiB},
+		{name: "high usage at maximum", current: 1024 * MiB, usage: 95, next: 1024 * MiB},
+		{name: "normal usage", current: 512 * MiB, usage: 60, next: 512 * MiB},
+		{name: "low usage", current: 512 * MiB, usage: 30, next: 256 * MiB},
+		{name: "low usage near minimum", current: 300 * MiB, usage: 10, next: 256 * MiB},
+		{name: "low usage at minimum", current: 256 * MiB, usage: 0, next: 256 * MiB},
+		{name: "unknown usage", current: 512 * MiB, usage: -1, next: 512 * MiB},
+		{name: "unknown usage above maximum", current: 2048 * MiB, usage: -1, next: 1024 * MiB},
+		{name: "unknown usage below minimum", current: 128 * MiB, usage: -1, next: 256 * MiB},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.next, policy.NextMemory(test.current, test.usage))
+		})
+	}
+}
+
+func TestAutoscaleCurrentCPUs(t *testing.T) {
+	tests := []struct {
+		name     string
+		instType instancetype.Type
+		limit    string
+		cpus     int64
+		scalable bool
+	}{
+		{name: "container count", instType: instancetype.Container, limit: "2", cpus: 2, scalable: true},
+		{name: "container unset", instType: instancetype.Container, limit: "", cpus: 16, scalable: true},
+		{name: "vm count", instType: instancetype.VM, limit: "4", cpus: 4, scalable: true},
+		{name: "vm unset", instType: instancetype.VM, limit: "", cpus: 1, scalable: true},
+		{name: "range", instType: instancetype.Container, limit: "0-3", scalable: false},
+		{name: "set", instType: instancetype.VM, limit: "0,2", scalable: false},
+		{name: "single pinned cpu", instType: instancetype.Container, limit: "1-1", scalable: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpus, scalable := AutoscaleCurrentCPUs(test.instType, test.limit, 16)
+			assert.Equal(t, test.scalable, scalable)
+			if test.scalable {
+				assert.Equal(t, test.cpus, cpus)
+			}
+		})
+	}
+}
+
+func TestAutoscaleCurrentMemory(t *testing.T) {
+	const MiB = 1024 * 1024
+
+	hostMemory := func() (int64, error) { return 8192 * MiB, nil }
+
+	tests := []struct {
+		name     string
+		instType instancetype.Type
+		limit    string
+		memory   int64
+		scalable bool
+		err      bool
+	}{
+		{name: "container size", instType: instancetype.Container, limit: "512MiB", memory: 512 * MiB, scalable: true},
+		{name: "container unset", instType: instancetype.Container, limit: "", memory: 8192 * MiB, scalable: true},
+		{name: "vm size", instType: instancetype.VM, limit: "2GiB", memory: 2048 * MiB, scalable: true},
+		{name: "vm unset", instType: instancetype.VM, limit: "", memory: 1024 * MiB, scalable: true},
+		{name: "percentage", instType: instancetype.Container, limit: "50%", scalable: false},
+		{name: "invalid", instType: instancetype.Container, limit: "lots", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			memory, scalable, err := AutoscaleCurrentMemory(test.instType, test.limit, hostMemory)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.scalable, scalable)
+			if test.scalable {
+				assert.Equal(t, test.memory, memory)
+			}
+		})
+	}
+}
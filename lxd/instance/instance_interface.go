@@ -71,6 +71,7 @@ type Instance interface {
 	Restart(timeout time.Duration) error
 	Unfreeze() error
 	RegisterDevices()
+	ReloadDevice(deviceName string) error
 	SaveConfigFile() error
 
 	Info() Info
@@ -171,6 +172,44 @@ type Container interface {
 	IdmappedStorage(path string) idmap.IdmapStorageType
 }
 
+// VM interface is for VM specific functions.
+type VM interface {
+	Instance
+
+	// MirrorRootDisk mirrors the root disk of the running VM onto the disk at diskPath and switches the VM over
+	// to it once both are in sync. The switchover function is called just before switching over and the mirror
+	// is cancelled (leaving the VM on its original root disk) if it returns an error.
+	MirrorRootDisk(diskPath string, switchover func() error, op *operations.Operation) error
+
+	// RemountConfigDrive moves the config drive share of the running VM onto its current instance volume after
+	// the volume was moved to another storage pool.
+	RemountConfigDrive() error
+
+	// MigrateSend live migrates the running VM to a target receiving it with MigrateReceive, resuming the
+	// local VM once the target has confirmed it resumed it. After a cluster move, the local VM is released instead
+	// as the target took over its database record.
+	MigrateSend(args VMMigrateSendArgs) error
+
+	// MigrateReceive starts the VM from a live migration sent by MigrateSend.
+	MigrateReceive(args VMMigrateReceiveArgs) error
+}
+
+// VMMigrateSendArgs represents the arguments for live migrating a running VM.
+type VMMigrateSendArgs struct {
+	StateConn   io.ReadWriteCloser // Connection the VM state is sent over.
+	DiskConn    io.ReadWriteCloser // Connection the root disk writes made during the migration are sent over, nil if the storage is shared with the target.
+	StorageSync func() error       // Transfers the instance volumes while the root disk content is frozen, nil if the storage is shared with the target.
+	Confirm     func() error       // Waits for the target to confirm it resumed the VM.
+	ClusterMove bool               // Whether the VM is moved to another cluster member which takes over its database record.
+	Op          *operations.Operation
+}
+
+// VMMigrateReceiveArgs represents the arguments for receiving a live migrated VM.
+type VMMigrateReceiveArgs struct {
+	StateConn io.ReadWriteCloser // Connection the VM state is received over.
+	DiskConn  io.ReadWriteCloser // Connection the root disk writes made during the migration are received over, nil if the storage is shared with the source.
+}
+
 // CriuMigrationArgs arguments for CRIU migration.
 type CriuMigrationArgs struct {
 	Cmd          uint
@@ -186,6 +186,11 @@ func ValidConfig(sysOS *sys.OS, config map[string]string, expanded bool, instanc
 		return err
 	}
 
+	err = validScheduleConfig(config)
+	if err != nil {
+		return err
+	}
+
 	if expanded && (shared.IsFalseOrEmpty(config["security.privileged"])) && sysOS.IdmapSet == nil {
 		return fmt.Errorf("LXD doesn't have a uid/gid allocation. In this mode, only privileged containers are supported")
 	}
@@ -208,6 +213,14 @@ func ValidConfig(sysOS *sys.OS, config map[string]string, expanded bool, instanc
 		return fmt.Errorf("nvidia.runtime is incompatible with privileged containers")
 	}
 
+	// Autoscaling bounds may be split between profiles and the instance, so only check the expanded config.
+	if expanded {
+		_, err := ParseAutoscalePolicy(config)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -235,6 +248,28 @@ func validConfigKey(os *sys.OS, key string, value string, instanceType instancet
 	return nil
 }
 
+// validScheduleConfig checks that at most one of the schedule.* keys uses schedule aliases. The aliases all run at
+// the same minute past the hour for a given instance, so actions scheduled with them would collide.
+func validScheduleConfig(config map[string]string) error {
+	aliasKey := ""
+	for _, key := range []string{"schedule.start", "schedule.stop", "schedule.restart"} {
+		for _, spec := range shared.SplitNTrimSpace(config[key], ",", -1, true) {
+			if !strings.HasPrefix(spec, "@") || strings.ToLower(spec) == "@never" {
+				continue
+			}
+
+			if aliasKey != "" {
+				return fmt.Errorf("%s and %s can't both use schedule aliases as they would run at the same time, use a cron expression instead", aliasKey, key)
+			}
+
+			aliasKey = key
+			break
+		}
+	}
+
+	return nil
+}
+
 func lxcParseRawLXC(line string) (string, string, error) {
 	// Ignore empty lines
 	if len(line) == 0 {
@@ -663,7 +698,7 @@ func BackupLoadByName(s *state.State, project, name string) (*backup.InstanceBac
 		return nil, fmt.Errorf("Load instance from database: %w", err)
 	}
 
-	return backup.NewInstanceBackup(s, instance, args.ID, name, args.CreationDate, args.ExpiryDate, args.InstanceOnly, args.OptimizedStorage), nil
+	return backup.NewInstanceBackup(s, instance, args.ID, name, args.CreationDate, args.ExpiryDate, args.InstanceOnly, args.OptimizedStorage, args.Parent), nil
 }
 
 // ResolveImage takes an instance source and returns a hash suitable for instance creation or download.
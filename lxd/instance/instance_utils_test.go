@@ -0,0 +1,36 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidScheduleConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]string
+		err    bool
+	}{
+		{name: "no schedules", config: map[string]string{}},
+		{name: "single alias", config: map[string]string{"schedule.start": "@daily"}},
+		{name: "alias and cron", config: map[string]string{"schedule.start": "@daily", "schedule.stop": "0 18 * * *"}},
+		{name: "cron expressions", config: map[string]string{"schedule.start": "0 8 * * *", "schedule.stop": "0 8 * * *"}},
+		{name: "aliases on one key", config: map[string]string{"schedule.restart": "@daily, @weekly"}},
+		{name: "never alias", config: map[string]string{"schedule.start": "@daily", "schedule.stop": "@never"}},
+		{name: "same alias on two keys", config: map[string]string{"schedule.start": "@daily", "schedule.stop": "@daily"}, err: true},
+		{name: "different aliases on two keys", config: map[string]string{"schedule.stop": "@hourly", "schedule.restart": "@weekly"}, err: true},
+		{name: "alias in list", config: map[string]string{"schedule.start": "0 8 * * *, @MONTHLY", "schedule.stop": "@yearly"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validScheduleConfig(test.config)
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
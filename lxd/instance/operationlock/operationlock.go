@@ -36,6 +36,9 @@ const ActionRestore Action = "restore"
 // ActionUpdate for updating an instance.
 const ActionUpdate Action = "update"
 
+// ActionAutoscale for adjusting the limits of an instance.
+const ActionAutoscale Action = "autoscale"
+
 // ErrNonReusuableSucceeded is returned when no operation is created due to having to wait for a matching
 // non-reusuable operation that has now completed successfully.
 var ErrNonReusuableSucceeded error = fmt.Errorf("A matching non-reusable operation has now succeeded")
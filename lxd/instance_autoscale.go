@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/metrics"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// instanceAutoscaleState is what the autoscaler remembers about an instance between two runs.
+type instanceAutoscaleState struct {
+	// Time and total busy CPU seconds of the previous sample, used to compute the CPU usage.
+	sampleTime time.Time
+	cpuSeconds float64
+
+	// Time of the last adjustment of the instance's limits.
+	lastChange time.Time
+}
+
+func autoscaleInstancesTask(d *Daemon) (task.Func, task.Schedule) {
+	// Autoscaling state of each instance, keyed by project and instance name.
+	states := map[string]*instanceAutoscaleState{}
+
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		instances, err := instance.LoadNodeAll(s, instancetype.Any)
+		if err != nil {
+			logger.Error("Failed to load instances for autoscaling", logger.Ctx{"err": err})
+			return
+		}
+
+		seen := map[string]bool{}
+		for _, inst := range instances {
+			if ctx.Err() != nil {
+				return
+			}
+
+			config := inst.ExpandedConfig()
+			if config["limits.autoscale.cpu.max"] == "" && config["limits.autoscale.memory.max"] == "" {
+				continue
+			}
+
+			if !inst.IsRunning() {
+				continue
+			}
+
+			key := project.Instance(inst.Project(), inst.Name())
+			seen[key] = true
+
+			if states[key] == nil {
+				states[key] = &instanceAutoscaleState{}
+			}
+
+			err := autoscaleInstance(s, inst, states[key])
+			if err != nil {
+				logger.Warn("Failed autoscaling instance", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+			}
+		}
+
+		// Forget about instances that were stopped, deleted or had autoscaling disabled.
+		for key := range states {
+			if !seen[key] {
+				delete(states, key)
+			}
+		}
+	}
+
+	schedule := task.Every(time.Minute)
+
+	return f, schedule
+}
+
+// autoscaleInstance samples the metrics of a running instance and adjusts its limits.cpu and limits.memory
+// settings according to its autoscaling policy, sending a lifecycle event when they were changed.
+func autoscaleInstance(s *state.State, inst instance.Instance, autoscaleState *instanceAutoscaleState) error {
+	config := inst.ExpandedConfig()
+
+	policy, err := instance.ParseAutoscalePolicy(config)
+	if err != nil {
+		return err
+	}
+
+	if policy == nil {
+		return nil
+	}
+
+	metricSet, err := inst.Metrics()
+	if err != nil {
+		return fmt.Errorf("Failed getting metrics: %w", err)
+	}
+
+	now := time.Now()
+	newValues := map[string]string{}
+	usage := map[string]any{}
+
+	// Sum the time spent by all CPUs on anything but idling, the usage is then the rate of growth of that sum.
+	cpuSeconds := float64(0)
+	for _, sample := range metricSet.Samples(metrics.CPUSecondsTotal) {
+		if sample.Labels["mode"] != "idle" && sample.Labels["mode"] != "iowait" {
+			cpuSeconds += sample.Value
+		}
+	}
+
+	previousTime := autoscaleState.sampleTime
+	previousSeconds := autoscaleState.cpuSeconds
+	autoscaleState.sampleTime = now
+	autoscaleState.cpuSeconds = cpuSeconds
+
+	cooldown := now.Sub(autoscaleState.lastChange) < policy.Cooldown
+
+	cpus, cpuScalable := instance.AutoscaleCurrentCPUs(inst.Type(), config["limits.cpu"], int64(runtime.NumCPU()))
+	if policy.CPUMax > 0 && cpuScalable {
+		// Counters go backwards when vCPUs are removed or the instance restarts, skip until the next sample.
+		cpuUsage := float64(-1)
+		if !previousTime.IsZero() && cpuSeconds >= previousSeconds {
+			cpuUsage = (cpuSeconds - previousSeconds) * 100 / (now.Sub(previousTime).Seconds() * float64(cpus))
+			usage["cpu_usage"] = cpuUsage
+		}
+
+		// During the cooldown, only a limit set outside of the bounds is brought back within them.
+		if cooldown {
+			cpuUsage = -1
+		}
+
+		next := policy.NextCPU(cpus, cpuUsage)
+		if next != cpus {
+			newValues["limits.cpu"] = strconv.FormatInt(next, 10)
+		}
+	}
+
+	memory := int64(-1)
+	memoryScalable := false
+	if policy.MemoryMax > 0 {
+		memory, memoryScalable, err = instance.AutoscaleCurrentMemory(inst.Type(), config["limits.memory"], shared.DeviceTotalMemory)
+		if err != nil {
+			return err
+		}
+	}
+
+	if memoryScalable {
+		memoryUsage := float64(-1)
+		memTotal := metricSet.Samples(metrics.MemoryMemTotalBytes)
+		memAvailable := metricSet.Samples(metrics.MemoryMemAvailableBytes)
+		if len(memTotal) > 0 && len(memAvailable) > 0 && memTotal[0].Value > 0 {
+			memoryUsage = (memTotal[0].Value - memAvailable[0].Value) * 100 / memTotal[0].Value
+			usage["memory_usage"] = memoryUsage
+		}
+
+		if cooldown {
+			memoryUsage = -1
+		}
+
+		next := policy.NextMemory(memory, memoryUsage)
+		if next != memory {
+			newValues["limits.memory"] = autoscaleMemoryString(next)
+		}
+	}
+
+	if len(newValues) == 0 {
+		return nil
+	}
+
+	// Prevent concurrent changes to the instance while its limits are updated.
+	op, err := operationlock.Create(inst.Project(), inst.Name(), operationlock.ActionAutoscale, false, false)
+	if err != nil {
+		logger.Debug("Skipping autoscaling of busy instance", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+		return nil
+	}
+
+	defer op.Done(nil)
+
+	// Reload the instance so changes made since the metrics were sampled aren't overwritten.
+	inst, err = instance.LoadByProjectAndName(s, inst.Project(), inst.Name())
+	if err != nil {
+		return fmt.Errorf("Failed reloading instance: %w", err)
+	}
+
+	if !inst.IsRunning() {
+		return nil
+	}
+
+	// Skip this run if the limits or the policy were changed in the meantime.
+	if autoscaleConfigChanged(config, inst.ExpandedConfig()) {
+		return nil
+	}
+
+	localConfig := make(map[string]string, len(inst.LocalConfig())+len(newValues))
+	for k, v := range inst.LocalConfig() {
+		localConfig[k] = v
+	}
+
+	oldValues := map[string]string{}
+	for k, v := range newValues {
+		oldValues[k] = config[k]
+		localConfig[k] = v
+	}
+
+	args := db.InstanceArgs{
+		Architecture: inst.Architecture(),
+		Config:       localConfig,
+		Description:  inst.Description(),
+		Devices:      inst.LocalDevices(),
+		Ephemeral:    inst.IsEphemeral(),
+		Profiles:     inst.Profiles(),
+		Project:      inst.Project(),
+		ExpiryDate:   inst.ExpiryDate(),
+	}
+
+	// Retry after the cooldown rather than on every run when the change can't be applied.
+	autoscaleState.lastChange = now
+
+	err = inst.Update(args, false)
+	if err != nil {
+		return fmt.Errorf("Failed applying %v: %w", newValues, err)
+	}
+
+	// The CPU counters change meaning with the number of CPUs, start sampling again.
+	if newValues["limits.cpu"] != "" {
+		autoscaleState.sampleTime = time.Time{}
+	}
+
+	logger.Info("Autoscaled instance", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "old": oldValues, "new": newValues})
+
+	ctx := map[string]any{
+		"old_config": oldValues,
+		"config":     newValues,
+	}
+
+	for k, v := range usage {
+		ctx[k] = v
+	}
+
+	s.Events.SendLifecycle(inst.Project(), lifecycle.InstanceAutoscaled.Event(inst, ctx))
+
+	return nil
+}
+
+// autoscaleConfigChanged returns whether the limits or the autoscaling policy differ between two configs.
+func autoscaleConfigChanged(oldConfig map[string]string, newConfig map[string]string) bool {
+	isAutoscaleKey := func(key string) bool {
+		return key == "limits.cpu" || key == "limits.memory" || strings.HasPrefix(key, "limits.autoscale.")
+	}
+
+	for k, v := range oldConfig {
+		if isAutoscaleKey(k) && newConfig[k] != v {
+			return true
+		}
+	}
+
+	for k, v := range newConfig {
+		if isAutoscaleKey(k) && oldConfig[k] != v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// autoscaleMemoryString returns the limits.memory value for a size in bytes, in MiB when possible.
+func autoscaleMemoryString(size int64) string {
+	if size%(1024*1024) == 0 {
+		return fmt.Sprintf("%dMiB", size/1024/1024)
+	}
+
+	return strconv.FormatInt(size, 10)
+}
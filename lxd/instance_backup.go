@@ -310,6 +310,24 @@ func instanceBackupsPost(d *Daemon, r *http.Request) response.Response {
 	fullName := name + shared.SnapshotDelimiter + req.Name
 	instanceOnly := req.InstanceOnly || req.ContainerOnly
 
+	// Validate the parent backup for incremental backups.
+	parentName := ""
+	if req.Parent != "" {
+		if strings.Contains(req.Parent, "/") {
+			return response.BadRequest(fmt.Errorf("Parent backup names may not contain slashes"))
+		}
+
+		if instanceOnly {
+			return response.BadRequest(fmt.Errorf("Incremental backups cannot be instance only"))
+		}
+
+		parentName = name + shared.SnapshotDelimiter + req.Parent
+		_, err = instance.BackupLoadByName(d.State(), projectName, parentName)
+		if err != nil {
+			return response.SmartError(fmt.Errorf("Failed loading parent backup %q: %w", req.Parent, err))
+		}
+	}
+
 	backup := func(op *operations.Operation) error {
 		args := db.InstanceBackup{
 			Name:                 fullName,
@@ -319,6 +337,7 @@ func instanceBackupsPost(d *Daemon, r *http.Request) response.Response {
 			InstanceOnly:         instanceOnly,
 			OptimizedStorage:     req.OptimizedStorage,
 			CompressionAlgorithm: req.CompressionAlgorithm,
+			Parent:               parentName,
 		}
 
 		err := backupCreate(d.State(), args, inst, op)
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket/pcapgo"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	clusterConfig "github.com/lxc/lxd/lxd/cluster/config"
+	"github.com/lxc/lxd/lxd/db/operationtype"
+	"github.com/lxc/lxd/lxd/device/nictype"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// nicCaptureSource describes where the traffic of an instance NIC is captured from.
+type nicCaptureSource struct {
+	// Host interface the traffic is captured on.
+	hostName string
+
+	// Filter restricting the captured traffic to the NIC (when capturing on a shared interface).
+	filter string
+
+	// OVS bridge the NIC's port is mirrored from (empty when capturing on the NIC's host interface).
+	ovsBridge string
+	ovsPort   string
+}
+
+type nicCaptureWs struct {
+	// instance and NIC currently worked on
+	instance instance.Instance
+	nicName  string
+	source   nicCaptureSource
+
+	// capture request
+	filter   string
+	duration time.Duration
+
+	// websocket connection the capture is streamed to
+	conn     *websocket.Conn
+	connLock sync.Mutex
+
+	// websocket secret
+	secret string
+
+	// channel to wait until the websocket is connected
+	connected chan struct{}
+
+	// cancels the capture
+	cancel     context.CancelFunc
+	cancelOnce sync.Once
+	ctx        context.Context
+}
+
+func (s *nicCaptureWs) Metadata() any {
+	return shared.Jmap{"fds": shared.Jmap{"0": s.secret}}
+}
+
+func (s *nicCaptureWs) Connect(op *operations.Operation, r *http.Request, w http.ResponseWriter) error {
+	secret := r.FormValue("secret")
+	if secret == "" {
+		return fmt.Errorf("missing secret")
+	}
+
+	// If we didn't find the right secret, the user provided a bad one,
+	// which 403, not 404, since this operation actually exists.
+	if secret != s.secret {
+		return os.ErrPermission
+	}
+
+	s.connLock.Lock()
+	defer s.connLock.Unlock()
+
+	if s.conn != nil {
+		return fmt.Errorf("Capture websocket is already connected")
+	}
+
+	conn, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+	close(s.connected)
+
+	return nil
+}
+
+func (s *nicCaptureWs) Cancel(op *operations.Operation) error {
+	s.cancelOnce.Do(s.cancel)
+
+	return nil
+}
+
+func (s *nicCaptureWs) Do(op *operations.Operation) error {
+	defer logger.Debug("NIC capture websocket finished")
+	defer s.cancelOnce.Do(s.cancel)
+
+	select {
+	case <-s.connected:
+	case <-s.ctx.Done():
+		return nil
+	}
+
+	defer func() { _ = s.conn.Close() }()
+
+	// Stop the capture when the client disconnects.
+	go func() {
+		for {
+			_, _, err := s.conn.NextReader()
+			if err != nil {
+				s.cancelOnce.Do(s.cancel)
+				return
+			}
+		}
+	}()
+
+	ctx := s.ctx
+	if s.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.duration)
+		defer cancel()
+	}
+
+	// Mirror the traffic of OVS ports to a dedicated interface.
+	captureDev := s.source.hostName
+	if s.source.ovsBridge != "" {
+		captureDev = network.RandomDevName("lxdcap")
+
+		ovs := openvswitch.NewOVS()
+		err := ovs.BridgePortMirrorAdd(s.source.ovsBridge, s.source.ovsPort, captureDev, captureDev)
+		if err != nil {
+			return fmt.Errorf("Failed mirroring OVS port %q: %w", s.source.ovsPort, err)
+		}
+
+		defer func() {
+			err := ovs.BridgePortMirrorDelete(s.source.ovsBridge, captureDev, captureDev)
+			if err != nil {
+				logger.Warn("Failed removing OVS port mirror", logger.Ctx{"mirror": captureDev, "err": err})
+			}
+		}()
+
+		_, err = shared.RunCommand("ip", "link", "set", "dev", captureDev, "up")
+		if err != nil {
+			return fmt.Errorf("Failed bringing up mirror interface %q: %w", captureDev, err)
+		}
+	}
+
+	filter := nicCaptureFilter(s.source.filter, s.filter)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "tcpdump", nicCaptureArgs(captureDev, filter)...)
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("Failed starting tcpdump: %w", err)
+	}
+
+	// Convert the pcap stream to pcapng and send it over the websocket.
+	pr, pw := io.Pipe()
+	sendDone := shared.WebsocketSendStream(s.conn, pr, -1)
+
+	convertErr := nicCaptureConvert(stdout, pw, captureDev, filter)
+	_ = pw.Close()
+	<-sendDone
+
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		// The capture was stopped by the client or reached its duration.
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed capturing traffic: %w (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return convertErr
+}
+
+// nicCaptureArgs returns the tcpdump arguments writing the traffic of the interface to stdout in pcap format.
+// The filter is placed after "--" so that it can never be parsed as tcpdump options.
+func nicCaptureArgs(dev string, filter string) []string {
+	args := []string{"-i", dev, "-n", "-U", "-s", "0", "-w", "-"}
+	if filter != "" {
+		args = append(args, "--", filter)
+	}
+
+	return args
+}
+
+// nicCaptureFilter combines the filter restricting the traffic to the NIC with the user provided one.
+func nicCaptureFilter(sourceFilter string, userFilter string) string {
+	if sourceFilter == "" {
+		return userFilter
+	}
+
+	if userFilter == "" {
+		return sourceFilter
+	}
+
+	return fmt.Sprintf("(%s) and (%s)", sourceFilter, userFilter)
+}
+
+// nicCaptureConvert reads a pcap stream and writes it to w in pcapng format, flushing every packet.
+func nicCaptureConvert(r io.Reader, w io.Writer, dev string, filter string) error {
+	reader, err := pcapgo.NewReader(r)
+	if err != nil {
+		// The capture stopped before writing anything.
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+
+		return err
+	}
+
+	writer, err := pcapgo.NewNgWriterInterface(w, pcapgo.NgInterface{
+		Name:                dev,
+		Filter:              filter,
+		OS:                  runtime.GOOS,
+		LinkType:            reader.LinkType(),
+		SnapLength:          reader.Snaplen(),
+		TimestampResolution: 9,
+	}, pcapgo.NgWriterOptions{
+		SectionInfo: pcapgo.NgSectionInfo{
+			Hardware:    runtime.GOARCH,
+			OS:          runtime.GOOS,
+			Application: fmt.Sprintf("LXD %s", version.Version),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	for {
+		data, ci, err := reader.ReadPacketData()
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		err = writer.WritePacket(ci, data)
+		if err != nil {
+			return err
+		}
+
+		err = writer.Flush()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// nicCaptureSourceGet returns where the traffic of the running instance NIC can be captured from.
+func nicCaptureSourceGet(s *state.State, inst instance.Instance, nicName string) (*nicCaptureSource, error) {
+	nicConfig, ok := inst.ExpandedDevices()[nicName]
+	if !ok || nicConfig["type"] != "nic" {
+		return nil, api.StatusErrorf(http.StatusNotFound, "NIC %q not found", nicName)
+	}
+
+	nicType, err := nictype.NICType(s, inst.Project(), nicConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	volatile := inst.LocalConfig()
+	hostName := volatile[fmt.Sprintf("volatile.%s.host_name", nicName)]
+	hwaddr := nicConfig["hwaddr"]
+	if hwaddr == "" {
+		hwaddr = volatile[fmt.Sprintf("volatile.%s.hwaddr", nicName)]
+	}
+
+	source := &nicCaptureSource{hostName: hostName}
+
+	switch nicType {
+	case "bridged", "routed", "p2p":
+		// The traffic is captured on the host side of the NIC.
+	case "ovn":
+		integrationBridge, err := clusterConfig.GetString(s.DB.Cluster, "network.ovn.integration_bridge")
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get OVN integration bridge name: %w", err)
+		}
+
+		source.ovsBridge = integrationBridge
+		source.ovsPort = hostName
+	case "macvlan":
+		// Virtual machines use a macvtap interface on the host, containers get the macvlan interface moved
+		// into them so the traffic is captured on the parent interface instead.
+		if inst.Type() == instancetype.VM {
+			break
+		}
+
+		parent := nicConfig["parent"]
+		vlan := nicConfig["vlan"]
+		if nicConfig["network"] != "" {
+			networkProjectName, _, err := project.NetworkProject(s.DB.Cluster, inst.Project())
+			if err != nil {
+				return nil, err
+			}
+
+			n, err := network.LoadByName(s, networkProjectName, nicConfig["network"])
+			if err != nil {
+				return nil, fmt.Errorf("Failed loading network %q: %w", nicConfig["network"], err)
+			}
+
+			parent = n.Config()["parent"]
+			vlan = n.Config()["vlan"]
+		}
+
+		if hwaddr == "" {
+			return nil, fmt.Errorf("Couldn't find the MAC address of NIC %q", nicName)
+		}
+
+		source.hostName = network.GetHostDevice(parent, vlan)
+		source.filter = fmt.Sprintf("ether host %s", hwaddr)
+	default:
+		return nil, api.StatusErrorf(http.StatusBadRequest, "Traffic capture isn't supported for %q NICs", nicType)
+	}
+
+	if source.hostName == "" || !network.InterfaceExists(source.hostName) {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "NIC %q isn't running", nicName)
+	}
+
+	return source, nil
+}
+
+// swagger:operation POST /1.0/instances/{name}/nics/{nic}/capture instances instance_nic_capture_post
+//
+// Capture NIC traffic
+//
+// Captures the traffic of an instance NIC.
+//
+// The returned operation metadata will contain a websocket the traffic is streamed to in pcapng format.
+// The capture stops once its duration is reached or the websocket is closed.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: capture
+//     description: Capture request
+//     schema:
+//       $ref: "#/definitions/InstanceNICCapturePost"
+// responses:
+//   "202":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceNICCapturePost(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	nicName, err := url.PathUnescape(mux.Vars(r)["nic"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if shared.IsSnapshot(name) {
+		return response.BadRequest(fmt.Errorf("Invalid instance name"))
+	}
+
+	post := api.InstanceNICCapturePost{}
+	err = json.NewDecoder(r.Body).Decode(&post)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if post.Duration < 0 {
+		return response.BadRequest(fmt.Errorf("Invalid capture duration %d", post.Duration))
+	}
+
+	// Forward the request if the instance is remote.
+	client, err := cluster.ConnectIfInstanceIsRemote(d.db.Cluster, projectName, name, d.endpoints.NetworkCert(), d.serverCert(), r, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if client != nil {
+		url := api.NewURL().Path(version.APIVersion, "instances", name, "nics", nicName, "capture").Project(projectName)
+		resp, _, err := client.RawQuery("POST", url.String(), post, "")
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		opAPI, err := resp.MetadataAsOperation()
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return operations.ForwardedOperationResponse(projectName, opAPI)
+	}
+
+	_, err = exec.LookPath("tcpdump")
+	if err != nil {
+		return response.InternalError(fmt.Errorf("Traffic capture requires tcpdump to be installed"))
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !inst.IsRunning() {
+		return response.BadRequest(fmt.Errorf("Instance is not running"))
+	}
+
+	source, err := nicCaptureSourceGet(d.State(), inst, nicName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Check the filter compiles before starting the capture.
+	if post.Filter != "" {
+		if strings.HasPrefix(strings.TrimSpace(post.Filter), "-") {
+			return response.BadRequest(fmt.Errorf("Invalid capture filter %q: Filters cannot start with \"-\"", post.Filter))
+		}
+
+		_, err = shared.RunCommand("tcpdump", "-d", "-i", source.hostName, "--", post.Filter)
+		if err != nil {
+			return response.BadRequest(fmt.Errorf("Invalid capture filter %q: %w", post.Filter, err))
+		}
+	}
+
+	ws := &nicCaptureWs{}
+	ws.secret, err = shared.RandomCryptoString()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	ws.instance = inst
+	ws.nicName = nicName
+	ws.source = *source
+	ws.filter = post.Filter
+	ws.duration = time.Duration(post.Duration) * time.Second
+	ws.connected = make(chan struct{})
+	ws.ctx, ws.cancel = context.WithCancel(context.Background())
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{inst.Name()}
+
+	if inst.Type() == instancetype.Container {
+		resources["containers"] = resources["instances"]
+	}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassWebsocket, operationtype.InstanceNICCapture, resources, ws.Metadata(), ws.Do, ws.Cancel, ws.Connect, r)
+	if err != nil {
+		ws.cancel()
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
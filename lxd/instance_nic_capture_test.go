@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNICCaptureArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		dev    string
+		filter string
+		args   []string
+	}{
+		{
+			name: "no filter",
+			dev:  "veth1234",
+			args: []string{"-i", "veth1234", "-n", "-U", "-s", "0", "-w", "-"},
+		},
+		{
+			name:   "filter",
+			dev:    "tap1234",
+			filter: "tcp port 22",
+			args:   []string{"-i", "tap1234", "-n", "-U", "-s", "0", "-w", "-", "--", "tcp port 22"},
+		},
+		{
+			name:   "filter looking like an option",
+			dev:    "veth1234",
+			filter: "-r /etc/shadow",
+			args:   []string{"-i", "veth1234", "-n", "-U", "-s", "0", "-w", "-", "--", "-r /etc/shadow"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.args, nicCaptureArgs(test.dev, test.filter))
+		})
+	}
+}
+
+func TestNICCaptureFilter(t *testing.T) {
+	tests := []struct {
+		name         string
+		sourceFilter string
+		userFilter   string
+		filter       string
+	}{
+		{
+			name: "no filters",
+		},
+		{
+			name:       "user filter only",
+			userFilter: "icmp",
+			filter:     "icmp",
+		},
+		{
+			name:         "source filter only",
+			sourceFilter: "ether host 00:16:3e:00:00:01",
+			filter:       "ether host 00:16:3e:00:00:01",
+		},
+		{
+			name:         "both filters",
+			sourceFilter: "ether host 00:16:3e:00:00:01",
+			userFilter:   "icmp or arp",
+			filter:       "(ether host 00:16:3e:00:00:01) and (icmp or arp)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.filter, nicCaptureFilter(test.sourceFilter, test.userFilter))
+		})
+	}
+}
+
+func TestNICCaptureConvert(t *testing.T) {
+	// writePcap returns a pcap stream holding the given packets.
+	writePcap := func(packets [][]byte, timestamps []time.Time) []byte {
+		buf := &bytes.Buffer{}
+		writer := pcapgo.NewWriter(buf)
+		err := writer.WriteFileHeader(65535, layers.LinkTypeEthernet)
+		require.NoError(t, err)
+
+		for i, packet := range packets {
+			err = writer.WritePacket(gopacket.CaptureInfo{Timestamp: timestamps[i], CaptureLength: len(packet), Length: len(packet)}, packet)
+			require.NoError(t, err)
+		}
+
+		return buf.Bytes()
+	}
+
+	packets := [][]byte{
+		bytes.Repeat([]byte{0x01}, 60),
+		bytes.Repeat([]byte{0x02}, 98),
+	}
+
+	timestamps := []time.Time{
+		time.Date(2022, time.March, 1, 10, 0, 0, 123456000, time.UTC),
+		time.Date(2022, time.March, 1, 10, 0, 1, 654321000, time.UTC),
+	}
+
+	pcap := writePcap(packets, timestamps)
+
+	tests := []struct {
+		name    string
+		input   []byte
+		packets int
+		empty   bool
+		err     bool
+	}{
+		{
+			name:    "packets",
+			input:   pcap,
+			packets: 2,
+		},
+		{
+			name:  "no packets",
+			input: writePcap(nil, nil),
+		},
+		{
+			name:  "empty stream",
+			input: []byte{},
+			empty: true,
+		},
+		{
+			name:  "truncated header",
+			input: pcap[:10],
+			empty: true,
+		},
+		{
+			name:    "truncated packet",
+			input:   pcap[:len(pcap)-10],
+			packets: 1,
+		},
+		{
+			name:  "invalid stream",
+			input: bytes.Repeat([]byte{0xff}, 64),
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := nicCaptureConvert(bytes.NewReader(test.input), out, "veth1234", "icmp")
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			if test.empty {
+				assert.Zero(t, out.Len())
+				return
+			}
+
+			reader, err := pcapgo.NewNgReader(out, pcapgo.DefaultNgReaderOptions)
+			require.NoError(t, err)
+
+			assert.Equal(t, layers.LinkTypeEthernet, reader.LinkType())
+
+			for i := 0; i < test.packets; i++ {
+				data, ci, err := reader.ReadPacketData()
+				require.NoError(t, err)
+
+				assert.Equal(t, packets[i], data)
+				assert.True(t, timestamps[i].Equal(ci.Timestamp), "Expected timestamp %v, got %v", timestamps[i], ci.Timestamp)
+
+				intf, err := reader.Interface(ci.InterfaceIndex)
+				require.NoError(t, err)
+
+				assert.Equal(t, "veth1234", intf.Name)
+				assert.Equal(t, "icmp", intf.Filter)
+				assert.Equal(t, uint32(65535), intf.SnapLength)
+			}
+
+			_, _, err = reader.ReadPacketData()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
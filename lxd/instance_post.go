@@ -3,19 +3,25 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 
 	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
 	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
 	"github.com/lxc/lxd/lxd/db/operationtype"
+	"github.com/lxc/lxd/lxd/device/nictype"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/migration"
@@ -23,11 +29,20 @@ import (
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/rbac"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
 )
 
+var internalClusterInstanceMoveCmd = APIEndpoint{
+	Path: "cluster/instance-move/{name}",
+
+	Post: APIEndpointAction{Handler: internalClusterInstanceMovePost},
+}
+
 var internalClusterInstanceMovedCmd = APIEndpoint{
 	Path: "cluster/instance-moved/{name}",
 
@@ -376,6 +391,46 @@ func instancePostPoolMigration(d *Daemon, inst instance.Instance, newName string
 		return fmt.Errorf("Instance snapshots cannot be moved between pools")
 	}
 
+	// Running VMs without snapshots can be moved between local pools without downtime by mirroring their root disk.
+	if stateful && inst.Type() == instancetype.VM && inst.IsRunning() {
+		snapshots, err := inst.Snapshots()
+		if err != nil {
+			return err
+		}
+
+		srcPool, err := storagePools.LoadByInstance(d.State(), inst)
+		if err != nil {
+			return err
+		}
+
+		pool, err := storagePools.LoadByName(d.State(), newPool)
+		if err != nil {
+			return err
+		}
+
+		reason := ""
+		if newName != inst.Name() {
+			reason = "it is being renamed"
+		} else if len(snapshots) > 0 {
+			reason = "it has snapshots"
+		} else if srcPool.Driver().Info().Remote {
+			reason = fmt.Sprintf("source pool %q is remote", srcPool.Name())
+		} else if pool.Driver().Info().Remote {
+			reason = fmt.Sprintf("target pool %q is remote", pool.Name())
+		}
+
+		if reason == "" {
+			return instancePostPoolMigrationLive(d, inst, srcPool, pool, op)
+		}
+
+		// Without live migration support the instance can't be stopped statefully either, so fail early.
+		if shared.IsFalseOrEmpty(inst.ExpandedConfig()["migration.stateful"]) {
+			return api.StatusErrorf(http.StatusBadRequest, "Running virtual machine can't be moved live between pools because %s", reason)
+		}
+
+		logger.Warn("Running virtual machine can't be moved live between pools, stopping it statefully instead", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "pool": newPool, "reason": reason})
+	}
+
 	statefulStart := false
 	if inst.IsRunning() {
 		if stateful {
@@ -465,6 +520,33 @@ func instancePostPoolMigration(d *Daemon, inst instance.Instance, newName string
 	return nil
 }
 
+// Move a running VM to another pool by mirroring its root disk.
+func instancePostPoolMigrationLive(d *Daemon, inst instance.Instance, srcPool storagePools.Pool, pool storagePools.Pool, op *operations.Operation) error {
+	// Load source root disk from expanded devices (in case instance doesn't have its own root disk).
+	rootDevKey, rootDev, err := shared.GetRootDiskDevice(inst.ExpandedDevices().CloneNative())
+	if err != nil {
+		return err
+	}
+
+	// Copy device config from instance, and update the root disk device with the new pool name.
+	localDevices := inst.LocalDevices().Clone()
+	rootDev["pool"] = pool.Name()
+	localDevices[rootDevKey] = rootDev
+
+	err = pool.MoveInstanceLive(inst, srcPool, localDevices, op)
+	if err != nil {
+		return err
+	}
+
+	// Reload the instance so that its backup file reflects the new root disk device.
+	inst, err = instance.LoadByProjectAndName(d.State(), inst.Project(), inst.Name())
+	if err != nil {
+		return err
+	}
+
+	return inst.UpdateBackupFile()
+}
+
 // Move an instance to another project.
 func instancePostProjectMigration(d *Daemon, inst instance.Instance, newName string, newProject string, instanceOnly bool, stateful bool, allowInconsistent bool, op *operations.Operation) error {
 	localConfig := inst.LocalConfig()
@@ -890,6 +972,308 @@ func instancePostClusteringMigrateWithCeph(d *Daemon, r *http.Request, inst inst
 	return run, nil
 }
 
+// instanceClusterMoveLiveCheck returns why the running VM can't be live moved to another cluster member, nil if it
+// can.
+func instanceClusterMoveLiveCheck(s *state.State, inst instance.Instance, pool storagePools.Pool) error {
+	snapshotCount := 0
+	if !pool.Driver().Info().Remote {
+		snapshots, err := inst.Snapshots()
+		if err != nil {
+			return err
+		}
+
+		snapshotCount = len(snapshots)
+	}
+
+	nicTypes := []string{}
+	for _, dev := range inst.ExpandedDevices() {
+		if dev["type"] != "nic" {
+			continue
+		}
+
+		nicType, err := nictype.NICType(s, inst.Project(), dev)
+		if err != nil {
+			return err
+		}
+
+		nicTypes = append(nicTypes, nicType)
+	}
+
+	return clusterMoveLiveCheck(inst.Type(), inst.ExpandedConfig(), pool.Driver().Info().Remote, snapshotCount, nicTypes)
+}
+
+// clusterMoveLiveCheck returns why an instance of the given type, config, storage and NIC types can't be live moved
+// to another cluster member, nil if it can.
+func clusterMoveLiveCheck(instType instancetype.Type, config map[string]string, remotePool bool, snapshotCount int, nicTypes []string) error {
+	if instType != instancetype.VM {
+		return fmt.Errorf("Only virtual machines can be live moved between cluster members")
+	}
+
+	if shared.IsFalseOrEmpty(config["migration.stateful"]) {
+		return fmt.Errorf("Live migration requires migration.stateful to be set to true")
+	}
+
+	// The snapshots are only transferred along with the volumes when stopped.
+	if !remotePool && snapshotCount > 0 {
+		return fmt.Errorf("Instances with snapshots can only be live moved when using a remote storage pool")
+	}
+
+	// The OVN logical switch ports are shared between the members, so can't be set up on the target member
+	// while still in use on the source.
+	if shared.StringInSlice("ovn", nicTypes) {
+		return fmt.Errorf("Instances with OVN NICs can't be live moved between cluster members")
+	}
+
+	return nil
+}
+
+// instancePostClusteringMigrateLive live moves a running VM to another cluster member.
+// The target member receives the VM into the existing instance record, which is only relinked to it once the VM
+// runs there. The local volumes are then removed, unless the storage pool is shared between the members.
+func instancePostClusteringMigrateLive(d *Daemon, r *http.Request, inst instance.Instance, pool storagePools.Pool, newNode string, allowInconsistent bool, op *operations.Operation) error {
+	s := d.State()
+
+	var sourceAddress string
+	var targetAddress string
+
+	err := d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+
+		sourceAddress, err = tx.GetLocalNodeAddress()
+		if err != nil {
+			return fmt.Errorf("Failed to get local member address: %w", err)
+		}
+
+		node, err := tx.GetNodeByName(newNode)
+		if err != nil {
+			return fmt.Errorf("Failed to get new member address: %w", err)
+		}
+
+		targetAddress = node.Address
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The snapshots are either shared or don't exist, so only the instance volume is sent.
+	ws, err := newMigrationSource(inst, true, true, allowInconsistent)
+	if err != nil {
+		return err
+	}
+
+	ws.clusterMove = true
+
+	// The target member updates the volatile config of the VM when starting it, keep the original one to restore
+	// it on failure.
+	origVolatile := map[string]string{}
+	for k, v := range inst.LocalConfig() {
+		if strings.HasPrefix(k, shared.ConfigVolatilePrefix) {
+			origVolatile[k] = v
+		}
+	}
+
+	sourceDone := make(chan error, 1)
+	run := func(op *operations.Operation) error {
+		err := ws.Do(s, op)
+		sourceDone <- err
+		return err
+	}
+
+	cancel := func(op *operations.Operation) error {
+		ws.disconnect()
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{inst.Name()}
+
+	sourceOp, err := operations.OperationCreate(s, inst.Project(), operations.OperationClassWebsocket, operationtype.InstanceMigrate, resources, ws.Metadata(), run, cancel, ws.Connect, r)
+	if err != nil {
+		return err
+	}
+
+	err = sourceOp.Start()
+	if err != nil {
+		return err
+	}
+
+	// Ask the target member to receive the VM.
+	target, err := cluster.Connect(targetAddress, d.endpoints.NetworkCert(), d.serverCert(), r, true)
+	if err == nil {
+		target = target.UseProject(inst.Project())
+
+		req := api.InstancePostTarget{
+			Certificate: string(d.endpoints.NetworkPublicKey()),
+			Operation:   fmt.Sprintf("https://%s/1.0/operations/%s", sourceAddress, url.PathEscape(sourceOp.ID())),
+			Websockets: map[string]string{
+				"control": ws.controlSecret,
+				"criu":    ws.criuSecret,
+				"fs":      ws.fsSecret,
+			},
+		}
+
+		var targetOp lxd.Operation
+		u := api.NewURL().Project(inst.Project()).Path("internal", "cluster", "instance-move", inst.Name())
+		targetOp, _, err = target.RawOperation("POST", u.String(), req, "")
+		if err == nil {
+			_, _ = targetOp.AddHandler(func(newOp api.Operation) {
+				_ = op.UpdateMetadata(newOp.Metadata)
+			})
+
+			err = targetOp.Wait()
+		}
+	}
+
+	if err != nil {
+		_, _ = sourceOp.Cancel()
+	}
+
+	sourceErr := <-sourceDone
+	if err == nil {
+		err = sourceErr
+	}
+
+	if err != nil {
+		// Restore the volatile config the target member may have changed.
+		restore := map[string]string{}
+		current, loadErr := instance.LoadByProjectAndName(s, inst.Project(), inst.Name())
+		if loadErr == nil {
+			for k, v := range current.LocalConfig() {
+				if strings.HasPrefix(k, shared.ConfigVolatilePrefix) && origVolatile[k] != v {
+					restore[k] = origVolatile[k]
+				}
+			}
+
+			for k, v := range origVolatile {
+				if current.LocalConfig()[k] != v {
+					restore[k] = v
+				}
+			}
+		}
+
+		if len(restore) > 0 {
+			restoreErr := inst.VolatileSet(restore)
+			if restoreErr != nil {
+				logger.Warn("Failed restoring instance volatile config", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": restoreErr})
+			}
+		}
+
+		return fmt.Errorf("Failed live migrating instance %q: %w", inst.Name(), err)
+	}
+
+	// Re-link the database entries against the new member now the VM runs there.
+	err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return tx.UpdateInstanceNodeID(ctx, inst.Project(), inst.Name(), newNode)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed updating cluster member to %q for instance %q: %w", newNode, inst.Name(), err)
+	}
+
+	// Remove the local volume, now replaced by the one of the target member.
+	if !pool.Driver().Info().Remote {
+		err = pool.DeleteInstance(inst, op)
+		if err != nil {
+			return fmt.Errorf("Failed deleting instance %q volume on source member: %w", inst.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// Receive an instance live moved from another cluster member.
+//
+// The instance record is kept as is, the target member only creating the instance volume when not shared and
+// starting the VM from the migration stream.
+func internalClusterInstanceMovePost(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+	instanceName, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.InstancePostTarget{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, instanceName)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading instance on target node: %w", err))
+	}
+
+	if inst.Type() != instancetype.VM {
+		return response.BadRequest(fmt.Errorf("Only virtual machines can be live moved between cluster members"))
+	}
+
+	instOp, err := inst.LockExclusive()
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed getting exclusive access to instance: %w", err))
+	}
+
+	defer instOp.Done(err)
+
+	var cert *x509.Certificate
+	if req.Certificate != "" {
+		certBlock, _ := pem.Decode([]byte(req.Certificate))
+		if certBlock == nil {
+			return response.InternalError(fmt.Errorf("Invalid certificate"))
+		}
+
+		cert, err = x509.ParseCertificate(certBlock.Bytes)
+		if err != nil {
+			return response.InternalError(err)
+		}
+	}
+
+	config, err := shared.GetTLSConfig("", "", "", cert)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	sink, err := newMigrationSink(&MigrationSinkArgs{
+		URL: req.Operation,
+		Dialer: websocket.Dialer{
+			TLSClientConfig:  config,
+			NetDial:          shared.RFC3493Dialer,
+			HandshakeTimeout: time.Second * 5,
+		},
+		Instance:     inst,
+		Secrets:      req.Websockets,
+		Live:         true,
+		InstanceOnly: true,
+		ClusterMove:  true,
+	})
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	run := func(op *operations.Operation) error {
+		revert := revert.New()
+		defer revert.Fail()
+
+		err := sink.Do(d.State(), revert, op)
+		if err != nil {
+			return fmt.Errorf("Error transferring instance data: %w", err)
+		}
+
+		revert.Success()
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{instanceName}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, operationtype.InstanceMigrate, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
 // Notification that an instance was moved.
 //
 // At the moment it's used for ceph-based instances, where the target node needs
@@ -940,6 +1324,21 @@ func migrateInstance(d *Daemon, r *http.Request, inst instance.Instance, targetN
 	if err != nil {
 		return fmt.Errorf("Failed loading instance storage pool: %w", err)
 	}
+
+	// Keep running VMs running while moving them, failing rather than stopping them when it isn't possible.
+	if !sourceNodeOffline && req.Live && inst.Type() == instancetype.VM && inst.IsRunning() {
+		if req.Name != "" && req.Name != inst.Name() {
+			return fmt.Errorf("Instance %q can't be renamed while live moved", inst.Name())
+		}
+
+		err = instanceClusterMoveLiveCheck(d.State(), inst, pool)
+		if err != nil {
+			return fmt.Errorf("Instance %q can't be live moved: %w", inst.Name(), err)
+		}
+
+		return instancePostClusteringMigrateLive(d, r, inst, pool, targetNode, req.AllowInconsistent, op)
+	}
+
 	if pool.Driver().Info().Name == "ceph" {
 		f, err := instancePostClusteringMigrateWithCeph(d, r, inst, pool, req.Name, sourceNodeOffline, targetNode, req.Live)
 		if err != nil {
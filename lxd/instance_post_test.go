@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+)
+
+func TestClusterMoveLiveCheck(t *testing.T) {
+	stateful := map[string]string{"migration.stateful": "true"}
+
+	tests := []struct {
+		name          string
+		instType      instancetype.Type
+		config        map[string]string
+		remotePool    bool
+		snapshotCount int
+		nicTypes      []string
+		err           string
+	}{
+		{
+			name:     "local pool",
+			instType: instancetype.VM,
+			config:   stateful,
+			nicTypes: []string{"bridged", "macvlan"},
+		},
+		{
+			name:          "remote pool with snapshots",
+			instType:      instancetype.VM,
+			config:        stateful,
+			remotePool:    true,
+			snapshotCount: 2,
+		},
+		{
+			name:     "container",
+			instType: instancetype.Container,
+			config:   stateful,
+			err:      "Only virtual machines can be live moved between cluster members",
+		},
+		{
+			name:     "not stateful",
+			instType: instancetype.VM,
+			config:   map[string]string{},
+			err:      "Live migration requires migration.stateful to be set to true",
+		},
+		{
+			name:          "local pool with snapshots",
+			instType:      instancetype.VM,
+			config:        stateful,
+			snapshotCount: 1,
+			err:           "Instances with snapshots can only be live moved when using a remote storage pool",
+		},
+		{
+			name:       "OVN NIC",
+			instType:   instancetype.VM,
+			config:     stateful,
+			remotePool: true,
+			nicTypes:   []string{"bridged", "ovn"},
+			err:        "Instances with OVN NICs can't be live moved between cluster members",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := clusterMoveLiveCheck(test.instType, test.config, test.remotePool, test.snapshotCount, test.nicTypes)
+			if test.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/db/operationtype"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/lxd/warnings"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// instanceScheduleActions lists the instance actions that can be scheduled through schedule.* keys, in order of
+// precedence when more than one of them is due at the same time.
+var instanceScheduleActions = []shared.InstanceAction{shared.Stop, shared.Restart, shared.Start}
+
+// instanceScheduleMaxConcurrent is the number of scheduled instance actions run at the same time, so that slow
+// clean shutdowns don't delay the other actions due in the same minute past the next run of the task.
+const instanceScheduleMaxConcurrent = 10
+
+// scheduledInstanceAction identifies an instance action that is due.
+type scheduledInstanceAction struct {
+	id           int
+	project      string
+	name         string
+	node         string // Cluster member hosting the instance.
+	instanceType instancetype.Type
+	action       shared.InstanceAction
+	schedule     string
+	timeout      int // Seconds to wait for a clean shutdown before forcing it.
+}
+
+func instanceSchedulesTask(d *Daemon) (task.Func, task.Schedule) {
+	// End of the window of schedules that were checked by the previous run. Actions scheduled since then are run
+	// on the next run, so that the ones due while a previous run was still going aren't missed.
+	var checkedUntil time.Time
+
+	f := func(ctx context.Context) {
+		// The schedules are evaluated against all the instances of the cluster, so only the leader runs the task
+		// to avoid members running the same action more than once.
+		localAddress, err := node.ClusterAddress(d.db.Node)
+		if err != nil {
+			logger.Error("Failed to get current cluster member address", logger.Ctx{"err": err})
+			return
+		}
+
+		leader, err := d.gateway.LeaderAddress()
+		if err != nil {
+			if !errors.Is(err, cluster.ErrNodeIsNotClustered) {
+				logger.Error("Failed to get leader cluster member address", logger.Ctx{"err": err})
+				return
+			}
+		} else if localAddress != leader {
+			logger.Debug("Skipping scheduled instance actions task since we're not leader")
+			checkedUntil = time.Time{}
+			return
+		}
+
+		// Schedules are matched against the minute following the current one, as for snapshot schedules.
+		to := time.Now().Truncate(time.Minute).Add(time.Minute)
+		from := checkedUntil
+		if from.IsZero() || !from.Before(to) {
+			from = to.Add(-time.Minute)
+		}
+
+		actions, err := scheduledInstanceActions(d.State().DB.Cluster, from, to)
+		if err != nil {
+			logger.Error("Failed to get scheduled instance actions", logger.Ctx{"err": err})
+			return
+		}
+
+		checkedUntil = to
+
+		// Skip if there is nothing to run.
+		if len(actions) == 0 {
+			return
+		}
+
+		opRun := func(op *operations.Operation) error {
+			runScheduledInstanceActions(ctx, d, actions, op)
+			return nil
+		}
+
+		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, operationtype.InstanceScheduledActions, nil, nil, opRun, nil, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start scheduled instance actions operation", logger.Ctx{"err": err})
+			return
+		}
+
+		logger.Info("Running scheduled instance actions")
+		err = op.Start()
+		if err != nil {
+			logger.Error("Failed to run scheduled instance actions", logger.Ctx{"err": err})
+		}
+
+		_, _ = op.Wait(ctx)
+		logger.Info("Done running scheduled instance actions")
+	}
+
+	first := true
+	schedule := func() (time.Duration, error) {
+		interval := time.Minute
+
+		if first {
+			first = false
+			return interval, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
+
+// scheduledInstanceActions returns the instance actions across the cluster that are scheduled in the (from, to]
+// window. At most one action is returned per instance.
+func scheduledInstanceActions(c *db.Cluster, from time.Time, to time.Time) ([]scheduledInstanceAction, error) {
+	var result []scheduledInstanceAction
+
+	err := c.InstanceList(nil, func(inst db.InstanceArgs, p api.Project, profiles []api.Profile) error {
+		config := db.ExpandInstanceConfig(inst.Config, profiles)
+
+		action, schedule := instanceScheduleDueAction(config, int64(inst.ID), from, to)
+		if action == "" {
+			return nil
+		}
+
+		timeout, err := strconv.Atoi(config["boot.host_shutdown_timeout"])
+		if err != nil {
+			timeout = 30
+		}
+
+		result = append(result, scheduledInstanceAction{
+			id:           inst.ID,
+			project:      inst.Project,
+			name:         inst.Name,
+			node:         inst.Node,
+			instanceType: inst.Type,
+			action:       action,
+			schedule:     schedule,
+			timeout:      timeout,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// instanceScheduleDueAction returns the action (and its schedule) to run on the instance for the schedules in its
+// config that are due in the (from, to] window. When more than one action is due, the one scheduled last wins, and
+// actions due at the same time are picked in the order of instanceScheduleActions.
+// Returns an empty action if none is due.
+func instanceScheduleDueAction(config map[string]string, subjectID int64, from time.Time, to time.Time) (shared.InstanceAction, string) {
+	var dueAction shared.InstanceAction
+	var dueSchedule string
+	var dueTime time.Time
+
+	for _, action := range instanceScheduleActions {
+		schedule := config[fmt.Sprintf("schedule.%s", action)]
+		if schedule == "" {
+			continue
+		}
+
+		last := instanceScheduleLastDue(schedule, subjectID, from, to)
+		if !last.IsZero() && last.After(dueTime) {
+			dueAction = action
+			dueSchedule = schedule
+			dueTime = last
+		}
+	}
+
+	return dueAction, dueSchedule
+}
+
+// instanceScheduleLastDue returns the last time in the (from, to] window that the schedule spec is due for the
+// subject, or the zero time if it isn't due in the window.
+func instanceScheduleLastDue(spec string, subjectID int64, from time.Time, to time.Time) time.Time {
+	var last time.Time
+
+	for _, curSpec := range buildCronSpecs(spec, subjectID) {
+		sched, err := cron.ParseStandard(curSpec)
+		if err != nil {
+			continue
+		}
+
+		for next := sched.Next(from); !next.IsZero() && !next.After(to); next = sched.Next(next) {
+			if next.After(last) {
+				last = next
+			}
+		}
+	}
+
+	return last
+}
+
+// runScheduledInstanceActions runs the given actions concurrently (at most instanceScheduleMaxConcurrent at a
+// time), raising a warning for each instance whose action failed and resolving it once an action succeeds again.
+// Failures don't stop the remaining actions.
+func runScheduledInstanceActions(ctx context.Context, d *Daemon, actions []scheduledInstanceAction, op *operations.Operation) {
+	wg := sync.WaitGroup{}
+	sem := make(chan struct{}, instanceScheduleMaxConcurrent)
+
+	for _, a := range actions {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+
+		wg.Add(1)
+		go func(a scheduledInstanceAction) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			runScheduledInstanceActionAndWarn(d, a, op)
+		}(a)
+	}
+
+	wg.Wait()
+}
+
+// runScheduledInstanceActionAndWarn runs a single scheduled action and records its outcome as a warning and
+// lifecycle event.
+func runScheduledInstanceActionAndWarn(d *Daemon, a scheduledInstanceAction, op *operations.Operation) {
+	s := d.State()
+
+	warn := func(err error) {
+		warnErr := s.DB.Cluster.UpsertWarning(a.node, a.project, dbCluster.TypeInstance, a.id, db.WarningScheduledInstanceActionFailure, fmt.Sprintf("Failed to %s instance: %v", a.action, err))
+		if warnErr != nil {
+			logger.Warn("Failed to create scheduled instance action failure warning", logger.Ctx{"err": warnErr, "project": a.project, "instance": a.name})
+		}
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, a.project, a.name)
+	if err != nil {
+		logger.Error("Failed to load instance for scheduled action", logger.Ctx{"err": err, "project": a.project, "instance": a.name})
+		warn(fmt.Errorf("Failed loading instance: %w", err))
+		return
+	}
+
+	run, err := runScheduledInstanceAction(d, inst, a, op)
+	if err != nil {
+		logger.Error("Failed to run scheduled instance action", logger.Ctx{"err": err, "project": a.project, "instance": a.name, "action": a.action})
+		warn(err)
+		return
+	}
+
+	// Resolve any previous warning.
+	warnErr := warnings.ResolveWarningsByNodeAndProjectAndTypeAndEntity(s.DB.Cluster, a.node, a.project, db.WarningScheduledInstanceActionFailure, dbCluster.TypeInstance, a.id)
+	if warnErr != nil {
+		logger.Warn("Failed to resolve scheduled instance action failure warning", logger.Ctx{"err": warnErr, "project": a.project, "instance": a.name})
+	}
+
+	if run {
+		s.Events.SendLifecycle(a.project, lifecycle.InstanceScheduledAction.Event(inst, map[string]any{"action": a.action, "schedule": a.schedule}))
+	}
+}
+
+// runScheduledInstanceAction performs the action on the instance, forwarding it to the cluster member hosting
+// the instance when it isn't the local one. A clean stop or restart that fails is retried forcefully.
+// Returns false if nothing was done because the instance was already in the requested state.
+func runScheduledInstanceAction(d *Daemon, inst instance.Instance, a scheduledInstanceAction, op *operations.Operation) (bool, error) {
+	client, err := cluster.ConnectIfInstanceIsRemote(d.db.Cluster, a.project, a.name, d.endpoints.NetworkCert(), d.serverCert(), nil, a.instanceType)
+	if err != nil {
+		return false, fmt.Errorf("Failed to connect to instance cluster member: %w", err)
+	}
+
+	running := false
+	if client != nil {
+		state, _, err := client.GetInstanceState(a.name)
+		if err != nil {
+			return false, fmt.Errorf("Failed to get instance state: %w", err)
+		}
+
+		running = state.StatusCode == api.Running
+	} else {
+		running = inst.IsRunning()
+	}
+
+	if !instanceScheduleActionNeeded(a.action, running) {
+		return false, nil
+	}
+
+	updateState := func(req api.InstanceStatePut) error {
+		if client != nil {
+			remoteOp, err := client.UpdateInstanceState(a.name, req, "")
+			if err != nil {
+				return err
+			}
+
+			return remoteOp.Wait()
+		}
+
+		inst.SetOperation(op)
+
+		return doInstanceStatePut(inst, req)
+	}
+
+	req := api.InstanceStatePut{Action: string(a.action)}
+	if a.action != shared.Start {
+		req.Timeout = a.timeout
+	}
+
+	err = updateState(req)
+	if err != nil && a.action != shared.Start {
+		logger.Warn("Failed to cleanly run scheduled instance action, forcing it", logger.Ctx{"err": err, "project": a.project, "instance": a.name, "action": a.action})
+
+		req.Force = true
+		err = updateState(req)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// instanceScheduleActionNeeded returns whether a scheduled action changes the state of an instance, as only
+// stopped instances are started and only running ones are stopped or restarted.
+func instanceScheduleActionNeeded(action shared.InstanceAction, running bool) bool {
+	if action == shared.Start {
+		return !running
+	}
+
+	return running
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/lxd/shared"
+)
+
+func TestInstanceScheduleDueAction(t *testing.T) {
+	const subjectID = 42
+
+	minute, _ := getObfuscatedTimeValuesForSubject(subjectID)
+
+	at := func(hour int, minute int) time.Time {
+		return time.Date(2022, time.March, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name     string
+		config   map[string]string
+		from     time.Time
+		to       time.Time
+		action   shared.InstanceAction
+		schedule string
+	}{
+		{
+			name:   "nothing scheduled",
+			config: map[string]string{},
+			from:   at(7, 59),
+			to:     at(8, 0),
+		},
+		{
+			name:     "due at the end of the window",
+			config:   map[string]string{"schedule.start": "0 8 * * *"},
+			from:     at(7, 59),
+			to:       at(8, 0),
+			action:   shared.Start,
+			schedule: "0 8 * * *",
+		},
+		{
+			name:   "due at the start of the window",
+			config: map[string]string{"schedule.start": "0 8 * * *"},
+			from:   at(8, 0),
+			to:     at(8, 1),
+		},
+		{
+			name:   "not due yet",
+			config: map[string]string{"schedule.start": "0 8 * * *"},
+			from:   at(7, 58),
+			to:     at(7, 59),
+		},
+		{
+			name:     "missed during a previous run",
+			config:   map[string]string{"schedule.stop": "0 8 * * *"},
+			from:     at(7, 59),
+			to:       at(8, 5),
+			action:   shared.Stop,
+			schedule: "0 8 * * *",
+		},
+		{
+			name:     "one of several schedules due",
+			config:   map[string]string{"schedule.restart": "0 6 * * *, 0 8 * * *"},
+			from:     at(7, 59),
+			to:       at(8, 0),
+			action:   shared.Restart,
+			schedule: "0 6 * * *, 0 8 * * *",
+		},
+		{
+			name:     "stop has precedence over restart and start",
+			config:   map[string]string{"schedule.start": "0 8 * * *", "schedule.stop": "0 8 * * *", "schedule.restart": "0 8 * * *"},
+			from:     at(7, 59),
+			to:       at(8, 0),
+			action:   shared.Stop,
+			schedule: "0 8 * * *",
+		},
+		{
+			name:     "restart has precedence over start",
+			config:   map[string]string{"schedule.start": "0 8 * * *", "schedule.restart": "0 8 * * *"},
+			from:     at(7, 59),
+			to:       at(8, 0),
+			action:   shared.Restart,
+			schedule: "0 8 * * *",
+		},
+		{
+			name:     "last scheduled action wins",
+			config:   map[string]string{"schedule.stop": "0 8 * * *", "schedule.start": "3 8 * * *"},
+			from:     at(7, 59),
+			to:       at(8, 5),
+			action:   shared.Start,
+			schedule: "3 8 * * *",
+		},
+		{
+			name:     "alias",
+			config:   map[string]string{"schedule.restart": "@hourly"},
+			from:     at(8, 0),
+			to:       at(9, 0),
+			action:   shared.Restart,
+			schedule: "@hourly",
+		},
+		{
+			name:   "never alias",
+			config: map[string]string{"schedule.restart": "@never"},
+			from:   at(8, 0),
+			to:     at(9, 0),
+		},
+		{
+			name:   "invalid schedule",
+			config: map[string]string{"schedule.start": "not a schedule"},
+			from:   at(8, 0),
+			to:     at(9, 0),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			action, schedule := instanceScheduleDueAction(test.config, subjectID, test.from, test.to)
+			assert.Equal(t, test.action, action)
+			assert.Equal(t, test.schedule, schedule)
+		})
+	}
+
+	// The alias is due at the obfuscated minute of the subject only.
+	minutes, err := strconv.Atoi(minute)
+	assert.NoError(t, err)
+
+	due := at(8, 0).Add(time.Duration(minutes) * time.Minute)
+	action, _ := instanceScheduleDueAction(map[string]string{"schedule.start": "@hourly"}, subjectID, due.Add(-time.Minute), due)
+	assert.Equal(t, shared.Start, action, fmt.Sprintf("@hourly should be due at minute %s", minute))
+
+	action, _ = instanceScheduleDueAction(map[string]string{"schedule.start": "@hourly"}, subjectID, due, due.Add(time.Minute))
+	assert.Equal(t, shared.InstanceAction(""), action)
+}
+
+func TestInstanceScheduleActionNeeded(t *testing.T) {
+	tests := []struct {
+		action  shared.InstanceAction
+		running bool
+		needed  bool
+	}{
+		{action: shared.Start, running: false, needed: true},
+		{action: shared.Start, running: true, needed: false},
+		{action: shared.Stop, running: true, needed: true},
+		{action: shared.Stop, running: false, needed: false},
+		{action: shared.Restart, running: true, needed: true},
+		{action: shared.Restart, running: false, needed: false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s (running=%v)", test.action, test.running), func(t *testing.T) {
+			assert.Equal(t, test.needed, instanceScheduleActionNeeded(test.action, test.running))
+		})
+	}
+}
@@ -139,6 +139,17 @@ var instanceExecCmd = APIEndpoint{
 	Post: APIEndpointAction{Handler: instanceExecPost, AccessHandler: allowProjectPermission("containers", "operate-containers")},
 }
 
+var instanceNICCaptureCmd = APIEndpoint{
+	Name: "instanceNICCapture",
+	Path: "instances/{name}/nics/{nic}/capture",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerNICCapture", Path: "containers/{name}/nics/{nic}/capture"},
+		{Name: "vmNICCapture", Path: "virtual-machines/{name}/nics/{nic}/capture"},
+	},
+
+	Post: APIEndpointAction{Handler: instanceNICCapturePost, AccessHandler: allowProjectPermission("containers", "operate-containers")},
+}
+
 var instanceMetadataCmd = APIEndpoint{
 	Name: "instanceMetadata",
 	Path: "instances/{name}/metadata",
@@ -1,6 +1,8 @@
 package ip
 
 import (
+	"encoding/json"
+
 	"github.com/lxc/lxd/shared"
 )
 
@@ -15,6 +17,7 @@ type Class struct {
 type ClassHTB struct {
 	Class
 	Rate string
+	Ceil string
 }
 
 // Add adds class to a node
@@ -29,9 +32,46 @@ func (class *ClassHTB) Add() error {
 		cmd = append(cmd, "rate", class.Rate)
 	}
 
+	if class.Ceil != "" {
+		cmd = append(cmd, "ceil", class.Ceil)
+	}
+
 	_, err := shared.RunCommand("tc", cmd...)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// ClassStatistics represents the counters of a qdisc class.
+type ClassStatistics struct {
+	Bytes      int64 `json:"bytes"`
+	Packets    int64 `json:"packets"`
+	Drops      int64 `json:"drops"`
+	Overlimits int64 `json:"overlimits"`
+}
+
+// GetClassStatistics returns the counters of the classes of a device keyed by class ID.
+func GetClassStatistics(dev string) (map[string]ClassStatistics, error) {
+	out, err := shared.RunCommand("tc", "-s", "-j", "class", "show", "dev", dev)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := []struct {
+		ClassStatistics
+		Handle string `json:"handle"`
+	}{}
+
+	err = json.Unmarshal([]byte(out), &classes)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]ClassStatistics, len(classes))
+	for _, class := range classes {
+		stats[class.Handle] = class.ClassStatistics
+	}
+
+	return stats, nil
+}
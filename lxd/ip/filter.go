@@ -38,6 +38,18 @@ func (a *ActionPolice) AddAction() []string {
 	return result
 }
 
+// ActionMirred represents an action of 'mirred' type
+type ActionMirred struct {
+	Direction string
+	Action    string
+	Dev       string
+}
+
+// AddAction generates a part of command specific for 'mirred' action
+func (a *ActionMirred) AddAction() []string {
+	return []string{"action", "mirred", a.Direction, a.Action, "dev", a.Dev}
+}
+
 // Filter represents filter object
 type Filter struct {
 	Dev      string
@@ -79,3 +91,69 @@ func (u32 *U32Filter) Add() error {
 	}
 	return nil
 }
+
+// FlowerFilter represents a flow based traffic control filter
+type FlowerFilter struct {
+	Filter
+	Pref   string
+	Handle string
+	SrcMAC string
+	DstMAC string
+}
+
+// Replace adds or replaces the flow based traffic control filter of a node
+func (flower *FlowerFilter) Replace() error {
+	cmd := []string{"filter", "replace", "dev", flower.Dev}
+	cmd = append(cmd, flower.args()...)
+	cmd = append(cmd, "flower")
+
+	if flower.SrcMAC != "" {
+		cmd = append(cmd, "src_mac", flower.SrcMAC)
+	}
+
+	if flower.DstMAC != "" {
+		cmd = append(cmd, "dst_mac", flower.DstMAC)
+	}
+
+	if flower.Flowid != "" {
+		cmd = append(cmd, "classid", flower.Flowid)
+	}
+
+	_, err := shared.RunCommand("tc", cmd...)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Delete deletes the flow based traffic control filter from a node
+func (flower *FlowerFilter) Delete() error {
+	cmd := []string{"filter", "del", "dev", flower.Dev}
+	cmd = append(cmd, flower.args()...)
+	cmd = append(cmd, "flower")
+
+	_, err := shared.RunCommand("tc", cmd...)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (flower *FlowerFilter) args() []string {
+	args := []string{}
+	if flower.Parent != "" {
+		args = append(args, "parent", flower.Parent)
+	}
+
+	args = append(args, "protocol", flower.Protocol)
+
+	if flower.Pref != "" {
+		args = append(args, "pref", flower.Pref)
+	}
+
+	if flower.Handle != "" {
+		args = append(args, "handle", flower.Handle)
+	}
+
+	return args
+}
@@ -0,0 +1,11 @@
+package ip
+
+// Ifb represents arguments for link device of type ifb
+type Ifb struct {
+	Link
+}
+
+// Add adds new virtual link
+func (i *Ifb) Add() error {
+	return i.Link.add("ifb", nil)
+}
@@ -62,7 +62,9 @@ func (r *Route) Flush() error {
 	if r.Via != "" {
 		cmd = append(cmd, "via", r.Via)
 	}
-	cmd = append(cmd, "dev", r.DevName)
+	if r.DevName != "" {
+		cmd = append(cmd, "dev", r.DevName)
+	}
 	if r.Proto != "" {
 		cmd = append(cmd, "proto", r.Proto)
 	}
@@ -76,7 +78,11 @@ func (r *Route) Flush() error {
 
 // Replace changes or adds new route
 func (r *Route) Replace(routes []string) error {
-	cmd := []string{r.Family, "route", "replace", "dev", r.DevName, "proto", r.Proto}
+	cmd := []string{r.Family, "route", "replace"}
+	if r.DevName != "" {
+		cmd = append(cmd, "dev", r.DevName)
+	}
+	cmd = append(cmd, "proto", r.Proto)
 	cmd = append(cmd, routes...)
 	_, err := shared.RunCommand("ip", cmd...)
 	if err != nil {
@@ -88,7 +94,12 @@ func (r *Route) Replace(routes []string) error {
 // Show lists routes
 func (r *Route) Show() ([]string, error) {
 	routes := []string{}
-	out, err := shared.RunCommand("ip", r.Family, "route", "show", "dev", r.DevName, "proto", r.Proto)
+	cmd := []string{r.Family, "route", "show"}
+	if r.DevName != "" {
+		cmd = append(cmd, "dev", r.DevName)
+	}
+	cmd = append(cmd, "proto", r.Proto)
+	out, err := shared.RunCommand("ip", cmd...)
 	if err != nil {
 		return routes, err
 	}
@@ -38,6 +38,8 @@ const (
 	InstanceFileRetrieved    = InstanceAction("file-retrieved")
 	InstanceFilePushed       = InstanceAction("file-pushed")
 	InstanceFileDeleted      = InstanceAction("file-deleted")
+	InstanceAutoscaled       = InstanceAction("autoscaled")
+	InstanceScheduledAction  = InstanceAction("scheduled-action")
 )
 
 // Event creates the lifecycle event for an action on an instance.
@@ -16,6 +16,8 @@ const (
 	StoragePoolCreated = StoragePoolAction("created")
 	StoragePoolDeleted = StoragePoolAction("deleted")
 	StoragePoolUpdated = StoragePoolAction("updated")
+
+	StoragePoolFillThresholdExceeded = StoragePoolAction("fill-threshold-exceeded")
 )
 
 // Event creates the lifecycle event for an action on an storage pool.
@@ -37,6 +37,11 @@ func (m *MetricSet) AddSamples(metricType MetricType, samples ...Sample) {
 	m.set[metricType] = append(m.set[metricType], samples...)
 }
 
+// Samples returns the samples of the type metricType in the MetricSet.
+func (m *MetricSet) Samples(metricType MetricType) []Sample {
+	return m.set[metricType]
+}
+
 // Merge merges two MetricSets.
 func (m *MetricSet) Merge(metricSet *MetricSet) {
 	if metricSet == nil {
@@ -75,7 +80,7 @@ func (m *MetricSet) String() string {
 			metricTypeName = "gauge"
 		} else if strings.HasSuffix(MetricNames[metricType], "_total") {
 			metricTypeName = "counter"
-		} else if strings.HasSuffix(MetricNames[metricType], "_bytes") {
+		} else if strings.HasSuffix(MetricNames[metricType], "_bytes") || strings.HasSuffix(MetricNames[metricType], "_seconds") {
 			metricTypeName = "gauge"
 		}
 
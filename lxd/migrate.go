@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -50,6 +51,7 @@ type migrationFields struct {
 	live         bool
 	instanceOnly bool
 	instance     instance.Instance
+	clusterMove  bool // Whether the instance is live moved to another cluster member sharing its record.
 
 	// storage specific fields
 	volumeOnly        bool
@@ -199,6 +201,12 @@ func (s *migrationSourceWs) Connect(op *operations.Operation, r *http.Request, w
 
 	*conn = c
 
+	// The VM state channel is optional as targets not supporting live migration of VMs don't connect it.
+	// Targets supporting it connect it before the filesystem channel.
+	if s.instance != nil && s.instance.Type() == instancetype.VM && conn == &s.criuConn {
+		return nil
+	}
+
 	// Check criteria for considering all channels to be connected.
 	if s.instance != nil && s.instance.Type() == instancetype.Container && s.live && s.criuConn == nil {
 		return nil
@@ -244,7 +252,17 @@ func (s *migrationSourceWs) ConnectTarget(certificate string, operation string,
 		HandshakeTimeout: time.Second * 5,
 	}
 
-	for name, secret := range websockets {
+	// Connect in a stable order so the state channel is connected before the filesystem channel.
+	names := make([]string, 0, len(websockets))
+	for name := range websockets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		secret := websockets[name]
+
 		var conn **websocket.Conn
 
 		switch name {
@@ -306,6 +324,7 @@ type MigrationSinkArgs struct {
 	Live         bool
 	Refresh      bool
 	Snapshots    []*migration.Snapshot
+	ClusterMove  bool
 
 	// Storage specific fields
 	VolumeOnly bool
@@ -372,6 +391,11 @@ func (s *migrationSink) Connect(op *operations.Operation, r *http.Request, w htt
 
 	*conn = c
 
+	// The VM state channel is optional as sources not supporting live migration of VMs may not connect it.
+	if s.src.instance != nil && s.src.instance.Type() == instancetype.VM && conn == &s.dest.criuConn {
+		return nil
+	}
+
 	// Check criteria for considering all channels to be connected.
 	if s.src.instance != nil && s.src.instance.Type() == instancetype.Container && s.dest.live && s.dest.criuConn == nil {
 		return nil
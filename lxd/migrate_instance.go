@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -61,6 +62,12 @@ func newMigrationSource(inst instance.Instance, stateful bool, instanceOnly bool
 				return nil, fmt.Errorf("Unable to perform container live migration. CRIU isn't installed on the source server")
 			}
 
+			ret.criuSecret, err = shared.RandomCryptoString()
+			if err != nil {
+				return nil, err
+			}
+		} else if inst.Type() == instancetype.VM {
+			// Used to send the VM state to targets supporting live migration of VMs.
 			ret.criuSecret, err = shared.RandomCryptoString()
 			if err != nil {
 				return nil, err
@@ -441,6 +448,11 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 		offerHeader.Predump = proto.Bool(offerUsePreDumps)
 	}
 
+	// Offer to live migrate running VMs through QEMU.
+	if s.instance.Type() == instancetype.VM && s.live {
+		offerHeader.Criu = migration.CRIUType_VM_QEMU.Enum()
+	}
+
 	srcConfig, err := pool.GenerateInstanceBackupConfig(s.instance, !s.instanceOnly, migrateOp)
 	if err != nil {
 		return abort(fmt.Errorf("Failed generating instance migration config: %w", err))
@@ -532,11 +544,38 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 		volSourceArgs.MultiSync = s.live || (respHeader.Criu != nil && *respHeader.Criu == migration.CRIUType_NONE)
 	}
 
+	// Live migrate the VM if the target accepted it, otherwise fallback to statefully stopping it and
+	// transferring its state file.
 	if s.instance.Type() == instancetype.VM && s.live {
+		if respHeader.GetCriu() == migration.CRIUType_VM_QEMU && s.criuConn != nil {
+			volSourceArgs.LiveVM = true
+
+			err = s.migrateVM(pool, volSourceArgs, migrateOp)
+			if err != nil {
+				return abort(err)
+			}
+
+			return nil
+		}
+
+		// The stateful stop fallback can't be used when the target shares the instance record.
+		if s.clusterMove {
+			return abort(fmt.Errorf("Target doesn't support live migration of virtual machines"))
+		}
+
 		err = s.instance.Stop(true)
 		if err != nil {
 			return abort(fmt.Errorf("Failed statefully stopping instance: %w", err))
 		}
+
+		// Resume the instance from its state once transferred as a copy keeps running. Remote moves delete
+		// the instance once done.
+		defer func() {
+			err := s.instance.Start(true)
+			if err != nil {
+				l.Error("Failed restoring instance after migration", logger.Ctx{"err": err})
+			}
+		}()
 	}
 
 	err = pool.MigrateInstance(s.instance, &shared.WebsocketIO{Conn: s.fsConn}, volSourceArgs, migrateOp)
@@ -758,9 +797,60 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	return nil
 }
 
+// migrateVM live migrates the running VM, transferring its volumes while it keeps running unless they are shared
+// with the target.
+func (s *migrationSourceWs) migrateVM(pool storagePools.Pool, volSourceArgs *migration.VolumeSourceArgs, migrateOp *operations.Operation) error {
+	vm, ok := s.instance.(instance.VM)
+	if !ok {
+		return fmt.Errorf("Instance is not a virtual machine")
+	}
+
+	args := instance.VMMigrateSendArgs{
+		StateConn:   &shared.WebsocketIO{Conn: s.criuConn},
+		ClusterMove: s.clusterMove,
+		Op:          migrateOp,
+	}
+
+	// When moving to another cluster member using the same remote pool, the volumes are already available there.
+	if !s.clusterMove || !pool.Driver().Info().Remote {
+		args.DiskConn = &shared.WebsocketIO{Conn: s.fsConn}
+		args.StorageSync = func() error {
+			return pool.MigrateInstance(s.instance, &shared.WebsocketIO{Conn: s.fsConn}, volSourceArgs, migrateOp)
+		}
+	}
+
+	args.Confirm = func() error {
+		msg := migration.MigrationControl{}
+		err := s.recv(&msg)
+		if err != nil {
+			return err
+		}
+
+		if !msg.GetSuccess() {
+			return fmt.Errorf(msg.GetMessage())
+		}
+
+		return nil
+	}
+
+	return vm.MigrateSend(args)
+}
+
+// migrationSinkVMReceiveArgs returns the arguments to receive a live migrated VM with. The VM state is streamed
+// straight into the target QEMU. With shared storage, the root disk is already available so no disk writes are
+// received.
+func migrationSinkVMReceiveArgs(stateConn io.ReadWriteCloser, diskConn io.ReadWriteCloser, sharedStorage bool) instance.VMMigrateReceiveArgs {
+	args := instance.VMMigrateReceiveArgs{StateConn: stateConn}
+	if !sharedStorage {
+		args.DiskConn = diskConn
+	}
+
+	return args
+}
+
 func newMigrationSink(args *MigrationSinkArgs) (*migrationSink, error) {
 	sink := migrationSink{
-		src:     migrationFields{instance: args.Instance, instanceOnly: args.InstanceOnly},
+		src:     migrationFields{instance: args.Instance, instanceOnly: args.InstanceOnly, clusterMove: args.ClusterMove},
 		dest:    migrationFields{instanceOnly: args.InstanceOnly},
 		url:     args.URL,
 		dialer:  args.Dialer,
@@ -847,19 +937,21 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 		}
 		defer c.src.disconnect()
 
-		c.src.fsConn, err = c.connectWithSecret(c.src.fsSecret)
-		if err != nil {
-			c.src.sendControl(err)
-			return err
-		}
-
-		if c.src.live && c.src.instance.Type() == instancetype.Container {
+		// Connect the state channel before the filesystem channel as the source doesn't wait for the state
+		// channel of VMs to be connected.
+		if c.src.live && (c.src.instance.Type() == instancetype.Container || c.src.criuSecret != "") {
 			c.src.criuConn, err = c.connectWithSecret(c.src.criuSecret)
 			if err != nil {
 				c.src.sendControl(err)
 				return err
 			}
 		}
+
+		c.src.fsConn, err = c.connectWithSecret(c.src.fsSecret)
+		if err != nil {
+			c.src.sendControl(err)
+			return err
+		}
 	}
 
 	l.Info("Migration channels connected on target")
@@ -893,6 +985,11 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 		live = c.dest.live
 	}
 
+	criuConn := c.src.criuConn
+	if c.push {
+		criuConn = c.dest.criuConn
+	}
+
 	criuType := migration.CRIUType_CRIU_RSYNC.Enum()
 	if offerHeader.Criu != nil && *offerHeader.Criu == migration.CRIUType_NONE {
 		criuType = migration.CRIUType_NONE.Enum()
@@ -902,6 +999,19 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 		}
 	}
 
+	// Accept to live migrate the VM through QEMU if offered and the state channel is connected.
+	liveVM := live && c.src.instance.Type() == instancetype.VM && offerHeader.GetCriu() == migration.CRIUType_VM_QEMU && criuConn != nil
+	if liveVM {
+		criuType = migration.CRIUType_VM_QEMU.Enum()
+	}
+
+	// Cluster moves reuse the instance record, which only the live migration of VMs supports.
+	if c.src.clusterMove && !liveVM {
+		err = fmt.Errorf("Cluster member moves require live migration of a running virtual machine")
+		controller(err)
+		return err
+	}
+
 	// The function that will be executed to receive the sender's migration data.
 	var myTarget func(conn *websocket.Conn, op *operations.Operation, args MigrationSinkArgs) error
 
@@ -910,6 +1020,9 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 		return err
 	}
 
+	// When moving from another cluster member using the same remote pool, the volumes are already available.
+	sharedStorage := c.src.clusterMove && pool.Driver().Info().Remote
+
 	// Extract the source's migration type and then match it against our pool's
 	// supported types and features. If a match is found the combined features list
 	// will be sent back to requester.
@@ -1000,8 +1113,11 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 		}
 
 		// Only delete entire instance on error if the pool volume creation has succeeded to avoid
-		// deleting an existing conflicting volume.
-		if !volTargetArgs.Refresh {
+		// deleting an existing conflicting volume. On cluster moves, the instance record belongs to the source
+		// so only delete the local volume.
+		if c.src.clusterMove {
+			revert.Add(func() { _ = pool.DeleteInstance(args.Instance, op) })
+		} else if !volTargetArgs.Refresh {
 			revert.Add(func() { _ = args.Instance.Delete(true) })
 		}
 
@@ -1152,7 +1268,12 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 				VolumeSize:    offerHeader.GetVolumeSize(), // Block size setting override.
 			}
 
-			err = myTarget(fsConn, migrateOp, args)
+			if sharedStorage {
+				err = pool.ImportInstance(c.src.instance, nil, migrateOp)
+			} else {
+				err = myTarget(fsConn, migrateOp, args)
+			}
+
 			if err != nil {
 				fsTransfer <- err
 				return
@@ -1183,13 +1304,6 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 
 			defer func() { _ = os.RemoveAll(imagesDir) }()
 
-			var criuConn *websocket.Conn
-			if c.push {
-				criuConn = c.dest.criuConn
-			} else {
-				criuConn = c.src.criuConn
-			}
-
 			sync := &migration.MigrationSync{
 				FinalPreDump: proto.Bool(false),
 			}
@@ -1260,7 +1374,23 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 				}
 			}
 
-			if c.src.instance.Type() == instancetype.VM {
+			if c.src.instance.Type() == instancetype.VM && liveVM {
+				var fsConn *websocket.Conn
+				if c.push {
+					fsConn = c.dest.fsConn
+				} else {
+					fsConn = c.src.fsConn
+				}
+
+				receiveArgs := migrationSinkVMReceiveArgs(&shared.WebsocketIO{Conn: criuConn}, &shared.WebsocketIO{Conn: fsConn}, sharedStorage)
+
+				vm := c.src.instance.(instance.VM)
+				err = vm.MigrateReceive(receiveArgs)
+				if err != nil {
+					restore <- err
+					return
+				}
+			} else if c.src.instance.Type() == instancetype.VM {
 				err = c.src.instance.Migrate(nil)
 				if err != nil {
 					restore <- err
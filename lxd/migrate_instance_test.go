@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationSinkVMReceiveArgs(t *testing.T) {
+	stateConn, stateConnPeer := net.Pipe()
+	defer func() { _ = stateConn.Close() }()
+	defer func() { _ = stateConnPeer.Close() }()
+
+	diskConn, diskConnPeer := net.Pipe()
+	defer func() { _ = diskConn.Close() }()
+	defer func() { _ = diskConnPeer.Close() }()
+
+	// The root disk writes are received along with the state.
+	args := migrationSinkVMReceiveArgs(stateConn, diskConn, false)
+	assert.Equal(t, stateConn, args.StateConn)
+	assert.Equal(t, diskConn, args.DiskConn)
+
+	// With shared storage, the state is streamed straight into QEMU and no disk writes are received.
+	args = migrationSinkVMReceiveArgs(stateConn, diskConn, true)
+	assert.Equal(t, stateConn, args.StateConn)
+	assert.Nil(t, args.DiskConn)
+}
@@ -59,6 +59,7 @@ type VolumeSourceArgs struct {
 	Refresh            bool
 	Info               *Info
 	VolumeOnly         bool
+	LiveVM             bool // Whether the running VM keeps its root disk content frozen while being live migrated.
 }
 
 // VolumeTargetArgs represents the arguments needed to setup a volume migration sink.
@@ -17,7 +17,7 @@ func FirewallApplyACLRules(s *state.State, logger logger.Logger, aclProjectName
 	var allowRules []firewallDrivers.ACLRule
 
 	// convertACLRules converts the ACL rules to Firewall ACL rules.
-	convertACLRules := func(direction string, logPrefix string, rules ...api.NetworkACLRule) error {
+	convertACLRules := func(aclID int64, direction string, logPrefix string, rules ...api.NetworkACLRule) error {
 		for ruleIndex, rule := range rules {
 			if rule.State == "disabled" {
 				continue
@@ -33,6 +33,7 @@ func FirewallApplyACLRules(s *state.State, logger logger.Logger, aclProjectName
 				DestinationPort: rule.DestinationPort,
 				ICMPType:        rule.ICMPType,
 				ICMPCode:        rule.ICMPCode,
+				CounterName:     firewallACLRuleCounterName(aclID, direction, ruleIndex),
 			}
 
 			if rule.State == "logged" {
@@ -60,17 +61,17 @@ func FirewallApplyACLRules(s *state.State, logger logger.Logger, aclProjectName
 
 	// Load ACLs specified by network.
 	for _, aclName := range shared.SplitNTrimSpace(aclNet.Config["security.acls"], ",", -1, true) {
-		_, aclInfo, err := s.DB.Cluster.GetNetworkACL(aclProjectName, aclName)
+		aclID, aclInfo, err := s.DB.Cluster.GetNetworkACL(aclProjectName, aclName)
 		if err != nil {
 			return fmt.Errorf("Failed loading ACL %q for network %q: %w", aclName, aclNet.Name, err)
 		}
 
-		err = convertACLRules("ingress", logPrefix, aclInfo.Ingress...)
+		err = convertACLRules(aclID, "ingress", logPrefix, aclInfo.Ingress...)
 		if err != nil {
 			return fmt.Errorf("Failed converting ACL %q ingress rules for network %q: %w", aclInfo.Name, aclNet.Name, err)
 		}
 
-		err = convertACLRules("egress", logPrefix, aclInfo.Egress...)
+		err = convertACLRules(aclID, "egress", logPrefix, aclInfo.Egress...)
 		if err != nil {
 			return fmt.Errorf("Failed converting ACL %q egress rules for network %q: %w", aclInfo.Name, aclNet.Name, err)
 		}
@@ -102,6 +103,11 @@ func FirewallApplyACLRules(s *state.State, logger logger.Logger, aclProjectName
 	return s.Firewall.NetworkApplyACLRules(aclNet.Name, rules)
 }
 
+// firewallACLRuleCounterName returns the counter name used for an ACL rule in the network firewall.
+func firewallACLRuleCounterName(aclID int64, direction string, ruleIndex int) string {
+	return fmt.Sprintf("acl%d-%s-%d", aclID, direction, ruleIndex)
+}
+
 // firewallACLDefaults returns the action and logging mode to use for the specified direction's default rule.
 // If the security.acls.default.{in,e}gress.action or security.acls.default.{in,e}gress.logged settings are not
 // specified in the network config, then it returns "reject" and false respectively.
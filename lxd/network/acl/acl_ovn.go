@@ -8,8 +8,10 @@ import (
 	"strings"
 	"time"
 
+	clusterConfig "github.com/lxc/lxd/lxd/cluster/config"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/db/cluster"
+	firewallDrivers "github.com/lxc/lxd/lxd/firewall/drivers"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
 	"github.com/lxc/lxd/lxd/revert"
@@ -342,6 +344,8 @@ func ovnApplyToPortGroup(l logger.Logger, client *openvswitch.OVN, aclInfo *api.
 				ovnACLRule.LogName = fmt.Sprintf("%s-%s-%d", portGroupName, direction, ruleIndex)
 			}
 
+			ovnACLRule.CounterName = fmt.Sprintf("%s-%d", direction, ruleIndex)
+
 			if networkSpecific {
 				networkRules = append(networkRules, ovnACLRule)
 			} else {
@@ -1105,3 +1109,35 @@ func ovnParseLogEntry(input string, prefix string) string {
 
 	return string(out)
 }
+
+// OVNACLRuleCounters returns the hit counters of the rules applied to the specified port groups on the local
+// chassis, keyed by the rule counter name.
+func OVNACLRuleCounters(s *state.State, client *openvswitch.OVN, portGroupNames ...openvswitch.OVNPortGroup) (map[string]firewallDrivers.ACLRuleCounter, error) {
+	integrationBridge, err := clusterConfig.GetString(s.DB.Cluster, "network.ovn.integration_bridge")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get OVN integration bridge name: %w", err)
+	}
+
+	flowCounters, err := openvswitch.NewOVS().BridgeFlowCounters(integrationBridge)
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting OVS flow counters: %w", err)
+	}
+
+	ruleCookies, err := client.PortGroupACLRuleFlowCookies(portGroupNames...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting OVN ACL rule flows: %w", err)
+	}
+
+	counters := map[string]firewallDrivers.ACLRuleCounter{}
+	for counterName, cookies := range ruleCookies {
+		counter := counters[counterName]
+		for _, cookie := range cookies {
+			counter.Packets += flowCounters[cookie].Packets
+			counter.Bytes += flowCounters[cookie].Bytes
+		}
+
+		counters[counterName] = counter
+	}
+
+	return counters, nil
+}
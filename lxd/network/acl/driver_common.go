@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -814,3 +815,133 @@ func (d *common) GetLog(clientType request.ClientType) (string, error) {
 
 	return strings.Join(logEntries, "\n") + "\n", nil
 }
+
+// GetState gets the ACL state, made of the hit counters of its rules.
+// When called by a normal client, the counters of all the cluster members are aggregated.
+func (d *common) GetState(clientType request.ClientType) (*api.NetworkACLState, error) {
+	aclState := &api.NetworkACLState{
+		Egress:  make([]api.NetworkACLRuleState, len(d.info.Egress)),
+		Ingress: make([]api.NetworkACLRuleState, len(d.info.Ingress)),
+	}
+
+	// addCounter adds the counter of a rule to the state.
+	addCounter := func(direction string, ruleIndex int, packets uint64, bytes uint64) {
+		rules := aclState.Ingress
+		if direction == string(ruleDirectionEgress) {
+			rules = aclState.Egress
+		}
+
+		if ruleIndex < 0 || ruleIndex >= len(rules) {
+			return
+		}
+
+		rules[ruleIndex].Packets += packets
+		rules[ruleIndex].Bytes += bytes
+	}
+
+	// Get a list of networks that are using this ACL (either directly or indirectly via a NIC).
+	aclNets := map[string]NetworkACLUsage{}
+	err := NetworkUsage(d.state, d.projectName, []string{d.info.Name}, aclNets)
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting ACL network usage: %w", err)
+	}
+
+	var ovnPortGroups []openvswitch.OVNPortGroup
+	for _, aclNet := range aclNets {
+		switch aclNet.Type {
+		case "bridge":
+			counters, err := d.state.Firewall.NetworkACLRuleCounters(aclNet.Name)
+			if err != nil {
+				// The network may not be running on this member.
+				d.logger.Warn("Failed getting ACL rule counters", logger.Ctx{"network": aclNet.Name, "err": err})
+				continue
+			}
+
+			for counterName, counter := range counters {
+				// Counter names are in the form "acl<ID>-<direction>-<index>".
+				fields := strings.Split(counterName, "-")
+				if len(fields) != 3 || fields[0] != fmt.Sprintf("acl%d", d.id) {
+					continue
+				}
+
+				ruleIndex, err := strconv.Atoi(fields[2])
+				if err != nil {
+					continue
+				}
+
+				addCounter(fields[1], ruleIndex, counter.Packets, counter.Bytes)
+			}
+
+		case "ovn":
+			// OVN networks share the ACL port group, plus a port group per network for network specific rules.
+			if len(ovnPortGroups) == 0 {
+				ovnPortGroups = append(ovnPortGroups, OVNACLPortGroupName(d.id))
+			}
+
+			ovnPortGroups = append(ovnPortGroups, OVNACLNetworkPortGroupName(d.id, aclNet.ID))
+		}
+	}
+
+	if len(ovnPortGroups) > 0 {
+		client, err := openvswitch.NewOVN(d.state)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get OVN client: %w", err)
+		}
+
+		counters, err := OVNACLRuleCounters(d.state, client, ovnPortGroups...)
+		if err != nil {
+			return nil, err
+		}
+
+		for counterName, counter := range counters {
+			// Counter names are in the form "<direction>-<index>".
+			direction, index, found := strings.Cut(counterName, "-")
+			if !found {
+				continue
+			}
+
+			ruleIndex, err := strconv.Atoi(index)
+			if err != nil {
+				continue
+			}
+
+			addCounter(direction, ruleIndex, counter.Packets, counter.Bytes)
+		}
+	}
+
+	// Aggregates the counters from the rest of the cluster.
+	if clientType == request.ClientTypeNormal {
+		// Setup notifier to reach the rest of the cluster.
+		notifier, err := cluster.NewNotifier(d.state, d.state.Endpoints.NetworkCert(), d.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return nil, err
+		}
+
+		mu := sync.Mutex{}
+		err = notifier(func(client lxd.InstanceServer) error {
+			memberState, err := client.UseProject(d.projectName).GetNetworkACLState(d.info.Name)
+			if err != nil {
+				return err
+			}
+
+			// Prevent concurrent writes to the state.
+			mu.Lock()
+			defer mu.Unlock()
+
+			for ruleIndex, rule := range memberState.Egress {
+				addCounter(string(ruleDirectionEgress), ruleIndex, rule.Packets, rule.Bytes)
+			}
+
+			for ruleIndex, rule := range memberState.Ingress {
+				addCounter(string(ruleDirectionIngress), ruleIndex, rule.Packets, rule.Bytes)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return aclState, nil
+}
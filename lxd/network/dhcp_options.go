@@ -0,0 +1,187 @@
+package network
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+// DHCPv4OptionsPrefix is the config key prefix used for structured DHCPv4 options.
+const DHCPv4OptionsPrefix = "ipv4.dhcp.options."
+
+// dhcpv4Option describes a structured DHCPv4 option and how it maps to dnsmasq and OVN.
+type dhcpv4Option struct {
+	dnsmasqName string // Option name understood by dnsmasq's --dhcp-option.
+	ovnName     string // Option name in the OVN DHCP_Options table.
+	validator   func(value string) error
+	ovnValue    func(value string) string
+}
+
+// dhcpv4Options lists the supported structured DHCPv4 options keyed by their config key suffix.
+var dhcpv4Options = map[string]dhcpv4Option{
+	"bootfile_name": {
+		dnsmasqName: "option:bootfile-name",
+		ovnName:     "bootfile_name",
+		validator:   dhcpValidString,
+		ovnValue:    dhcpOVNString,
+	},
+	"classless_static_routes": {
+		dnsmasqName: "option:classless-static-route",
+		ovnName:     "classless_static_route",
+		validator:   dhcpValidClasslessStaticRoutes,
+		ovnValue:    dhcpOVNClasslessStaticRoutes,
+	},
+	"dns_server": {
+		dnsmasqName: "option:dns-server",
+		ovnName:     "dns_server",
+		validator:   validate.IsNetworkAddressV4List,
+		ovnValue:    dhcpOVNList,
+	},
+	"domain_search": {
+		dnsmasqName: "option:domain-search",
+		ovnName:     "domain_search_list",
+		validator:   validate.IsListOf(dhcpValidDomain),
+		ovnValue:    dhcpOVNString,
+	},
+	"ntp_server": {
+		dnsmasqName: "option:ntp-server",
+		ovnName:     "ntp_server",
+		validator:   validate.IsNetworkAddressV4List,
+		ovnValue:    dhcpOVNList,
+	},
+	"tftp_server": {
+		dnsmasqName: "option:tftp-server",
+		ovnName:     "tftp_server",
+		validator:   dhcpValidString,
+		ovnValue:    dhcpOVNString,
+	},
+}
+
+// DHCPv4OptionsValidationRules returns the validation rules for the structured DHCPv4 option keys.
+func DHCPv4OptionsValidationRules() map[string]func(value string) error {
+	rules := make(map[string]func(value string) error, len(dhcpv4Options))
+	for name, option := range dhcpv4Options {
+		rules[DHCPv4OptionsPrefix+name] = validate.Optional(option.validator)
+	}
+
+	return rules
+}
+
+// DHCPv4OptionsDnsmasq returns the structured DHCPv4 options found in config in the format used by dnsmasq's
+// --dhcp-option and --dhcp-optsfile (without any tag), sorted by key.
+func DHCPv4OptionsDnsmasq(config map[string]string) []string {
+	options := []string{}
+	for _, name := range dhcpv4OptionNames(config) {
+		values := shared.SplitNTrimSpace(config[DHCPv4OptionsPrefix+name], ",", -1, true)
+		options = append(options, fmt.Sprintf("%s,%s", dhcpv4Options[name].dnsmasqName, strings.Join(values, ",")))
+	}
+
+	return options
+}
+
+// DHCPv4OptionsOVN returns the structured DHCPv4 options found in config in the format used by the options
+// column of the OVN DHCP_Options table.
+func DHCPv4OptionsOVN(config map[string]string) map[string]string {
+	options := map[string]string{}
+	for _, name := range dhcpv4OptionNames(config) {
+		option := dhcpv4Options[name]
+		options[option.ovnName] = option.ovnValue(config[DHCPv4OptionsPrefix+name])
+	}
+
+	return options
+}
+
+// dhcpv4OptionNames returns the sorted names of the structured DHCPv4 options set in config.
+func dhcpv4OptionNames(config map[string]string) []string {
+	names := []string{}
+	for k, v := range config {
+		if !strings.HasPrefix(k, DHCPv4OptionsPrefix) || v == "" {
+			continue
+		}
+
+		name := strings.TrimPrefix(k, DHCPv4OptionsPrefix)
+		_, found := dhcpv4Options[name]
+		if !found {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// dhcpDomainRegex matches the domain names allowed in DHCP options.
+var dhcpDomainRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9.])?$`)
+
+// dhcpValidDomain validates a domain name used in a DHCP option.
+func dhcpValidDomain(value string) error {
+	if !dhcpDomainRegex.MatchString(value) {
+		return fmt.Errorf("Invalid domain name")
+	}
+
+	return nil
+}
+
+// dhcpValidString validates a single string value used in a DHCP option.
+func dhcpValidString(value string) error {
+	if strings.ContainsAny(value, "\",\\ \t\r\n") {
+		return fmt.Errorf("Value cannot contain whitespace, commas, quotes or backslashes")
+	}
+
+	return nil
+}
+
+// dhcpValidClasslessStaticRoutes validates a comma separated list of destination subnet and gateway pairs.
+func dhcpValidClasslessStaticRoutes(value string) error {
+	fields := shared.SplitNTrimSpace(value, ",", -1, true)
+	if len(fields)%2 != 0 {
+		return fmt.Errorf("Routes must be specified as pairs of destination subnet and gateway")
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		err := validate.IsNetworkV4(fields[i])
+		if err != nil {
+			return fmt.Errorf("Invalid destination: %w", err)
+		}
+
+		err = validate.IsNetworkAddressV4(fields[i+1])
+		if err != nil {
+			return fmt.Errorf("Invalid gateway: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// dhcpOVNString formats a string option value for OVN.
+func dhcpOVNString(value string) string {
+	values := shared.SplitNTrimSpace(value, ",", -1, true)
+
+	return fmt.Sprintf(`"%s"`, strings.Join(values, ","))
+}
+
+// dhcpOVNList formats an address list option value for OVN.
+func dhcpOVNList(value string) string {
+	values := shared.SplitNTrimSpace(value, ",", -1, true)
+
+	return fmt.Sprintf("{%s}", strings.Join(values, ","))
+}
+
+// dhcpOVNClasslessStaticRoutes formats a list of destination subnet and gateway pairs for OVN.
+func dhcpOVNClasslessStaticRoutes(value string) string {
+	fields := shared.SplitNTrimSpace(value, ",", -1, true)
+
+	routes := make([]string, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		routes = append(routes, fmt.Sprintf("%s,%s", fields[i], fields[i+1]))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(routes, ", "))
+}
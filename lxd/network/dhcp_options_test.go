@@ -0,0 +1,50 @@
+package network
+
+import (
+	"fmt"
+	"sort"
+)
+
+func Example_dhcpv4Options() {
+	config := map[string]string{
+		"ipv4.dhcp.options.bootfile_name":           "pxelinux.0",
+		"ipv4.dhcp.options.classless_static_routes": "10.0.0.0/8,192.168.1.254, 0.0.0.0/0,192.168.1.1",
+		"ipv4.dhcp.options.domain_search":           "example.com, example.net",
+		"ipv4.dhcp.options.ntp_server":              "192.168.1.10,192.168.1.11",
+		"ipv4.dhcp.options.unknown":                 "ignored",
+		"ipv4.dhcp.expiry":                          "1h",
+	}
+
+	for _, option := range DHCPv4OptionsDnsmasq(config) {
+		fmt.Println(option)
+	}
+
+	ovnOptions := DHCPv4OptionsOVN(config)
+	names := make([]string, 0, len(ovnOptions))
+	for name := range ovnOptions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%s=%s\n", name, ovnOptions[name])
+	}
+
+	rules := DHCPv4OptionsValidationRules()
+	for _, value := range []string{"10.0.0.0/8,192.168.1.254", "10.0.0.0/8", "10.0.0.0/8,fd00::1"} {
+		fmt.Println(rules["ipv4.dhcp.options.classless_static_routes"](value))
+	}
+
+	// Output: option:bootfile-name,pxelinux.0
+	// option:classless-static-route,10.0.0.0/8,192.168.1.254,0.0.0.0/0,192.168.1.1
+	// option:domain-search,example.com,example.net
+	// option:ntp-server,192.168.1.10,192.168.1.11
+	// bootfile_name="pxelinux.0"
+	// classless_static_route={10.0.0.0/8,192.168.1.254, 0.0.0.0/0,192.168.1.1}
+	// domain_search_list="example.com,example.net"
+	// ntp_server={192.168.1.10,192.168.1.11}
+	// <nil>
+	// Routes must be specified as pairs of destination subnet and gateway
+	// Invalid gateway: Not an IPv4 address "fd00::1"
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -42,6 +43,7 @@ import (
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/subprocess"
+	"github.com/lxc/lxd/shared/units"
 	"github.com/lxc/lxd/shared/validate"
 	"github.com/lxc/lxd/shared/version"
 )
@@ -54,9 +56,21 @@ const ForkdnsServersListFile = "servers.conf"
 
 var forkdnsServersLock sync.Mutex
 
+// bridgeQoSClass represents a QoS class of a bridge network.
+type bridgeQoSClass struct {
+	name     string
+	classID  string
+	rate     int64
+	ceil     int64
+	priority string
+}
+
 // bridge represents a LXD bridge network.
 type bridge struct {
 	common
+
+	// snatSubnetV4 overrides the subnet used to select IPv4 traffic for SNAT (used by mesh networks).
+	snatSubnetV4 *net.IPNet
 }
 
 // Type returns the network type.
@@ -73,6 +87,7 @@ func (n *bridge) DBType() db.NetworkType {
 func (n *bridge) Info() Info {
 	info := n.common.Info()
 	info.AddressForwards = true
+	info.LoadBalancers = true
 
 	return info
 }
@@ -272,6 +287,8 @@ func (n *bridge) Validate(config map[string]string) error {
 		"dns.zone.reverse.ipv4":                validate.Optional(n.validateZoneName),
 		"dns.zone.reverse.ipv6":                validate.Optional(n.validateZoneName),
 		"raw.dnsmasq":                          validate.IsAny,
+		"qos.bandwidth":                        validate.Optional(networkValidBitRate),
+		"qos.default":                          validate.IsAny,
 		"maas.subnet.ipv4":                     validate.IsAny,
 		"maas.subnet.ipv6":                     validate.IsAny,
 		"security.acls":                        validate.IsAny,
@@ -317,6 +334,21 @@ func (n *bridge) Validate(config map[string]string) error {
 				rules[k] = validate.Optional(validate.IsUint8)
 			}
 		}
+
+		// QoS class keys have the class name in their name, extract the suffix.
+		if strings.HasPrefix(k, "qos.classes.") {
+			fields := strings.Split(k, ".")
+			if len(fields) != 4 || fields[2] == "" {
+				return fmt.Errorf("Invalid network configuration key: %s", k)
+			}
+
+			switch fields[3] {
+			case "rate", "ceil":
+				rules[k] = validate.Optional(networkValidBitRate)
+			case "priority":
+				rules[k] = validate.Optional(validate.IsUint32)
+			}
+		}
 	}
 
 	// Add the BGP validation rules.
@@ -329,6 +361,11 @@ func (n *bridge) Validate(config map[string]string) error {
 		rules[k] = v
 	}
 
+	// Add the DHCP options validation rules.
+	for k, v := range DHCPv4OptionsValidationRules() {
+		rules[k] = v
+	}
+
 	// Validate the configuration.
 	err = n.validate(config, rules)
 	if err != nil {
@@ -337,6 +374,24 @@ func (n *bridge) Validate(config map[string]string) error {
 
 	// Peform composite key checks after per-key validation.
 
+	// Validate the QoS classes.
+	qosClasses, err := n.qosClasses(config)
+	if err != nil {
+		return err
+	}
+
+	if len(qosClasses) > 0 {
+		if !shared.StringInSlice(config["bridge.driver"], []string{"", "native"}) {
+			return fmt.Errorf("QoS classes are only supported with the native bridge driver")
+		}
+
+		if len(n.name) > 11 {
+			return fmt.Errorf("Network name too long to use with QoS classes (must be 11 characters or less)")
+		}
+	} else if config["qos.default"] != "" {
+		return fmt.Errorf(`"qos.default" requires QoS classes to be defined`)
+	}
+
 	// Validate network name when used in fan mode.
 	bridgeMode := config["bridge.mode"]
 	if bridgeMode == "fan" && len(n.name) > 11 {
@@ -346,7 +401,7 @@ func (n *bridge) Validate(config map[string]string) error {
 	for k, v := range config {
 		key := k
 		// Bridge mode checks
-		if bridgeMode == "fan" && strings.HasPrefix(key, "ipv4.") && !shared.StringInSlice(key, []string{"ipv4.dhcp.expiry", "ipv4.firewall", "ipv4.nat", "ipv4.nat.order"}) && v != "" {
+		if bridgeMode == "fan" && strings.HasPrefix(key, "ipv4.") && !shared.StringInSlice(key, []string{"ipv4.dhcp.expiry", "ipv4.firewall", "ipv4.nat", "ipv4.nat.order"}) && !strings.HasPrefix(key, DHCPv4OptionsPrefix) && v != "" {
 			return fmt.Errorf("IPv4 configuration may not be set when in 'fan' mode")
 		}
 
@@ -504,8 +559,14 @@ func (n *bridge) Delete(clientType request.ClientType) error {
 		}
 	}
 
+	// Delete any ifb device left behind while the network wasn't running.
+	err := n.qosClear()
+	if err != nil {
+		return err
+	}
+
 	// Delete apparmor profiles.
-	err := apparmor.NetworkDelete(n.state.OS, n)
+	err = apparmor.NetworkDelete(n.state.OS, n)
 	if err != nil {
 		return err
 	}
@@ -937,6 +998,9 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 				dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-no-override", "--dhcp-authoritative", fmt.Sprintf("--dhcp-leasefile=%s", shared.VarPath("networks", n.name, "dnsmasq.leases")), fmt.Sprintf("--dhcp-hostsfile=%s", shared.VarPath("networks", n.name, "dnsmasq.hosts"))}...)
 			}
 
+			// Instance devices specific DHCP options.
+			dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--dhcp-optsfile=%s", shared.VarPath("networks", n.name, "dnsmasq.opts")))
+
 			if n.config["ipv4.dhcp.gateway"] != "" {
 				dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--dhcp-option-force=3,%s", n.config["ipv4.dhcp.gateway"]))
 			}
@@ -945,11 +1009,16 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 				dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--dhcp-option-force=26,%s", mtu))
 			}
 
+			// The domain search option replaces dns.search when set.
 			dnsSearch := n.config["dns.search"]
-			if dnsSearch != "" {
+			if dnsSearch != "" && n.config[DHCPv4OptionsPrefix+"domain_search"] == "" {
 				dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--dhcp-option-force=119,%s", strings.Trim(dnsSearch, " ")))
 			}
 
+			for _, option := range DHCPv4OptionsDnsmasq(n.config) {
+				dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--dhcp-option=%s", option))
+			}
+
 			expiry := "1h"
 			if n.config["ipv4.dhcp.expiry"] != "" {
 				expiry = n.config["ipv4.dhcp.expiry"]
@@ -989,6 +1058,10 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 				Subnet:      subnet,
 			}
 
+			if n.snatSubnetV4 != nil {
+				fwOpts.SNATV4.Subnet = n.snatSubnetV4
+			}
+
 			if n.config["ipv4.nat.order"] == "after" {
 				fwOpts.SNATV4.Append = true
 			}
@@ -1282,8 +1355,13 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			"--dhcp-no-override", "--dhcp-authoritative",
 			fmt.Sprintf("--dhcp-leasefile=%s", shared.VarPath("networks", n.name, "dnsmasq.leases")),
 			fmt.Sprintf("--dhcp-hostsfile=%s", shared.VarPath("networks", n.name, "dnsmasq.hosts")),
+			fmt.Sprintf("--dhcp-optsfile=%s", shared.VarPath("networks", n.name, "dnsmasq.opts")),
 			"--dhcp-range", fmt.Sprintf("%s,%s,%s", dhcpalloc.GetIP(hostSubnet, 2).String(), dhcpalloc.GetIP(hostSubnet, -2).String(), expiry)}...)
 
+		for _, option := range DHCPv4OptionsDnsmasq(n.config) {
+			dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--dhcp-option=%s", option))
+		}
+
 		// Setup the tunnel.
 		if n.config["fan.type"] == "ipip" {
 			r := &ip.Route{
@@ -1540,11 +1618,13 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			dnsmasqCmd = append(dnsmasqCmd, []string{"-g", n.state.OS.UnprivGroup}...)
 		}
 
-		// Create DHCP hosts directory.
-		if !shared.PathExists(shared.VarPath("networks", n.name, "dnsmasq.hosts")) {
-			err = os.MkdirAll(shared.VarPath("networks", n.name, "dnsmasq.hosts"), 0755)
-			if err != nil {
-				return err
+		// Create DHCP hosts and options directories.
+		for _, dir := range []string{"dnsmasq.hosts", "dnsmasq.opts"} {
+			if !shared.PathExists(shared.VarPath("networks", n.name, dir)) {
+				err = os.MkdirAll(shared.VarPath("networks", n.name, dir), 0755)
+				if err != nil {
+					return err
+				}
 			}
 		}
 
@@ -1683,6 +1763,18 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		return err
 	}
 
+	// Setup network load balancers.
+	err = n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	// Setup QoS.
+	err = n.qosSetup()
+	if err != nil {
+		return fmt.Errorf("Failed setting up QoS: %w", err)
+	}
+
 	// Setup BGP.
 	err = n.bgpSetup(oldConfig)
 	if err != nil {
@@ -1707,6 +1799,12 @@ func (n *bridge) Stop() error {
 		return err
 	}
 
+	// Clear QoS.
+	err = n.qosClear()
+	if err != nil {
+		return err
+	}
+
 	// Destroy the bridge interface
 	if n.config["bridge.driver"] == "openvswitch" {
 		ovs := openvswitch.NewOVS()
@@ -2477,6 +2575,7 @@ func (n *bridge) getExternalSubnetInUse() ([]externalSubnetUsage, error) {
 	var err error
 	var projectNetworks map[string]map[int64]api.Network
 	var projectNetworksForwardsOnUplink map[string]map[int64][]string
+	var projectNetworksLoadBalancersOnUplink map[string]map[int64][]string
 
 	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 		// Get all managed networks across all projects.
@@ -2491,6 +2590,12 @@ func (n *bridge) getExternalSubnetInUse() ([]externalSubnetUsage, error) {
 			return fmt.Errorf("Failed loading network forward listen addresses: %w", err)
 		}
 
+		// Get all network load balancer listen addresses for all load balancers assigned to this specific cluster member.
+		projectNetworksLoadBalancersOnUplink, err = tx.GetProjectNetworkLoadBalancerListenAddressesOnMember()
+		if err != nil {
+			return fmt.Errorf("Failed loading network load balancer listen addresses: %w", err)
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -2516,24 +2621,26 @@ func (n *bridge) getExternalSubnetInUse() ([]externalSubnetUsage, error) {
 	externalSubnets = append(externalSubnets, bridgeNetworkExternalSubnets...)
 	externalSubnets = append(externalSubnets, bridgedNICExternalRoutes...)
 
-	// Add forward listen addresses to this list.
-	for projectName, networks := range projectNetworksForwardsOnUplink {
-		for networkID, listenAddresses := range networks {
-			for _, listenAddress := range listenAddresses {
-				// Convert listen address to subnet.
-				listenAddressNet, err := ParseIPToNet(listenAddress)
-				if err != nil {
-					return nil, fmt.Errorf("Invalid existing forward listen address %q", listenAddress)
-				}
+	// Add forward and load balancer listen addresses to this list.
+	for _, projectNetworksListenAddresses := range []map[string]map[int64][]string{projectNetworksForwardsOnUplink, projectNetworksLoadBalancersOnUplink} {
+		for projectName, networks := range projectNetworksListenAddresses {
+			for networkID, listenAddresses := range networks {
+				for _, listenAddress := range listenAddresses {
+					// Convert listen address to subnet.
+					listenAddressNet, err := ParseIPToNet(listenAddress)
+					if err != nil {
+						return nil, fmt.Errorf("Invalid existing listen address %q", listenAddress)
+					}
 
-				// Create an externalSubnetUsage for the listen address by using the network ID
-				// of the listen address to retrieve the already loaded network name from the
-				// projectNetworks map.
-				externalSubnets = append(externalSubnets, externalSubnetUsage{
-					subnet:         *listenAddressNet,
-					networkProject: projectName,
-					networkName:    projectNetworks[projectName][networkID].Name,
-				})
+					// Create an externalSubnetUsage for the listen address by using the network ID
+					// of the listen address to retrieve the already loaded network name from the
+					// projectNetworks map.
+					externalSubnets = append(externalSubnets, externalSubnetUsage{
+						subnet:         *listenAddressNet,
+						networkProject: projectName,
+						networkName:    projectNetworks[projectName][networkID].Name,
+					})
+				}
 			}
 		}
 	}
@@ -2551,6 +2658,12 @@ func (n *bridge) ForwardCreate(forward api.NetworkForwardsPost, clientType reque
 		return api.StatusErrorf(http.StatusConflict, "A forward for that listen address already exists")
 	}
 
+	// Check if there is an existing load balancer using the same listen address.
+	_, _, err = n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, forward.ListenAddress)
+	if err == nil {
+		return api.StatusErrorf(http.StatusConflict, "A load balancer for that listen address already exists")
+	}
+
 	// Convert listen address to subnet so we can check its valid and can be used.
 	listenAddressNet, err := ParseIPToNet(forward.ListenAddress)
 	if err != nil {
@@ -2626,75 +2739,89 @@ func (n *bridge) ForwardCreate(forward api.NetworkForwardsPost, clientType reque
 				return fmt.Errorf("Failed loading network forwards: %w", err)
 			}
 
-			// If we are the first forward on this bridge, enable hairpin mode on active NIC ports.
-			if len(listenAddresses) <= 1 {
-				var localNode string
-
-				err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-					localNode, err = tx.GetLocalNodeName()
-					if err != nil {
-						return fmt.Errorf("Failed to get local member name: %w", err)
-					}
+			loadBalancerListenAddresses, err := n.state.DB.Cluster.GetNetworkLoadBalancerListenAddresses(n.ID(), true)
+			if err != nil {
+				return fmt.Errorf("Failed loading network load balancers: %w", err)
+			}
 
-					return err
-				})
+			// If we are the first forward on this bridge, enable hairpin mode on active NIC ports.
+			if len(listenAddresses) <= 1 && len(loadBalancerListenAddresses) == 0 {
+				err = n.enableHairpinMode()
 				if err != nil {
 					return err
 				}
+			}
+		}
+	}
 
-				filter := dbCluster.InstanceFilter{
-					Node: &localNode,
-				}
+	// Refresh exported BGP prefixes on local member.
+	err = n.forwardBGPSetupPrefixes()
+	if err != nil {
+		return fmt.Errorf("Failed applying BGP prefixes for address forwards: %w", err)
+	}
 
-				err = n.state.DB.Cluster.InstanceList(&filter, func(inst db.InstanceArgs, p api.Project, profiles []api.Profile) error {
-					// Get the instance's effective network project name.
-					instNetworkProject := project.NetworkProjectFromRecord(&p)
+	revert.Success()
+	return nil
+}
 
-					if instNetworkProject != project.Default {
-						return nil // Managed bridge networks can only exist in default project.
-					}
-					devices := db.ExpandInstanceDevices(inst.Devices.Clone(), profiles)
+// enableHairpinMode enables hairpin mode on the bridge ports of the active NICs connected to the network on the
+// local member. This allows instances targeted by forwards or load balancers to connect to their listen addresses.
+func (n *bridge) enableHairpinMode() error {
+	var err error
+	var localNode string
 
-					// Iterate through each of the instance's devices, looking for bridged NICs
-					// that are linked to this network.
-					for devName, devConfig := range devices {
-						if devConfig["type"] != "nic" {
-							continue
-						}
+	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		localNode, err = tx.GetLocalNodeName()
+		if err != nil {
+			return fmt.Errorf("Failed to get local member name: %w", err)
+		}
 
-						// Check whether the NIC device references our network..
-						if !NICUsesNetwork(devConfig, &api.Network{Name: n.Name()}) {
-							continue
-						}
+		return err
+	})
+	if err != nil {
+		return err
+	}
 
-						hostName := inst.Config[fmt.Sprintf("volatile.%s.host_name", devName)]
-						if InterfaceExists(hostName) {
-							link := &ip.Link{Name: hostName}
-							err = link.BridgeLinkSetHairpin(true)
-							if err != nil {
-								return fmt.Errorf("Error enabling hairpin mode on bridge port %q: %w", link.Name, err)
-							}
-							n.logger.Debug("Enabled hairpin mode on NIC bridge port", logger.Ctx{"inst": inst.Name, "project": inst.Project, "device": devName, "dev": link.Name})
-						}
-					}
+	filter := dbCluster.InstanceFilter{
+		Node: &localNode,
+	}
+
+	err = n.state.DB.Cluster.InstanceList(&filter, func(inst db.InstanceArgs, p api.Project, profiles []api.Profile) error {
+		// Get the instance's effective network project name.
+		instNetworkProject := project.NetworkProjectFromRecord(&p)
+
+		if instNetworkProject != project.Default {
+			return nil // Managed bridge networks can only exist in default project.
+		}
+		devices := db.ExpandInstanceDevices(inst.Devices.Clone(), profiles)
+
+		// Iterate through each of the instance's devices, looking for bridged NICs
+		// that are linked to this network.
+		for devName, devConfig := range devices {
+			if devConfig["type"] != "nic" {
+				continue
+			}
+
+			// Check whether the NIC device references our network..
+			if !NICUsesNetwork(devConfig, &api.Network{Name: n.Name()}) {
+				continue
+			}
 
-					return nil
-				})
+			hostName := inst.Config[fmt.Sprintf("volatile.%s.host_name", devName)]
+			if InterfaceExists(hostName) {
+				link := &ip.Link{Name: hostName}
+				err = link.BridgeLinkSetHairpin(true)
 				if err != nil {
-					return err
+					return fmt.Errorf("Error enabling hairpin mode on bridge port %q: %w", link.Name, err)
 				}
+				n.logger.Debug("Enabled hairpin mode on NIC bridge port", logger.Ctx{"inst": inst.Name, "project": inst.Project, "device": devName, "dev": link.Name})
 			}
 		}
-	}
 
-	// Refresh exported BGP prefixes on local member.
-	err = n.forwardBGPSetupPrefixes()
-	if err != nil {
-		return fmt.Errorf("Failed applying BGP prefixes for address forwards: %w", err)
-	}
+		return nil
+	})
 
-	revert.Success()
-	return nil
+	return err
 }
 
 // ForwardUpdate updates a network forward.
@@ -2858,136 +2985,664 @@ func (n *bridge) forwardSetupFirewall() error {
 	return nil
 }
 
-// Leases returns a list of leases for the bridged network. It will reach out to other cluster members as needed.
-// The projectName passed here refers to the initial project from the API request which may differ from the network's project.
-func (n *bridge) Leases(projectName string, clientType request.ClientType) ([]api.NetworkLease, error) {
-	leases := []api.NetworkLease{}
-	projectMacs := []string{}
+// loadBalancerBackendHealth tracks the health check results of a bridge load balancer backend.
+type loadBalancerBackendHealth struct {
+	healthy   bool
+	failures  int
+	successes int
+}
 
-	// Get all static leases.
-	if clientType == request.ClientTypeNormal {
-		// Get the downstream networks.
-		if n.project == project.Default {
-			var err error
+// loadBalancerHealth holds the health of the bridge load balancer backends on this member, keyed on network ID,
+// listen address and backend name.
+var loadBalancerHealth = make(map[string]*loadBalancerBackendHealth)
 
-			// Load all the networks.
-			var projectNetworks map[string]map[int64]api.Network
-			err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-				projectNetworks, err = tx.GetCreatedNetworks()
-				return err
-			})
-			if err != nil {
-				return nil, err
-			}
+// loadBalancerHealthChecked holds the time of the last health check of the bridge load balancers on this member,
+// keyed on network ID and listen address.
+var loadBalancerHealthChecked = make(map[string]time.Time)
 
-			// Look for networks using the current network as an uplink.
-			for projectName, networks := range projectNetworks {
-				for _, network := range networks {
-					if network.Config["network"] != n.name {
-						continue
-					}
+var loadBalancerHealthMu sync.Mutex
 
-					// Found a network, add leases.
-					for _, k := range []string{"volatile.network.ipv4.address", "volatile.network.ipv6.address"} {
-						v := network.Config[k]
-						if v != "" {
-							leases = append(leases, api.NetworkLease{
-								Hostname: fmt.Sprintf("%s-%s.uplink", projectName, network.Name),
-								Address:  v,
-								Type:     "uplink",
-							})
-						}
-					}
-				}
-			}
-		}
+// loadBalancerHealthKey returns the key used to track the health of a load balancer or one of its backends.
+func loadBalancerHealthKey(networkID int64, listenAddress string, backendName string) string {
+	return fmt.Sprintf("%d/%s/%s", networkID, listenAddress, backendName)
+}
 
-		// Get all the instances.
-		instances, err := instance.LoadByProject(n.state, projectName)
-		if err != nil {
-			return nil, err
-		}
+// loadBalancerConfigRules returns the load balancer config keys supported by bridge networks.
+func (n *bridge) loadBalancerConfigRules() map[string]func(value string) error {
+	return map[string]func(value string) error{
+		"healthcheck":               validate.Optional(validate.IsBool),
+		"healthcheck.interval":      validate.Optional(validate.IsInRange(1, 3600)),
+		"healthcheck.timeout":       validate.Optional(validate.IsInRange(1, 3600)),
+		"healthcheck.failure_count": validate.Optional(validate.IsInRange(1, 100)),
+		"healthcheck.success_count": validate.Optional(validate.IsInRange(1, 100)),
+	}
+}
 
-		for _, inst := range instances {
-			// Go through all its devices (including profiles).
-			for k, dev := range inst.ExpandedDevices() {
-				// Skip uninteresting entries.
-				if dev["type"] != "nic" {
-					continue
-				}
+// loadBalancerBackendHealthy returns whether the backend of the load balancer passes its health checks.
+// Backends are considered healthy when health checks are disabled or haven't failed yet.
+func (n *bridge) loadBalancerBackendHealthy(loadBalancer *api.NetworkLoadBalancer, backendName string) bool {
+	if shared.IsFalseOrEmpty(loadBalancer.Config["healthcheck"]) {
+		return true
+	}
 
-				nicType, err := nictype.NICType(n.state, inst.Project(), dev)
-				if err != nil || nicType != "bridged" {
-					continue
-				}
+	loadBalancerHealthMu.Lock()
+	defer loadBalancerHealthMu.Unlock()
 
-				// Temporarily populate parent from network setting if used.
-				if dev["network"] != "" {
-					dev["parent"] = dev["network"]
-				}
+	health, found := loadBalancerHealth[loadBalancerHealthKey(n.id, loadBalancer.ListenAddress, backendName)]
+	if !found {
+		return true
+	}
 
-				if dev["parent"] != n.name {
-					continue
-				}
+	return health.healthy
+}
 
-				// Fill in the hwaddr from volatile.
-				if dev["hwaddr"] == "" {
-					dev["hwaddr"] = inst.LocalConfig()[fmt.Sprintf("volatile.%s.hwaddr", k)]
-				}
+// loadBalancerConvertToFirewallLoadBalancers converts load balancers into format compatible with the firewall
+// package, leaving out the backends that fail their health checks.
+func (n *bridge) loadBalancerConvertToFirewallLoadBalancers(loadBalancer *api.NetworkLoadBalancer, listenAddress net.IP, portMaps []*loadBalancerPortMap) []firewallDrivers.LoadBalancer {
+	var rules []firewallDrivers.LoadBalancer
 
-				// Record the MAC.
-				if dev["hwaddr"] != "" {
-					projectMacs = append(projectMacs, dev["hwaddr"])
-				}
+	for _, portMap := range portMaps {
+		rule := firewallDrivers.LoadBalancer{
+			ListenAddress: listenAddress,
+			Protocol:      portMap.protocol,
+			ListenPorts:   portMap.listenPorts,
+		}
 
-				// Add the lease.
-				if dev["ipv4.address"] != "" {
-					leases = append(leases, api.NetworkLease{
-						Hostname: inst.Name(),
-						Address:  dev["ipv4.address"],
-						Hwaddr:   dev["hwaddr"],
-						Type:     "static",
-						Location: inst.Location(),
-					})
-				}
+		for _, target := range portMap.targets {
+			if !n.loadBalancerBackendHealthy(loadBalancer, target.backendName) {
+				continue
+			}
 
-				if dev["ipv6.address"] != "" {
-					leases = append(leases, api.NetworkLease{
-						Hostname: inst.Name(),
-						Address:  dev["ipv6.address"],
-						Hwaddr:   dev["hwaddr"],
-						Type:     "static",
-						Location: inst.Location(),
-					})
-				}
+			rule.Backends = append(rule.Backends, firewallDrivers.LoadBalancerBackend{
+				TargetAddress: target.address,
+				TargetPorts:   target.ports,
+			})
+		}
 
-				// Add EUI64 records.
-				ipv6Address := n.config["ipv6.address"]
-				if ipv6Address != "" && ipv6Address != "none" && shared.IsFalseOrEmpty(n.config["ipv6.dhcp.stateful"]) {
-					_, netAddress, _ := net.ParseCIDR(ipv6Address)
-					hwAddr, _ := net.ParseMAC(dev["hwaddr"])
-					if netAddress != nil && hwAddr != nil {
-						ipv6, err := eui64.ParseMAC(netAddress.IP, hwAddr)
-						if err == nil {
-							leases = append(leases, api.NetworkLease{
-								Hostname: inst.Name(),
-								Address:  ipv6.String(),
-								Hwaddr:   dev["hwaddr"],
-								Type:     "dynamic",
-								Location: inst.Location(),
-							})
-						}
-					}
-				}
-			}
+		// Without healthy backends there is nothing to forward the listen ports to.
+		if len(rule.Backends) <= 0 {
+			continue
 		}
+
+		rules = append(rules, rule)
 	}
 
-	// Local server name.
-	var err error
-	var serverName string
-	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		serverName, err = tx.GetLocalNodeName()
+	return rules
+}
+
+// LoadBalancerCreate creates a network load balancer.
+func (n *bridge) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error {
+	memberSpecific := true // bridge supports per-member load balancers.
+
+	// Check if there is an existing load balancer or forward using the same listen address.
+	_, _, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, loadBalancer.ListenAddress)
+	if err == nil {
+		return api.StatusErrorf(http.StatusConflict, "A load balancer for that listen address already exists")
+	}
+
+	_, _, err = n.state.DB.Cluster.GetNetworkForward(n.ID(), memberSpecific, loadBalancer.ListenAddress)
+	if err == nil {
+		return api.StatusErrorf(http.StatusConflict, "A forward for that listen address already exists")
+	}
+
+	// Convert listen address to subnet so we can check its valid and can be used.
+	listenAddressNet, err := ParseIPToNet(loadBalancer.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("Failed parsing load balancer listen address %q: %w", loadBalancer.ListenAddress, err)
+	}
+
+	_, err = n.loadBalancerValidate(listenAddressNet.IP, &loadBalancer.NetworkLoadBalancerPut, n.loadBalancerConfigRules())
+	if err != nil {
+		return err
+	}
+
+	externalSubnetsInUse, err := n.getExternalSubnetInUse()
+	if err != nil {
+		return err
+	}
+
+	// Check the listen address subnet doesn't fall within any existing network external subnets.
+	for _, externalSubnetUser := range externalSubnetsInUse {
+		// Skip our own network's SNAT address (as it can be used for NICs in the network).
+		if externalSubnetUser.networkSNAT && externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name {
+			continue
+		}
+
+		// Skip our own network (but not NIC devices on our own network).
+		if externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name && externalSubnetUser.instanceDevice == "" {
+			continue
+		}
+
+		if SubnetContains(&externalSubnetUser.subnet, listenAddressNet) || SubnetContains(listenAddressNet, &externalSubnetUser.subnet) {
+			// This error is purposefully vague so that it doesn't reveal any names of
+			// resources potentially outside of the network.
+			return fmt.Errorf("Load balancer listen address %q overlaps with another network or NIC", listenAddressNet.String())
+		}
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create load balancer DB record.
+	loadBalancerID, err := n.state.DB.Cluster.CreateNetworkLoadBalancer(n.ID(), memberSpecific, &loadBalancer)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_ = n.state.DB.Cluster.DeleteNetworkLoadBalancer(n.ID(), loadBalancerID)
+		_ = n.loadBalancerSetupFirewall()
+		_ = n.forwardBGPSetupPrefixes()
+	})
+
+	err = n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	// Check if hairpin mode needs to be enabled on active NIC bridge ports.
+	if n.config["bridge.driver"] != "openvswitch" {
+		brNetfilterEnabled := false
+		for _, ipVersion := range []uint{4, 6} {
+			if BridgeNetfilterEnabled(ipVersion) == nil {
+				brNetfilterEnabled = true
+				break
+			}
+		}
+
+		// If br_netfilter is enabled, we enable hairpin mode on each NIC's bridge port in case a backend of
+		// the load balancer attempts to connect to the load balancer's listener.
+		if brNetfilterEnabled {
+			fwdListenAddresses, err := n.state.DB.Cluster.GetNetworkForwardListenAddresses(n.ID(), true)
+			if err != nil {
+				return fmt.Errorf("Failed loading network forwards: %w", err)
+			}
+
+			listenAddresses, err := n.state.DB.Cluster.GetNetworkLoadBalancerListenAddresses(n.ID(), true)
+			if err != nil {
+				return fmt.Errorf("Failed loading network load balancers: %w", err)
+			}
+
+			// If we are the first forward or load balancer on this bridge, enable hairpin mode on active
+			// NIC ports.
+			if len(listenAddresses) <= 1 && len(fwdListenAddresses) == 0 {
+				err = n.enableHairpinMode()
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Refresh exported BGP prefixes on local member.
+	err = n.forwardBGPSetupPrefixes()
+	if err != nil {
+		return fmt.Errorf("Failed applying BGP prefixes for load balancers: %w", err)
+	}
+
+	revert.Success()
+	return nil
+}
+
+// LoadBalancerUpdate updates a network load balancer.
+func (n *bridge) LoadBalancerUpdate(listenAddress string, req api.NetworkLoadBalancerPut, clientType request.ClientType) error {
+	memberSpecific := true // bridge supports per-member load balancers.
+	curLoadBalancerID, curLoadBalancer, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	_, err = n.loadBalancerValidate(net.ParseIP(curLoadBalancer.ListenAddress), &req, n.loadBalancerConfigRules())
+	if err != nil {
+		return err
+	}
+
+	curLoadBalancerEtagHash, err := util.EtagHash(curLoadBalancer.Etag())
+	if err != nil {
+		return err
+	}
+
+	newLoadBalancer := api.NetworkLoadBalancer{
+		ListenAddress:          curLoadBalancer.ListenAddress,
+		NetworkLoadBalancerPut: req,
+	}
+
+	newLoadBalancerEtagHash, err := util.EtagHash(newLoadBalancer.Etag())
+	if err != nil {
+		return err
+	}
+
+	if curLoadBalancerEtagHash == newLoadBalancerEtagHash {
+		return nil // Nothing has changed.
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = n.state.DB.Cluster.UpdateNetworkLoadBalancer(n.ID(), curLoadBalancerID, &newLoadBalancer.NetworkLoadBalancerPut)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_ = n.state.DB.Cluster.UpdateNetworkLoadBalancer(n.ID(), curLoadBalancerID, &curLoadBalancer.NetworkLoadBalancerPut)
+		_ = n.loadBalancerSetupFirewall()
+	})
+
+	// Forget the health of the backends so that changed backends get checked from scratch.
+	n.loadBalancerHealthClear(curLoadBalancer.ListenAddress)
+
+	err = n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// LoadBalancerDelete deletes a network load balancer.
+func (n *bridge) LoadBalancerDelete(listenAddress string, clientType request.ClientType) error {
+	memberSpecific := true // bridge supports per-member load balancers.
+	loadBalancerID, loadBalancer, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = n.state.DB.Cluster.DeleteNetworkLoadBalancer(n.ID(), loadBalancerID)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		newLoadBalancer := api.NetworkLoadBalancersPost{
+			NetworkLoadBalancerPut: loadBalancer.NetworkLoadBalancerPut,
+			ListenAddress:          loadBalancer.ListenAddress,
+		}
+		_, _ = n.state.DB.Cluster.CreateNetworkLoadBalancer(n.ID(), memberSpecific, &newLoadBalancer)
+		_ = n.loadBalancerSetupFirewall()
+		_ = n.forwardBGPSetupPrefixes()
+	})
+
+	err = n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	// Refresh exported BGP prefixes on local member.
+	err = n.forwardBGPSetupPrefixes()
+	if err != nil {
+		return fmt.Errorf("Failed applying BGP prefixes for load balancers: %w", err)
+	}
+
+	n.loadBalancerHealthClear(loadBalancer.ListenAddress)
+
+	revert.Success()
+	return nil
+}
+
+// LoadBalancerState returns the health of the load balancer backends.
+func (n *bridge) LoadBalancerState(listenAddress string) (*api.NetworkLoadBalancerState, error) {
+	memberSpecific := true // bridge supports per-member load balancers.
+	_, loadBalancer, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	state := api.NetworkLoadBalancerState{
+		BackendHealth: make(map[string]api.NetworkLoadBalancerStateBackendHealth, len(loadBalancer.Backends)),
+	}
+
+	for _, backend := range loadBalancer.Backends {
+		state.BackendHealth[backend.Name] = api.NetworkLoadBalancerStateBackendHealth{
+			Address: backend.TargetAddress,
+			Healthy: n.loadBalancerBackendHealthy(loadBalancer, backend.Name),
+		}
+	}
+
+	return &state, nil
+}
+
+// loadBalancerHealthClear forgets the health of the load balancer's backends.
+func (n *bridge) loadBalancerHealthClear(listenAddress string) {
+	loadBalancerHealthMu.Lock()
+	defer loadBalancerHealthMu.Unlock()
+
+	prefix := loadBalancerHealthKey(n.id, listenAddress, "")
+	for key := range loadBalancerHealth {
+		if strings.HasPrefix(key, prefix) {
+			delete(loadBalancerHealth, key)
+		}
+	}
+
+	delete(loadBalancerHealthChecked, prefix)
+}
+
+// LoadBalancerHealthCheck checks the health of the backends of the load balancers on this member that have
+// health checks enabled and whose check interval has elapsed. The firewall rules are refreshed when a backend
+// changes state.
+func (n *bridge) LoadBalancerHealthCheck() error {
+	memberSpecific := true // Get all load balancers for this cluster member.
+	loadBalancers, err := n.state.DB.Cluster.GetNetworkLoadBalancers(n.ID(), memberSpecific)
+	if err != nil {
+		return fmt.Errorf("Failed loading network load balancers: %w", err)
+	}
+
+	changed := false
+
+	for _, loadBalancer := range loadBalancers {
+		if shared.IsFalseOrEmpty(loadBalancer.Config["healthcheck"]) {
+			continue
+		}
+
+		interval := time.Duration(loadBalancerConfigInt(loadBalancer.Config, "healthcheck.interval", 10)) * time.Second
+		checkKey := loadBalancerHealthKey(n.id, loadBalancer.ListenAddress, "")
+
+		loadBalancerHealthMu.Lock()
+		lastChecked := loadBalancerHealthChecked[checkKey]
+		loadBalancerHealthMu.Unlock()
+
+		if time.Since(lastChecked) < interval {
+			continue
+		}
+
+		lbChanged, err := n.loadBalancerCheckBackends(loadBalancer)
+		if err != nil {
+			n.logger.Warn("Failed checking load balancer backends", logger.Ctx{"listenAddress": loadBalancer.ListenAddress, "err": err})
+			continue
+		}
+
+		loadBalancerHealthMu.Lock()
+		loadBalancerHealthChecked[checkKey] = time.Now()
+		loadBalancerHealthMu.Unlock()
+
+		if lbChanged {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return n.loadBalancerSetupFirewall()
+}
+
+// loadBalancerCheckBackends probes each backend of the load balancer by opening a TCP connection to the first
+// port it serves, and updates the health of the backends accordingly. Backends that only serve UDP ports can't
+// be probed and are left healthy. Returns whether any backend changed state.
+func (n *bridge) loadBalancerCheckBackends(loadBalancer *api.NetworkLoadBalancer) (bool, error) {
+	listenAddressNet, err := ParseIPToNet(loadBalancer.ListenAddress)
+	if err != nil {
+		return false, fmt.Errorf("Failed parsing load balancer listen address %q: %w", loadBalancer.ListenAddress, err)
+	}
+
+	portMaps, err := n.loadBalancerValidate(listenAddressNet.IP, &loadBalancer.NetworkLoadBalancerPut, n.loadBalancerConfigRules())
+	if err != nil {
+		return false, err
+	}
+
+	timeout := time.Duration(loadBalancerConfigInt(loadBalancer.Config, "healthcheck.timeout", 5)) * time.Second
+	failureCount := loadBalancerConfigInt(loadBalancer.Config, "healthcheck.failure_count", 3)
+	successCount := loadBalancerConfigInt(loadBalancer.Config, "healthcheck.success_count", 2)
+
+	// Work out the address to probe for each backend.
+	probes := make(map[string]string)
+	for _, portMap := range portMaps {
+		if portMap.protocol != "tcp" {
+			continue
+		}
+
+		for _, target := range portMap.targets {
+			if _, found := probes[target.backendName]; found {
+				continue
+			}
+
+			port := portMap.listenPorts[0]
+			if len(target.ports) > 0 {
+				port = target.ports[0]
+			}
+
+			probes[target.backendName] = net.JoinHostPort(target.address.String(), fmt.Sprintf("%d", port))
+		}
+	}
+
+	// Probe the backends concurrently so that slow backends don't delay the others.
+	results := make(map[string]bool, len(probes))
+	resultsMu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for backendName, address := range probes {
+		wg.Add(1)
+		go func(backendName string, address string) {
+			defer wg.Done()
+
+			conn, err := net.DialTimeout("tcp", address, timeout)
+			if err == nil {
+				_ = conn.Close()
+			}
+
+			resultsMu.Lock()
+			results[backendName] = err == nil
+			resultsMu.Unlock()
+		}(backendName, address)
+	}
+
+	wg.Wait()
+
+	loadBalancerHealthMu.Lock()
+	defer loadBalancerHealthMu.Unlock()
+
+	changed := false
+	for backendName, success := range results {
+		key := loadBalancerHealthKey(n.id, loadBalancer.ListenAddress, backendName)
+
+		health, found := loadBalancerHealth[key]
+		if !found {
+			health = &loadBalancerBackendHealth{healthy: true}
+			loadBalancerHealth[key] = health
+		}
+
+		if success {
+			health.failures = 0
+			health.successes++
+
+			if !health.healthy && health.successes >= successCount {
+				health.healthy = true
+				changed = true
+				n.logger.Info("Load balancer backend is healthy", logger.Ctx{"listenAddress": loadBalancer.ListenAddress, "backend": backendName})
+			}
+		} else {
+			health.successes = 0
+			health.failures++
+
+			if health.healthy && health.failures >= failureCount {
+				health.healthy = false
+				changed = true
+				n.logger.Warn("Load balancer backend failed health checks", logger.Ctx{"listenAddress": loadBalancer.ListenAddress, "backend": backendName})
+			}
+		}
+	}
+
+	return changed, nil
+}
+
+// loadBalancerSetupFirewall applies all network load balancers defined for this network and this member.
+func (n *bridge) loadBalancerSetupFirewall() error {
+	memberSpecific := true // Get all load balancers for this cluster member.
+	loadBalancers, err := n.state.DB.Cluster.GetNetworkLoadBalancers(n.ID(), memberSpecific)
+	if err != nil {
+		return fmt.Errorf("Failed loading network load balancers: %w", err)
+	}
+
+	var fwLoadBalancers []firewallDrivers.LoadBalancer
+
+	for _, loadBalancer := range loadBalancers {
+		// Convert listen address to subnet so we can check its valid and can be used.
+		listenAddressNet, err := ParseIPToNet(loadBalancer.ListenAddress)
+		if err != nil {
+			return fmt.Errorf("Failed parsing load balancer listen address %q: %w", loadBalancer.ListenAddress, err)
+		}
+
+		portMaps, err := n.loadBalancerValidate(listenAddressNet.IP, &loadBalancer.NetworkLoadBalancerPut, n.loadBalancerConfigRules())
+		if err != nil {
+			return fmt.Errorf("Failed validating firewall load balancer for listen address %q: %w", loadBalancer.ListenAddress, err)
+		}
+
+		fwLoadBalancers = append(fwLoadBalancers, n.loadBalancerConvertToFirewallLoadBalancers(loadBalancer, listenAddressNet.IP, portMaps)...)
+	}
+
+	err = n.state.Firewall.NetworkApplyLoadBalancers(n.name, fwLoadBalancers)
+	if err != nil {
+		return fmt.Errorf("Failed applying firewall load balancers: %w", err)
+	}
+
+	return nil
+}
+
+// loadBalancerConfigInt returns the integer value of a load balancer config key, or the default if unset.
+func loadBalancerConfigInt(config map[string]string, key string, defaultValue int) int {
+	value, err := strconv.Atoi(config[key])
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// Leases returns a list of leases for the bridged network. It will reach out to other cluster members as needed.
+// The projectName passed here refers to the initial project from the API request which may differ from the network's project.
+func (n *bridge) Leases(projectName string, clientType request.ClientType) ([]api.NetworkLease, error) {
+	leases := []api.NetworkLease{}
+	projectMacs := []string{}
+
+	// Get all static leases.
+	if clientType == request.ClientTypeNormal {
+		// Get the downstream networks.
+		if n.project == project.Default {
+			var err error
+
+			// Load all the networks.
+			var projectNetworks map[string]map[int64]api.Network
+			err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+				projectNetworks, err = tx.GetCreatedNetworks()
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			// Look for networks using the current network as an uplink.
+			for projectName, networks := range projectNetworks {
+				for _, network := range networks {
+					if network.Config["network"] != n.name {
+						continue
+					}
+
+					// Found a network, add leases.
+					for _, k := range []string{"volatile.network.ipv4.address", "volatile.network.ipv6.address"} {
+						v := network.Config[k]
+						if v != "" {
+							leases = append(leases, api.NetworkLease{
+								Hostname: fmt.Sprintf("%s-%s.uplink", projectName, network.Name),
+								Address:  v,
+								Type:     "uplink",
+							})
+						}
+					}
+				}
+			}
+		}
+
+		// Get all the instances.
+		instances, err := instance.LoadByProject(n.state, projectName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, inst := range instances {
+			// Go through all its devices (including profiles).
+			for k, dev := range inst.ExpandedDevices() {
+				// Skip uninteresting entries.
+				if dev["type"] != "nic" {
+					continue
+				}
+
+				nicType, err := nictype.NICType(n.state, inst.Project(), dev)
+				if err != nil || nicType != "bridged" {
+					continue
+				}
+
+				// Temporarily populate parent from network setting if used.
+				if dev["network"] != "" {
+					dev["parent"] = dev["network"]
+				}
+
+				if dev["parent"] != n.name {
+					continue
+				}
+
+				// Fill in the hwaddr from volatile.
+				if dev["hwaddr"] == "" {
+					dev["hwaddr"] = inst.LocalConfig()[fmt.Sprintf("volatile.%s.hwaddr", k)]
+				}
+
+				// Record the MAC.
+				if dev["hwaddr"] != "" {
+					projectMacs = append(projectMacs, dev["hwaddr"])
+				}
+
+				// Add the lease.
+				if dev["ipv4.address"] != "" {
+					leases = append(leases, api.NetworkLease{
+						Hostname: inst.Name(),
+						Address:  dev["ipv4.address"],
+						Hwaddr:   dev["hwaddr"],
+						Type:     "static",
+						Location: inst.Location(),
+					})
+				}
+
+				if dev["ipv6.address"] != "" {
+					leases = append(leases, api.NetworkLease{
+						Hostname: inst.Name(),
+						Address:  dev["ipv6.address"],
+						Hwaddr:   dev["hwaddr"],
+						Type:     "static",
+						Location: inst.Location(),
+					})
+				}
+
+				// Add EUI64 records.
+				ipv6Address := n.config["ipv6.address"]
+				if ipv6Address != "" && ipv6Address != "none" && shared.IsFalseOrEmpty(n.config["ipv6.dhcp.stateful"]) {
+					_, netAddress, _ := net.ParseCIDR(ipv6Address)
+					hwAddr, _ := net.ParseMAC(dev["hwaddr"])
+					if netAddress != nil && hwAddr != nil {
+						ipv6, err := eui64.ParseMAC(netAddress.IP, hwAddr)
+						if err == nil {
+							leases = append(leases, api.NetworkLease{
+								Hostname: inst.Name(),
+								Address:  ipv6.String(),
+								Hwaddr:   dev["hwaddr"],
+								Type:     "dynamic",
+								Location: inst.Location(),
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Local server name.
+	var err error
+	var serverName string
+	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		serverName, err = tx.GetLocalNodeName()
 		return err
 	})
 	if err != nil {
@@ -3000,90 +3655,594 @@ func (n *bridge) Leases(projectName string, clientType request.ClientType) ([]ap
 		return leases, nil
 	}
 
-	content, err := ioutil.ReadFile(leaseFile)
-	if err != nil {
-		return nil, err
-	}
+	content, err := ioutil.ReadFile(leaseFile)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lease := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(lease)
+		if len(fields) >= 5 {
+			// Parse the MAC.
+			mac := GetMACSlice(fields[1])
+			macStr := strings.Join(mac, ":")
+
+			if len(macStr) < 17 && fields[4] != "" {
+				macStr = fields[4][len(fields[4])-17:]
+			}
+
+			// Look for an existing static entry.
+			found := false
+			for _, entry := range leases {
+				if entry.Hwaddr == macStr && entry.Address == fields[2] {
+					found = true
+					break
+				}
+			}
+
+			if found {
+				continue
+			}
+
+			// DHCPv6 leases can't be tracked down to a MAC so clear the field.
+			// This means that instance project filtering will not work on IPv6 leases.
+			if strings.Contains(fields[2], ":") {
+				macStr = ""
+			}
+
+			// Skip leases that don't match any of the instance MACs from the project (only when we
+			// have populated the projectMacs list in ClientTypeNormal mode). Otherwise get all local
+			// leases and they will be filtered on the server handling the end user request.
+			if clientType == request.ClientTypeNormal && macStr != "" && !shared.StringInSlice(macStr, projectMacs) {
+				continue
+			}
+
+			// Add the lease to the list.
+			leases = append(leases, api.NetworkLease{
+				Hostname: fields[3],
+				Address:  fields[2],
+				Hwaddr:   macStr,
+				Type:     "dynamic",
+				Location: serverName,
+			})
+		}
+	}
+
+	// Collect leases from other servers.
+	if clientType == request.ClientTypeNormal {
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return nil, err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			memberLeases, err := client.GetNetworkLeases(n.name)
+			if err != nil {
+				return err
+			}
+
+			// Add local leases from other members, filtering them for MACs that belong to the project.
+			for _, lease := range memberLeases {
+				if lease.Hwaddr != "" && shared.StringInSlice(lease.Hwaddr, projectMacs) {
+					leases = append(leases, lease)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return leases, nil
+}
+
+// UsesDNSMasq indicates if network's config indicates if it needs to use dnsmasq.
+func (n *bridge) UsesDNSMasq() bool {
+	return n.config["bridge.mode"] == "fan" || !shared.StringInSlice(n.config["ipv4.address"], []string{"", "none"}) || !shared.StringInSlice(n.config["ipv6.address"], []string{"", "none"})
+}
+
+// BGPImportRoutes installs the routes learned from the BGP peers of the network into the host routing table and
+// removes the ones which are no longer announced. This runs on every member as the routing table is local.
+func (n *bridge) BGPImportRoutes(clusterLeader bool) error {
+	if !n.isRunning() {
+		return nil
+	}
+
+	routes, err := n.bgpImportedRoutes(n.id, n.config)
+	if err != nil {
+		return err
+	}
+
+	// The routes aren't tied to the bridge as the next hop may be reached through another interface, so the
+	// installed routes belonging to this network are identified by their next hop being one of its peers or
+	// one of the next hops they announced.
+	nextHops := map[string]struct{}{}
+	for _, peer := range n.bgpGetPeers(n.config) {
+		nextHops[peer.address.String()] = struct{}{}
+	}
+
+	for _, route := range routes {
+		nextHops[route.NextHop.String()] = struct{}{}
+	}
+
+	for _, family := range []string{ip.FamilyV4, ip.FamilyV6} {
+		// Build the list of routes which should be installed.
+		wanted := map[string]string{}
+		for _, route := range routes {
+			if (route.Prefix.IP.To4() != nil) != (family == ip.FamilyV4) {
+				continue
+			}
+
+			wanted[route.Prefix.String()] = route.NextHop.String()
+		}
+
+		// Get the currently installed routes.
+		r := &ip.Route{
+			Proto:  "bgp",
+			Family: family,
+		}
+
+		lines, err := r.Show()
+		if err != nil {
+			return fmt.Errorf("Failed listing BGP routes: %w", err)
+		}
+
+		installed := map[string]string{}
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
 
-	for _, lease := range strings.Split(string(content), "\n") {
-		fields := strings.Fields(lease)
-		if len(fields) >= 5 {
-			// Parse the MAC.
-			mac := GetMACSlice(fields[1])
-			macStr := strings.Join(mac, ":")
+			prefix := fields[0]
+			if prefix == "default" {
+				if family == ip.FamilyV4 {
+					prefix = "0.0.0.0/0"
+				} else {
+					prefix = "::/0"
+				}
+			}
 
-			if len(macStr) < 17 && fields[4] != "" {
-				macStr = fields[4][len(fields[4])-17:]
+			if !strings.Contains(prefix, "/") {
+				if family == ip.FamilyV4 {
+					prefix = fmt.Sprintf("%s/32", prefix)
+				} else {
+					prefix = fmt.Sprintf("%s/128", prefix)
+				}
 			}
 
-			// Look for an existing static entry.
-			found := false
-			for _, entry := range leases {
-				if entry.Hwaddr == macStr && entry.Address == fields[2] {
-					found = true
-					break
+			_, subnet, err := net.ParseCIDR(prefix)
+			if err != nil {
+				continue
+			}
+
+			nextHop := ""
+			for i, field := range fields {
+				if field == "via" && i+1 < len(fields) {
+					nextHop = fields[i+1]
 				}
 			}
 
+			_, found := nextHops[nextHop]
+			if !found {
+				continue
+			}
+
+			installed[subnet.String()] = nextHop
+		}
+
+		// Remove the withdrawn routes.
+		for prefix, nextHop := range installed {
+			_, found := wanted[prefix]
 			if found {
 				continue
 			}
 
-			// DHCPv6 leases can't be tracked down to a MAC so clear the field.
-			// This means that instance project filtering will not work on IPv6 leases.
-			if strings.Contains(fields[2], ":") {
-				macStr = ""
+			r := &ip.Route{
+				Route:  prefix,
+				Via:    nextHop,
+				Proto:  "bgp",
+				Family: family,
 			}
 
-			// Skip leases that don't match any of the instance MACs from the project (only when we
-			// have populated the projectMacs list in ClientTypeNormal mode). Otherwise get all local
-			// leases and they will be filtered on the server handling the end user request.
-			if clientType == request.ClientTypeNormal && macStr != "" && !shared.StringInSlice(macStr, projectMacs) {
+			err = r.Flush()
+			if err != nil {
+				return fmt.Errorf("Failed removing BGP route %q: %w", prefix, err)
+			}
+		}
+
+		// Install the new and changed routes.
+		for prefix, nextHop := range wanted {
+			if installed[prefix] == nextHop {
 				continue
 			}
 
-			// Add the lease to the list.
-			leases = append(leases, api.NetworkLease{
-				Hostname: fields[3],
-				Address:  fields[2],
-				Hwaddr:   macStr,
-				Type:     "dynamic",
-				Location: serverName,
-			})
+			err = r.Replace([]string{prefix, "via", nextHop})
+			if err != nil {
+				return fmt.Errorf("Failed installing BGP route %q via %q: %w", prefix, nextHop, err)
+			}
 		}
 	}
 
-	// Collect leases from other servers.
-	if clientType == request.ClientTypeNormal {
-		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+	return nil
+}
+
+// qosClasses returns the QoS classes defined in config sorted by name.
+func (n *bridge) qosClasses(config map[string]string) ([]bridgeQoSClass, error) {
+	classNames := []string{}
+	for k := range config {
+		if !strings.HasPrefix(k, "qos.classes.") {
+			continue
+		}
+
+		fields := strings.Split(k, ".")
+		if len(fields) != 4 || shared.StringInSlice(fields[2], classNames) {
+			continue
+		}
+
+		classNames = append(classNames, fields[2])
+	}
+
+	if len(classNames) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(classNames)
+
+	if config["qos.bandwidth"] == "" {
+		return nil, fmt.Errorf(`"qos.bandwidth" is required when QoS classes are defined`)
+	}
+
+	bandwidth, err := units.ParseBitSizeString(config["qos.bandwidth"])
+	if err != nil {
+		return nil, err
+	}
+
+	var totalRate int64
+	classes := make([]bridgeQoSClass, 0, len(classNames))
+	priorities := map[string]string{}
+	defaultFound := config["qos.default"] == ""
+
+	for i, className := range classNames {
+		prefix := fmt.Sprintf("qos.classes.%s.", className)
+
+		if config[prefix+"rate"] == "" {
+			return nil, fmt.Errorf("%q is required", prefix+"rate")
+		}
+
+		rate, err := units.ParseBitSizeString(config[prefix+"rate"])
 		if err != nil {
 			return nil, err
 		}
 
-		err = notifier(func(client lxd.InstanceServer) error {
-			memberLeases, err := client.GetNetworkLeases(n.name)
+		ceil := bandwidth
+		if config[prefix+"ceil"] != "" {
+			ceil, err = units.ParseBitSizeString(config[prefix+"ceil"])
 			if err != nil {
-				return err
+				return nil, err
 			}
+		}
 
-			// Add local leases from other members, filtering them for MACs that belong to the project.
-			for _, lease := range memberLeases {
-				if lease.Hwaddr != "" && shared.StringInSlice(lease.Hwaddr, projectMacs) {
-					leases = append(leases, lease)
-				}
+		if ceil < rate || ceil > bandwidth {
+			return nil, fmt.Errorf("The ceiling of QoS class %q must be between its rate and %q", className, "qos.bandwidth")
+		}
+
+		priority := config[prefix+"priority"]
+		if priority != "" {
+			otherClass, found := priorities[priority]
+			if found {
+				return nil, fmt.Errorf("QoS classes %q and %q use the same priority %q", otherClass, className, priority)
 			}
 
-			return nil
+			priorities[priority] = className
+		}
+
+		if className == config["qos.default"] {
+			defaultFound = true
+		}
+
+		totalRate += rate
+		classes = append(classes, bridgeQoSClass{
+			name:     className,
+			classID:  fmt.Sprintf("1:%x", 0x10+i),
+			rate:     rate,
+			ceil:     ceil,
+			priority: priority,
 		})
+	}
+
+	if totalRate > bandwidth {
+		return nil, fmt.Errorf(`The sum of the QoS class rates exceeds "qos.bandwidth"`)
+	}
+
+	if !defaultFound {
+		return nil, fmt.Errorf("Default QoS class %q doesn't exist", config["qos.default"])
+	}
+
+	return classes, nil
+}
+
+// qosIfbName returns the name of the device the traffic received from the instances is redirected to for shaping.
+func (n *bridge) qosIfbName() string {
+	return fmt.Sprintf("%s-ifb", n.name)
+}
+
+// qosClear removes the QoS qdiscs from the bridge and deletes its ifb device if they exist.
+func (n *bridge) qosClear() error {
+	ifbName := n.qosIfbName()
+	if !InterfaceExists(ifbName) {
+		return nil
+	}
+
+	if InterfaceExists(n.name) {
+		_ = (&ip.Qdisc{Dev: n.name, Root: true}).Delete()
+		_ = (&ip.Qdisc{Dev: n.name, Ingress: true}).Delete()
+	}
+
+	err := (&ip.Link{Name: ifbName}).Delete()
+	if err != nil {
+		return fmt.Errorf("Failed deleting ifb device %q: %w", ifbName, err)
+	}
+
+	return nil
+}
+
+// qosSetup builds the HTB trees shaping the traffic of the QoS classes. The traffic sent to the instances is
+// shaped on the bridge itself and the traffic received from them is redirected to an ifb device and shaped there.
+func (n *bridge) qosSetup() error {
+	classes, err := n.qosClasses(n.config)
+	if err != nil {
+		return err
+	}
+
+	ifbName := n.qosIfbName()
+
+	// Clear any existing setup.
+	err = n.qosClear()
+	if err != nil {
+		return err
+	}
+
+	if len(classes) == 0 {
+		return nil
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	ifb := &ip.Ifb{Link: ip.Link{Name: ifbName}}
+	err = ifb.Add()
+	if err != nil {
+		return fmt.Errorf("Failed creating ifb device %q: %w", ifbName, err)
+	}
+
+	revert.Add(func() { _ = ifb.Delete() })
+
+	err = ifb.SetUp()
+	if err != nil {
+		return err
+	}
+
+	// Unclassified traffic goes to the default class if any, otherwise it isn't shaped.
+	defaultClass := ""
+	for _, class := range classes {
+		if class.name == n.config["qos.default"] {
+			defaultClass = strings.TrimPrefix(class.classID, "1:")
+		}
+	}
+
+	bandwidth := n.config["qos.bandwidth"]
+	for _, dev := range []string{n.name, ifbName} {
+		qdisc := &ip.QdiscHTB{Qdisc: ip.Qdisc{Dev: dev, Handle: "1:0", Root: true}, Default: defaultClass}
+		err = qdisc.Add()
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("Failed creating root tc qdisc on %q: %w", dev, err)
+		}
+
+		revert.Add(func() { _ = qdisc.Delete() })
+
+		rootClass := &ip.ClassHTB{Class: ip.Class{Dev: dev, Parent: "1:0", Classid: "1:1"}, Rate: bandwidth, Ceil: bandwidth}
+		err = rootClass.Add()
+		if err != nil {
+			return fmt.Errorf("Failed creating root tc class on %q: %w", dev, err)
+		}
+
+		for _, class := range classes {
+			classHTB := &ip.ClassHTB{
+				Class: ip.Class{Dev: dev, Parent: "1:1", Classid: class.classID},
+				Rate:  fmt.Sprintf("%dbit", class.rate),
+				Ceil:  fmt.Sprintf("%dbit", class.ceil),
+			}
+
+			err = classHTB.Add()
+			if err != nil {
+				return fmt.Errorf("Failed creating tc class for QoS class %q on %q: %w", class.name, dev, err)
+			}
 		}
 	}
 
-	return leases, nil
+	// Redirect the traffic received from the instances to the ifb device.
+	ingress := &ip.Qdisc{Dev: n.name, Handle: "ffff:0", Ingress: true}
+	err = ingress.Add()
+	if err != nil {
+		return fmt.Errorf("Failed creating ingress tc qdisc: %w", err)
+	}
+
+	revert.Add(func() { _ = ingress.Delete() })
+
+	redirect := &ip.U32Filter{
+		Filter:  ip.Filter{Dev: n.name, Parent: "ffff:0", Protocol: "all"},
+		Value:   "0",
+		Mask:    "0",
+		Actions: []ip.Action{&ip.ActionMirred{Direction: "egress", Action: "redirect", Dev: ifbName}},
+	}
+
+	err = redirect.Add()
+	if err != nil {
+		return fmt.Errorf("Failed creating ingress redirect tc filter: %w", err)
+	}
+
+	// Classify the traffic of the instance NICs already connected to the network.
+	err = usedByInstanceDevices(n.state, n.project, n.name, func(inst db.InstanceArgs, nicName string, nicConfig map[string]string) error {
+		if inst.Node != n.state.ServerName {
+			return nil
+		}
+
+		hwaddr := nicConfig["hwaddr"]
+		if hwaddr == "" {
+			hwaddr = inst.Config[fmt.Sprintf("volatile.%s.hwaddr", nicName)]
+		}
+
+		if hwaddr == "" {
+			return nil
+		}
+
+		return n.qosNICSetup(classes, hwaddr, nicConfig["limits.priority"])
+	})
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
 }
 
-// UsesDNSMasq indicates if network's config indicates if it needs to use dnsmasq.
-func (n *bridge) UsesDNSMasq() bool {
-	return n.config["bridge.mode"] == "fan" || !shared.StringInSlice(n.config["ipv4.address"], []string{"", "none"}) || !shared.StringInSlice(n.config["ipv6.address"], []string{"", "none"})
+// qosNICFilters returns the filters classifying the traffic of an instance NIC on the bridge and ifb devices.
+func (n *bridge) qosNICFilters(hwaddr string) ([]*ip.FlowerFilter, error) {
+	mac, err := net.ParseMAC(hwaddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mac) != 6 {
+		return nil, fmt.Errorf("Invalid MAC address %q", hwaddr)
+	}
+
+	// The last 4 bytes of the MAC address are used as filter handle so they can be found again.
+	handle := fmt.Sprintf("0x%x", binary.BigEndian.Uint32(mac[2:]))
+
+	filters := []*ip.FlowerFilter{
+		{Filter: ip.Filter{Dev: n.name, Parent: "1:0", Protocol: "all"}, Pref: "10", Handle: handle, DstMAC: mac.String()},
+		{Filter: ip.Filter{Dev: n.qosIfbName(), Parent: "1:0", Protocol: "all"}, Pref: "10", Handle: handle, SrcMAC: mac.String()},
+	}
+
+	return filters, nil
+}
+
+// qosNICSetup classifies the traffic of an instance NIC into the QoS class matching its priority.
+// The traffic of NICs without matching class goes to the default class.
+func (n *bridge) qosNICSetup(classes []bridgeQoSClass, hwaddr string, priority string) error {
+	filters, err := n.qosNICFilters(hwaddr)
+	if err != nil {
+		return err
+	}
+
+	classID := ""
+	if priority != "" {
+		for _, class := range classes {
+			if class.priority == priority {
+				classID = class.classID
+				break
+			}
+		}
+	}
+
+	for _, filter := range filters {
+		if classID == "" {
+			_ = filter.Delete()
+			continue
+		}
+
+		filter.Flowid = classID
+		err = filter.Replace()
+		if err != nil {
+			return fmt.Errorf("Failed classifying traffic of %q on %q: %w", hwaddr, filter.Dev, err)
+		}
+	}
+
+	return nil
+}
+
+// QoSNICSetup classifies the traffic of an instance NIC into the QoS class matching its priority.
+func (n *bridge) QoSNICSetup(hwaddr string, priority string) error {
+	classes, err := n.qosClasses(n.config)
+	if err != nil {
+		return err
+	}
+
+	if len(classes) == 0 || !InterfaceExists(n.qosIfbName()) {
+		return nil
+	}
+
+	return n.qosNICSetup(classes, hwaddr, priority)
+}
+
+// QoSNICClear removes the classification of the traffic of an instance NIC.
+func (n *bridge) QoSNICClear(hwaddr string) error {
+	if !InterfaceExists(n.qosIfbName()) {
+		return nil
+	}
+
+	filters, err := n.qosNICFilters(hwaddr)
+	if err != nil {
+		return err
+	}
+
+	for _, filter := range filters {
+		_ = filter.Delete()
+	}
+
+	return nil
+}
+
+// State returns the network state, including the counters of the QoS classes.
+func (n *bridge) State() (*api.NetworkState, error) {
+	state, err := n.common.State()
+	if err != nil {
+		return nil, err
+	}
+
+	classes, err := n.qosClasses(n.config)
+	if err != nil || len(classes) == 0 || !InterfaceExists(n.qosIfbName()) {
+		return state, nil
+	}
+
+	sentStats, err := ip.GetClassStatistics(n.name)
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting QoS counters: %w", err)
+	}
+
+	receivedStats, err := ip.GetClassStatistics(n.qosIfbName())
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting QoS counters: %w", err)
+	}
+
+	state.QoS = make([]api.NetworkStateQoSClass, 0, len(classes))
+	for _, class := range classes {
+		sent := sentStats[class.classID]
+		received := receivedStats[class.classID]
+
+		state.QoS = append(state.QoS, api.NetworkStateQoSClass{
+			Name:     class.name,
+			Rate:     class.rate,
+			Ceil:     class.ceil,
+			Priority: class.priority,
+			Sent: api.NetworkStateQoSCounters{
+				Bytes:      sent.Bytes,
+				Packets:    sent.Packets,
+				Drops:      sent.Drops,
+				Overlimits: sent.Overlimits,
+			},
+			Received: api.NetworkStateQoSCounters{
+				Bytes:      received.Bytes,
+				Packets:    received.Packets,
+				Drops:      received.Drops,
+				Overlimits: received.Overlimits,
+			},
+		})
+	}
+
+	return state, nil
 }
@@ -0,0 +1,128 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridgeQoSClasses(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]string
+		classes []bridgeQoSClass
+		err     string
+	}{
+		{
+			name:   "no classes",
+			config: map[string]string{"qos.bandwidth": "1Gbit"},
+		},
+		{
+			name:   "no classes nor bandwidth",
+			config: map[string]string{"ipv4.address": "10.0.0.1/24"},
+		},
+		{
+			name: "classes sorted by name",
+			config: map[string]string{
+				"qos.bandwidth":               "1Gbit",
+				"qos.default":                 "bulk",
+				"qos.classes.web.rate":        "300Mbit",
+				"qos.classes.web.ceil":        "800Mbit",
+				"qos.classes.web.priority":    "1",
+				"qos.classes.bulk.rate":       "100Mbit",
+				"qos.classes.bulk.priority":   "7",
+				"qos.classes.backup.rate":     "50Mbit",
+				"qos.classes.backup.ceil":     "50Mbit",
+				"qos.classes.invalid":         "ignored",
+				"qos.classes.web.rate.ignore": "ignored",
+			},
+			classes: []bridgeQoSClass{
+				{name: "backup", classID: "1:10", rate: 50000000, ceil: 50000000},
+				{name: "bulk", classID: "1:11", rate: 100000000, ceil: 1000000000, priority: "7"},
+				{name: "web", classID: "1:12", rate: 300000000, ceil: 800000000, priority: "1"},
+			},
+		},
+		{
+			name: "rates adding up to the bandwidth",
+			config: map[string]string{
+				"qos.bandwidth":      "100Mbit",
+				"qos.classes.a.rate": "60Mbit",
+				"qos.classes.b.rate": "40Mbit",
+			},
+			classes: []bridgeQoSClass{
+				{name: "a", classID: "1:10", rate: 60000000, ceil: 100000000},
+				{name: "b", classID: "1:11", rate: 40000000, ceil: 100000000},
+			},
+		},
+		{
+			name:   "missing bandwidth",
+			config: map[string]string{"qos.classes.web.rate": "10Mbit"},
+			err:    `"qos.bandwidth" is required when QoS classes are defined`,
+		},
+		{
+			name:   "invalid bandwidth",
+			config: map[string]string{"qos.bandwidth": "fast", "qos.classes.web.rate": "10Mbit"},
+			err:    "Invalid value: fast",
+		},
+		{
+			name:   "missing rate",
+			config: map[string]string{"qos.bandwidth": "1Gbit", "qos.classes.web.ceil": "10Mbit"},
+			err:    `"qos.classes.web.rate" is required`,
+		},
+		{
+			name:   "ceiling below rate",
+			config: map[string]string{"qos.bandwidth": "1Gbit", "qos.classes.web.rate": "100Mbit", "qos.classes.web.ceil": "10Mbit"},
+			err:    `The ceiling of QoS class "web" must be between its rate and "qos.bandwidth"`,
+		},
+		{
+			name:   "ceiling above bandwidth",
+			config: map[string]string{"qos.bandwidth": "1Gbit", "qos.classes.web.rate": "100Mbit", "qos.classes.web.ceil": "2Gbit"},
+			err:    `The ceiling of QoS class "web" must be between its rate and "qos.bandwidth"`,
+		},
+		{
+			name: "duplicate priority",
+			config: map[string]string{
+				"qos.bandwidth":             "1Gbit",
+				"qos.classes.bulk.rate":     "100Mbit",
+				"qos.classes.bulk.priority": "3",
+				"qos.classes.web.rate":      "100Mbit",
+				"qos.classes.web.priority":  "3",
+			},
+			err: `QoS classes "bulk" and "web" use the same priority "3"`,
+		},
+		{
+			name: "rates exceeding the bandwidth",
+			config: map[string]string{
+				"qos.bandwidth":         "100Mbit",
+				"qos.classes.bulk.rate": "60Mbit",
+				"qos.classes.web.rate":  "50Mbit",
+			},
+			err: `The sum of the QoS class rates exceeds "qos.bandwidth"`,
+		},
+		{
+			name: "unknown default class",
+			config: map[string]string{
+				"qos.bandwidth":        "1Gbit",
+				"qos.default":          "bulk",
+				"qos.classes.web.rate": "100Mbit",
+			},
+			err: `Default QoS class "bulk" doesn't exist`,
+		},
+	}
+
+	n := &bridge{}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			classes, err := n.qosClasses(test.config)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.classes, classes)
+		})
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/bgp"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/cluster/request"
 	"github.com/lxc/lxd/lxd/db"
@@ -29,6 +31,7 @@ type Info struct {
 	Projects           bool // Indicates if driver can be used in network enabled projects.
 	NodeSpecificConfig bool // Whether driver has cluster node specific config as a prerequisite for creation.
 	AddressForwards    bool // Indicates if driver supports address forwards.
+	LoadBalancers      bool // Indicates if driver supports load balancers.
 	Peering            bool // Indicates if the driver supports network peering.
 }
 
@@ -40,6 +43,20 @@ type forwardPortMap struct {
 	protocol      string
 }
 
+// loadBalancerTarget represents a backend that a load balancer port is forwarded to.
+type loadBalancerTarget struct {
+	backendName string
+	address     net.IP
+	ports       []uint64
+}
+
+// loadBalancerPortMap represents a mapping of listen port(s) to target backend(s) for a protocol.
+type loadBalancerPortMap struct {
+	listenPorts []uint64
+	protocol    string
+	targets     []loadBalancerTarget
+}
+
 // externalSubnetUsage represents usage of a subnet by a network or NIC.
 type externalSubnetUsage struct {
 	subnet          net.IPNet
@@ -207,6 +224,7 @@ func (n *common) Info() Info {
 		Projects:           false,
 		NodeSpecificConfig: true,
 		AddressForwards:    false,
+		LoadBalancers:      false,
 	}
 }
 
@@ -506,12 +524,12 @@ func (n *common) bgpValidationRules(config map[string]string) (map[string]func(v
 	rules := map[string]func(value string) error{}
 	for k := range config {
 		// BGP keys have the peer name in their name, extract the suffix.
-		if !strings.HasPrefix(k, "bgp.") {
+		if !strings.HasPrefix(k, "bgp.peers.") {
 			continue
 		}
 
 		// Validate remote name in key.
-		fields := strings.Split(k, ".")
+		fields := strings.SplitN(k, ".", 4)
 		if len(fields) != 4 {
 			return nil, fmt.Errorf("Invalid network configuration key: %s", k)
 		}
@@ -526,6 +544,15 @@ func (n *common) bgpValidationRules(config map[string]string) (map[string]func(v
 			rules[k] = validate.Optional(validate.IsInRange(1, 4294967294))
 		case "password":
 			rules[k] = validate.Optional(validate.IsAny)
+		case "import":
+			rules[k] = validate.Optional(validate.IsBool)
+		case "import.prefixes", "export.prefixes":
+			rules[k] = validate.Optional(validate.IsListOf(validate.IsNetwork))
+		case "import.communities", "export.communities":
+			rules[k] = validate.Optional(validate.IsListOf(func(value string) error {
+				_, err := bgp.ParseCommunity(value)
+				return err
+			}))
 		}
 	}
 
@@ -581,8 +608,7 @@ func (n *common) bgpClearPeers(config map[string]string) error {
 	peers := n.bgpGetPeers(config)
 	for _, peer := range peers {
 		// Remove the peer.
-		fields := strings.Split(peer, ",")
-		err := n.state.BGP.RemovePeer(net.ParseIP(fields[0]))
+		err := n.state.BGP.RemovePeer(bgpNetworkKey(n.id), peer.address)
 		if err != nil {
 			return err
 		}
@@ -597,15 +623,24 @@ func (n *common) bgpSetupPeers(oldConfig map[string]string) error {
 	newPeers := n.bgpGetPeers(n.config)
 	oldPeers := n.bgpGetPeers(oldConfig)
 
+	peerInList := func(peer bgpPeer, peers []bgpPeer) bool {
+		for _, other := range peers {
+			if peer.equal(other) {
+				return true
+			}
+		}
+
+		return false
+	}
+
 	// Remove old peers.
 	for _, peer := range oldPeers {
-		if shared.StringInSlice(peer, newPeers) {
+		if peerInList(peer, newPeers) {
 			continue
 		}
 
 		// Remove old peer.
-		fields := strings.Split(peer, ",")
-		err := n.state.BGP.RemovePeer(net.ParseIP(fields[0]))
+		err := n.state.BGP.RemovePeer(bgpNetworkKey(n.id), peer.address)
 		if err != nil {
 			return err
 		}
@@ -613,18 +648,12 @@ func (n *common) bgpSetupPeers(oldConfig map[string]string) error {
 
 	// Add new peers.
 	for _, peer := range newPeers {
-		if shared.StringInSlice(peer, oldPeers) {
+		if peerInList(peer, oldPeers) {
 			continue
 		}
 
 		// Add new peer.
-		fields := strings.Split(peer, ",")
-		asn, err := strconv.ParseUint(fields[1], 10, 32)
-		if err != nil {
-			return err
-		}
-
-		err = n.state.BGP.AddPeer(net.ParseIP(fields[0]), uint32(asn), fields[2])
+		err := n.state.BGP.AddPeer(bgpNetworkKey(n.id), peer.address, peer.asn, peer.password, peer.policy)
 		if err != nil {
 			return err
 		}
@@ -662,6 +691,11 @@ func (n *common) bgpSetupPrefixes(oldConfig map[string]string) error {
 		}
 	}
 
+	bgpNetwork, err := bgpPolicyNetwork(n.state, n.id, n.config)
+	if err != nil {
+		return err
+	}
+
 	// Add the new prefixes.
 	for _, ipVersion := range []uint{4, 6} {
 		nextHopAddr := n.bgpNextHopAddress(ipVersion)
@@ -680,7 +714,7 @@ func (n *common) bgpSetupPrefixes(oldConfig map[string]string) error {
 					return err
 				}
 
-				err = n.state.BGP.AddPrefix(*subnet, nextHopAddr, bgpOwner)
+				err = n.state.BGP.AddPrefix(*subnet, nextHopAddr, bgpOwner, bgpNetwork)
 				if err != nil {
 					return err
 				}
@@ -693,7 +727,7 @@ func (n *common) bgpSetupPrefixes(oldConfig map[string]string) error {
 				return fmt.Errorf("Failed parsing network address %q: %w", netAddress, err)
 			}
 
-			err = n.state.BGP.AddPrefix(*subnet, nextHopAddr, bgpOwner)
+			err = n.state.BGP.AddPrefix(*subnet, nextHopAddr, bgpOwner, bgpNetwork)
 			if err != nil {
 				return err
 			}
@@ -703,8 +737,22 @@ func (n *common) bgpSetupPrefixes(oldConfig map[string]string) error {
 	return nil
 }
 
-// bgpGetPeers returns a list of strings representing the BGP peers.
-func (n *common) bgpGetPeers(config map[string]string) []string {
+// bgpPeer represents a BGP peer defined in the network configuration.
+type bgpPeer struct {
+	name     string
+	address  net.IP
+	asn      uint32
+	password string
+	policy   bgp.PeerPolicy
+}
+
+// equal checks whether two peers have the same configuration.
+func (p bgpPeer) equal(other bgpPeer) bool {
+	return p.name == other.name && p.address.Equal(other.address) && p.asn == other.asn && p.password == other.password && p.policy.Equal(other.policy)
+}
+
+// bgpGetPeers returns the list of BGP peers defined in the config.
+func (n *common) bgpGetPeers(config map[string]string) []bgpPeer {
 	// Get a list of peer names.
 	peerNames := []string{}
 	for k := range config {
@@ -718,21 +766,142 @@ func (n *common) bgpGetPeers(config map[string]string) []string {
 		}
 	}
 
-	// Build up a list of peer strings.
-	peers := []string{}
-	for _, peerName := range peerNames {
-		peerAddress := config[fmt.Sprintf("bgp.peers.%s.address", peerName)]
-		peerASN := config[fmt.Sprintf("bgp.peers.%s.asn", peerName)]
-		peerPassword := config[fmt.Sprintf("bgp.peers.%s.password", peerName)]
+	sort.Strings(peerNames)
+
+	parseSubnets := func(value string) []net.IPNet {
+		subnets := []net.IPNet{}
+		for _, entry := range shared.SplitNTrimSpace(value, ",", -1, true) {
+			_, subnet, err := net.ParseCIDR(entry)
+			if err != nil {
+				continue
+			}
+
+			subnets = append(subnets, *subnet)
+		}
 
-		if peerAddress != "" && peerASN != "" {
-			peers = append(peers, fmt.Sprintf("%s,%s,%s", peerAddress, peerASN, peerPassword))
+		return subnets
+	}
+
+	// Build up a list of peers.
+	peers := []bgpPeer{}
+	for _, peerName := range peerNames {
+		peerAddress := net.ParseIP(config[fmt.Sprintf("bgp.peers.%s.address", peerName)])
+		peerASN, err := strconv.ParseUint(config[fmt.Sprintf("bgp.peers.%s.asn", peerName)], 10, 32)
+		if peerAddress == nil || err != nil {
+			continue
 		}
+
+		peers = append(peers, bgpPeer{
+			name:     peerName,
+			address:  peerAddress,
+			asn:      uint32(peerASN),
+			password: config[fmt.Sprintf("bgp.peers.%s.password", peerName)],
+			policy: bgp.PeerPolicy{
+				Import:            shared.IsTrue(config[fmt.Sprintf("bgp.peers.%s.import", peerName)]),
+				ImportPrefixes:    parseSubnets(config[fmt.Sprintf("bgp.peers.%s.import.prefixes", peerName)]),
+				ImportCommunities: shared.SplitNTrimSpace(config[fmt.Sprintf("bgp.peers.%s.import.communities", peerName)], ",", -1, true),
+				ExportPrefixes:    parseSubnets(config[fmt.Sprintf("bgp.peers.%s.export.prefixes", peerName)]),
+				ExportCommunities: shared.SplitNTrimSpace(config[fmt.Sprintf("bgp.peers.%s.export.communities", peerName)], ",", -1, true),
+			},
+		})
 	}
 
 	return peers
 }
 
+// bgpImportedRoutes returns the routes learned from the BGP peers defined in the config of the network with the
+// given ID which passed their import policy. When several peers announce the same prefix, the first peer (by name)
+// wins.
+func (n *common) bgpImportedRoutes(networkID int64, config map[string]string) ([]bgp.Route, error) {
+	routes := []bgp.Route{}
+	prefixes := map[string]struct{}{}
+
+	for _, peer := range n.bgpGetPeers(config) {
+		if !peer.policy.Import {
+			continue
+		}
+
+		peerRoutes, err := n.state.BGP.ImportedRoutes(bgpNetworkKey(networkID), peer.address)
+		if err != nil {
+			return nil, fmt.Errorf("Failed getting routes learned from BGP peer %q: %w", peer.name, err)
+		}
+
+		for _, route := range peerRoutes {
+			_, found := prefixes[route.Prefix.String()]
+			if !route.Accepted || found {
+				continue
+			}
+
+			prefixes[route.Prefix.String()] = struct{}{}
+			routes = append(routes, route)
+		}
+	}
+
+	return routes, nil
+}
+
+// bgpState returns the session state of the BGP peers defined in the config of the network with the given ID and
+// the routes learned from them.
+func (n *common) bgpState(networkID int64, config map[string]string) (*api.NetworkBGPState, error) {
+	state := &api.NetworkBGPState{
+		Peers:  []api.NetworkBGPPeerState{},
+		Routes: []api.NetworkBGPRoute{},
+	}
+
+	for _, peer := range n.bgpGetPeers(config) {
+		peerState := api.NetworkBGPPeerState{
+			Name:    peer.name,
+			Address: peer.address.String(),
+			ASN:     peer.asn,
+			State:   "unknown",
+		}
+
+		sessionState, err := n.state.BGP.PeerState(peer.address)
+		if err != nil && err != bgp.ErrPeerNotFound {
+			return nil, fmt.Errorf("Failed getting state of BGP peer %q: %w", peer.name, err)
+		}
+
+		if sessionState != nil {
+			peerState.State = sessionState.State
+			peerState.Uptime = int64(sessionState.Uptime.Seconds())
+			peerState.ReceivedRoutes = sessionState.Received
+		}
+
+		routes, err := n.state.BGP.ImportedRoutes(bgpNetworkKey(networkID), peer.address)
+		if err != nil {
+			return nil, fmt.Errorf("Failed getting routes learned from BGP peer %q: %w", peer.name, err)
+		}
+
+		for _, route := range routes {
+			if route.Accepted {
+				peerState.AcceptedRoutes++
+			}
+
+			state.Routes = append(state.Routes, api.NetworkBGPRoute{
+				Prefix:      route.Prefix.String(),
+				NextHop:     route.NextHop.String(),
+				Peer:        peer.name,
+				Communities: route.Communities,
+				Accepted:    route.Accepted,
+			})
+		}
+
+		state.Peers = append(state.Peers, peerState)
+	}
+
+	return state, nil
+}
+
+// BGPState returns the session state of the BGP peers of the network and the routes learned from them.
+func (n *common) BGPState() (*api.NetworkBGPState, error) {
+	return n.bgpState(n.id, n.config)
+}
+
+// BGPImportRoutes does nothing for drivers that do not install routes learned from BGP peers.
+func (n *common) BGPImportRoutes(clusterLeader bool) error {
+	return nil
+}
+
 // forwardValidate valites the forward request.
 func (n *common) forwardValidate(listenAddress net.IP, forward *api.NetworkForwardPut) ([]*forwardPortMap, error) {
 	if listenAddress == nil {
@@ -909,7 +1078,195 @@ func (n *common) ForwardDelete(listenAddress string, clientType request.ClientTy
 	return ErrNotImplemented
 }
 
-// forwardBGPSetupPrefixes exports external forward addresses as prefixes.
+// loadBalancerValidate validates the load balancer request. The configRules argument defines the load balancer
+// config keys supported by the driver.
+func (n *common) loadBalancerValidate(listenAddress net.IP, loadBalancer *api.NetworkLoadBalancerPut, configRules map[string]func(value string) error) ([]*loadBalancerPortMap, error) {
+	if listenAddress == nil {
+		return nil, fmt.Errorf("Invalid listen address")
+	}
+
+	listenIsIP4 := listenAddress.To4() != nil
+
+	// For checking target addresses are within network's subnet.
+	netIPKey := "ipv4.address"
+	if !listenIsIP4 {
+		netIPKey = "ipv6.address"
+	}
+
+	netIPAddress := n.config[netIPKey]
+
+	var err error
+	var netSubnet *net.IPNet
+	if netIPAddress != "" {
+		_, netSubnet, err = net.ParseCIDR(n.config[netIPKey])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate config.
+	for k, v := range loadBalancer.Config {
+		// User keys are not validated.
+		if shared.IsUserConfig(k) {
+			continue
+		}
+
+		validator, found := configRules[k]
+		if !found {
+			return nil, fmt.Errorf("Invalid option %q", k)
+		}
+
+		err = validator(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value for option %q: %w", k, err)
+		}
+	}
+
+	// Validate backends.
+	backendsByName := make(map[string]*loadBalancerTarget, len(loadBalancer.Backends))
+
+	for backendSpecID, backendSpec := range loadBalancer.Backends {
+		err = validate.IsDeviceName(backendSpec.Name)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid name in backend specification %d: %w", backendSpecID, err)
+		}
+
+		if _, found := backendsByName[backendSpec.Name]; found {
+			return nil, fmt.Errorf("Duplicate name %q in backend specification %d", backendSpec.Name, backendSpecID)
+		}
+
+		targetAddress := net.ParseIP(backendSpec.TargetAddress)
+		if targetAddress == nil {
+			return nil, fmt.Errorf("Invalid target address in backend specification %d", backendSpecID)
+		}
+
+		targetIsIP4 := targetAddress.To4() != nil
+		if listenIsIP4 != targetIsIP4 {
+			return nil, fmt.Errorf("Cannot mix IP versions in listen address and backend specification %d target address", backendSpecID)
+		}
+
+		// Check target address is within network's subnet.
+		if netSubnet != nil && !SubnetContainsIP(netSubnet, targetAddress) {
+			return nil, fmt.Errorf("Target address is not within the network subnet in backend specification %d", backendSpecID)
+		}
+
+		target := loadBalancerTarget{
+			backendName: backendSpec.Name,
+			address:     targetAddress,
+		}
+
+		// Check valid target port(s) supplied.
+		for _, pr := range shared.SplitNTrimSpace(backendSpec.TargetPort, ",", -1, true) {
+			portFirst, portRange, err := ParsePortRange(pr)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid target port in backend specification %d", backendSpecID)
+			}
+
+			for i := int64(0); i < portRange; i++ {
+				target.ports = append(target.ports, uint64(portFirst+i))
+			}
+		}
+
+		backendsByName[backendSpec.Name] = &target
+	}
+
+	// Validate port rules.
+	validPortProcols := []string{"tcp", "udp"}
+
+	// Used to ensure that each listen port is only used once.
+	listenPorts := map[string]map[int64]struct{}{
+		"tcp": make(map[int64]struct{}),
+		"udp": make(map[int64]struct{}),
+	}
+
+	portMaps := make([]*loadBalancerPortMap, 0, len(loadBalancer.Ports))
+
+	for portSpecID, portSpec := range loadBalancer.Ports {
+		if !shared.StringInSlice(portSpec.Protocol, validPortProcols) {
+			return nil, fmt.Errorf("Invalid port protocol in port specification %d, protocol must be one of: %s", portSpecID, strings.Join(validPortProcols, ", "))
+		}
+
+		// Check valid listen port(s) supplied.
+		listenPortRanges := shared.SplitNTrimSpace(portSpec.ListenPort, ",", -1, true)
+		if len(listenPortRanges) <= 0 {
+			return nil, fmt.Errorf("Missing listen port in port specification %d", portSpecID)
+		}
+
+		portMap := loadBalancerPortMap{
+			listenPorts: make([]uint64, 0),
+			protocol:    portSpec.Protocol,
+		}
+
+		for _, pr := range listenPortRanges {
+			portFirst, portRange, err := ParsePortRange(pr)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid listen port in port specification %d: %w", portSpecID, err)
+			}
+
+			for i := int64(0); i < portRange; i++ {
+				port := portFirst + i
+				if _, found := listenPorts[portSpec.Protocol][port]; found {
+					return nil, fmt.Errorf("Duplicate listen port %d for protocol %q in port specification %d", port, portSpec.Protocol, portSpecID)
+				}
+
+				listenPorts[portSpec.Protocol][port] = struct{}{}
+				portMap.listenPorts = append(portMap.listenPorts, uint64(port))
+			}
+		}
+
+		// Check valid target backend(s) supplied.
+		if len(portSpec.TargetBackend) <= 0 {
+			return nil, fmt.Errorf("Missing target backend in port specification %d", portSpecID)
+		}
+
+		for _, backendName := range portSpec.TargetBackend {
+			target, found := backendsByName[backendName]
+			if !found {
+				return nil, fmt.Errorf("Invalid target backend name %q in port specification %d", backendName, portSpecID)
+			}
+
+			// Only check if the target port count matches the listen port count if the target ports
+			// don't equal 1, because we allow many-to-one type mapping.
+			targetPortsLen := len(target.ports)
+			if targetPortsLen > 1 && len(portMap.listenPorts) != targetPortsLen {
+				return nil, fmt.Errorf("Mismatch of listen port(s) and target port(s) count for backend %q in port specification %d", backendName, portSpecID)
+			}
+
+			portMap.targets = append(portMap.targets, *target)
+		}
+
+		portMaps = append(portMaps, &portMap)
+	}
+
+	return portMaps, nil
+}
+
+// LoadBalancerCreate returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
+// LoadBalancerUpdate returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerUpdate(listenAddress string, newLoadBalancer api.NetworkLoadBalancerPut, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
+// LoadBalancerDelete returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerDelete(listenAddress string, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
+// LoadBalancerState returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerState(listenAddress string) (*api.NetworkLoadBalancerState, error) {
+	return nil, ErrNotImplemented
+}
+
+// LoadBalancerHealthCheck does nothing for drivers that do not health check load balancer backends.
+func (n *common) LoadBalancerHealthCheck() error {
+	return nil
+}
+
+// forwardBGPSetupPrefixes exports external forward and load balancer addresses as prefixes.
 func (n *common) forwardBGPSetupPrefixes() error {
 	// Retrieve network forwards before clearing existing prefixes, and separate them by IP family.
 	fwdListenAddresses, err := n.state.DB.Cluster.GetNetworkForwardListenAddresses(n.ID(), true)
@@ -917,12 +1274,27 @@ func (n *common) forwardBGPSetupPrefixes() error {
 		return fmt.Errorf("Failed loading network forwards: %w", err)
 	}
 
+	// Load balancer listen addresses are exported in the same way as the forward ones.
+	lbListenAddresses, err := n.state.DB.Cluster.GetNetworkLoadBalancerListenAddresses(n.ID(), true)
+	if err != nil {
+		return fmt.Errorf("Failed loading network load balancers: %w", err)
+	}
+
+	listenAddresses := make([]string, 0, len(fwdListenAddresses)+len(lbListenAddresses))
+	for _, fwdListenAddress := range fwdListenAddresses {
+		listenAddresses = append(listenAddresses, fwdListenAddress)
+	}
+
+	for _, lbListenAddress := range lbListenAddresses {
+		listenAddresses = append(listenAddresses, lbListenAddress)
+	}
+
 	fwdListenAddressesByFamily := map[uint][]string{
 		4: make([]string, 0),
 		6: make([]string, 0),
 	}
 
-	for _, fwdListenAddress := range fwdListenAddresses {
+	for _, fwdListenAddress := range listenAddresses {
 		if strings.Contains(fwdListenAddress, ":") {
 			fwdListenAddressesByFamily[6] = append(fwdListenAddressesByFamily[6], fwdListenAddress)
 		} else {
@@ -940,6 +1312,11 @@ func (n *common) forwardBGPSetupPrefixes() error {
 		return err
 	}
 
+	bgpNetwork, err := bgpPolicyNetwork(n.state, n.id, n.config)
+	if err != nil {
+		return err
+	}
+
 	// Add the new prefixes.
 	for _, ipVersion := range []uint{4, 6} {
 		nextHopAddr := n.bgpNextHopAddress(ipVersion)
@@ -965,7 +1342,7 @@ func (n *common) forwardBGPSetupPrefixes() error {
 				return err
 			}
 
-			err = n.state.BGP.AddPrefix(*ipRouteSubnet, nextHopAddr, bgpOwner)
+			err = n.state.BGP.AddPrefix(*ipRouteSubnet, nextHopAddr, bgpOwner, bgpNetwork)
 			if err != nil {
 				return err
 			}
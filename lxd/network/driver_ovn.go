@@ -101,6 +101,7 @@ func (n *ovn) Info() Info {
 	info.Projects = true
 	info.NodeSpecificConfig = false
 	info.AddressForwards = true
+	info.LoadBalancers = true
 	info.Peering = true
 
 	return info
@@ -164,6 +165,143 @@ func (n *ovn) State() (*api.NetworkState, error) {
 	}, nil
 }
 
+// BGPState returns the session state of the BGP peers of the uplink network and the routes learned from them.
+func (n *ovn) BGPState() (*api.NetworkBGPState, error) {
+	// Uplink network must be in default project.
+	uplinkNet, err := LoadByName(n.state, project.Default, n.config["network"])
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading uplink network %q: %w", n.config["network"], err)
+	}
+
+	return n.bgpState(uplinkNet.ID(), uplinkNet.Config())
+}
+
+// BGPImportRoutes installs the routes learned from the BGP peers of the uplink network into the logical router
+// of the network and removes the ones which are no longer announced. A withdrawn default route is pointed back
+// at the uplink gateway. As the logical router is shared by all members, this only runs on the cluster leader.
+func (n *ovn) BGPImportRoutes(clusterLeader bool) error {
+	if !clusterLeader || n.state.OS.MockMode {
+		return nil
+	}
+
+	// Uplink network must be in default project.
+	uplinkNet, err := LoadByName(n.state, project.Default, n.config["network"])
+	if err != nil {
+		return fmt.Errorf("Failed loading uplink network %q: %w", n.config["network"], err)
+	}
+
+	routes, err := n.bgpImportedRoutes(uplinkNet.ID(), uplinkNet.Config())
+	if err != nil {
+		return err
+	}
+
+	// Detect the uplink gateways used by the default routes.
+	uplinkGateways := map[uint]net.IP{}
+	for _, ipVersion := range []uint{4, 6} {
+		uplinkCIDR := uplinkNet.Config()[fmt.Sprintf("ipv%d.address", ipVersion)]
+		if uplinkCIDR == "" {
+			uplinkCIDR = uplinkNet.Config()[fmt.Sprintf("ipv%d.gateway", ipVersion)]
+		}
+
+		uplinkIP, _, err := net.ParseCIDR(uplinkCIDR)
+		if err == nil {
+			uplinkGateways[ipVersion] = uplinkIP
+		}
+	}
+
+	ipVersionOf := func(ip net.IP) uint {
+		if ip.To4() != nil {
+			return 4
+		}
+
+		return 6
+	}
+
+	// Only install routes for the address families with external connectivity.
+	wanted := map[string]openvswitch.OVNRouterRoute{}
+	for _, route := range routes {
+		if uplinkGateways[ipVersionOf(route.Prefix.IP)] == nil {
+			continue
+		}
+
+		wanted[route.Prefix.String()] = openvswitch.OVNRouterRoute{
+			Prefix:  route.Prefix,
+			NextHop: route.NextHop,
+			Port:    n.getRouterExtPortName(),
+		}
+	}
+
+	client, err := openvswitch.NewOVN(n.state)
+	if err != nil {
+		return fmt.Errorf("Failed to get OVN client: %w", err)
+	}
+
+	// The routes towards the uplink other than the default routes are the ones learned from BGP.
+	existing, err := client.LogicalRouterRoutes(n.getRouterName())
+	if err != nil {
+		return fmt.Errorf("Failed getting logical router routes: %w", err)
+	}
+
+	installed := map[string]openvswitch.OVNRouterRoute{}
+	for _, route := range existing {
+		if route.Port == n.getRouterExtPortName() {
+			installed[route.Prefix.String()] = route
+		}
+	}
+
+	removeRoutes := []net.IPNet{}
+	addRoutes := []openvswitch.OVNRouterRoute{}
+
+	for prefix, route := range installed {
+		_, found := wanted[prefix]
+		if found {
+			continue
+		}
+
+		ones, _ := route.Prefix.Mask.Size()
+		if ones == 0 {
+			// Restore the default route through the uplink gateway.
+			gateway := uplinkGateways[ipVersionOf(route.Prefix.IP)]
+			if gateway != nil && !route.NextHop.Equal(gateway) {
+				addRoutes = append(addRoutes, openvswitch.OVNRouterRoute{
+					Prefix:  route.Prefix,
+					NextHop: gateway,
+					Port:    n.getRouterExtPortName(),
+				})
+			}
+
+			continue
+		}
+
+		removeRoutes = append(removeRoutes, route.Prefix)
+	}
+
+	for prefix, route := range wanted {
+		current, found := installed[prefix]
+		if found && current.NextHop.Equal(route.NextHop) {
+			continue
+		}
+
+		addRoutes = append(addRoutes, route)
+	}
+
+	if len(removeRoutes) > 0 {
+		err = client.LogicalRouterRouteDelete(n.getRouterName(), removeRoutes...)
+		if err != nil {
+			return fmt.Errorf("Failed removing withdrawn BGP routes: %w", err)
+		}
+	}
+
+	if len(addRoutes) > 0 {
+		err = client.LogicalRouterRouteAdd(n.getRouterName(), true, addRoutes...)
+		if err != nil {
+			return fmt.Errorf("Failed adding BGP routes: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // uplinkRoutes parses ipv4.routes and ipv6.routes settings for an uplink network into a slice of *net.IPNet.
 func (n *ovn) uplinkRoutes(uplink *api.Network) ([]*net.IPNet, error) {
 	var err error
@@ -256,6 +394,7 @@ func (n *ovn) getExternalSubnetInUse(uplinkNetworkName string) ([]externalSubnet
 	var err error
 	var projectNetworks map[string]map[int64]api.Network
 	var projectNetworksForwardsOnUplink map[string]map[int64][]string
+	var projectNetworksLoadBalancersOnUplink map[string]map[int64][]string
 
 	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 		// Get all managed networks across all projects.
@@ -270,6 +409,12 @@ func (n *ovn) getExternalSubnetInUse(uplinkNetworkName string) ([]externalSubnet
 			return fmt.Errorf("Failed loading network forward listen addresses: %w", err)
 		}
 
+		// Get all network load balancer listen addresses for all load balancers connected to our uplink.
+		projectNetworksLoadBalancersOnUplink, err = tx.GetProjectNetworkLoadBalancerListenAddressesByUplink(uplinkNetworkName)
+		if err != nil {
+			return fmt.Errorf("Failed loading network load balancer listen addresses: %w", err)
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -295,24 +440,26 @@ func (n *ovn) getExternalSubnetInUse(uplinkNetworkName string) ([]externalSubnet
 	externalSubnets = append(externalSubnets, ovnNetworkExternalSubnets...)
 	externalSubnets = append(externalSubnets, ovnNICExternalRoutes...)
 
-	// Add forward listen addresses to this list.
-	for projectName, networks := range projectNetworksForwardsOnUplink {
-		for networkID, listenAddresses := range networks {
-			for _, listenAddress := range listenAddresses {
-				// Convert listen address to subnet.
-				listenAddressNet, err := ParseIPToNet(listenAddress)
-				if err != nil {
-					return nil, fmt.Errorf("Invalid existing forward listen address %q", listenAddress)
-				}
+	// Add forward and load balancer listen addresses to this list.
+	for _, projectNetworksListenAddresses := range []map[string]map[int64][]string{projectNetworksForwardsOnUplink, projectNetworksLoadBalancersOnUplink} {
+		for projectName, networks := range projectNetworksListenAddresses {
+			for networkID, listenAddresses := range networks {
+				for _, listenAddress := range listenAddresses {
+					// Convert listen address to subnet.
+					listenAddressNet, err := ParseIPToNet(listenAddress)
+					if err != nil {
+						return nil, fmt.Errorf("Invalid existing listen address %q", listenAddress)
+					}
 
-				// Create an externalSubnetUsage for the listen address by using the network ID
-				// of the listen address to retrieve the already loaded network name from the
-				// projectNetworks map.
-				externalSubnets = append(externalSubnets, externalSubnetUsage{
-					subnet:         *listenAddressNet,
-					networkProject: projectName,
-					networkName:    projectNetworks[projectName][networkID].Name,
-				})
+					// Create an externalSubnetUsage for the listen address by using the network ID
+					// of the listen address to retrieve the already loaded network name from the
+					// projectNetworks map.
+					externalSubnets = append(externalSubnets, externalSubnetUsage{
+						subnet:         *listenAddressNet,
+						networkProject: projectName,
+						networkName:    projectNetworks[projectName][networkID].Name,
+					})
+				}
 			}
 		}
 	}
@@ -363,6 +510,11 @@ func (n *ovn) Validate(config map[string]string) error {
 		ovnVolatileUplinkIPv6: validate.Optional(validate.IsNetworkAddressV6),
 	}
 
+	// Add the DHCP options validation rules.
+	for k, v := range DHCPv4OptionsValidationRules() {
+		rules[k] = v
+	}
+
 	err := n.validate(config, rules)
 	if err != nil {
 		return err
@@ -2147,6 +2299,7 @@ func (n *ovn) setup(update bool) error {
 			DomainName:         n.getDomainName(),
 			LeaseTime:          time.Duration(time.Hour * 1),
 			MTU:                bridgeMTU,
+			Options:            DHCPv4OptionsOVN(n.config),
 		})
 		if err != nil {
 			return fmt.Errorf("Failed adding DHCPv4 settings for internal switch: %w", err)
@@ -2524,6 +2677,22 @@ func (n *ovn) Delete(clientType request.ClientType) error {
 		if err != nil {
 			return fmt.Errorf("Failed deleting network forwards: %w", err)
 		}
+
+		// Delete any network load balancers.
+		lbListenAddresses, err := n.state.DB.Cluster.GetNetworkLoadBalancerListenAddresses(n.ID(), memberSpecific)
+		if err != nil {
+			return fmt.Errorf("Failed loading network load balancers: %w", err)
+		}
+
+		loadBalancers = make([]openvswitch.OVNLoadBalancer, 0, len(lbListenAddresses))
+		for _, listenAddress := range lbListenAddresses {
+			loadBalancers = append(loadBalancers, n.getLoadBalancerName(listenAddress))
+		}
+
+		err = client.LoadBalancerDelete(loadBalancers...)
+		if err != nil {
+			return fmt.Errorf("Failed deleting network load balancers: %w", err)
+		}
 	}
 
 	return n.common.delete(clientType)
@@ -2823,7 +2992,24 @@ func (n *ovn) Update(newNetwork api.NetworkPut, targetNode string, clientType re
 
 		var localNICRoutes []net.IPNet
 
-		// Apply ACL changes to running instance NICs that use this network.
+		// Get the network's DHCPv4 option set that the port specific DHCPv4 option sets are copied from.
+		var dhcpV4ID openvswitch.OVNDHCPOptionsUUID
+		dhcpV4Subnet := n.DHCPv4Subnet()
+		if dhcpV4Subnet != nil {
+			existingOpts, err := client.LogicalSwitchDHCPOptionsGet(n.getIntSwitchName())
+			if err != nil {
+				return fmt.Errorf("Failed getting existing DHCP settings for internal switch: %w", err)
+			}
+
+			for _, existingOpt := range existingOpts {
+				if existingOpt.CIDR.String() == dhcpV4Subnet.String() {
+					dhcpV4ID = existingOpt.UUID
+					break
+				}
+			}
+		}
+
+		// Apply ACL and DHCP changes to running instance NICs that use this network.
 		err = usedByInstanceDevices(n.state, n.project, n.name, func(inst db.InstanceArgs, nicName string, nicConfig map[string]string) error {
 			nicACLs := shared.SplitNTrimSpace(nicConfig["security.acls"], ",", -1, true)
 
@@ -2835,6 +3021,22 @@ func (n *ovn) Update(newNetwork api.NetworkPut, targetNode string, clientType re
 				return nil // No need to update a port that isn't started yet.
 			}
 
+			// Refresh the port specific DHCPv4 option set from the network's updated one.
+			nicDHCPv4Options := DHCPv4OptionsOVN(nicConfig)
+			if len(nicDHCPv4Options) > 0 {
+				if dhcpV4ID != "" {
+					_, err = client.LogicalSwitchPortDHCPv4OptionsSet(instancePortName, dhcpV4ID, nicDHCPv4Options)
+					if err != nil {
+						return fmt.Errorf("Failed updating DHCPv4 options for instance port: %w", err)
+					}
+				} else {
+					err = client.LogicalSwitchPortDHCPOptionsDelete(instancePortName)
+					if err != nil {
+						return fmt.Errorf("Failed deleting DHCPv4 options for instance port: %w", err)
+					}
+				}
+			}
+
 			// Apply security ACL and default rule changes.
 			if aclConfigChanged {
 				// Check whether we need to add any of the new ACLs to the NIC.
@@ -3250,6 +3452,24 @@ func (n *ovn) InstanceDevicePortSetup(opts *OVNInstanceNICSetupOpts, securityACL
 
 	instancePortName := n.getInstanceDevicePortName(opts.InstanceUUID, opts.DeviceName)
 
+	// Use a port specific DHCPv4 options set when the NIC overrides some of the network's options.
+	if dhcpV4ID != "" {
+		nicDHCPv4Options := DHCPv4OptionsOVN(opts.DeviceConfig)
+		if len(nicDHCPv4Options) > 0 {
+			dhcpV4ID, err = client.LogicalSwitchPortDHCPv4OptionsSet(instancePortName, dhcpV4ID, nicDHCPv4Options)
+			if err != nil {
+				return "", fmt.Errorf("Failed setting DHCPv4 options for instance port: %w", err)
+			}
+
+			revert.Add(func() { _ = client.LogicalSwitchPortDHCPOptionsDelete(instancePortName) })
+		} else {
+			err = client.LogicalSwitchPortDHCPOptionsDelete(instancePortName)
+			if err != nil {
+				return "", fmt.Errorf("Failed deleting DHCPv4 options for instance port: %w", err)
+			}
+		}
+	}
+
 	// Add port with mayExist set to true, so that if instance port exists, we don't fail and continue below
 	// to configure the port as needed. This is required in case the OVN northbound database was unavailable
 	// when the instance NIC was stopped and was unable to remove the port on last stop, which would otherwise
@@ -3993,7 +4213,11 @@ func (n *ovn) forwardFlattenVIPs(listenAddress net.IP, defaultTargetAddress net.
 	if defaultTargetAddress != nil {
 		vips = append(vips, openvswitch.OVNLoadBalancerVIP{
 			ListenAddress: listenAddress,
-			TargetAddress: defaultTargetAddress,
+			Targets: []openvswitch.OVNLoadBalancerTarget{
+				{
+					Address: defaultTargetAddress,
+				},
+			},
 		})
 	}
 
@@ -4015,9 +4239,13 @@ func (n *ovn) forwardFlattenVIPs(listenAddress net.IP, defaultTargetAddress net.
 			vips = append(vips, openvswitch.OVNLoadBalancerVIP{
 				ListenAddress: listenAddress,
 				Protocol:      portMap.protocol,
-				TargetAddress: portMap.targetAddress,
 				ListenPort:    lp,
-				TargetPort:    targetPort,
+				Targets: []openvswitch.OVNLoadBalancerTarget{
+					{
+						Address: portMap.targetAddress,
+						Port:    targetPort,
+					},
+				},
 			})
 		}
 	}
@@ -4039,6 +4267,12 @@ func (n *ovn) ForwardCreate(forward api.NetworkForwardsPost, clientType request.
 			return api.StatusErrorf(http.StatusConflict, "A forward for that listen address already exists")
 		}
 
+		// Check if there is an existing load balancer using the same listen address.
+		_, _, err = n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, forward.ListenAddress)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "A load balancer for that listen address already exists")
+		}
+
 		// Convert listen address to subnet so we can check its valid and can be used.
 		listenAddressNet, err := ParseIPToNet(forward.ListenAddress)
 		if err != nil {
@@ -4291,6 +4525,344 @@ func (n *ovn) ForwardDelete(listenAddress string, clientType request.ClientType)
 	return nil
 }
 
+// loadBalancerFlattenVIPs flattens port maps into format compatible with OVN load balancers.
+func (n *ovn) loadBalancerFlattenVIPs(listenAddress net.IP, portMaps []*loadBalancerPortMap) []openvswitch.OVNLoadBalancerVIP {
+	var vips []openvswitch.OVNLoadBalancerVIP
+
+	for _, portMap := range portMaps {
+		for i, lp := range portMap.listenPorts {
+			vip := openvswitch.OVNLoadBalancerVIP{
+				ListenAddress: listenAddress,
+				Protocol:      portMap.protocol,
+				ListenPort:    lp,
+			}
+
+			for _, target := range portMap.targets {
+				targetPortsLen := len(target.ports)
+				targetPort := lp // Default to using same port as listen port for target port.
+
+				if targetPortsLen == 1 {
+					// If a single target port is specified, forward all listen ports to it.
+					targetPort = target.ports[0]
+				} else if targetPortsLen > 1 {
+					// If more than 1 target port specified, use listen port index to get the
+					// target port to use.
+					targetPort = target.ports[i]
+				}
+
+				vip.Targets = append(vip.Targets, openvswitch.OVNLoadBalancerTarget{
+					Address: target.address,
+					Port:    targetPort,
+				})
+			}
+
+			vips = append(vips, vip)
+		}
+	}
+
+	return vips
+}
+
+// LoadBalancerCreate creates a network load balancer.
+func (n *ovn) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if clientType == request.ClientTypeNormal {
+		memberSpecific := false // OVN doesn't support per-member load balancers.
+
+		// Check if there is an existing load balancer or forward using the same listen address.
+		_, _, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, loadBalancer.ListenAddress)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "A load balancer for that listen address already exists")
+		}
+
+		_, _, err = n.state.DB.Cluster.GetNetworkForward(n.ID(), memberSpecific, loadBalancer.ListenAddress)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "A forward for that listen address already exists")
+		}
+
+		// Convert listen address to subnet so we can check its valid and can be used.
+		listenAddressNet, err := ParseIPToNet(loadBalancer.ListenAddress)
+		if err != nil {
+			return fmt.Errorf("Failed parsing %q: %w", loadBalancer.ListenAddress, err)
+		}
+
+		// OVN load balancers don't support health checks, so no config keys other than user ones are allowed.
+		portMaps, err := n.loadBalancerValidate(listenAddressNet.IP, &loadBalancer.NetworkLoadBalancerPut, nil)
+		if err != nil {
+			return err
+		}
+
+		// Load the project to get uplink network restrictions.
+		var p *api.Project
+		err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			project, err := dbCluster.GetProject(ctx, tx.Tx(), n.project)
+			if err != nil {
+				return err
+			}
+
+			p, err = project.ToAPI(ctx, tx.Tx())
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to load network restrictions from project %q: %w", n.project, err)
+		}
+
+		// Get uplink routes.
+		_, uplink, _, err := n.state.DB.Cluster.GetNetworkInAnyState(project.Default, n.config["network"])
+		if err != nil {
+			return fmt.Errorf("Failed to load uplink network %q: %w", n.config["network"], err)
+		}
+
+		uplinkRoutes, err := n.uplinkRoutes(uplink)
+		if err != nil {
+			return err
+		}
+
+		// Get project restricted routes.
+		projectRestrictedSubnets, err := n.projectRestrictedSubnets(p, n.config["network"])
+		if err != nil {
+			return err
+		}
+
+		externalSubnetsInUse, err := n.getExternalSubnetInUse(n.config["network"])
+		if err != nil {
+			return err
+		}
+
+		// Check the listen address subnet is allowed within both the uplink's external routes and any
+		// project restricted subnets.
+		err = n.validateExternalSubnet(uplinkRoutes, projectRestrictedSubnets, listenAddressNet)
+		if err != nil {
+			return err
+		}
+
+		// Check the listen address subnet doesn't fall within any existing OVN network external subnets.
+		for _, externalSubnetUser := range externalSubnetsInUse {
+			// Skip our own network's SNAT address (as it can be used for NICs in the network).
+			if externalSubnetUser.networkSNAT && externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name {
+				continue
+			}
+
+			// Skip our own network (but not NIC devices on our own network).
+			if externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name && externalSubnetUser.instanceDevice == "" {
+				continue
+			}
+
+			if SubnetContains(&externalSubnetUser.subnet, listenAddressNet) || SubnetContains(listenAddressNet, &externalSubnetUser.subnet) {
+				// This error is purposefully vague so that it doesn't reveal any names of
+				// resources potentially outside of the network's project.
+				return fmt.Errorf("Load balancer listen address %q overlaps with another OVN network or NIC", listenAddressNet.String())
+			}
+		}
+
+		client, err := openvswitch.NewOVN(n.state)
+		if err != nil {
+			return fmt.Errorf("Failed to get OVN client: %w", err)
+		}
+
+		// Create load balancer DB record.
+		loadBalancerID, err := n.state.DB.Cluster.CreateNetworkLoadBalancer(n.ID(), memberSpecific, &loadBalancer)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			_ = n.state.DB.Cluster.DeleteNetworkLoadBalancer(n.ID(), loadBalancerID)
+			_ = client.LoadBalancerDelete(n.getLoadBalancerName(loadBalancer.ListenAddress))
+			_ = n.forwardBGPSetupPrefixes()
+		})
+
+		vips := n.loadBalancerFlattenVIPs(net.ParseIP(loadBalancer.ListenAddress), portMaps)
+
+		err = client.LoadBalancerApply(n.getLoadBalancerName(loadBalancer.ListenAddress), []openvswitch.OVNRouter{n.getRouterName()}, []openvswitch.OVNSwitch{n.getIntSwitchName()}, vips...)
+		if err != nil {
+			return fmt.Errorf("Failed applying OVN load balancer: %w", err)
+		}
+
+		// Notify all other members to refresh their BGP prefixes.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.UseProject(n.project).CreateNetworkLoadBalancer(n.name, loadBalancer)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Refresh exported BGP prefixes on local member.
+	err := n.forwardBGPSetupPrefixes()
+	if err != nil {
+		return fmt.Errorf("Failed applying BGP prefixes for load balancers: %w", err)
+	}
+
+	revert.Success()
+	return nil
+}
+
+// LoadBalancerUpdate updates a network load balancer.
+func (n *ovn) LoadBalancerUpdate(listenAddress string, req api.NetworkLoadBalancerPut, clientType request.ClientType) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if clientType == request.ClientTypeNormal {
+		memberSpecific := false // OVN doesn't support per-member load balancers.
+		curLoadBalancerID, curLoadBalancer, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+		if err != nil {
+			return err
+		}
+
+		portMaps, err := n.loadBalancerValidate(net.ParseIP(curLoadBalancer.ListenAddress), &req, nil)
+		if err != nil {
+			return err
+		}
+
+		curLoadBalancerEtagHash, err := util.EtagHash(curLoadBalancer.Etag())
+		if err != nil {
+			return err
+		}
+
+		newLoadBalancer := api.NetworkLoadBalancer{
+			ListenAddress:          curLoadBalancer.ListenAddress,
+			NetworkLoadBalancerPut: req,
+		}
+
+		newLoadBalancerEtagHash, err := util.EtagHash(newLoadBalancer.Etag())
+		if err != nil {
+			return err
+		}
+
+		if curLoadBalancerEtagHash == newLoadBalancerEtagHash {
+			return nil // Nothing has changed.
+		}
+
+		client, err := openvswitch.NewOVN(n.state)
+		if err != nil {
+			return fmt.Errorf("Failed to get OVN client: %w", err)
+		}
+
+		vips := n.loadBalancerFlattenVIPs(net.ParseIP(newLoadBalancer.ListenAddress), portMaps)
+		err = client.LoadBalancerApply(n.getLoadBalancerName(newLoadBalancer.ListenAddress), []openvswitch.OVNRouter{n.getRouterName()}, []openvswitch.OVNSwitch{n.getIntSwitchName()}, vips...)
+		if err != nil {
+			return fmt.Errorf("Failed applying OVN load balancer: %w", err)
+		}
+
+		revert.Add(func() {
+			// Apply old settings to OVN on failure.
+			curPortMaps, err := n.loadBalancerValidate(net.ParseIP(curLoadBalancer.ListenAddress), &curLoadBalancer.NetworkLoadBalancerPut, nil)
+			if err == nil {
+				vips := n.loadBalancerFlattenVIPs(net.ParseIP(curLoadBalancer.ListenAddress), curPortMaps)
+				_ = client.LoadBalancerApply(n.getLoadBalancerName(curLoadBalancer.ListenAddress), []openvswitch.OVNRouter{n.getRouterName()}, []openvswitch.OVNSwitch{n.getIntSwitchName()}, vips...)
+			}
+
+			_ = n.forwardBGPSetupPrefixes()
+		})
+
+		err = n.state.DB.Cluster.UpdateNetworkLoadBalancer(n.ID(), curLoadBalancerID, &newLoadBalancer.NetworkLoadBalancerPut)
+		if err != nil {
+			return err
+		}
+
+		// Notify all other members to refresh their BGP prefixes.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.UseProject(n.project).UpdateNetworkLoadBalancer(n.name, curLoadBalancer.ListenAddress, req, "")
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Refresh exported BGP prefixes on local member.
+	err := n.forwardBGPSetupPrefixes()
+	if err != nil {
+		return fmt.Errorf("Failed applying BGP prefixes for load balancers: %w", err)
+	}
+
+	revert.Success()
+	return nil
+}
+
+// LoadBalancerDelete deletes a network load balancer.
+func (n *ovn) LoadBalancerDelete(listenAddress string, clientType request.ClientType) error {
+	if clientType == request.ClientTypeNormal {
+		memberSpecific := false // OVN doesn't support per-member load balancers.
+		loadBalancerID, loadBalancer, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+		if err != nil {
+			return err
+		}
+
+		client, err := openvswitch.NewOVN(n.state)
+		if err != nil {
+			return fmt.Errorf("Failed to get OVN client: %w", err)
+		}
+
+		err = client.LoadBalancerDelete(n.getLoadBalancerName(loadBalancer.ListenAddress))
+		if err != nil {
+			return fmt.Errorf("Failed deleting OVN load balancer: %w", err)
+		}
+
+		err = n.state.DB.Cluster.DeleteNetworkLoadBalancer(n.ID(), loadBalancerID)
+		if err != nil {
+			return err
+		}
+
+		// Notify all other members to refresh their BGP prefixes.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.UseProject(n.project).DeleteNetworkLoadBalancer(n.name, loadBalancer.ListenAddress)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Refresh exported BGP prefixes on local member.
+	err := n.forwardBGPSetupPrefixes()
+	if err != nil {
+		return fmt.Errorf("Failed applying BGP prefixes for load balancers: %w", err)
+	}
+
+	return nil
+}
+
+// LoadBalancerState returns the state of the load balancer backends. OVN doesn't health check the backends so
+// they are all reported as healthy.
+func (n *ovn) LoadBalancerState(listenAddress string) (*api.NetworkLoadBalancerState, error) {
+	memberSpecific := false // OVN doesn't support per-member load balancers.
+	_, loadBalancer, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	state := api.NetworkLoadBalancerState{
+		BackendHealth: make(map[string]api.NetworkLoadBalancerStateBackendHealth, len(loadBalancer.Backends)),
+	}
+
+	for _, backend := range loadBalancer.Backends {
+		state.BackendHealth[backend.Name] = api.NetworkLoadBalancerStateBackendHealth{
+			Address: backend.TargetAddress,
+			Healthy: true,
+		}
+	}
+
+	return &state, nil
+}
+
 // Leases returns a list of leases for the OVN network. Those are directly extracted from the OVN database.
 func (n *ovn) Leases(projectName string, clientType request.ClientType) ([]api.NetworkLease, error) {
 	leases := []api.NetworkLease{}
@@ -0,0 +1,801 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	mathRand "math/rand"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/curve25519"
+
+	"github.com/lxc/lxd/lxd/apparmor"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/cluster/request"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/dnsmasq/dhcpalloc"
+	"github.com/lxc/lxd/lxd/ip"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+// wireguardDefaultPort is the UDP port used by the mesh when "wireguard.port" isn't set.
+const wireguardDefaultPort = "51820"
+
+// wireguardDefaultMTU is the bridge MTU used when "bridge.mtu" isn't set (1500 minus the WireGuard overhead).
+const wireguardDefaultMTU = "1420"
+
+// wireguardMemberPrefix is the prefix length of the subnet allocated to each cluster member.
+const wireguardMemberPrefix = 24
+
+// wireguard represents a LXD WireGuard mesh network.
+// Each cluster member runs a local bridge with its own subnet of the overlay, and the members route between each
+// other's subnets over a full mesh of WireGuard tunnels. The keys and subnets are distributed through the cluster
+// database and the tunnel endpoints are refreshed from the cluster heartbeats.
+type wireguard struct {
+	bridge
+}
+
+// Type returns the network type.
+func (n *wireguard) Type() string {
+	return "wireguard"
+}
+
+// DBType returns the network type DB ID.
+func (n *wireguard) DBType() db.NetworkType {
+	return db.NetworkTypeWireguard
+}
+
+// Info returns the network driver info.
+func (n *wireguard) Info() Info {
+	return n.common.Info()
+}
+
+// FillConfig fills requested config with any default values.
+func (n *wireguard) FillConfig(config map[string]string) error {
+	if config["wireguard.overlay_subnet"] == "" {
+		config["wireguard.overlay_subnet"] = "auto"
+	}
+
+	// We enable NAT by default even if the overlay is manually specified.
+	if config["ipv4.nat"] == "" {
+		config["ipv4.nat"] = "true"
+	}
+
+	// Now replace any "auto" keys with generated values.
+	err := n.populateAutoConfig(config)
+	if err != nil {
+		return fmt.Errorf("Failed generating auto config")
+	}
+
+	return nil
+}
+
+// populateAutoConfig replaces "auto" in config with generated values.
+func (n *wireguard) populateAutoConfig(config map[string]string) error {
+	if config["wireguard.overlay_subnet"] != "auto" {
+		return nil
+	}
+
+	subnet, err := randomOverlaySubnetV4()
+	if err != nil {
+		return err
+	}
+
+	config["wireguard.overlay_subnet"] = subnet
+
+	// Re-validate config if changed.
+	if n.state != nil {
+		return n.Validate(config)
+	}
+
+	return nil
+}
+
+// ValidateName validates network name.
+func (n *wireguard) ValidateName(name string) error {
+	err := n.bridge.ValidateName(name)
+	if err != nil {
+		return err
+	}
+
+	// The WireGuard interface name has a "-wg" suffix.
+	if len(name) > 12 {
+		return fmt.Errorf("Network name too long to use with WireGuard (must be 12 characters or less)")
+	}
+
+	return nil
+}
+
+// Validate network config.
+func (n *wireguard) Validate(config map[string]string) error {
+	rules := map[string]func(value string) error{
+		"wireguard.overlay_subnet": validate.Required(func(value string) error {
+			if value == "auto" {
+				return nil
+			}
+
+			err := validate.IsNetworkV4(value)
+			if err != nil {
+				return err
+			}
+
+			_, subnet, _ := net.ParseCIDR(value)
+			ones, _ := subnet.Mask.Size()
+			if ones >= wireguardMemberPrefix {
+				return fmt.Errorf("Overlay subnet must be larger than a /%d", wireguardMemberPrefix)
+			}
+
+			return nil
+		}),
+		"wireguard.port": validate.Optional(validate.IsNetworkPort),
+
+		"bridge.mtu": validate.Optional(validate.IsNetworkMTU),
+
+		"ipv4.firewall":    validate.Optional(validate.IsBool),
+		"ipv4.nat":         validate.Optional(validate.IsBool),
+		"ipv4.nat.order":   validate.Optional(validate.IsOneOf("before", "after")),
+		"ipv4.dhcp":        validate.Optional(validate.IsBool),
+		"ipv4.dhcp.expiry": validate.IsAny,
+
+		"dns.domain":  validate.IsAny,
+		"dns.mode":    validate.Optional(validate.IsOneOf("dynamic", "managed", "none")),
+		"dns.search":  validate.IsAny,
+		"raw.dnsmasq": validate.IsAny,
+
+		"volatile.wireguard.ipv4.address": validate.Optional(validate.IsNetworkAddressCIDRV4),
+		"volatile.wireguard.public_key":   validate.IsAny,
+	}
+
+	// Add the DHCP options validation rules.
+	for k, v := range DHCPv4OptionsValidationRules() {
+		rules[k] = v
+	}
+
+	return n.validate(config, rules)
+}
+
+// Start starts the network.
+func (n *wireguard) Start() error {
+	n.logger.Debug("Start")
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	revert.Add(func() { n.setUnavailable() })
+
+	err := n.setup()
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+
+	// Ensure network is marked as available now its started.
+	n.setAvailable()
+
+	return nil
+}
+
+// setup restarts the network.
+func (n *wireguard) setup() error {
+	// If we are in mock mode, just no-op.
+	if n.state.OS.MockMode {
+		return nil
+	}
+
+	n.logger.Debug("Setting up network")
+
+	_, err := exec.LookPath("wg")
+	if err != nil {
+		return fmt.Errorf("The wg tool is required for WireGuard networks")
+	}
+
+	// Create directory.
+	if !shared.PathExists(shared.VarPath("networks", n.name)) {
+		err := os.MkdirAll(shared.VarPath("networks", n.name), 0711)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Make sure this member has a key pair and a subnet of the overlay.
+	err = n.setupMember()
+	if err != nil {
+		return err
+	}
+
+	// Setup the local bridge.
+	br := n.localBridge()
+	err = br.setup(nil)
+	if err != nil {
+		return err
+	}
+
+	// Remove any existing WireGuard interface so that it is recreated with the current config.
+	wgLink := &ip.Wireguard{Link: ip.Link{Name: n.wireguardName()}}
+	if InterfaceExists(wgLink.Name) {
+		err = wgLink.Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Setup the WireGuard interface.
+	err = wgLink.Add()
+	if err != nil {
+		return err
+	}
+
+	_, err = shared.RunCommand("wg", "set", wgLink.Name, "listen-port", n.port(), "private-key", n.privateKeyPath())
+	if err != nil {
+		return fmt.Errorf("Failed configuring WireGuard interface: %w", err)
+	}
+
+	err = wgLink.SetMTU(br.config["bridge.mtu"])
+	if err != nil {
+		return err
+	}
+
+	err = wgLink.SetUp()
+	if err != nil {
+		return err
+	}
+
+	// Add the peers from the cluster member addresses in the database, the heartbeats keep them updated.
+	members := map[int64]string{}
+	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		nodes, err := tx.GetNodes()
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			members[node.ID] = node.Address
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return n.refreshPeers(members)
+}
+
+// setupMember generates the member's key pair and allocates the member's subnet if needed.
+// The public key and the subnet gateway address are stored in the member specific network config.
+func (n *wireguard) setupMember() error {
+	// Generate the private key on first start.
+	if !shared.PathExists(n.privateKeyPath()) {
+		privateKey, err := wireguardGenerateKey()
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(n.privateKeyPath(), []byte(privateKey+"\n"), 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	content, err := ioutil.ReadFile(n.privateKeyPath())
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := wireguardPublicKey(strings.TrimSpace(string(content)))
+	if err != nil {
+		return err
+	}
+
+	_, overlaySubnet, err := net.ParseCIDR(n.config["wireguard.overlay_subnet"])
+	if err != nil {
+		return fmt.Errorf("Failed parsing wireguard.overlay_subnet: %w", err)
+	}
+
+	memberID := n.state.DB.Cluster.GetNodeID()
+
+	return n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		config := util.CopyConfig(n.config)
+		config["volatile.wireguard.public_key"] = publicKey
+
+		// Keep the current subnet if still part of the overlay.
+		memberIP, _, err := net.ParseCIDR(config["volatile.wireguard.ipv4.address"])
+		if err != nil || !overlaySubnet.Contains(memberIP) {
+			membersConfig, err := tx.GetNetworkMembersConfig(n.id)
+			if err != nil {
+				return err
+			}
+
+			usedSubnets := []*net.IPNet{}
+			for id, memberConfig := range membersConfig {
+				_, subnet, err := net.ParseCIDR(memberConfig["volatile.wireguard.ipv4.address"])
+				if id == memberID || err != nil {
+					continue
+				}
+
+				usedSubnets = append(usedSubnets, subnet)
+			}
+
+			address, err := wireguardAllocateSubnet(overlaySubnet, usedSubnets)
+			if err != nil {
+				return err
+			}
+
+			config["volatile.wireguard.ipv4.address"] = address
+		}
+
+		// Nothing changed.
+		if config["volatile.wireguard.public_key"] == n.config["volatile.wireguard.public_key"] && config["volatile.wireguard.ipv4.address"] == n.config["volatile.wireguard.ipv4.address"] {
+			return nil
+		}
+
+		err = tx.UpdateNetwork(n.id, n.description, config)
+		if err != nil {
+			return fmt.Errorf("Failed saving WireGuard member config: %w", err)
+		}
+
+		n.config = config
+
+		return nil
+	})
+}
+
+// localBridge returns the bridge used to setup the member's part of the mesh.
+func (n *wireguard) localBridge() *bridge {
+	br := &bridge{common: n.common}
+	br.config = wireguardBridgeConfig(n.config)
+
+	// Only NAT the traffic leaving the overlay.
+	_, overlaySubnet, err := net.ParseCIDR(n.config["wireguard.overlay_subnet"])
+	if err == nil {
+		br.snatSubnetV4 = overlaySubnet
+	}
+
+	return br
+}
+
+// refreshPeers configures a peer and a route for each other cluster member which has joined the mesh.
+// Accepts a map of cluster member addresses keyed by member ID.
+func (n *wireguard) refreshPeers(members map[int64]string) error {
+	var membersConfig map[int64]map[string]string
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+
+		membersConfig, err = tx.GetNetworkMembersConfig(n.id)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	wgName := n.wireguardName()
+	memberID := n.state.DB.Cluster.GetNodeID()
+
+	// Configure the peers.
+	peers := map[string]struct{}{}
+	routes := map[string]struct{}{}
+	for _, peer := range wireguardPeers(membersConfig, members, memberID, n.port()) {
+		_, err = shared.RunCommand("wg", "set", wgName, "peer", peer.publicKey, "endpoint", peer.endpoint, "allowed-ips", peer.allowedIPs, "persistent-keepalive", "25")
+		if err != nil {
+			return fmt.Errorf("Failed configuring WireGuard peer %q: %w", peer.endpoint, err)
+		}
+
+		peers[peer.publicKey] = struct{}{}
+		routes[peer.allowedIPs] = struct{}{}
+	}
+
+	// Remove the peers of members which left the mesh or are offline.
+	out, err := shared.RunCommand("wg", "show", wgName, "peers")
+	if err != nil {
+		return fmt.Errorf("Failed listing WireGuard peers: %w", err)
+	}
+
+	for _, publicKey := range shared.SplitNTrimSpace(out, "\n", -1, true) {
+		_, found := peers[publicKey]
+		if found {
+			continue
+		}
+
+		_, err = shared.RunCommand("wg", "set", wgName, "peer", publicKey, "remove")
+		if err != nil {
+			return fmt.Errorf("Failed removing WireGuard peer: %w", err)
+		}
+	}
+
+	// Route the other members' subnets through the WireGuard interface.
+	r := &ip.Route{
+		DevName: wgName,
+		Proto:   "static",
+		Family:  ip.FamilyV4,
+	}
+
+	installed, err := r.Show()
+	if err != nil {
+		return err
+	}
+
+	for _, line := range installed {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		_, found := routes[fields[0]]
+		if found {
+			delete(routes, fields[0])
+			continue
+		}
+
+		staleRoute := &ip.Route{
+			DevName: wgName,
+			Route:   fields[0],
+			Proto:   "static",
+			Family:  ip.FamilyV4,
+		}
+
+		err = staleRoute.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	for route := range routes {
+		err = r.Replace([]string{route})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop stops the network.
+func (n *wireguard) Stop() error {
+	n.logger.Debug("Stop")
+
+	// Remove the WireGuard interface, this also removes the routes to the other members.
+	wgLink := &ip.Link{Name: n.wireguardName()}
+	if InterfaceExists(wgLink.Name) {
+		err := wgLink.Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return n.localBridge().Stop()
+}
+
+// Delete deletes a network.
+func (n *wireguard) Delete(clientType request.ClientType) error {
+	n.logger.Debug("Delete", logger.Ctx{"clientType": clientType})
+
+	if n.isRunning() {
+		err := n.Stop()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Delete apparmor profiles.
+	err := apparmor.NetworkDelete(n.state.OS, n)
+	if err != nil {
+		return err
+	}
+
+	return n.common.delete(clientType)
+}
+
+// Rename renames a network.
+func (n *wireguard) Rename(newName string) error {
+	n.logger.Debug("Rename", logger.Ctx{"newName": newName})
+
+	if InterfaceExists(newName) {
+		return fmt.Errorf("Network interface %q already exists", newName)
+	}
+
+	// Bring the network down.
+	if n.isRunning() {
+		err := n.Stop()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Rename common steps.
+	err := n.common.rename(newName)
+	if err != nil {
+		return err
+	}
+
+	// Bring the network up.
+	return n.Start()
+}
+
+// Update updates the network. Accepts notification boolean indicating if this update request is coming from a
+// cluster notification, in which case do not update the database, just apply local changes needed.
+func (n *wireguard) Update(newNetwork api.NetworkPut, targetNode string, clientType request.ClientType) error {
+	n.logger.Debug("Update", logger.Ctx{"clientType": clientType, "newNetwork": newNetwork})
+
+	// Keep the member's mesh state when not part of the request.
+	for _, key := range []string{"volatile.wireguard.ipv4.address", "volatile.wireguard.public_key"} {
+		if newNetwork.Config[key] == "" && n.config[key] != "" {
+			newNetwork.Config[key] = n.config[key]
+		}
+	}
+
+	err := n.populateAutoConfig(newNetwork.Config)
+	if err != nil {
+		return fmt.Errorf("Failed generating auto config: %w", err)
+	}
+
+	dbUpdateNeeeded, changedKeys, oldNetwork, err := n.common.configChanged(newNetwork)
+	if err != nil {
+		return err
+	}
+
+	if !dbUpdateNeeeded {
+		return nil // Nothing changed.
+	}
+
+	// If the network as a whole has not had any previous creation attempts, or the node itself is still
+	// pending, then don't apply the new settings to the node, just to the database record (ready for the
+	// actual global create request to be initiated).
+	if n.Status() == api.NetworkStatusPending || n.LocalStatus() == api.NetworkStatusPending {
+		return n.common.update(newNetwork, targetNode, clientType)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	revert.Add(func() {
+		// Reset changes to all nodes and database.
+		_ = n.common.update(oldNetwork, targetNode, clientType)
+
+		// Reset any change that was made to the local member.
+		_ = n.setup()
+	})
+
+	// Apply changes to all nodes and database.
+	err = n.common.update(newNetwork, targetNode, clientType)
+	if err != nil {
+		return err
+	}
+
+	// Restart the network if needed.
+	if len(changedKeys) > 0 {
+		err = n.setup()
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// HandleHeartbeat refreshes the WireGuard peers using the addresses of the online cluster members.
+func (n *wireguard) HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error {
+	if !n.isRunning() {
+		return nil
+	}
+
+	members := map[int64]string{}
+	for _, member := range heartbeatData.Members {
+		if !member.Online {
+			continue
+		}
+
+		members[member.ID] = member.Address
+	}
+
+	return n.refreshPeers(members)
+}
+
+// DHCPv4Subnet returns the DHCPv4 subnet of the local member (if DHCP is enabled on network).
+func (n *wireguard) DHCPv4Subnet() *net.IPNet {
+	// DHCP is disabled on this network.
+	if !n.hasDHCPv4() {
+		return nil
+	}
+
+	_, subnet, err := net.ParseCIDR(n.config["volatile.wireguard.ipv4.address"])
+	if err != nil {
+		return nil
+	}
+
+	return subnet
+}
+
+// DHCPv6Subnet returns nil as WireGuard networks are IPv4 only.
+func (n *wireguard) DHCPv6Subnet() *net.IPNet {
+	return nil
+}
+
+// UsesDNSMasq indicates if network's config indicates if it needs to use dnsmasq.
+func (n *wireguard) UsesDNSMasq() bool {
+	return true
+}
+
+// wireguardName returns the name of the WireGuard interface of the network.
+func (n *wireguard) wireguardName() string {
+	return fmt.Sprintf("%s-wg", n.name)
+}
+
+// privateKeyPath returns the path of the member's WireGuard private key.
+func (n *wireguard) privateKeyPath() string {
+	return shared.VarPath("networks", n.name, "wireguard.key")
+}
+
+// port returns the UDP port used by the mesh.
+func (n *wireguard) port() string {
+	if n.config["wireguard.port"] != "" {
+		return n.config["wireguard.port"]
+	}
+
+	return wireguardDefaultPort
+}
+
+// wireguardAllocateSubnet returns the gateway address (in CIDR format) of the first member sized subnet of the
+// overlay which isn't in the list of used subnets.
+func wireguardAllocateSubnet(overlaySubnet *net.IPNet, usedSubnets []*net.IPNet) (string, error) {
+	ones, _ := overlaySubnet.Mask.Size()
+	start := binary.BigEndian.Uint32(overlaySubnet.IP.To4())
+
+	for i := uint32(0); i < 1<<(wireguardMemberPrefix-ones); i++ {
+		subnetIP := make(net.IP, 4)
+		binary.BigEndian.PutUint32(subnetIP, start+i<<(32-wireguardMemberPrefix))
+
+		used := false
+		for _, usedSubnet := range usedSubnets {
+			if usedSubnet.Contains(subnetIP) {
+				used = true
+				break
+			}
+		}
+
+		if used {
+			continue
+		}
+
+		subnet := &net.IPNet{IP: subnetIP, Mask: net.CIDRMask(wireguardMemberPrefix, 32)}
+
+		return fmt.Sprintf("%s/%d", dhcpalloc.GetIP(subnet, 1).String(), wireguardMemberPrefix), nil
+	}
+
+	return "", fmt.Errorf("No free /%d subnet left in overlay %q", wireguardMemberPrefix, overlaySubnet.String())
+}
+
+// wireguardGenerateKey returns a new base64 encoded WireGuard private key.
+func wireguardGenerateKey() (string, error) {
+	privateKey := make([]byte, curve25519.ScalarSize)
+	_, err := rand.Read(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("Failed generating WireGuard private key: %w", err)
+	}
+
+	// Clamp the key as expected by WireGuard.
+	privateKey[0] &= 248
+	privateKey[31] = (privateKey[31] & 127) | 64
+
+	return base64.StdEncoding.EncodeToString(privateKey), nil
+}
+
+// wireguardPublicKey returns the base64 encoded public key of a base64 encoded WireGuard private key.
+func wireguardPublicKey(privateKey string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("Failed parsing WireGuard private key: %w", err)
+	}
+
+	publicKey, err := curve25519.X25519(key, curve25519.Basepoint)
+	if err != nil {
+		return "", fmt.Errorf("Failed deriving WireGuard public key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+// wireguardBridgeConfig returns the config of a member's local bridge, derived from the mesh config and the
+// subnet allocated to the member.
+func wireguardBridgeConfig(netConfig map[string]string) map[string]string {
+	config := map[string]string{
+		"bridge.mtu":   wireguardDefaultMTU,
+		"ipv4.address": netConfig["volatile.wireguard.ipv4.address"],
+		"ipv6.address": "none",
+	}
+
+	for _, key := range []string{"bridge.mtu", "dns.domain", "dns.mode", "dns.search", "ipv4.dhcp", "ipv4.dhcp.expiry", "ipv4.firewall", "ipv4.nat", "ipv4.nat.order", "raw.dnsmasq"} {
+		value, found := netConfig[key]
+		if found {
+			config[key] = value
+		}
+	}
+
+	for key, value := range netConfig {
+		if strings.HasPrefix(key, DHCPv4OptionsPrefix) {
+			config[key] = value
+		}
+	}
+
+	return config
+}
+
+// wireguardPeer represents the WireGuard peer of another cluster member.
+type wireguardPeer struct {
+	publicKey  string
+	endpoint   string
+	allowedIPs string
+}
+
+// wireguardPeers returns the peers of the members (other than memberID) which have joined the mesh and have an
+// address in members. Accepts the member specific network config and the cluster member addresses keyed by
+// member ID.
+func wireguardPeers(membersConfig map[int64]map[string]string, members map[int64]string, memberID int64, port string) []wireguardPeer {
+	peers := []wireguardPeer{}
+	for id, memberConfig := range membersConfig {
+		if id == memberID {
+			continue
+		}
+
+		publicKey := memberConfig["volatile.wireguard.public_key"]
+		_, subnet, err := net.ParseCIDR(memberConfig["volatile.wireguard.ipv4.address"])
+		if publicKey == "" || err != nil {
+			continue // Member hasn't joined the mesh yet.
+		}
+
+		memberAddress, found := members[id]
+		if !found {
+			continue
+		}
+
+		host, _, err := net.SplitHostPort(memberAddress)
+		if err != nil {
+			host = memberAddress
+		}
+
+		peers = append(peers, wireguardPeer{
+			publicKey:  publicKey,
+			endpoint:   net.JoinHostPort(host, port),
+			allowedIPs: subnet.String(),
+		})
+	}
+
+	// Keep the peers in a stable order.
+	sort.Slice(peers, func(i, j int) bool { return peers[i].allowedIPs < peers[j].allowedIPs })
+
+	return peers
+}
+
+// randomOverlaySubnetV4 returns a random unused /16 IPv4 subnet to be used as a WireGuard overlay.
+func randomOverlaySubnetV4() (string, error) {
+	for i := 0; i < 100; i++ {
+		cidr := fmt.Sprintf("10.%d.0.0/16", mathRand.Intn(255))
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+
+		if inRoutingTable(subnet) {
+			continue
+		}
+
+		return cidr, nil
+	}
+
+	return "", fmt.Errorf("Failed to automatically find an unused IPv4 subnet, manual configuration required")
+}
@@ -0,0 +1,137 @@
+package network
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"sort"
+)
+
+func Example_wireguardAllocateSubnet() {
+	_, overlay, _ := net.ParseCIDR("10.120.0.0/22")
+
+	usedSubnets := []*net.IPNet{}
+	for i := 0; i < 5; i++ {
+		gateway, err := wireguardAllocateSubnet(overlay, usedSubnets)
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+
+		fmt.Println(gateway)
+
+		_, subnet, _ := net.ParseCIDR(gateway)
+		usedSubnets = append(usedSubnets, subnet)
+	}
+
+	// A subnet freed up by a member leaving the mesh is reused.
+	gateway, _ := wireguardAllocateSubnet(overlay, usedSubnets[1:])
+	fmt.Println(gateway)
+
+	// Output: 10.120.0.1/24
+	// 10.120.1.1/24
+	// 10.120.2.1/24
+	// 10.120.3.1/24
+	// No free /24 subnet left in overlay "10.120.0.0/22"
+	// 10.120.0.1/24
+}
+
+func Example_wireguardGenerateKey() {
+	privateKey, err := wireguardGenerateKey()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	key, _ := base64.StdEncoding.DecodeString(privateKey)
+	fmt.Println(len(key), key[0]&7, key[31]&128, key[31]&64)
+
+	otherKey, _ := wireguardGenerateKey()
+	fmt.Println(otherKey == privateKey)
+
+	_, err = wireguardPublicKey(privateKey)
+	fmt.Println(err)
+
+	// Output: 32 0 0 64
+	// false
+	// <nil>
+}
+
+func Example_wireguardPublicKey() {
+	// Test vector from RFC 7748 section 6.1.
+	publicKey, err := wireguardPublicKey("dwdtCnMYpX08FsFyUbJmRd9ML4frwJkqsXf7pR25LCo=")
+	fmt.Println(publicKey, err)
+
+	_, err = wireguardPublicKey("not a key")
+	fmt.Println(err != nil)
+
+	// Output: hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTmo= <nil>
+	// true
+}
+
+func Example_wireguardBridgeConfig() {
+	printConfig := func(config map[string]string) {
+		keys := make([]string, 0, len(config))
+		for key := range config {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s=%s\n", key, config[key])
+		}
+
+		fmt.Println("---")
+	}
+
+	printConfig(wireguardBridgeConfig(map[string]string{
+		"wireguard.overlay_subnet":        "10.120.0.0/16",
+		"volatile.wireguard.ipv4.address": "10.120.3.1/24",
+		"volatile.wireguard.public_key":   "hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTmo=",
+	}))
+
+	printConfig(wireguardBridgeConfig(map[string]string{
+		"wireguard.overlay_subnet":        "10.120.0.0/16",
+		"volatile.wireguard.ipv4.address": "10.120.3.1/24",
+		"bridge.mtu":                      "1380",
+		"ipv4.nat":                        "true",
+		"ipv4.dhcp.options.ntp_server":    "10.120.0.10",
+		"ipv6.address":                    "fd42::1/64",
+		"ipv6.nat":                        "true",
+	}))
+
+	// Output: bridge.mtu=1420
+	// ipv4.address=10.120.3.1/24
+	// ipv6.address=none
+	// ---
+	// bridge.mtu=1380
+	// ipv4.address=10.120.3.1/24
+	// ipv4.dhcp.options.ntp_server=10.120.0.10
+	// ipv4.nat=true
+	// ipv6.address=none
+	// ---
+}
+
+func Example_wireguardPeers() {
+	membersConfig := map[int64]map[string]string{
+		1: {"volatile.wireguard.ipv4.address": "10.120.0.1/24", "volatile.wireguard.public_key": "key1"},
+		2: {"volatile.wireguard.ipv4.address": "10.120.2.1/24", "volatile.wireguard.public_key": "key2"},
+		3: {"volatile.wireguard.ipv4.address": "10.120.1.1/24", "volatile.wireguard.public_key": "key3"},
+		4: {"volatile.wireguard.ipv4.address": "10.120.3.1/24"},
+		5: {"volatile.wireguard.ipv4.address": "10.120.4.1/24", "volatile.wireguard.public_key": "key5"},
+	}
+
+	members := map[int64]string{
+		1: "10.0.0.1:8443",
+		2: "10.0.0.2:8443",
+		3: "[fd00::3]:8443",
+		4: "10.0.0.4:8443",
+	}
+
+	for _, peer := range wireguardPeers(membersConfig, members, 1, "51820") {
+		fmt.Println(peer.publicKey, peer.endpoint, peer.allowedIPs)
+	}
+
+	// Output: key3 [fd00::3]:51820 10.120.1.0/24
+	// key2 10.0.0.2:51820 10.120.2.0/24
+}
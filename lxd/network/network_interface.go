@@ -57,12 +57,21 @@ type Network interface {
 	// Status.
 	State() (*api.NetworkState, error)
 	Leases(projectName string, clientType request.ClientType) ([]api.NetworkLease, error)
+	BGPState() (*api.NetworkBGPState, error)
+	BGPImportRoutes(clusterLeader bool) error
 
 	// Address Forwards.
 	ForwardCreate(forward api.NetworkForwardsPost, clientType request.ClientType) error
 	ForwardUpdate(listenAddress string, newForward api.NetworkForwardPut, clientType request.ClientType) error
 	ForwardDelete(listenAddress string, clientType request.ClientType) error
 
+	// Load Balancers.
+	LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error
+	LoadBalancerUpdate(listenAddress string, newLoadBalancer api.NetworkLoadBalancerPut, clientType request.ClientType) error
+	LoadBalancerDelete(listenAddress string, clientType request.ClientType) error
+	LoadBalancerState(listenAddress string) (*api.NetworkLoadBalancerState, error)
+	LoadBalancerHealthCheck() error
+
 	// Peerings.
 	PeerCreate(forward api.NetworkPeersPost) error
 	PeerUpdate(peerName string, newPeer api.NetworkPeerPut) error
@@ -31,6 +31,7 @@ import (
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/units"
 	"github.com/lxc/lxd/shared/version"
 )
 
@@ -51,6 +52,16 @@ func networkValidPort(value string) error {
 	return nil
 }
 
+// networkValidBitRate validates a bandwidth value in bit/s (various suffixes supported).
+func networkValidBitRate(value string) error {
+	_, err := units.ParseBitSizeString(value)
+	if err != nil {
+		return fmt.Errorf("Invalid bandwidth %q: %w", value, err)
+	}
+
+	return nil
+}
+
 // RandomDevName returns a random device name with prefix.
 // If the random string combined with the prefix exceeds 13 characters then empty string is returned.
 // This is to ensure we support buggy dhclient applications: https://bugs.debian.org/cgi-bin/bugreport.cgi?bug=858580
@@ -365,8 +376,9 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 		return err
 	}
 
-	// Build a list of dhcp host entries.
+	// Build a list of dhcp host entries and of the device specific DHCP options.
 	entries := map[string][][]string{}
+	dhcpOptions := map[string][]string{}
 	for _, inst := range insts {
 		// Go through all its devices (including profiles).
 		for deviceName, d := range inst.ExpandedDevices() {
@@ -419,6 +431,12 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 			}
 
 			entries[d["parent"]] = append(entries[d["parent"]], []string{d["hwaddr"], inst.Project(), inst.Name(), d["ipv4.address"], d["ipv6.address"], deviceName})
+
+			// Keep track of the device specific DHCP options.
+			deviceDHCPOptions := DHCPv4OptionsDnsmasq(d)
+			if len(deviceDHCPOptions) > 0 {
+				dhcpOptions[dnsmasq.StaticAllocationFileName(inst.Project(), inst.Name(), deviceName)] = deviceDHCPOptions
+			}
 		}
 	}
 
@@ -440,16 +458,18 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 		config := n.Config()
 
 		// Wipe everything clean.
-		files, err := ioutil.ReadDir(shared.VarPath("networks", network, "dnsmasq.hosts"))
-		if err != nil {
-			return err
-		}
-
-		for _, entry := range files {
-			err = os.Remove(shared.VarPath("networks", network, "dnsmasq.hosts", entry.Name()))
-			if err != nil {
+		for _, dir := range []string{"dnsmasq.hosts", "dnsmasq.opts"} {
+			files, err := ioutil.ReadDir(shared.VarPath("networks", network, dir))
+			if err != nil && !os.IsNotExist(err) {
 				return err
 			}
+
+			for _, entry := range files {
+				err = os.Remove(shared.VarPath("networks", network, dir, entry.Name()))
+				if err != nil {
+					return err
+				}
+			}
 		}
 
 		// Apply the changes.
@@ -496,7 +516,7 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 			}
 
 			// Generate the dhcp-host line.
-			err := dnsmasq.UpdateStaticEntry(network, projectName, cName, deviceName, config, hwaddr, ipv4Address, ipv6Address)
+			err := dnsmasq.UpdateStaticEntry(network, projectName, cName, deviceName, config, hwaddr, ipv4Address, ipv6Address, dhcpOptions[dnsmasq.StaticAllocationFileName(projectName, cName, deviceName)])
 			if err != nil {
 				return err
 			}
@@ -1308,3 +1328,30 @@ func BridgeNetfilterEnabled(ipVersion uint) error {
 
 	return nil
 }
+
+// bgpNetworkKey returns the key identifying a network's peers and policies on the BGP server.
+func bgpNetworkKey(networkID int64) string {
+	return fmt.Sprintf("network_%d", networkID)
+}
+
+// bgpPolicyNetwork returns the key of the network whose BGP peer policies apply to the prefixes advertised for the
+// network with the given ID and config. For OVN networks (the only ones with an uplink network in their config)
+// this is their uplink network, which holds the BGP peers.
+func bgpPolicyNetwork(s *state.State, networkID int64, config map[string]string) (string, error) {
+	if config["network"] != "" {
+		uplinkID, _, _, err := s.DB.Cluster.GetNetworkInAnyState(project.Default, config["network"])
+		if err != nil {
+			return "", fmt.Errorf("Failed loading uplink network %q: %w", config["network"], err)
+		}
+
+		networkID = uplinkID
+	}
+
+	return bgpNetworkKey(networkID), nil
+}
+
+// BGPPolicyNetwork returns the key of the network whose BGP peer policies apply to the prefixes advertised for the
+// network, to be used when adding prefixes to the BGP server.
+func BGPPolicyNetwork(s *state.State, n Network) (string, error) {
+	return bgpPolicyNetwork(s, n.ID(), n.Config())
+}
@@ -3,6 +3,7 @@ package openvswitch
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -72,6 +73,7 @@ const ovnExtIDLXDSwitch = "lxd_switch"
 const ovnExtIDLXDSwitchPort = "lxd_switch_port"
 const ovnExtIDLXDProjectID = "lxd_project_id"
 const ovnExtIDLXDPortGroup = "lxd_port_group"
+const ovnExtIDLXDACLRule = "lxd_acl_rule"
 
 // ErrOVNNoPortIPs used when no IPs are found for a logical port.
 var ErrOVNNoPortIPs = fmt.Errorf("No port IPs")
@@ -102,6 +104,7 @@ type OVNDHCPv4Opts struct {
 	DomainName         string
 	LeaseTime          time.Duration
 	MTU                uint32
+	Options            map[string]string // Additional options keyed by OVN option name.
 }
 
 // OVNDHCPv6Opts IPv6 DHCP option set that can be created (and then applied to a switch port by resulting ID).
@@ -121,12 +124,19 @@ type OVNSwitchPortOpts struct {
 
 // OVNACLRule represents an ACL rule that can be added to a logical switch or port group.
 type OVNACLRule struct {
-	Direction string // Either "from-lport" or "to-lport".
-	Action    string // Either "allow-related", "allow", "drop", or "reject".
-	Match     string // Match criteria. See OVN Southbound database's Logical_Flow table match column usage.
-	Priority  int    // Priority (between 0 and 32767, inclusive). Higher values take precedence.
-	Log       bool   // Whether or not to log matched packets.
-	LogName   string // Log label name (requires Log be true).
+	Direction   string // Either "from-lport" or "to-lport".
+	Action      string // Either "allow-related", "allow", "drop", or "reject".
+	Match       string // Match criteria. See OVN Southbound database's Logical_Flow table match column usage.
+	Priority    int    // Priority (between 0 and 32767, inclusive). Higher values take precedence.
+	Log         bool   // Whether or not to log matched packets.
+	LogName     string // Log label name (requires Log be true).
+	CounterName string // Counter label name used to report the hit counters of the rule.
+}
+
+// OVNLoadBalancerTarget represents an OVN load balancer Virtual IP target.
+type OVNLoadBalancerTarget struct {
+	Address net.IP
+	Port    uint64
 }
 
 // OVNLoadBalancerVIP represents a OVN load balancer Virtual IP entry.
@@ -134,8 +144,7 @@ type OVNLoadBalancerVIP struct {
 	Protocol      string // Either "tcp" or "udp". But only applies to port based VIPs.
 	ListenAddress net.IP
 	ListenPort    uint64
-	TargetAddress net.IP
-	TargetPort    uint64
+	Targets       []OVNLoadBalancerTarget
 }
 
 // OVNRouterRoute represents a static route added to a logical router.
@@ -759,6 +768,17 @@ func (o *OVN) LogicalSwitchDHCPv4OptionsSet(switchName OVNSwitch, uuid OVNDHCPOp
 		args = append(args, fmt.Sprintf("mtu=%d", opts.MTU))
 	}
 
+	optionNames := make([]string, 0, len(opts.Options))
+	for name := range opts.Options {
+		optionNames = append(optionNames, name)
+	}
+
+	sort.Strings(optionNames)
+
+	for _, name := range optionNames {
+		args = append(args, fmt.Sprintf("%s=%s", name, opts.Options[name]))
+	}
+
 	_, err = o.nbctl(args...)
 	if err != nil {
 		return err
@@ -879,6 +899,107 @@ func (o *OVN) LogicalSwitchDHCPOptionsDelete(switchName OVNSwitch, uuids ...OVND
 	return nil
 }
 
+// LogicalSwitchPortDHCPv4OptionsSet creates or updates the DHCPv4 option set specific to a switch port.
+// The option set is a copy of the base option set with the specified options added to it.
+// Returns the UUID of the port specific option set.
+func (o *OVN) LogicalSwitchPortDHCPv4OptionsSet(portName OVNSwitchPort, baseUUID OVNDHCPOptionsUUID, options map[string]string) (OVNDHCPOptionsUUID, error) {
+	baseCIDR, err := o.nbctl("get", "dhcp_options", string(baseUUID), "cidr")
+	if err != nil {
+		return "", err
+	}
+
+	baseOptions, err := o.nbctl("get", "dhcp_options", string(baseUUID), "options")
+	if err != nil {
+		return "", err
+	}
+
+	uuids, err := o.logicalSwitchPortDHCPOptions(portName)
+	if err != nil {
+		return "", err
+	}
+
+	var uuid string
+	if len(uuids) > 0 {
+		uuid = uuids[0]
+	} else {
+		uuidRaw, err := o.nbctl("create", "dhcp_options",
+			fmt.Sprintf("external_ids:%s=%s", ovnExtIDLXDSwitchPort, portName),
+			fmt.Sprintf("cidr=%s", strings.TrimSpace(baseCIDR)),
+		)
+		if err != nil {
+			return "", err
+		}
+
+		uuid = strings.TrimSpace(uuidRaw)
+	}
+
+	args := []string{"set", "dhcp_options", uuid,
+		fmt.Sprintf("cidr=%s", strings.TrimSpace(baseCIDR)),
+		fmt.Sprintf("options=%s", strings.TrimSpace(baseOptions)),
+	}
+
+	optionNames := make([]string, 0, len(options))
+	for name := range options {
+		optionNames = append(optionNames, name)
+	}
+
+	sort.Strings(optionNames)
+
+	for _, name := range optionNames {
+		args = append(args, fmt.Sprintf("options:%s=%s", name, strconv.Quote(options[name])))
+	}
+
+	_, err = o.nbctl(args...)
+	if err != nil {
+		return "", err
+	}
+
+	return OVNDHCPOptionsUUID(uuid), nil
+}
+
+// logicalSwitchPortDHCPOptions returns the UUIDs of the DHCP option sets specific to a switch port.
+func (o *OVN) logicalSwitchPortDHCPOptions(portName OVNSwitchPort) ([]string, error) {
+	output, err := o.nbctl("--format=csv", "--no-headings", "--data=bare", "--colum=_uuid", "find", "dhcp_options",
+		fmt.Sprintf("external_ids:%s=%s", ovnExtIDLXDSwitchPort, portName),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return shared.SplitNTrimSpace(strings.TrimSpace(output), "\n", -1, true), nil
+}
+
+// logicalSwitchPortDeleteDHCPOptionsAppendArgs adds the commands to delete the specified DHCP option sets.
+// Returns args with the commands added to it.
+func (o *OVN) logicalSwitchPortDeleteDHCPOptionsAppendArgs(args []string, uuids []string) []string {
+	for _, uuid := range uuids {
+		if len(args) > 0 {
+			args = append(args, "--")
+		}
+
+		args = append(args, "destroy", "dhcp_options", uuid)
+	}
+
+	return args
+}
+
+// LogicalSwitchPortDHCPOptionsDelete deletes the DHCP option sets specific to a switch port.
+func (o *OVN) LogicalSwitchPortDHCPOptionsDelete(portName OVNSwitchPort) error {
+	uuids, err := o.logicalSwitchPortDHCPOptions(portName)
+	if err != nil {
+		return err
+	}
+
+	if len(uuids) > 0 {
+		_, err = o.nbctl(o.logicalSwitchPortDeleteDHCPOptionsAppendArgs(nil, uuids)...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // logicalSwitchDNSRecordsDelete deletes any DNS records defined for a switch.
 func (o *OVN) logicalSwitchDNSRecordsDelete(switchName OVNSwitch) error {
 	uuids, err := o.nbctl("--format=csv", "--no-headings", "--data=bare", "--colum=_uuid", "find", "dns",
@@ -1275,6 +1396,11 @@ func (o *OVN) LogicalSwitchPortCleanup(portName OVNSwitchPort, switchName OVNSwi
 		return err
 	}
 
+	dhcpUUIDs, err := o.logicalSwitchPortDHCPOptions(portName)
+	if err != nil {
+		return err
+	}
+
 	args := o.aclRuleDeleteAppendArgs(nil, "port_group", string(switchPortGroupName), removeACLRuleUUIDs)
 
 	// Remove logical switch port.
@@ -1283,6 +1409,9 @@ func (o *OVN) LogicalSwitchPortCleanup(portName OVNSwitchPort, switchName OVNSwi
 	// Remove DNS records.
 	args = o.logicalSwitchPortDeleteDNSAppendArgs(args, switchName, dnsUUID)
 
+	// Remove port specific DHCP options.
+	args = o.logicalSwitchPortDeleteDHCPOptionsAppendArgs(args, dhcpUUIDs)
+
 	_, err = o.nbctl(args...)
 	if err != nil {
 		return err
@@ -1399,6 +1528,89 @@ func (o *OVN) ChassisGroupChassisDelete(haChassisGroupName OVNChassisGroup, chas
 	return nil
 }
 
+// PortGroupACLRuleFlowCookies returns the OpenFlow cookies of the logical flows generated for each ACL rule of the
+// specified port groups, keyed by the rule counter name.
+func (o *OVN) PortGroupACLRuleFlowCookies(portGroupNames ...OVNPortGroup) (map[string][]uint64, error) {
+	if len(portGroupNames) == 0 {
+		return map[string][]uint64{}, nil
+	}
+
+	// Fetch all the ACLs and logical flows at once rather than querying the logical flows of each ACL.
+	acls, err := o.nbctl("--format=csv", "--no-headings", "--data=bare", "--colum=_uuid,external_ids", "list", "acl")
+	if err != nil {
+		return nil, err
+	}
+
+	flows, err := o.sbctl("--format=csv", "--no-headings", "--data=bare", "--colum=_uuid,external_ids", "list", "logical_flow")
+	if err != nil {
+		return nil, err
+	}
+
+	return ovnACLRuleFlowCookies(acls, flows, portGroupNames...)
+}
+
+// ovnACLRuleFlowCookies matches the output of the ACL and logical flow listings and returns the OpenFlow cookies
+// of the logical flows generated for each ACL rule of the specified port groups, keyed by the rule counter name.
+func ovnACLRuleFlowCookies(acls string, flows string, portGroupNames ...OVNPortGroup) (map[string][]uint64, error) {
+	// parseLine parses a line of "_uuid,external_ids" output into the UUID and the external IDs.
+	// E.g. "c709c4a8-ef3f-4ffe-a45a-c75295eb2698,lxd_acl_rule=ingress-0 lxd_port_group=lxd_acl1"
+	parseLine := func(line string) (string, map[string]string) {
+		fields := shared.SplitNTrimSpace(line, ",", 2, true)
+		if len(fields) != 2 || len(fields[0]) < 8 {
+			return "", nil
+		}
+
+		extIDs := map[string]string{}
+		for _, extID := range strings.Fields(strings.Trim(fields[1], `"`)) {
+			key, value, found := strings.Cut(extID, "=")
+			if found {
+				extIDs[key] = value
+			}
+		}
+
+		return fields[0], extIDs
+	}
+
+	wantedPortGroups := make(map[string]bool, len(portGroupNames))
+	for _, portGroupName := range portGroupNames {
+		wantedPortGroups[string(portGroupName)] = true
+	}
+
+	// Logical flows reference the ACL they were generated from by the first 8 characters of its UUID.
+	counterNames := map[string]string{}
+	for _, line := range shared.SplitNTrimSpace(strings.TrimSpace(acls), "\n", -1, true) {
+		aclUUID, extIDs := parseLine(line)
+		if aclUUID == "" || !wantedPortGroups[extIDs[ovnExtIDLXDPortGroup]] || extIDs[ovnExtIDLXDACLRule] == "" {
+			continue
+		}
+
+		counterNames[aclUUID[:8]] = extIDs[ovnExtIDLXDACLRule]
+	}
+
+	cookies := map[string][]uint64{}
+	for _, line := range shared.SplitNTrimSpace(strings.TrimSpace(flows), "\n", -1, true) {
+		flowUUID, extIDs := parseLine(line)
+		if flowUUID == "" {
+			continue
+		}
+
+		counterName, found := counterNames[extIDs["stage-hint"]]
+		if !found {
+			continue
+		}
+
+		// The cookie of the OpenFlow flows is made of the first 32 bits of the logical flow UUID.
+		cookie, err := strconv.ParseUint(flowUUID[:8], 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Failed parsing logical flow UUID %q: %w", flowUUID, err)
+		}
+
+		cookies[counterName] = append(cookies[counterName], cookie)
+	}
+
+	return cookies, nil
+}
+
 // PortGroupInfo returns the port group UUID or empty string if port doesn't exist, and whether the port group has
 // any ACL rules defined on it.
 func (o *OVN) PortGroupInfo(portGroupName OVNPortGroup) (OVNPortGroupUUID, bool, error) {
@@ -1570,6 +1782,10 @@ func (o *OVN) aclRuleAddAppendArgs(args []string, entityTable string, entityName
 			}
 		}
 
+		if rule.CounterName != "" {
+			args = append(args, fmt.Sprintf("external_ids:%s=%s", ovnExtIDLXDACLRule, rule.CounterName))
+		}
+
 		for k, v := range externalIDs {
 			args = append(args, fmt.Sprintf("external_ids:%s=%s", k, v))
 		}
@@ -1669,12 +1885,12 @@ func (o *OVN) LoadBalancerApply(loadBalancerName OVNLoadBalancer, routers []OVNR
 			return fmt.Errorf("Missing VIP listen address")
 		}
 
-		if r.TargetAddress == nil {
-			return fmt.Errorf("Missing VIP target address")
+		if len(r.Targets) == 0 {
+			return fmt.Errorf("Missing VIP target")
 		}
 
-		if (r.ListenPort > 0 && r.TargetPort <= 0) || (r.TargetPort > 0 && r.ListenPort <= 0) {
-			return fmt.Errorf("The listen and target ports must be specified together")
+		if r.ListenPort > 0 && r.Protocol == "" {
+			return fmt.Errorf("Missing VIP protocol")
 		}
 
 		if r.Protocol == "udp" {
@@ -1685,16 +1901,34 @@ func (o *OVN) LoadBalancerApply(loadBalancerName OVNLoadBalancer, routers []OVNR
 			lbNames[lbTCPName] = struct{}{} // Record that TCP load balancer is created.
 		}
 
+		// Build up the list of targets, traffic is spread across all of them by OVN.
+		targetArgs := make([]string, 0, len(r.Targets))
+		for _, target := range r.Targets {
+			if target.Address == nil {
+				return fmt.Errorf("Missing VIP target address")
+			}
+
+			if (r.ListenPort > 0 && target.Port <= 0) || (target.Port > 0 && r.ListenPort <= 0) {
+				return fmt.Errorf("The listen and target ports must be specified together")
+			}
+
+			if r.ListenPort > 0 {
+				targetArgs = append(targetArgs, fmt.Sprintf("%s:%d", ipToString(target.Address), target.Port))
+			} else {
+				targetArgs = append(targetArgs, ipToString(target.Address))
+			}
+		}
+
 		if r.ListenPort > 0 {
 			args = append(args,
 				fmt.Sprintf("%s:%d", ipToString(r.ListenAddress), r.ListenPort),
-				fmt.Sprintf("%s:%d", ipToString(r.TargetAddress), r.TargetPort),
+				strings.Join(targetArgs, ","),
 				r.Protocol,
 			)
 		} else {
 			args = append(args,
 				ipToString(r.ListenAddress),
-				ipToString(r.TargetAddress),
+				strings.Join(targetArgs, ","),
 			)
 		}
 	}
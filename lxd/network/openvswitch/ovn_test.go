@@ -0,0 +1,35 @@
+package openvswitch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ovnACLRuleFlowCookies(t *testing.T) {
+	acls := `c709c4a8-ef3f-4ffe-a45a-c75295eb2698,"lxd_acl_rule=ingress-0 lxd_port_group=lxd_acl1"
+d1e2f3a4-0000-4000-8000-000000000001,"lxd_acl_rule=egress-0 lxd_port_group=lxd_acl1"
+e5f6a7b8-0000-4000-8000-000000000002,"lxd_acl_rule=ingress-0 lxd_port_group=lxd_acl2"
+f9a0b1c2-0000-4000-8000-000000000003,lxd_port_group=lxd_acl1`
+
+	flows := `8a5c2b1f-1111-4111-8111-111111111111,"source=northd.c:5990 stage-hint=c709c4a8 stage-name=ls_out_acl"
+8a5c2b20-1111-4111-8111-111111111112,"source=northd.c:5990 stage-hint=c709c4a8 stage-name=ls_out_acl"
+0b1c2d3e-1111-4111-8111-111111111113,"source=northd.c:5990 stage-hint=e5f6a7b8 stage-name=ls_out_acl"
+0f0f0f0f-1111-4111-8111-111111111114,"source=northd.c:6012 stage-hint=f9a0b1c2 stage-name=ls_in_acl"
+1a2b3c4d-1111-4111-8111-111111111115,"source=northd.c:4321 stage-name=ls_in_port_sec_l2"`
+
+	cookies, err := ovnACLRuleFlowCookies(acls, flows, "lxd_acl1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]uint64{
+		"ingress-0": {0x8a5c2b1f, 0x8a5c2b20},
+	}, cookies)
+
+	cookies, err = ovnACLRuleFlowCookies(acls, flows, "lxd_acl1", "lxd_acl2")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{0x8a5c2b1f, 0x8a5c2b20, 0x0b1c2d3e}, cookies["ingress-0"])
+	assert.NotContains(t, cookies, "egress-0")
+
+	cookies, err = ovnACLRuleFlowCookies(acls, flows)
+	assert.NoError(t, err)
+	assert.Empty(t, cookies)
+}
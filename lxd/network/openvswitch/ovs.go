@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"os/exec"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -127,6 +128,38 @@ func (o *OVS) BridgePortSet(portName string, options ...string) error {
 	return nil
 }
 
+// BridgePortMirrorAdd adds an internal port to the bridge and mirrors the traffic sent and received by the
+// specified port to it.
+func (o *OVS) BridgePortMirrorAdd(bridgeName string, portName string, mirrorName string, outputPortName string) error {
+	_, err := shared.RunCommand("ovs-vsctl", "add-port", bridgeName, outputPortName, "--", "set", "interface", outputPortName, "type=internal")
+	if err != nil {
+		return err
+	}
+
+	_, err = shared.RunCommand("ovs-vsctl",
+		"--", "--id=@src", "get", "port", portName,
+		"--", "--id=@out", "get", "port", outputPortName,
+		"--", "--id=@m", "create", "mirror", fmt.Sprintf("name=%s", mirrorName), "select-src-port=@src", "select-dst-port=@src", "output-port=@out",
+		"--", "add", "bridge", bridgeName, "mirrors", "@m",
+	)
+	if err != nil {
+		_ = o.BridgePortDelete(bridgeName, outputPortName)
+		return err
+	}
+
+	return nil
+}
+
+// BridgePortMirrorDelete removes a mirror and its output port from the bridge.
+func (o *OVS) BridgePortMirrorDelete(bridgeName string, mirrorName string, outputPortName string) error {
+	_, err := shared.RunCommand("ovs-vsctl", "--", "--id=@m", "get", "mirror", mirrorName, "--", "remove", "bridge", bridgeName, "mirrors", "@m")
+	if err != nil {
+		return err
+	}
+
+	return o.BridgePortDelete(bridgeName, outputPortName)
+}
+
 // InterfaceAssociateOVNSwitchPort removes any existing OVS ports associated to the specified ovnSwitchPortName
 // and then associates the specified interfaceName to the OVN switch port.
 func (o *OVS) InterfaceAssociateOVNSwitchPort(interfaceName string, ovnSwitchPortName OVNSwitchPort) error {
@@ -327,6 +360,61 @@ func (o *OVS) BridgePortList(bridgeName string) ([]string, error) {
 	return ports, nil
 }
 
+// OVSFlowCounter represents the hit counters of an OpenFlow flow.
+type OVSFlowCounter struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// BridgeFlowCounters returns the hit counters of the flows of a bridge, summed up by flow cookie.
+func (o *OVS) BridgeFlowCounters(bridgeName string) (map[uint64]OVSFlowCounter, error) {
+	output, err := shared.RunCommand("ovs-ofctl", "dump-flows", bridgeName)
+	if err != nil {
+		return nil, err
+	}
+
+	return ovsFlowCounters(output)
+}
+
+// ovsFlowCounters parses the output of "ovs-ofctl dump-flows" and returns the hit counters of the flows, summed
+// up by flow cookie.
+func ovsFlowCounters(output string) (map[uint64]OVSFlowCounter, error) {
+	var err error
+
+	counters := map[uint64]OVSFlowCounter{}
+	for _, line := range shared.SplitNTrimSpace(strings.TrimSpace(output), "\n", -1, true) {
+		// E.g. "cookie=0x8a5c2b1f, duration=10.5s, table=44, n_packets=10, n_bytes=840, priority=2002,..."
+		var cookie uint64
+		var counter OVSFlowCounter
+		for _, field := range shared.SplitNTrimSpace(line, ",", -1, true) {
+			key, value, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+
+			switch key {
+			case "cookie":
+				cookie, err = strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 64)
+			case "n_packets":
+				counter.Packets, err = strconv.ParseUint(value, 10, 64)
+			case "n_bytes":
+				counter.Bytes, err = strconv.ParseUint(value, 10, 64)
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("Failed parsing flow %q: %w", line, err)
+			}
+		}
+
+		total := counters[cookie]
+		total.Packets += counter.Packets
+		total.Bytes += counter.Bytes
+		counters[cookie] = total
+	}
+
+	return counters, nil
+}
+
 // HardwareOffloadingEnabled returns true if hardware offloading is enabled.
 func (o *OVS) HardwareOffloadingEnabled() bool {
 	// ovs-vsctl's get command doesn't support its --format flag, so we always get the output quoted.
@@ -0,0 +1,24 @@
+package openvswitch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ovsFlowCounters(t *testing.T) {
+	output := `NXST_FLOW reply (xid=0x4):
+ cookie=0x8a5c2b1f, duration=10.512s, table=44, n_packets=10, n_bytes=840, idle_age=2, priority=2002,tcp,metadata=0x1,tp_dst=22 actions=resubmit(,45)
+ cookie=0x8a5c2b1f, duration=10.512s, table=44, n_packets=2, n_bytes=160, idle_age=2, priority=2002,tcp6,metadata=0x1,tp_dst=22 actions=resubmit(,45)
+ cookie=0x0, duration=10.512s, table=0, n_packets=5, n_bytes=500, idle_age=2, priority=0 actions=drop`
+
+	counters, err := ovsFlowCounters(output)
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint64]OVSFlowCounter{
+		0x8a5c2b1f: {Packets: 12, Bytes: 1000},
+		0x0:        {Packets: 5, Bytes: 500},
+	}, counters)
+
+	_, err = ovsFlowCounters(` cookie=0x1, duration=1s, table=0, n_packets=x, n_bytes=0, priority=0 actions=drop`)
+	assert.Error(t, err)
+}
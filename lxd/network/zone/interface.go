@@ -1,6 +1,8 @@
 package zone
 
 import (
+	"github.com/miekg/dns"
+
 	"github.com/lxc/lxd/lxd/cluster/request"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared/api"
@@ -28,6 +30,7 @@ type NetworkZone interface {
 	GetRecord(name string) (*api.NetworkZoneRecord, error)
 	UpdateRecord(name string, req api.NetworkZoneRecordPut, clientType request.ClientType) error
 	DeleteRecord(name string) error
+	ApplyUpdate(peerName string, prerequisites func(content string) error, updates []dns.RR) error
 
 	// Internal validation.
 	validateName(name string) error
@@ -1,13 +1,20 @@
 package zone
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"path"
+	"strings"
 
 	"github.com/miekg/dns"
 
 	"github.com/lxc/lxd/lxd/cluster/request"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/locking"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
 )
 
 func (d *zone) AddRecord(req api.NetworkZoneRecordsPost) error {
@@ -143,3 +150,219 @@ func (d *zone) validateEntries(info api.NetworkZoneRecordPut) error {
 
 	return nil
 }
+
+// ApplyUpdate applies a set of RFC 2136 update records sent by the specified peer to the zone records.
+// The prerequisites function is called with the current zone content and the update is only applied if it succeeds.
+// Updates of the zone are serialized and the prerequisites are checked against the same records that the changes
+// are then applied to, within a single transaction, so that either all the changes get persisted or none.
+func (d *zone) ApplyUpdate(peerName string, prerequisites func(content string) error, updates []dns.RR) error {
+	allowedNames := shared.SplitNTrimSpace(d.info.Config[fmt.Sprintf("peers.%s.update.names", peerName)], ",", -1, true)
+	allowedTypes := shared.SplitNTrimSpace(strings.ToUpper(d.info.Config[fmt.Sprintf("peers.%s.update.types", peerName)]), ",", -1, true)
+
+	if len(allowedNames) == 0 || len(allowedTypes) == 0 {
+		return api.StatusErrorf(http.StatusForbidden, "Peer %q isn't allowed to update zone %q", peerName, d.info.Name)
+	}
+
+	unlock := locking.Lock(fmt.Sprintf("NetworkZoneUpdate_%d", d.id))
+	defer unlock()
+
+	// Get the records generated from the networks using the zone, these aren't affected by the update.
+	generated, err := d.generatedRecords()
+	if err != nil {
+		return err
+	}
+
+	changed := map[string][]api.NetworkZoneRecordEntry{}
+	err = d.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		// Load the current records.
+		dbRecords, err := tx.GetNetworkZoneRecords(d.id)
+		if err != nil {
+			return err
+		}
+
+		records := make([]api.NetworkZoneRecord, 0, len(dbRecords))
+		current := make(map[string]*api.NetworkZoneRecord, len(dbRecords))
+		currentIDs := make(map[string]int64, len(dbRecords))
+		for id, record := range dbRecords {
+			records = append(records, *record)
+			current[record.Name] = record
+			currentIDs[record.Name] = id
+		}
+
+		// Check the prerequisites against the current zone content.
+		content, err := d.render(generated, records)
+		if err != nil {
+			return err
+		}
+
+		err = prerequisites(strings.TrimSpace(content.String()))
+		if err != nil {
+			return err
+		}
+
+		changed, err = d.updateChanges(peerName, allowedNames, allowedTypes, current, updates)
+		if err != nil {
+			return err
+		}
+
+		// Persist the changes.
+		for name, entries := range changed {
+			id, ok := currentIDs[name]
+			if !ok {
+				if len(entries) == 0 {
+					continue
+				}
+
+				_, err = tx.CreateNetworkZoneRecord(d.id, api.NetworkZoneRecordsPost{Name: name, NetworkZoneRecordPut: api.NetworkZoneRecordPut{Entries: entries}})
+			} else if len(entries) == 0 {
+				err = tx.DeleteNetworkZoneRecord(id)
+			} else {
+				req := current[name].NetworkZoneRecordPut
+				req.Entries = entries
+				err = tx.UpdateNetworkZoneRecord(id, req)
+			}
+
+			if err != nil {
+				return fmt.Errorf("Failed updating record %q: %w", name, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for name := range changed {
+		d.logger.Info("Applied DNS update", logger.Ctx{"peer": peerName, "record": name})
+	}
+
+	return nil
+}
+
+// updateChanges returns the new entries of the records changed by the update records, after checking them
+// against the peer's permissions and validating them.
+func (d *zone) updateChanges(peerName string, allowedNames []string, allowedTypes []string, current map[string]*api.NetworkZoneRecord, updates []dns.RR) (map[string][]api.NetworkZoneRecordEntry, error) {
+	// Apply the changes to the in-memory records.
+	changed := map[string][]api.NetworkZoneRecordEntry{}
+	for _, rr := range updates {
+		hdr := rr.Header()
+		typeName := dns.TypeToString[hdr.Rrtype]
+
+		// Convert the owner name to a record name.
+		fqdn := strings.ToLower(dns.Fqdn(hdr.Name))
+		suffix := "." + strings.ToLower(d.info.Name) + "."
+		if !strings.HasSuffix(fqdn, suffix) {
+			return nil, api.StatusErrorf(http.StatusForbidden, "Name %q isn't a record of zone %q", hdr.Name, d.info.Name)
+		}
+
+		name := strings.TrimSuffix(fqdn, suffix)
+
+		if !zoneUpdateNameAllowed(allowedNames, name) {
+			return nil, api.StatusErrorf(http.StatusForbidden, "Peer %q isn't allowed to update record %q", peerName, name)
+		}
+
+		entries, ok := changed[name]
+		if !ok {
+			record, ok := current[name]
+			if ok {
+				entries = append(entries, record.Entries...)
+			}
+		}
+
+		switch hdr.Class {
+		case dns.ClassINET:
+			// Add to an RRset.
+			if !shared.StringInSlice(typeName, allowedTypes) {
+				return nil, api.StatusErrorf(http.StatusForbidden, "Peer %q isn't allowed to update %q entries", peerName, typeName)
+			}
+
+			value := strings.TrimPrefix(rr.String(), hdr.String())
+
+			found := false
+			for i, entry := range entries {
+				if entry.Type == typeName && zoneEntryValue(entry) == value {
+					entries[i].TTL = uint64(hdr.Ttl)
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				entries = append(entries, api.NetworkZoneRecordEntry{Type: typeName, TTL: uint64(hdr.Ttl), Value: value})
+			}
+
+		case dns.ClassANY:
+			// Delete an RRset or all RRsets of a name.
+			remaining := []api.NetworkZoneRecordEntry{}
+			for _, entry := range entries {
+				if hdr.Rrtype != dns.TypeANY && entry.Type != typeName {
+					remaining = append(remaining, entry)
+					continue
+				}
+
+				if !shared.StringInSlice(entry.Type, allowedTypes) {
+					return nil, api.StatusErrorf(http.StatusForbidden, "Peer %q isn't allowed to update %q entries", peerName, entry.Type)
+				}
+			}
+
+			entries = remaining
+
+		case dns.ClassNONE:
+			// Delete an RR from an RRset.
+			if !shared.StringInSlice(typeName, allowedTypes) {
+				return nil, api.StatusErrorf(http.StatusForbidden, "Peer %q isn't allowed to update %q entries", peerName, typeName)
+			}
+
+			value := strings.TrimPrefix(rr.String(), hdr.String())
+
+			remaining := []api.NetworkZoneRecordEntry{}
+			for _, entry := range entries {
+				if entry.Type == typeName && zoneEntryValue(entry) == value {
+					continue
+				}
+
+				remaining = append(remaining, entry)
+			}
+
+			entries = remaining
+
+		default:
+			return nil, fmt.Errorf("Invalid class %q for update of record %q", dns.ClassToString[hdr.Class], name)
+		}
+
+		changed[name] = entries
+	}
+
+	// Validate all the changes.
+	for _, entries := range changed {
+		err := d.validateEntries(api.NetworkZoneRecordPut{Entries: entries})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changed, nil
+}
+
+// zoneUpdateNameAllowed checks whether the record name matches one of the allowed name patterns.
+func zoneUpdateNameAllowed(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		match, err := path.Match(strings.ToLower(pattern), name)
+		if err == nil && match {
+			return true
+		}
+	}
+
+	return false
+}
+
+// zoneEntryValue returns the canonical representation of an entry's value.
+func zoneEntryValue(entry api.NetworkZoneRecordEntry) string {
+	rr, err := dns.NewRR(fmt.Sprintf("record %d IN %s %s", entry.TTL, entry.Type, entry.Value))
+	if err != nil || rr == nil {
+		return entry.Value
+	}
+
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
@@ -0,0 +1,174 @@
+package zone
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestZoneUpdateNameAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		record   string
+		allowed  bool
+	}{
+		{"Exact match", []string{"foo"}, "foo", true},
+		{"No match", []string{"foo"}, "bar", false},
+		{"No patterns", nil, "foo", false},
+		{"Wildcard", []string{"*"}, "foo", true},
+		{"Wildcard prefix", []string{"host-*"}, "host-1", true},
+		{"Wildcard prefix mismatch", []string{"host-*"}, "web-1", false},
+		{"Wildcard matches sub domains", []string{"_acme-challenge*"}, "_acme-challenge.www", true},
+		{"Wildcard in sub domain", []string{"*.sub"}, "foo.sub", true},
+		{"Single character", []string{"host-?"}, "host-1", true},
+		{"Pattern case is ignored", []string{"FOO"}, "foo", true},
+		{"Second pattern", []string{"foo", "bar"}, "bar", true},
+		{"Bad pattern", []string{"[foo"}, "foo", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, zoneUpdateNameAllowed(tt.patterns, tt.record))
+		})
+	}
+}
+
+func TestZoneUpdateChanges(t *testing.T) {
+	d := &zone{info: &api.NetworkZone{Name: "example.net"}}
+
+	current := map[string]*api.NetworkZoneRecord{
+		"foo": {
+			Name: "foo",
+			NetworkZoneRecordPut: api.NetworkZoneRecordPut{
+				Entries: []api.NetworkZoneRecordEntry{
+					{Type: "A", TTL: 300, Value: "192.0.2.1"},
+					{Type: "TXT", TTL: 300, Value: `"hello"`},
+				},
+			},
+		},
+	}
+
+	newRR := func(s string, class uint16) dns.RR {
+		rr, err := dns.NewRR(s)
+		require.NoError(t, err)
+
+		rr.Header().Class = class
+
+		return rr
+	}
+
+	tests := []struct {
+		name    string
+		types   []string
+		updates []dns.RR
+		changed map[string][]api.NetworkZoneRecordEntry
+		err     bool
+	}{
+		{
+			name:    "Add record",
+			updates: []dns.RR{newRR("bar.example.net. 60 IN A 192.0.2.2", dns.ClassINET)},
+			changed: map[string][]api.NetworkZoneRecordEntry{
+				"bar": {{Type: "A", TTL: 60, Value: "192.0.2.2"}},
+			},
+		},
+		{
+			name:    "Add entry to existing record",
+			updates: []dns.RR{newRR("foo.example.net. 60 IN A 192.0.2.2", dns.ClassINET)},
+			changed: map[string][]api.NetworkZoneRecordEntry{
+				"foo": {
+					{Type: "A", TTL: 300, Value: "192.0.2.1"},
+					{Type: "TXT", TTL: 300, Value: `"hello"`},
+					{Type: "A", TTL: 60, Value: "192.0.2.2"},
+				},
+			},
+		},
+		{
+			name:    "Add existing entry updates its TTL",
+			updates: []dns.RR{newRR("foo.example.net. 60 IN A 192.0.2.1", dns.ClassINET)},
+			changed: map[string][]api.NetworkZoneRecordEntry{
+				"foo": {
+					{Type: "A", TTL: 60, Value: "192.0.2.1"},
+					{Type: "TXT", TTL: 300, Value: `"hello"`},
+				},
+			},
+		},
+		{
+			name:    "Delete RRset",
+			updates: []dns.RR{newRR("foo.example.net. 0 IN A", dns.ClassANY)},
+			changed: map[string][]api.NetworkZoneRecordEntry{
+				"foo": {{Type: "TXT", TTL: 300, Value: `"hello"`}},
+			},
+		},
+		{
+			name:    "Delete RR",
+			updates: []dns.RR{newRR("foo.example.net. 0 IN A 192.0.2.1", dns.ClassNONE)},
+			changed: map[string][]api.NetworkZoneRecordEntry{
+				"foo": {{Type: "TXT", TTL: 300, Value: `"hello"`}},
+			},
+		},
+		{
+			name:    "Delete all RRsets",
+			types:   []string{"A", "TXT"},
+			updates: []dns.RR{newRR("foo.example.net. 0 IN ANY", dns.ClassANY)},
+			changed: map[string][]api.NetworkZoneRecordEntry{
+				"foo": {},
+			},
+		},
+		{
+			name: "Changes accumulate",
+			updates: []dns.RR{
+				newRR("foo.example.net. 0 IN A", dns.ClassANY),
+				newRR("foo.example.net. 60 IN A 192.0.2.3", dns.ClassINET),
+			},
+			changed: map[string][]api.NetworkZoneRecordEntry{
+				"foo": {
+					{Type: "TXT", TTL: 300, Value: `"hello"`},
+					{Type: "A", TTL: 60, Value: "192.0.2.3"},
+				},
+			},
+		},
+		{
+			name:    "Name outside zone",
+			updates: []dns.RR{newRR("foo.example.com. 60 IN A 192.0.2.2", dns.ClassINET)},
+			err:     true,
+		},
+		{
+			name:    "Name not allowed",
+			updates: []dns.RR{newRR("web.example.net. 60 IN A 192.0.2.2", dns.ClassINET)},
+			err:     true,
+		},
+		{
+			name:    "Type not allowed",
+			updates: []dns.RR{newRR("bar.example.net. 60 IN AAAA 2001:db8::1", dns.ClassINET)},
+			err:     true,
+		},
+		{
+			name:    "Deleting all RRsets with a type not allowed",
+			updates: []dns.RR{newRR("foo.example.net. 0 IN ANY", dns.ClassANY)},
+			err:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowedTypes := tt.types
+			if allowedTypes == nil {
+				allowedTypes = []string{"A"}
+			}
+
+			changed, err := d.updateChanges("peer", []string{"foo", "bar"}, allowedTypes, current, tt.updates)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.changed, changed)
+		})
+	}
+}
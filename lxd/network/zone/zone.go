@@ -3,9 +3,12 @@ package zone
 import (
 	"fmt"
 	"net"
+	"path"
 	"strings"
 	"time"
 
+	"github.com/miekg/dns"
+
 	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/cluster/request"
@@ -144,6 +147,7 @@ func (d *zone) validateConfig(info *api.NetworkZonePut) error {
 
 	// Regular config keys.
 	rules["dns.nameservers"] = validate.IsListOf(validate.IsAny)
+	rules["dns.query"] = validate.Optional(validate.IsBool)
 	rules["network.nat"] = validate.Optional(validate.IsBool)
 
 	// Validate peer config.
@@ -153,7 +157,7 @@ func (d *zone) validateConfig(info *api.NetworkZonePut) error {
 		}
 
 		// Validate remote name in key.
-		fields := strings.Split(k, ".")
+		fields := strings.SplitN(k, ".", 3)
 		if len(fields) != 3 {
 			return fmt.Errorf("Invalid network zone configuration key %q", k)
 		}
@@ -166,6 +170,20 @@ func (d *zone) validateConfig(info *api.NetworkZonePut) error {
 			rules[k] = validate.Optional(validate.IsNetworkAddress)
 		case "key":
 			rules[k] = validate.Optional(validate.IsAny)
+		case "update.names":
+			rules[k] = validate.Optional(validate.IsListOf(func(value string) error {
+				_, err := path.Match(value, "")
+				return err
+			}))
+		case "update.types":
+			rules[k] = validate.Optional(validate.IsListOf(func(value string) error {
+				_, ok := dns.StringToType[strings.ToUpper(value)]
+				if !ok {
+					return fmt.Errorf("Unknown DNS record type %q", value)
+				}
+
+				return nil
+			}))
 		}
 	}
 
@@ -290,6 +308,22 @@ func (d *zone) Delete() error {
 
 // Content returns the DNS zone content.
 func (d *zone) Content() (*strings.Builder, error) {
+	records, err := d.generatedRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the extra records.
+	extraRecords, err := d.GetRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.render(records, extraRecords)
+}
+
+// generatedRecords returns the records generated from the networks using the zone.
+func (d *zone) generatedRecords() ([]map[string]string, error) {
 	records := []map[string]string{}
 
 	// Check if we should include NAT records.
@@ -407,12 +441,11 @@ func (d *zone) Content() (*strings.Builder, error) {
 		}
 	}
 
-	// Add the extra records.
-	extraRecords, err := d.GetRecords()
-	if err != nil {
-		return nil, err
-	}
+	return records, nil
+}
 
+// render templates the zone file from the generated records and the extra records of the zone.
+func (d *zone) render(records []map[string]string, extraRecords []api.NetworkZoneRecord) (*strings.Builder, error) {
 	for _, extraRecord := range extraRecords {
 		for _, entry := range extraRecord.Entries {
 			record := map[string]string{}
@@ -448,7 +481,7 @@ func (d *zone) Content() (*strings.Builder, error) {
 
 	// Template the zone file.
 	sb := &strings.Builder{}
-	err = zoneTemplate.Execute(sb, map[string]any{
+	err := zoneTemplate.Execute(sb, map[string]any{
 		"primary":     primary,
 		"nameservers": nameservers,
 		"zone":        d.info.Name,
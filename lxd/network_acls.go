@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 
 	clusterRequest "github.com/lxc/lxd/lxd/cluster/request"
 	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/metrics"
 	"github.com/lxc/lxd/lxd/network/acl"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/request"
@@ -45,6 +47,12 @@ var networkACLLogCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: networkACLLogGet, AccessHandler: allowProjectPermission("networks", "view")},
 }
 
+var networkACLStateCmd = APIEndpoint{
+	Path: "network-acls/{name}/state",
+
+	Get: APIEndpointAction{Handler: networkACLStateGet, AccessHandler: allowProjectPermission("networks", "view")},
+}
+
 // API endpoints.
 
 // swagger:operation GET /1.0/network-acls network-acls network_acls_get
@@ -607,3 +615,116 @@ func networkACLLogGet(d *Daemon, r *http.Request) response.Response {
 
 	return response.FileResponse(r, []response.FileResponseEntry{ent}, nil)
 }
+
+// swagger:operation GET /1.0/network-acls/{name}/state network-acls network_acl_state_get
+//
+// Get the network ACL state
+//
+// Gets the hit counters of the rules of a specific network ACL.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: ACL state
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/NetworkACLState"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func networkACLStateGet(d *Daemon, r *http.Request) response.Response {
+	projectName, _, err := project.NetworkProject(d.State().DB.Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	aclName, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	netACL, err := acl.LoadByName(d.State(), projectName, aclName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+	aclState, err := netACL.GetState(clientType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, aclState)
+}
+
+// networkACLMetrics returns the hit counters of the rules of the network ACLs of a project on this member.
+func networkACLMetrics(d *Daemon, projectName string) (*metrics.MetricSet, error) {
+	set := metrics.NewMetricSet(map[string]string{"project": projectName})
+
+	// ACLs of projects without their own networks are in the default project.
+	networkProjectName, _, err := project.NetworkProject(d.State().DB.Cluster, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	if networkProjectName != projectName {
+		return set, nil
+	}
+
+	aclNames, err := d.State().DB.Cluster.GetNetworkACLs(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, aclName := range aclNames {
+		netACL, err := acl.LoadByName(d.State(), projectName, aclName)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only report the counters of this member (metrics are gathered per member).
+		aclState, err := netACL.GetState(clusterRequest.ClientTypeNotifier)
+		if err != nil {
+			return nil, err
+		}
+
+		addSamples := func(direction string, rules []api.NetworkACLRuleState) {
+			for ruleIndex, rule := range rules {
+				labels := map[string]string{"acl": aclName, "direction": direction, "rule": strconv.Itoa(ruleIndex)}
+				set.AddSamples(metrics.NetworkACLRuleBytesTotal, metrics.Sample{Value: float64(rule.Bytes), Labels: labels})
+
+				labels = map[string]string{"acl": aclName, "direction": direction, "rule": strconv.Itoa(ruleIndex)}
+				set.AddSamples(metrics.NetworkACLRulePacketsTotal, metrics.Sample{Value: float64(rule.Packets), Labels: labels})
+			}
+		}
+
+		addSamples("egress", aclState.Egress)
+		addSamples("ingress", aclState.Ingress)
+	}
+
+	return set, nil
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	clusterRequest "github.com/lxc/lxd/lxd/cluster/request"
+	"github.com/lxc/lxd/lxd/db"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/device/nictype"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/rbac"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// networkAllocationTypes lists the types of entities network allocations can be filtered on.
+var networkAllocationTypes = []string{"instance", "network", "network-forward", "network-load-balancer", "uplink"}
+
+var networkAllocationsCmd = APIEndpoint{
+	Path: "network-allocations",
+
+	Get: APIEndpointAction{Handler: networkAllocationsGet, AccessHandler: allowProjectPermission("networks", "view")},
+}
+
+// swagger:operation GET /1.0/network-allocations network-allocations network_allocations_get
+//
+// Get the network allocations in use
+//
+// Returns a list of the addresses assigned by LXD to instances, networks, network forwards, network load
+// balancers and OVN uplink connections.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: all-projects
+//     description: Retrieve the allocations of all projects
+//     type: boolean
+//   - in: query
+//     name: address
+//     description: Only return the allocations within the given address or subnet
+//     type: string
+//     example: 10.0.0.0/24
+//   - in: query
+//     name: type
+//     description: Only return the allocations of the given type
+//     type: string
+//     example: instance
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of network allocations
+//           items:
+//             $ref: "#/definitions/NetworkAllocations"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func networkAllocationsGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	// Parse the filters.
+	var addressFilter *net.IPNet
+	address := queryParam(r, "address")
+	if address != "" {
+		var err error
+
+		addressFilter, err = networkAllocationParseAddress(address)
+		if err != nil {
+			return response.BadRequest(fmt.Errorf("Invalid address filter %q", address))
+		}
+	}
+
+	typeFilter := queryParam(r, "type")
+	if typeFilter != "" && !shared.StringInSlice(typeFilter, networkAllocationTypes) {
+		return response.BadRequest(fmt.Errorf("Invalid type filter %q", typeFilter))
+	}
+
+	// Get the projects to list the allocations of.
+	projectNames := []string{projectParam(r)}
+	if shared.IsTrue(queryParam(r, "all-projects")) {
+		projectNames = []string{}
+
+		err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			projects, err := dbCluster.GetProjects(ctx, tx.Tx(), dbCluster.ProjectFilter{})
+			if err != nil {
+				return err
+			}
+
+			for _, p := range projects {
+				if !rbac.UserHasPermission(r, p.Name, "view") {
+					continue
+				}
+
+				projectNames = append(projectNames, p.Name)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	allocations := []api.NetworkAllocations{}
+	addAllocation := func(allocation api.NetworkAllocations) {
+		if typeFilter != "" && allocation.Type != typeFilter {
+			return
+		}
+
+		if addressFilter != nil {
+			allocationIP, _, err := net.ParseCIDR(allocation.Address)
+			if err != nil || !addressFilter.Contains(allocationIP) {
+				return
+			}
+		}
+
+		allocations = append(allocations, allocation)
+	}
+
+	// Networks may be shared by several projects, only list their own allocations once.
+	seenNetworks := map[network.ProjectNetwork]struct{}{}
+
+	for _, projectName := range projectNames {
+		networkProjectName, _, err := project.NetworkProject(s.DB.Cluster, projectName)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		networkNames, err := s.DB.Cluster.GetCreatedNetworks(networkProjectName)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		for _, networkName := range networkNames {
+			n, err := network.LoadByName(s, networkProjectName, networkName)
+			if err != nil {
+				return response.SmartError(fmt.Errorf("Failed loading network %q in project %q: %w", networkName, networkProjectName, err))
+			}
+
+			pn := network.ProjectNetwork{ProjectName: networkProjectName, NetworkName: networkName}
+			_, seen := seenNetworks[pn]
+			if !seen {
+				seenNetworks[pn] = struct{}{}
+
+				netAllocations, err := networkAllocationsForNetwork(s.DB.Cluster, n)
+				if err != nil {
+					return response.SmartError(err)
+				}
+
+				for _, allocation := range netAllocations {
+					addAllocation(allocation)
+				}
+			}
+
+			// Get the addresses of the instances of the project.
+			leases, err := n.Leases(projectName, clusterRequest.ClientTypeNormal)
+			if err != nil {
+				if !errors.Is(err, network.ErrNotImplemented) {
+					logger.Warn("Failed getting network leases, skipping network", logger.Ctx{"project": projectName, "network": networkName, "err": err})
+				}
+
+				continue
+			}
+
+			for _, lease := range leases {
+				// Uplink addresses are listed from the OVN networks themselves.
+				if !shared.StringInSlice(lease.Type, []string{"static", "dynamic"}) {
+					continue
+				}
+
+				leaseIP := net.ParseIP(lease.Address)
+				if leaseIP == nil {
+					continue
+				}
+
+				addAllocation(api.NetworkAllocations{
+					Address:  networkAllocationAddress(leaseIP),
+					UsedBy:   api.NewURL().Path(version.APIVersion, "instances", lease.Hostname).Project(projectName).String(),
+					Type:     "instance",
+					NAT:      networkAllocationNAT(n.Config(), leaseIP),
+					Hwaddr:   lease.Hwaddr,
+					Network:  networkName,
+					Project:  projectName,
+					Location: lease.Location,
+				})
+			}
+		}
+
+		// Get the addresses of the instance NICs which aren't part of the network leases.
+		err = s.DB.Cluster.InstanceList(&dbCluster.InstanceFilter{Project: &projectName}, func(inst db.InstanceArgs, p api.Project, profiles []api.Profile) error {
+			devices := db.ExpandInstanceDevices(inst.Devices.Clone(), profiles)
+			for devName, devConfig := range devices {
+				if devConfig["type"] != "nic" {
+					continue
+				}
+
+				nicType, err := nictype.NICType(s, projectName, devConfig)
+				if err != nil {
+					logger.Warn("Failed getting NIC type, skipping device", logger.Ctx{"project": projectName, "instance": inst.Name, "device": devName, "err": err})
+					continue
+				}
+
+				hwaddr := inst.Config[fmt.Sprintf("volatile.%s.hwaddr", devName)]
+
+				var stateAddresses []net.IP
+				if nicType == "macvlan" && inst.Node == s.ServerName {
+					stateAddresses = networkAllocationsInstanceAddresses(s, inst, hwaddr)
+				}
+
+				for _, allocation := range networkAllocationsForNIC(projectName, inst, nicType, devConfig, hwaddr, stateAddresses) {
+					addAllocation(allocation)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	return response.SyncResponse(true, allocations)
+}
+
+// networkAllocationsForNIC returns the addresses used by an instance NIC that aren't handed out by its network:
+// the addresses of routed NICs, the routes of bridged and OVN NICs and the addresses found in the state of macvlan
+// NICs (stateAddresses).
+func networkAllocationsForNIC(projectName string, inst db.InstanceArgs, nicType string, nicConfig map[string]string, hwaddr string, stateAddresses []net.IP) []api.NetworkAllocations {
+	allocations := []api.NetworkAllocations{}
+
+	networkName := nicConfig["network"]
+	if networkName == "" {
+		networkName = nicConfig["parent"]
+	}
+
+	newAllocation := func(address string) api.NetworkAllocations {
+		return api.NetworkAllocations{
+			Address:  address,
+			UsedBy:   api.NewURL().Path(version.APIVersion, "instances", inst.Name).Project(projectName).String(),
+			Type:     "instance",
+			Hwaddr:   hwaddr,
+			Network:  networkName,
+			Project:  projectName,
+			Location: inst.Node,
+		}
+	}
+
+	switch nicType {
+	case "routed":
+		for _, key := range []string{"ipv4.address", "ipv6.address"} {
+			for _, address := range shared.SplitNTrimSpace(nicConfig[key], ",", -1, true) {
+				ip := net.ParseIP(address)
+				if ip == nil {
+					continue
+				}
+
+				allocations = append(allocations, newAllocation(networkAllocationAddress(ip)))
+			}
+		}
+
+	case "bridged", "ovn":
+		for _, key := range []string{"ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external"} {
+			for _, route := range shared.SplitNTrimSpace(nicConfig[key], ",", -1, true) {
+				_, subnet, err := net.ParseCIDR(route)
+				if err != nil {
+					continue
+				}
+
+				allocations = append(allocations, newAllocation(subnet.String()))
+			}
+		}
+
+	case "macvlan":
+		for _, ip := range stateAddresses {
+			allocations = append(allocations, newAllocation(networkAllocationAddress(ip)))
+		}
+	}
+
+	return allocations
+}
+
+// networkAllocationsInstanceAddresses returns the global addresses of the interface with the given MAC address in
+// the state of a running local instance.
+func networkAllocationsInstanceAddresses(s *state.State, dbInst db.InstanceArgs, hwaddr string) []net.IP {
+	if hwaddr == "" {
+		return nil
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, dbInst.Project, dbInst.Name)
+	if err != nil || !inst.IsRunning() {
+		return nil
+	}
+
+	instState, err := inst.RenderState()
+	if err != nil {
+		logger.Warn("Failed getting instance state", logger.Ctx{"project": dbInst.Project, "instance": dbInst.Name, "err": err})
+		return nil
+	}
+
+	return networkAllocationsStateAddresses(instState, hwaddr)
+}
+
+// networkAllocationsStateAddresses returns the global addresses of the interface with the given MAC address in an
+// instance state.
+func networkAllocationsStateAddresses(instState *api.InstanceState, hwaddr string) []net.IP {
+	ips := []net.IP{}
+
+	for _, iface := range instState.Network {
+		if !strings.EqualFold(iface.Hwaddr, hwaddr) {
+			continue
+		}
+
+		for _, address := range iface.Addresses {
+			if address.Scope != "global" {
+				continue
+			}
+
+			ip := net.ParseIP(address.Address)
+			if ip == nil {
+				continue
+			}
+
+			ips = append(ips, ip)
+		}
+	}
+
+	return ips
+}
+
+// networkAllocationsForNetwork returns the addresses used by a network itself, its forwards and load balancers, and
+// its uplink connection.
+func networkAllocationsForNetwork(cluster *db.Cluster, n network.Network) ([]api.NetworkAllocations, error) {
+	allocations := []api.NetworkAllocations{}
+	netConfig := n.Config()
+	netURL := api.NewURL().Path(version.APIVersion, "networks", n.Name()).Project(n.Project()).String()
+
+	// Addresses of the network itself.
+	for _, key := range []string{"ipv4.address", "ipv6.address", "volatile.wireguard.ipv4.address"} {
+		ip, subnet, err := net.ParseCIDR(netConfig[key])
+		if err != nil {
+			continue
+		}
+
+		subnet.IP = ip
+		allocations = append(allocations, api.NetworkAllocations{
+			Address: subnet.String(),
+			UsedBy:  netURL,
+			Type:    "network",
+			NAT:     networkAllocationNAT(netConfig, ip),
+			Network: n.Name(),
+			Project: n.Project(),
+		})
+	}
+
+	// Addresses allocated to OVN networks on their uplink.
+	if n.Type() == "ovn" {
+		for _, key := range []string{"volatile.network.ipv4.address", "volatile.network.ipv6.address"} {
+			ip := net.ParseIP(netConfig[key])
+			if ip == nil {
+				continue
+			}
+
+			allocations = append(allocations, api.NetworkAllocations{
+				Address: networkAllocationAddress(ip),
+				UsedBy:  netURL,
+				Type:    "uplink",
+				NAT:     networkAllocationNAT(netConfig, ip),
+				Network: netConfig["network"],
+				Project: n.Project(),
+			})
+		}
+	}
+
+	// Listen addresses of the network forwards.
+	if n.Info().AddressForwards {
+		forwards, err := cluster.GetNetworkForwards(n.ID(), false)
+		if err != nil {
+			return nil, fmt.Errorf("Failed loading forwards of network %q: %w", n.Name(), err)
+		}
+
+		for _, forward := range forwards {
+			ip := net.ParseIP(forward.ListenAddress)
+			if ip == nil {
+				continue
+			}
+
+			allocations = append(allocations, api.NetworkAllocations{
+				Address:  networkAllocationAddress(ip),
+				UsedBy:   api.NewURL().Path(version.APIVersion, "networks", n.Name(), "forwards", forward.ListenAddress).Project(n.Project()).String(),
+				Type:     "network-forward",
+				Network:  n.Name(),
+				Project:  n.Project(),
+				Location: forward.Location,
+			})
+		}
+	}
+
+	// Listen addresses of the network load balancers.
+	if n.Info().LoadBalancers {
+		loadBalancers, err := cluster.GetNetworkLoadBalancers(n.ID(), false)
+		if err != nil {
+			return nil, fmt.Errorf("Failed loading load balancers of network %q: %w", n.Name(), err)
+		}
+
+		for _, loadBalancer := range loadBalancers {
+			ip := net.ParseIP(loadBalancer.ListenAddress)
+			if ip == nil {
+				continue
+			}
+
+			allocations = append(allocations, api.NetworkAllocations{
+				Address:  networkAllocationAddress(ip),
+				UsedBy:   api.NewURL().Path(version.APIVersion, "networks", n.Name(), "load-balancers", loadBalancer.ListenAddress).Project(n.Project()).String(),
+				Type:     "network-load-balancer",
+				Network:  n.Name(),
+				Project:  n.Project(),
+				Location: loadBalancer.Location,
+			})
+		}
+	}
+
+	return allocations, nil
+}
+
+// networkAllocationParseAddress parses an address filter, single addresses are turned into a host subnet.
+func networkAllocationParseAddress(address string) (*net.IPNet, error) {
+	if strings.Contains(address, "/") {
+		_, subnet, err := net.ParseCIDR(address)
+
+		return subnet, err
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, fmt.Errorf("Invalid IP address")
+	}
+
+	_, subnet, err := net.ParseCIDR(networkAllocationAddress(ip))
+
+	return subnet, err
+}
+
+// networkAllocationAddress returns a single address in CIDR format.
+func networkAllocationAddress(ip net.IP) string {
+	if ip.To4() != nil {
+		return fmt.Sprintf("%s/32", ip.String())
+	}
+
+	return fmt.Sprintf("%s/128", ip.String())
+}
+
+// networkAllocationNAT returns whether the network NATs the traffic of the address' family.
+func networkAllocationNAT(netConfig map[string]string, ip net.IP) bool {
+	if ip.To4() != nil {
+		return shared.IsTrue(netConfig["ipv4.nat"])
+	}
+
+	return shared.IsTrue(netConfig["ipv6.nat"])
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestNetworkAllocationsForNIC(t *testing.T) {
+	inst := db.InstanceArgs{Name: "c1", Node: "lxd01"}
+
+	tests := []struct {
+		name           string
+		nicType        string
+		nicConfig      map[string]string
+		stateAddresses []net.IP
+		addresses      []string
+		network        string
+	}{
+		{
+			name:      "routed",
+			nicType:   "routed",
+			nicConfig: map[string]string{"parent": "eth0", "ipv4.address": "192.0.2.10, 192.0.2.11", "ipv6.address": "2001:db8::10"},
+			addresses: []string{"192.0.2.10/32", "192.0.2.11/32", "2001:db8::10/128"},
+			network:   "eth0",
+		},
+		{
+			name:      "routed without addresses",
+			nicType:   "routed",
+			nicConfig: map[string]string{"parent": "eth0"},
+			addresses: []string{},
+		},
+		{
+			name:    "bridged routes",
+			nicType: "bridged",
+			nicConfig: map[string]string{
+				"network":              "lxdbr0",
+				"ipv4.address":         "10.0.0.2",
+				"ipv4.routes":          "192.0.2.0/28",
+				"ipv6.routes":          "2001:db8:1::/64",
+				"ipv4.routes.external": "198.51.100.1/32,198.51.100.16/28",
+				"ipv6.routes.external": "2001:db8:2::/64",
+			},
+			addresses: []string{"192.0.2.0/28", "2001:db8:1::/64", "198.51.100.1/32", "198.51.100.16/28", "2001:db8:2::/64"},
+			network:   "lxdbr0",
+		},
+		{
+			name:      "ovn routes",
+			nicType:   "ovn",
+			nicConfig: map[string]string{"network": "ovn0", "ipv4.routes.external": "198.51.100.0/30"},
+			addresses: []string{"198.51.100.0/30"},
+			network:   "ovn0",
+		},
+		{
+			name:           "macvlan",
+			nicType:        "macvlan",
+			nicConfig:      map[string]string{"parent": "eth0"},
+			stateAddresses: []net.IP{net.ParseIP("192.0.2.20"), net.ParseIP("2001:db8::20")},
+			addresses:      []string{"192.0.2.20/32", "2001:db8::20/128"},
+			network:        "eth0",
+		},
+		{
+			name:      "macvlan without state",
+			nicType:   "macvlan",
+			nicConfig: map[string]string{"network": "macvlan0"},
+			addresses: []string{},
+		},
+		{
+			name:           "physical",
+			nicType:        "physical",
+			nicConfig:      map[string]string{"parent": "eth1"},
+			stateAddresses: []net.IP{net.ParseIP("192.0.2.30")},
+			addresses:      []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			allocations := networkAllocationsForNIC("p1", inst, test.nicType, test.nicConfig, "00:16:3e:00:00:01", test.stateAddresses)
+
+			addresses := []string{}
+			for _, allocation := range allocations {
+				addresses = append(addresses, allocation.Address)
+
+				assert.Equal(t, "instance", allocation.Type)
+				assert.Equal(t, "/1.0/instances/c1?project=p1", allocation.UsedBy)
+				assert.Equal(t, "00:16:3e:00:00:01", allocation.Hwaddr)
+				assert.Equal(t, test.network, allocation.Network)
+				assert.Equal(t, "p1", allocation.Project)
+				assert.Equal(t, "lxd01", allocation.Location)
+			}
+
+			assert.Equal(t, test.addresses, addresses)
+		})
+	}
+}
+
+func TestNetworkAllocationsStateAddresses(t *testing.T) {
+	instState := &api.InstanceState{
+		Network: map[string]api.InstanceStateNetwork{
+			"eth0": {
+				Hwaddr: "00:16:3e:00:00:01",
+				Addresses: []api.InstanceStateNetworkAddress{
+					{Family: "inet", Address: "192.0.2.20", Netmask: "24", Scope: "global"},
+					{Family: "inet6", Address: "2001:db8::20", Netmask: "64", Scope: "global"},
+					{Family: "inet6", Address: "fe80::1", Netmask: "64", Scope: "link"},
+				},
+			},
+			"eth1": {
+				Hwaddr: "00:16:3e:00:00:02",
+				Addresses: []api.InstanceStateNetworkAddress{
+					{Family: "inet", Address: "198.51.100.20", Netmask: "24", Scope: "global"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		hwaddr    string
+		addresses []string
+	}{
+		{
+			name:      "matching interface",
+			hwaddr:    "00:16:3E:00:00:01",
+			addresses: []string{"192.0.2.20", "2001:db8::20"},
+		},
+		{
+			name:      "other interface",
+			hwaddr:    "00:16:3e:00:00:02",
+			addresses: []string{"198.51.100.20"},
+		},
+		{
+			name:      "unknown interface",
+			hwaddr:    "00:16:3e:00:00:03",
+			addresses: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addresses := []string{}
+			for _, ip := range networkAllocationsStateAddresses(instState, test.hwaddr) {
+				addresses = append(addresses, ip.String())
+			}
+
+			assert.Equal(t, test.addresses, addresses)
+		})
+	}
+}
@@ -25,12 +25,14 @@ import (
 	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/resources"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/lxd/warnings"
 	"github.com/lxc/lxd/shared"
@@ -71,6 +73,12 @@ var networkStateCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: networkStateGet, AccessHandler: allowProjectPermission("networks", "view")},
 }
 
+var networkBGPCmd = APIEndpoint{
+	Path: "networks/{name}/bgp",
+
+	Get: APIEndpointAction{Handler: networkBGPGet, AccessHandler: allowProjectPermission("networks", "view")},
+}
+
 // API endpoints
 
 // swagger:operation GET /1.0/networks networks networks_get
@@ -1712,3 +1720,140 @@ func networkStateGet(d *Daemon, r *http.Request) response.Response {
 
 	return response.SyncResponse(true, state)
 }
+
+// swagger:operation GET /1.0/networks/{name}/bgp networks networks_bgp_get
+//
+// Get the network BGP state
+//
+// Returns the session state of the BGP peers of the network and the routes learned from them.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/NetworkBGPState"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func networkBGPGet(d *Daemon, r *http.Request) response.Response {
+	// If a target was specified, forward the request to the relevant node.
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	networkName, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName, _, err := project.NetworkProject(d.State().DB.Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading network: %w", err))
+	}
+
+	if !shared.StringInSlice(n.Type(), []string{"bridge", "ovn", "physical"}) {
+		return response.BadRequest(fmt.Errorf("Network driver %q does not support BGP", n.Type()))
+	}
+
+	state, err := n.BGPState()
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed fetching BGP state: %w", err))
+	}
+
+	return response.SyncResponse(true, state)
+}
+
+func networkBGPImportTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		// Routes shared by the whole cluster are only applied by the leader.
+		clusterLeader := true
+		localAddress, err := node.ClusterAddress(s.DB.Node)
+		if err != nil {
+			logger.Error("Failed to get current cluster member address", logger.Ctx{"err": err})
+			return
+		}
+
+		leader, err := d.gateway.LeaderAddress()
+		if err != nil && !errors.Is(err, cluster.ErrNodeIsNotClustered) {
+			logger.Error("Failed to get leader cluster member address", logger.Ctx{"err": err})
+			return
+		} else if err == nil {
+			clusterLeader = localAddress == leader
+		}
+
+		var projectNetworks map[string]map[int64]api.Network
+
+		err = s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
+			projectNetworks, err = tx.GetCreatedNetworks()
+			return err
+		})
+		if err != nil {
+			logger.Error("Failed to load networks", logger.Ctx{"err": err})
+			return
+		}
+
+		for projectName, networks := range projectNetworks {
+			for _, ni := range networks {
+				if ctx.Err() != nil {
+					return
+				}
+
+				n, err := network.LoadByName(s, projectName, ni.Name)
+				if err != nil {
+					logger.Warn("Failed loading network", logger.Ctx{"project": projectName, "network": ni.Name, "err": err})
+					continue
+				}
+
+				err = n.BGPImportRoutes(clusterLeader)
+				if err != nil {
+					logger.Warn("Failed applying routes learned from BGP", logger.Ctx{"project": projectName, "network": ni.Name, "err": err})
+				}
+			}
+		}
+	}
+
+	schedule := task.Every(10 * time.Second)
+
+	return f, schedule
+}
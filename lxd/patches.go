@@ -69,6 +69,7 @@ var patches = []patch{
 	{name: "warnings_remove_empty_node", stage: patchPostDaemonStorage, run: patchRemoveWarningsWithEmptyNode},
 	{name: "dnsmasq_entries_include_device_name", stage: patchPostDaemonStorage, run: patchDnsmasqEntriesIncludeDeviceName},
 	{name: "storage_missing_snapshot_records", stage: patchPostDaemonStorage, run: patchGenericStorage},
+	{name: "storage_create_buckets_dataset", stage: patchPostDaemonStorage, run: patchGenericStorage},
 }
 
 type patch struct {
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/response"
 )
@@ -76,3 +78,30 @@ func forwardedResponseIfVolumeIsRemote(d *Daemon, r *http.Request, poolName stri
 
 	return response.ForwardedResponse(client, r)
 }
+
+// forwardedResponseIfBucketIsRemote redirects a request to the member hosting the bucket with the given pool ID,
+// project and name. If the bucket is local or isn't specific to a member, nothing gets done and nil is returned.
+//
+// This is used when no targetNode is specified, and saves users some typing when the bucket name is unique to a
+// member.
+func forwardedResponseIfBucketIsRemote(d *Daemon, r *http.Request, poolID int64, projectName string, bucketName string) response.Response {
+	if queryParam(r, "target") != "" {
+		return nil
+	}
+
+	var bucket *db.StorageBucket
+	err := d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		bucket, err = tx.GetStoragePoolBucket(poolID, projectName, false, bucketName)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if bucket.Location == "" {
+		return nil
+	}
+
+	return forwardedResponseToNode(d, r, bucket.Location)
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/db/operationtype"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/operations"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// retentionSnapshot identifies an instance or custom volume snapshot selected for pruning.
+type retentionSnapshot struct {
+	project      string
+	name         string // Full snapshot name in the form "<parent>/<snapshot>".
+	instanceType instancetype.Type
+	pool         string // Only set for custom volume snapshots.
+}
+
+func pruneSnapshotsRetentionTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		// The retention policy is evaluated against all the snapshots of the cluster, so only the leader
+		// runs the task to avoid members racing each other deleting the same snapshots.
+		localAddress, err := node.ClusterAddress(d.db.Node)
+		if err != nil {
+			logger.Error("Failed to get current cluster member address", logger.Ctx{"err": err})
+			return
+		}
+
+		leader, err := d.gateway.LeaderAddress()
+		if err != nil {
+			if !errors.Is(err, cluster.ErrNodeIsNotClustered) {
+				logger.Error("Failed to get leader cluster member address", logger.Ctx{"err": err})
+				return
+			}
+		} else if localAddress != leader {
+			logger.Debug("Skipping snapshot retention task since we're not leader")
+			return
+		}
+
+		instanceSnapshots, err := retentionInstanceSnapshots(d.State().DB.Cluster)
+		if err != nil {
+			logger.Error("Failed to get instance snapshots for retention", logger.Ctx{"err": err})
+			return
+		}
+
+		volumeSnapshots, err := retentionCustomVolumeSnapshots(d.State().DB.Cluster)
+		if err != nil {
+			logger.Error("Failed to get custom volume snapshots for retention", logger.Ctx{"err": err})
+			return
+		}
+
+		// Skip if there is nothing to prune.
+		if len(instanceSnapshots) == 0 && len(volumeSnapshots) == 0 {
+			return
+		}
+
+		opRun := func(op *operations.Operation) error {
+			return pruneSnapshotsRetention(ctx, d, instanceSnapshots, volumeSnapshots)
+		}
+
+		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, operationtype.SnapshotsRetentionPrune, nil, nil, opRun, nil, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start snapshot retention operation", logger.Ctx{"err": err})
+			return
+		}
+
+		logger.Info("Pruning snapshots by retention policy")
+		err = op.Start()
+		if err != nil {
+			logger.Error("Failed to prune snapshots by retention policy", logger.Ctx{"err": err})
+		}
+
+		_, _ = op.Wait(ctx)
+		logger.Info("Done pruning snapshots by retention policy")
+	}
+
+	first := true
+	schedule := func() (time.Duration, error) {
+		interval := 5 * time.Minute
+
+		if first {
+			first = false
+			return interval, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
+
+// retentionInstanceSnapshots returns the instance snapshots across the cluster that fall outside of the
+// retention policy of their instance. Snapshots with an expiry date are left to the expiry task.
+func retentionInstanceSnapshots(c *db.Cluster) ([]retentionSnapshot, error) {
+	retentions := map[string]*shared.SnapshotRetention{}
+	instanceTypes := map[string]instancetype.Type{}
+
+	err := c.InstanceList(nil, func(inst db.InstanceArgs, p api.Project, profiles []api.Profile) error {
+		retention, err := shared.GetSnapshotRetention(db.ExpandInstanceConfig(inst.Config, profiles))
+		if err != nil {
+			logger.Warn("Invalid snapshot retention policy", logger.Ctx{"err": err, "project": inst.Project, "instance": inst.Name})
+			return nil
+		}
+
+		if retention != nil {
+			key := inst.Project + "/" + inst.Name
+			retentions[key] = retention
+			instanceTypes[key] = inst.Type
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(retentions) == 0 {
+		return nil, nil
+	}
+
+	snapshotsByInstance := map[string][]dbCluster.InstanceSnapshot{}
+	err = c.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		snapshots, err := dbCluster.GetInstanceSnapshots(ctx, tx.Tx(), dbCluster.InstanceSnapshotFilter{})
+		if err != nil {
+			return err
+		}
+
+		for _, snapshot := range snapshots {
+			if snapshot.ExpiryDate.Valid && snapshot.ExpiryDate.Time.Unix() > 0 {
+				continue
+			}
+
+			key := snapshot.Project + "/" + snapshot.Instance
+			snapshotsByInstance[key] = append(snapshotsByInstance[key], snapshot)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []retentionSnapshot
+	for key, retention := range retentions {
+		snapshots := snapshotsByInstance[key]
+
+		creationDates := make([]time.Time, 0, len(snapshots))
+		for _, snapshot := range snapshots {
+			creationDates = append(creationDates, snapshot.CreationDate)
+		}
+
+		for _, i := range retention.SnapshotsToPrune(creationDates) {
+			result = append(result, retentionSnapshot{
+				project:      snapshots[i].Project,
+				name:         snapshots[i].Instance + shared.SnapshotDelimiter + snapshots[i].Name,
+				instanceType: instanceTypes[key],
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// retentionCustomVolumeSnapshots returns the custom volume snapshots across the cluster that fall outside of the
+// retention policy of their volume. Snapshots with an expiry date are left to the expiry task.
+func retentionCustomVolumeSnapshots(c *db.Cluster) ([]retentionSnapshot, error) {
+	var volumes []db.StorageVolumeArgs
+	var snapshotsByVolume map[int64][]db.StorageVolumeArgs
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+
+		volumes, err = tx.GetStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return err
+		}
+
+		snapshotsByVolume, err = tx.GetStorageVolumeSnapshotsWithType(db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []retentionSnapshot
+	for _, vol := range volumes {
+		retention, err := shared.GetSnapshotRetention(vol.Config)
+		if err != nil {
+			logger.Warn("Invalid snapshot retention policy", logger.Ctx{"err": err, "project": vol.ProjectName, "pool": vol.PoolName, "volume": vol.Name})
+			continue
+		}
+
+		if retention == nil {
+			continue
+		}
+
+		snapshots := []db.StorageVolumeArgs{}
+		for _, snapshot := range snapshotsByVolume[vol.ID] {
+			if snapshot.ExpiryDate.Unix() > 0 {
+				continue
+			}
+
+			snapshots = append(snapshots, snapshot)
+		}
+
+		creationDates := make([]time.Time, 0, len(snapshots))
+		for _, snapshot := range snapshots {
+			creationDates = append(creationDates, snapshot.CreationDate)
+		}
+
+		for _, i := range retention.SnapshotsToPrune(creationDates) {
+			result = append(result, retentionSnapshot{
+				project: snapshots[i].ProjectName,
+				name:    snapshots[i].Name,
+				pool:    snapshots[i].PoolName,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// pruneSnapshotsRetention deletes the given snapshots, forwarding the deletion to the cluster member that holds
+// the snapshot when it isn't the local one. Failures are logged and don't stop the remaining deletions.
+func pruneSnapshotsRetention(ctx context.Context, d *Daemon, instanceSnapshots []retentionSnapshot, volumeSnapshots []retentionSnapshot) error {
+	s := d.State()
+
+	for _, snap := range instanceSnapshots {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		parentName, snapName, _ := shared.InstanceGetParentAndSnapshotName(snap.name)
+
+		client, err := cluster.ConnectIfInstanceIsRemote(s.DB.Cluster, snap.project, parentName, d.endpoints.NetworkCert(), d.serverCert(), nil, snap.instanceType)
+		if err != nil {
+			logger.Warn("Failed to connect to instance snapshot member", logger.Ctx{"err": err, "project": snap.project, "snapshot": snap.name})
+			continue
+		}
+
+		if client != nil {
+			op, err := client.DeleteInstanceSnapshot(parentName, snapName)
+			if err == nil {
+				err = op.Wait()
+			}
+
+			if err != nil {
+				logger.Error("Failed to delete instance snapshot", logger.Ctx{"err": err, "project": snap.project, "snapshot": snap.name})
+			}
+
+			continue
+		}
+
+		inst, err := instance.LoadByProjectAndName(s, snap.project, snap.name)
+		if err == nil {
+			err = inst.Delete(true)
+		}
+
+		if err != nil {
+			logger.Error("Failed to delete instance snapshot", logger.Ctx{"err": err, "project": snap.project, "snapshot": snap.name})
+		}
+	}
+
+	for _, snap := range volumeSnapshots {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		parentName, snapName, _ := shared.InstanceGetParentAndSnapshotName(snap.name)
+
+		client, err := cluster.ConnectIfVolumeIsRemote(s, snap.pool, snap.project, parentName, db.StoragePoolVolumeTypeCustom, d.endpoints.NetworkCert(), d.serverCert(), nil)
+		if err != nil {
+			logger.Warn("Failed to connect to custom volume snapshot member", logger.Ctx{"err": err, "project": snap.project, "pool": snap.pool, "snapshot": snap.name})
+			continue
+		}
+
+		if client != nil {
+			op, err := client.UseProject(snap.project).DeleteStoragePoolVolumeSnapshot(snap.pool, db.StoragePoolVolumeTypeNameCustom, parentName, snapName)
+			if err == nil {
+				err = op.Wait()
+			}
+
+			if err != nil {
+				logger.Error("Failed to delete custom volume snapshot", logger.Ctx{"err": err, "project": snap.project, "pool": snap.pool, "snapshot": snap.name})
+			}
+
+			continue
+		}
+
+		pool, err := storagePools.LoadByName(s, snap.pool)
+		if err != nil {
+			return fmt.Errorf("Failed to get pool %q: %w", snap.pool, err)
+		}
+
+		err = pool.DeleteCustomVolumeSnapshot(snap.project, snap.name, nil)
+		if err != nil {
+			logger.Error("Failed to delete custom volume snapshot", logger.Ctx{"err": err, "project": snap.project, "pool": snap.pool, "snapshot": snap.name})
+		}
+	}
+
+	return nil
+}
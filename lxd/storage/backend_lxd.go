@@ -8,13 +8,16 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 	"unicode"
 
+	"golang.org/x/sys/unix"
 	"gopkg.in/yaml.v2"
 
 	"github.com/lxc/lxd/lxd/backup"
@@ -28,19 +31,25 @@ import (
 	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/locking"
 	"github.com/lxc/lxd/lxd/migration"
+	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/rsync"
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/storage/chunkstore"
 	"github.com/lxc/lxd/lxd/storage/drivers"
 	"github.com/lxc/lxd/lxd/storage/filesystem"
 	"github.com/lxc/lxd/lxd/storage/memorypipe"
+	"github.com/lxc/lxd/lxd/storage/s3"
+	"github.com/lxc/lxd/lxd/storage/s3/miniod"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/instancewriter"
 	"github.com/lxc/lxd/shared/ioprogress"
 	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/units"
 )
 
 var unavailablePools = make(map[string]struct{})
@@ -252,6 +261,128 @@ func (b *lxdBackend) GetResources() (*api.ResourcesStoragePool, error) {
 	return b.driver.GetResources()
 }
 
+// Deduplicate shares identical data between the volumes on the storage pool.
+// Returns the amount of data deduplicated and the disk space freed as a result.
+func (b *lxdBackend) Deduplicate(op *operations.Operation) (*api.StoragePoolDeduplicateResult, error) {
+	l := logger.AddContext(b.logger, nil)
+	l.Debug("Deduplicate started")
+	defer l.Debug("Deduplicate finished")
+
+	var dbVols []db.StorageVolumeArgs
+	err := b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		dbVols, err = tx.GetLocalStoragePoolVolumesAllProjects(b.ID())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting pool volume records: %w", err)
+	}
+
+	// Only deduplicate the instance and custom volumes which aren't in use, as their data is expected to be
+	// changing and the running workloads shouldn't have to compete with the deduplication for IO.
+	vols := make([]drivers.Volume, 0, len(dbVols))
+	for _, dbVol := range dbVols {
+		volType, err := VolumeDBTypeToType(dbVol.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		if volType == drivers.VolumeTypeImage {
+			continue
+		}
+
+		inUse, err := b.deduplicateVolumeInUse(dbVol, volType)
+		if err != nil {
+			return nil, err
+		}
+
+		if inUse {
+			l.Debug("Skipping volume in use", logger.Ctx{"project": dbVol.ProjectName, "volType": volType, "volName": dbVol.Name})
+			continue
+		}
+
+		dbContentType, err := VolumeContentTypeNameToContentType(dbVol.ContentType)
+		if err != nil {
+			return nil, err
+		}
+
+		contentType, err := VolumeDBContentTypeToContentType(dbContentType)
+		if err != nil {
+			return nil, err
+		}
+
+		volStorageName := project.Instance(dbVol.ProjectName, dbVol.Name)
+		if volType == drivers.VolumeTypeCustom {
+			volStorageName = project.StorageVolume(dbVol.ProjectName, dbVol.Name)
+		}
+
+		vols = append(vols, b.GetVolume(volType, contentType, volStorageName, dbVol.Config))
+	}
+
+	before, err := b.driver.GetResources()
+	if err != nil {
+		return nil, err
+	}
+
+	deduplicated, err := b.driver.Deduplicate(vols, op)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := b.driver.GetResources()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &api.StoragePoolDeduplicateResult{Deduplicated: deduplicated}
+	if before.Space.Used > after.Space.Used {
+		result.Saved = int64(before.Space.Used - after.Space.Used)
+	}
+
+	return result, nil
+}
+
+// deduplicateVolumeInUse returns whether an instance or custom volume is used by a running instance or the daemon.
+func (b *lxdBackend) deduplicateVolumeInUse(dbVol db.StorageVolumeArgs, volType drivers.VolumeType) (bool, error) {
+	if volType != drivers.VolumeTypeCustom {
+		inst, err := instance.LoadByProjectAndName(b.state, dbVol.ProjectName, dbVol.Name)
+		if err != nil {
+			return false, err
+		}
+
+		return inst.IsRunning(), nil
+	}
+
+	used, err := VolumeUsedByDaemon(b.state, b.name, dbVol.Name)
+	if err != nil {
+		return false, err
+	}
+
+	if used {
+		return true, nil
+	}
+
+	vol := &api.StorageVolume{Name: dbVol.Name, Type: dbVol.TypeName}
+	err = VolumeUsedByInstanceDevices(b.state, b.name, dbVol.ProjectName, vol, true, func(dbInst db.InstanceArgs, project api.Project, profiles []api.Profile, usedByDevices []string) error {
+		inst, err := instance.Load(b.state, dbInst, profiles)
+		if err != nil {
+			return err
+		}
+
+		if inst.IsRunning() {
+			used = true
+			return db.ErrInstanceListStop
+		}
+
+		return nil
+	})
+	if err != nil && err != db.ErrInstanceListStop {
+		return false, err
+	}
+
+	return used, nil
+}
+
 // IsUsed returns whether the storage pool is used by any volumes or profiles (excluding image volumes).
 func (b *lxdBackend) IsUsed() (bool, error) {
 	// Get all users of the storage pool.
@@ -1596,8 +1727,81 @@ func (b *lxdBackend) imageFiller(fingerprint string, op *operations.Operation) f
 				}}
 		}
 		imageFile := shared.VarPath("images", fingerprint)
-		return ImageUnpack(imageFile, vol, rootBlockPath, b.driver.Info().BlockBacking, b.state.OS, allowUnsafeResize, tracker)
+
+		// Container images can be populated from the image chunk store when enabled, sharing the content
+		// of the image files with the other storage pools instead of unpacking the image again.
+		if rootBlockPath != "" || !b.imageChunksEnabled() {
+			return ImageUnpack(imageFile, vol, rootBlockPath, b.driver.Info().BlockBacking, b.state.OS, allowUnsafeResize, tracker)
+		}
+
+		// Only image volumes are read-only and so can share inodes with the store.
+		allowHardlink := vol.Type() == drivers.VolumeTypeImage
+
+		// Volumes that aren't on the same file system as the store (such as zfs, lvm and ceph volumes) can't
+		// share their files with it, so don't use the store for them.
+		store := chunkstore.New(shared.VarPath("images", "chunks"))
+		if !store.CanShare(vol.MountPath(), allowHardlink) {
+			b.logger.Debug("Volume can't share files with image chunk store, unpacking image", logger.Ctx{"fingerprint": fingerprint, "volName": vol.Name()})
+			return ImageUnpack(imageFile, vol, rootBlockPath, b.driver.Info().BlockBacking, b.state.OS, allowUnsafeResize, tracker)
+		}
+
+		if store.HasImage(fingerprint) {
+			mode, err := store.Populate(fingerprint, vol.MountPath(), allowHardlink)
+			if err == nil {
+				b.logger.Debug("Populated volume from image chunk store", logger.Ctx{"fingerprint": fingerprint, "volName": vol.Name(), "mode": mode})
+				return 0, nil
+			}
+
+			b.logger.Warn("Failed populating volume from image chunk store, unpacking image", logger.Ctx{"fingerprint": fingerprint, "volName": vol.Name(), "err": err})
+
+			// Clear the partially populated volume before unpacking the image into it.
+			entries, err := os.ReadDir(vol.MountPath())
+			if err != nil {
+				return -1, err
+			}
+
+			for _, entry := range entries {
+				err = os.RemoveAll(filepath.Join(vol.MountPath(), entry.Name()))
+				if err != nil {
+					return -1, fmt.Errorf("Failed clearing volume %q: %w", vol.Name(), err)
+				}
+			}
+		}
+
+		sizeBytes, err := ImageUnpack(imageFile, vol, rootBlockPath, b.driver.Info().BlockBacking, b.state.OS, allowUnsafeResize, tracker)
+		if err != nil {
+			return -1, err
+		}
+
+		// A failure to fill the store doesn't affect the volume, the next volume will try again.
+		err = store.Import(fingerprint, vol.MountPath())
+		if err != nil {
+			b.logger.Warn("Failed adding image to image chunk store", logger.Ctx{"fingerprint": fingerprint, "err": err})
+		}
+
+		return sizeBytes, nil
+	}
+}
+
+// imageChunksEnabled returns whether the local member shares unpacked images through the image chunk store.
+func (b *lxdBackend) imageChunksEnabled() bool {
+	var enabled bool
+	err := b.state.DB.Node.Transaction(func(tx *db.NodeTx) error {
+		nodeConfig, err := node.ConfigLoad(tx)
+		if err != nil {
+			return err
+		}
+
+		enabled = nodeConfig.StorageImagesChunks()
+
+		return nil
+	})
+	if err != nil {
+		b.logger.Warn("Failed loading member config", logger.Ctx{"err": err})
+		return false
 	}
+
+	return enabled
 }
 
 // CreateInstanceFromImage creates a new volume for an instance populated with the image requested.
@@ -2234,8 +2438,8 @@ func (b *lxdBackend) MigrateInstance(inst instance.Instance, conn io.ReadWriteCl
 	l.Debug("MigrateInstance started")
 	defer l.Debug("MigrateInstance finished")
 
-	// rsync+dd can't handle running source instances
-	if inst.IsRunning() && args.MigrationType.FSType == migration.MigrationFSType_BLOCK_AND_RSYNC {
+	// rsync+dd can't handle running source instances, unless the VM is being live migrated.
+	if inst.IsRunning() && !args.LiveVM && args.MigrationType.FSType == migration.MigrationFSType_BLOCK_AND_RSYNC {
 		return fmt.Errorf("Rsync based migration doesn't support running virtual machines")
 	}
 
@@ -2288,7 +2492,7 @@ func (b *lxdBackend) MigrateInstance(inst instance.Instance, conn io.ReadWriteCl
 
 	// Freeze the instance only when the underlying driver doesn't support it, and allowInconsistent is not set (and it's
 	// not already frozen/stopped)
-	if !inst.IsSnapshot() && b.driver.Info().RunningCopyFreeze && inst.IsRunning() && !inst.IsFrozen() && !args.AllowInconsistent {
+	if !inst.IsSnapshot() && b.driver.Info().RunningCopyFreeze && inst.IsRunning() && !inst.IsFrozen() && !args.AllowInconsistent && !args.LiveVM {
 		err = inst.Freeze()
 		if err != nil {
 			return err
@@ -2308,9 +2512,210 @@ func (b *lxdBackend) MigrateInstance(inst instance.Instance, conn io.ReadWriteCl
 	return nil
 }
 
+// MoveInstanceLive moves the root volume of a running VM from srcPool to this pool without stopping it.
+// The root disk is mirrored onto a new volume on this pool and, right before the VM is switched over to it, the
+// volume record and the instance's local devices (whose root disk device is expected to reference this pool) are
+// updated in a single transaction and the config drive share is moved onto the new volume. The volume on the source
+// pool is deleted afterwards.
+func (b *lxdBackend) MoveInstanceLive(inst instance.Instance, srcPool Pool, localDevices deviceConfig.Devices, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "srcPool": srcPool.Name()})
+	l.Debug("MoveInstanceLive started")
+	defer l.Debug("MoveInstanceLive finished")
+
+	err := b.isStatusReady()
+	if err != nil {
+		return err
+	}
+
+	vm, ok := inst.(instance.VM)
+	if !ok || !inst.IsRunning() {
+		return fmt.Errorf("Only running virtual machines can be moved live between storage pools")
+	}
+
+	srcBackend, ok := srcPool.(*lxdBackend)
+	if !ok {
+		return fmt.Errorf("Pool is not a lxdBackend")
+	}
+
+	if b.driver.Info().Remote || srcBackend.driver.Info().Remote {
+		return fmt.Errorf("Instances can only be moved live between local storage pools")
+	}
+
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return err
+	}
+
+	volDBType, err := VolumeTypeToDBType(volType)
+	if err != nil {
+		return err
+	}
+
+	contentType := InstanceContentType(inst)
+
+	_, srcVolRecord, err := b.state.DB.Cluster.GetLocalStoragePoolVolume(inst.Project(), inst.Name(), volDBType, srcPool.ID())
+	if err != nil {
+		return fmt.Errorf("Failed loading source volume record: %w", err)
+	}
+
+	srcVol, err := srcBackend.instanceEffectiveRootVolume(inst, srcVolRecord.Config)
+	if err != nil {
+		return err
+	}
+
+	srcDiskPath, err := srcBackend.driver.GetVolumeDiskPath(*srcVol)
+	if err != nil {
+		return err
+	}
+
+	sizeBytes, err := drivers.BlockDiskSizeBytes(srcDiskPath)
+	if err != nil {
+		return fmt.Errorf("Failed getting size of %q: %w", srcDiskPath, err)
+	}
+
+	// Validate the volume config against this pool's driver, dropping any keys specific to the source driver.
+	volConfig := make(map[string]string, len(srcVolRecord.Config))
+	for k, v := range srcVolRecord.Config {
+		volConfig[k] = v
+	}
+
+	vol := b.GetVolume(volType, contentType, srcVol.Name(), volConfig)
+
+	err = b.driver.FillVolumeConfig(vol)
+	if err != nil {
+		return err
+	}
+
+	err = b.driver.ValidateVolume(vol, true)
+	if err != nil {
+		return err
+	}
+
+	// The new volume must be at least the size of the source disk to be used as a mirror target.
+	newVolConfig := make(map[string]string, len(volConfig))
+	for k, v := range volConfig {
+		newVolConfig[k] = v
+	}
+
+	newVolConfig["size"] = fmt.Sprintf("%dB", sizeBytes)
+	if srcVol.ExpandedConfig("size.state") != "" {
+		newVolConfig["size.state"] = srcVol.ExpandedConfig("size.state")
+	}
+
+	newVol := b.GetVolume(volType, contentType, srcVol.Name(), newVolConfig)
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = b.driver.CreateVolume(newVol, nil, op)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = b.driver.DeleteVolume(newVol, op) })
+
+	err = b.driver.MountVolume(newVol, op)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _, _ = b.driver.UnmountVolume(newVol, false, op) })
+
+	diskPath, err := b.driver.GetVolumeDiskPath(newVol)
+	if err != nil {
+		return err
+	}
+
+	newSizeBytes, err := drivers.BlockDiskSizeBytes(diskPath)
+	if err != nil {
+		return fmt.Errorf("Failed getting size of %q: %w", diskPath, err)
+	}
+
+	// Some drivers round the volume size up (such as to the LVM extent size).
+	if newSizeBytes < sizeBytes {
+		return fmt.Errorf("Volume created on pool %q has a size of %d bytes rather than at least %d bytes", b.name, newSizeBytes, sizeBytes)
+	}
+
+	// Copy the instance's config filesystem, leaving out the root disk files (which get mirrored).
+	rsyncArgs := []string{}
+	for _, path := range []string{srcDiskPath, diskPath} {
+		if strings.HasPrefix(path, srcVol.MountPath()+"/") || strings.HasPrefix(path, newVol.MountPath()+"/") {
+			rsyncArgs = append(rsyncArgs, "--exclude", fmt.Sprintf("/%s", filepath.Base(path)))
+		}
+	}
+
+	_, err = rsync.LocalCopy(srcVol.MountPath(), newVol.MountPath(), "", true, rsyncArgs...)
+	if err != nil {
+		return fmt.Errorf("Failed copying instance config filesystem: %w", err)
+	}
+
+	updateRecords := func(fromPoolID int64, toPoolID int64, volConfig map[string]string, localDevices deviceConfig.Devices) error {
+		return b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			err := tx.MoveStoragePoolVolume(inst.Project(), inst.Name(), volDBType, fromPoolID, toPoolID, volConfig)
+			if err != nil {
+				return err
+			}
+
+			devices, err := cluster.APIToDevices(localDevices.CloneNative())
+			if err != nil {
+				return err
+			}
+
+			return cluster.UpdateInstanceDevices(ctx, tx.Tx(), int64(inst.ID()), devices)
+		})
+	}
+
+	switchover := func() error {
+		err := updateRecords(srcPool.ID(), b.ID(), vol.Config(), localDevices)
+		if err != nil {
+			return fmt.Errorf("Failed moving volume record to pool %q: %w", b.name, err)
+		}
+
+		revert.Add(func() { _ = updateRecords(b.ID(), srcPool.ID(), srcVolRecord.Config, inst.LocalDevices()) })
+
+		// Move the config drive share so that the source volume is no longer used once switched over.
+		err = b.ensureInstanceSymlink(inst.Type(), inst.Project(), inst.Name(), newVol.MountPath())
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			_ = b.ensureInstanceSymlink(inst.Type(), inst.Project(), inst.Name(), srcVol.MountPath())
+			_ = vm.RemountConfigDrive()
+		})
+
+		err = vm.RemountConfigDrive()
+		if err != nil {
+			return fmt.Errorf("Failed moving config drive to pool %q: %w", b.name, err)
+		}
+
+		return nil
+	}
+
+	err = vm.MirrorRootDisk(diskPath, switchover, op)
+	if err != nil {
+		return fmt.Errorf("Failed mirroring root disk: %w", err)
+	}
+
+	revert.Success()
+
+	// The VM now runs from this pool, so the source volume can't be reverted to anymore.
+	_, err = srcBackend.driver.UnmountVolume(*srcVol, false, op)
+	if err != nil {
+		return fmt.Errorf("Failed unmounting volume on source pool %q: %w", srcPool.Name(), err)
+	}
+
+	err = srcBackend.driver.DeleteVolume(*srcVol, op)
+	if err != nil {
+		return fmt.Errorf("Failed deleting volume on source pool %q: %w", srcPool.Name(), err)
+	}
+
+	return nil
+}
+
 // BackupInstance creates an instance backup.
-func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
-	l := logger.AddContext(b.logger, logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "optimized": optimized, "snapshots": snapshots})
+func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, baseSnapshot string, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "optimized": optimized, "snapshots": snapshots, "baseSnapshot": baseSnapshot})
 	l.Debug("BackupInstance started")
 	defer l.Debug("BackupInstance finished")
 
@@ -2352,7 +2757,19 @@ func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancew
 		}
 	}
 
-	err = b.driver.BackupVolume(*vol, tarWriter, optimized, snapNames, op)
+	// Incremental backups only include the snapshots taken after the base snapshot.
+	if baseSnapshot != "" {
+		if !snapshots {
+			return fmt.Errorf("Incremental backups require snapshots to be included")
+		}
+
+		snapNames, err = backup.SnapshotsAfter(snapNames, baseSnapshot)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = b.driver.BackupVolume(*vol, tarWriter, optimized, snapNames, baseSnapshot, op)
 	if err != nil {
 		return err
 	}
@@ -2553,19 +2970,49 @@ func (b *lxdBackend) getInstanceDisk(inst instance.Instance) (string, error) {
 	return diskPath, nil
 }
 
-// CreateInstanceSnapshot creates a snaphot of an instance volume.
-func (b *lxdBackend) CreateInstanceSnapshot(inst instance.Instance, src instance.Instance, op *operations.Operation) error {
-	l := logger.AddContext(b.logger, logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "src": src.Name()})
-	l.Debug("CreateInstanceSnapshot started")
-	defer l.Debug("CreateInstanceSnapshot finished")
-
-	if inst.Type() != src.Type() {
-		return fmt.Errorf("Instance types must match")
+// instanceLiveDisk returns the running VM (or the running parent VM of a snapshot) as a live disk if the driver can
+// change the disk of running VMs. Returns nil otherwise.
+func (b *lxdBackend) instanceLiveDisk(inst instance.Instance) (drivers.LiveDisk, error) {
+	if !b.driver.Info().LiveVMSnapshots || inst.Type() != instancetype.VM {
+		return nil, nil
 	}
 
-	if !inst.IsSnapshot() {
-		return fmt.Errorf("Instance must be a snapshot")
-	}
+	if inst.IsSnapshot() {
+		parentName, _, _ := shared.InstanceGetParentAndSnapshotName(inst.Name())
+
+		parent, err := instance.LoadByProjectAndName(b.state, inst.Project(), parentName)
+		if err != nil {
+			return nil, fmt.Errorf("Failed loading parent instance %q: %w", parentName, err)
+		}
+
+		inst = parent
+	}
+
+	if !inst.IsRunning() {
+		return nil, nil
+	}
+
+	liveDisk, ok := inst.(drivers.LiveDisk)
+	if !ok {
+		return nil, nil
+	}
+
+	return liveDisk, nil
+}
+
+// CreateInstanceSnapshot creates a snaphot of an instance volume.
+func (b *lxdBackend) CreateInstanceSnapshot(inst instance.Instance, src instance.Instance, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "src": src.Name()})
+	l.Debug("CreateInstanceSnapshot started")
+	defer l.Debug("CreateInstanceSnapshot finished")
+
+	if inst.Type() != src.Type() {
+		return fmt.Errorf("Instance types must match")
+	}
+
+	if !inst.IsSnapshot() {
+		return fmt.Errorf("Instance must be a snapshot")
+	}
 
 	if src.IsSnapshot() {
 		return fmt.Errorf("Source instance cannot be a snapshot")
@@ -2596,8 +3043,14 @@ func (b *lxdBackend) CreateInstanceSnapshot(inst instance.Instance, src instance
 
 	revert.Add(func() { _ = VolumeDBDelete(b, inst.Project(), inst.Name(), volType) })
 
+	liveDisk, err := b.instanceLiveDisk(src)
+	if err != nil {
+		return err
+	}
+
 	// Some driver backing stores require that running instances be frozen during snapshot.
-	if b.driver.Info().RunningCopyFreeze && src.IsRunning() && !src.IsFrozen() {
+	// This isn't needed when the driver can switch a running VM over to a new disk image instead.
+	if liveDisk == nil && b.driver.Info().RunningCopyFreeze && src.IsRunning() && !src.IsFrozen() {
 		// Freeze the processes.
 		err = src.Freeze()
 		if err != nil {
@@ -2614,6 +3067,7 @@ func (b *lxdBackend) CreateInstanceSnapshot(inst instance.Instance, src instance
 	// Get the volume.
 	// There's no need to pass config as it's not needed when creating volume snapshots.
 	vol := b.GetVolume(volType, contentType, volStorageName, nil)
+	vol.SetLiveDisk(liveDisk)
 
 	// Lock this operation to ensure that the only one snapshot is made at the time.
 	// Other operations will wait for this one to finish.
@@ -2670,8 +3124,14 @@ func (b *lxdBackend) RenameInstanceSnapshot(inst instance.Instance, newName stri
 	contentType := InstanceContentType(inst)
 	volStorageName := project.Instance(inst.Project(), inst.Name())
 
+	liveDisk, err := b.instanceLiveDisk(inst)
+	if err != nil {
+		return err
+	}
+
 	// Rename storage volume snapshot. No need to pass config as it's not needed when renaming a volume.
 	snapVol := b.GetVolume(volType, contentType, volStorageName, nil)
+	snapVol.SetLiveDisk(liveDisk)
 	err = b.driver.RenameVolumeSnapshot(snapVol, newName, op)
 	if err != nil {
 		return err
@@ -2682,6 +3142,7 @@ func (b *lxdBackend) RenameInstanceSnapshot(inst instance.Instance, newName stri
 	revert.Add(func() {
 		// Revert rename. No need to pass config as it's not needed when renaming a volume.
 		newSnapVol := b.GetVolume(volType, contentType, project.Instance(inst.Project(), newVolName), nil)
+		newSnapVol.SetLiveDisk(liveDisk)
 		_ = b.driver.RenameVolumeSnapshot(newSnapVol, oldSnapshotName, op)
 	})
 
@@ -2737,6 +3198,13 @@ func (b *lxdBackend) DeleteInstanceSnapshot(inst instance.Instance, op *operatio
 	// There's no need to pass config as it's not needed when deleting a volume snapshot.
 	vol := b.GetVolume(volType, contentType, snapVolName, nil)
 
+	liveDisk, err := b.instanceLiveDisk(inst)
+	if err != nil {
+		return err
+	}
+
+	vol.SetLiveDisk(liveDisk)
+
 	if b.driver.HasVolume(vol) {
 		err = b.driver.DeleteVolumeSnapshot(vol, op)
 		if err != nil {
@@ -3220,6 +3688,12 @@ func (b *lxdBackend) UpdateImage(fingerprint, newDesc string, newConfig map[stri
 	return b.updateVolumeDescriptionOnly(project.Default, fingerprint, db.StoragePoolVolumeTypeImage, newDesc, newConfig, op)
 }
 
+// customVolumeOperationLock locks a custom volume against other operations that create, rename, delete or check
+// it and returns the UnlockFunc.
+func (b *lxdBackend) customVolumeOperationLock(projectName string, volName string) locking.UnlockFunc {
+	return locking.Lock(drivers.OperationLockName("CustomVolume", b.name, drivers.VolumeTypeCustom, "", project.StorageVolume(projectName, volName)))
+}
+
 // CreateCustomVolume creates an empty custom volume.
 func (b *lxdBackend) CreateCustomVolume(projectName string, volName string, desc string, config map[string]string, contentType drivers.ContentType, op *operations.Operation) error {
 	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "volName": volName, "desc": desc, "config": config, "contentType": contentType})
@@ -3231,6 +3705,9 @@ func (b *lxdBackend) CreateCustomVolume(projectName string, volName string, desc
 		return err
 	}
 
+	unlock := b.customVolumeOperationLock(projectName, volName)
+	defer unlock()
+
 	// Get the volume name on storage.
 	volStorageName := project.StorageVolume(projectName, volName)
 
@@ -3365,6 +3842,9 @@ func (b *lxdBackend) CreateCustomVolumeFromCopy(projectName string, srcProjectNa
 	if srcPool == b {
 		l.Debug("CreateCustomVolumeFromCopy same-pool mode detected")
 
+		unlock := b.customVolumeOperationLock(projectName, volName)
+		defer unlock()
+
 		// Get the volume name on storage.
 		volStorageName := project.StorageVolume(projectName, volName)
 		vol := b.GetVolume(drivers.VolumeTypeCustom, contentType, volStorageName, config)
@@ -3662,6 +4142,9 @@ func (b *lxdBackend) CreateCustomVolumeFromMigration(projectName string, conn io
 		return err
 	}
 
+	unlock := b.customVolumeOperationLock(projectName, args.Name)
+	defer unlock()
+
 	storagePoolSupported := false
 	for _, supportedType := range b.Driver().Info().VolumeTypes {
 		if supportedType == drivers.VolumeTypeCustom {
@@ -3804,6 +4287,12 @@ func (b *lxdBackend) RenameCustomVolume(projectName string, volName string, newV
 		return fmt.Errorf("New volume name cannot be a snapshot")
 	}
 
+	unlock := b.customVolumeOperationLock(projectName, volName)
+	defer unlock()
+
+	newUnlock := b.customVolumeOperationLock(projectName, newVolName)
+	defer newUnlock()
+
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -3841,7 +4330,7 @@ func (b *lxdBackend) RenameCustomVolume(projectName string, volName string, newV
 		backupRow := br // Local var for revert.
 		_, backupName, _ := shared.InstanceGetParentAndSnapshotName(backupRow.Name)
 		newVolBackupName := drivers.GetSnapshotVolumeName(newVolName, backupName)
-		volBackup := backup.NewVolumeBackup(b.state, projectName, b.name, volName, backupRow.ID, backupRow.Name, backupRow.CreationDate, backupRow.ExpiryDate, backupRow.VolumeOnly, backupRow.OptimizedStorage)
+		volBackup := backup.NewVolumeBackup(b.state, projectName, b.name, volName, backupRow.ID, backupRow.Name, backupRow.CreationDate, backupRow.ExpiryDate, backupRow.VolumeOnly, backupRow.OptimizedStorage, backupRow.Parent)
 		err = volBackup.Rename(newVolBackupName)
 		if err != nil {
 			return fmt.Errorf("Failed renaming backup %q to %q: %w", backupRow.Name, newVolBackupName, err)
@@ -4007,6 +4496,51 @@ func (b *lxdBackend) UpdateCustomVolume(projectName string, volName string, newD
 		}
 	}
 
+	// Apply IO limit changes to the running instances using the volume.
+	limitsChanged := false
+	for key := range changedConfig {
+		if strings.HasPrefix(key, "limits.") {
+			limitsChanged = true
+			break
+		}
+	}
+
+	if limitsChanged {
+		usedBy := map[string][]string{}
+		instances := map[string]db.InstanceArgs{}
+		instProfiles := map[string][]api.Profile{}
+
+		err = VolumeUsedByInstanceDevices(b.state, b.name, projectName, curVol, true, func(dbInst db.InstanceArgs, project api.Project, profiles []api.Profile, usedByDevices []string) error {
+			key := fmt.Sprintf("%s/%s", dbInst.Project, dbInst.Name)
+			instances[key] = dbInst
+			instProfiles[key] = profiles
+			usedBy[key] = usedByDevices
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for key, dbInst := range instances {
+			inst, err := instance.Load(b.state, dbInst, instProfiles[key])
+			if err != nil {
+				return err
+			}
+
+			if !inst.IsRunning() {
+				continue
+			}
+
+			for _, devName := range usedBy[key] {
+				err = inst.ReloadDevice(devName)
+				if err != nil {
+					l.Warn("Failed applying IO limits to instance device", logger.Ctx{"instance": inst.Name(), "device": devName, "err": err})
+				}
+			}
+		}
+	}
+
 	b.state.Events.SendLifecycle(projectName, lifecycle.StorageVolumeUpdated.Event(newVol, string(newVol.Type()), projectName, op, nil))
 
 	return nil
@@ -4070,6 +4604,15 @@ func (b *lxdBackend) DeleteCustomVolume(projectName string, volName string, op *
 		return fmt.Errorf("Volume name cannot be a snapshot")
 	}
 
+	unlock := b.customVolumeOperationLock(projectName, volName)
+	defer unlock()
+
+	return b.deleteCustomVolume(projectName, volName, op)
+}
+
+// deleteCustomVolume deletes a custom volume and its snapshots. The caller must hold the volume's operation lock.
+func (b *lxdBackend) deleteCustomVolume(projectName string, volName string, op *operations.Operation) error {
+
 	// Retrieve a list of snapshots.
 	snapshots, err := VolumeDBSnapshotsGet(b, projectName, volName, drivers.VolumeTypeCustom)
 	if err != nil {
@@ -4167,6 +4710,60 @@ func (b *lxdBackend) GetCustomVolumeUsage(projectName, volName string) (int64, e
 	return b.driver.GetVolumeUsage(vol)
 }
 
+// GetCustomVolumeIOStats returns the IO counters of a custom volume.
+// Returns nil if the volume isn't active or isn't backed by a block device of its own.
+func (b *lxdBackend) GetCustomVolumeIOStats(projectName, volName string) (*api.StorageVolumeStateIO, error) {
+	_, volume, err := b.state.DB.Cluster.GetLocalStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the volume name on storage.
+	volStorageName := project.StorageVolume(projectName, volName)
+	vol := b.GetVolume(drivers.VolumeTypeCustom, drivers.ContentType(volume.ContentType), volStorageName, volume.Config)
+
+	var devID uint64
+	if vol.ContentType() == drivers.ContentTypeBlock {
+		diskPath, err := b.driver.GetVolumeDiskPath(vol)
+		if err != nil {
+			return nil, nil // Volume isn't active.
+		}
+
+		var stat unix.Stat_t
+		err = unix.Stat(diskPath, &stat)
+		if err != nil || stat.Mode&unix.S_IFMT != unix.S_IFBLK {
+			return nil, nil // Volume isn't active or is backed by a file.
+		}
+
+		devID = stat.Rdev
+	} else {
+		if !filesystem.IsMountPoint(vol.MountPath()) {
+			return nil, nil
+		}
+
+		var volStat, poolStat unix.Stat_t
+		err = unix.Stat(vol.MountPath(), &volStat)
+		if err != nil {
+			return nil, err
+		}
+
+		err = unix.Stat(drivers.GetPoolMountPath(b.name), &poolStat)
+		if err != nil {
+			return nil, err
+		}
+
+		// Volumes sharing the device of the pool or using anonymous devices (such as btrfs subvolumes
+		// or ZFS datasets) have no counters of their own.
+		if volStat.Dev == poolStat.Dev || unix.Major(volStat.Dev) == 0 {
+			return nil, nil
+		}
+
+		devID = volStat.Dev
+	}
+
+	return blockDeviceIOStats(unix.Major(devID), unix.Minor(devID))
+}
+
 // MountCustomVolume mounts a custom volume.
 func (b *lxdBackend) MountCustomVolume(projectName, volName string, op *operations.Operation) error {
 	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "volName": volName})
@@ -4220,6 +4817,17 @@ func (b *lxdBackend) ImportCustomVolume(projectName string, poolVol *backupConfi
 	l.Debug("ImportCustomVolume started")
 	defer l.Debug("ImportCustomVolume finished")
 
+	unlock := b.customVolumeOperationLock(projectName, poolVol.Volume.Name)
+	defer unlock()
+
+	return b.importCustomVolume(projectName, poolVol, op)
+}
+
+// importCustomVolume creates the DB records of an existing custom volume. The caller must hold the volume's
+// operation lock.
+func (b *lxdBackend) importCustomVolume(projectName string, poolVol *backupConfig.Config, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "volName": poolVol.Volume.Name})
+
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -5167,120 +5775,455 @@ func (b *lxdBackend) detectUnknownCustomVolume(vol *drivers.Volume, projectVols
 	return nil
 }
 
-// ImportInstance takes an existing instance volume on the storage backend and ensures that the volume directories
-// and symlinks are restored as needed to make it operational with LXD. Used during the recovery import stage.
-// If the instance exists on the local cluster member then the local mount status is restored as needed.
-// If the optional poolVol argument is provided then it is used to create the storage volume database records.
-func (b *lxdBackend) ImportInstance(inst instance.Instance, poolVol *backupConfig.Config, op *operations.Operation) error {
-	l := logger.AddContext(b.logger, logger.Ctx{"project": inst.Project(), "instance": inst.Name()})
-	l.Debug("ImportInstance started")
-	defer l.Debug("ImportInstance finished")
+// checkVolumeSizeTolerance is the minimum difference between the configured and actual size of a block volume
+// that is reported by CheckVolumes. This allows for the rounding of volume sizes performed by storage drivers.
+const checkVolumeSizeTolerance = 64 * 1024 * 1024
 
-	volType, err := InstanceTypeToVolumeType(inst.Type())
-	if err != nil {
-		return err
+// checkVolumeSizeString returns the size used in the descriptions of the issues found by CheckVolumes.
+func checkVolumeSizeString(sizeBytes int64) string {
+	if sizeBytes <= 0 {
+		return "unlimited"
 	}
 
-	// Get any snapshots the instance has in the format <instance name>/<snapshot name>.
-	snapshots, err := b.state.DB.Cluster.GetInstanceSnapshotsNames(inst.Project(), inst.Name())
+	return units.GetByteSizeStringIEC(sizeBytes, 2)
+}
+
+// CheckVolumes compares the instance and custom volumes that exist on the storage pool with the volume records
+// in the database and returns any inconsistencies found. Volume records are only checked if they are located on
+// the local member (or the pool is remote). If repair is true then an attempt is made to repair each issue and the
+// outcome is recorded in the returned issue.
+func (b *lxdBackend) CheckVolumes(repair bool, op *operations.Operation) ([]api.StoragePoolCheckIssue, error) {
+	l := logger.AddContext(b.logger, logger.Ctx{"repair": repair})
+	l.Debug("CheckVolumes started")
+	defer l.Debug("CheckVolumes finished")
+
+	poolVols, err := b.driver.ListVolumes()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("Failed getting pool volumes: %w", err)
 	}
 
-	// Get local cluster member name.
-	var nodeName string
+	var dbVols []db.StorageVolumeArgs
 	err = b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		nodeName, err = tx.GetLocalNodeName()
+		dbVols, err = tx.GetLocalStoragePoolVolumesAllProjects(b.ID())
 		return err
 	})
 	if err != nil {
-		return fmt.Errorf("Failed getting local cluster member name: %w", err)
+		return nil, fmt.Errorf("Failed getting pool volume records: %w", err)
 	}
 
-	contentType := InstanceContentType(inst)
+	// Index the volumes found on the storage pool by their type and name on storage.
+	diskVols := make(map[string]drivers.Volume, len(poolVols))
+	diskVolKeys := make([]string, 0, len(poolVols))
+	for _, poolVol := range poolVols {
+		if poolVol.Type() == drivers.VolumeTypeImage {
+			continue // Image volumes are managed by the image cache.
+		}
 
-	revert := revert.New()
-	defer revert.Fail()
+		if poolVol.Type() == drivers.VolumeTypeBucket {
+			continue // Bucket volumes are recorded in the storage buckets table, not as storage volumes.
+		}
 
-	var volumeConfig map[string]string
+		key := fmt.Sprintf("%s/%s", poolVol.Type(), poolVol.Name())
+		diskVols[key] = poolVol
+		diskVolKeys = append(diskVolKeys, key)
+	}
 
-	// Create storage volume database records if in recover mode.
-	if poolVol != nil {
-		// Copy volume config from backup file config if present,
-		// so VolumeDBCreate can safely modify the copy if needed.
-		if poolVol.Volume != nil {
-			volumeConfig = make(map[string]string, len(poolVol.Volume.Config))
-			for k, v := range poolVol.Volume.Config {
-				volumeConfig[k] = v
+	sort.Strings(diskVolKeys)
+
+	issues := []api.StoragePoolCheckIssue{}
+
+	// addIssue records an issue, running the repair function if repairing is requested.
+	addIssue := func(issue api.StoragePoolCheckIssue, repairFunc func() error) {
+		if repair {
+			err := repairFunc()
+			if err != nil {
+				issue.RepairError = err.Error()
+			} else {
+				issue.Repaired = true
 			}
 		}
 
-		// Validate config and create database entry for recovered storage volume.
-		err = VolumeDBCreate(b, inst.Project(), poolVol.Volume.Name, "", volType, false, volumeConfig, time.Time{}, contentType, false)
+		l.Warn("Storage pool check found an issue", logger.Ctx{"type": issue.Type, "project": issue.Project, "volType": issue.VolumeType, "volName": issue.Volume, "description": issue.Description, "repaired": issue.Repaired, "repairErr": issue.RepairError})
+		issues = append(issues, issue)
+	}
+
+	knownVols := make(map[string]struct{}, len(dbVols))
+
+	// checkRecord compares a volume record with the volume found on the storage pool.
+	checkRecord := func(dbVol db.StorageVolumeArgs) error {
+		volType, err := VolumeDBTypeToType(dbVol.Type)
 		if err != nil {
 			return err
 		}
 
-		revert.Add(func() { _ = VolumeDBDelete(b, inst.Project(), poolVol.Volume.Name, volType) })
+		if volType == drivers.VolumeTypeImage {
+			return nil // Image volumes are managed by the image cache.
+		}
 
-		if len(snapshots) > 0 && len(poolVol.VolumeSnapshots) > 0 {
-			// Create storage volume snapshot DB records from the entries in the backup file config.
-			for _, poolVolSnap := range poolVol.VolumeSnapshots {
-				fullSnapName := drivers.GetSnapshotVolumeName(poolVol.Volume.Name, poolVolSnap.Name)
+		volStorageName := project.Instance(dbVol.ProjectName, dbVol.Name)
+		if volType == drivers.VolumeTypeCustom {
+			volStorageName = project.StorageVolume(dbVol.ProjectName, dbVol.Name)
+		}
 
-				// Copy volume config from backup file if present,
-				// so VolumeDBCreate can safely modify the copy if needed.
-				snapVolumeConfig := make(map[string]string, len(poolVolSnap.Config))
-				for k, v := range poolVolSnap.Config {
-					snapVolumeConfig[k] = v
+		key := fmt.Sprintf("%s/%s", volType, volStorageName)
+		knownVols[key] = struct{}{}
+
+		if volType == drivers.VolumeTypeCustom {
+			// Stop the volume being created, renamed or deleted while it is checked and repaired, and
+			// reload its record in case that happened since the records were listed.
+			unlock := b.customVolumeOperationLock(dbVol.ProjectName, dbVol.Name)
+			defer unlock()
+
+			_, volume, err := b.state.DB.Cluster.GetLocalStoragePoolVolume(dbVol.ProjectName, dbVol.Name, dbVol.Type, b.ID())
+			if err != nil {
+				if response.IsNotFoundError(err) {
+					return nil
 				}
 
-				// Validate config and create database entry for recovered storage volume.
-				err = VolumeDBCreate(b, inst.Project(), fullSnapName, poolVolSnap.Description, volType, true, snapVolumeConfig, time.Time{}, contentType, false)
+				return err
+			}
+
+			dbVol.ContentType = volume.ContentType
+			dbVol.Config = volume.Config
+		}
+
+		issue := api.StoragePoolCheckIssue{
+			Project:    dbVol.ProjectName,
+			VolumeType: dbVol.TypeName,
+			Volume:     dbVol.Name,
+		}
+
+		dbContentType, err := VolumeContentTypeNameToContentType(dbVol.ContentType)
+		if err != nil {
+			return err
+		}
+
+		contentType, err := VolumeDBContentTypeToContentType(dbContentType)
+		if err != nil {
+			return err
+		}
+
+		vol := b.GetVolume(volType, contentType, volStorageName, dbVol.Config)
+
+		diskVol, found := diskVols[key]
+		if !found && volType == drivers.VolumeTypeCustom && b.driver.HasVolume(vol) {
+			return nil // Volume was created after the storage pool volumes were listed.
+		}
+
+		if !found {
+			issue.Type = api.StoragePoolCheckIssueMissing
+			issue.Description = "Volume record exists in the database but the volume wasn't found on the storage pool"
+			addIssue(issue, func() error {
+				if volType != drivers.VolumeTypeCustom {
+					return fmt.Errorf("Missing instance volumes can't be repaired, delete the instance instead")
+				}
+
+				return b.checkRepairMissingCustomVolume(dbVol, op)
+			})
+
+			return nil
+		}
+
+		// Instance volumes always have the content type implied by their volume type.
+		if volType != drivers.VolumeTypeCustom {
+			return nil
+		}
+
+		if diskVol.ContentType() != contentType {
+			issue.Type = api.StoragePoolCheckIssueContentType
+			issue.Description = fmt.Sprintf("Volume has content type %q in the database but %q on the storage pool", contentType, diskVol.ContentType())
+			addIssue(issue, func() error {
+				diskContentType, err := VolumeContentTypeToDBContentType(diskVol.ContentType())
 				if err != nil {
 					return err
 				}
 
-				revert.Add(func() { _ = VolumeDBDelete(b, inst.Project(), fullSnapName, volType) })
-			}
-		} else {
-			b.logger.Warn("Missing volume snapshot info in backup config, using parent volume config")
+				return b.state.DB.Cluster.UpdateStoragePoolVolumeContentType(dbVol.ProjectName, dbVol.Name, dbVol.Type, b.ID(), diskContentType)
+			})
 
-			// Create storage volume snapshot DB records based on instance snapshot list, as the
-			// backup config doesn't contain the required info. This is needed because there was a
-			// historical bug that meant that the instance's backup file didn't store the storage
-			// volume snapshot info.
-			for _, i := range snapshots {
-				fullSnapName := i // Local var for revert.
+			return nil
+		}
 
-				// Validate config and create database entry for new storage volume.
-				// Use parent volume config.
-				err = VolumeDBCreate(b, inst.Project(), fullSnapName, "", volType, true, volumeConfig, time.Time{}, contentType, false)
+		sizeBytes, err := units.ParseByteSizeString(vol.ConfigSize())
+		if err != nil {
+			return err
+		}
+
+		var diskSizeBytes int64
+		if contentType == drivers.ContentTypeBlock {
+			err = vol.MountTask(func(_ string, op *operations.Operation) error {
+				diskPath, err := b.driver.GetVolumeDiskPath(vol)
 				if err != nil {
 					return err
 				}
 
-				revert.Add(func() { _ = VolumeDBDelete(b, inst.Project(), fullSnapName, volType) })
+				diskSizeBytes, err = drivers.BlockDiskSizeBytes(diskPath)
+				return err
+			}, op)
+		} else {
+			// The size limit of filesystem volumes is only compared when the driver can report it.
+			quotaReporter, ok := b.driver.(drivers.VolumeQuotaReporter)
+			if !ok {
+				return nil
 			}
+
+			diskSizeBytes, err = quotaReporter.GetVolumeQuota(vol)
 		}
-	}
 
-	// Generate the effective root device volume for instance.
-	vol, err := b.instanceEffectiveRootVolume(inst, volumeConfig)
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			l.Warn("Failed getting volume size", logger.Ctx{"project": dbVol.ProjectName, "volName": dbVol.Name, "err": err})
+			return nil
+		}
 
-	err = vol.EnsureMountPath()
-	if err != nil {
-		return err
-	}
+		tolerance := sizeBytes / 100
+		if tolerance < checkVolumeSizeTolerance {
+			tolerance = checkVolumeSizeTolerance
+		}
 
-	// Only attempt to restore mount status on instance's local cluster member.
-	if inst.Location() == nodeName {
-		l.Debug("Restoring local instance mount status")
+		if diskSizeBytes < sizeBytes || diskSizeBytes-sizeBytes > tolerance {
+			issue.Type = api.StoragePoolCheckIssueQuota
+			issue.Description = fmt.Sprintf("Volume size is %s on the storage pool but configured as %s", checkVolumeSizeString(diskSizeBytes), checkVolumeSizeString(sizeBytes))
+			addIssue(issue, func() error {
+				return vol.SetQuota(vol.ConfigSize(), false, op)
+			})
+		}
 
-		if inst.IsRunning() {
+		return nil
+	}
+
+	for _, dbVol := range dbVols {
+		err = checkRecord(dbVol)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// checkOrphan reports a volume found on the storage pool that has no volume record.
+	checkOrphan := func(diskVol drivers.Volume) error {
+		volTypeName, err := VolumeTypeToDBTypeName(diskVol.Type())
+		if err != nil {
+			return err
+		}
+
+		projectName, volName := project.InstanceParts(diskVol.Name())
+		if diskVol.Type() == drivers.VolumeTypeCustom {
+			projectName, volName = project.StorageVolumeParts(diskVol.Name())
+
+			// Stop the volume being created, renamed or deleted while it is checked and repaired, and
+			// skip it if that happened since the storage pool volumes were listed.
+			unlock := b.customVolumeOperationLock(projectName, volName)
+			defer unlock()
+
+			if !b.driver.HasVolume(diskVol) {
+				return nil
+			}
+
+			_, err = VolumeDBGet(b, projectName, volName, drivers.VolumeTypeCustom)
+			if err == nil {
+				return nil
+			} else if !response.IsNotFoundError(err) {
+				return err
+			}
+		}
+
+		issue := api.StoragePoolCheckIssue{
+			Type:        api.StoragePoolCheckIssueOrphan,
+			Project:     projectName,
+			VolumeType:  volTypeName,
+			Volume:      volName,
+			Description: "Volume exists on the storage pool but has no record in the database",
+		}
+
+		addIssue(issue, func() error {
+			if diskVol.Type() != drivers.VolumeTypeCustom {
+				return fmt.Errorf(`Orphaned instance volumes can't be repaired, use "lxd recover" instead`)
+			}
+
+			return b.checkRepairOrphanCustomVolume(diskVol, op)
+		})
+
+		return nil
+	}
+
+	for _, key := range diskVolKeys {
+		_, found := knownVols[key]
+		if found {
+			continue
+		}
+
+		err = checkOrphan(diskVols[key])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return issues, nil
+}
+
+// checkRepairMissingCustomVolume removes the database records of a custom volume that doesn't exist on the
+// storage pool, as long as it isn't in use.
+func (b *lxdBackend) checkRepairMissingCustomVolume(dbVol db.StorageVolumeArgs, op *operations.Operation) error {
+	used, err := VolumeUsedByDaemon(b.state, b.name, dbVol.Name)
+	if err != nil {
+		return err
+	}
+
+	if used {
+		return fmt.Errorf("Volume is in use by the daemon")
+	}
+
+	vol := &api.StorageVolume{Name: dbVol.Name, Type: dbVol.TypeName}
+	err = VolumeUsedByInstanceDevices(b.state, b.name, dbVol.ProjectName, vol, true, func(inst db.InstanceArgs, project api.Project, profiles []api.Profile, usedByDevices []string) error {
+		used = true
+		return db.ErrInstanceListStop
+	})
+	if err != nil && err != db.ErrInstanceListStop {
+		return err
+	}
+
+	if used {
+		return fmt.Errorf("Volume is in use by instances")
+	}
+
+	return b.deleteCustomVolume(dbVol.ProjectName, dbVol.Name, op)
+}
+
+// checkRepairOrphanCustomVolume creates the database records for a custom volume that exists on the storage
+// pool, using the same logic as used by the recovery process.
+func (b *lxdBackend) checkRepairOrphanCustomVolume(vol drivers.Volume, op *operations.Operation) error {
+	projectVols := make(map[string][]*backupConfig.Config)
+
+	err := b.detectUnknownCustomVolume(&vol, projectVols, op)
+	if err != nil {
+		return err
+	}
+
+	for projectName, poolVols := range projectVols {
+		for _, poolVol := range poolVols {
+			err = b.importCustomVolume(projectName, poolVol, op)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ImportInstance takes an existing instance volume on the storage backend and ensures that the volume directories
+// and symlinks are restored as needed to make it operational with LXD. Used during the recovery import stage.
+// If the instance exists on the local cluster member then the local mount status is restored as needed.
+// If the optional poolVol argument is provided then it is used to create the storage volume database records.
+func (b *lxdBackend) ImportInstance(inst instance.Instance, poolVol *backupConfig.Config, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": inst.Project(), "instance": inst.Name()})
+	l.Debug("ImportInstance started")
+	defer l.Debug("ImportInstance finished")
+
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return err
+	}
+
+	// Get any snapshots the instance has in the format <instance name>/<snapshot name>.
+	snapshots, err := b.state.DB.Cluster.GetInstanceSnapshotsNames(inst.Project(), inst.Name())
+	if err != nil {
+		return err
+	}
+
+	// Get local cluster member name.
+	var nodeName string
+	err = b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		nodeName, err = tx.GetLocalNodeName()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Failed getting local cluster member name: %w", err)
+	}
+
+	contentType := InstanceContentType(inst)
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	var volumeConfig map[string]string
+
+	// Create storage volume database records if in recover mode.
+	if poolVol != nil {
+		// Copy volume config from backup file config if present,
+		// so VolumeDBCreate can safely modify the copy if needed.
+		if poolVol.Volume != nil {
+			volumeConfig = make(map[string]string, len(poolVol.Volume.Config))
+			for k, v := range poolVol.Volume.Config {
+				volumeConfig[k] = v
+			}
+		}
+
+		// Validate config and create database entry for recovered storage volume.
+		err = VolumeDBCreate(b, inst.Project(), poolVol.Volume.Name, "", volType, false, volumeConfig, time.Time{}, contentType, false)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() { _ = VolumeDBDelete(b, inst.Project(), poolVol.Volume.Name, volType) })
+
+		if len(snapshots) > 0 && len(poolVol.VolumeSnapshots) > 0 {
+			// Create storage volume snapshot DB records from the entries in the backup file config.
+			for _, poolVolSnap := range poolVol.VolumeSnapshots {
+				fullSnapName := drivers.GetSnapshotVolumeName(poolVol.Volume.Name, poolVolSnap.Name)
+
+				// Copy volume config from backup file if present,
+				// so VolumeDBCreate can safely modify the copy if needed.
+				snapVolumeConfig := make(map[string]string, len(poolVolSnap.Config))
+				for k, v := range poolVolSnap.Config {
+					snapVolumeConfig[k] = v
+				}
+
+				// Validate config and create database entry for recovered storage volume.
+				err = VolumeDBCreate(b, inst.Project(), fullSnapName, poolVolSnap.Description, volType, true, snapVolumeConfig, time.Time{}, contentType, false)
+				if err != nil {
+					return err
+				}
+
+				revert.Add(func() { _ = VolumeDBDelete(b, inst.Project(), fullSnapName, volType) })
+			}
+		} else {
+			b.logger.Warn("Missing volume snapshot info in backup config, using parent volume config")
+
+			// Create storage volume snapshot DB records based on instance snapshot list, as the
+			// backup config doesn't contain the required info. This is needed because there was a
+			// historical bug that meant that the instance's backup file didn't store the storage
+			// volume snapshot info.
+			for _, i := range snapshots {
+				fullSnapName := i // Local var for revert.
+
+				// Validate config and create database entry for new storage volume.
+				// Use parent volume config.
+				err = VolumeDBCreate(b, inst.Project(), fullSnapName, "", volType, true, volumeConfig, time.Time{}, contentType, false)
+				if err != nil {
+					return err
+				}
+
+				revert.Add(func() { _ = VolumeDBDelete(b, inst.Project(), fullSnapName, volType) })
+			}
+		}
+	}
+
+	// Generate the effective root device volume for instance.
+	vol, err := b.instanceEffectiveRootVolume(inst, volumeConfig)
+	if err != nil {
+		return err
+	}
+
+	err = vol.EnsureMountPath()
+	if err != nil {
+		return err
+	}
+
+	// Only attempt to restore mount status on instance's local cluster member.
+	if inst.Location() == nodeName {
+		l.Debug("Restoring local instance mount status")
+
+		if inst.IsRunning() {
 			// If the instance is running then this implies the volume is mounted, but if the LXD
 			// daemon has been restarted since the DB records were removed then there will be no mount
 			// reference counter showing the volume is in use. If this is the case then call mount the
@@ -5340,8 +6283,8 @@ func (b *lxdBackend) ImportInstance(inst instance.Instance, poolVol *backupConfi
 	return nil
 }
 
-func (b *lxdBackend) BackupCustomVolume(projectName string, volName string, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
-	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "volume": volName, "optimized": optimized, "snapshots": snapshots})
+func (b *lxdBackend) BackupCustomVolume(projectName string, volName string, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, baseSnapshot string, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "volume": volName, "optimized": optimized, "snapshots": snapshots, "baseSnapshot": baseSnapshot})
 	l.Debug("BackupCustomVolume started")
 	defer l.Debug("BackupCustomVolume finished")
 
@@ -5370,7 +6313,19 @@ func (b *lxdBackend) BackupCustomVolume(projectName string, volName string, tarW
 
 	vol := b.GetVolume(drivers.VolumeTypeCustom, drivers.ContentType(volume.ContentType), volStorageName, volume.Config)
 
-	err = b.driver.BackupVolume(vol, tarWriter, optimized, snapNames, op)
+	// Incremental backups only include the snapshots taken after the base snapshot.
+	if baseSnapshot != "" {
+		if !snapshots {
+			return fmt.Errorf("Incremental backups require snapshots to be included")
+		}
+
+		snapNames, err = backup.SnapshotsAfter(snapNames, baseSnapshot)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = b.driver.BackupVolume(vol, tarWriter, optimized, snapNames, baseSnapshot, op)
 	if err != nil {
 		return err
 	}
@@ -5391,6 +6346,9 @@ func (b *lxdBackend) CreateCustomVolumeFromBackup(srcBackup backup.Info, srcData
 		return fmt.Errorf("Valid volume snapshot config not found in index")
 	}
 
+	unlock := b.customVolumeOperationLock(srcBackup.Project, srcBackup.Name)
+	defer unlock()
+
 	// Check whether we are allowed to create volumes.
 	req := api.StorageVolumesPost{
 		StorageVolumePut: api.StorageVolumePut{
@@ -5469,3 +6427,481 @@ func (b *lxdBackend) CreateCustomVolumeFromBackup(srcBackup backup.Info, srcData
 	revert.Success()
 	return nil
 }
+
+// bucketVolume returns the storage volume used for the bucket.
+func (b *lxdBackend) bucketVolume(projectName string, bucketName string, config map[string]string) drivers.Volume {
+	// Remote drivers use the volume name as the name of the bucket on the remote object store.
+	if b.driver.Info().Remote {
+		return b.GetVolume(drivers.VolumeTypeBucket, drivers.ContentTypeFS, bucketName, config)
+	}
+
+	return b.GetVolume(drivers.VolumeTypeBucket, drivers.ContentTypeFS, project.StorageVolume(projectName, bucketName), config)
+}
+
+// bucketsMemberSpecific returns whether the pool's buckets are specific to the local member.
+func (b *lxdBackend) bucketsMemberSpecific() bool {
+	return !b.driver.Info().Remote
+}
+
+// loadBucket loads the bucket record from the database.
+func (b *lxdBackend) loadBucket(projectName string, bucketName string) (*db.StorageBucket, error) {
+	var bucket *db.StorageBucket
+	err := b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		bucket, err = tx.GetStoragePoolBucket(b.id, projectName, b.bucketsMemberSpecific(), bucketName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bucket, nil
+}
+
+// GetBucketURL returns the S3 URL of the bucket, or nil if the bucket isn't reachable.
+func (b *lxdBackend) GetBucketURL(bucketName string) *url.URL {
+	if b.driver.Info().Remote {
+		return b.driver.GetBucketURL(bucketName)
+	}
+
+	address := b.state.Endpoints.StorageBucketsAddress()
+	if address == "" {
+		return nil
+	}
+
+	return &url.URL{
+		Scheme: "https",
+		Host:   address,
+		Path:   fmt.Sprintf("/%s", bucketName),
+	}
+}
+
+// ActivateBucket mounts the local bucket volume and ensures its MinIO process is running.
+func (b *lxdBackend) ActivateBucket(projectName string, bucketName string, op *operations.Operation) (*miniod.Process, error) {
+	if b.driver.Info().Remote {
+		return nil, fmt.Errorf("Remote buckets aren't served locally")
+	}
+
+	minioProc := miniod.Get(b.name, projectName, bucketName)
+	if minioProc != nil {
+		return minioProc, nil
+	}
+
+	vol := b.bucketVolume(projectName, bucketName, nil)
+
+	err := b.driver.MountVolume(vol, op)
+	if err != nil {
+		return nil, fmt.Errorf("Failed mounting bucket volume: %w", err)
+	}
+
+	minioProc, err = miniod.EnsureRunning(b.name, projectName, bucketName, vol.MountPath())
+	if err != nil {
+		_, _ = b.driver.UnmountVolume(vol, false, op)
+		return nil, err
+	}
+
+	return minioProc, nil
+}
+
+// deactivateBucket stops the bucket's MinIO process and unmounts the local bucket volume.
+func (b *lxdBackend) deactivateBucket(projectName string, bucketName string, vol drivers.Volume, op *operations.Operation) error {
+	minioProc := miniod.Get(b.name, projectName, bucketName)
+	if minioProc == nil {
+		return nil
+	}
+
+	err := minioProc.Stop()
+	if err != nil {
+		return err
+	}
+
+	_, err = b.driver.UnmountVolume(vol, false, op)
+	if err != nil && !errors.Is(err, drivers.ErrInUse) {
+		return fmt.Errorf("Failed unmounting bucket volume: %w", err)
+	}
+
+	return nil
+}
+
+// CreateBucket creates a new storage bucket.
+func (b *lxdBackend) CreateBucket(projectName string, bucket api.StorageBucketsPost, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "bucketName": bucket.Name, "desc": bucket.Description, "config": bucket.Config})
+	l.Debug("CreateBucket started")
+	defer l.Debug("CreateBucket finished")
+
+	err := b.isStatusReady()
+	if err != nil {
+		return err
+	}
+
+	if !b.driver.Info().Buckets {
+		return api.StatusErrorf(http.StatusBadRequest, "Storage pool does not support buckets")
+	}
+
+	vol := b.bucketVolume(projectName, bucket.Name, bucket.Config)
+	err = b.driver.ValidateBucket(vol)
+	if err != nil {
+		return api.StatusErrorf(http.StatusBadRequest, err.Error())
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create the database record.
+	var bucketID int64
+	err = b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		bucketID, err = tx.CreateStoragePoolBucket(b.id, projectName, b.bucketsMemberSpecific(), bucket)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_ = b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			return tx.DeleteStoragePoolBucket(b.id, bucketID)
+		})
+	})
+
+	// Remote drivers manage the bucket directly.
+	if b.driver.Info().Remote {
+		err = b.driver.CreateBucket(vol, op)
+		if err != nil {
+			return err
+		}
+
+		revert.Success()
+		return nil
+	}
+
+	// Local drivers store the bucket on a dedicated volume served by MinIO.
+	err = b.driver.CreateVolume(vol, nil, op)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = b.driver.DeleteVolume(vol, op) })
+
+	minioProc, err := b.ActivateBucket(projectName, bucket.Name, op)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = b.deactivateBucket(projectName, bucket.Name, vol, op) })
+
+	s3Client := s3.NewClient(minioProc.URL(), minioProc.AdminCredentials(), false)
+	err = s3Client.CreateBucket(context.TODO(), bucket.Name)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// UpdateBucket updates an existing storage bucket.
+func (b *lxdBackend) UpdateBucket(projectName string, bucketName string, bucket api.StorageBucketPut, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "bucketName": bucketName, "desc": bucket.Description, "config": bucket.Config})
+	l.Debug("UpdateBucket started")
+	defer l.Debug("UpdateBucket finished")
+
+	err := b.isStatusReady()
+	if err != nil {
+		return err
+	}
+
+	curBucket, err := b.loadBucket(projectName, bucketName)
+	if err != nil {
+		return err
+	}
+
+	vol := b.bucketVolume(projectName, bucketName, bucket.Config)
+	err = b.driver.ValidateBucket(vol)
+	if err != nil {
+		return api.StatusErrorf(http.StatusBadRequest, err.Error())
+	}
+
+	// Work out which config keys have changed.
+	changedConfig := map[string]string{}
+	for k, v := range bucket.Config {
+		if curBucket.Config[k] != v {
+			changedConfig[k] = v
+		}
+	}
+
+	for k := range curBucket.Config {
+		_, found := bucket.Config[k]
+		if !found {
+			changedConfig[k] = ""
+		}
+	}
+
+	if len(changedConfig) > 0 {
+		if b.driver.Info().Remote {
+			err = b.driver.UpdateBucket(vol, changedConfig)
+			if err != nil {
+				return err
+			}
+		} else {
+			newSize, sizeChanged := changedConfig["size"]
+			if sizeChanged {
+				err = b.driver.SetVolumeQuota(vol, newSize, false, op)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return tx.UpdateStoragePoolBucket(b.id, curBucket.ID, &bucket)
+	})
+}
+
+// DeleteBucket deletes an existing storage bucket and all of its contents.
+func (b *lxdBackend) DeleteBucket(projectName string, bucketName string, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "bucketName": bucketName})
+	l.Debug("DeleteBucket started")
+	defer l.Debug("DeleteBucket finished")
+
+	err := b.isStatusReady()
+	if err != nil {
+		return err
+	}
+
+	bucket, err := b.loadBucket(projectName, bucketName)
+	if err != nil {
+		return err
+	}
+
+	vol := b.bucketVolume(projectName, bucketName, bucket.Config)
+
+	if b.driver.Info().Remote {
+		err = b.driver.DeleteBucket(vol, op)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = b.deactivateBucket(projectName, bucketName, vol, op)
+		if err != nil {
+			return err
+		}
+
+		err = b.driver.DeleteVolume(vol, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return tx.DeleteStoragePoolBucket(b.id, bucket.ID)
+	})
+}
+
+// CreateBucketKey creates a new key for an existing storage bucket.
+// If the access or secret keys aren't provided they are generated.
+func (b *lxdBackend) CreateBucketKey(projectName string, bucketName string, key api.StorageBucketKeysPost, op *operations.Operation) (*api.StorageBucketKey, error) {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "bucketName": bucketName, "keyName": key.Name, "role": key.Role})
+	l.Debug("CreateBucketKey started")
+	defer l.Debug("CreateBucketKey finished")
+
+	err := b.isStatusReady()
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Role == "" {
+		key.Role = s3.RoleReadOnly
+	}
+
+	creds := s3.Credentials{AccessKey: key.AccessKey, SecretKey: key.SecretKey}
+	err = b.driver.ValidateBucketKey(key.Name, creds, key.Role)
+	if err != nil {
+		return nil, api.StatusErrorf(http.StatusBadRequest, err.Error())
+	}
+
+	bucket, err := b.loadBucket(projectName, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	vol := b.bucketVolume(projectName, bucketName, bucket.Config)
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	var newCreds *s3.Credentials
+	if b.driver.Info().Remote {
+		newCreds, err = b.driver.CreateBucketKey(vol, key.Name, creds, key.Role, op)
+		if err != nil {
+			return nil, err
+		}
+
+		revert.Add(func() { _ = b.driver.DeleteBucketKey(vol, key.Name, op) })
+	} else {
+		minioProc, err := b.ActivateBucket(projectName, bucketName, op)
+		if err != nil {
+			return nil, err
+		}
+
+		newCreds, err = s3.FillCredentials(creds)
+		if err != nil {
+			return nil, err
+		}
+
+		err = minioProc.CreateServiceAccount(context.TODO(), *newCreds, key.Role)
+		if err != nil {
+			return nil, err
+		}
+
+		revert.Add(func() { _ = minioProc.DeleteServiceAccount(context.TODO(), newCreds.AccessKey) })
+	}
+
+	key.AccessKey = newCreds.AccessKey
+	key.SecretKey = newCreds.SecretKey
+
+	err = b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		_, err = tx.CreateStoragePoolBucketKey(bucket.ID, key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	revert.Success()
+
+	return &api.StorageBucketKey{
+		StorageBucketKeyPut: key.StorageBucketKeyPut,
+		Name:                key.Name,
+	}, nil
+}
+
+// UpdateBucketKey updates an existing key of a storage bucket.
+func (b *lxdBackend) UpdateBucketKey(projectName string, bucketName string, keyName string, key api.StorageBucketKeyPut, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "bucketName": bucketName, "keyName": keyName, "role": key.Role})
+	l.Debug("UpdateBucketKey started")
+	defer l.Debug("UpdateBucketKey finished")
+
+	err := b.isStatusReady()
+	if err != nil {
+		return err
+	}
+
+	if key.Role == "" {
+		key.Role = s3.RoleReadOnly
+	}
+
+	creds := s3.Credentials{AccessKey: key.AccessKey, SecretKey: key.SecretKey}
+	err = b.driver.ValidateBucketKey(keyName, creds, key.Role)
+	if err != nil {
+		return api.StatusErrorf(http.StatusBadRequest, err.Error())
+	}
+
+	bucket, err := b.loadBucket(projectName, bucketName)
+	if err != nil {
+		return err
+	}
+
+	var curKey *db.StorageBucketKey
+	err = b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		curKey, err = tx.GetStoragePoolBucketKey(bucket.ID, keyName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	vol := b.bucketVolume(projectName, bucketName, bucket.Config)
+
+	var newCreds *s3.Credentials
+	if b.driver.Info().Remote {
+		newCreds, err = b.driver.UpdateBucketKey(vol, keyName, creds, key.Role, op)
+		if err != nil {
+			return err
+		}
+	} else {
+		minioProc, err := b.ActivateBucket(projectName, bucketName, op)
+		if err != nil {
+			return err
+		}
+
+		newCreds, err = s3.FillCredentials(creds)
+		if err != nil {
+			return err
+		}
+
+		// Service accounts are identified by their access key, so recreate it if the access key changed.
+		if newCreds.AccessKey != curKey.AccessKey {
+			err = minioProc.DeleteServiceAccount(context.TODO(), curKey.AccessKey)
+			if err != nil {
+				return err
+			}
+
+			err = minioProc.CreateServiceAccount(context.TODO(), *newCreds, key.Role)
+			if err != nil {
+				return err
+			}
+		} else {
+			err = minioProc.UpdateServiceAccount(context.TODO(), *newCreds, key.Role)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	key.AccessKey = newCreds.AccessKey
+	key.SecretKey = newCreds.SecretKey
+
+	return b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return tx.UpdateStoragePoolBucketKey(bucket.ID, curKey.ID, &key)
+	})
+}
+
+// DeleteBucketKey deletes an existing key of a storage bucket.
+func (b *lxdBackend) DeleteBucketKey(projectName string, bucketName string, keyName string, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "bucketName": bucketName, "keyName": keyName})
+	l.Debug("DeleteBucketKey started")
+	defer l.Debug("DeleteBucketKey finished")
+
+	err := b.isStatusReady()
+	if err != nil {
+		return err
+	}
+
+	bucket, err := b.loadBucket(projectName, bucketName)
+	if err != nil {
+		return err
+	}
+
+	var key *db.StorageBucketKey
+	err = b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		key, err = tx.GetStoragePoolBucketKey(bucket.ID, keyName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	vol := b.bucketVolume(projectName, bucketName, bucket.Config)
+
+	if b.driver.Info().Remote {
+		err = b.driver.DeleteBucketKey(vol, keyName, op)
+		if err != nil {
+			return err
+		}
+	} else {
+		minioProc, err := b.ActivateBucket(projectName, bucketName, op)
+		if err != nil {
+			return err
+		}
+
+		err = minioProc.DeleteServiceAccount(context.TODO(), key.AccessKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return tx.DeleteStoragePoolBucketKey(bucket.ID, key.ID)
+	})
+}
@@ -0,0 +1,336 @@
+//go:build linux && cgo && !agent
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/events"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/storage/drivers"
+	"github.com/lxc/lxd/lxd/sys"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/units"
+)
+
+// checkTestDriver is a mock driver that keeps track of the volumes that exist on the storage pool.
+type checkTestDriver struct {
+	drivers.Driver
+
+	dir      string
+	volumes  map[string]drivers.Volume // Volumes returned by ListVolumes.
+	unlisted map[string]drivers.Volume // Volumes that exist but were created after ListVolumes was called.
+	quotas   map[string]int64          // Size limits of the filesystem volumes.
+}
+
+func checkTestVolumeKey(vol drivers.Volume) string {
+	return fmt.Sprintf("%s/%s", vol.Type(), vol.Name())
+}
+
+func (d *checkTestDriver) addVolume(poolName string, volType drivers.VolumeType, contentType drivers.ContentType, volName string, size int64) error {
+	vol := drivers.NewVolume(d, poolName, volType, contentType, volName, nil, nil)
+	d.volumes[checkTestVolumeKey(vol)] = vol
+
+	if contentType == drivers.ContentTypeBlock {
+		err := os.WriteFile(d.diskPath(vol), nil, 0600)
+		if err != nil {
+			return err
+		}
+
+		return os.Truncate(d.diskPath(vol), size)
+	}
+
+	d.quotas[vol.Name()] = size
+	return nil
+}
+
+func (d *checkTestDriver) diskPath(vol drivers.Volume) string {
+	return filepath.Join(d.dir, vol.Name())
+}
+
+func (d *checkTestDriver) ListVolumes() ([]drivers.Volume, error) {
+	vols := make([]drivers.Volume, 0, len(d.volumes))
+	for _, vol := range d.volumes {
+		vols = append(vols, vol)
+	}
+
+	return vols, nil
+}
+
+func (d *checkTestDriver) HasVolume(vol drivers.Volume) bool {
+	key := checkTestVolumeKey(vol)
+	_, found := d.volumes[key]
+	_, unlisted := d.unlisted[key]
+
+	return found || unlisted
+}
+
+func (d *checkTestDriver) DeleteVolume(vol drivers.Volume, op *operations.Operation) error {
+	delete(d.volumes, checkTestVolumeKey(vol))
+	return nil
+}
+
+func (d *checkTestDriver) GetVolumeDiskPath(vol drivers.Volume) (string, error) {
+	return d.diskPath(vol), nil
+}
+
+func (d *checkTestDriver) GetVolumeQuota(vol drivers.Volume) (int64, error) {
+	return d.quotas[vol.Name()], nil
+}
+
+func (d *checkTestDriver) SetVolumeQuota(vol drivers.Volume, size string, allowUnsafeResize bool, op *operations.Operation) error {
+	sizeBytes, err := units.ParseByteSizeString(size)
+	if err != nil {
+		return err
+	}
+
+	if vol.ContentType() == drivers.ContentTypeBlock {
+		return os.Truncate(d.diskPath(vol), sizeBytes)
+	}
+
+	d.quotas[vol.Name()] = sizeBytes
+	return nil
+}
+
+// newCheckTestBackend returns a storage pool backed by a checkTestDriver with its database records.
+func newCheckTestBackend(t *testing.T) (*lxdBackend, *checkTestDriver) {
+	dir := t.TempDir()
+	t.Setenv("LXD_DIR", dir)
+
+	cluster, clusterCleanup := db.NewTestCluster(t)
+	t.Cleanup(clusterCleanup)
+
+	node, nodeCleanup := db.NewTestNode(t)
+	t.Cleanup(nodeCleanup)
+
+	s := &state.State{
+		DB:     &db.DB{Node: node, Cluster: cluster},
+		OS:     &sys.OS{MockMode: true},
+		Events: events.NewServer(false, false, nil),
+	}
+
+	poolID, err := cluster.CreateStoragePool("pool1", "", "mock", nil)
+	require.NoError(t, err)
+
+	mock, err := drivers.Load(s, "mock", "pool1", nil, logger.Log, nil, nil)
+	require.NoError(t, err)
+
+	driver := &checkTestDriver{
+		Driver:   mock,
+		dir:      dir,
+		volumes:  map[string]drivers.Volume{},
+		unlisted: map[string]drivers.Volume{},
+		quotas:   map[string]int64{},
+	}
+
+	err = os.MkdirAll(drivers.GetPoolMountPath("pool1"), 0711)
+	require.NoError(t, err)
+
+	for _, volType := range []drivers.VolumeType{drivers.VolumeTypeCustom, drivers.VolumeTypeContainer} {
+		err = os.MkdirAll(drivers.GetVolumeMountPath("pool1", volType, ""), 0711)
+		require.NoError(t, err)
+	}
+
+	b := &lxdBackend{
+		driver: driver,
+		id:     poolID,
+		db:     api.StoragePool{Name: "pool1", Driver: "mock"},
+		name:   "pool1",
+		state:  s,
+		logger: logger.Log,
+	}
+
+	return b, driver
+}
+
+func TestCheckVolumes(t *testing.T) {
+	gib := int64(1024 * 1024 * 1024)
+
+	type record struct {
+		name        string
+		volType     int
+		contentType int
+		size        string
+	}
+
+	type diskVolume struct {
+		name        string
+		volType     drivers.VolumeType
+		contentType drivers.ContentType
+		size        int64
+	}
+
+	tests := []struct {
+		name     string
+		records  []record
+		volumes  []diskVolume
+		unlisted []diskVolume
+		issues   []api.StoragePoolCheckIssue // Expected issues when not repairing.
+		repaired []bool                      // Whether each issue is expected to be repaired.
+	}{
+		{
+			name:    "Consistent",
+			records: []record{{"vol1", db.StoragePoolVolumeTypeCustom, db.StoragePoolVolumeContentTypeFS, "1GiB"}},
+			volumes: []diskVolume{{"default_vol1", drivers.VolumeTypeCustom, drivers.ContentTypeFS, gib}},
+		},
+		{
+			name:    "Missing custom volume",
+			records: []record{{"vol1", db.StoragePoolVolumeTypeCustom, db.StoragePoolVolumeContentTypeFS, ""}},
+			issues: []api.StoragePoolCheckIssue{
+				{Type: api.StoragePoolCheckIssueMissing, Project: "default", VolumeType: "custom", Volume: "vol1"},
+			},
+			repaired: []bool{true},
+		},
+		{
+			name:     "Custom volume created after listing",
+			records:  []record{{"vol1", db.StoragePoolVolumeTypeCustom, db.StoragePoolVolumeContentTypeFS, ""}},
+			unlisted: []diskVolume{{"default_vol1", drivers.VolumeTypeCustom, drivers.ContentTypeFS, 0}},
+		},
+		{
+			name:    "Missing instance volume",
+			records: []record{{"c1", db.StoragePoolVolumeTypeContainer, db.StoragePoolVolumeContentTypeFS, ""}},
+			issues: []api.StoragePoolCheckIssue{
+				{Type: api.StoragePoolCheckIssueMissing, Project: "default", VolumeType: "container", Volume: "c1"},
+			},
+			repaired: []bool{false},
+		},
+		{
+			name:    "Orphaned custom volume",
+			volumes: []diskVolume{{"default_vol1", drivers.VolumeTypeCustom, drivers.ContentTypeFS, 0}},
+			issues: []api.StoragePoolCheckIssue{
+				{Type: api.StoragePoolCheckIssueOrphan, Project: "default", VolumeType: "custom", Volume: "vol1"},
+			},
+			repaired: []bool{true},
+		},
+		{
+			name:    "Orphaned instance volume",
+			volumes: []diskVolume{{"default_c1", drivers.VolumeTypeContainer, drivers.ContentTypeFS, 0}},
+			issues: []api.StoragePoolCheckIssue{
+				{Type: api.StoragePoolCheckIssueOrphan, Project: "default", VolumeType: "container", Volume: "c1"},
+			},
+			repaired: []bool{false},
+		},
+		{
+			name:    "Wrong content type",
+			records: []record{{"vol1", db.StoragePoolVolumeTypeCustom, db.StoragePoolVolumeContentTypeFS, ""}},
+			volumes: []diskVolume{{"default_vol1", drivers.VolumeTypeCustom, drivers.ContentTypeBlock, 10 * gib}},
+			issues: []api.StoragePoolCheckIssue{
+				{Type: api.StoragePoolCheckIssueContentType, Project: "default", VolumeType: "custom", Volume: "vol1"},
+			},
+			repaired: []bool{true},
+		},
+		{
+			name:    "Wrong filesystem quota",
+			records: []record{{"vol1", db.StoragePoolVolumeTypeCustom, db.StoragePoolVolumeContentTypeFS, "1GiB"}},
+			volumes: []diskVolume{{"default_vol1", drivers.VolumeTypeCustom, drivers.ContentTypeFS, 2 * gib}},
+			issues: []api.StoragePoolCheckIssue{
+				{Type: api.StoragePoolCheckIssueQuota, Project: "default", VolumeType: "custom", Volume: "vol1"},
+			},
+			repaired: []bool{true},
+		},
+		{
+			name:    "Missing filesystem quota",
+			records: []record{{"vol1", db.StoragePoolVolumeTypeCustom, db.StoragePoolVolumeContentTypeFS, "1GiB"}},
+			volumes: []diskVolume{{"default_vol1", drivers.VolumeTypeCustom, drivers.ContentTypeFS, 0}},
+			issues: []api.StoragePoolCheckIssue{
+				{Type: api.StoragePoolCheckIssueQuota, Project: "default", VolumeType: "custom", Volume: "vol1"},
+			},
+			repaired: []bool{true},
+		},
+		{
+			name:    "Filesystem quota within tolerance",
+			records: []record{{"vol1", db.StoragePoolVolumeTypeCustom, db.StoragePoolVolumeContentTypeFS, "1GiB"}},
+			volumes: []diskVolume{{"default_vol1", drivers.VolumeTypeCustom, drivers.ContentTypeFS, gib + 4*1024*1024}},
+		},
+		{
+			name:    "Wrong block volume size",
+			records: []record{{"vol1", db.StoragePoolVolumeTypeCustom, db.StoragePoolVolumeContentTypeBlock, "1GiB"}},
+			volumes: []diskVolume{{"default_vol1", drivers.VolumeTypeCustom, drivers.ContentTypeBlock, 0}},
+			issues: []api.StoragePoolCheckIssue{
+				{Type: api.StoragePoolCheckIssueQuota, Project: "default", VolumeType: "custom", Volume: "vol1"},
+			},
+			repaired: []bool{true},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, repair := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s (repair=%v)", tt.name, repair), func(t *testing.T) {
+				b, driver := newCheckTestBackend(t)
+
+				for _, r := range tt.records {
+					config := map[string]string{}
+					if r.size != "" {
+						config["size"] = r.size
+					}
+
+					_, err := b.state.DB.Cluster.CreateStoragePoolVolume("default", r.name, "", r.volType, b.ID(), config, r.contentType)
+					require.NoError(t, err)
+				}
+
+				for _, v := range tt.volumes {
+					require.NoError(t, driver.addVolume(b.name, v.volType, v.contentType, v.name, v.size))
+				}
+
+				for _, v := range tt.unlisted {
+					vol := drivers.NewVolume(driver, b.name, v.volType, v.contentType, v.name, nil, nil)
+					driver.unlisted[checkTestVolumeKey(vol)] = vol
+				}
+
+				issues, err := b.CheckVolumes(repair, nil)
+				require.NoError(t, err)
+				require.Len(t, issues, len(tt.issues))
+
+				for i, issue := range issues {
+					assert.Equal(t, tt.issues[i].Type, issue.Type)
+					assert.Equal(t, tt.issues[i].Project, issue.Project)
+					assert.Equal(t, tt.issues[i].VolumeType, issue.VolumeType)
+					assert.Equal(t, tt.issues[i].Volume, issue.Volume)
+					assert.NotEmpty(t, issue.Description)
+
+					if !repair {
+						assert.False(t, issue.Repaired)
+						assert.Empty(t, issue.RepairError)
+						continue
+					}
+
+					assert.Equal(t, tt.repaired[i], issue.Repaired)
+					if !tt.repaired[i] {
+						assert.NotEmpty(t, issue.RepairError)
+					}
+				}
+
+				if !repair {
+					return
+				}
+
+				// Check that the repaired issues are no longer found.
+				issues, err = b.CheckVolumes(false, nil)
+				require.NoError(t, err)
+
+				var remaining []string
+				for i, repaired := range tt.repaired {
+					if !repaired {
+						remaining = append(remaining, tt.issues[i].Type)
+					}
+				}
+
+				var found []string
+				for _, issue := range issues {
+					found = append(found, issue.Type)
+				}
+
+				assert.Equal(t, remaining, found)
+			})
+		}
+	}
+}
@@ -0,0 +1,609 @@
+package chunkstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// Entry types stored in a manifest.
+const (
+	EntryTypeDir     = "dir"
+	EntryTypeFile    = "file"
+	EntryTypeSymlink = "symlink"
+	EntryTypeDevice  = "device"
+)
+
+// Entry represents a single file system entry of an unpacked image.
+type Entry struct {
+	Path    string            `json:"path"`
+	Type    string            `json:"type"`
+	Mode    uint32            `json:"mode"` // Full st_mode, including the file type bits.
+	UID     int               `json:"uid"`
+	GID     int               `json:"gid"`
+	ModTime int64             `json:"mtime"` // Unix time in nanoseconds.
+	Size    int64             `json:"size,omitempty"`
+	Hash    string            `json:"hash,omitempty"`   // SHA256 of the content of regular files.
+	Target  string            `json:"target,omitempty"` // Target of symlinks.
+	Rdev    uint64            `json:"rdev,omitempty"`   // Device number of device nodes and fifos.
+	Xattrs  map[string][]byte `json:"xattrs,omitempty"`
+}
+
+// Manifest lists the entries of an unpacked image in walk order (parents before children).
+type Manifest struct {
+	Fingerprint string  `json:"fingerprint"`
+	Entries     []Entry `json:"entries"`
+}
+
+// PopulateMode indicates how the files of an image were placed into a destination.
+type PopulateMode string
+
+// Populate modes, from cheapest to most expensive.
+const (
+	PopulateModeReflink  PopulateMode = "reflink"
+	PopulateModeHardlink PopulateMode = "hardlink"
+	PopulateModeCopy     PopulateMode = "copy"
+)
+
+// storeLock prevents garbage collection from removing objects while images are imported or populated.
+var storeLock sync.RWMutex
+
+// Store is a content-addressed store of the files of unpacked images.
+// Each file content is stored once as an object named after its SHA256 hash, and each image is described by a
+// manifest listing its entries and referencing the objects holding the content of its regular files.
+type Store struct {
+	path string
+}
+
+// New returns a Store rooted at path.
+func New(path string) *Store {
+	return &Store{path: path}
+}
+
+func (s *Store) manifestPath(fingerprint string) string {
+	return filepath.Join(s.path, "manifests", fingerprint+".json")
+}
+
+func (s *Store) objectPath(hash string) string {
+	return filepath.Join(s.path, "objects", hash[:2], hash)
+}
+
+// HasImage returns whether the store holds the image with the given fingerprint.
+func (s *Store) HasImage(fingerprint string) bool {
+	return shared.PathExists(s.manifestPath(fingerprint))
+}
+
+// Fingerprints returns the fingerprints of the images held by the store.
+func (s *Store) Fingerprints() ([]string, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(s.path, "manifests"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	fingerprints := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".json") {
+			fingerprints = append(fingerprints, strings.TrimSuffix(entry.Name(), ".json"))
+		}
+	}
+
+	return fingerprints, nil
+}
+
+// Manifest returns the manifest of the image with the given fingerprint.
+func (s *Store) Manifest(fingerprint string) (*Manifest, error) {
+	content, err := ioutil.ReadFile(s.manifestPath(fingerprint))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	err = json.Unmarshal(content, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing manifest of image %q: %w", fingerprint, err)
+	}
+
+	return manifest, nil
+}
+
+// Import adds the unpacked image found at rootPath to the store.
+// The content of regular files that isn't already in the store is reflinked into it when possible, copied
+// otherwise. The manifest is only written once all objects are stored, so a partial import is never used.
+func (s *Store) Import(fingerprint string, rootPath string) error {
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+
+	manifest := Manifest{Fingerprint: fingerprint}
+
+	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath == "." {
+			return nil
+		}
+
+		var stat unix.Stat_t
+		err = unix.Lstat(path, &stat)
+		if err != nil {
+			return fmt.Errorf("Failed getting file information of %q: %w", path, err)
+		}
+
+		entry := Entry{
+			Path:    relPath,
+			Mode:    stat.Mode,
+			UID:     int(stat.Uid),
+			GID:     int(stat.Gid),
+			ModTime: info.ModTime().UnixNano(),
+		}
+
+		switch stat.Mode & unix.S_IFMT {
+		case unix.S_IFDIR:
+			entry.Type = EntryTypeDir
+		case unix.S_IFLNK:
+			entry.Type = EntryTypeSymlink
+			entry.Target, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		case unix.S_IFCHR, unix.S_IFBLK, unix.S_IFIFO:
+			entry.Type = EntryTypeDevice
+			entry.Rdev = uint64(stat.Rdev)
+		case unix.S_IFREG:
+			entry.Type = EntryTypeFile
+			entry.Size = info.Size()
+			entry.Hash, err = s.storeObject(path, &stat)
+			if err != nil {
+				return fmt.Errorf("Failed storing %q: %w", path, err)
+			}
+
+		default:
+			return fmt.Errorf("Unsupported file type of %q", path)
+		}
+
+		// Symlinks can't carry xattrs that we could restore portably.
+		if entry.Type != EntryTypeSymlink {
+			xattrs, err := shared.GetAllXattr(path)
+			if err != nil {
+				return fmt.Errorf("Failed getting xattrs of %q: %w", path, err)
+			}
+
+			if len(xattrs) > 0 {
+				entry.Xattrs = make(map[string][]byte, len(xattrs))
+				for k, v := range xattrs {
+					entry.Xattrs[k] = []byte(v)
+				}
+			}
+		}
+
+		manifest.Entries = append(manifest.Entries, entry)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.manifestPath(fingerprint), content)
+}
+
+// storeObject stores the content of the regular file at path and returns its hash.
+// New objects keep the ownership and mode of the file, allowing them to be hardlinked to files matching them.
+func (s *Store) storeObject(path string, stat *unix.Stat_t) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	hasher := sha256.New()
+	_, err = io.Copy(hasher, f)
+	if err != nil {
+		return "", err
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	objectPath := s.objectPath(hash)
+	if shared.PathExists(objectPath) {
+		return hash, nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(objectPath), 0700)
+	if err != nil {
+		return "", err
+	}
+
+	suffix, err := shared.RandomCryptoString()
+	if err != nil {
+		return "", err
+	}
+
+	tmpPath := objectPath + "." + suffix + ".tmp"
+	_, err = cloneFile(path, tmpPath)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return "", err
+	}
+
+	// The setuid and setgid bits are cleared by chown so chmod has to come after it.
+	err = os.Lchown(tmpPath, int(stat.Uid), int(stat.Gid))
+	if err == nil {
+		err = unix.Chmod(tmpPath, stat.Mode&07777)
+	}
+
+	if err == nil {
+		err = os.Rename(tmpPath, objectPath)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return "", err
+	}
+
+	return hash, nil
+}
+
+// Populate recreates the image with the given fingerprint at destPath, which must be an existing directory.
+// Regular files are reflinked from the store when the file systems allow it. Otherwise they are hardlinked when
+// allowHardlink is true and the stored object has the same ownership, mode and no xattrs, and copied as a last
+// resort. The returned mode is the most expensive mode that was needed for any of the files.
+func (s *Store) Populate(fingerprint string, destPath string, allowHardlink bool) (PopulateMode, error) {
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+
+	manifest, err := s.Manifest(fingerprint)
+	if err != nil {
+		return "", err
+	}
+
+	mode := PopulateModeReflink
+	useMode := func(m PopulateMode) {
+		if m == PopulateModeCopy || (m == PopulateModeHardlink && mode == PopulateModeReflink) {
+			mode = m
+		}
+	}
+
+	var dirs []Entry
+	for _, entry := range manifest.Entries {
+		path, err := safeJoin(destPath, entry.Path)
+		if err != nil {
+			return "", err
+		}
+
+		switch entry.Type {
+		case EntryTypeDir:
+			err = os.Mkdir(path, 0700)
+			if err != nil && !os.IsExist(err) {
+				return "", err
+			}
+
+			// Permissions and times are applied once the directory content has been created.
+			dirs = append(dirs, entry)
+		case EntryTypeSymlink:
+			err = os.Symlink(entry.Target, path)
+		case EntryTypeDevice:
+			err = unix.Mknod(path, entry.Mode, int(entry.Rdev))
+		case EntryTypeFile:
+			var fileMode PopulateMode
+			fileMode, err = s.populateFile(entry, path, allowHardlink)
+			if err == nil {
+				useMode(fileMode)
+				if fileMode == PopulateModeHardlink {
+					continue // The object already carries the metadata.
+				}
+			}
+
+		default:
+			err = fmt.Errorf("Unsupported entry type %q", entry.Type)
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("Failed creating %q: %w", path, err)
+		}
+
+		if entry.Type != EntryTypeDir {
+			err = applyMetadata(path, entry)
+			if err != nil {
+				return "", fmt.Errorf("Failed applying metadata to %q: %w", path, err)
+			}
+		}
+	}
+
+	// Apply the directories metadata deepest first, so that the times aren't changed by later creations.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		path := filepath.Join(destPath, dirs[i].Path)
+		err = applyMetadata(path, dirs[i])
+		if err != nil {
+			return "", fmt.Errorf("Failed applying metadata to %q: %w", path, err)
+		}
+	}
+
+	return mode, nil
+}
+
+// populateFile creates the regular file described by entry at path from its stored object.
+func (s *Store) populateFile(entry Entry, path string, allowHardlink bool) (PopulateMode, error) {
+	objectPath := s.objectPath(entry.Hash)
+
+	if allowHardlink && len(entry.Xattrs) == 0 {
+		var stat unix.Stat_t
+		err := unix.Lstat(objectPath, &stat)
+		if err != nil {
+			return "", err
+		}
+
+		if int(stat.Uid) == entry.UID && int(stat.Gid) == entry.GID && stat.Mode&07777 == entry.Mode&07777 {
+			err = os.Link(objectPath, path)
+			if err == nil {
+				return PopulateModeHardlink, nil
+			}
+		}
+	}
+
+	return cloneFile(objectPath, path)
+}
+
+// CanShare returns whether the files of destPath can share their content with the store, either through a reflink
+// or, when allowHardlink is true, through a hardlink. Populating a destination that can't share anything with the
+// store would copy all files, which is no cheaper than unpacking the image again.
+func (s *Store) CanShare(destPath string, allowHardlink bool) bool {
+	err := os.MkdirAll(s.path, 0700)
+	if err != nil {
+		return false
+	}
+
+	probe, err := ioutil.TempFile(s.path, ".probe")
+	if err != nil {
+		return false
+	}
+
+	defer func() { _ = os.Remove(probe.Name()) }()
+	defer func() { _ = probe.Close() }()
+
+	_, err = probe.Write([]byte("probe"))
+	if err != nil {
+		return false
+	}
+
+	target := filepath.Join(destPath, filepath.Base(probe.Name()))
+	defer func() { _ = os.Remove(target) }()
+
+	if allowHardlink && os.Link(probe.Name(), target) == nil {
+		return true
+	}
+
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return false
+	}
+
+	defer func() { _ = dst.Close() }()
+
+	return unix.IoctlFileClone(int(dst.Fd()), int(probe.Fd())) == nil
+}
+
+// GC removes the images that aren't returned by keep from the store, as well as the objects that are no longer
+// referenced by any image. It returns the number of removed objects.
+// The keep function is called with the fingerprints of the images held by the store while no images are imported
+// or populated, so that an image added concurrently can't be removed before keep had the chance to retain it.
+func (s *Store) GC(keep func(fingerprints []string) ([]string, error)) (int, error) {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+
+	fingerprints, err := s.Fingerprints()
+	if err != nil {
+		return 0, err
+	}
+
+	// Nothing to do if the store is empty or was never used.
+	if len(fingerprints) == 0 {
+		return 0, nil
+	}
+
+	kept, err := keep(fingerprints)
+	if err != nil {
+		return 0, err
+	}
+
+	referenced := map[string]struct{}{}
+	for _, fingerprint := range fingerprints {
+		if !shared.StringInSlice(fingerprint, kept) {
+			err = os.Remove(s.manifestPath(fingerprint))
+			if err != nil && !os.IsNotExist(err) {
+				return 0, err
+			}
+
+			continue
+		}
+
+		manifest, err := s.Manifest(fingerprint)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, entry := range manifest.Entries {
+			if entry.Hash != "" {
+				referenced[entry.Hash] = struct{}{}
+			}
+		}
+	}
+
+	removed := 0
+	objectsPath := filepath.Join(s.path, "objects")
+	err = filepath.Walk(objectsPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == objectsPath {
+				return nil
+			}
+
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		// Leftovers of interrupted imports are removed too.
+		_, ok := referenced[info.Name()]
+		if ok {
+			return nil
+		}
+
+		err = os.Remove(path)
+		if err != nil {
+			return err
+		}
+
+		removed++
+
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	return removed, nil
+}
+
+// cloneFile creates dest with the content of source, sharing the data blocks through a reflink if the file system
+// supports it and copying the content otherwise.
+func cloneFile(source string, dest string) (PopulateMode, error) {
+	src, err := os.Open(source)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = dst.Close() }()
+
+	err = unix.IoctlFileClone(int(dst.Fd()), int(src.Fd()))
+	if err == nil {
+		return PopulateModeReflink, dst.Close()
+	}
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		return "", err
+	}
+
+	return PopulateModeCopy, dst.Close()
+}
+
+// applyMetadata sets the ownership, permissions, xattrs and modification time of path from entry.
+func applyMetadata(path string, entry Entry) error {
+	err := os.Lchown(path, entry.UID, entry.GID)
+	if err != nil {
+		return err
+	}
+
+	if entry.Type == EntryTypeSymlink {
+		mtime := unix.NsecToTimespec(entry.ModTime)
+		return unix.UtimesNanoAt(unix.AT_FDCWD, path, []unix.Timespec{mtime, mtime}, unix.AT_SYMLINK_NOFOLLOW)
+	}
+
+	// The setuid and setgid bits are cleared by chown so chmod has to come after it.
+	err = unix.Chmod(path, entry.Mode&07777)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range entry.Xattrs {
+		err = unix.Lsetxattr(path, k, v, 0)
+		if err != nil && !errors.Is(err, unix.EOPNOTSUPP) {
+			return fmt.Errorf("Failed setting xattr %q: %w", k, err)
+		}
+	}
+
+	mtime := time.Unix(0, entry.ModTime)
+
+	return os.Chtimes(path, mtime, mtime)
+}
+
+// safeJoin joins relPath to rootPath, refusing paths that escape rootPath or whose parent isn't a directory.
+func safeJoin(rootPath string, relPath string) (string, error) {
+	path := filepath.Join(rootPath, relPath)
+	if !strings.HasPrefix(path, filepath.Clean(rootPath)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("Invalid path %q", relPath)
+	}
+
+	parent, err := os.Lstat(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+
+	if !parent.IsDir() {
+		return "", fmt.Errorf("Parent of %q isn't a directory", relPath)
+	}
+
+	return path, nil
+}
+
+// writeFileAtomic writes content to path through a temporary file, so readers never see a partial file.
+func writeFileAtomic(path string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := f.Name()
+	_, err = f.Write(content)
+	if err == nil {
+		err = f.Close()
+	} else {
+		_ = f.Close()
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package chunkstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test importing an image into the store and populating a new tree from it.
+func TestStoreImportPopulate(t *testing.T) {
+	tmpDir := t.TempDir()
+	rootPath := filepath.Join(tmpDir, "image")
+
+	// Create a small image tree with two files sharing the same content.
+	err := os.MkdirAll(filepath.Join(rootPath, "rootfs", "etc"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"metadata.yaml":       "architecture: x86_64\n",
+		"rootfs/etc/hostname": "image\n",
+		"rootfs/etc/hosts":    "image\n",
+	}
+
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(rootPath, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = os.Symlink("hostname", filepath.Join(rootPath, "rootfs", "etc", "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := New(filepath.Join(tmpDir, "store"))
+	if store.HasImage("abc") {
+		t.Fatal("Unexpected image in empty store")
+	}
+
+	err = store.Import("abc", rootPath)
+	if err != nil {
+		t.Fatalf("Unexpected import error: %v", err)
+	}
+
+	if !store.HasImage("abc") {
+		t.Fatal("Missing image after import")
+	}
+
+	for _, allowHardlink := range []bool{false, true} {
+		destPath, err := ioutil.TempDir(tmpDir, "dest")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = store.Populate("abc", destPath, allowHardlink)
+		if err != nil {
+			t.Fatalf("Unexpected populate error: %v", err)
+		}
+
+		for name, content := range files {
+			data, err := ioutil.ReadFile(filepath.Join(destPath, name))
+			if err != nil {
+				t.Fatalf("Unexpected read error: %v", err)
+			}
+
+			if string(data) != content {
+				t.Errorf("Unexpected content of %q: %q, expected: %q", name, data, content)
+			}
+		}
+
+		target, err := os.Readlink(filepath.Join(destPath, "rootfs", "etc", "link"))
+		if err != nil {
+			t.Fatalf("Unexpected readlink error: %v", err)
+		}
+
+		if target != "hostname" {
+			t.Errorf("Unexpected symlink target: %q, expected: %q", target, "hostname")
+		}
+	}
+
+	// Populating must not be able to change the stored objects when files aren't hardlinked.
+	destPath := filepath.Join(tmpDir, "copy")
+	err = os.Mkdir(destPath, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Populate("abc", destPath, false)
+	if err != nil {
+		t.Fatalf("Unexpected populate error: %v", err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(destPath, "metadata.yaml"), []byte("changed\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := store.Manifest("abc")
+	if err != nil {
+		t.Fatalf("Unexpected manifest error: %v", err)
+	}
+
+	for _, entry := range manifest.Entries {
+		if entry.Path != "metadata.yaml" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(store.objectPath(entry.Hash))
+		if err != nil {
+			t.Fatalf("Unexpected read error: %v", err)
+		}
+
+		if string(data) != files["metadata.yaml"] {
+			t.Errorf("Stored object was modified: %q", data)
+		}
+	}
+}
+
+// Test that garbage collection only removes the objects of images that aren't kept.
+func TestStoreGC(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := New(filepath.Join(tmpDir, "store"))
+
+	images := map[string]string{
+		"abc": "shared\n",
+		"def": "unique\n",
+	}
+
+	for fingerprint, content := range images {
+		rootPath := filepath.Join(tmpDir, fingerprint)
+		err := os.MkdirAll(rootPath, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(filepath.Join(rootPath, "common"), []byte("shared\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(filepath.Join(rootPath, "file"), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = store.Import(fingerprint, rootPath)
+		if err != nil {
+			t.Fatalf("Unexpected import error: %v", err)
+		}
+	}
+
+	removed, err := store.GC(func(fingerprints []string) ([]string, error) { return []string{"abc"}, nil })
+	if err != nil {
+		t.Fatalf("Unexpected GC error: %v", err)
+	}
+
+	if removed != 1 {
+		t.Errorf("Unexpected number of removed objects: %d, expected: 1", removed)
+	}
+
+	if store.HasImage("def") {
+		t.Error("Image wasn't removed from store")
+	}
+
+	destPath := filepath.Join(tmpDir, "dest")
+	err = os.Mkdir(destPath, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Populate("abc", destPath, false)
+	if err != nil {
+		t.Fatalf("Unexpected populate error after GC: %v", err)
+	}
+
+	removed, err = store.GC(func(fingerprints []string) ([]string, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("Unexpected GC error: %v", err)
+	}
+
+	if removed != 1 {
+		t.Errorf("Unexpected number of removed objects: %d, expected: 1", removed)
+	}
+
+	fingerprints, err := store.Fingerprints()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fingerprints) != 0 {
+		t.Errorf("Unexpected images left in store: %v", fingerprints)
+	}
+}
+
+// Test that hardlinks can be shared with a destination on the same file system and that no probe is left behind.
+func TestStoreCanShare(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := New(filepath.Join(tmpDir, "store"))
+
+	destPath := filepath.Join(tmpDir, "dest")
+	err := os.Mkdir(destPath, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !store.CanShare(destPath, true) {
+		t.Error("Unexpected failure to share hardlinks on the same file system")
+	}
+
+	if store.CanShare(filepath.Join(tmpDir, "missing"), true) {
+		t.Error("Unexpected sharing with a missing destination")
+	}
+
+	for _, path := range []string{destPath, filepath.Join(tmpDir, "store")} {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(entries) != 0 {
+			t.Errorf("Unexpected entries left in %q: %d", path, len(entries))
+		}
+	}
+}
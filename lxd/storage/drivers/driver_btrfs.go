@@ -14,6 +14,7 @@ import (
 	"github.com/lxc/lxd/lxd/storage/filesystem"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/units"
 	"github.com/lxc/lxd/shared/validate"
 	"github.com/lxc/lxd/shared/version"
@@ -23,6 +24,9 @@ var btrfsVersion string
 var btrfsLoaded bool
 var btrfsPropertyForce bool
 
+// btrfsCompressionAlgorithms are the values accepted by the compression volume option.
+var btrfsCompressionAlgorithms = []string{"none", "zlib", "lzo", "zstd"}
+
 type btrfs struct {
 	common
 }
@@ -33,6 +37,7 @@ func (d *btrfs) load() error {
 	d.patches = map[string]func() error{
 		"storage_lvm_skipactivation":       nil,
 		"storage_missing_snapshot_records": nil,
+		"storage_create_buckets_dataset":   nil,
 	}
 
 	// Done if previously loaded.
@@ -91,11 +96,13 @@ func (d *btrfs) Info() Info {
 		OptimizedBackupHeader: true,
 		PreservesInodes:       !d.state.OS.RunningInUserNS,
 		Remote:                d.isRemote(),
-		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
+		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM, VolumeTypeBucket},
 		BlockBacking:          false,
 		RunningCopyFreeze:     false,
 		DirectIO:              true,
 		MountedRoot:           true,
+		Buckets:               true,
+		Deduplication:         true,
 	}
 }
 
@@ -283,6 +290,7 @@ func (d *btrfs) Validate(config map[string]string) error {
 	rules := map[string]func(value string) error{
 		"size":                validate.Optional(validate.IsSize),
 		"btrfs.mount_options": validate.IsAny,
+		"volume.compression":  validate.Optional(validate.IsOneOf(btrfsCompressionAlgorithms...)),
 	}
 
 	return d.validatePool(config, rules)
@@ -406,7 +414,87 @@ func (d *btrfs) Unmount() (bool, error) {
 
 // GetResources returns the pool resource usage information.
 func (d *btrfs) GetResources() (*api.ResourcesStoragePool, error) {
-	return genericVFSGetResources(d)
+	res, err := genericVFSGetResources(d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Report the space saved by extents shared between subvolumes (only available with quotas enabled).
+	saved, err := d.getSharedSpace(GetPoolMountPath(d.name))
+	if err == nil {
+		res.Space.Saved = uint64(saved)
+	} else if err != errBtrfsNoQuota {
+		d.logger.Debug("Failed getting shared space usage", logger.Ctx{"err": err})
+	}
+
+	return res, nil
+}
+
+// Deduplicate shares identical file extents between the given volumes.
+// Returns the amount of data that was found to be identical and is now shared.
+func (d *btrfs) Deduplicate(vols []Volume, op *operations.Operation) (int64, error) {
+	// Group the candidate files by size, only files of identical size are compared.
+	filesBySize := map[int64][]string{}
+	for _, vol := range vols {
+		err := filepath.Walk(vol.MountPath(), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+
+				return err
+			}
+
+			if !info.Mode().IsRegular() || info.Size() < btrfsDedupeMinFileSize {
+				return nil
+			}
+
+			filesBySize[info.Size()] = append(filesBySize[info.Size()], path)
+			return nil
+		})
+		if err != nil {
+			return -1, fmt.Errorf("Failed scanning volume %q: %w", vol.name, err)
+		}
+	}
+
+	var deduplicated int64
+	for _, paths := range filesBySize {
+		if len(paths) < 2 {
+			continue
+		}
+
+		// Group the files by content.
+		filesByHash := map[string][]string{}
+		for _, path := range paths {
+			hash, err := btrfsFileHash(path)
+			if err != nil {
+				d.logger.Debug("Failed hashing file for deduplication", logger.Ctx{"path": path, "err": err})
+				continue
+			}
+
+			filesByHash[hash] = append(filesByHash[hash], path)
+		}
+
+		// Share the extents of the first file of each group with the other files.
+		for _, paths := range filesByHash {
+			for _, path := range paths[1:] {
+				n, err := btrfsDedupeFile(paths[0], path)
+				if err != nil {
+					d.logger.Debug("Failed deduplicating file", logger.Ctx{"source": paths[0], "path": path, "err": err})
+				}
+
+				deduplicated += n
+			}
+		}
+	}
+
+	// Make sure the freed extents are accounted for before usage is reported.
+	_, err := shared.RunCommand("btrfs", "filesystem", "sync", GetPoolMountPath(d.name))
+	if err != nil {
+		return -1, err
+	}
+
+	return deduplicated, nil
 }
 
 // MigrationType returns the type of transfer methods to be used when doing migrations between pools in preference order.
@@ -2,6 +2,7 @@ package drivers
 
 import (
 	"bufio"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -27,6 +28,13 @@ import (
 var errBtrfsNoQuota = fmt.Errorf("Quotas disabled on filesystem")
 var errBtrfsNoQGroup = fmt.Errorf("Unable to find quota group")
 
+// btrfsDedupeMinFileSize is the size below which files aren't considered for deduplication.
+const btrfsDedupeMinFileSize = 64 * 1024
+
+// btrfsDedupeMaxLength is the largest range passed to a single FIDEDUPERANGE call.
+// The kernel silently truncates larger requests to 16MiB.
+const btrfsDedupeMaxLength = 16 * 1024 * 1024
+
 // setReceivedUUID sets the "Received UUID" field on a subvolume with the given path using ioctl.
 func setReceivedUUID(path string, UUID string) error {
 	type btrfsIoctlReceivedSubvolArgs struct {
@@ -357,6 +365,153 @@ func (d *btrfs) setSubvolumeReadonlyProperty(path string, readonly bool) error {
 	return err
 }
 
+// btrfsSetCompressionProperty sets the compression algorithm used for data written to a subvolume or directory.
+// An empty value resets the property so that the filesystem mount options apply.
+func btrfsSetCompressionProperty(path string, compression string) error {
+	_, err := shared.RunCommand("btrfs", "property", "set", path, "compression", compression)
+	if err != nil {
+		return fmt.Errorf("Failed setting compression to %q on %q: %w", compression, path, err)
+	}
+
+	return nil
+}
+
+// getSharedSpace returns the space saved on the filesystem by extents being shared between subvolumes.
+// This is the difference between the sum of the space referenced by each subvolume and the space actually used.
+func (d *btrfs) getSharedSpace(path string) (int64, error) {
+	output, err := shared.RunCommand("btrfs", "qgroup", "show", "--raw", path)
+	if err != nil {
+		return -1, errBtrfsNoQuota
+	}
+
+	var referenced int64
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "0/") {
+			continue
+		}
+
+		val, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		referenced += val
+	}
+
+	output, err = shared.RunCommand("btrfs", "filesystem", "df", "-b", path)
+	if err != nil {
+		return -1, err
+	}
+
+	var used int64
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "Data,") {
+			continue
+		}
+
+		fields := strings.Split(line, "used=")
+		if len(fields) != 2 {
+			continue
+		}
+
+		val, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+		if err != nil {
+			return -1, fmt.Errorf("Failed parsing data usage %q: %w", line, err)
+		}
+
+		used += val
+	}
+
+	if referenced < used {
+		return 0, nil
+	}
+
+	return referenced - used, nil
+}
+
+// btrfsFileHash returns the SHA256 hash of a file's content.
+func btrfsFileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, f)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// btrfsDedupeFile shares the extents of the source file with the identically sized target file using
+// FIDEDUPERANGE. The kernel compares the data of each range and only shares it if it is identical.
+// Returns the number of bytes that are now shared.
+func btrfsDedupeFile(srcPath string, dstPath string) (int64, error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.Open(dstPath)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = dst.Close() }()
+
+	info, err := src.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var deduplicated int64
+	for offset := int64(0); offset < info.Size(); {
+		length := info.Size() - offset
+		if length > btrfsDedupeMaxLength {
+			length = btrfsDedupeMaxLength
+		}
+
+		dedupe := unix.FileDedupeRange{
+			Src_offset: uint64(offset),
+			Src_length: uint64(length),
+			Info: []unix.FileDedupeRangeInfo{{
+				Dest_fd:     int64(dst.Fd()),
+				Dest_offset: uint64(offset),
+			}},
+		}
+
+		err = unix.IoctlFileDedupeRange(int(src.Fd()), &dedupe)
+		if err != nil {
+			return deduplicated, err
+		}
+
+		status := dedupe.Info[0].Status
+		if status < 0 {
+			return deduplicated, unix.Errno(-status)
+		} else if status == unix.FILE_DEDUPE_RANGE_DIFFERS {
+			// The file changed since it was hashed.
+			return deduplicated, nil
+		}
+
+		// The kernel may share less than requested, carry on from where it stopped.
+		if dedupe.Info[0].Bytes_deduped == 0 {
+			break
+		}
+
+		deduplicated += int64(dedupe.Info[0].Bytes_deduped)
+		offset += int64(dedupe.Info[0].Bytes_deduped)
+	}
+
+	return deduplicated, nil
+}
+
 // BTRFSSubVolume is the structure used to store information about a subvolume.
 // Note: This is used by both migration and backup subsystems so do not modify without considering both!
 type BTRFSSubVolume struct {
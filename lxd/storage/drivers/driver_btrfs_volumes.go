@@ -29,6 +29,7 @@ import (
 	"github.com/lxc/lxd/shared/ioprogress"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/units"
+	"github.com/lxc/lxd/shared/validate"
 )
 
 // CreateVolume creates an empty volume and can optionally fill it by executing the supplied filler function.
@@ -49,6 +50,15 @@ func (d *btrfs) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Op
 		_ = os.Remove(volPath)
 	})
 
+	// Set the compression before filling so that the initial data is compressed too.
+	compression := vol.ExpandedConfig("compression")
+	if vol.contentType == ContentTypeFS && compression != "" {
+		err = btrfsSetCompressionProperty(volPath, compression)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create sparse loopback file if volume is block.
 	rootBlockPath := ""
 	if vol.contentType == ContentTypeBlock {
@@ -139,7 +149,7 @@ func (d *btrfs) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Op
 func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) (VolumePostHook, revert.Hook, error) {
 	// Handle the non-optimized tarballs through the generic unpacker.
 	if !*srcBackup.OptimizedStorage {
-		return genericVFSBackupUnpack(d, d.state.OS, vol, srcBackup.Snapshots, srcData, op)
+		return genericVFSBackupUnpack(d, d.state.OS, vol, srcBackup, srcData, op)
 	}
 
 	if d.HasVolume(vol) {
@@ -152,10 +162,12 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 	// Define a revert function that will be used both to revert if an error occurs inside this
 	// function but also return it for use from the calling functions if no error internally.
 	revertHook := func() {
-		for _, snapName := range srcBackup.Snapshots {
-			fullSnapshotName := GetSnapshotVolumeName(vol.name, snapName)
-			snapVol := NewVolume(d, d.name, vol.volType, vol.contentType, fullSnapshotName, vol.config, vol.poolConfig)
-			_ = d.DeleteVolumeSnapshot(snapVol, op)
+		for _, entry := range srcBackup.ChainEntries(srcData) {
+			for _, snapName := range entry.Info.Snapshots {
+				fullSnapshotName := GetSnapshotVolumeName(vol.name, snapName)
+				snapVol := NewVolume(d, d.name, vol.volType, vol.contentType, fullSnapshotName, vol.config, vol.poolConfig)
+				_ = d.DeleteVolumeSnapshot(snapVol, op)
+			}
 		}
 
 		// And lastly the main volume.
@@ -164,45 +176,6 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 	// Only execute the revert function if we have had an error internally.
 	revert.Add(revertHook)
 
-	// Find the compression algorithm used for backup source data.
-	_, err := srcData.Seek(0, 0)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	_, _, unpacker, err := shared.DetectCompressionFile(srcData)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// Load optimized backup header file if specified.
-	var optimizedHeader *BTRFSMetaDataHeader
-	if *srcBackup.OptimizedHeader {
-		optimizedHeader, err = d.loadOptimizedBackupHeader(srcData, GetVolumeMountPath(d.name, vol.volType, ""))
-		if err != nil {
-			return nil, nil, err
-		}
-	}
-
-	// Populate optimized header with pseudo data for unified handling when backup doesn't contain the
-	// optimized header file. This approach can only be used to restore root subvolumes (not sub-subvolumes).
-	if optimizedHeader == nil {
-		optimizedHeader = &BTRFSMetaDataHeader{}
-		for _, snapName := range srcBackup.Snapshots {
-			optimizedHeader.Subvolumes = append(optimizedHeader.Subvolumes, BTRFSSubVolume{
-				Snapshot: snapName,
-				Path:     string(filepath.Separator),
-				Readonly: true, // Snapshots are made readonly.
-			})
-		}
-
-		optimizedHeader.Subvolumes = append(optimizedHeader.Subvolumes, BTRFSSubVolume{
-			Snapshot: "",
-			Path:     string(filepath.Separator),
-			Readonly: false,
-		})
-	}
-
 	// Create a temporary directory to unpack the backup into.
 	tmpUnpackDir, err := ioutil.TempDir(GetVolumeMountPath(d.name, vol.volType, ""), "backup.")
 	if err != nil {
@@ -252,9 +225,10 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 	}
 
 	var copyOps []btrfsCopyOp
+	var restoredSubvolumes []BTRFSSubVolume
 
 	// unpackVolume unpacks all subvolumes in a LXD volume from a backup tarball file.
-	unpackVolume := func(v Volume, srcFilePrefix string) error {
+	unpackVolume := func(v Volume, srcData io.ReadSeeker, unpacker []string, optimizedHeader *BTRFSMetaDataHeader, srcFilePrefix string) error {
 		_, snapName, _ := shared.InstanceGetParentAndSnapshotName(v.name)
 
 		for _, subVol := range optimizedHeader.Subvolumes {
@@ -292,55 +266,109 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 				src:  unpackedSubVolPath,
 				dest: subVolTargetPath,
 			})
+
+			restoredSubvolumes = append(restoredSubvolumes, subVol)
 		}
 
 		return nil
 	}
 
-	if len(srcBackup.Snapshots) > 0 {
-		// Create new snapshots directory.
-		err := createParentSnapshotDirIfMissing(d.name, vol.volType, vol.name)
+	// Restore each backup in the chain (oldest first). The subvolumes of incremental backups are received
+	// relative to the ones received from the previous backups, so all subvolumes are received before any of
+	// them are moved into place. The volume itself is only restored from the last backup in the chain.
+	var restoredSnapshots []string
+	entries := srcBackup.ChainEntries(srcData)
+	for i, entry := range entries {
+		// Find the compression algorithm used for backup source data.
+		_, err := entry.Data.Seek(0, 0)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		// Restore backup snapshots from oldest to newest.
-		for _, snapName := range srcBackup.Snapshots {
-			snapVol, _ := vol.NewSnapshot(snapName)
-			snapDir := "snapshots"
-			srcFilePrefix := snapName
-			if vol.volType == VolumeTypeVM {
-				snapDir = "virtual-machine-snapshots"
-				if vol.contentType == ContentTypeFS {
-					srcFilePrefix = fmt.Sprintf("%s-config", snapName)
-				}
-			} else if vol.volType == VolumeTypeCustom {
-				snapDir = "volume-snapshots"
+		_, _, unpacker, err := shared.DetectCompressionFile(entry.Data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Load optimized backup header file if specified.
+		var optimizedHeader *BTRFSMetaDataHeader
+		if *entry.Info.OptimizedHeader {
+			optimizedHeader, err = d.loadOptimizedBackupHeader(entry.Data, GetVolumeMountPath(d.name, vol.volType, ""))
+			if err != nil {
+				return nil, nil, err
 			}
+		}
 
-			srcFilePrefix = filepath.Join(snapDir, srcFilePrefix)
-			err = unpackVolume(snapVol, srcFilePrefix)
+		// Populate optimized header with pseudo data for unified handling when backup doesn't contain the
+		// optimized header file. This approach can only be used to restore root subvolumes (not sub-subvolumes).
+		if optimizedHeader == nil {
+			optimizedHeader = &BTRFSMetaDataHeader{}
+			for _, snapName := range entry.Info.Snapshots {
+				optimizedHeader.Subvolumes = append(optimizedHeader.Subvolumes, BTRFSSubVolume{
+					Snapshot: snapName,
+					Path:     string(filepath.Separator),
+					Readonly: true, // Snapshots are made readonly.
+				})
+			}
+
+			optimizedHeader.Subvolumes = append(optimizedHeader.Subvolumes, BTRFSSubVolume{
+				Snapshot: "",
+				Path:     string(filepath.Separator),
+				Readonly: false,
+			})
+		}
+
+		if len(entry.Info.Snapshots) > 0 {
+			// Create new snapshots directory.
+			err := createParentSnapshotDirIfMissing(d.name, vol.volType, vol.name)
 			if err != nil {
 				return nil, nil, err
 			}
+
+			// Restore backup snapshots from oldest to newest.
+			for _, snapName := range entry.Info.Snapshots {
+				snapVol, _ := vol.NewSnapshot(snapName)
+				snapDir := "snapshots"
+				srcFilePrefix := snapName
+				if vol.volType == VolumeTypeVM {
+					snapDir = "virtual-machine-snapshots"
+					if vol.contentType == ContentTypeFS {
+						srcFilePrefix = fmt.Sprintf("%s-config", snapName)
+					}
+				} else if vol.volType == VolumeTypeCustom {
+					snapDir = "volume-snapshots"
+				}
+
+				srcFilePrefix = filepath.Join(snapDir, srcFilePrefix)
+				err = unpackVolume(snapVol, entry.Data, unpacker, optimizedHeader, srcFilePrefix)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				restoredSnapshots = append(restoredSnapshots, snapName)
+			}
 		}
-	}
 
-	// Extract main volume.
-	srcFilePrefix := "container"
-	if vol.volType == VolumeTypeVM {
-		if vol.contentType == ContentTypeFS {
-			srcFilePrefix = "virtual-machine-config"
-		} else {
-			srcFilePrefix = "virtual-machine"
+		if i < len(entries)-1 {
+			continue
 		}
-	} else if vol.volType == VolumeTypeCustom {
-		srcFilePrefix = "volume"
-	}
 
-	err = unpackVolume(vol, srcFilePrefix)
-	if err != nil {
-		return nil, nil, err
+		// Extract main volume.
+		srcFilePrefix := "container"
+		if vol.volType == VolumeTypeVM {
+			if vol.contentType == ContentTypeFS {
+				srcFilePrefix = "virtual-machine-config"
+			} else {
+				srcFilePrefix = "virtual-machine"
+			}
+		} else if vol.volType == VolumeTypeCustom {
+			srcFilePrefix = "volume"
+		}
+
+		err = unpackVolume(vol, entry.Data, unpacker, optimizedHeader, srcFilePrefix)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	for _, copyOp := range copyOps {
@@ -360,7 +388,7 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 	}
 
 	// Restore readonly property on subvolumes that need it.
-	for _, subVol := range optimizedHeader.Subvolumes {
+	for _, subVol := range restoredSubvolumes {
 		if !subVol.Readonly {
 			continue // All subvolumes are made writable during unpack process so we can skip these.
 		}
@@ -378,6 +406,19 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 		}
 	}
 
+	// Remove the snapshots that were only needed to apply the incremental backups.
+	for _, snapName := range restoredSnapshots {
+		if shared.StringInSlice(snapName, srcBackup.Snapshots) {
+			continue
+		}
+
+		snapVol, _ := vol.NewSnapshot(snapName)
+		err = d.DeleteVolumeSnapshot(snapVol, op)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	revert.Success()
 	return nil, revertHook, nil
 }
@@ -403,6 +444,15 @@ func (d *btrfs) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots bo
 
 	revert.Add(func() { _ = d.deleteSubvolume(target, true) })
 
+	// Apply the compression of the new volume if it differs from the source volume.
+	compression := vol.ExpandedConfig("compression")
+	if vol.contentType == ContentTypeFS && compression != srcVol.ExpandedConfig("compression") {
+		err = btrfsSetCompressionProperty(target, compression)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Restore readonly property on subvolumes in reverse order (except root which should be left writable).
 	subVolCount := len(subVols)
 	for i := range subVols {
@@ -921,7 +971,20 @@ func (d *btrfs) HasVolume(vol Volume) bool {
 
 // ValidateVolume validates the supplied volume config.
 func (d *btrfs) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
-	return d.validateVolume(vol, nil, removeUnknownKeys)
+	rules := map[string]func(value string) error{
+		"compression": validate.Optional(validate.IsOneOf(btrfsCompressionAlgorithms...)),
+	}
+
+	err := d.validateVolume(vol, rules, removeUnknownKeys)
+	if err != nil {
+		return err
+	}
+
+	if vol.contentType != ContentTypeFS && vol.config["compression"] != "" {
+		return fmt.Errorf("compression can only be set on filesystem volumes")
+	}
+
+	return nil
 }
 
 // UpdateVolume applies config changes to the volume.
@@ -934,6 +997,19 @@ func (d *btrfs) UpdateVolume(vol Volume, changedConfig map[string]string) error
 		}
 	}
 
+	// Only affects data written after the change.
+	newCompression, compressionChanged := changedConfig["compression"]
+	if compressionChanged && vol.contentType == ContentTypeFS {
+		if newCompression == "" {
+			newCompression = vol.poolConfig["volume.compression"]
+		}
+
+		err := btrfsSetCompressionProperty(vol.MountPath(), newCompression)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -1429,7 +1505,7 @@ func (d *btrfs) migrateVolumeOptimized(vol Volume, conn io.ReadWriteCloser, volS
 
 // BackupVolume copies a volume (and optionally its snapshots) to a specified target path.
 // This driver does not support optimized backups.
-func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, op *operations.Operation) error {
+func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, baseSnapshot string, op *operations.Operation) error {
 	// Handle the non-optimized tarballs through the generic packer.
 	if !optimized {
 		// Because the generic backup method will not take a consistent backup if files are being modified
@@ -1448,12 +1524,18 @@ func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWr
 			vol.mountCustomPath = snapshotPath
 		}
 
-		return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
+		return genericVFSBackupVolume(d, vol, tarWriter, snapshots, baseSnapshot, op)
 	}
 
 	// Optimized backup.
 
-	if len(snapshots) > 0 {
+	if baseSnapshot != "" {
+		// Check base and requested snapshots exist in storage.
+		err := vol.SnapshotsExist(append([]string{baseSnapshot}, snapshots...), op)
+		if err != nil {
+			return err
+		}
+	} else if len(snapshots) > 0 {
 		// Check requested snapshot match those in storage.
 		err := vol.SnapshotsMatch(snapshots, op)
 		if err != nil {
@@ -1598,6 +1680,12 @@ func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWr
 
 	// Backup snapshots if populated.
 	lastVolPath := "" // Used as parent for differential exports.
+
+	// For incremental backups the subvolumes are sent relative to the base snapshot.
+	if baseSnapshot != "" {
+		baseVol, _ := vol.NewSnapshot(baseSnapshot)
+		lastVolPath = baseVol.MountPath()
+	}
 	for _, snapName := range snapshots {
 		snapVol, _ := vol.NewSnapshot(snapName)
 
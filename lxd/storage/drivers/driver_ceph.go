@@ -31,6 +31,7 @@ func (d *ceph) load() error {
 	d.patches = map[string]func() error{
 		"storage_lvm_skipactivation":       nil,
 		"storage_missing_snapshot_records": nil,
+		"storage_create_buckets_dataset":   nil,
 	}
 
 	// Done if previously loaded.
@@ -84,6 +85,7 @@ func (d *ceph) Info() Info {
 		RunningCopyFreeze: true,
 		DirectIO:          true,
 		MountedRoot:       false,
+		Buckets:           true,
 	}
 }
 
@@ -258,6 +260,7 @@ func (d *ceph) Validate(config map[string]string) error {
 		"ceph.osd.pg_num":            validate.IsAny,
 		"ceph.osd.pool_name":         validate.IsAny,
 		"ceph.osd.data_pool_name":    validate.IsAny,
+		"ceph.radosgw.endpoint":      validate.Optional(validate.IsRequestURL),
 		"ceph.rbd.clone_copy":        validate.Optional(validate.IsBool),
 		"ceph.rbd.du":                validate.Optional(validate.IsBool),
 		"ceph.rbd.features":          validate.IsAny,
@@ -0,0 +1,242 @@
+package drivers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/storage/s3"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/units"
+)
+
+// radosgwBucketUserPrefix is the prefix of the RADOS gateway user that owns each bucket.
+const radosgwBucketUserPrefix = "lxd-"
+
+// radosgwTimeout is the timeout used for S3 requests against the RADOS gateway.
+const radosgwTimeout = 30 * time.Second
+
+// radosgwUserKey represents a key returned by radosgw-admin.
+type radosgwUserKey struct {
+	User      string `json:"user"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+// radosgwUser represents a user returned by radosgw-admin.
+type radosgwUser struct {
+	UserID string           `json:"user_id"`
+	Keys   []radosgwUserKey `json:"keys"`
+}
+
+// radosgwAdmin runs radosgw-admin against the pool's cluster.
+func (d *ceph) radosgwAdmin(args ...string) (string, error) {
+	cmd := []string{"--cluster", d.config["ceph.cluster_name"], "--id", d.config["ceph.user.name"]}
+
+	return shared.RunCommand("radosgw-admin", append(cmd, args...)...)
+}
+
+// radosgwBucketUser returns the name of the RADOS gateway user owning the bucket.
+func (d *ceph) radosgwBucketUser(bucketName string) string {
+	return fmt.Sprintf("%s%s", radosgwBucketUserPrefix, bucketName)
+}
+
+// radosgwEndpoint returns the configured RADOS gateway endpoint.
+func (d *ceph) radosgwEndpoint() (*url.URL, error) {
+	if d.config["ceph.radosgw.endpoint"] == "" {
+		return nil, fmt.Errorf(`Storage pool config "ceph.radosgw.endpoint" must be set to use buckets`)
+	}
+
+	u, err := url.Parse(d.config["ceph.radosgw.endpoint"])
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing RADOS gateway endpoint: %w", err)
+	}
+
+	return u, nil
+}
+
+// radosgwSetBucketQuota sets the bucket quota from the bucket's size config. An empty size removes the quota.
+func (d *ceph) radosgwSetBucketQuota(bucketName string, size string) error {
+	if size == "" || size == "0" {
+		_, err := d.radosgwAdmin("quota", "disable", "--quota-scope=bucket", fmt.Sprintf("--bucket=%s", bucketName))
+		if err != nil {
+			return fmt.Errorf("Failed disabling bucket quota: %w", err)
+		}
+
+		return nil
+	}
+
+	sizeBytes, err := units.ParseByteSizeString(size)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.radosgwAdmin("quota", "set", "--quota-scope=bucket", fmt.Sprintf("--bucket=%s", bucketName), fmt.Sprintf("--max-size=%d", sizeBytes))
+	if err != nil {
+		return fmt.Errorf("Failed setting bucket quota: %w", err)
+	}
+
+	_, err = d.radosgwAdmin("quota", "enable", "--quota-scope=bucket", fmt.Sprintf("--bucket=%s", bucketName))
+	if err != nil {
+		return fmt.Errorf("Failed enabling bucket quota: %w", err)
+	}
+
+	return nil
+}
+
+// GetBucketURL returns the URL of the specified bucket.
+func (d *ceph) GetBucketURL(bucketName string) *url.URL {
+	u, err := d.radosgwEndpoint()
+	if err != nil {
+		return nil
+	}
+
+	u.Path = fmt.Sprintf("/%s", bucketName)
+
+	return u
+}
+
+// CreateBucket creates a new bucket owned by a dedicated RADOS gateway user.
+func (d *ceph) CreateBucket(bucket Volume, op *operations.Operation) error {
+	endpoint, err := d.radosgwEndpoint()
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	bucketUser := d.radosgwBucketUser(bucket.name)
+	out, err := d.radosgwAdmin("user", "create", fmt.Sprintf("--uid=%s", bucketUser), fmt.Sprintf("--display-name=%s", bucket.name), "--max-buckets=1")
+	if err != nil {
+		return fmt.Errorf("Failed creating bucket user: %w", err)
+	}
+
+	revert.Add(func() { _, _ = d.radosgwAdmin("user", "rm", fmt.Sprintf("--uid=%s", bucketUser), "--purge-keys") })
+
+	var user radosgwUser
+	err = json.Unmarshal([]byte(out), &user)
+	if err != nil {
+		return fmt.Errorf("Failed parsing bucket user: %w", err)
+	}
+
+	if len(user.Keys) < 1 {
+		return fmt.Errorf("Bucket user has no keys")
+	}
+
+	creds := s3.Credentials{AccessKey: user.Keys[0].AccessKey, SecretKey: user.Keys[0].SecretKey}
+
+	ctx, cancel := context.WithTimeout(context.Background(), radosgwTimeout)
+	defer cancel()
+
+	client := s3.NewClient(endpoint, creds, false)
+	err = client.CreateBucket(ctx, bucket.name)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_, _ = d.radosgwAdmin("bucket", "rm", fmt.Sprintf("--bucket=%s", bucket.name), "--purge-objects")
+	})
+
+	err = d.radosgwSetBucketQuota(bucket.name, bucket.config["size"])
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// DeleteBucket deletes an existing bucket, its objects and its owning user.
+func (d *ceph) DeleteBucket(bucket Volume, op *operations.Operation) error {
+	_, err := d.radosgwAdmin("bucket", "rm", fmt.Sprintf("--bucket=%s", bucket.name), "--purge-objects")
+	if err != nil && !strings.Contains(err.Error(), "No such file or directory") {
+		return fmt.Errorf("Failed deleting bucket: %w", err)
+	}
+
+	_, err = d.radosgwAdmin("user", "rm", fmt.Sprintf("--uid=%s", d.radosgwBucketUser(bucket.name)), "--purge-keys")
+	if err != nil && !strings.Contains(err.Error(), "could not find user") {
+		return fmt.Errorf("Failed deleting bucket user: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateBucket updates an existing bucket.
+func (d *ceph) UpdateBucket(bucket Volume, changedConfig map[string]string) error {
+	newSize, sizeChanged := changedConfig["size"]
+	if sizeChanged {
+		return d.radosgwSetBucketQuota(bucket.name, newSize)
+	}
+
+	return nil
+}
+
+// radosgwSubuser returns the name of the RADOS gateway subuser used for a bucket key.
+func (d *ceph) radosgwSubuser(bucketName string, keyName string) string {
+	return fmt.Sprintf("%s:%s", d.radosgwBucketUser(bucketName), keyName)
+}
+
+// radosgwSubuserAccess returns the RADOS gateway access level for a bucket key role.
+func (d *ceph) radosgwSubuserAccess(roleName string) (string, error) {
+	switch roleName {
+	case s3.RoleAdmin:
+		return "full", nil
+	case s3.RoleReadOnly:
+		return "read", nil
+	}
+
+	return "", fmt.Errorf("Invalid bucket key role %q", roleName)
+}
+
+// CreateBucketKey creates a new bucket key as a RADOS gateway subuser of the bucket user.
+func (d *ceph) CreateBucketKey(bucket Volume, keyName string, creds s3.Credentials, roleName string, op *operations.Operation) (*s3.Credentials, error) {
+	access, err := d.radosgwSubuserAccess(roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	newCreds, err := s3.FillCredentials(creds)
+	if err != nil {
+		return nil, err
+	}
+
+	bucketUser := d.radosgwBucketUser(bucket.name)
+	subuser := d.radosgwSubuser(bucket.name, keyName)
+
+	_, err = d.radosgwAdmin("subuser", "create", fmt.Sprintf("--uid=%s", bucketUser), fmt.Sprintf("--subuser=%s", subuser), fmt.Sprintf("--access=%s", access), "--key-type=s3", fmt.Sprintf("--access-key=%s", newCreds.AccessKey), fmt.Sprintf("--secret-key=%s", newCreds.SecretKey))
+	if err != nil {
+		return nil, fmt.Errorf("Failed creating bucket key: %w", err)
+	}
+
+	return newCreds, nil
+}
+
+// UpdateBucketKey updates an existing bucket key by recreating the RADOS gateway subuser.
+func (d *ceph) UpdateBucketKey(bucket Volume, keyName string, creds s3.Credentials, roleName string, op *operations.Operation) (*s3.Credentials, error) {
+	err := d.DeleteBucketKey(bucket, keyName, op)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.CreateBucketKey(bucket, keyName, creds, roleName, op)
+}
+
+// DeleteBucketKey deletes an existing bucket key.
+func (d *ceph) DeleteBucketKey(bucket Volume, keyName string, op *operations.Operation) error {
+	bucketUser := d.radosgwBucketUser(bucket.name)
+	subuser := d.radosgwSubuser(bucket.name, keyName)
+
+	_, err := d.radosgwAdmin("subuser", "rm", fmt.Sprintf("--uid=%s", bucketUser), fmt.Sprintf("--subuser=%s", subuser), "--purge-keys")
+	if err != nil {
+		return fmt.Errorf("Failed deleting bucket key: %w", err)
+	}
+
+	return nil
+}
@@ -29,6 +29,7 @@ func (d *cephfs) load() error {
 	d.patches = map[string]func() error{
 		"storage_lvm_skipactivation":       nil,
 		"storage_missing_snapshot_records": nil,
+		"storage_create_buckets_dataset":   nil,
 	}
 
 	// Done if previously loaded.
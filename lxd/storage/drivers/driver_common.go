@@ -2,13 +2,17 @@ package drivers
 
 import (
 	"fmt"
+	"net/url"
 	"os/exec"
 	"strings"
 
 	"github.com/lxc/lxd/lxd/migration"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/storage/s3"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/validate"
 )
 
 type common struct {
@@ -122,8 +126,8 @@ func (d *common) validateVolume(vol Volume, driverRules map[string]func(value st
 		}
 	}
 
-	// If volume type is not custom, don't allow "size" property.
-	if vol.volType != VolumeTypeCustom && vol.config["size"] != "" {
+	// If volume type is not custom or bucket, don't allow "size" property.
+	if vol.volType != VolumeTypeCustom && vol.volType != VolumeTypeBucket && vol.config["size"] != "" {
 		return fmt.Errorf("Volume %q property is only valid for custom volume types", "size")
 	}
 
@@ -198,6 +202,11 @@ func (d *common) ApplyPatch(name string) error {
 	return patch()
 }
 
+// Deduplicate shares identical data between the given volumes.
+func (d *common) Deduplicate(vols []Volume, op *operations.Operation) (int64, error) {
+	return -1, ErrNotSupported
+}
+
 // moveGPTAltHeader moves the GPT alternative header to the end of the disk device supplied.
 // If the device supplied is not detected as not being a GPT disk then no action is taken and nil is returned.
 // If the required sgdisk command is not available a warning is logged, but no error is returned, as really it is
@@ -249,3 +258,79 @@ func (d *common) runFiller(vol Volume, devPath string, filler *VolumeFiller, all
 
 	return nil
 }
+
+// ValidateBucket validates the supplied bucket name and config.
+func (d *common) ValidateBucket(bucket Volume) error {
+	err := s3.ValidateBucketName(bucket.name)
+	if err != nil {
+		return err
+	}
+
+	rules := map[string]func(value string) error{
+		"size": validate.Optional(validate.IsSize),
+	}
+
+	for k, v := range bucket.config {
+		validator, found := rules[k]
+		if !found {
+			// User keys are not validated.
+			if strings.HasPrefix(k, "user.") {
+				continue
+			}
+
+			return fmt.Errorf("Invalid option for bucket %q option %q", bucket.name, k)
+		}
+
+		err := validator(v)
+		if err != nil {
+			return fmt.Errorf("Invalid value for bucket %q option %q: %w", bucket.name, k, err)
+		}
+	}
+
+	return nil
+}
+
+// GetBucketURL returns the URL of the specified bucket.
+// Drivers that serve buckets locally return nil, as the URL is derived from the storage buckets listener.
+func (d *common) GetBucketURL(bucketName string) *url.URL {
+	return nil
+}
+
+// CreateBucket creates a new bucket.
+func (d *common) CreateBucket(bucket Volume, op *operations.Operation) error {
+	return ErrNotSupported
+}
+
+// DeleteBucket deletes an existing bucket.
+func (d *common) DeleteBucket(bucket Volume, op *operations.Operation) error {
+	return ErrNotSupported
+}
+
+// UpdateBucket updates an existing bucket.
+func (d *common) UpdateBucket(bucket Volume, changedConfig map[string]string) error {
+	return ErrNotSupported
+}
+
+// ValidateBucketKey validates the supplied bucket key config.
+func (d *common) ValidateBucketKey(keyName string, creds s3.Credentials, roleName string) error {
+	if keyName == "" {
+		return fmt.Errorf("Key name is required")
+	}
+
+	return s3.ValidateRole(roleName)
+}
+
+// CreateBucketKey creates a new bucket key.
+func (d *common) CreateBucketKey(bucket Volume, keyName string, creds s3.Credentials, roleName string, op *operations.Operation) (*s3.Credentials, error) {
+	return nil, ErrNotSupported
+}
+
+// UpdateBucketKey updates an existing bucket key.
+func (d *common) UpdateBucketKey(bucket Volume, keyName string, creds s3.Credentials, roleName string, op *operations.Operation) (*s3.Credentials, error) {
+	return nil, ErrNotSupported
+}
+
+// DeleteBucketKey deletes an existing bucket key.
+func (d *common) DeleteBucketKey(bucket Volume, keyName string, op *operations.Operation) error {
+	return ErrNotSupported
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/validate"
 )
 
 type dir struct {
@@ -22,6 +23,7 @@ func (d *dir) load() error {
 	d.patches = map[string]func() error{
 		"storage_lvm_skipactivation":       nil,
 		"storage_missing_snapshot_records": nil,
+		"storage_create_buckets_dataset":   nil,
 	}
 
 	return nil
@@ -35,11 +37,13 @@ func (d *dir) Info() Info {
 		OptimizedImages:   false,
 		PreservesInodes:   false,
 		Remote:            d.isRemote(),
-		VolumeTypes:       []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
+		VolumeTypes:       []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM, VolumeTypeBucket},
 		BlockBacking:      false,
 		RunningCopyFreeze: true,
 		DirectIO:          true,
 		MountedRoot:       true,
+		Buckets:           true,
+		LiveVMSnapshots:   d.qcow2Snapshots(),
 	}
 }
 
@@ -95,7 +99,12 @@ func (d *dir) Delete(op *operations.Operation) error {
 
 // Validate checks that all provide keys are supported and that no conflicting or missing configuration is present.
 func (d *dir) Validate(config map[string]string) error {
-	return d.validatePool(config, nil)
+	rules := map[string]func(value string) error{
+		"dir.qcow2_snapshots": validate.Optional(validate.IsBool),
+		"volume.compression":  validate.Optional(validate.IsOneOf(btrfsCompressionAlgorithms...)),
+	}
+
+	return d.validatePool(config, rules)
 }
 
 // Update applies any driver changes required from a configuration change.
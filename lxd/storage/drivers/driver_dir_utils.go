@@ -2,9 +2,13 @@ package drivers
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/storage/filesystem"
 	"github.com/lxc/lxd/lxd/storage/quota"
+	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/units"
 )
@@ -19,6 +23,42 @@ func (d *dir) withoutGetVolID() Driver {
 	return newDriver
 }
 
+// initVolume prepares a new volume before its data is copied or received.
+func (d *dir) initVolume(vol Volume) (revert.Hook, error) {
+	compression := vol.ExpandedConfig("compression")
+	if compression != "" {
+		err := d.setCompression(vol, compression)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d.setupInitialQuota(vol)
+}
+
+// setCompression sets the compression algorithm used for data written to a filesystem volume.
+// This relies on the Btrfs compression property and so requires the pool directory to be on Btrfs.
+func (d *dir) setCompression(vol Volume, compression string) error {
+	if vol.contentType != ContentTypeFS {
+		return nil
+	}
+
+	fsType, err := filesystem.Detect(vol.MountPath())
+	if err != nil {
+		return err
+	}
+
+	if fsType != "btrfs" {
+		if compression == "" {
+			return nil
+		}
+
+		return fmt.Errorf("Volume compression requires the storage pool directory to be on a btrfs filesystem, not %q", fsType)
+	}
+
+	return btrfsSetCompressionProperty(vol.MountPath(), compression)
+}
+
 // setupInitialQuota enables quota on a new volume and sets with an initial quota from config.
 // Returns a revert fail function that can be used to undo this function if a subsequent step fails.
 func (d *dir) setupInitialQuota(vol Volume) (revert.Hook, error) {
@@ -38,7 +78,7 @@ func (d *dir) setupInitialQuota(vol Volume) (revert.Hook, error) {
 	defer revert.Fail()
 
 	// Define a function to revert the quota being setup.
-	revertFunc := func() { _ = d.deleteQuota(volPath, volID) }
+	revertFunc := func() { _ = d.deleteQuota(volPath, vol.volType, volID) }
 	revert.Add(revertFunc)
 
 	// Initialise the volume's project using the volume ID and set the quota.
@@ -47,7 +87,7 @@ func (d *dir) setupInitialQuota(vol Volume) (revert.Hook, error) {
 		return nil, err
 	}
 
-	err = d.setQuota(volPath, volID, sizeBytes)
+	err = d.setQuota(volPath, vol.volType, volID, sizeBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +97,7 @@ func (d *dir) setupInitialQuota(vol Volume) (revert.Hook, error) {
 }
 
 // deleteQuota removes the project quota for a volID from a path.
-func (d *dir) deleteQuota(path string, volID int64) error {
+func (d *dir) deleteQuota(path string, volType VolumeType, volID int64) error {
 	if volID == volIDQuotaSkip {
 		// Disabled on purpose, just ignore
 		return nil
@@ -73,7 +113,7 @@ func (d *dir) deleteQuota(path string, volID int64) error {
 		return nil
 	}
 
-	err = quota.DeleteProject(path, d.quotaProjectID(volID))
+	err = quota.DeleteProject(path, d.quotaProjectID(volType, volID))
 	if err != nil {
 		return err
 	}
@@ -82,17 +122,22 @@ func (d *dir) deleteQuota(path string, volID int64) error {
 }
 
 // quotaProjectID generates a project quota ID from a volume ID.
-func (d *dir) quotaProjectID(volID int64) uint32 {
+// Bucket volumes have their IDs allocated from a separate table, so they use a separate project ID range.
+func (d *dir) quotaProjectID(volType VolumeType, volID int64) uint32 {
 	if volID == volIDQuotaSkip {
 		// Disabled on purpose, just ignore
 		return 0
 	}
 
+	if volType == VolumeTypeBucket {
+		return uint32(volID + 2000000000)
+	}
+
 	return uint32(volID + 10000)
 }
 
 // setQuota sets the project quota on the path. The volID generates a quota project ID.
-func (d *dir) setQuota(path string, volID int64, sizeBytes int64) error {
+func (d *dir) setQuota(path string, volType VolumeType, volID int64, sizeBytes int64) error {
 	if volID == volIDQuotaSkip {
 		// Disabled on purpose, just ignore.
 		return nil
@@ -112,14 +157,14 @@ func (d *dir) setQuota(path string, volID int64, sizeBytes int64) error {
 		return nil
 	}
 
-	projectID := d.quotaProjectID(volID)
+	projectID := d.quotaProjectID(volType, volID)
 	currentProjectID, err := quota.GetProject(path)
 	if err != nil {
 		return err
 	}
 
 	// Remove current project if desired project ID is different.
-	if currentProjectID != d.quotaProjectID(volID) {
+	if currentProjectID != d.quotaProjectID(volType, volID) {
 		err = quota.DeleteProject(path, currentProjectID)
 		if err != nil {
 			return err
@@ -135,3 +180,323 @@ func (d *dir) setQuota(path string, volID int64, sizeBytes int64) error {
 	// Set the project quota size.
 	return quota.SetProjectQuota(path, projectID, sizeBytes)
 }
+
+// dirVolumeDiskPath returns the path of the disk image file in a VM volume directory.
+// VMs using qcow2 snapshots have a qcow2 disk image rather than a raw one.
+func dirVolumeDiskPath(volPath string) string {
+	qcow2Path := filepath.Join(volPath, genericVolumeQcow2DiskFile)
+	if shared.PathExists(qcow2Path) {
+		return qcow2Path
+	}
+
+	return filepath.Join(volPath, genericVolumeDiskFile)
+}
+
+// diskImageFormat returns the format of the disk image file at path.
+func diskImageFormat(path string) string {
+	if IsQcow2DiskPath(path) {
+		return "qcow2"
+	}
+
+	return "raw"
+}
+
+// qcow2Snapshots returns true if VM snapshots are created as qcow2 backing images of the VM's disk.
+func (d *dir) qcow2Snapshots() bool {
+	return shared.IsTrue(d.config["dir.qcow2_snapshots"])
+}
+
+// createQcow2Overlay creates a qcow2 disk image at path of the specified size that uses the disk image at
+// backingPath as its backing image. The backing image doesn't need to exist yet.
+func (d *dir) createQcow2Overlay(path string, backingPath string, sizeBytes int64) error {
+	_, err := shared.RunCommand("qemu-img", "create", "-f", "qcow2", "-u", "-b", backingPath, "-F", diskImageFormat(backingPath), path, fmt.Sprintf("%d", sizeBytes))
+	if err != nil {
+		return fmt.Errorf("Failed creating qcow2 disk image %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// setDiskImageBackingFile changes the backing image recorded in the qcow2 disk image at path, without changing its
+// contents. If liveDisk is set, the disk image is in use by that running instance and is changed through it.
+func (d *dir) setDiskImageBackingFile(path string, backingPath string, liveDisk LiveDisk) error {
+	if liveDisk != nil {
+		return liveDisk.BlockChangeBackingFile(path, backingPath)
+	}
+
+	_, err := shared.RunCommand("qemu-img", "rebase", "-u", "-f", "qcow2", "-b", backingPath, "-F", diskImageFormat(backingPath), path)
+	if err != nil {
+		return fmt.Errorf("Failed changing backing image of %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// moveDiskImage moves a disk image file to another directory of the pool. The file's project quota ID is first
+// set to the one of the target directory, so that the move is allowed and it is accounted to the right volume.
+func (d *dir) moveDiskImage(path string, newPath string) error {
+	ok, err := quota.Supported(path)
+	if err == nil && ok {
+		projectID, err := quota.GetProject(filepath.Dir(newPath))
+		if err != nil {
+			return err
+		}
+
+		err = quota.SetProject(path, projectID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.Rename(path, newPath)
+	if err != nil {
+		return fmt.Errorf("Failed moving disk image %q to %q: %w", path, newPath, err)
+	}
+
+	return nil
+}
+
+// volumeDiskImages returns the paths of the disk images of a VM volume and of all its snapshots.
+func (d *dir) volumeDiskImages(vol Volume) ([]string, error) {
+	var paths []string
+
+	diskPath := dirVolumeDiskPath(vol.MountPath())
+	if shared.PathExists(diskPath) {
+		paths = append(paths, diskPath)
+	}
+
+	snapshots, err := d.VolumeSnapshots(vol, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, snapName := range snapshots {
+		snapVol, err := vol.NewSnapshot(snapName)
+		if err != nil {
+			return nil, err
+		}
+
+		snapDiskPath := dirVolumeDiskPath(snapVol.MountPath())
+		if shared.PathExists(snapDiskPath) {
+			paths = append(paths, snapDiskPath)
+		}
+	}
+
+	return paths, nil
+}
+
+// diskImageChildren returns the disk images of a VM volume and its snapshots that use the disk image at path as
+// their backing image.
+func (d *dir) diskImageChildren(vol Volume, path string) ([]string, error) {
+	paths, err := d.volumeDiskImages(vol)
+	if err != nil {
+		return nil, err
+	}
+
+	var children []string
+	for _, childPath := range paths {
+		if !IsQcow2DiskPath(childPath) {
+			continue
+		}
+
+		info, err := qcow2DiskImageInfo(childPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.BackingFile == path {
+			children = append(children, childPath)
+		}
+	}
+
+	return children, nil
+}
+
+// activeDiskImages returns the disk images making up the current disk of a VM volume.
+func (d *dir) activeDiskImages(vol Volume) (map[string]bool, error) {
+	chain, err := DiskImageBackingChain(dirVolumeDiskPath(vol.MountPath()))
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]bool, len(chain))
+	for _, image := range chain {
+		active[image.Path] = true
+	}
+
+	return active, nil
+}
+
+// activeDiskImageLiveDisk returns the live disk through which the disk image at path must be changed if it is part
+// of the current disk of the VM volume (as per active), nil if it can be changed directly. Fails if the disk image
+// is in use by the VM but no live disk is available.
+func (d *dir) activeDiskImageLiveDisk(vol Volume, liveDisk LiveDisk, active map[string]bool, path string) (LiveDisk, error) {
+	if !active[path] {
+		return nil, nil
+	}
+
+	if liveDisk == nil && vol.MountInUse() {
+		return nil, fmt.Errorf("Disk image %q is in use by a running VM", path)
+	}
+
+	return liveDisk, nil
+}
+
+// createQcow2Snapshot snapshots the disk of a VM volume by moving its current disk image into the snapshot and
+// replacing it with a new qcow2 disk image that uses it as backing image. If the VM is running, the switch over to
+// the new disk image is done through snapVol's live disk.
+func (d *dir) createQcow2Snapshot(vol Volume, snapVol Volume) error {
+	diskPath := dirVolumeDiskPath(vol.MountPath())
+	snapDiskPath := filepath.Join(snapVol.MountPath(), filepath.Base(diskPath))
+	overlayPath := filepath.Join(vol.MountPath(), genericVolumeQcow2DiskFile)
+	newOverlayPath := fmt.Sprintf("%s.new", overlayPath)
+
+	sizeBytes, err := BlockDiskSizeBytes(diskPath)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = d.createQcow2Overlay(newOverlayPath, snapDiskPath, sizeBytes)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = os.Remove(newOverlayPath) })
+
+	// A running VM keeps using the moved disk image until it is switched over to the new one.
+	err = d.moveDiskImage(diskPath, snapDiskPath)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = d.moveDiskImage(snapDiskPath, diskPath) })
+
+	if snapVol.liveDisk != nil {
+		err = snapVol.liveDisk.BlockSnapshot(newOverlayPath)
+		if err != nil {
+			return fmt.Errorf("Failed switching running VM to new disk image: %w", err)
+		}
+	}
+
+	revert.Success()
+
+	err = os.Rename(newOverlayPath, overlayPath)
+	if err != nil {
+		return fmt.Errorf("Failed moving disk image %q to %q: %w", newOverlayPath, overlayPath, err)
+	}
+
+	return nil
+}
+
+// deleteQcow2Snapshot takes the disk image of a VM snapshot out of the backing chains it is part of, so that the
+// snapshot can be removed. The disk image is merged with the child image using it (preferring the one in use by
+// the VM) and takes its place, while any other child image is made independent of it.
+func (d *dir) deleteQcow2Snapshot(vol Volume, snapVol Volume) error {
+	snapDiskPath := dirVolumeDiskPath(snapVol.MountPath())
+	if !shared.PathExists(snapDiskPath) {
+		return nil
+	}
+
+	children, err := d.diskImageChildren(vol, snapDiskPath)
+	if err != nil {
+		return err
+	}
+
+	// Nothing depends on the disk image, so it can be removed along with the snapshot.
+	if len(children) == 0 {
+		return nil
+	}
+
+	active, err := d.activeDiskImages(vol)
+	if err != nil {
+		return err
+	}
+
+	mergePath := children[0]
+	for _, childPath := range children {
+		if active[childPath] {
+			mergePath = childPath
+			break
+		}
+	}
+
+	// Make the other child images independent of the snapshot's disk image by copying the data they use from it.
+	rebaseArgs := []string{"-n19", "qemu-img", "rebase", "-f", "qcow2", "-b", ""}
+	if IsQcow2DiskPath(snapDiskPath) {
+		info, err := qcow2DiskImageInfo(snapDiskPath)
+		if err != nil {
+			return err
+		}
+
+		if info.BackingFile != "" {
+			rebaseArgs = []string{"-n19", "qemu-img", "rebase", "-f", "qcow2", "-b", info.BackingFile, "-F", info.BackingFormat}
+		}
+	}
+
+	for _, childPath := range children {
+		if childPath == mergePath {
+			continue
+		}
+
+		_, err = shared.RunCommand("nice", append(rebaseArgs, childPath)...)
+		if err != nil {
+			return fmt.Errorf("Failed rebasing disk image %q: %w", childPath, err)
+		}
+	}
+
+	// The children of the merged image need pointing at the snapshot's disk image once it has taken its place.
+	grandChildren, err := d.diskImageChildren(vol, mergePath)
+	if err != nil {
+		return err
+	}
+
+	newPath := filepath.Join(filepath.Dir(mergePath), filepath.Base(snapDiskPath))
+
+	liveDisk, err := d.activeDiskImageLiveDisk(vol, snapVol.liveDisk, active, mergePath)
+	if err != nil {
+		return err
+	}
+
+	if liveDisk != nil {
+		err = liveDisk.BlockCommit(mergePath, snapDiskPath, newPath)
+		if err != nil {
+			return fmt.Errorf("Failed merging disk image %q of running VM: %w", mergePath, err)
+		}
+	} else {
+		_, err = shared.RunCommand("nice", "-n19", "qemu-img", "commit", "-f", "qcow2", "-b", snapDiskPath, mergePath)
+		if err != nil {
+			return fmt.Errorf("Failed merging disk image %q: %w", mergePath, err)
+		}
+	}
+
+	err = d.moveDiskImage(snapDiskPath, newPath)
+	if err != nil {
+		return err
+	}
+
+	if newPath == mergePath {
+		return nil
+	}
+
+	err = os.Remove(mergePath)
+	if err != nil {
+		return fmt.Errorf("Failed removing disk image %q: %w", mergePath, err)
+	}
+
+	for _, childPath := range grandChildren {
+		liveDisk, err := d.activeDiskImageLiveDisk(vol, snapVol.liveDisk, active, childPath)
+		if err != nil {
+			return err
+		}
+
+		err = d.setDiskImageBackingFile(childPath, newPath, liveDisk)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package drivers
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// qcow2TestDiskSize is the size of the disks used by the qcow2 snapshot tests.
+const qcow2TestDiskSize = 1024 * 1024
+
+// qcow2TestChunkSize is the size of the data written at once by the qcow2 snapshot tests.
+const qcow2TestChunkSize = 64 * 1024
+
+// requireCommands skips the test if any of the commands isn't available.
+func requireCommands(t *testing.T, commands ...string) {
+	for _, command := range commands {
+		_, err := exec.LookPath(command)
+		if err != nil {
+			t.Skipf("%s isn't available", command)
+		}
+	}
+}
+
+// newQcow2TestVM returns a dir driver using qcow2 snapshots on a temporary pool and a VM volume with a raw disk.
+func newQcow2TestVM(t *testing.T) (*dir, Volume) {
+	requireCommands(t, "qemu-img", "qemu-io")
+
+	// The volume directories are restricted to root.
+	if os.Geteuid() != 0 {
+		t.Skip("Test requires root")
+	}
+
+	t.Setenv("LXD_DIR", t.TempDir())
+
+	d := &dir{}
+	d.init(nil, "pool", map[string]string{"dir.qcow2_snapshots": "true"}, logger.AddContext(logger.Log, nil), nil, nil)
+
+	vol := NewVolume(d, d.name, VolumeTypeVM, ContentTypeBlock, "vm", nil, d.config)
+	require.NoError(t, os.MkdirAll(filepath.Dir(vol.MountPath()), 0711))
+	require.NoError(t, vol.EnsureMountPath())
+
+	diskPath := filepath.Join(vol.MountPath(), genericVolumeDiskFile)
+	_, err := shared.RunCommand("qemu-img", "create", "-f", "raw", diskPath, fmt.Sprintf("%d", qcow2TestDiskSize))
+	require.NoError(t, err)
+
+	return d, vol
+}
+
+// createQcow2TestSnapshot snapshots the VM volume using a qcow2 backing image.
+func createQcow2TestSnapshot(t *testing.T, d *dir, vol Volume, snapName string) Volume {
+	snapVol, err := vol.NewSnapshot(snapName)
+	require.NoError(t, err)
+	require.NoError(t, snapVol.EnsureMountPath())
+	require.NoError(t, d.createQcow2Snapshot(vol, snapVol))
+
+	return snapVol
+}
+
+// deleteQcow2TestSnapshot deletes a snapshot of the VM volume.
+func deleteQcow2TestSnapshot(t *testing.T, d *dir, vol Volume, snapName string) {
+	snapVol, err := vol.NewSnapshot(snapName)
+	require.NoError(t, err)
+	require.NoError(t, d.DeleteVolumeSnapshot(snapVol, nil))
+}
+
+// writeQcow2TestChunk fills the chunk at index of the disk image at path with value.
+func writeQcow2TestChunk(t *testing.T, path string, index int, value byte) {
+	_, err := shared.RunCommand("qemu-io", "-f", diskImageFormat(path), "-c", fmt.Sprintf("write -P %d %d %d", value, index*qcow2TestChunkSize, qcow2TestChunkSize), path)
+	require.NoError(t, err)
+}
+
+// qcow2TestContent returns the expected raw content of a disk with the given chunk values.
+func qcow2TestContent(chunks map[int]byte) []byte {
+	content := make([]byte, qcow2TestDiskSize)
+	for index, value := range chunks {
+		copy(content[index*qcow2TestChunkSize:], bytes.Repeat([]byte{value}, qcow2TestChunkSize))
+	}
+
+	return content
+}
+
+// readQcow2TestDisk returns the raw content of the disk image at path, including its backing images.
+func readQcow2TestDisk(t *testing.T, path string) []byte {
+	rawPath := filepath.Join(t.TempDir(), "disk.raw")
+	_, err := shared.RunCommand("qemu-img", "convert", "-U", "-f", diskImageFormat(path), "-O", "raw", path, rawPath)
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(rawPath)
+	require.NoError(t, err)
+
+	return content
+}
+
+// qcow2TestBackingFile returns the backing image of the qcow2 disk image at path.
+func qcow2TestBackingFile(t *testing.T, path string) string {
+	info, err := qcow2DiskImageInfo(path)
+	require.NoError(t, err)
+
+	return info.BackingFile
+}
+
+// Test deleting a snapshot in the middle of the backing chain of the VM's disk.
+func TestDirDeleteQcow2SnapshotMiddle(t *testing.T) {
+	d, vol := newQcow2TestVM(t)
+
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 0, 1)
+	snap0 := createQcow2TestSnapshot(t, d, vol, "snap0")
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 1, 2)
+	snap1 := createQcow2TestSnapshot(t, d, vol, "snap1")
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 2, 3)
+	createQcow2TestSnapshot(t, d, vol, "snap2")
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 3, 4)
+
+	// Chain: vm/root.qcow2 -> snap2/root.qcow2 -> snap1/root.qcow2 -> snap0/root.img.
+	deleteQcow2TestSnapshot(t, d, vol, "snap1")
+	assert.False(t, shared.PathExists(snap1.MountPath()))
+
+	// The snapshot's disk image was merged into the disk image of snap2, which now uses the one of snap0.
+	snap2, err := vol.NewSnapshot("snap2")
+	require.NoError(t, err)
+
+	snap2DiskPath := dirVolumeDiskPath(snap2.MountPath())
+	assert.Equal(t, filepath.Join(snap0.MountPath(), genericVolumeDiskFile), qcow2TestBackingFile(t, snap2DiskPath))
+	assert.Equal(t, snap2DiskPath, qcow2TestBackingFile(t, dirVolumeDiskPath(vol.MountPath())))
+
+	assert.Equal(t, qcow2TestContent(map[int]byte{0: 1}), readQcow2TestDisk(t, dirVolumeDiskPath(snap0.MountPath())))
+	assert.Equal(t, qcow2TestContent(map[int]byte{0: 1, 1: 2, 2: 3}), readQcow2TestDisk(t, snap2DiskPath))
+	assert.Equal(t, qcow2TestContent(map[int]byte{0: 1, 1: 2, 2: 3, 3: 4}), readQcow2TestDisk(t, dirVolumeDiskPath(vol.MountPath())))
+}
+
+// Test deleting the snapshot whose disk image is the direct backing image of the VM's disk.
+func TestDirDeleteQcow2SnapshotActiveParent(t *testing.T) {
+	d, vol := newQcow2TestVM(t)
+
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 0, 1)
+	createQcow2TestSnapshot(t, d, vol, "snap0")
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 1, 2)
+
+	// Chain: vm/root.qcow2 -> snap0/root.img.
+	deleteQcow2TestSnapshot(t, d, vol, "snap0")
+
+	// The VM's disk was merged into the snapshot's raw disk image, which took its place.
+	assert.Equal(t, filepath.Join(vol.MountPath(), genericVolumeDiskFile), dirVolumeDiskPath(vol.MountPath()))
+	assert.False(t, shared.PathExists(filepath.Join(vol.MountPath(), genericVolumeQcow2DiskFile)))
+	assert.Equal(t, qcow2TestContent(map[int]byte{0: 1, 1: 2}), readQcow2TestDisk(t, dirVolumeDiskPath(vol.MountPath())))
+
+	snapshots, err := d.VolumeSnapshots(vol, nil)
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+// Test deleting a snapshot whose disk image is the backing image of both another snapshot and the VM's disk.
+func TestDirDeleteQcow2SnapshotMultipleChildren(t *testing.T) {
+	d, vol := newQcow2TestVM(t)
+
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 0, 1)
+	snap0 := createQcow2TestSnapshot(t, d, vol, "snap0")
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 1, 2)
+	snap1 := createQcow2TestSnapshot(t, d, vol, "snap1")
+
+	// Restore snap0 by replacing the VM's disk with a new one based on snap0's disk image.
+	snap0DiskPath := dirVolumeDiskPath(snap0.MountPath())
+	diskPath := filepath.Join(vol.MountPath(), genericVolumeQcow2DiskFile)
+	require.NoError(t, os.Remove(diskPath))
+	require.NoError(t, d.createQcow2Overlay(diskPath, snap0DiskPath, qcow2TestDiskSize))
+	writeQcow2TestChunk(t, diskPath, 2, 3)
+
+	// Chains: vm/root.qcow2 -> snap0/root.img and snap1/root.qcow2 -> snap0/root.img.
+	deleteQcow2TestSnapshot(t, d, vol, "snap0")
+
+	// The VM's disk was merged into snap0's disk image which took its place, snap1 was made independent.
+	assert.Equal(t, filepath.Join(vol.MountPath(), genericVolumeDiskFile), dirVolumeDiskPath(vol.MountPath()))
+	assert.Equal(t, qcow2TestContent(map[int]byte{0: 1, 2: 3}), readQcow2TestDisk(t, dirVolumeDiskPath(vol.MountPath())))
+
+	snap1DiskPath := dirVolumeDiskPath(snap1.MountPath())
+	assert.Equal(t, "", qcow2TestBackingFile(t, snap1DiskPath))
+	assert.Equal(t, qcow2TestContent(map[int]byte{0: 1, 1: 2}), readQcow2TestDisk(t, snap1DiskPath))
+}
+
+// Test renaming a snapshot whose disk image is used as backing image.
+func TestDirRenameQcow2VolumeSnapshot(t *testing.T) {
+	d, vol := newQcow2TestVM(t)
+
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 0, 1)
+	snap0 := createQcow2TestSnapshot(t, d, vol, "snap0")
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 1, 2)
+	createQcow2TestSnapshot(t, d, vol, "snap1")
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 2, 3)
+
+	// Chain: vm/root.qcow2 -> snap1/root.qcow2 -> snap0/root.img.
+	require.NoError(t, d.RenameVolumeSnapshot(snap0, "renamed", nil))
+
+	renamed, err := vol.NewSnapshot("renamed")
+	require.NoError(t, err)
+	assert.False(t, shared.PathExists(snap0.MountPath()))
+
+	snap1, err := vol.NewSnapshot("snap1")
+	require.NoError(t, err)
+
+	// The disk image of snap1 now uses the renamed disk image.
+	snap1DiskPath := dirVolumeDiskPath(snap1.MountPath())
+	assert.Equal(t, filepath.Join(renamed.MountPath(), genericVolumeDiskFile), qcow2TestBackingFile(t, snap1DiskPath))
+	assert.Equal(t, qcow2TestContent(map[int]byte{0: 1, 1: 2}), readQcow2TestDisk(t, snap1DiskPath))
+	assert.Equal(t, qcow2TestContent(map[int]byte{0: 1, 1: 2, 2: 3}), readQcow2TestDisk(t, dirVolumeDiskPath(vol.MountPath())))
+
+	// Renaming the snapshot whose disk image is the backing image of the VM's disk updates the VM's disk.
+	require.NoError(t, d.RenameVolumeSnapshot(snap1, "renamed1", nil))
+
+	renamed1, err := vol.NewSnapshot("renamed1")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(renamed1.MountPath(), genericVolumeQcow2DiskFile), qcow2TestBackingFile(t, dirVolumeDiskPath(vol.MountPath())))
+	assert.Equal(t, qcow2TestContent(map[int]byte{0: 1, 1: 2, 2: 3}), readQcow2TestDisk(t, dirVolumeDiskPath(vol.MountPath())))
+}
+
+// Test reading the raw contents of a qcow2 disk image.
+func TestOpenRawDiskImage(t *testing.T) {
+	requireCommands(t, "qemu-nbd")
+	d, vol := newQcow2TestVM(t)
+
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 0, 1)
+	createQcow2TestSnapshot(t, d, vol, "snap0")
+	writeQcow2TestChunk(t, dirVolumeDiskPath(vol.MountPath()), 15, 2)
+
+	for _, path := range []string{filepath.Join(vol.MountPath(), genericVolumeQcow2DiskFile), filepath.Join(GetVolumeMountPath(d.name, VolumeTypeVM, "vm/snap0"), genericVolumeDiskFile)} {
+		from, cleanup, err := openRawDiskImage(path)
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadAll(from)
+		cleanup()
+		require.NoError(t, err)
+
+		assert.Equal(t, readQcow2TestDisk(t, path), content)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/lxc/lxd/lxd/backup"
 	"github.com/lxc/lxd/lxd/migration"
@@ -18,6 +19,7 @@ import (
 	"github.com/lxc/lxd/shared/instancewriter"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/units"
+	"github.com/lxc/lxd/shared/validate"
 )
 
 // CreateVolume creates an empty volume and can optionally fill it by executing the supplied
@@ -35,6 +37,15 @@ func (d *dir) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Oper
 	}
 	revert.Add(func() { _ = os.RemoveAll(volPath) })
 
+	// Set the compression before filling so that the initial data is compressed too.
+	compression := vol.ExpandedConfig("compression")
+	if compression != "" {
+		err = d.setCompression(vol, compression)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create sparse loopback file if volume is block.
 	rootBlockPath := ""
 	if vol.contentType == ContentTypeBlock {
@@ -93,7 +104,7 @@ func (d *dir) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Oper
 // CreateVolumeFromBackup restores a backup tarball onto the storage device.
 func (d *dir) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) (VolumePostHook, revert.Hook, error) {
 	// Run the generic backup unpacker
-	postHook, revertHook, err := genericVFSBackupUnpack(d.withoutGetVolID(), d.state.OS, vol, srcBackup.Snapshots, srcData, op)
+	postHook, revertHook, err := genericVFSBackupUnpack(d.withoutGetVolID(), d.state.OS, vol, srcBackup, srcData, op)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -142,17 +153,17 @@ func (d *dir) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots bool
 	}
 
 	// Run the generic copy.
-	return genericVFSCopyVolume(d, d.setupInitialQuota, vol, srcVol, srcSnapshots, false, allowInconsistent, op)
+	return genericVFSCopyVolume(d, d.initVolume, vol, srcVol, srcSnapshots, false, allowInconsistent, op)
 }
 
 // CreateVolumeFromMigration creates a volume being sent via a migration.
 func (d *dir) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, volTargetArgs migration.VolumeTargetArgs, preFiller *VolumeFiller, op *operations.Operation) error {
-	return genericVFSCreateVolumeFromMigration(d, d.setupInitialQuota, vol, conn, volTargetArgs, preFiller, op)
+	return genericVFSCreateVolumeFromMigration(d, d.initVolume, vol, conn, volTargetArgs, preFiller, op)
 }
 
 // RefreshVolume provides same-pool volume and specific snapshots syncing functionality.
 func (d *dir) RefreshVolume(vol Volume, srcVol Volume, srcSnapshots []Volume, allowInconsistent bool, op *operations.Operation) error {
-	return genericVFSCopyVolume(d, d.setupInitialQuota, vol, srcVol, srcSnapshots, true, allowInconsistent, op)
+	return genericVFSCopyVolume(d, d.initVolume, vol, srcVol, srcSnapshots, true, allowInconsistent, op)
 }
 
 // DeleteVolume deletes a volume of the storage device. If any snapshots of the volume remain then
@@ -181,7 +192,7 @@ func (d *dir) DeleteVolume(vol Volume, op *operations.Operation) error {
 	}
 
 	// Remove the project quota.
-	err = d.deleteQuota(volPath, volID)
+	err = d.deleteQuota(volPath, vol.volType, volID)
 	if err != nil {
 		return err
 	}
@@ -209,7 +220,20 @@ func (d *dir) HasVolume(vol Volume) bool {
 
 // ValidateVolume validates the supplied volume config. Optionally removes invalid keys from the volume's config.
 func (d *dir) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
-	return d.validateVolume(vol, nil, removeUnknownKeys)
+	rules := map[string]func(value string) error{
+		"compression": validate.Optional(validate.IsOneOf(btrfsCompressionAlgorithms...)),
+	}
+
+	err := d.validateVolume(vol, rules, removeUnknownKeys)
+	if err != nil {
+		return err
+	}
+
+	if vol.contentType != ContentTypeFS && vol.config["compression"] != "" {
+		return fmt.Errorf("compression can only be set on filesystem volumes")
+	}
+
+	return nil
 }
 
 // UpdateVolume applies config changes to the volume.
@@ -222,6 +246,19 @@ func (d *dir) UpdateVolume(vol Volume, changedConfig map[string]string) error {
 		}
 	}
 
+	// Only affects data written after the change.
+	newCompression, compressionChanged := changedConfig["compression"]
+	if compressionChanged {
+		if newCompression == "" {
+			newCompression = vol.poolConfig["volume.compression"]
+		}
+
+		err := d.setCompression(vol, newCompression)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -244,7 +281,7 @@ func (d *dir) GetVolumeUsage(vol Volume) (int64, error) {
 		return -1, err
 	}
 
-	projectID := d.quotaProjectID(volID)
+	projectID := d.quotaProjectID(vol.volType, volID)
 
 	// Get project quota used.
 	size, err := quota.GetProjectUsage(volPath, projectID)
@@ -283,8 +320,8 @@ func (d *dir) SetVolumeQuota(vol Volume, size string, allowUnsafeResize bool, op
 
 		// Move the GPT alt header to end of disk if needed and resize has taken place (not needed in
 		// unsafe resize mode as it is expected the caller will do all necessary post resize actions
-		// themselves).
-		if vol.IsVMBlock() && resized && !allowUnsafeResize {
+		// themselves). This can't be done on qcow2 disk images, so is left to the guest in that case.
+		if vol.IsVMBlock() && resized && !allowUnsafeResize && !IsQcow2DiskPath(rootBlockPath) {
 			err = d.moveGPTAltHeader(rootBlockPath)
 			if err != nil {
 				return err
@@ -302,9 +339,9 @@ func (d *dir) SetVolumeQuota(vol Volume, size string, allowUnsafeResize bool, op
 
 	// Custom handling for filesystem volume associated with a VM.
 	volPath := vol.MountPath()
-	if sizeBytes > 0 && vol.volType == VolumeTypeVM && shared.PathExists(filepath.Join(volPath, genericVolumeDiskFile)) {
+	if sizeBytes > 0 && vol.volType == VolumeTypeVM && shared.PathExists(dirVolumeDiskPath(volPath)) {
 		// Get the size of the VM image.
-		blockSize, err := BlockDiskSizeBytes(filepath.Join(volPath, genericVolumeDiskFile))
+		blockSize, err := BlockDiskSizeBytes(dirVolumeDiskPath(volPath))
 		if err != nil {
 			return err
 		}
@@ -314,11 +351,15 @@ func (d *dir) SetVolumeQuota(vol Volume, size string, allowUnsafeResize bool, op
 		d.logger.Debug("Accounting for VM image file size", logger.Ctx{"sizeBytes": sizeBytes})
 	}
 
-	return d.setQuota(vol.MountPath(), volID, sizeBytes)
+	return d.setQuota(vol.MountPath(), vol.volType, volID, sizeBytes)
 }
 
 // GetVolumeDiskPath returns the location of a disk volume.
 func (d *dir) GetVolumeDiskPath(vol Volume) (string, error) {
+	if vol.IsVMBlock() {
+		return dirVolumeDiskPath(vol.MountPath()), nil
+	}
+
 	return genericVFSGetVolumeDiskPath(vol)
 }
 
@@ -362,7 +403,63 @@ func (d *dir) UnmountVolume(vol Volume, keepBlockDev bool, op *operations.Operat
 
 // RenameVolume renames a volume and its snapshots.
 func (d *dir) RenameVolume(vol Volume, newVolName string, op *operations.Operation) error {
-	return genericVFSRenameVolume(d, vol, newVolName, op)
+	// Record the backing images of qcow2 disk images, as their paths change with the volume name.
+	backingFiles := map[string]string{}
+	if vol.IsVMBlock() {
+		paths, err := d.volumeDiskImages(vol)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			if !IsQcow2DiskPath(path) {
+				continue
+			}
+
+			info, err := qcow2DiskImageInfo(path)
+			if err != nil {
+				return err
+			}
+
+			if info.BackingFile != "" {
+				backingFiles[path] = info.BackingFile
+			}
+		}
+	}
+
+	err := genericVFSRenameVolume(d, vol, newVolName, op)
+	if err != nil {
+		return err
+	}
+
+	renamedPaths := map[string]string{
+		GetVolumeMountPath(d.name, vol.volType, vol.name):   GetVolumeMountPath(d.name, vol.volType, newVolName),
+		GetVolumeSnapshotDir(d.name, vol.volType, vol.name): GetVolumeSnapshotDir(d.name, vol.volType, newVolName),
+	}
+
+	renamedPath := func(path string) string {
+		for oldPrefix, newPrefix := range renamedPaths {
+			if strings.HasPrefix(path, oldPrefix+"/") {
+				return newPrefix + strings.TrimPrefix(path, oldPrefix)
+			}
+		}
+
+		return path
+	}
+
+	for path, backingFile := range backingFiles {
+		newBackingFile := renamedPath(backingFile)
+		if newBackingFile == backingFile {
+			continue
+		}
+
+		err = d.setDiskImageBackingFile(renamedPath(path), newBackingFile, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // MigrateVolume sends a volume for migration.
@@ -372,8 +469,8 @@ func (d *dir) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *mig
 
 // BackupVolume copies a volume (and optionally its snapshots) to a specified target path.
 // This driver does not support optimized backups.
-func (d *dir) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, op *operations.Operation) error {
-	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
+func (d *dir) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, baseSnapshot string, op *operations.Operation) error {
+	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, baseSnapshot, op)
 }
 
 // CreateVolumeSnapshot creates a snapshot of a volume.
@@ -396,7 +493,7 @@ func (d *dir) CreateVolumeSnapshot(snapVol Volume, op *operations.Operation) err
 		var rsyncArgs []string
 
 		if snapVol.IsVMBlock() {
-			rsyncArgs = append(rsyncArgs, "--exclude", genericVolumeDiskFile)
+			rsyncArgs = append(rsyncArgs, "--exclude", genericVolumeDiskFile, "--exclude", genericVolumeQcow2DiskFile)
 		}
 
 		bwlimit := d.config["rsync.bwlimit"]
@@ -410,8 +507,18 @@ func (d *dir) CreateVolumeSnapshot(snapVol Volume, op *operations.Operation) err
 		}
 	}
 
-	if snapVol.IsVMBlock() || (snapVol.contentType == ContentTypeBlock && snapVol.volType == VolumeTypeCustom) {
-		parentVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, parentName, nil, d.config)
+	parentVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, parentName, nil, d.config)
+
+	// Use qcow2 backing images for VM snapshots if enabled. A running VM can only be switched over to a new disk
+	// image through its live disk, so fallback to copying the disk otherwise.
+	if snapVol.IsVMBlock() && d.qcow2Snapshots() && (snapVol.liveDisk != nil || !parentVol.MountInUse()) {
+		d.Logger().Debug("Creating qcow2 block volume snapshot", logger.Ctx{"volName": snapVol.name, "live": snapVol.liveDisk != nil})
+
+		err = d.createQcow2Snapshot(parentVol, snapVol)
+		if err != nil {
+			return err
+		}
+	} else if snapVol.IsVMBlock() || (snapVol.contentType == ContentTypeBlock && snapVol.volType == VolumeTypeCustom) {
 		srcDevPath, err := d.GetVolumeDiskPath(parentVol)
 		if err != nil {
 			return err
@@ -443,6 +550,16 @@ func (d *dir) CreateVolumeSnapshot(snapVol Volume, op *operations.Operation) err
 // must be bare names and should not be in the format "volume/snapshot".
 func (d *dir) DeleteVolumeSnapshot(snapVol Volume, op *operations.Operation) error {
 	snapPath := snapVol.MountPath()
+	parentName, _, _ := shared.InstanceGetParentAndSnapshotName(snapVol.name)
+
+	// Take the snapshot's disk image out of any qcow2 backing chains using it.
+	if snapVol.IsVMBlock() {
+		parentVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, parentName, snapVol.config, snapVol.poolConfig)
+		err := d.deleteQcow2Snapshot(parentVol, snapVol)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Remove the snapshot from the storage device.
 	err := forceRemoveAll(snapPath)
@@ -450,8 +567,6 @@ func (d *dir) DeleteVolumeSnapshot(snapVol Volume, op *operations.Operation) err
 		return fmt.Errorf("Failed to remove '%s': %w", snapPath, err)
 	}
 
-	parentName, _, _ := shared.InstanceGetParentAndSnapshotName(snapVol.name)
-
 	// Remove the parent snapshot directory if this is the last snapshot being removed.
 	err = deleteParentSnapshotDirIfEmpty(d.name, snapVol.volType, parentName)
 	if err != nil {
@@ -532,7 +647,7 @@ func (d *dir) RestoreVolume(vol Volume, snapshotName string, op *operations.Oper
 		var rsyncArgs []string
 
 		if vol.IsVMBlock() {
-			rsyncArgs = append(rsyncArgs, "--exclude", genericVolumeDiskFile)
+			rsyncArgs = append(rsyncArgs, "--exclude", genericVolumeDiskFile, "--exclude", genericVolumeQcow2DiskFile)
 		}
 
 		bwlimit := d.config["rsync.bwlimit"]
@@ -554,6 +669,34 @@ func (d *dir) RestoreVolume(vol Volume, snapshotName string, op *operations.Oper
 			return err
 		}
 
+		// With qcow2 snapshots, restore by creating a new qcow2 disk image on top of the snapshot's one.
+		if vol.IsVMBlock() && (d.qcow2Snapshots() || IsQcow2DiskPath(srcDevPath)) {
+			overlayPath := filepath.Join(volPath, genericVolumeQcow2DiskFile)
+			d.Logger().Debug("Restoring qcow2 block volume", logger.Ctx{"srcDevPath": srcDevPath, "targetPath": overlayPath})
+
+			sizeBytes, err := BlockDiskSizeBytes(srcDevPath)
+			if err != nil {
+				return err
+			}
+
+			err = os.Remove(targetDevPath)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("Failed removing disk image %q: %w", targetDevPath, err)
+			}
+
+			return d.createQcow2Overlay(overlayPath, srcDevPath, sizeBytes)
+		}
+
+		// Replace a qcow2 disk image with a raw one.
+		if IsQcow2DiskPath(targetDevPath) {
+			err = os.Remove(targetDevPath)
+			if err != nil {
+				return fmt.Errorf("Failed removing disk image %q: %w", targetDevPath, err)
+			}
+
+			targetDevPath = filepath.Join(volPath, genericVolumeDiskFile)
+		}
+
 		d.Logger().Debug("Restoring block volume", logger.Ctx{"srcDevPath": srcDevPath, "targetPath": targetDevPath})
 
 		err = ensureSparseFile(targetDevPath, 0)
@@ -572,5 +715,52 @@ func (d *dir) RestoreVolume(vol Volume, snapshotName string, op *operations.Oper
 
 // RenameVolumeSnapshot renames a volume snapshot.
 func (d *dir) RenameVolumeSnapshot(snapVol Volume, newSnapshotName string, op *operations.Operation) error {
-	return genericVFSRenameVolumeSnapshot(d, snapVol, newSnapshotName, op)
+	if !snapVol.IsVMBlock() {
+		return genericVFSRenameVolumeSnapshot(d, snapVol, newSnapshotName, op)
+	}
+
+	parentName, _, _ := shared.InstanceGetParentAndSnapshotName(snapVol.name)
+	parentVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, parentName, snapVol.config, snapVol.poolConfig)
+
+	// Find the qcow2 disk images using the snapshot's disk image as backing image before it moves.
+	snapDiskPath := dirVolumeDiskPath(snapVol.MountPath())
+	children, err := d.diskImageChildren(parentVol, snapDiskPath)
+	if err != nil {
+		return err
+	}
+
+	childLiveDisks := make(map[string]LiveDisk, len(children))
+	if len(children) > 0 {
+		active, err := d.activeDiskImages(parentVol)
+		if err != nil {
+			return err
+		}
+
+		for _, childPath := range children {
+			childLiveDisks[childPath], err = d.activeDiskImageLiveDisk(parentVol, snapVol.liveDisk, active, childPath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = genericVFSRenameVolumeSnapshot(d, snapVol, newSnapshotName, op)
+	if err != nil {
+		return err
+	}
+
+	newSnapVol, err := parentVol.NewSnapshot(newSnapshotName)
+	if err != nil {
+		return err
+	}
+
+	newSnapDiskPath := filepath.Join(newSnapVol.MountPath(), filepath.Base(snapDiskPath))
+	for _, childPath := range children {
+		err = d.setDiskImageBackingFile(childPath, newSnapDiskPath, childLiveDisks[childPath])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
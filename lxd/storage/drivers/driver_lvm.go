@@ -35,6 +35,7 @@ func (d *lvm) load() error {
 	d.patches = map[string]func() error{
 		"storage_lvm_skipactivation":       d.patchStorageSkipActivation,
 		"storage_missing_snapshot_records": nil,
+		"storage_create_buckets_dataset":   nil,
 	}
 
 	// Done if previously loaded.
@@ -88,11 +89,12 @@ func (d *lvm) Info() Info {
 		OptimizedImages:   d.usesThinpool(), // Only thinpool pools support optimized images.
 		PreservesInodes:   false,
 		Remote:            d.isRemote(),
-		VolumeTypes:       []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
+		VolumeTypes:       []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM, VolumeTypeBucket},
 		BlockBacking:      true,
 		RunningCopyFreeze: true,
 		DirectIO:          true,
 		MountedRoot:       false,
+		Buckets:           true,
 	}
 }
 
@@ -453,6 +455,9 @@ func (d *lvm) Validate(config map[string]string) error {
 		"volume.lvm.stripes":         validate.Optional(validate.IsUint32),
 		"volume.lvm.stripes.size":    validate.Optional(validate.IsSize),
 		"lvm.vg.force_reuse":         validate.Optional(validate.IsBool),
+		"volume.overcommit_ratio":    validate.Optional(validateOvercommitRatio),
+		"fill_threshold.warning":     validate.Optional(validate.IsInRange(1, 100)),
+		"fill_threshold.critical":    validate.Optional(validate.IsInRange(1, 100)),
 	}
 
 	err := d.validatePool(config, rules)
@@ -460,6 +465,11 @@ func (d *lvm) Validate(config map[string]string) error {
 		return err
 	}
 
+	err = validateFillThresholds(config)
+	if err != nil {
+		return err
+	}
+
 	if shared.IsFalse(config["lvm.use_thinpool"]) {
 		if config["lvm.thinpool_name"] != "" {
 			return fmt.Errorf("The key lvm.use_thinpool cannot be set to false when lvm.thinpool_name is set")
@@ -468,6 +478,10 @@ func (d *lvm) Validate(config map[string]string) error {
 		if config["lvm.thinpool_metadata_size"] != "" {
 			return fmt.Errorf("The key lvm.use_thinpool cannot be set to false when lvm.thinpool_metadata_size is set")
 		}
+
+		if config["volume.overcommit_ratio"] != "" {
+			return fmt.Errorf("The key lvm.use_thinpool cannot be set to false when volume.overcommit_ratio is set")
+		}
 	}
 
 	return nil
@@ -597,6 +611,13 @@ func (d *lvm) GetResources() (*api.ResourcesStoragePool, error) {
 
 		res.Space.Total = totalSize
 		res.Space.Used = usedSize
+
+		provisionedSize, err := d.thinPoolProvisionedSize(d.config["lvm.vg_name"], d.thinpoolName())
+		if err != nil {
+			return nil, err
+		}
+
+		res.Space.Provisioned = provisionedSize
 	} else {
 		// If thinpools are not in use, calculate used space in volume group.
 		args := []string{
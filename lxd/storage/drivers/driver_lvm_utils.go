@@ -329,6 +329,11 @@ func (d *lvm) createLogicalVolume(vgName, thinPoolName string, vol Volume, makeT
 	}
 
 	if makeThinLv {
+		err = d.checkVolumeOvercommit(lvSizeBytes)
+		if err != nil {
+			return err
+		}
+
 		targetVg := fmt.Sprintf("%s/%s", vgName, thinPoolName)
 		args = append(args,
 			"--thin",
@@ -398,6 +403,19 @@ func (d *lvm) createLogicalVolumeSnapshot(vgName string, srcVol Volume, snapVol
 		return "", fmt.Errorf("Error checking LVM version: %w", err)
 	}
 
+	// Thin snapshots provision the full size of their origin in the thin pool.
+	if makeThinLv {
+		srcSizeBytes, err := d.logicalVolumeSize(srcVolDevPath)
+		if err != nil {
+			return "", err
+		}
+
+		err = d.checkVolumeOvercommit(srcSizeBytes)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	snapLvName := d.lvmFullVolumeName(snapVol.volType, snapVol.contentType, snapVol.name)
 	logCtx := logger.Ctx{"vg_name": vgName, "lv_name": snapLvName, "src_dev": srcVolDevPath, "thin": makeThinLv}
 	args := []string{"-n", snapLvName, "-s", srcVolDevPath}
@@ -699,6 +717,56 @@ func (d *lvm) thinPoolVolumeUsage(volDevPath string) (uint64, uint64, error) {
 	return totalSize, usedSize, nil
 }
 
+// thinPoolProvisionedSize returns the sum of the virtual sizes of the thin logical volumes in the thin pool.
+// Snapshots are included as they can grow to the size of their origin.
+func (d *lvm) thinPoolProvisionedSize(vgName string, thinPoolName string) (uint64, error) {
+	args := []string{
+		vgName,
+		"--noheadings",
+		"--units", "b",
+		"--nosuffix",
+		"-o", "lv_size",
+		"--select", fmt.Sprintf("pool_lv=%s", thinPoolName),
+	}
+
+	out, err := shared.RunCommand("lvs", args...)
+	if err != nil {
+		return 0, err
+	}
+
+	var provisioned uint64
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		size, err := strconv.ParseUint(line, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Unexpected output from lvs command: %w", err)
+		}
+
+		provisioned += size
+	}
+
+	return provisioned, nil
+}
+
+// checkVolumeOvercommit returns ErrOvercommit if growing the space provisioned in the thin pool by extraBytes
+// would exceed the pool's "volume.overcommit_ratio" setting. Thick volumes can't overcommit so aren't checked.
+func (d *lvm) checkVolumeOvercommit(extraBytes int64) error {
+	if d.config["volume.overcommit_ratio"] == "" || !d.usesThinpool() || extraBytes <= 0 {
+		return nil
+	}
+
+	res, err := d.GetResources()
+	if err != nil {
+		return fmt.Errorf("Failed getting pool usage: %w", err)
+	}
+
+	return checkOvercommit(d.config, res, extraBytes)
+}
+
 // parseLogicalVolumeSnapshot parses a raw logical volume name (from lvs command) and checks whether it is a
 // snapshot of the supplied parent volume. Returns unescaped parsed snapshot name if snapshot volume recognised,
 // empty string if not. The parent is required due to limitations in the naming scheme that LXD has historically
@@ -15,6 +15,28 @@ type Info struct {
 	RunningCopyFreeze     bool         // Whether instance should be frozen during snapshot if running.
 	DirectIO              bool         // Whether the driver supports direct I/O.
 	MountedRoot           bool         // Whether the pool directory itself is a mount.
+	Buckets               bool         // Whether the driver supports S3 storage buckets.
+	Deduplication         bool         // Whether the driver supports offline deduplication of volume data.
+	LiveVMSnapshots       bool         // Whether running VMs can be snapshotted without being frozen.
+}
+
+// LiveDisk represents a running instance whose disk backing chain can be changed by the storage driver.
+type LiveDisk interface {
+	// BlockSnapshot makes the (already created) qcow2 disk image at overlayPath the new active layer of the disk.
+	BlockSnapshot(overlayPath string) error
+
+	// BlockCommit merges the disk image at topPath into the disk image at basePath. The image above topPath
+	// then uses backingFile as the path of its backing image.
+	BlockCommit(topPath string, basePath string, backingFile string) error
+
+	// BlockChangeBackingFile changes the backing image path recorded in the disk image at path.
+	BlockChangeBackingFile(path string, backingFile string) error
+}
+
+// VolumeQuotaReporter is implemented by storage drivers that can report the size limit of a filesystem volume.
+type VolumeQuotaReporter interface {
+	// GetVolumeQuota returns the size limit in bytes applied to the filesystem volume, or 0 if it has none.
+	GetVolumeQuota(vol Volume) (int64, error)
 }
 
 // VolumeFiller provides a struct for filling a volume.
@@ -13,6 +13,7 @@ import (
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/units"
 	"github.com/lxc/lxd/shared/validate"
 	"github.com/lxc/lxd/shared/version"
@@ -43,6 +44,7 @@ func (d *zfs) load() error {
 	d.patches = map[string]func() error{
 		"storage_lvm_skipactivation":       nil,
 		"storage_missing_snapshot_records": nil,
+		"storage_create_buckets_dataset":   d.patchStorageCreateBucketsDataset,
 	}
 
 	// Done if previously loaded.
@@ -114,11 +116,12 @@ func (d *zfs) Info() Info {
 		OptimizedBackups:  true,
 		PreservesInodes:   true,
 		Remote:            d.isRemote(),
-		VolumeTypes:       []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
+		VolumeTypes:       []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM, VolumeTypeBucket},
 		BlockBacking:      false,
 		RunningCopyFreeze: false,
 		DirectIO:          zfsDirectIO,
 		MountedRoot:       false,
+		Buckets:           true,
 	}
 
 	return info
@@ -370,9 +373,17 @@ func (d *zfs) Validate(config map[string]string) error {
 		"volume.zfs.remove_snapshots": validate.Optional(validate.IsBool),
 		"volume.zfs.use_refquota":     validate.Optional(validate.IsBool),
 		"volume.zfs.reserve_space":    validate.Optional(validate.IsBool),
+		"volume.overcommit_ratio":     validate.Optional(validateOvercommitRatio),
+		"fill_threshold.warning":      validate.Optional(validate.IsInRange(1, 100)),
+		"fill_threshold.critical":     validate.Optional(validate.IsInRange(1, 100)),
 	}
 
-	return d.validatePool(config, rules)
+	err := d.validatePool(config, rules)
+	if err != nil {
+		return err
+	}
+
+	return validateFillThresholds(config)
 }
 
 // Update applies any driver changes required from a configuration change.
@@ -480,6 +491,14 @@ func (d *zfs) GetResources() (*api.ResourcesStoragePool, error) {
 	res.Space.Total = used + available
 	res.Space.Used = used
 
+	// Still report the pool usage if the provisioned space can't be determined.
+	provisioned, err := d.provisionedSize()
+	if err != nil {
+		d.logger.Warn("Failed getting provisioned space", logger.Ctx{"err": err})
+	} else {
+		res.Space.Provisioned = provisioned
+	}
+
 	return &res, nil
 }
 
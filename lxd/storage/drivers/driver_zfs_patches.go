@@ -1 +1,22 @@
 package drivers
+
+import (
+	"path/filepath"
+)
+
+// patchStorageCreateBucketsDataset creates the parent datasets used for bucket volumes on existing pools.
+func (d *zfs) patchStorageCreateBucketsDataset() error {
+	for _, dataset := range []string{BaseDirectories[VolumeTypeBucket][0], filepath.Join("deleted", BaseDirectories[VolumeTypeBucket][0])} {
+		datasetPath := filepath.Join(d.config["zfs.pool_name"], dataset)
+		if d.checkDataset(datasetPath) {
+			continue
+		}
+
+		err := d.createDataset(datasetPath, "mountpoint=legacy")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
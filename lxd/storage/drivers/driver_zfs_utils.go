@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/pborman/uuid"
@@ -72,6 +73,11 @@ func (d *zfs) createDataset(dataset string, options ...string) error {
 func (d *zfs) createVolume(dataset string, size int64, options ...string) error {
 	size = roundVolumeBlockFileSizeBytes(size)
 
+	err := d.checkVolumeOvercommit(size)
+	if err != nil {
+		return err
+	}
+
 	args := []string{"create", "-s", "-V", fmt.Sprintf("%d", size)}
 	for _, option := range options {
 		args = append(args, "-o")
@@ -79,7 +85,7 @@ func (d *zfs) createVolume(dataset string, size int64, options ...string) error
 	}
 	args = append(args, dataset)
 
-	_, err := shared.RunCommand("zfs", args...)
+	_, err = shared.RunCommand("zfs", args...)
 	if err != nil {
 		return err
 	}
@@ -244,6 +250,129 @@ func (d *zfs) getDatasetProperty(dataset string, key string) (string, error) {
 	return strings.TrimSpace(output), nil
 }
 
+// provisionedSize returns the space promised to the volumes of the pool. This is the size of the volume datasets
+// and the quota of the filesystem datasets, as filesystems without a quota aren't bounded.
+func (d *zfs) provisionedSize() (uint64, error) {
+	out, err := shared.RunCommand("zfs", "list", "-H", "-p", "-r", "-t", "filesystem,volume", "-o", "type,volsize,quota,refquota", d.config["zfs.pool_name"])
+	if err != nil {
+		return 0, err
+	}
+
+	parseSize := func(value string) (uint64, error) {
+		if value == "-" || value == "none" {
+			return 0, nil
+		}
+
+		return strconv.ParseUint(value, 10, 64)
+	}
+
+	var provisioned uint64
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			continue
+		}
+
+		if fields[0] == "volume" {
+			volSize, err := parseSize(fields[1])
+			if err != nil {
+				return 0, err
+			}
+
+			provisioned += volSize
+			continue
+		}
+
+		var quota uint64
+		for _, value := range fields[2:] {
+			size, err := parseSize(value)
+			if err != nil {
+				return 0, err
+			}
+
+			if size > quota {
+				quota = size
+			}
+		}
+
+		provisioned += quota
+	}
+
+	return provisioned, nil
+}
+
+// datasetQuota returns the larger of the quota and refquota of a filesystem dataset, or 0 if it has none.
+func (d *zfs) datasetQuota(dataset string) (int64, error) {
+	var quota int64
+	for _, key := range []string{"quota", "refquota"} {
+		value, err := d.getDatasetProperty(dataset, key)
+		if err != nil {
+			return 0, err
+		}
+
+		if value == "-" || value == "none" {
+			continue
+		}
+
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		if size > quota {
+			quota = size
+		}
+	}
+
+	return quota, nil
+}
+
+// checkVolumeOvercommit returns ErrOvercommit if growing the space provisioned on the pool by extraBytes would
+// exceed the pool's "volume.overcommit_ratio" setting.
+func (d *zfs) checkVolumeOvercommit(extraBytes int64) error {
+	if d.config["volume.overcommit_ratio"] == "" || extraBytes <= 0 {
+		return nil
+	}
+
+	res, err := d.GetResources()
+	if err != nil {
+		return fmt.Errorf("Failed getting pool usage: %w", err)
+	}
+
+	// Don't rely on the provisioned space reported by GetResources as it is left out when it can't be determined.
+	res.Space.Provisioned, err = d.provisionedSize()
+	if err != nil {
+		return fmt.Errorf("Failed getting provisioned space: %w", err)
+	}
+
+	return checkOvercommit(d.config, res, extraBytes)
+}
+
+// copyProvisionedSize returns the space provisioned by a copy of srcVol before any quota is applied to it.
+// Block volumes keep the size of their source, filesystem volumes only keep its quota when its properties are
+// copied along (withProperties).
+func (d *zfs) copyProvisionedSize(srcVol Volume, withProperties bool) (int64, error) {
+	dataset := d.dataset(srcVol, false)
+	if srcVol.volType == VolumeTypeImage {
+		dataset = fmt.Sprintf("%s@readonly", dataset)
+	}
+
+	if srcVol.contentType == ContentTypeBlock {
+		volSize, err := d.getDatasetProperty(dataset, "volsize")
+		if err != nil {
+			return 0, err
+		}
+
+		return strconv.ParseInt(volSize, 10, 64)
+	}
+
+	if !withProperties {
+		return 0, nil
+	}
+
+	return d.datasetQuota(dataset)
+}
+
 // version returns the ZFS version based on package or kernel module version.
 func (d *zfs) version() (string, error) {
 	// This function is only really ever relevant on Ubuntu as the only
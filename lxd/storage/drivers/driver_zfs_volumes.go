@@ -295,7 +295,7 @@ func (d *zfs) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Oper
 func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) (VolumePostHook, revert.Hook, error) {
 	// Handle the non-optimized tarballs through the generic unpacker.
 	if !*srcBackup.OptimizedStorage {
-		return genericVFSBackupUnpack(d, d.state.OS, vol, srcBackup.Snapshots, srcData, op)
+		return genericVFSBackupUnpack(d, d.state.OS, vol, srcBackup, srcData, op)
 	}
 
 	if d.HasVolume(vol) {
@@ -307,11 +307,14 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 
 	// Define a revert function that will be used both to revert if an error occurs inside this
 	// function but also return it for use from the calling functions if no error internally.
+	entries := srcBackup.ChainEntries(srcData)
 	revertHook := func() {
-		for _, snapName := range srcBackup.Snapshots {
-			fullSnapshotName := GetSnapshotVolumeName(vol.name, snapName)
-			snapVol := NewVolume(d, d.name, vol.volType, vol.contentType, fullSnapshotName, vol.config, vol.poolConfig)
-			_ = d.DeleteVolumeSnapshot(snapVol, op)
+		for _, entry := range entries {
+			for _, snapName := range entry.Info.Snapshots {
+				fullSnapshotName := GetSnapshotVolumeName(vol.name, snapName)
+				snapVol := NewVolume(d, d.name, vol.volType, vol.contentType, fullSnapshotName, vol.config, vol.poolConfig)
+				_ = d.DeleteVolumeSnapshot(snapVol, op)
+			}
 		}
 
 		// And lastly the main volume.
@@ -372,71 +375,95 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 	vols = append(vols, vol)
 
 	for _, v := range vols {
-		// Find the compression algorithm used for backup source data.
-		_, err := srcData.Seek(0, 0)
-		if err != nil {
-			return nil, nil, err
-		}
+		var restoredSnapshots []string
 
-		_, _, unpacker, err := shared.DetectCompressionFile(srcData)
-		if err != nil {
-			return nil, nil, err
-		}
+		// Restore each backup in the chain (oldest first). Incremental backups are received on top of the
+		// snapshots restored from the previous backups and the volume itself is only restored from the last one.
+		for i, entry := range entries {
+			// Find the compression algorithm used for backup source data.
+			_, err := entry.Data.Seek(0, 0)
+			if err != nil {
+				return nil, nil, err
+			}
 
-		if len(srcBackup.Snapshots) > 0 {
-			// Create new snapshots directory.
-			err := createParentSnapshotDirIfMissing(d.name, v.volType, v.name)
+			_, _, unpacker, err := shared.DetectCompressionFile(entry.Data)
 			if err != nil {
 				return nil, nil, err
 			}
-		}
 
-		// Restore backups from oldest to newest.
-		for _, snapName := range srcBackup.Snapshots {
-			prefix := "snapshots"
-			fileName := fmt.Sprintf("%s.bin", snapName)
+			if len(entry.Info.Snapshots) > 0 {
+				// Create new snapshots directory.
+				err := createParentSnapshotDirIfMissing(d.name, v.volType, v.name)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+
+			// Restore backups from oldest to newest.
+			for _, snapName := range entry.Info.Snapshots {
+				prefix := "snapshots"
+				fileName := fmt.Sprintf("%s.bin", snapName)
+				if v.volType == VolumeTypeVM {
+					prefix = "virtual-machine-snapshots"
+					if v.contentType == ContentTypeFS {
+						fileName = fmt.Sprintf("%s-config.bin", snapName)
+					}
+				} else if v.volType == VolumeTypeCustom {
+					prefix = "volume-snapshots"
+				}
+
+				srcFile := fmt.Sprintf("backup/%s/%s", prefix, fileName)
+				dstSnapshot := fmt.Sprintf("%s@snapshot-%s", d.dataset(v, false), snapName)
+				err = unpackVolume(v, entry.Data, unpacker, srcFile, dstSnapshot)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				restoredSnapshots = append(restoredSnapshots, snapName)
+			}
+
+			if i < len(entries)-1 {
+				continue
+			}
+
+			// Extract main volume.
+			fileName := "container.bin"
 			if v.volType == VolumeTypeVM {
-				prefix = "virtual-machine-snapshots"
 				if v.contentType == ContentTypeFS {
-					fileName = fmt.Sprintf("%s-config.bin", snapName)
+					fileName = "virtual-machine-config.bin"
+				} else {
+					fileName = "virtual-machine.bin"
 				}
 			} else if v.volType == VolumeTypeCustom {
-				prefix = "volume-snapshots"
+				fileName = "volume.bin"
 			}
 
-			srcFile := fmt.Sprintf("backup/%s/%s", prefix, fileName)
-			dstSnapshot := fmt.Sprintf("%s@snapshot-%s", d.dataset(v, false), snapName)
-			err = unpackVolume(v, srcData, unpacker, srcFile, dstSnapshot)
+			err = unpackVolume(v, entry.Data, unpacker, fmt.Sprintf("backup/%s", fileName), d.dataset(v, false))
 			if err != nil {
 				return nil, nil, err
 			}
 		}
 
-		// Extract main volume.
-		fileName := "container.bin"
-		if v.volType == VolumeTypeVM {
-			if v.contentType == ContentTypeFS {
-				fileName = "virtual-machine-config.bin"
-			} else {
-				fileName = "virtual-machine.bin"
+		// Remove the snapshots that were only needed to apply the incremental backups.
+		for _, snapName := range restoredSnapshots {
+			if shared.StringInSlice(snapName, srcBackup.Snapshots) {
+				continue
 			}
-		} else if v.volType == VolumeTypeCustom {
-			fileName = "volume.bin"
-		}
 
-		err = unpackVolume(v, srcData, unpacker, fmt.Sprintf("backup/%s", fileName), d.dataset(v, false))
-		if err != nil {
-			return nil, nil, err
+			_, err := shared.RunCommand("zfs", "destroy", fmt.Sprintf("%s@snapshot-%s", d.dataset(v, false), snapName))
+			if err != nil {
+				return nil, nil, err
+			}
 		}
 
 		// Strip internal snapshots.
-		entries, err := d.getDatasets(d.dataset(v, false))
+		datasets, err := d.getDatasets(d.dataset(v, false))
 		if err != nil {
 			return nil, nil, err
 		}
 
 		// Filter only the snapshots.
-		for _, entry := range entries {
+		for _, entry := range datasets {
 			if strings.HasPrefix(entry, "@snapshot-") {
 				continue
 			}
@@ -525,6 +552,17 @@ func (d *zfs) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots bool
 		}
 	}
 
+	// Clones and full copies both provision the space of their source.
+	copySizeBytes, err := d.copyProvisionedSize(srcVol, len(snapshots) > 0)
+	if err != nil {
+		return err
+	}
+
+	err = d.checkVolumeOvercommit(copySizeBytes)
+	if err != nil {
+		return err
+	}
+
 	var srcSnapshot string
 	if srcVol.volType == VolumeTypeImage {
 		srcSnapshot = fmt.Sprintf("%s@readonly", d.dataset(srcVol, false))
@@ -704,7 +742,7 @@ func (d *zfs) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots bool
 
 	// Resize volume to the size specified. Only uses volume "size" property and does not use pool/defaults
 	// to give the caller more control over the size being used.
-	err := d.SetVolumeQuota(vol, vol.config["size"], false, op)
+	err = d.SetVolumeQuota(vol, vol.config["size"], false, op)
 	if err != nil {
 		return err
 	}
@@ -1352,6 +1390,11 @@ func (d *zfs) GetVolumeUsage(vol Volume) (int64, error) {
 	return valueInt, nil
 }
 
+// GetVolumeQuota returns the quota in bytes applied to a filesystem volume's dataset, or 0 if it has none.
+func (d *zfs) GetVolumeQuota(vol Volume) (int64, error) {
+	return d.datasetQuota(d.dataset(vol, false))
+}
+
 // SetVolumeQuota sets the quota/reservation on the volume.
 // Does nothing if supplied with an empty/zero size for block volumes
 func (d *zfs) SetVolumeQuota(vol Volume, size string, allowUnsafeResize bool, op *operations.Operation) error {
@@ -1404,6 +1447,11 @@ func (d *zfs) SetVolumeQuota(vol Volume, size string, allowUnsafeResize bool, op
 			}
 		}
 
+		err = d.checkVolumeOvercommit(sizeBytes - oldVolSizeBytes)
+		if err != nil {
+			return err
+		}
+
 		err = d.setDatasetProperties(d.dataset(vol, false), fmt.Sprintf("volsize=%d", sizeBytes))
 		if err != nil {
 			return err
@@ -1428,6 +1476,19 @@ func (d *zfs) SetVolumeQuota(vol Volume, size string, allowUnsafeResize bool, op
 		return nil
 	}
 
+	// Check the pool can take the quota growth before clearing the existing quota.
+	if sizeBytes > 0 {
+		oldQuotaBytes, err := d.datasetQuota(d.dataset(vol, false))
+		if err != nil {
+			return err
+		}
+
+		err = d.checkVolumeOvercommit(sizeBytes - oldQuotaBytes)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Clear the existing quota.
 	for _, property := range []string{"quota", "refquota", "reservation", "refreservation"} {
 		err = d.setDatasetProperties(d.dataset(vol, false), fmt.Sprintf("%s=none", property))
@@ -2104,7 +2165,7 @@ func (d *zfs) readonlySnapshot(vol Volume) (string, revert.Hook, error) {
 }
 
 // BackupVolume creates an exported version of a volume.
-func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, op *operations.Operation) error {
+func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, baseSnapshot string, op *operations.Operation) error {
 	// Handle the non-optimized tarballs through the generic packer.
 	if !optimized {
 		// Because the generic backup method will not take a consistent backup if files are being modified
@@ -2123,12 +2184,18 @@ func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWrit
 			vol.mountCustomPath = snapshotPath
 		}
 
-		return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
+		return genericVFSBackupVolume(d, vol, tarWriter, snapshots, baseSnapshot, op)
 	}
 
 	// Optimized backup.
 
-	if len(snapshots) > 0 {
+	if baseSnapshot != "" {
+		// Check base and requested snapshots exist in storage.
+		err := vol.SnapshotsExist(append([]string{baseSnapshot}, snapshots...), op)
+		if err != nil {
+			return err
+		}
+	} else if len(snapshots) > 0 {
 		// Check requested snapshot match those in storage.
 		err := vol.SnapshotsMatch(snapshots, op)
 		if err != nil {
@@ -2139,7 +2206,7 @@ func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWrit
 	// Backup VM config volumes first.
 	if vol.IsVMBlock() {
 		fsVol := vol.NewVMBlockFilesystemVolume()
-		err := d.BackupVolume(fsVol, tarWriter, optimized, snapshots, op)
+		err := d.BackupVolume(fsVol, tarWriter, optimized, snapshots, baseSnapshot, op)
 		if err != nil {
 			return err
 		}
@@ -2187,13 +2254,19 @@ func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWrit
 	}
 
 	// Handle snapshots.
+	// For incremental backups the streams are generated relative to the base snapshot.
 	finalParent := ""
+	if baseSnapshot != "" {
+		baseVol, _ := vol.NewSnapshot(baseSnapshot)
+		finalParent = d.dataset(baseVol, false)
+	}
+
 	if len(snapshots) > 0 {
 		for i, snapName := range snapshots {
 			snapshot, _ := vol.NewSnapshot(snapName)
 
 			// Figure out parent and current subvolumes.
-			parent := ""
+			parent := finalParent
 			if i > 0 {
 				oldSnapshot, _ := vol.NewSnapshot(snapshots[i-1])
 				parent = d.dataset(oldSnapshot, false)
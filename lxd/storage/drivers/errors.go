@@ -19,6 +19,9 @@ var ErrCannotBeShrunk = fmt.Errorf("Cannot be shrunk")
 // ErrInUse indicates operation cannot proceed as resource is in use.
 var ErrInUse = fmt.Errorf("In use")
 
+// ErrOvercommit indicates the operation would provision more space than the pool's overcommit ratio allows.
+var ErrOvercommit = fmt.Errorf("Pool overcommit ratio exceeded")
+
 // ErrSnapshotDoesNotMatchIncrementalSource in the "Snapshot does not match incremental source" error
 var ErrSnapshotDoesNotMatchIncrementalSource = fmt.Errorf("Snapshot does not match incremental source")
 
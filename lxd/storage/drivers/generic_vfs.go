@@ -1,16 +1,22 @@
 package drivers
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/lxc/lxd/lxd/archive"
+	"github.com/lxc/lxd/lxd/backup"
 	"github.com/lxc/lxd/lxd/migration"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/revert"
@@ -31,6 +37,21 @@ const genericVolumeBlockExtension = "img"
 // genericVolumeDiskFile used to indicate the file name used for block volume disk files.
 const genericVolumeDiskFile = "root.img"
 
+// genericVolumeQcow2DiskFile used to indicate the file name used for qcow2 block volume disk files.
+const genericVolumeQcow2DiskFile = "root.qcow2"
+
+// genericVolumeDeltaExtension extension used for block volume deltas in incremental backups.
+const genericVolumeDeltaExtension = "delta"
+
+// genericVolumeDeletedExtension extension used for the list of removed files in incremental backups.
+const genericVolumeDeletedExtension = "deleted"
+
+// genericVolumeDeltaMagic is the header used to identify block volume delta files.
+const genericVolumeDeltaMagic = "LXDDELTA"
+
+// genericVolumeDeltaChunkSize is the size of the chunks that are compared when generating block volume deltas.
+const genericVolumeDeltaChunkSize = 1024 * 1024
+
 // genericVFSGetResources is a generic GetResources implementation for VFS-only drivers.
 func genericVFSGetResources(d Driver) (*api.ResourcesStoragePool, error) {
 	// Get the VFS information
@@ -154,7 +175,7 @@ func genericVFSMigrateVolume(d Driver, s *state.State, vol Volume, conn io.ReadW
 			return ErrNotSupported
 		}
 
-		rsyncArgs = []string{"--exclude", genericVolumeDiskFile}
+		rsyncArgs = []string{"--exclude", genericVolumeDiskFile, "--exclude", genericVolumeQcow2DiskFile}
 	} else if vol.contentType == ContentTypeBlock && volSrcArgs.MigrationType.FSType != migration.MigrationFSType_BLOCK_AND_RSYNC || vol.contentType == ContentTypeFS && volSrcArgs.MigrationType.FSType != migration.MigrationFSType_RSYNC {
 		return ErrNotSupported
 	}
@@ -194,11 +215,11 @@ func genericVFSMigrateVolume(d Driver, s *state.State, vol Volume, conn io.ReadW
 			return fmt.Errorf("Error getting VM block volume disk path: %w", err)
 		}
 
-		from, err := os.Open(path)
+		from, cleanup, err := openRawDiskImage(path)
 		if err != nil {
 			return fmt.Errorf("Error opening file for reading %q: %w", path, err)
 		}
-		defer func() { _ = from.Close() }()
+		defer cleanup()
 
 		// Setup progress tracker.
 		fromPipe := io.ReadCloser(from)
@@ -343,17 +364,11 @@ func genericVFSCreateVolumeFromMigration(d Driver, initVolume func(vol Volume) (
 		var err error
 
 		// Setup paths to the main volume. We will receive each snapshot to these paths and then create
-		// a snapshot of the main volume for each one.
+		// a snapshot of the main volume for each one. The block path is resolved before each receive as
+		// creating a snapshot may have changed the main volume's disk file.
 		path := shared.AddSlash(mountPath)
 		pathBlock := ""
 
-		if vol.IsVMBlock() || (vol.contentType == ContentTypeBlock && vol.volType == VolumeTypeCustom) {
-			pathBlock, err = d.GetVolumeDiskPath(vol)
-			if err != nil {
-				return fmt.Errorf("Error getting VM block volume disk path: %w", err)
-			}
-		}
-
 		// Snapshots are sent first by the sender, so create these first.
 		for _, snapName := range volTargetArgs.Snapshots {
 			fullSnapshotName := GetSnapshotVolumeName(vol.name, snapName)
@@ -368,6 +383,11 @@ func genericVFSCreateVolumeFromMigration(d Driver, initVolume func(vol Volume) (
 
 			// Receive the block snapshot next (if needed).
 			if vol.IsVMBlock() || (vol.contentType == ContentTypeBlock && vol.volType == VolumeTypeCustom) {
+				pathBlock, err = genericVFSRawVolumeDiskPath(d, vol)
+				if err != nil {
+					return fmt.Errorf("Error getting VM block volume disk path: %w", err)
+				}
+
 				err = recvBlockVol(snapVol.name, conn, pathBlock)
 				if err != nil {
 					return err
@@ -421,6 +441,11 @@ func genericVFSCreateVolumeFromMigration(d Driver, initVolume func(vol Volume) (
 
 		// Receive the block volume next (if needed).
 		if vol.IsVMBlock() || (vol.contentType == ContentTypeBlock && vol.volType == VolumeTypeCustom) {
+			pathBlock, err = genericVFSRawVolumeDiskPath(d, vol)
+			if err != nil {
+				return fmt.Errorf("Error getting VM block volume disk path: %w", err)
+			}
+
 			err = recvBlockVol(vol.name, conn, pathBlock)
 			if err != nil {
 				return err
@@ -437,6 +462,34 @@ func genericVFSCreateVolumeFromMigration(d Driver, initVolume func(vol Volume) (
 	return nil
 }
 
+// genericVFSRawVolumeDiskPath returns the disk path of a block volume that raw data can be written into. If the
+// volume's disk is a qcow2 disk image (as used by qcow2 snapshots) it is first converted into a raw disk image.
+func genericVFSRawVolumeDiskPath(d Driver, vol Volume) (string, error) {
+	path, err := d.GetVolumeDiskPath(vol)
+	if err != nil {
+		return "", err
+	}
+
+	if !IsQcow2DiskPath(path) {
+		return path, nil
+	}
+
+	rawPath := filepath.Join(filepath.Dir(path), genericVolumeDiskFile)
+	d.Logger().Debug("Converting qcow2 disk image to raw", logger.Ctx{"path": path, "rawPath": rawPath})
+
+	_, err = shared.RunCommand("nice", "-n19", "qemu-img", "convert", "-f", "qcow2", "-O", "raw", path, rawPath)
+	if err != nil {
+		return "", fmt.Errorf("Failed converting disk image %q to raw: %w", path, err)
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		return "", fmt.Errorf("Failed removing disk image %q: %w", path, err)
+	}
+
+	return rawPath, nil
+}
+
 // genericVFSHasVolume is a generic HasVolume implementation for VFS-only drivers.
 func genericVFSHasVolume(vol Volume) bool {
 	return shared.PathExists(vol.MountPath())
@@ -452,8 +505,16 @@ func genericVFSGetVolumeDiskPath(vol Volume) (string, error) {
 }
 
 // genericVFSBackupVolume is a generic BackupVolume implementation for VFS-only drivers.
-func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.InstanceTarWriter, snapshots []string, op *operations.Operation) error {
-	if len(snapshots) > 0 {
+// If baseSnapshot is specified an incremental backup is generated, where each snapshot (and then the volume
+// itself) is stored as the difference from the preceding snapshot, starting with baseSnapshot.
+func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.InstanceTarWriter, snapshots []string, baseSnapshot string, op *operations.Operation) error {
+	if baseSnapshot != "" {
+		// Check base and requested snapshots exist in storage.
+		err := vol.SnapshotsExist(append([]string{baseSnapshot}, snapshots...), op)
+		if err != nil {
+			return err
+		}
+	} else if len(snapshots) > 0 {
 		// Check requested snapshot match those in storage.
 		err := vol.SnapshotsMatch(snapshots, op)
 		if err != nil {
@@ -526,11 +587,11 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 				}
 
 				d.Logger().Debug(logMsg, logger.Ctx{"sourcePath": blockPath, "file": name, "size": blockDiskSize})
-				from, err := os.Open(blockPath)
+				from, cleanup, err := openRawDiskImage(blockPath)
 				if err != nil {
 					return fmt.Errorf("Error opening file for reading %q: %w", blockPath, err)
 				}
-				defer func() { _ = from.Close() }()
+				defer cleanup()
 
 				fi := instancewriter.FileInfo{
 					FileName:    name,
@@ -595,6 +656,62 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 		}, op)
 	}
 
+	// Define a function that can copy the differences between a volume and a previous snapshot of it into the
+	// backup target location.
+	backupVolumeDiff := func(v Volume, prevVol Volume, prefix string) error {
+		return prevVol.MountTask(func(prevMountPath string, op *operations.Operation) error {
+			return v.MountTask(func(mountPath string, op *operations.Operation) error {
+				// Reset hard link cache as we are copying a new volume (instance or snapshot).
+				tarWriter.ResetHardLinkMap()
+
+				if v.contentType == ContentTypeBlock {
+					blockPath, err := d.GetVolumeDiskPath(v)
+					if err != nil {
+						return fmt.Errorf("Error getting block volume disk path: %w", err)
+					}
+
+					prevBlockPath, err := d.GetVolumeDiskPath(prevVol)
+					if err != nil {
+						return fmt.Errorf("Error getting block volume disk path: %w", err)
+					}
+
+					if v.IsVMBlock() {
+						var exclude []string // Files to exclude from filesystem volume backup.
+						if !shared.IsBlockdevPath(blockPath) {
+							exclude = append(exclude, strings.TrimPrefix(blockPath, mountPath))
+						}
+
+						d.Logger().Debug("Copying virtual machine config volume changes", logger.Ctx{"sourcePath": mountPath, "parentPath": prevMountPath, "prefix": prefix})
+						err = genericVFSBackupFilesystemDiff(tarWriter, mountPath, prevMountPath, prefix, exclude)
+						if err != nil {
+							return err
+						}
+					}
+
+					name := fmt.Sprintf("%s.%s.%s", prefix, genericVolumeBlockExtension, genericVolumeDeltaExtension)
+					d.Logger().Debug("Copying block volume changes", logger.Ctx{"sourcePath": blockPath, "parentPath": prevBlockPath, "file": name})
+
+					return genericVFSBackupBlockDelta(tarWriter, blockPath, prevBlockPath, name)
+				}
+
+				d.Logger().Debug("Copying filesystem volume changes", logger.Ctx{"sourcePath": mountPath, "parentPath": prevMountPath, "prefix": prefix})
+
+				return genericVFSBackupFilesystemDiff(tarWriter, mountPath, prevMountPath, prefix, nil)
+			}, op)
+		}, op)
+	}
+
+	// Keep track of the previous snapshot for incremental backups.
+	var prevVol *Volume
+	if baseSnapshot != "" {
+		baseVol, err := vol.NewSnapshot(baseSnapshot)
+		if err != nil {
+			return err
+		}
+
+		prevVol = &baseVol
+	}
+
 	// Handle snapshots.
 	if len(snapshots) > 0 {
 		snapshotsPrefix := "backup/snapshots"
@@ -611,10 +728,19 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 				return err
 			}
 
-			err = backupVolume(snapVol, prefix)
+			if prevVol != nil {
+				err = backupVolumeDiff(snapVol, *prevVol, prefix)
+			} else {
+				err = backupVolume(snapVol, prefix)
+			}
+
 			if err != nil {
 				return err
 			}
+
+			if baseSnapshot != "" {
+				prevVol = &snapVol
+			}
 		}
 	}
 
@@ -626,22 +752,353 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 		prefix = "backup/volume"
 	}
 
-	err := backupVolume(vol, prefix)
+	var err error
+	if prevVol != nil {
+		err = backupVolumeDiff(vol, *prevVol, prefix)
+	} else {
+		err = backupVolume(vol, prefix)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// genericVFSResolveMountPath follows the target of mountPath if it is a symlink.
+// Functions like filepath.Walk() won't list any directory content otherwise.
+func genericVFSResolveMountPath(mountPath string) string {
+	target, err := os.Readlink(mountPath)
+	if err == nil {
+		// Make sure the target is valid before using it.
+		_, err = os.Stat(target)
+		if err == nil {
+			return target
+		}
+	}
+
+	return mountPath
+}
+
+// genericVFSFileChanged returns whether the file at path differs from the file at prevPath.
+// Files are compared using their type, permissions, size, modification time, ownership and symlink target.
+func genericVFSFileChanged(path string, fi os.FileInfo, prevPath string, prevFi os.FileInfo) bool {
+	if fi.Mode() != prevFi.Mode() || fi.Size() != prevFi.Size() || !fi.ModTime().Equal(prevFi.ModTime()) {
+		return true
+	}
+
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	prevSt, prevOk := prevFi.Sys().(*syscall.Stat_t)
+	if ok && prevOk && (st.Uid != prevSt.Uid || st.Gid != prevSt.Gid) {
+		return true
+	}
+
+	if fi.Mode()&os.ModeSymlink != 0 {
+		target, _ := os.Readlink(path)
+		prevTarget, _ := os.Readlink(prevPath)
+		if target != prevTarget {
+			return true
+		}
+	}
+
+	return false
+}
+
+// genericVFSBackupFilesystemDiff writes the differences between the filesystem at mountPath and the one at
+// prevMountPath into the tarball. Added and modified entries are written under prefix (directories are always
+// written so their metadata is kept up to date) and removed entries are listed (one per line, relative to the
+// volume root) in a file named after prefix with the genericVolumeDeletedExtension extension.
+// Entries whose type changed are both listed as removed and written as added.
+func genericVFSBackupFilesystemDiff(tarWriter *instancewriter.InstanceTarWriter, mountPath string, prevMountPath string, prefix string, exclude []string) error {
+	mountPath = genericVFSResolveMountPath(mountPath)
+	prevMountPath = genericVFSResolveMountPath(prevMountPath)
+
+	// Find the entries that have been removed.
+	var deleted []string
+	err := filepath.Walk(prevMountPath, func(prevPath string, prevFi os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("Error walking file during export: %q: %w", prevPath, err)
+		}
+
+		relPath := strings.TrimPrefix(prevPath, prevMountPath)
+		if relPath == "" || shared.StringInSlice(relPath, exclude) {
+			return nil
+		}
+
+		fi, err := os.Lstat(filepath.Join(mountPath, relPath))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err != nil || fi.Mode().Type() != prevFi.Mode().Type() {
+			deleted = append(deleted, relPath)
+
+			// No need to list the contents of a removed directory.
+			if prevFi.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write the entries that have been added or modified.
+	err = filepath.Walk(mountPath, func(srcPath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				logger.Warnf("File vanished during export: %q, skipping", srcPath)
+				return nil
+			}
+
+			return fmt.Errorf("Error walking file during export: %q: %w", srcPath, err)
+		}
+
+		relPath := strings.TrimPrefix(srcPath, mountPath)
+		if shared.StringInSlice(relPath, exclude) {
+			return nil
+		}
+
+		if !fi.IsDir() {
+			prevPath := filepath.Join(prevMountPath, relPath)
+			prevFi, err := os.Lstat(prevPath)
+			if err == nil && !genericVFSFileChanged(srcPath, fi, prevPath, prevFi) {
+				return nil
+			}
+		}
+
+		name := filepath.Join(prefix, relPath)
+
+		// Write the file to the tarball with ignoreGrowth enabled so that if the
+		// source file grows during copy we only copy up to the original size.
+		err = tarWriter.WriteFile(name, srcPath, fi, true)
+		if err != nil {
+			return fmt.Errorf("Error adding %q as %q to tarball: %w", srcPath, name, err)
+		}
+
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
+	// Write the list of removed entries.
+	deletedData := []byte(strings.Join(deleted, "\n"))
+	fi := instancewriter.FileInfo{
+		FileName:    fmt.Sprintf("%s.%s", prefix, genericVolumeDeletedExtension),
+		FileSize:    int64(len(deletedData)),
+		FileMode:    0600,
+		FileModTime: time.Now(),
+	}
+
+	err = tarWriter.WriteFileFromReader(bytes.NewReader(deletedData), &fi)
+	if err != nil {
+		return fmt.Errorf("Error writing list of removed files to tarball: %w", err)
+	}
+
 	return nil
 }
 
+// genericVFSBackupBlockDelta writes the chunks of the block volume at blockPath that differ from the ones at
+// prevBlockPath into the tarball as a delta file called name. The delta file starts with genericVolumeDeltaMagic
+// followed by the size of the volume and then a list of offset, length and data records.
+func genericVFSBackupBlockDelta(tarWriter *instancewriter.InstanceTarWriter, blockPath string, prevBlockPath string, name string) error {
+	blockDiskSize, err := BlockDiskSizeBytes(blockPath)
+	if err != nil {
+		return fmt.Errorf("Error getting block device size %q: %w", blockPath, err)
+	}
+
+	from, cleanup, err := openRawDiskImage(blockPath)
+	if err != nil {
+		return fmt.Errorf("Error opening file for reading %q: %w", blockPath, err)
+	}
+
+	defer cleanup()
+
+	prev, prevCleanup, err := openRawDiskImage(prevBlockPath)
+	if err != nil {
+		return fmt.Errorf("Error opening file for reading %q: %w", prevBlockPath, err)
+	}
+
+	defer prevCleanup()
+
+	// Create temporary file to store the delta so its size is known for the tarball header.
+	tmpFile, err := ioutil.TempFile(shared.VarPath("backups"), fmt.Sprintf("%s_delta", backup.WorkingDirPrefix))
+	if err != nil {
+		return fmt.Errorf("Failed to open temporary file for block volume delta: %w", err)
+	}
+
+	defer func() { _ = tmpFile.Close() }()
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	w := bufio.NewWriter(tmpFile)
+	_, err = w.WriteString(genericVolumeDeltaMagic)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, uint64(blockDiskSize))
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, genericVolumeDeltaChunkSize)
+	prevBuf := make([]byte, genericVolumeDeltaChunkSize)
+	for offset := int64(0); offset < blockDiskSize; offset += genericVolumeDeltaChunkSize {
+		n, err := io.ReadFull(from, buf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return fmt.Errorf("Error reading %q: %w", blockPath, err)
+		}
+
+		if n == 0 {
+			break
+		}
+
+		prevN, err := io.ReadFull(prev, prevBuf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return fmt.Errorf("Error reading %q: %w", prevBlockPath, err)
+		}
+
+		if n == prevN && bytes.Equal(buf[:n], prevBuf[:prevN]) {
+			continue
+		}
+
+		err = binary.Write(w, binary.BigEndian, []uint64{uint64(offset), uint64(n)})
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(buf[:n])
+		if err != nil {
+			return err
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	// Get info (importantly size) of the generated file for tarball header.
+	tmpFileInfo, err := os.Lstat(tmpFile.Name())
+	if err != nil {
+		return err
+	}
+
+	err = tarWriter.WriteFile(name, tmpFile.Name(), tmpFileInfo, false)
+	if err != nil {
+		return fmt.Errorf("Error adding %q to tarball: %w", name, err)
+	}
+
+	return tmpFile.Close()
+}
+
+// genericVFSApplyBlockDelta applies a block volume delta generated by genericVFSBackupBlockDelta to the target.
+// The setSize function is called with the size of the volume before any changes are written.
+func genericVFSApplyBlockDelta(r io.Reader, targetPath string, setSize func(size int64) error) error {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(genericVolumeDeltaMagic))
+	_, err := io.ReadFull(br, magic)
+	if err != nil || string(magic) != genericVolumeDeltaMagic {
+		return fmt.Errorf("Invalid block volume delta header")
+	}
+
+	var size uint64
+	err = binary.Read(br, binary.BigEndian, &size)
+	if err != nil {
+		return fmt.Errorf("Invalid block volume delta header: %w", err)
+	}
+
+	err = setSize(int64(size))
+	if err != nil {
+		return err
+	}
+
+	// Open block file (use O_CREATE to support drivers that use image files).
+	to, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("Error opening file for writing %q: %w", targetPath, err)
+	}
+
+	defer func() { _ = to.Close() }()
+
+	buf := make([]byte, genericVolumeDeltaChunkSize)
+	for {
+		var record [2]uint64
+		err = binary.Read(br, binary.BigEndian, &record)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("Invalid block volume delta record: %w", err)
+		}
+
+		offset, length := record[0], record[1]
+		if length > genericVolumeDeltaChunkSize || offset+length > size {
+			return fmt.Errorf("Invalid block volume delta record at offset %d", offset)
+		}
+
+		_, err = io.ReadFull(br, buf[:length])
+		if err != nil {
+			return fmt.Errorf("Failed reading block volume delta data: %w", err)
+		}
+
+		_, err = to.WriteAt(buf[:length], int64(offset))
+		if err != nil {
+			return fmt.Errorf("Failed writing to %q: %w", targetPath, err)
+		}
+	}
+
+	return to.Close()
+}
+
 // genericVFSBackupUnpack unpacks a non-optimized backup tarball through a storage driver.
 // Returns a post hook function that should be called once the database entries for the restored backup have been
 // created and a revert function that can be used to undo the actions this function performs should something
 // subsequently fail. For VolumeTypeCustom volumes, a nil post hook is returned as it is expected that the DB
 // record be created before the volume is unpacked due to differences in the archive format that allows this.
-func genericVFSBackupUnpack(d Driver, sysOS *sys.OS, vol Volume, snapshots []string, srcData io.ReadSeeker, op *operations.Operation) (VolumePostHook, revert.Hook, error) {
+// For incremental backup chains, each backup in the chain is applied in turn on top of the previous one.
+func genericVFSBackupUnpack(d Driver, sysOS *sys.OS, vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) (VolumePostHook, revert.Hook, error) {
+	// Define function to find a file in a backup tarball file and return its reader.
+	findFile := func(r io.ReadSeeker, unpacker []string, mountPath string, fileName string, fn func(hdr *tar.Header, tr io.Reader) error) error {
+		_, err := r.Seek(0, 0)
+		if err != nil {
+			return err
+		}
+
+		tr, cancelFunc, err := archive.CompressedTarReader(context.Background(), r, unpacker, sysOS, mountPath)
+		if err != nil {
+			return err
+		}
+
+		defer cancelFunc()
+
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break // End of archive.
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if hdr.Name == fileName {
+				return fn(hdr, tr)
+			}
+		}
+
+		return fmt.Errorf("Could not find %q", fileName)
+	}
+
 	// Define function to unpack a volume from a backup tarball file.
-	unpackVolume := func(r io.ReadSeeker, tarArgs []string, unpacker []string, srcPrefix string, mountPath string) error {
+	// When incremental is true the backup contains the differences from the current contents of the volume.
+	unpackVolume := func(r io.ReadSeeker, tarArgs []string, unpacker []string, srcPrefix string, mountPath string, incremental bool) error {
 		volTypeName := "container"
 		if vol.IsVMBlock() {
 			volTypeName = "virtual machine"
@@ -649,10 +1106,43 @@ func genericVFSBackupUnpack(d Driver, sysOS *sys.OS, vol Volume, snapshots []str
 			volTypeName = "custom"
 		}
 
-		// Clear the volume ready for unpack.
-		err := wipeDirectory(mountPath)
-		if err != nil {
-			return fmt.Errorf("Error clearing volume before unpack: %w", err)
+		if !incremental {
+			// Clear the volume ready for unpack.
+			err := wipeDirectory(mountPath)
+			if err != nil {
+				return fmt.Errorf("Error clearing volume before unpack: %w", err)
+			}
+		} else if !vol.IsCustomBlock() {
+			// Remove the entries that were removed since the previous backup.
+			deletedFile := fmt.Sprintf("%s.%s", srcPrefix, genericVolumeDeletedExtension)
+			err := findFile(r, unpacker, mountPath, deletedFile, func(hdr *tar.Header, tr io.Reader) error {
+				data, err := ioutil.ReadAll(tr)
+				if err != nil {
+					return err
+				}
+
+				for _, relPath := range strings.Split(string(data), "\n") {
+					if relPath == "" {
+						continue
+					}
+
+					// Ensure the removed path is within the volume.
+					path := filepath.Join(mountPath, filepath.Clean("/"+relPath))
+					if path == mountPath {
+						continue
+					}
+
+					err = os.RemoveAll(path)
+					if err != nil {
+						return fmt.Errorf("Failed removing %q: %w", path, err)
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("Error applying removed files: %w", err)
+			}
 		}
 
 		// Unpack the filesystem parts of the volume (for containers and custom filesystem volumes that is
@@ -687,7 +1177,7 @@ func genericVFSBackupUnpack(d Driver, sysOS *sys.OS, vol Volume, snapshots []str
 
 			// Extract filesystem volume.
 			d.Logger().Debug(fmt.Sprintf("Unpacking %s filesystem volume", volTypeName), logger.Ctx{"source": srcPrefix, "target": mountPath, "args": fmt.Sprintf("%+v", args)})
-			_, err := srcData.Seek(0, 0)
+			_, err := r.Seek(0, 0)
 			if err != nil {
 				return err
 			}
@@ -711,13 +1201,36 @@ func genericVFSBackupUnpack(d Driver, sysOS *sys.OS, vol Volume, snapshots []str
 
 		// Extract block file to block volume.
 		if vol.contentType == ContentTypeBlock {
-			targetPath, err := d.GetVolumeDiskPath(vol)
+			targetPath, err := genericVFSRawVolumeDiskPath(d, vol)
 			if err != nil {
 				return err
 			}
 
 			srcFile := fmt.Sprintf("%s.%s", srcPrefix, genericVolumeBlockExtension)
 
+			if incremental {
+				deltaFile := fmt.Sprintf("%s.%s", srcFile, genericVolumeDeltaExtension)
+
+				logMsg := "Applying virtual machine block volume changes"
+				if vol.volType == VolumeTypeCustom {
+					logMsg = "Applying custom block volume changes"
+				}
+
+				d.Logger().Debug(logMsg, logger.Ctx{"source": deltaFile, "target": targetPath})
+
+				return findFile(r, unpacker, mountPath, deltaFile, func(hdr *tar.Header, tr io.Reader) error {
+					return genericVFSApplyBlockDelta(tr, targetPath, func(size int64) error {
+						// Allow potentially destructive resize of volume to match the size of the source.
+						return d.SetVolumeQuota(vol, fmt.Sprintf("%d", size), true, op)
+					})
+				})
+			}
+
+			_, err = r.Seek(0, 0)
+			if err != nil {
+				return err
+			}
+
 			tr, cancelFunc, err := archive.CompressedTarReader(context.Background(), r, unpacker, sysOS, mountPath)
 			if err != nil {
 				return err
@@ -779,36 +1292,17 @@ func genericVFSBackupUnpack(d Driver, sysOS *sys.OS, vol Volume, snapshots []str
 	revert := revert.New()
 	defer revert.Fail()
 
-	// Find the compression algorithm used for backup source data.
-	_, err := srcData.Seek(0, 0)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	tarArgs, _, unpacker, err := shared.DetectCompressionFile(srcData)
-	if err != nil {
-		return nil, nil, err
-	}
-
 	if d.HasVolume(vol) {
 		return nil, nil, fmt.Errorf("Cannot restore volume, already exists on target")
 	}
 
 	// Create new empty volume.
-	err = d.CreateVolume(vol, nil, nil)
+	err := d.CreateVolume(vol, nil, nil)
 	if err != nil {
 		return nil, nil, err
 	}
 	revert.Add(func() { _ = d.DeleteVolume(vol, op) })
 
-	if len(snapshots) > 0 {
-		// Create new snapshots directory.
-		err := createParentSnapshotDirIfMissing(d.Name(), vol.volType, vol.name)
-		if err != nil {
-			return nil, nil, err
-		}
-	}
-
 	backupSnapshotsPrefix := "backup/snapshots"
 	if vol.IsVMBlock() {
 		backupSnapshotsPrefix = "backup/virtual-machine-snapshots"
@@ -816,45 +1310,80 @@ func genericVFSBackupUnpack(d Driver, sysOS *sys.OS, vol Volume, snapshots []str
 		backupSnapshotsPrefix = "backup/volume-snapshots"
 	}
 
-	for _, snapName := range snapshots {
-		err = vol.MountTask(func(mountPath string, op *operations.Operation) error {
-			backupSnapshotPrefix := fmt.Sprintf("%s/%s", backupSnapshotsPrefix, snapName)
-			return unpackVolume(srcData, tarArgs, unpacker, backupSnapshotPrefix, mountPath)
-		}, op)
+	backupPrefix := "backup/container"
+	if vol.IsVMBlock() {
+		backupPrefix = "backup/virtual-machine"
+	} else if vol.volType == VolumeTypeCustom {
+		backupPrefix = "backup/volume"
+	}
+
+	// Restore the snapshots of each backup in the chain (oldest first). The volume itself is only restored
+	// from the last backup as each incremental backup's snapshots are based on the previous backup's snapshots.
+	entries := srcBackup.ChainEntries(srcData)
+	for i, entry := range entries {
+		// Find the compression algorithm used for backup source data.
+		_, err = entry.Data.Seek(0, 0)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		snapVol, err := vol.NewSnapshot(snapName)
+		tarArgs, _, unpacker, err := shared.DetectCompressionFile(entry.Data)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		d.Logger().Debug("Creating volume snapshot", logger.Ctx{"snapshotName": snapVol.Name()})
-		err = d.CreateVolumeSnapshot(snapVol, op)
-		if err != nil {
-			return nil, nil, err
+		incremental := entry.Info.BaseSnapshot != ""
+
+		if len(entry.Info.Snapshots) > 0 {
+			// Create new snapshots directory.
+			err := createParentSnapshotDirIfMissing(d.Name(), vol.volType, vol.name)
+			if err != nil {
+				return nil, nil, err
+			}
 		}
-		revert.Add(func() { _ = d.DeleteVolumeSnapshot(snapVol, op) })
-	}
 
-	err = d.MountVolume(vol, op)
-	if err != nil {
-		return nil, nil, err
-	}
-	revert.Add(func() { _, _ = d.UnmountVolume(vol, false, op) })
+		for _, snapName := range entry.Info.Snapshots {
+			err = vol.MountTask(func(mountPath string, op *operations.Operation) error {
+				backupSnapshotPrefix := fmt.Sprintf("%s/%s", backupSnapshotsPrefix, snapName)
+				return unpackVolume(entry.Data, tarArgs, unpacker, backupSnapshotPrefix, mountPath, incremental)
+			}, op)
+			if err != nil {
+				return nil, nil, err
+			}
 
-	backupPrefix := "backup/container"
-	if vol.IsVMBlock() {
-		backupPrefix = "backup/virtual-machine"
-	} else if vol.volType == VolumeTypeCustom {
-		backupPrefix = "backup/volume"
-	}
+			// The snapshot still needs unpacking as the following backups in the chain are based on its
+			// contents, but it is only created if it still existed when the last backup was taken.
+			if !shared.StringInSlice(snapName, srcBackup.Snapshots) {
+				continue
+			}
 
-	mountPath := vol.MountPath()
-	err = unpackVolume(srcData, tarArgs, unpacker, backupPrefix, mountPath)
-	if err != nil {
-		return nil, nil, err
+			snapVol, err := vol.NewSnapshot(snapName)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			d.Logger().Debug("Creating volume snapshot", logger.Ctx{"snapshotName": snapVol.Name()})
+			err = d.CreateVolumeSnapshot(snapVol, op)
+			if err != nil {
+				return nil, nil, err
+			}
+			revert.Add(func() { _ = d.DeleteVolumeSnapshot(snapVol, op) })
+		}
+
+		if i < len(entries)-1 {
+			continue
+		}
+
+		err = d.MountVolume(vol, op)
+		if err != nil {
+			return nil, nil, err
+		}
+		revert.Add(func() { _, _ = d.UnmountVolume(vol, false, op) })
+
+		err = unpackVolume(entry.Data, tarArgs, unpacker, backupPrefix, vol.MountPath(), incremental)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Run EnsureMountPath after mounting and unpacking to ensure the mounted directory has the
@@ -903,7 +1432,7 @@ func genericVFSCopyVolume(d Driver, initVolume func(vol Volume) (revert.Hook, er
 	var rsyncArgs []string
 
 	if srcVol.IsVMBlock() {
-		rsyncArgs = append(rsyncArgs, "--exclude", genericVolumeDiskFile)
+		rsyncArgs = append(rsyncArgs, "--exclude", genericVolumeDiskFile, "--exclude", genericVolumeQcow2DiskFile)
 	}
 
 	revert := revert.New()
@@ -939,7 +1468,7 @@ func genericVFSCopyVolume(d Driver, initVolume func(vol Volume) (revert.Hook, er
 			return err
 		}
 
-		targetDevPath, err := d.GetVolumeDiskPath(targetVol)
+		targetDevPath, err := genericVFSRawVolumeDiskPath(d, targetVol)
 		if err != nil {
 			return err
 		}
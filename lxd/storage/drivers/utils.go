@@ -1,12 +1,15 @@
 package drivers
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,8 +18,10 @@ import (
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/storage/filesystem"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/idmap"
 	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/units"
 )
 
 // MinBlockBoundary minimum block boundary size to use.
@@ -350,6 +355,13 @@ func ensureVolumeBlockFile(vol Volume, path string, sizeBytes int64, allowUnsafe
 		}
 
 		oldSizeBytes := fi.Size()
+		if IsQcow2DiskPath(path) {
+			oldSizeBytes, err = BlockDiskSizeBytes(path)
+			if err != nil {
+				return false, err
+			}
+		}
+
 		if sizeBytes == oldSizeBytes {
 			return false, nil
 		}
@@ -375,7 +387,18 @@ func ensureVolumeBlockFile(vol Volume, path string, sizeBytes int64, allowUnsafe
 			}
 		}
 
-		err = ensureSparseFile(path, sizeBytes)
+		if IsQcow2DiskPath(path) {
+			args := []string{"resize", "-f", "qcow2"}
+			if sizeBytes < oldSizeBytes {
+				args = append(args, "--shrink")
+			}
+
+			args = append(args, path, fmt.Sprintf("%d", sizeBytes))
+			_, err = shared.RunCommand("qemu-img", args...)
+		} else {
+			err = ensureSparseFile(path, sizeBytes)
+		}
+
 		if err != nil {
 			return false, fmt.Errorf("Failed resizing disk image %q to size %d: %w", path, sizeBytes, err)
 		}
@@ -672,7 +695,17 @@ func regenerateFilesystemXFSUUID(devPath string) error {
 
 // copyDevice copies one device path to another using dd running at low priority.
 // It expects outputPath to exist already, so will not create it.
+// If inputPath is a qcow2 disk image, its contents are converted into raw format using qemu-img instead.
 func copyDevice(inputPath string, outputPath string) error {
+	if IsQcow2DiskPath(inputPath) {
+		_, err := shared.RunCommand("nice", "-n19", "qemu-img", "convert", "-U", "-n", "-f", "qcow2", "-O", "raw", inputPath, outputPath)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
 	cmd := []string{
 		"nice", "-n19", // Run dd with low priority to reduce CPU impact on other processes.
 		"dd", fmt.Sprintf("if=%s", inputPath), fmt.Sprintf("of=%s", outputPath),
@@ -834,8 +867,17 @@ func ShiftZFSSkipper(dir string, absPath string, fi os.FileInfo) bool {
 	return false
 }
 
-// BlockDiskSizeBytes returns the size of a block disk (path can be either block device or raw file).
+// BlockDiskSizeBytes returns the size of a block disk (path can be either block device, raw file or qcow2 file).
 func BlockDiskSizeBytes(blockDiskPath string) (int64, error) {
+	if IsQcow2DiskPath(blockDiskPath) {
+		info, err := qcow2DiskImageInfo(blockDiskPath)
+		if err != nil {
+			return -1, err
+		}
+
+		return info.VirtualSize, nil
+	}
+
 	if shared.IsBlockdevPath(blockDiskPath) {
 		// Attempt to open the device path.
 		f, err := os.Open(blockDiskPath)
@@ -863,6 +905,90 @@ func BlockDiskSizeBytes(blockDiskPath string) (int64, error) {
 	return fi.Size(), nil
 }
 
+// IsQcow2DiskPath returns true if the disk image file at path is a qcow2 disk image rather than a raw one.
+// The format is derived from the file name rather than probed from the contents, as those are guest controlled.
+func IsQcow2DiskPath(path string) bool {
+	return filepath.Ext(path) == ".qcow2"
+}
+
+// qcow2ImageInfo represents the information about a qcow2 disk image reported by qemu-img.
+type qcow2ImageInfo struct {
+	Filename      string `json:"filename"`
+	Format        string `json:"format"`
+	VirtualSize   int64  `json:"virtual-size"`
+	BackingFile   string `json:"backing-filename"`
+	BackingFormat string `json:"backing-filename-format"`
+}
+
+// qcow2DiskImageInfo returns the information about the qcow2 disk image at path.
+func qcow2DiskImageInfo(path string) (*qcow2ImageInfo, error) {
+	out, err := shared.RunCommand("qemu-img", "info", "-U", "-f", "qcow2", "--output=json", path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting info of disk image %q: %w", path, err)
+	}
+
+	info := qcow2ImageInfo{}
+	err = json.Unmarshal([]byte(out), &info)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing info of disk image %q: %w", path, err)
+	}
+
+	return &info, nil
+}
+
+// DiskImage represents a disk image file and its format.
+type DiskImage struct {
+	Path   string
+	Format string
+}
+
+// DiskImageBackingChain returns the disk images making up the disk at path, starting with the disk image itself
+// followed by its backing images (if any). The backing images are those recorded in the qcow2 headers.
+func DiskImageBackingChain(path string) ([]DiskImage, error) {
+	if !IsQcow2DiskPath(path) {
+		return []DiskImage{{Path: path, Format: "raw"}}, nil
+	}
+
+	out, err := shared.RunCommand("qemu-img", "info", "-U", "-f", "qcow2", "--backing-chain", "--output=json", path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting backing chain of disk image %q: %w", path, err)
+	}
+
+	infos := []qcow2ImageInfo{}
+	err = json.Unmarshal([]byte(out), &infos)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing backing chain of disk image %q: %w", path, err)
+	}
+
+	chain := make([]DiskImage, 0, len(infos))
+	for _, info := range infos {
+		chain = append(chain, DiskImage{Path: info.Filename, Format: info.Format})
+	}
+
+	return chain, nil
+}
+
+// openRawDiskImage opens the disk image at path for reading its raw contents. As qcow2 disk images cannot be read
+// directly, their raw contents are streamed from a read-only qemu-nbd server. The returned cleanup function must
+// be called once done.
+func openRawDiskImage(path string) (io.ReadCloser, func(), error) {
+	if !IsQcow2DiskPath(path) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return f, func() { _ = f.Close() }, nil
+	}
+
+	r, err := newNBDDiskReader(path, "qcow2")
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed opening disk image %q: %w", path, err)
+	}
+
+	return r, func() { _ = r.Close() }, nil
+}
+
 // OperationLockName returns the storage specific lock name to use with locking package.
 func OperationLockName(operationName string, poolName string, volType VolumeType, contentType ContentType, volName string) string {
 	return fmt.Sprintf("%s/%s/%s/%s/%s", operationName, poolName, volType, contentType, volName)
@@ -888,3 +1014,63 @@ func loopFileSizeDefault() (uint64, error) {
 
 	return defaultSize, nil
 }
+
+// validateOvercommitRatio validates the "volume.overcommit_ratio" pool setting, which must be a positive number.
+func validateOvercommitRatio(value string) error {
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid overcommit ratio %q", value)
+	}
+
+	if ratio <= 0 {
+		return fmt.Errorf("Overcommit ratio must be greater than 0")
+	}
+
+	return nil
+}
+
+// validateFillThresholds checks that the "fill_threshold.warning" pool setting is lower than the
+// "fill_threshold.critical" one when both are set.
+func validateFillThresholds(config map[string]string) error {
+	if config["fill_threshold.warning"] == "" || config["fill_threshold.critical"] == "" {
+		return nil
+	}
+
+	warning, err := strconv.Atoi(config["fill_threshold.warning"])
+	if err != nil {
+		return err
+	}
+
+	critical, err := strconv.Atoi(config["fill_threshold.critical"])
+	if err != nil {
+		return err
+	}
+
+	if warning >= critical {
+		return fmt.Errorf("The key fill_threshold.warning must be lower than fill_threshold.critical")
+	}
+
+	return nil
+}
+
+// checkOvercommit returns ErrOvercommit if provisioning extraBytes more on a pool with the given resources would
+// take its provisioned space beyond the total space times the pool's "volume.overcommit_ratio" setting.
+// Nothing is checked if the setting isn't set or if the space provisioned on the pool doesn't grow.
+func checkOvercommit(config map[string]string, res *api.ResourcesStoragePool, extraBytes int64) error {
+	if config["volume.overcommit_ratio"] == "" || extraBytes <= 0 {
+		return nil
+	}
+
+	ratio, err := strconv.ParseFloat(config["volume.overcommit_ratio"], 64)
+	if err != nil {
+		return err
+	}
+
+	maxBytes := uint64(float64(res.Space.Total) * ratio)
+	provisionedBytes := res.Space.Provisioned + uint64(extraBytes)
+	if provisionedBytes > maxBytes {
+		return fmt.Errorf("Provisioning %s would bring the pool to %s of %s allowed by its overcommit ratio: %w", units.GetByteSizeStringIEC(extraBytes, 2), units.GetByteSizeStringIEC(int64(provisionedBytes), 2), units.GetByteSizeStringIEC(int64(maxBytes), 2), ErrOvercommit)
+	}
+
+	return nil
+}
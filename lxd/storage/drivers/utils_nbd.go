@@ -0,0 +1,248 @@
+package drivers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NBD protocol constants (see https://github.com/NetworkBlockDevice/nbd/blob/master/doc/proto.md).
+const (
+	nbdMagic             = 0x4e42444d41474943 // "NBDMAGIC"
+	nbdOptMagic          = 0x49484156454f5054 // "IHAVEOPT"
+	nbdRequestMagic      = 0x25609513
+	nbdSimpleReplyMagic  = 0x67446698
+	nbdFlagFixedNewstyle = 1 << 0
+	nbdFlagNoZeroes      = 1 << 1
+	nbdOptExportName     = 1
+	nbdCmdRead           = 0
+	nbdCmdDisc           = 2
+)
+
+// nbdExportName is the name under which qemu-nbd exports the disk image.
+const nbdExportName = "disk"
+
+// nbdMaxReadLength is the largest read requested at once, well below the 32MiB qemu-nbd accepts.
+const nbdMaxReadLength = 1024 * 1024
+
+// nbdStartTimeout is how long to wait for qemu-nbd to start listening.
+const nbdStartTimeout = 30 * time.Second
+
+// nbdDiskReader reads the raw contents of a disk image sequentially from a qemu-nbd server serving it.
+type nbdDiskReader struct {
+	cmd     *exec.Cmd
+	exited  chan error
+	stderr  *bytes.Buffer
+	tmpDir  string
+	conn    net.Conn
+	size    int64
+	offset  int64
+	handle  uint64
+	request []byte
+	reply   []byte
+}
+
+// newNBDDiskReader starts a read-only qemu-nbd server for the disk image at path (in the given format) on a
+// temporary unix socket and connects to it. The server exits once the returned reader is closed.
+func newNBDDiskReader(path string, format string) (*nbdDiskReader, error) {
+	tmpDir, err := ioutil.TempDir("", "lxd_nbd_")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &nbdDiskReader{
+		tmpDir:  tmpDir,
+		exited:  make(chan error, 1),
+		stderr:  &bytes.Buffer{},
+		request: make([]byte, 28),
+		reply:   make([]byte, 16),
+	}
+
+	socketPath := filepath.Join(tmpDir, "nbd.sock")
+	r.cmd = exec.Command("nice", "-n19", "qemu-nbd", "--read-only", "--force-share", "--format", format, "--export-name", nbdExportName, "--socket", socketPath, path)
+	r.cmd.Stderr = r.stderr
+
+	err = r.cmd.Start()
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("Failed starting qemu-nbd: %w", err)
+	}
+
+	go func() { r.exited <- r.cmd.Wait() }()
+
+	err = r.connect(socketPath)
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// connect waits for the qemu-nbd server to listen on socketPath, connects to it and negotiates the export.
+func (r *nbdDiskReader) connect(socketPath string) error {
+	deadline := time.Now().Add(nbdStartTimeout)
+	for {
+		conn, err := net.Dial("unix", socketPath)
+		if err == nil {
+			r.conn = conn
+			break
+		}
+
+		select {
+		case err := <-r.exited:
+			r.exited <- err // Keep the exit status for Close.
+			return fmt.Errorf("qemu-nbd exited: %s", strings.TrimSpace(r.stderr.String()))
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out waiting for qemu-nbd to start")
+		}
+	}
+
+	// Fixed newstyle handshake.
+	greeting := make([]byte, 18)
+	_, err := io.ReadFull(r.conn, greeting)
+	if err != nil {
+		return fmt.Errorf("Failed reading NBD greeting: %w", err)
+	}
+
+	if binary.BigEndian.Uint64(greeting[0:8]) != nbdMagic || binary.BigEndian.Uint64(greeting[8:16]) != nbdOptMagic {
+		return fmt.Errorf("Unsupported NBD greeting")
+	}
+
+	serverFlags := binary.BigEndian.Uint16(greeting[16:18])
+	if serverFlags&nbdFlagFixedNewstyle == 0 {
+		return fmt.Errorf("NBD server doesn't support the fixed newstyle handshake")
+	}
+
+	clientFlags := uint32(nbdFlagFixedNewstyle)
+	if serverFlags&nbdFlagNoZeroes != 0 {
+		clientFlags |= nbdFlagNoZeroes
+	}
+
+	option := make([]byte, 20, 20+len(nbdExportName))
+	binary.BigEndian.PutUint32(option[0:4], clientFlags)
+	binary.BigEndian.PutUint64(option[4:12], nbdOptMagic)
+	binary.BigEndian.PutUint32(option[12:16], nbdOptExportName)
+	binary.BigEndian.PutUint32(option[16:20], uint32(len(nbdExportName)))
+	option = append(option, nbdExportName...)
+
+	_, err = r.conn.Write(option)
+	if err != nil {
+		return fmt.Errorf("Failed selecting NBD export: %w", err)
+	}
+
+	exportInfo := make([]byte, 10)
+	if clientFlags&nbdFlagNoZeroes == 0 {
+		exportInfo = make([]byte, 10+124)
+	}
+
+	_, err = io.ReadFull(r.conn, exportInfo)
+	if err != nil {
+		return fmt.Errorf("Failed reading NBD export information: %w", err)
+	}
+
+	r.size = int64(binary.BigEndian.Uint64(exportInfo[0:8]))
+
+	return nil
+}
+
+// Read reads the next part of the disk image's raw contents.
+func (r *nbdDiskReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	length := int64(len(p))
+	if length > r.size-r.offset {
+		length = r.size - r.offset
+	}
+
+	if length > nbdMaxReadLength {
+		length = nbdMaxReadLength
+	}
+
+	if length == 0 {
+		return 0, nil
+	}
+
+	r.handle++
+	err := r.sendRequest(nbdCmdRead, r.offset, uint32(length))
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = io.ReadFull(r.conn, r.reply)
+	if err != nil {
+		return 0, fmt.Errorf("Failed reading NBD reply: %w", err)
+	}
+
+	if binary.BigEndian.Uint32(r.reply[0:4]) != nbdSimpleReplyMagic || binary.BigEndian.Uint64(r.reply[8:16]) != r.handle {
+		return 0, fmt.Errorf("Unexpected NBD reply")
+	}
+
+	errno := binary.BigEndian.Uint32(r.reply[4:8])
+	if errno != 0 {
+		return 0, fmt.Errorf("Failed reading %d bytes at offset %d over NBD: error %d", length, r.offset, errno)
+	}
+
+	n, err := io.ReadFull(r.conn, p[:length])
+	r.offset += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("Failed reading NBD data: %w", err)
+	}
+
+	return n, nil
+}
+
+// sendRequest sends a simple NBD request.
+func (r *nbdDiskReader) sendRequest(cmd uint16, offset int64, length uint32) error {
+	binary.BigEndian.PutUint32(r.request[0:4], nbdRequestMagic)
+	binary.BigEndian.PutUint16(r.request[4:6], 0)
+	binary.BigEndian.PutUint16(r.request[6:8], cmd)
+	binary.BigEndian.PutUint64(r.request[8:16], r.handle)
+	binary.BigEndian.PutUint64(r.request[16:24], uint64(offset))
+	binary.BigEndian.PutUint32(r.request[24:28], length)
+
+	_, err := r.conn.Write(r.request)
+	if err != nil {
+		return fmt.Errorf("Failed sending NBD request: %w", err)
+	}
+
+	return nil
+}
+
+// Close disconnects from the qemu-nbd server, stops it and removes its socket. It can be called multiple times.
+func (r *nbdDiskReader) Close() error {
+	if r.cmd == nil {
+		return nil
+	}
+
+	if r.conn != nil {
+		_ = r.sendRequest(nbdCmdDisc, 0, 0)
+		_ = r.conn.Close()
+		r.conn = nil
+	}
+
+	// The server exits by itself once its client disconnected.
+	select {
+	case <-r.exited:
+	case <-time.After(5 * time.Second):
+		_ = r.cmd.Process.Kill()
+		<-r.exited
+	}
+
+	r.cmd = nil
+
+	return os.RemoveAll(r.tmpDir)
+}
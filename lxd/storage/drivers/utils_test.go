@@ -1,9 +1,12 @@
 package drivers
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/lxd/shared/api"
 )
 
 // Test GetVolumeMountPath
@@ -35,3 +38,39 @@ func TestGetVolumeMountPath(t *testing.T) {
 	expected = GetPoolMountPath(poolName) + "/virtual-machines/testvol"
 	assert.Equal(t, expected, path)
 }
+
+// Test checkOvercommit
+func TestCheckOvercommit(t *testing.T) {
+	res := &api.ResourcesStoragePool{}
+	res.Space.Total = 100
+	res.Space.Provisioned = 150
+
+	// Test pool without overcommit ratio.
+	assert.NoError(t, checkOvercommit(map[string]string{}, res, 1000))
+
+	// Test growth within the ratio.
+	config := map[string]string{"volume.overcommit_ratio": "2"}
+	assert.NoError(t, checkOvercommit(config, res, 50))
+
+	// Test growth beyond the ratio.
+	err := checkOvercommit(config, res, 51)
+	assert.True(t, errors.Is(err, ErrOvercommit))
+
+	// Test shrinking an already overcommitted pool.
+	config["volume.overcommit_ratio"] = "1"
+	assert.NoError(t, checkOvercommit(config, res, -10))
+}
+
+// Test validateFillThresholds
+func TestValidateFillThresholds(t *testing.T) {
+	assert.NoError(t, validateFillThresholds(map[string]string{"fill_threshold.warning": "80"}))
+	assert.NoError(t, validateFillThresholds(map[string]string{"fill_threshold.warning": "80", "fill_threshold.critical": "95"}))
+	assert.Error(t, validateFillThresholds(map[string]string{"fill_threshold.warning": "95", "fill_threshold.critical": "80"}))
+}
+
+// Test IsQcow2DiskPath
+func TestIsQcow2DiskPath(t *testing.T) {
+	assert.True(t, IsQcow2DiskPath("/var/lib/lxd/storage-pools/default/virtual-machines/v1/root.qcow2"))
+	assert.False(t, IsQcow2DiskPath("/var/lib/lxd/storage-pools/default/virtual-machines/v1/root.img"))
+	assert.False(t, IsQcow2DiskPath("/dev/sdb"))
+}
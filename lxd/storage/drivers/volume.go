@@ -53,6 +53,9 @@ const VolumeTypeContainer = VolumeType("containers")
 // VolumeTypeVM represents a virtual-machine storage volume.
 const VolumeTypeVM = VolumeType("virtual-machines")
 
+// VolumeTypeBucket represents a bucket storage volume.
+const VolumeTypeBucket = VolumeType("buckets")
+
 // ContentType indicates the format of the volume.
 type ContentType string
 
@@ -68,6 +71,7 @@ type VolumePostHook func(vol Volume) error
 
 // BaseDirectories maps volume types to the expected directories.
 var BaseDirectories = map[VolumeType][]string{
+	VolumeTypeBucket:    {"buckets"},
 	VolumeTypeContainer: {"containers", "containers-snapshots"},
 	VolumeTypeCustom:    {"custom", "custom-snapshots"},
 	VolumeTypeImage:     {"images"},
@@ -83,8 +87,9 @@ type Volume struct {
 	contentType          ContentType
 	config               map[string]string
 	driver               Driver
-	mountCustomPath      string // Mount the filesystem volume at a custom location.
-	mountFilesystemProbe bool   // Probe filesystem type when mounting volume (when needed).
+	mountCustomPath      string   // Mount the filesystem volume at a custom location.
+	mountFilesystemProbe bool     // Probe filesystem type when mounting volume (when needed).
+	liveDisk             LiveDisk // Running instance using the volume's disk (when needed).
 }
 
 // NewVolume instantiates a new Volume struct.
@@ -353,6 +358,27 @@ func (v Volume) SnapshotsMatch(snapNames []string, op *operations.Operation) err
 	return nil
 }
 
+// SnapshotsExist checks that all of the supplied snapshot names exist in storage.
+// Unlike SnapshotsMatch, additional snapshots in storage are allowed.
+func (v Volume) SnapshotsExist(snapNames []string, op *operations.Operation) error {
+	if v.IsSnapshot() {
+		return fmt.Errorf("Volume is a snapshot")
+	}
+
+	snapshots, err := v.driver.VolumeSnapshots(v, op)
+	if err != nil {
+		return err
+	}
+
+	for _, snapName := range snapNames {
+		if !shared.StringInSlice(snapName, snapshots) {
+			return fmt.Errorf("Snapshot %q expected but not in storage", snapName)
+		}
+	}
+
+	return nil
+}
+
 // IsBlockBacked indicates whether storage device is block backed.
 func (v Volume) IsBlockBacked() bool {
 	return v.driver.Info().BlockBacking
@@ -525,3 +551,9 @@ func (v Volume) ConfigSizeFromSource(srcVol Volume) (string, error) {
 func (v *Volume) SetMountFilesystemProbe(probe bool) {
 	v.mountFilesystemProbe = probe
 }
+
+// SetLiveDisk sets the running instance using the volume's disk, allowing the driver to change its disk backing
+// chain without stopping it.
+func (v *Volume) SetLiveDisk(liveDisk LiveDisk) {
+	v.liveDisk = liveDisk
+}
@@ -1,8 +1,10 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/response"
@@ -22,6 +24,32 @@ const PoolIDTemporary = -1
 func volIDFuncMake(state *state.State, poolID int64) func(volType drivers.VolumeType, volName string) (int64, error) {
 	// Return a function to retrieve a volume ID for a volume Name for use in driver.
 	return func(volType drivers.VolumeType, volName string) (int64, error) {
+		// Bucket volumes use the ID of their bucket record.
+		if volType == drivers.VolumeTypeBucket {
+			bucketProjectName, bucketName := project.StorageVolumeParts(volName)
+
+			var bucketID int64
+			err := state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+				bucket, err := tx.GetStoragePoolBucket(poolID, bucketProjectName, true, bucketName)
+				if err != nil {
+					return err
+				}
+
+				bucketID = bucket.ID
+
+				return nil
+			})
+			if err != nil {
+				if response.IsNotFoundError(err) {
+					return -1, fmt.Errorf("Failed to get volume ID for bucket %q: Bucket doesn't exist", volName)
+				}
+
+				return -1, err
+			}
+
+			return bucketID, nil
+		}
+
 		volTypeID, err := VolumeTypeToDBType(volType)
 		if err != nil {
 			return -1, err
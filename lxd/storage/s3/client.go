@@ -0,0 +1,115 @@
+package s3
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Client is a minimal S3 client used to manage buckets.
+type Client struct {
+	endpoint *url.URL
+	creds    Credentials
+	client   *http.Client
+}
+
+// NewClient returns a new S3 client for the specified endpoint.
+// If insecure is true then the TLS certificate of the endpoint isn't verified.
+func NewClient(endpoint *url.URL, creds Credentials, insecure bool) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: insecure}
+
+	return &Client{
+		endpoint: endpoint,
+		creds:    creds,
+		client:   &http.Client{Transport: transport},
+	}
+}
+
+// do performs a signed request without a body against the specified bucket.
+func (c *Client) do(ctx context.Context, method string, bucketName string) (*http.Response, error) {
+	u := *c.endpoint
+	u.Path = fmt.Sprintf("/%s", bucketName)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	SignRequest(req, c.creds, DefaultRegion, EmptyPayloadHash, time.Now())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// checkResponse returns an error containing the response body if the request failed.
+func checkResponse(resp *http.Response, okCodes ...int) error {
+	defer func() { _ = resp.Body.Close() }()
+
+	for _, code := range okCodes {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	return fmt.Errorf("Unexpected response status %d: %s", resp.StatusCode, string(body))
+}
+
+// CreateBucket creates the specified bucket.
+func (c *Client) CreateBucket(ctx context.Context, bucketName string) error {
+	resp, err := c.do(ctx, http.MethodPut, bucketName)
+	if err != nil {
+		return fmt.Errorf("Failed creating bucket %q: %w", bucketName, err)
+	}
+
+	err = checkResponse(resp, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("Failed creating bucket %q: %w", bucketName, err)
+	}
+
+	return nil
+}
+
+// DeleteBucket deletes the specified bucket. The bucket must be empty.
+func (c *Client) DeleteBucket(ctx context.Context, bucketName string) error {
+	resp, err := c.do(ctx, http.MethodDelete, bucketName)
+	if err != nil {
+		return fmt.Errorf("Failed deleting bucket %q: %w", bucketName, err)
+	}
+
+	err = checkResponse(resp, http.StatusNoContent, http.StatusNotFound)
+	if err != nil {
+		return fmt.Errorf("Failed deleting bucket %q: %w", bucketName, err)
+	}
+
+	return nil
+}
+
+// BucketExists checks whether the specified bucket exists.
+func (c *Client) BucketExists(ctx context.Context, bucketName string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, bucketName)
+	if err != nil {
+		return false, fmt.Errorf("Failed checking bucket %q: %w", bucketName, err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+
+	return false, fmt.Errorf("Failed checking bucket %q: Unexpected response status %d", bucketName, resp.StatusCode)
+}
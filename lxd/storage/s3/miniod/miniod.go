@@ -0,0 +1,308 @@
+package miniod
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lxc/lxd/lxd/storage/s3"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/subprocess"
+)
+
+// minioHost is the address the MinIO processes listen on.
+const minioHost = "127.0.0.1"
+
+// minioAdminUser is the name of the admin user of each MinIO process.
+const minioAdminUser = "lxd-admin"
+
+// minioAlias is the alias used when running mc commands against a MinIO process.
+const minioAlias = "lxd"
+
+// minioStartTimeout is the amount of time to wait for a MinIO process to become ready.
+const minioStartTimeout = 10 * time.Second
+
+var minios = make(map[string]*Process)
+var miniosMu sync.Mutex
+
+// Process represents a running MinIO process serving a single bucket.
+type Process struct {
+	key        string
+	bucketName string
+	bucketPath string
+	port       int
+	password   string
+	configDir  string
+	process    *subprocess.Process
+}
+
+// URL returns the URL of the MinIO process.
+func (p *Process) URL() *url.URL {
+	return &url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(minioHost, fmt.Sprintf("%d", p.port)),
+	}
+}
+
+// AdminCredentials returns the credentials of the admin user of the MinIO process.
+func (p *Process) AdminCredentials() s3.Credentials {
+	return s3.Credentials{AccessKey: minioAdminUser, SecretKey: p.password}
+}
+
+// mc runs the MinIO client against the process.
+func (p *Process) mc(ctx context.Context, args ...string) (string, error) {
+	u := p.URL()
+	u.User = url.UserPassword(minioAdminUser, p.password)
+
+	cmd := exec.CommandContext(ctx, "mc", append([]string{"--config-dir", p.configDir, "--quiet"}, args...)...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("MC_HOST_%s=%s", minioAlias, u.String()))
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("Failed running mc %q: %w (%s)", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+
+	return string(out), nil
+}
+
+// writePolicy writes the policy for the given role to a temporary file and returns its path.
+func (p *Process) writePolicy(roleName string) (string, error) {
+	policy, err := s3.BucketPolicy(p.bucketName, roleName)
+	if err != nil {
+		return "", err
+	}
+
+	policyPath := filepath.Join(p.configDir, fmt.Sprintf("policy-%s.json", roleName))
+	err = os.WriteFile(policyPath, policy, 0600)
+	if err != nil {
+		return "", fmt.Errorf("Failed writing bucket policy: %w", err)
+	}
+
+	return policyPath, nil
+}
+
+// CreateServiceAccount creates a service account with the given credentials and role.
+func (p *Process) CreateServiceAccount(ctx context.Context, creds s3.Credentials, roleName string) error {
+	policyPath, err := p.writePolicy(roleName)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.Remove(policyPath) }()
+
+	_, err = p.mc(ctx, "admin", "user", "svcacct", "add", "--access-key", creds.AccessKey, "--secret-key", creds.SecretKey, "--policy", policyPath, minioAlias, minioAdminUser)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateServiceAccount updates the secret key and role of the service account with the given access key.
+func (p *Process) UpdateServiceAccount(ctx context.Context, creds s3.Credentials, roleName string) error {
+	policyPath, err := p.writePolicy(roleName)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.Remove(policyPath) }()
+
+	_, err = p.mc(ctx, "admin", "user", "svcacct", "edit", "--secret-key", creds.SecretKey, "--policy", policyPath, minioAlias, creds.AccessKey)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteServiceAccount deletes the service account with the given access key.
+func (p *Process) DeleteServiceAccount(ctx context.Context, accessKey string) error {
+	_, err := p.mc(ctx, "admin", "user", "svcacct", "rm", minioAlias, accessKey)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Stop stops the MinIO process.
+func (p *Process) Stop() error {
+	miniosMu.Lock()
+	defer miniosMu.Unlock()
+
+	return p.stop()
+}
+
+// stop stops the MinIO process. Must be called with miniosMu held.
+func (p *Process) stop() error {
+	delete(minios, p.key)
+
+	_ = os.RemoveAll(p.configDir)
+
+	err := p.process.Stop()
+	if err != nil && err != subprocess.ErrNotRunning {
+		return fmt.Errorf("Failed stopping MinIO for bucket %q: %w", p.bucketName, err)
+	}
+
+	return nil
+}
+
+// processKey returns the key identifying the MinIO process of a bucket. Bucket names are only unique within a
+// project of a storage pool.
+func processKey(poolName string, projectName string, bucketName string) string {
+	return fmt.Sprintf("%s/%s/%s", poolName, projectName, bucketName)
+}
+
+// Get returns the running MinIO process for the given bucket, or nil if not running.
+func Get(poolName string, projectName string, bucketName string) *Process {
+	miniosMu.Lock()
+	defer miniosMu.Unlock()
+
+	return minios[processKey(poolName, projectName, bucketName)]
+}
+
+// freePort returns a free TCP port on the MinIO listen address.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(minioHost, "0"))
+	if err != nil {
+		return -1, err
+	}
+
+	defer func() { _ = listener.Close() }()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// EnsureRunning starts a MinIO process serving the bucket stored in bucketPath if not already running.
+func EnsureRunning(poolName string, projectName string, bucketName string, bucketPath string) (*Process, error) {
+	miniosMu.Lock()
+	defer miniosMu.Unlock()
+
+	key := processKey(poolName, projectName, bucketName)
+	p, found := minios[key]
+	if found {
+		_, err := p.process.GetPid()
+		if err == nil {
+			return p, nil
+		}
+
+		_ = p.stop()
+	}
+
+	_, err := exec.LookPath("minio")
+	if err != nil {
+		return nil, fmt.Errorf("Required tool %q is missing", "minio")
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, fmt.Errorf("Failed allocating MinIO port: %w", err)
+	}
+
+	creds, err := s3.NewCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	configDir, err := os.MkdirTemp("", "lxd_minio_")
+	if err != nil {
+		return nil, fmt.Errorf("Failed creating MinIO client config directory: %w", err)
+	}
+
+	logPath := shared.LogPath(fmt.Sprintf("minio-%s_%s_%s.log", poolName, projectName, bucketName))
+	args := []string{"server", "--quiet", "--address", net.JoinHostPort(minioHost, fmt.Sprintf("%d", port)), bucketPath}
+
+	proc, err := subprocess.NewProcess("minio", args, logPath, logPath)
+	if err != nil {
+		_ = os.RemoveAll(configDir)
+		return nil, err
+	}
+
+	proc.Env = []string{
+		fmt.Sprintf("MINIO_ROOT_USER=%s", minioAdminUser),
+		fmt.Sprintf("MINIO_ROOT_PASSWORD=%s", creds.SecretKey),
+		"MINIO_BROWSER=off",
+	}
+
+	err = proc.Start()
+	if err != nil {
+		_ = os.RemoveAll(configDir)
+		return nil, fmt.Errorf("Failed starting MinIO for bucket %q: %w", bucketName, err)
+	}
+
+	p = &Process{
+		key:        key,
+		bucketName: bucketName,
+		bucketPath: bucketPath,
+		port:       port,
+		password:   creds.SecretKey,
+		configDir:  configDir,
+		process:    proc,
+	}
+
+	minios[key] = p
+
+	// Wait for MinIO to be ready.
+	ctx, cancel := context.WithTimeout(context.Background(), minioStartTimeout)
+	defer cancel()
+
+	err = p.waitReady(ctx)
+	if err != nil {
+		_ = p.stop()
+		return nil, fmt.Errorf("Failed waiting for MinIO for bucket %q to start: %w", bucketName, err)
+	}
+
+	logger.Debug("MinIO started", logger.Ctx{"pool": poolName, "project": projectName, "bucket": bucketName, "port": port})
+
+	return p, nil
+}
+
+// waitReady waits until the MinIO process reports itself as healthy.
+func (p *Process) waitReady(ctx context.Context) error {
+	u := p.URL()
+	u.Path = "/minio/health/live"
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// StopAll stops all running MinIO processes.
+func StopAll() {
+	miniosMu.Lock()
+	defer miniosMu.Unlock()
+
+	for _, p := range minios {
+		err := p.stop()
+		if err != nil {
+			logger.Warn("Failed stopping MinIO", logger.Ctx{"bucket": p.key, "err": err})
+		}
+	}
+}
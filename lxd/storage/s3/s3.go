@@ -0,0 +1,148 @@
+package s3
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// RoleAdmin is the name of the role granting full access to a bucket.
+const RoleAdmin = "admin"
+
+// RoleReadOnly is the name of the role granting read-only access to a bucket.
+const RoleReadOnly = "read-only"
+
+// Roles lists the supported bucket key roles.
+var Roles = []string{RoleAdmin, RoleReadOnly}
+
+// Credentials represents the access and secret keys used to access a bucket.
+type Credentials struct {
+	AccessKey string
+	SecretKey string
+}
+
+// bucketNameRegexp matches valid S3 bucket names.
+var bucketNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// ValidateBucketName validates a bucket name against the S3 bucket naming rules.
+func ValidateBucketName(bucketName string) error {
+	if !bucketNameRegexp.MatchString(bucketName) {
+		return fmt.Errorf("Bucket name must be between 3 and 63 characters long and contain only lowercase letters, numbers, dots and hyphens, beginning and ending with a letter or number")
+	}
+
+	if strings.Contains(bucketName, "..") {
+		return fmt.Errorf("Bucket name must not contain two adjacent dots")
+	}
+
+	if net.ParseIP(bucketName) != nil {
+		return fmt.Errorf("Bucket name must not be formatted as an IP address")
+	}
+
+	return nil
+}
+
+// ValidateRole validates a bucket key role name.
+func ValidateRole(roleName string) error {
+	if !shared.StringInSlice(roleName, Roles) {
+		return fmt.Errorf("Invalid bucket key role %q", roleName)
+	}
+
+	return nil
+}
+
+// randomString returns a random string of the requested length using the supplied alphabet.
+func randomString(length int, alphabet string) (string, error) {
+	buf := make([]byte, length)
+	max := big.NewInt(int64(len(alphabet)))
+
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		buf[i] = alphabet[n.Int64()]
+	}
+
+	return string(buf), nil
+}
+
+// NewCredentials generates a new random set of credentials.
+func NewCredentials() (*Credentials, error) {
+	accessKey, err := randomString(16, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+	if err != nil {
+		return nil, fmt.Errorf("Failed generating access key: %w", err)
+	}
+
+	secretKey, err := randomString(32, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789")
+	if err != nil {
+		return nil, fmt.Errorf("Failed generating secret key: %w", err)
+	}
+
+	return &Credentials{AccessKey: accessKey, SecretKey: secretKey}, nil
+}
+
+// FillCredentials generates any of the access and secret keys that are missing from the supplied credentials.
+func FillCredentials(creds Credentials) (*Credentials, error) {
+	newCreds, err := NewCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	if creds.AccessKey != "" {
+		newCreds.AccessKey = creds.AccessKey
+	}
+
+	if creds.SecretKey != "" {
+		newCreds.SecretKey = creds.SecretKey
+	}
+
+	return newCreds, nil
+}
+
+// policyStatement represents a single statement in an S3 bucket policy.
+type policyStatement struct {
+	Effect   string
+	Action   []string
+	Resource []string
+}
+
+// policy represents an S3 bucket policy.
+type policy struct {
+	Version   string
+	Statement []policyStatement
+}
+
+// BucketPolicy returns the S3 policy document restricting access to the specified bucket for the given role.
+func BucketPolicy(bucketName string, roleName string) (json.RawMessage, error) {
+	var actions []string
+
+	switch roleName {
+	case RoleAdmin:
+		actions = []string{"s3:*"}
+	case RoleReadOnly:
+		actions = []string{"s3:ListBucket", "s3:GetBucketLocation", "s3:GetObject", "s3:GetObjectVersion"}
+	default:
+		return nil, fmt.Errorf("Invalid bucket key role %q", roleName)
+	}
+
+	p := policy{
+		Version: "2012-10-17",
+		Statement: []policyStatement{{
+			Effect: "Allow",
+			Action: actions,
+			Resource: []string{
+				fmt.Sprintf("arn:aws:s3:::%s", bucketName),
+				fmt.Sprintf("arn:aws:s3:::%s/*", bucketName),
+			},
+		}},
+	}
+
+	return json.Marshal(p)
+}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -118,6 +119,22 @@ func VolumeDBTypeToType(volDBType int) (drivers.VolumeType, error) {
 	return "", fmt.Errorf("Invalid storage volume type")
 }
 
+// VolumeTypeToDBTypeName converts storage driver volume type to volume type name.
+func VolumeTypeToDBTypeName(volType drivers.VolumeType) (string, error) {
+	switch volType {
+	case drivers.VolumeTypeContainer:
+		return db.StoragePoolVolumeTypeNameContainer, nil
+	case drivers.VolumeTypeVM:
+		return db.StoragePoolVolumeTypeNameVM, nil
+	case drivers.VolumeTypeImage:
+		return db.StoragePoolVolumeTypeNameImage, nil
+	case drivers.VolumeTypeCustom:
+		return db.StoragePoolVolumeTypeNameCustom, nil
+	}
+
+	return "", fmt.Errorf("Invalid storage volume type")
+}
+
 // InstanceTypeToVolumeType converts instance type to storage driver volume type.
 func InstanceTypeToVolumeType(instType instancetype.Type) (drivers.VolumeType, error) {
 	switch instType {
@@ -336,6 +353,10 @@ func validateVolumeCommonRules(vol drivers.Volume) map[string]func(string) error
 		},
 		"snapshots.schedule": validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly"})),
 		"snapshots.pattern":  validate.IsAny,
+
+		"snapshots.retention.hourly": validate.Optional(validate.IsUint32),
+		"snapshots.retention.daily":  validate.Optional(validate.IsUint32),
+		"snapshots.retention.weekly": validate.Optional(validate.IsUint32),
 	}
 
 	// volatile.idmap settings only make sense for filesystem volumes.
@@ -361,6 +382,25 @@ func validateVolumeCommonRules(vol drivers.Volume) map[string]func(string) error
 		rules["security.unmapped"] = validate.Optional(validate.IsBool)
 	}
 
+	// IO limits are enforced by the disk devices attaching custom volumes.
+	if vol.Type() == drivers.VolumeTypeCustom {
+		rules["limits.iops.read"] = validate.Optional(validate.IsUint32)
+		rules["limits.iops.write"] = validate.Optional(validate.IsUint32)
+		rules["limits.bandwidth.read"] = validate.Optional(validate.IsSize)
+		rules["limits.bandwidth.write"] = validate.Optional(validate.IsSize)
+	}
+
+	// Replication to a remote LXD server is only supported for custom volumes.
+	if vol.Type() == drivers.VolumeTypeCustom {
+		rules["replication.target"] = validate.Optional(validate.IsRequestURL)
+		rules["replication.target.certificate"] = validate.IsAny
+		rules["replication.target.pool"] = validate.IsAny
+		rules["replication.target.project"] = validate.IsAny
+		rules["replication.schedule"] = validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly"}))
+		rules["volatile.replication.last_sync"] = validate.IsAny
+		rules["volatile.replication.last_snapshot"] = validate.IsAny
+	}
+
 	// volatile.rootfs.size is only used for image volumes.
 	if vol.Type() == drivers.VolumeTypeImage {
 		rules["volatile.rootfs.size"] = validate.Optional(validate.IsInt64)
@@ -369,6 +409,39 @@ func validateVolumeCommonRules(vol drivers.Volume) map[string]func(string) error
 	return rules
 }
 
+// blockDeviceIOStats returns the IO counters of the block device with the supplied major and minor numbers.
+func blockDeviceIOStats(major uint32, minor uint32) (*api.StorageVolumeStateIO, error) {
+	statPath := fmt.Sprintf("/sys/dev/block/%d:%d/stat", major, minor)
+
+	content, err := ioutil.ReadFile(statPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed reading %q: %w", statPath, err)
+	}
+
+	// The fields are documented in the kernel's Documentation/block/stat.rst, sectors are 512 bytes.
+	fields := strings.Fields(string(content))
+	if len(fields) < 7 {
+		return nil, fmt.Errorf("Unexpected format of %q", statPath)
+	}
+
+	values := make([]uint64, 7)
+	for i := range values {
+		values[i], err = strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value %q in %q: %w", fields[i], statPath, err)
+		}
+	}
+
+	stats := &api.StorageVolumeStateIO{
+		ReadsCompleted:  values[0],
+		ReadBytes:       values[2] * 512,
+		WritesCompleted: values[4],
+		WrittenBytes:    values[6] * 512,
+	}
+
+	return stats, nil
+}
+
 // ImageUnpack unpacks a filesystem image into the destination path.
 // There are several formats that images can come in:
 // Container Format A: Separate metadata tarball and root squashfs file.
@@ -0,0 +1,1060 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/lxd/response"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var storagePoolBucketsCmd = APIEndpoint{
+	Path: "storage-pools/{poolName}/buckets",
+
+	Get:  APIEndpointAction{Handler: storagePoolBucketsGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Post: APIEndpointAction{Handler: storagePoolBucketsPost, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+var storagePoolBucketCmd = APIEndpoint{
+	Path: "storage-pools/{poolName}/buckets/{bucketName}",
+
+	Delete: APIEndpointAction{Handler: storagePoolBucketDelete, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+	Get:    APIEndpointAction{Handler: storagePoolBucketGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Patch:  APIEndpointAction{Handler: storagePoolBucketPut, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+	Put:    APIEndpointAction{Handler: storagePoolBucketPut, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+var storagePoolBucketKeysCmd = APIEndpoint{
+	Path: "storage-pools/{poolName}/buckets/{bucketName}/keys",
+
+	Get:  APIEndpointAction{Handler: storagePoolBucketKeysGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Post: APIEndpointAction{Handler: storagePoolBucketKeysPost, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+var storagePoolBucketKeyCmd = APIEndpoint{
+	Path: "storage-pools/{poolName}/buckets/{bucketName}/keys/{keyName}",
+
+	Delete: APIEndpointAction{Handler: storagePoolBucketKeyDelete, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+	Get:    APIEndpointAction{Handler: storagePoolBucketKeyGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Put:    APIEndpointAction{Handler: storagePoolBucketKeyPut, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+// storagePoolBucketRequest loads the project, pool and bucket name referenced by the request.
+func storagePoolBucketRequest(d *Daemon, r *http.Request) (string, storagePools.Pool, string, error) {
+	projectName, err := project.StorageBucketProject(d.State().DB.Cluster, projectParam(r))
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	poolName, err := url.PathUnescape(mux.Vars(r)["poolName"])
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	pool, err := storagePools.LoadByName(d.State(), poolName)
+	if err != nil {
+		return "", nil, "", fmt.Errorf("Failed loading storage pool: %w", err)
+	}
+
+	if !pool.Driver().Info().Buckets {
+		return "", nil, "", api.StatusErrorf(http.StatusBadRequest, "Storage pool driver %q does not support buckets", pool.Driver().Info().Name)
+	}
+
+	bucketName, err := url.PathUnescape(mux.Vars(r)["bucketName"])
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	return projectName, pool, bucketName, nil
+}
+
+// storagePoolBucketFill populates the S3 URL of the bucket if it is reachable from this member.
+func storagePoolBucketFill(d *Daemon, pool storagePools.Pool, bucket *db.StorageBucket) {
+	if bucket.Location != "" && bucket.Location != d.State().ServerName {
+		return
+	}
+
+	u := pool.GetBucketURL(bucket.Name)
+	if u != nil {
+		bucket.S3URL = u.String()
+	}
+}
+
+// API endpoints
+
+// swagger:operation GET /1.0/storage-pools/{poolName}/buckets storage storage_pool_buckets_get
+//
+// Get the storage pool buckets
+//
+// Returns a list of storage pool buckets (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/storage-pools/default/buckets/foo",
+//               "/1.0/storage-pools/default/buckets/bar",
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/storage-pools/{poolName}/buckets?recursion=1 storage storage_pool_buckets_get_recursion1
+//
+// Get the storage pool buckets
+//
+// Returns a list of storage pool buckets (structs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of storage pool buckets
+//           items:
+//             $ref: "#/definitions/StorageBucket"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolBucketsGet(d *Daemon, r *http.Request) response.Response {
+	projectName, pool, _, err := storagePoolBucketRequest(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	poolID := pool.ID()
+	filter := db.StorageBucketFilter{
+		PoolID:  &poolID,
+		Project: &projectName,
+	}
+
+	var dbBuckets []*db.StorageBucket
+	err = d.State().DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		dbBuckets, err = tx.GetStoragePoolBuckets(false, filter)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading storage buckets: %w", err))
+	}
+
+	if util.IsRecursionRequest(r) {
+		buckets := make([]*api.StorageBucket, 0, len(dbBuckets))
+		for _, dbBucket := range dbBuckets {
+			storagePoolBucketFill(d, pool, dbBucket)
+			buckets = append(buckets, &dbBucket.StorageBucket)
+		}
+
+		return response.SyncResponse(true, buckets)
+	}
+
+	urls := make([]string, 0, len(dbBuckets))
+	for _, dbBucket := range dbBuckets {
+		u := api.NewURL().Path(version.APIVersion, "storage-pools", pool.Name(), "buckets", dbBucket.Name)
+		urls = append(urls, u.String())
+	}
+
+	return response.SyncResponse(true, urls)
+}
+
+// swagger:operation GET /1.0/storage-pools/{poolName}/buckets/{bucketName} storage storage_pool_bucket_get
+//
+// Get the storage pool bucket
+//
+// Gets a specific storage pool bucket.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: Storage pool bucket
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/StorageBucket"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolBucketGet(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, pool, bucketName, err := storagePoolBucketRequest(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp = forwardedResponseIfBucketIsRemote(d, r, pool.ID(), projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	var bucket *db.StorageBucket
+	err = d.State().DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		bucket, err = tx.GetStoragePoolBucket(pool.ID(), projectName, !pool.Driver().Info().Remote, bucketName)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	storagePoolBucketFill(d, pool, bucket)
+
+	return response.SyncResponseETag(true, &bucket.StorageBucket, bucket.Etag())
+}
+
+// swagger:operation POST /1.0/storage-pools/{poolName}/buckets storage storage_pool_bucket_post
+//
+// Add a storage pool bucket.
+//
+// Creates a new storage pool bucket.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+//   - in: body
+//     name: bucket
+//     description: Bucket
+//     required: true
+//     schema:
+//       $ref: "#/definitions/StorageBucketsPost"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolBucketsPost(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, pool, _, err := storagePoolBucketRequest(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Parse the request into a record.
+	req := api.StorageBucketsPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = pool.CreateBucket(projectName, req, nil)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed creating storage bucket: %w", err))
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.StorageBucketCreated.Event(pool.Name(), projectName, req.Name, request.CreateRequestor(r), nil))
+
+	u := api.NewURL().Path(version.APIVersion, "storage-pools", pool.Name(), "buckets", req.Name)
+
+	return response.SyncResponseLocation(true, nil, u.String())
+}
+
+// swagger:operation PATCH /1.0/storage-pools/{poolName}/buckets/{bucketName} storage storage_pool_bucket_patch
+//
+// Partially update the storage bucket.
+//
+// Updates a subset of the storage bucket configuration.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+//   - in: body
+//     name: storage bucket
+//     description: Storage bucket configuration
+//     required: true
+//     schema:
+//       $ref: "#/definitions/StorageBucketPut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation PUT /1.0/storage-pools/{poolName}/buckets/{bucketName} storage storage_pool_bucket_put
+//
+// Update the storage bucket
+//
+// Updates the entire storage bucket configuration.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+//   - in: body
+//     name: storage bucket
+//     description: Storage bucket configuration
+//     required: true
+//     schema:
+//       $ref: "#/definitions/StorageBucketPut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolBucketPut(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, pool, bucketName, err := storagePoolBucketRequest(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp = forwardedResponseIfBucketIsRemote(d, r, pool.ID(), projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	var bucket *db.StorageBucket
+	err = d.State().DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		bucket, err = tx.GetStoragePoolBucket(pool.ID(), projectName, !pool.Driver().Info().Remote, bucketName)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	err = util.EtagCheck(r, bucket.Etag())
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// Decode the request.
+	req := api.StorageBucketPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if r.Method == http.MethodPatch {
+		// Merge existing config into the request for keys not specified.
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+
+		for k, v := range bucket.Config {
+			_, found := req.Config[k]
+			if !found {
+				req.Config[k] = v
+			}
+		}
+	}
+
+	err = pool.UpdateBucket(projectName, bucketName, req, nil)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed updating storage bucket: %w", err))
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.StorageBucketUpdated.Event(pool.Name(), projectName, bucketName, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation DELETE /1.0/storage-pools/{poolName}/buckets/{bucketName} storage storage_pool_bucket_delete
+//
+// Delete the storage bucket
+//
+// Removes the storage bucket.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolBucketDelete(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, pool, bucketName, err := storagePoolBucketRequest(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp = forwardedResponseIfBucketIsRemote(d, r, pool.ID(), projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	err = pool.DeleteBucket(projectName, bucketName, nil)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed deleting storage bucket: %w", err))
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.StorageBucketDeleted.Event(pool.Name(), projectName, bucketName, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// storagePoolBucketLoad loads the bucket record referenced by the request.
+func storagePoolBucketLoad(d *Daemon, pool storagePools.Pool, projectName string, bucketName string) (*db.StorageBucket, error) {
+	var bucket *db.StorageBucket
+	err := d.State().DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		bucket, err = tx.GetStoragePoolBucket(pool.ID(), projectName, !pool.Driver().Info().Remote, bucketName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bucket, nil
+}
+
+// swagger:operation GET /1.0/storage-pools/{poolName}/buckets/{bucketName}/keys storage storage_pool_bucket_keys_get
+//
+// Get the storage pool bucket keys
+//
+// Returns a list of storage pool bucket keys (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/storage-pools/default/buckets/foo/keys/my-read-only-key",
+//               "/1.0/storage-pools/default/buckets/foo/keys/my-admin-key"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/storage-pools/{poolName}/buckets/{bucketName}/keys?recursion=1 storage storage_pool_bucket_keys_get_recursion1
+//
+// Get the storage pool bucket keys
+//
+// Returns a list of storage pool bucket keys (structs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of storage pool bucket keys
+//           items:
+//             $ref: "#/definitions/StorageBucketKey"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolBucketKeysGet(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, pool, bucketName, err := storagePoolBucketRequest(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp = forwardedResponseIfBucketIsRemote(d, r, pool.ID(), projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	bucket, err := storagePoolBucketLoad(d, pool, projectName, bucketName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var dbKeys []*db.StorageBucketKey
+	err = d.State().DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		dbKeys, err = tx.GetStoragePoolBucketKeys(bucket.ID)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading storage bucket keys: %w", err))
+	}
+
+	if util.IsRecursionRequest(r) {
+		keys := make([]*api.StorageBucketKey, 0, len(dbKeys))
+		for _, dbKey := range dbKeys {
+			keys = append(keys, &dbKey.StorageBucketKey)
+		}
+
+		return response.SyncResponse(true, keys)
+	}
+
+	urls := make([]string, 0, len(dbKeys))
+	for _, dbKey := range dbKeys {
+		u := api.NewURL().Path(version.APIVersion, "storage-pools", pool.Name(), "buckets", bucketName, "keys", dbKey.Name)
+		urls = append(urls, u.String())
+	}
+
+	return response.SyncResponse(true, urls)
+}
+
+// swagger:operation GET /1.0/storage-pools/{poolName}/buckets/{bucketName}/keys/{keyName} storage storage_pool_bucket_key_get
+//
+// Get the storage pool bucket key
+//
+// Gets a specific storage pool bucket key.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: Storage pool bucket key
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/StorageBucketKey"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolBucketKeyGet(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, pool, bucketName, err := storagePoolBucketRequest(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp = forwardedResponseIfBucketIsRemote(d, r, pool.ID(), projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	keyName, err := url.PathUnescape(mux.Vars(r)["keyName"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	bucket, err := storagePoolBucketLoad(d, pool, projectName, bucketName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var key *db.StorageBucketKey
+	err = d.State().DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		key, err = tx.GetStoragePoolBucketKey(bucket.ID, keyName)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, &key.StorageBucketKey, key.Etag())
+}
+
+// swagger:operation POST /1.0/storage-pools/{poolName}/buckets/{bucketName}/keys storage storage_pool_bucket_key_post
+//
+// Add a storage pool bucket key.
+//
+// Creates a new storage pool bucket key.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+//   - in: body
+//     name: bucket
+//     description: Bucket key
+//     required: true
+//     schema:
+//       $ref: "#/definitions/StorageBucketKeysPost"
+// responses:
+//   "200":
+//     description: Storage pool bucket key
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/StorageBucketKey"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolBucketKeysPost(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, pool, bucketName, err := storagePoolBucketRequest(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp = forwardedResponseIfBucketIsRemote(d, r, pool.ID(), projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	// Parse the request into a record.
+	req := api.StorageBucketKeysPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	key, err := pool.CreateBucketKey(projectName, bucketName, req, nil)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed creating storage bucket key: %w", err))
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.StorageBucketKeyCreated.Event(pool.Name(), projectName, bucketName, req.Name, request.CreateRequestor(r), nil))
+
+	u := api.NewURL().Path(version.APIVersion, "storage-pools", pool.Name(), "buckets", bucketName, "keys", req.Name)
+
+	return response.SyncResponseLocation(true, key, u.String())
+}
+
+// swagger:operation PUT /1.0/storage-pools/{poolName}/buckets/{bucketName}/keys/{keyName} storage storage_pool_bucket_key_put
+//
+// Update the storage bucket key
+//
+// Updates the entire storage bucket key configuration.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+//   - in: body
+//     name: storage bucket
+//     description: Storage bucket key configuration
+//     required: true
+//     schema:
+//       $ref: "#/definitions/StorageBucketKeyPut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolBucketKeyPut(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, pool, bucketName, err := storagePoolBucketRequest(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp = forwardedResponseIfBucketIsRemote(d, r, pool.ID(), projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	keyName, err := url.PathUnescape(mux.Vars(r)["keyName"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	bucket, err := storagePoolBucketLoad(d, pool, projectName, bucketName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var key *db.StorageBucketKey
+	err = d.State().DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		key, err = tx.GetStoragePoolBucketKey(bucket.ID, keyName)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	err = util.EtagCheck(r, key.Etag())
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// Decode the request.
+	req := api.StorageBucketKeyPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = pool.UpdateBucketKey(projectName, bucketName, keyName, req, nil)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed updating storage bucket key: %w", err))
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.StorageBucketKeyUpdated.Event(pool.Name(), projectName, bucketName, keyName, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation DELETE /1.0/storage-pools/{poolName}/buckets/{bucketName}/keys/{keyName} storage storage_pool_bucket_key_delete
+//
+// Delete the storage bucket key
+//
+// Removes the storage bucket key.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolBucketKeyDelete(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, pool, bucketName, err := storagePoolBucketRequest(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp = forwardedResponseIfBucketIsRemote(d, r, pool.ID(), projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	keyName, err := url.PathUnescape(mux.Vars(r)["keyName"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = pool.DeleteBucketKey(projectName, bucketName, keyName, nil)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed deleting storage bucket key: %w", err))
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.StorageBucketKeyDeleted.Event(pool.Name(), projectName, bucketName, keyName, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
@@ -16,7 +16,9 @@ import (
 	clusterRequest "github.com/lxc/lxd/lxd/cluster/request"
 	"github.com/lxc/lxd/lxd/db"
 	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/db/operationtype"
 	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
@@ -47,6 +49,18 @@ var storagePoolCmd = APIEndpoint{
 	Put:    APIEndpointAction{Handler: storagePoolPut},
 }
 
+var storagePoolCheckCmd = APIEndpoint{
+	Path: "storage-pools/{name}/check",
+
+	Post: APIEndpointAction{Handler: storagePoolCheckPost},
+}
+
+var storagePoolDeduplicateCmd = APIEndpoint{
+	Path: "storage-pools/{name}/deduplicate",
+
+	Post: APIEndpointAction{Handler: storagePoolDeduplicatePost},
+}
+
 // swagger:operation GET /1.0/storage-pools storage storage_pools_get
 //
 // Get the storage pools
@@ -984,3 +998,156 @@ func storagePoolDelete(d *Daemon, r *http.Request) response.Response {
 
 	return response.EmptySyncResponse
 }
+
+// swagger:operation POST /1.0/storage-pools/{name}/check storage storage_pool_check_post
+//
+// Check the storage pool
+//
+// Compares the volumes on the storage pool with the volume records in the database.
+// The issues found (orphaned volumes, missing volumes, wrong quotas and wrong content types) are
+// returned in the "issues" field of the operation metadata, and optionally repaired.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+//   - in: body
+//     name: check
+//     description: Storage pool check request
+//     required: true
+//     schema:
+//       $ref: "#/definitions/StoragePoolCheckPost"
+// responses:
+//   "202":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolCheckPost(d *Daemon, r *http.Request) response.Response {
+	// If a target was specified, forward the request to the relevant node.
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	poolName, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.StoragePoolCheckPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	pool, err := storagePools.LoadByName(d.State(), poolName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if pool.LocalStatus() != api.StoragePoolStatusCreated {
+		return response.BadRequest(fmt.Errorf("Storage pool %q isn't available on this member", pool.Name()))
+	}
+
+	run := func(op *operations.Operation) error {
+		issues, err := pool.CheckVolumes(req.Repair, op)
+		if err != nil {
+			return fmt.Errorf("Failed checking storage pool %q: %w", pool.Name(), err)
+		}
+
+		return op.UpdateMetadata(map[string]any{"issues": issues})
+	}
+
+	resources := map[string][]string{}
+	resources["storage_pools"] = []string{pool.Name()}
+
+	op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, operationtype.StoragePoolCheck, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// swagger:operation POST /1.0/storage-pools/{name}/deduplicate storage storage_pool_deduplicate_post
+//
+// Deduplicate the storage pool
+//
+// Shares identical data between the instance and custom volumes on the storage pool,
+// skipping the volumes used by running instances.
+// The amount of data deduplicated and the disk space freed are returned in the
+// "deduplicated" and "saved" fields of the operation metadata.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "202":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolDeduplicatePost(d *Daemon, r *http.Request) response.Response {
+	// If a target was specified, forward the request to the relevant node.
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	poolName, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	pool, err := storagePools.LoadByName(d.State(), poolName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if pool.LocalStatus() != api.StoragePoolStatusCreated {
+		return response.BadRequest(fmt.Errorf("Storage pool %q isn't available on this member", pool.Name()))
+	}
+
+	if !pool.Driver().Info().Deduplication {
+		return response.BadRequest(fmt.Errorf("Storage pool %q doesn't support deduplication", pool.Name()))
+	}
+
+	run := func(op *operations.Operation) error {
+		result, err := pool.Deduplicate(op)
+		if err != nil {
+			return fmt.Errorf("Failed deduplicating storage pool %q: %w", pool.Name(), err)
+		}
+
+		return op.UpdateMetadata(map[string]any{"deduplicated": result.Deduplicated, "saved": result.Saved})
+	}
+
+	resources := map[string][]string{}
+	resources["storage_pools"] = []string{pool.Name()}
+
+	op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, operationtype.StoragePoolDeduplicate, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/lxd/warnings"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// Storage pool fill levels, in increasing order.
+const (
+	storagePoolFillNormal = iota
+	storagePoolFillWarning
+	storagePoolFillCritical
+)
+
+func checkStoragePoolsFillTask(d *Daemon) (task.Func, task.Schedule) {
+	// Last fill level seen for each pool, so lifecycle events are only sent when a threshold is crossed.
+	levels := map[string]int{}
+
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		poolNames, err := s.DB.Cluster.GetStoragePoolNames()
+		if err != nil {
+			if !response.IsNotFoundError(err) {
+				logger.Error("Failed to get storage pools", logger.Ctx{"err": err})
+			}
+
+			return
+		}
+
+		for _, poolName := range poolNames {
+			if ctx.Err() != nil {
+				return
+			}
+
+			level, err := checkStoragePoolFill(s, poolName, levels[poolName])
+			if err != nil {
+				logger.Warn("Failed checking storage pool fill level", logger.Ctx{"pool": poolName, "err": err})
+				continue
+			}
+
+			levels[poolName] = level
+		}
+	}
+
+	schedule := task.Every(5 * time.Minute)
+
+	return f, schedule
+}
+
+// checkStoragePoolFill compares the usage of the pool against its fill_threshold.warning and
+// fill_threshold.critical settings, raising or resolving the matching warnings on the local member and sending a
+// lifecycle event when the pool goes above a threshold it wasn't above at the previous check. It returns the
+// current fill level of the pool.
+func checkStoragePoolFill(s *state.State, poolName string, previousLevel int) (int, error) {
+	pool, err := storagePools.LoadByName(s, poolName)
+	if err != nil {
+		return previousLevel, err
+	}
+
+	config := pool.Driver().Config()
+	if config["fill_threshold.warning"] == "" && config["fill_threshold.critical"] == "" {
+		// Clear any warning left over from thresholds that were since unset.
+		if previousLevel != storagePoolFillNormal {
+			resolveStoragePoolFillWarnings(s, pool.ID(), storagePoolFillNormal)
+		}
+
+		return storagePoolFillNormal, nil
+	}
+
+	res, err := pool.GetResources()
+	if err != nil {
+		return previousLevel, err
+	}
+
+	if res.Space.Total == 0 {
+		return previousLevel, nil
+	}
+
+	usedPercent := float64(res.Space.Used) * 100 / float64(res.Space.Total)
+
+	level := storagePoolFillNormal
+	for _, threshold := range []struct {
+		key   string
+		level int
+	}{
+		{key: "fill_threshold.warning", level: storagePoolFillWarning},
+		{key: "fill_threshold.critical", level: storagePoolFillCritical},
+	} {
+		if config[threshold.key] == "" {
+			continue
+		}
+
+		limit, err := strconv.Atoi(config[threshold.key])
+		if err != nil {
+			return previousLevel, fmt.Errorf("Invalid %q setting: %w", threshold.key, err)
+		}
+
+		if usedPercent >= float64(limit) {
+			level = threshold.level
+		}
+	}
+
+	resolveStoragePoolFillWarnings(s, pool.ID(), level)
+
+	if level == storagePoolFillNormal {
+		return level, nil
+	}
+
+	warningType := db.WarningStoragePoolFillThreshold
+	if level == storagePoolFillCritical {
+		warningType = db.WarningStoragePoolFillCritical
+	}
+
+	msg := fmt.Sprintf("Storage pool is %.1f%% full (%d of %d bytes used, %d bytes provisioned)", usedPercent, res.Space.Used, res.Space.Total, res.Space.Provisioned)
+	err = s.DB.Cluster.UpsertWarningLocalNode("", cluster.TypeStoragePool, int(pool.ID()), warningType, msg)
+	if err != nil {
+		logger.Warn("Failed to create storage pool fill level warning", logger.Ctx{"pool": poolName, "err": err})
+	}
+
+	if level > previousLevel {
+		ctx := map[string]any{
+			"level":       db.WarningTypeNames[warningType],
+			"used":        res.Space.Used,
+			"total":       res.Space.Total,
+			"provisioned": res.Space.Provisioned,
+		}
+
+		s.Events.SendLifecycle(project.Default, lifecycle.StoragePoolFillThresholdExceeded.Event(poolName, project.Default, nil, ctx))
+	}
+
+	return level, nil
+}
+
+// resolveStoragePoolFillWarnings resolves the fill level warnings of the pool that don't match its current level.
+func resolveStoragePoolFillWarnings(s *state.State, poolID int64, level int) {
+	warningTypes := map[int]db.WarningType{
+		storagePoolFillWarning:  db.WarningStoragePoolFillThreshold,
+		storagePoolFillCritical: db.WarningStoragePoolFillCritical,
+	}
+
+	for warningLevel, warningType := range warningTypes {
+		if warningLevel == level {
+			continue
+		}
+
+		err := warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(s.DB.Cluster, "", warningType, cluster.TypeStoragePool, int(poolID))
+		if err != nil {
+			logger.Warn("Failed to resolve storage pool fill level warning", logger.Ctx{"poolID": poolID, "err": err})
+		}
+	}
+}
@@ -1830,10 +1830,20 @@ func createStoragePoolVolumeFromBackup(d *Daemon, r *http.Request, requestProjec
 	}
 
 	logger.Debug("Reading backup file info")
-	bInfo, err := backup.GetInfo(backupFile, d.State().OS, backupFile.Name())
+	bInfo, chainCleanup, err := backup.GetChainInfo(backupFile, d.State().OS, shared.VarPath("backups"))
 	if err != nil {
 		return response.BadRequest(err)
 	}
+
+	revert.Add(chainCleanup)
+
+	// Not an incremental backup chain, so parse as a regular backup file.
+	if bInfo == nil {
+		bInfo, err = backup.GetInfo(backupFile, d.State().OS, backupFile.Name())
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
 	bInfo.Project = projectName
 
 	// Override pool.
@@ -1888,6 +1898,7 @@ func createStoragePoolVolumeFromBackup(d *Daemon, r *http.Request, requestProjec
 
 	run := func(op *operations.Operation) error {
 		defer func() { _ = backupFile.Close() }()
+		defer chainCleanup()
 		defer runRevert.Fail()
 
 		pool, err := storagePools.LoadByName(d.State(), bInfo.Pool)
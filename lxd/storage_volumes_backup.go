@@ -205,7 +205,7 @@ func storagePoolVolumeTypeCustomBackupsGet(d *Daemon, r *http.Request) response.
 	backups := make([]*backup.VolumeBackup, len(volumeBackups))
 
 	for i, b := range volumeBackups {
-		backups[i] = backup.NewVolumeBackup(d.State(), projectName, poolName, volumeName, b.ID, b.Name, b.CreationDate, b.ExpiryDate, b.VolumeOnly, b.OptimizedStorage)
+		backups[i] = backup.NewVolumeBackup(d.State(), projectName, poolName, volumeName, b.ID, b.Name, b.CreationDate, b.ExpiryDate, b.VolumeOnly, b.OptimizedStorage, b.Parent)
 	}
 
 	resultString := []string{}
@@ -393,6 +393,24 @@ func storagePoolVolumeTypeCustomBackupsPost(d *Daemon, r *http.Request) response
 	fullName := volumeName + shared.SnapshotDelimiter + req.Name
 	volumeOnly := req.VolumeOnly
 
+	// Validate the parent backup for incremental backups.
+	parentName := ""
+	if req.Parent != "" {
+		if strings.Contains(req.Parent, "/") {
+			return response.BadRequest(fmt.Errorf("Parent backup names may not contain slashes"))
+		}
+
+		if volumeOnly {
+			return response.BadRequest(fmt.Errorf("Incremental backups cannot be volume only"))
+		}
+
+		parentName = volumeName + shared.SnapshotDelimiter + req.Parent
+		_, err = d.db.Cluster.GetStoragePoolVolumeBackup(projectName, poolName, parentName)
+		if err != nil {
+			return response.SmartError(fmt.Errorf("Failed loading parent backup %q: %w", req.Parent, err))
+		}
+	}
+
 	backup := func(op *operations.Operation) error {
 		args := db.StoragePoolVolumeBackup{
 			Name:                 fullName,
@@ -402,6 +420,7 @@ func storagePoolVolumeTypeCustomBackupsPost(d *Daemon, r *http.Request) response
 			VolumeOnly:           volumeOnly,
 			OptimizedStorage:     req.OptimizedStorage,
 			CompressionAlgorithm: req.CompressionAlgorithm,
+			Parent:               parentName,
 		}
 
 		err := volumeBackupCreate(d.State(), args, projectName, poolName, volumeName)
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/db/operationtype"
+	"github.com/lxc/lxd/lxd/metrics"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/revert"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/lxd/warnings"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// customVolumeReplicationSnapshotPrefix is the prefix of the snapshots taken to replicate custom volumes.
+const customVolumeReplicationSnapshotPrefix = "replication-"
+
+// customVolumeReplicationCache holds the custom volumes the local member replicates, so that the metrics don't
+// need to query the database and the cluster leader on each scrape. It is refreshed by the replication task.
+var customVolumeReplicationCache []db.StorageVolumeArgs
+var customVolumeReplicationCacheLoaded bool
+var customVolumeReplicationCacheLock sync.Mutex
+
+func autoReplicateCustomVolumesTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		replicatedVolumes, err := localReplicatedCustomVolumes(d)
+		if err != nil {
+			logger.Error("Failed to schedule custom volume replication", logger.Ctx{"err": err})
+			return
+		}
+
+		customVolumeReplicationCacheUpdate(replicatedVolumes)
+
+		var volumes []db.StorageVolumeArgs
+		for _, v := range replicatedVolumes {
+			schedule := v.Config["replication.schedule"]
+			if schedule != "" && snapshotIsScheduledNow(schedule, v.ID) {
+				volumes = append(volumes, v)
+			}
+		}
+
+		// Skip if there is nothing to replicate.
+		if len(volumes) == 0 {
+			return
+		}
+
+		opRun := func(op *operations.Operation) error {
+			autoReplicateCustomVolumes(ctx, d, volumes, op)
+			return nil
+		}
+
+		op, err := operations.OperationCreate(s, "", operations.OperationClassTask, operationtype.CustomVolumeReplicate, nil, nil, opRun, nil, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start custom volume replication operation", logger.Ctx{"err": err})
+			return
+		}
+
+		logger.Info("Replicating custom volumes")
+		err = op.Start()
+		if err != nil {
+			logger.Error("Failed to replicate custom volumes", logger.Ctx{"err": err})
+		}
+
+		_, _ = op.Wait(ctx)
+		logger.Info("Done replicating custom volumes")
+	}
+
+	first := true
+	schedule := func() (time.Duration, error) {
+		interval := time.Minute
+
+		if first {
+			first = false
+			return interval, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
+
+// localReplicatedCustomVolumes returns the custom volumes with a replication target that the local member is in
+// charge of replicating. Volumes on remote storage pools are visible from all
+// members, so only the leader replicates them.
+func localReplicatedCustomVolumes(d *Daemon) ([]db.StorageVolumeArgs, error) {
+	leader := true
+	localAddress, err := node.ClusterAddress(d.db.Node)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get current cluster member address: %w", err)
+	}
+
+	leaderAddress, err := d.gateway.LeaderAddress()
+	if err != nil {
+		if !errors.Is(err, cluster.ErrNodeIsNotClustered) {
+			return nil, fmt.Errorf("Failed to get leader cluster member address: %w", err)
+		}
+	} else {
+		leader = localAddress == leaderAddress
+	}
+
+	var volumes []db.StorageVolumeArgs
+	err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		allVolumes, err := tx.GetStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return fmt.Errorf("Failed getting custom volumes: %w", err)
+		}
+
+		localNodeID := tx.GetNodeID()
+		for _, v := range allVolumes {
+			if v.Config["replication.target"] == "" {
+				continue
+			}
+
+			if v.NodeID == localNodeID || (v.NodeID < 0 && leader) {
+				volumes = append(volumes, v)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return volumes, nil
+}
+
+func autoReplicateCustomVolumes(ctx context.Context, d *Daemon, volumes []db.StorageVolumeArgs, op *operations.Operation) {
+	// Replicate the volumes sequentially.
+	for _, v := range volumes {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := replicateCustomVolume(d, v, op)
+		if err != nil {
+			logger.Error("Failed to replicate custom volume", logger.Ctx{"err": err, "project": v.ProjectName, "pool": v.PoolName, "volume": v.Name})
+
+			warnErr := d.db.Cluster.UpsertWarningLocalNode(v.ProjectName, dbCluster.TypeStorageVolume, int(v.ID), db.WarningStorageVolumeReplicationFailure, fmt.Sprintf("%v", err))
+			if warnErr != nil {
+				logger.Warn("Failed to create volume replication failure warning", logger.Ctx{"err": warnErr, "volume": v.Name})
+			}
+
+			continue
+		}
+
+		// Resolve any previous warning.
+		warnErr := warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(d.db.Cluster, v.ProjectName, db.WarningStorageVolumeReplicationFailure, dbCluster.TypeStorageVolume, int(v.ID))
+		if warnErr != nil {
+			logger.Warn("Failed to resolve volume replication failure warning", logger.Ctx{"err": warnErr, "volume": v.Name})
+		}
+	}
+}
+
+// replicateCustomVolume snapshots the volume and pushes it with its snapshots to the configured replication target,
+// creating the target volume on first run and refreshing it afterwards. As the snapshot of the previous replication
+// is kept until the next one succeeds, optimized transfers only send the changes since that snapshot.
+// On success the start time of the replication is recorded in the volume's volatile.replication.last_sync key and
+// the new snapshot in its volatile.replication.last_snapshot key.
+func replicateCustomVolume(d *Daemon, v db.StorageVolumeArgs, op *operations.Operation) error {
+	s := d.State()
+	startTime := time.Now().UTC()
+
+	pool, err := storagePools.LoadByName(s, v.PoolName)
+	if err != nil {
+		return fmt.Errorf("Failed loading storage pool %q: %w", v.PoolName, err)
+	}
+
+	_, vol, err := s.DB.Cluster.GetLocalStoragePoolVolume(v.ProjectName, v.Name, db.StoragePoolVolumeTypeCustom, pool.ID())
+	if err != nil {
+		return fmt.Errorf("Failed loading storage volume: %w", err)
+	}
+
+	targetURL := strings.TrimSuffix(vol.Config["replication.target"], "/")
+	targetPool := vol.Config["replication.target.pool"]
+	if targetPool == "" {
+		targetPool = v.PoolName
+	}
+
+	targetProject := vol.Config["replication.target.project"]
+	if targetProject == "" {
+		targetProject = v.ProjectName
+	}
+
+	// Connect to the target server, authenticating with the server certificate.
+	serverCert := d.serverCert()
+	args := &lxd.ConnectionArgs{
+		TLSServerCert: vol.Config["replication.target.certificate"],
+		TLSClientCert: string(serverCert.PublicKey()),
+		TLSClientKey:  string(serverCert.PrivateKey()),
+		UserAgent:     version.UserAgent,
+	}
+
+	client, err := lxd.ConnectLXD(targetURL, args)
+	if err != nil {
+		return fmt.Errorf("Failed connecting to replication target %q: %w", targetURL, err)
+	}
+
+	client = client.UseProject(targetProject)
+
+	if !client.HasExtension("custom_volume_refresh") {
+		return fmt.Errorf("The replication target is missing the required \"custom_volume_refresh\" API extension")
+	}
+
+	info, err := client.GetConnectionInfo()
+	if err != nil {
+		return fmt.Errorf("Failed getting replication target connection info: %w", err)
+	}
+
+	// Refresh the target volume if it was replicated before.
+	refresh := true
+	_, _, err = client.GetStoragePoolVolume(targetPool, db.StoragePoolVolumeTypeNameCustom, v.Name)
+	if err != nil {
+		if !api.StatusErrorCheck(err, http.StatusNotFound) {
+			return fmt.Errorf("Failed checking target volume: %w", err)
+		}
+
+		refresh = false
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Snapshot the volume so that a consistent state is sent, and so the next replication can be sent
+	// incrementally from it.
+	snapName := customVolumeReplicationSnapshotPrefix + startTime.Format("20060102-150405")
+	err = pool.CreateCustomVolumeSnapshot(v.ProjectName, v.Name, snapName, time.Time{}, op)
+	if err != nil {
+		return fmt.Errorf("Failed creating replication snapshot: %w", err)
+	}
+
+	revert.Add(func() {
+		_ = pool.DeleteCustomVolumeSnapshot(v.ProjectName, fmt.Sprintf("%s/%s", v.Name, snapName), op)
+	})
+
+	// Don't carry the replication settings over to the target so it doesn't replicate the volume itself.
+	config := make(map[string]string, len(vol.Config))
+	for k, v := range vol.Config {
+		if strings.HasPrefix(k, "replication.") || strings.HasPrefix(k, "volatile.") {
+			continue
+		}
+
+		config[k] = v
+	}
+
+	req := api.StorageVolumesPost{
+		Name:        v.Name,
+		Type:        db.StoragePoolVolumeTypeNameCustom,
+		ContentType: vol.ContentType,
+		StorageVolumePut: api.StorageVolumePut{
+			Config:      config,
+			Description: vol.Description,
+		},
+		Source: api.StorageVolumeSource{
+			Type:    "migration",
+			Mode:    "push",
+			Refresh: refresh,
+		},
+	}
+
+	targetOp, _, err := client.RawOperation("POST", fmt.Sprintf("/storage-pools/%s/volumes/%s", url.PathEscape(targetPool), db.StoragePoolVolumeTypeNameCustom), req, "")
+	if err != nil {
+		return fmt.Errorf("Failed creating target volume: %w", err)
+	}
+
+	targetSecrets := map[string]string{}
+	for k, v := range targetOp.Get().Metadata {
+		targetSecrets[k] = v.(string)
+	}
+
+	ws, err := newStorageMigrationSource(false)
+	if err != nil {
+		_ = targetOp.Cancel()
+		return err
+	}
+
+	err = ws.ConnectTarget(info.Certificate, fmt.Sprintf("%s/1.0/operations/%s", targetURL, url.PathEscape(targetOp.Get().ID)), targetSecrets)
+	if err != nil {
+		_ = targetOp.Cancel()
+		return fmt.Errorf("Failed connecting to target migration: %w", err)
+	}
+
+	err = ws.DoStorage(s, v.ProjectName, v.PoolName, v.Name, op)
+	if err != nil {
+		_ = targetOp.Cancel()
+		return fmt.Errorf("Failed sending volume: %w", err)
+	}
+
+	err = targetOp.Wait()
+	if err != nil {
+		return fmt.Errorf("Failed receiving volume on target: %w", err)
+	}
+
+	// Record the successful sync, reloading the config to not override concurrent changes.
+	_, vol, err = s.DB.Cluster.GetLocalStoragePoolVolume(v.ProjectName, v.Name, db.StoragePoolVolumeTypeCustom, pool.ID())
+	if err != nil {
+		return fmt.Errorf("Failed loading storage volume: %w", err)
+	}
+
+	lastSnapName := vol.Config["volatile.replication.last_snapshot"]
+	vol.Config["volatile.replication.last_sync"] = startTime.Format(time.RFC3339)
+	vol.Config["volatile.replication.last_snapshot"] = snapName
+
+	err = s.DB.Cluster.UpdateStoragePoolVolume(v.ProjectName, v.Name, db.StoragePoolVolumeTypeCustom, pool.ID(), vol.Description, vol.Config)
+	if err != nil {
+		return fmt.Errorf("Failed recording volume replication: %w", err)
+	}
+
+	revert.Success()
+
+	customVolumeReplicationCacheSetConfig(v.ID, vol.Config)
+
+	// The snapshot of the previous replication is no longer needed, the target drops it on the next refresh.
+	if lastSnapName != "" && lastSnapName != snapName {
+		err = pool.DeleteCustomVolumeSnapshot(v.ProjectName, fmt.Sprintf("%s/%s", v.Name, lastSnapName), op)
+		if err != nil {
+			logger.Warn("Failed deleting previous replication snapshot", logger.Ctx{"err": err, "project": v.ProjectName, "pool": v.PoolName, "volume": v.Name, "snapshot": lastSnapName})
+		}
+	}
+
+	return nil
+}
+
+// customVolumeReplicationCacheUpdate replaces the cached custom volumes the local member replicates.
+func customVolumeReplicationCacheUpdate(volumes []db.StorageVolumeArgs) {
+	customVolumeReplicationCacheLock.Lock()
+	defer customVolumeReplicationCacheLock.Unlock()
+
+	customVolumeReplicationCache = volumes
+	customVolumeReplicationCacheLoaded = true
+}
+
+// customVolumeReplicationCacheSetConfig updates the config of a cached custom volume after its replication.
+func customVolumeReplicationCacheSetConfig(volumeID int64, config map[string]string) {
+	customVolumeReplicationCacheLock.Lock()
+	defer customVolumeReplicationCacheLock.Unlock()
+
+	volumes := make([]db.StorageVolumeArgs, 0, len(customVolumeReplicationCache))
+	for _, v := range customVolumeReplicationCache {
+		if v.ID == volumeID {
+			v.Config = config
+		}
+
+		volumes = append(volumes, v)
+	}
+
+	customVolumeReplicationCache = volumes
+}
+
+// customVolumeReplicationCacheGet returns the cached custom volumes the local member replicates, loading them if
+// the replication task hasn't run yet.
+func customVolumeReplicationCacheGet(d *Daemon) ([]db.StorageVolumeArgs, error) {
+	customVolumeReplicationCacheLock.Lock()
+	loaded := customVolumeReplicationCacheLoaded
+	volumes := customVolumeReplicationCache
+	customVolumeReplicationCacheLock.Unlock()
+
+	if loaded {
+		return volumes, nil
+	}
+
+	volumes, err := localReplicatedCustomVolumes(d)
+	if err != nil {
+		return nil, err
+	}
+
+	customVolumeReplicationCacheUpdate(volumes)
+
+	return volumes, nil
+}
+
+// customVolumeReplicationState returns the replication status of a custom volume from its config, or nil if the
+// volume has no replication target.
+func customVolumeReplicationState(config map[string]string) *api.StorageVolumeStateReplication {
+	if config["replication.target"] == "" {
+		return nil
+	}
+
+	state := &api.StorageVolumeStateReplication{
+		Target: config["replication.target"],
+		Lag:    -1,
+	}
+
+	lastSync, err := time.Parse(time.RFC3339, config["volatile.replication.last_sync"])
+	if err == nil {
+		state.LastSync = lastSync
+		state.Lag = int64(time.Since(lastSync).Seconds())
+	}
+
+	return state
+}
+
+// customVolumeReplicationMetrics returns the replication metrics of the custom volumes of the project that the
+// local member is in charge of replicating.
+func customVolumeReplicationMetrics(d *Daemon, projectName string) (*metrics.MetricSet, error) {
+	volumes, err := customVolumeReplicationCacheGet(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return customVolumeReplicationMetricSet(volumes, projectName), nil
+}
+
+// customVolumeReplicationMetricSet returns the replication metrics of the given custom volumes that are in the
+// project and were replicated at least once.
+func customVolumeReplicationMetricSet(volumes []db.StorageVolumeArgs, projectName string) *metrics.MetricSet {
+	set := metrics.NewMetricSet(map[string]string{"project": projectName})
+	for _, v := range volumes {
+		if v.ProjectName != projectName {
+			continue
+		}
+
+		state := customVolumeReplicationState(v.Config)
+		if state == nil || state.Lag < 0 {
+			continue
+		}
+
+		labels := map[string]string{"pool": v.PoolName, "name": v.Name, "target": state.Target}
+		set.AddSamples(metrics.StorageVolumeReplicationLastSyncTimestampSeconds, metrics.Sample{Value: float64(state.LastSync.Unix()), Labels: labels})
+
+		labels = map[string]string{"pool": v.PoolName, "name": v.Name, "target": state.Target}
+		set.AddSamples(metrics.StorageVolumeReplicationLagSeconds, metrics.Sample{Value: float64(state.Lag), Labels: labels})
+	}
+
+	return set
+}
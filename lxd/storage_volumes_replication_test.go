@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/metrics"
+)
+
+func TestCustomVolumeReplicationState(t *testing.T) {
+	lastSync := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	// Volumes without a replication target have no replication state.
+	assert.Nil(t, customVolumeReplicationState(map[string]string{}))
+
+	// Volumes that were never replicated have no lag.
+	state := customVolumeReplicationState(map[string]string{"replication.target": "https://192.0.2.1:8443"})
+	require.NotNil(t, state)
+	assert.Equal(t, "https://192.0.2.1:8443", state.Target)
+	assert.True(t, state.LastSync.IsZero())
+	assert.Equal(t, int64(-1), state.Lag)
+
+	// The lag is the time elapsed since the last sync.
+	state = customVolumeReplicationState(map[string]string{"replication.target": "https://192.0.2.1:8443", "volatile.replication.last_sync": lastSync.Format(time.RFC3339)})
+	require.NotNil(t, state)
+	assert.Equal(t, lastSync, state.LastSync)
+	assert.InDelta(t, time.Hour.Seconds(), state.Lag, 5)
+}
+
+func TestCustomVolumeReplicationMetricSet(t *testing.T) {
+	lastSync := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+
+	volumes := []db.StorageVolumeArgs{
+		{Name: "synced", PoolName: "default", ProjectName: "default", Config: map[string]string{"replication.target": "https://192.0.2.1:8443", "volatile.replication.last_sync": lastSync.Format(time.RFC3339)}},
+		{Name: "pending", PoolName: "default", ProjectName: "default", Config: map[string]string{"replication.target": "https://192.0.2.1:8443"}},
+		{Name: "other", PoolName: "default", ProjectName: "other", Config: map[string]string{"replication.target": "https://192.0.2.1:8443", "volatile.replication.last_sync": lastSync.Format(time.RFC3339)}},
+	}
+
+	set := customVolumeReplicationMetricSet(volumes, "default")
+
+	// Only the replicated volumes of the project are included.
+	samples := set.Samples(metrics.StorageVolumeReplicationLastSyncTimestampSeconds)
+	require.Len(t, samples, 1)
+	assert.Equal(t, map[string]string{"project": "default", "pool": "default", "name": "synced", "target": "https://192.0.2.1:8443"}, samples[0].Labels)
+	assert.Equal(t, float64(lastSync.Unix()), samples[0].Value)
+
+	samples = set.Samples(metrics.StorageVolumeReplicationLagSeconds)
+	require.Len(t, samples, 1)
+	assert.InDelta(t, time.Minute.Seconds(), samples[0].Value, 5)
+
+	// Projects without replicated volumes have no samples.
+	set = customVolumeReplicationMetricSet(volumes, "empty")
+	assert.Empty(t, set.Samples(metrics.StorageVolumeReplicationLastSyncTimestampSeconds))
+	assert.Empty(t, set.Samples(metrics.StorageVolumeReplicationLagSeconds))
+}
+
+func TestCustomVolumeReplicationCache(t *testing.T) {
+	defer customVolumeReplicationCacheUpdate(nil)
+
+	cached := []db.StorageVolumeArgs{
+		{ID: 1, Name: "vol1", Config: map[string]string{"replication.target": "https://192.0.2.1:8443"}},
+		{ID: 2, Name: "vol2", Config: map[string]string{"replication.target": "https://192.0.2.1:8443"}},
+	}
+
+	customVolumeReplicationCacheUpdate(cached)
+
+	// The cache is used once loaded, without loading the volumes.
+	volumes, err := customVolumeReplicationCacheGet(nil)
+	require.NoError(t, err)
+	assert.Equal(t, cached, volumes)
+
+	// Recording a replication updates the cached volume only, and doesn't modify previously returned volumes.
+	config := map[string]string{"replication.target": "https://192.0.2.1:8443", "volatile.replication.last_sync": "2022-01-01T00:00:00Z"}
+	customVolumeReplicationCacheSetConfig(2, config)
+
+	updated, err := customVolumeReplicationCacheGet(nil)
+	require.NoError(t, err)
+	assert.Equal(t, config, updated[1].Config)
+	assert.Equal(t, cached[0].Config, updated[0].Config)
+	assert.Empty(t, volumes[1].Config["volatile.replication.last_sync"])
+}
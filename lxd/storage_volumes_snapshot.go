@@ -24,6 +24,7 @@ import (
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/lxd/warnings"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
@@ -1314,6 +1315,20 @@ func autoCreateCustomVolumeSnapshots(ctx context.Context, d *Daemon, volumes []d
 			err = pool.CreateCustomVolumeSnapshot(v.ProjectName, v.Name, snapshotName, expiry, nil)
 			if err != nil {
 				logger.Error("Error creating volume snapshot", logger.Ctx{"err": err, "volume": v})
+
+				warnErr := d.db.Cluster.UpsertWarningLocalNode(v.ProjectName, dbCluster.TypeStorageVolume, int(v.ID), db.WarningScheduledSnapshotFailure, fmt.Sprintf("%v", err))
+				if warnErr != nil {
+					logger.Warn("Failed to create scheduled snapshot failure warning", logger.Ctx{"err": warnErr, "volume": v})
+				}
+
+				ch <- struct{}{}
+				return
+			}
+
+			// Resolve any previous warning.
+			warnErr := warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(d.db.Cluster, v.ProjectName, db.WarningScheduledSnapshotFailure, dbCluster.TypeStorageVolume, int(v.ID))
+			if warnErr != nil {
+				logger.Warn("Failed to resolve scheduled snapshot failure warning", logger.Ctx{"err": warnErr, "volume": v})
 			}
 
 			ch <- struct{}{}
@@ -28,7 +28,7 @@ var storagePoolVolumeTypeStateCmd = APIEndpoint{
 //
 // Get the storage volume state
 //
-// Gets a specific storage volume state (usage data).
+// Gets a specific storage volume state (usage data and IO counters).
 //
 // ---
 // produces:
@@ -163,5 +163,15 @@ func storagePoolVolumeTypeStateGet(d *Daemon, r *http.Request) response.Response
 		state.Usage.Total = total
 	}
 
+	// Fetch the IO counters.
+	if volumeType == db.StoragePoolVolumeTypeCustom {
+		state.IO, err = pool.GetCustomVolumeIOStats(projectName, volumeName)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		state.Replication = customVolumeReplicationState(vol.Config)
+	}
+
 	return response.SyncResponse(true, state)
 }
@@ -33,6 +33,12 @@ type InstanceBackupsPost struct {
 	//
 	// API extension: backup_compression_algorithm
 	CompressionAlgorithm string `json:"compression_algorithm" yaml:"compression_algorithm"`
+
+	// Name of the parent backup to make an incremental backup against
+	// Example: backup0
+	//
+	// API extension: backup_incremental
+	Parent string `json:"parent" yaml:"parent"`
 }
 
 // InstanceBackup represents a LXD instance backup.
@@ -64,6 +70,12 @@ type InstanceBackup struct {
 	// Whether to use a pool-optimized binary format (instead of plain tarball)
 	// Example: true
 	OptimizedStorage bool `json:"optimized_storage" yaml:"optimized_storage"`
+
+	// Name of the parent backup (for incremental backups)
+	// Example: backup0
+	//
+	// API extension: backup_incremental
+	Parent string `json:"parent" yaml:"parent"`
 }
 
 // InstanceBackupPost represents the fields available for the renaming of a instance backup.
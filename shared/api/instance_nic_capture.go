@@ -0,0 +1,16 @@
+package api
+
+// InstanceNICCapturePost represents a request to capture the traffic of an instance NIC.
+//
+// swagger:model
+//
+// API extension: instance_nic_capture
+type InstanceNICCapturePost struct {
+	// Packet filter in pcap-filter syntax
+	// Example: tcp port 80
+	Filter string `json:"filter" yaml:"filter"`
+
+	// Capture duration in seconds (0 to capture until disconnected)
+	// Example: 60
+	Duration int `json:"duration" yaml:"duration"`
+}
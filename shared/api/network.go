@@ -182,6 +182,11 @@ type NetworkState struct {
 	//
 	// API extension: network_state_ovn
 	OVN *NetworkStateOVN `json:"ovn" yaml:"ovn"`
+
+	// QoS classes and their counters
+	//
+	// API extension: network_bridge_qos
+	QoS []NetworkStateQoSClass `json:"qos" yaml:"qos"`
 }
 
 // NetworkStateAddress represents a network address
@@ -226,6 +231,58 @@ type NetworkStateCounters struct {
 	PacketsSent int64 `json:"packets_sent" yaml:"packets_sent"`
 }
 
+// NetworkStateQoSClass represents the state of a QoS class
+//
+// swagger:model
+//
+// API extension: network_bridge_qos
+type NetworkStateQoSClass struct {
+	// Class name
+	// Example: voice
+	Name string `json:"name" yaml:"name"`
+
+	// Guaranteed bandwidth (bit/s)
+	// Example: 10000000
+	Rate int64 `json:"rate" yaml:"rate"`
+
+	// Maximum bandwidth (bit/s)
+	// Example: 100000000
+	Ceil int64 `json:"ceil" yaml:"ceil"`
+
+	// Priority of the NICs assigned to the class
+	// Example: 5
+	Priority string `json:"priority" yaml:"priority"`
+
+	// Counters of the traffic sent to the instances
+	Sent NetworkStateQoSCounters `json:"sent" yaml:"sent"`
+
+	// Counters of the traffic received from the instances
+	Received NetworkStateQoSCounters `json:"received" yaml:"received"`
+}
+
+// NetworkStateQoSCounters represents the counters of a QoS class
+//
+// swagger:model
+//
+// API extension: network_bridge_qos
+type NetworkStateQoSCounters struct {
+	// Number of bytes
+	// Example: 250542118
+	Bytes int64 `json:"bytes" yaml:"bytes"`
+
+	// Number of packets
+	// Example: 1182515
+	Packets int64 `json:"packets" yaml:"packets"`
+
+	// Number of dropped packets
+	// Example: 12
+	Drops int64 `json:"drops" yaml:"drops"`
+
+	// Number of times the class exceeded its rate
+	// Example: 104
+	Overlimits int64 `json:"overlimits" yaml:"overlimits"`
+}
+
 // NetworkStateBond represents bond specific state
 //
 // swagger:model
@@ -316,3 +373,78 @@ type NetworkStateOVN struct {
 	// OVN network chassis name
 	Chassis string `json:"chassis" yaml:"chassis"`
 }
+
+// NetworkBGPState represents the BGP state of a network
+//
+// swagger:model
+//
+// API extension: network_bgp_import
+type NetworkBGPState struct {
+	// List of BGP peers
+	Peers []NetworkBGPPeerState `json:"peers" yaml:"peers"`
+
+	// List of routes learned from the BGP peers
+	Routes []NetworkBGPRoute `json:"routes" yaml:"routes"`
+}
+
+// NetworkBGPPeerState represents the state of a BGP peer
+//
+// swagger:model
+//
+// API extension: network_bgp_import
+type NetworkBGPPeerState struct {
+	// Peer name
+	// Example: upstream
+	Name string `json:"name" yaml:"name"`
+
+	// Peer address
+	// Example: 10.0.0.1
+	Address string `json:"address" yaml:"address"`
+
+	// Peer ASN
+	// Example: 65000
+	ASN uint32 `json:"asn" yaml:"asn"`
+
+	// Session state
+	// Example: established
+	State string `json:"state" yaml:"state"`
+
+	// Time since the session was established (seconds)
+	// Example: 3600
+	Uptime int64 `json:"uptime" yaml:"uptime"`
+
+	// Number of routes received from the peer
+	// Example: 2
+	ReceivedRoutes uint64 `json:"received_routes" yaml:"received_routes"`
+
+	// Number of received routes accepted by the import policy
+	// Example: 1
+	AcceptedRoutes uint64 `json:"accepted_routes" yaml:"accepted_routes"`
+}
+
+// NetworkBGPRoute represents a route learned from a BGP peer
+//
+// swagger:model
+//
+// API extension: network_bgp_import
+type NetworkBGPRoute struct {
+	// Route prefix
+	// Example: 0.0.0.0/0
+	Prefix string `json:"prefix" yaml:"prefix"`
+
+	// Route next hop
+	// Example: 10.0.0.1
+	NextHop string `json:"nexthop" yaml:"nexthop"`
+
+	// Name of the peer the route was learned from
+	// Example: upstream
+	Peer string `json:"peer" yaml:"peer"`
+
+	// BGP communities attached to the route
+	// Example: ["65000:100"]
+	Communities []string `json:"communities" yaml:"communities"`
+
+	// Whether the route passed the import policy of the peer
+	// Example: true
+	Accepted bool `json:"accepted" yaml:"accepted"`
+}
@@ -149,3 +149,31 @@ type NetworkACLsPost struct {
 	NetworkACLPost `yaml:",inline"`
 	NetworkACLPut  `yaml:",inline"`
 }
+
+// NetworkACLState used for displaying the state of an ACL.
+//
+// swagger:model
+//
+// API extension: network_acl_state
+type NetworkACLState struct {
+	// Counters of the egress rules (in the same order as the rules)
+	Egress []NetworkACLRuleState `json:"egress" yaml:"egress"`
+
+	// Counters of the ingress rules (in the same order as the rules)
+	Ingress []NetworkACLRuleState `json:"ingress" yaml:"ingress"`
+}
+
+// NetworkACLRuleState used for displaying the hit counters of an ACL rule.
+//
+// swagger:model
+//
+// API extension: network_acl_state
+type NetworkACLRuleState struct {
+	// Number of packets which matched the rule
+	// Example: 1024
+	Packets uint64 `json:"packets" yaml:"packets"`
+
+	// Number of bytes which matched the rule
+	// Example: 65536
+	Bytes uint64 `json:"bytes" yaml:"bytes"`
+}
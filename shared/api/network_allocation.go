@@ -0,0 +1,40 @@
+package api
+
+// NetworkAllocations represents an address assigned by LXD to an entity (instance, network, forward...).
+//
+// swagger:model
+//
+// API extension: network_allocations
+type NetworkAllocations struct {
+	// The allocated address (in CIDR format)
+	// Example: 10.0.0.2/32
+	Address string `json:"address" yaml:"address"`
+
+	// URL of the entity using the address
+	// Example: /1.0/instances/c1?project=default
+	UsedBy string `json:"used_by" yaml:"used_by"`
+
+	// Type of the entity using the address (instance, network, network-forward, network-load-balancer or uplink)
+	// Example: instance
+	Type string `json:"type" yaml:"type"`
+
+	// Whether the traffic of the entity is NATed by LXD
+	// Example: true
+	NAT bool `json:"nat" yaml:"nat"`
+
+	// MAC address of the entity using the address
+	// Example: 00:16:3e:00:00:01
+	Hwaddr string `json:"hwaddr" yaml:"hwaddr"`
+
+	// Name of the network the address belongs to
+	// Example: lxdbr0
+	Network string `json:"network" yaml:"network"`
+
+	// Project of the entity using the address
+	// Example: default
+	Project string `json:"project" yaml:"project"`
+
+	// Cluster member the address is in use on (if specific to one)
+	// Example: lxd01
+	Location string `json:"location" yaml:"location"`
+}
@@ -772,6 +772,18 @@ type ResourcesStoragePoolSpace struct {
 	// Total disk space (bytes)
 	// Example: 420100937728
 	Total uint64 `json:"total" yaml:"total"`
+
+	// Disk space saved through extents shared between volumes (bytes)
+	// Example: 10737418240
+	//
+	// API extension: storage_btrfs_compression_dedup
+	Saved uint64 `json:"saved,omitempty" yaml:"saved,omitempty"`
+
+	// Disk space promised to volumes, which can exceed the total on thin provisioned pools (bytes)
+	// Example: 644245094400
+	//
+	// API extension: storage_pool_overcommit
+	Provisioned uint64 `json:"provisioned,omitempty" yaml:"provisioned,omitempty"`
 }
 
 // ResourcesStoragePoolInodes represents the inodes available to a given storage pool
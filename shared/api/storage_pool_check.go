@@ -0,0 +1,69 @@
+package api
+
+// StoragePoolCheckIssueOrphan is a volume found on the storage pool without a database record.
+const StoragePoolCheckIssueOrphan = "orphan"
+
+// StoragePoolCheckIssueMissing is a volume with a database record that isn't found on the storage pool.
+const StoragePoolCheckIssueMissing = "missing"
+
+// StoragePoolCheckIssueQuota is a volume whose size on the storage pool doesn't match its configured size.
+const StoragePoolCheckIssueQuota = "quota"
+
+// StoragePoolCheckIssueContentType is a volume whose content type on the storage pool doesn't match its database record.
+const StoragePoolCheckIssueContentType = "content_type"
+
+// StoragePoolCheckPost represents the fields used to start a consistency check of a LXD storage pool
+//
+// swagger:model
+//
+// API extension: storage_pool_check
+type StoragePoolCheckPost struct {
+	// Whether to attempt to repair the issues that are found
+	// Example: false
+	Repair bool `json:"repair" yaml:"repair"`
+}
+
+// StoragePoolCheckIssue represents an inconsistency found by a storage pool consistency check
+//
+// swagger:model
+//
+// API extension: storage_pool_check
+type StoragePoolCheckIssue struct {
+	// Type of issue (orphan, missing, quota or content_type)
+	// Example: missing
+	Type string `json:"type" yaml:"type"`
+
+	// Project the volume belongs to
+	// Example: default
+	Project string `json:"project" yaml:"project"`
+
+	// Volume type (container, virtual-machine or custom)
+	// Example: custom
+	VolumeType string `json:"volume_type" yaml:"volume_type"`
+
+	// Volume name
+	// Example: vol1
+	Volume string `json:"volume" yaml:"volume"`
+
+	// Description of the issue
+	// Example: Volume record exists in the database but the volume wasn't found on the storage pool
+	Description string `json:"description" yaml:"description"`
+
+	// Whether the issue was repaired
+	// Example: false
+	Repaired bool `json:"repaired" yaml:"repaired"`
+
+	// Reason the issue couldn't be repaired (when repairing was requested)
+	// Example: Volume is in use by instances
+	RepairError string `json:"repair_error" yaml:"repair_error"`
+}
+
+// StoragePoolCheckResult represents the result of a storage pool consistency check
+//
+// swagger:model
+//
+// API extension: storage_pool_check
+type StoragePoolCheckResult struct {
+	// List of issues found
+	Issues []StoragePoolCheckIssue `json:"issues" yaml:"issues"`
+}
@@ -0,0 +1,16 @@
+package api
+
+// StoragePoolDeduplicateResult represents the result of a LXD storage pool deduplication
+//
+// swagger:model
+//
+// API extension: storage_btrfs_compression_dedup
+type StoragePoolDeduplicateResult struct {
+	// Amount of data that was found to be identical and is now shared (bytes)
+	// Example: 2147483648
+	Deduplicated int64 `json:"deduplicated" yaml:"deduplicated"`
+
+	// Disk space freed by the deduplication (bytes)
+	// Example: 1073741824
+	Saved int64 `json:"saved" yaml:"saved"`
+}
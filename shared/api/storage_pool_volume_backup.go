@@ -27,6 +27,12 @@ type StoragePoolVolumeBackup struct {
 	// Whether to use a pool-optimized binary format (instead of plain tarball)
 	// Example: true
 	OptimizedStorage bool `json:"optimized_storage" yaml:"optimized_storage"`
+
+	// Name of the parent backup (for incremental backups)
+	// Example: backup0
+	//
+	// API extension: backup_incremental
+	Parent string `json:"parent" yaml:"parent"`
 }
 
 // StoragePoolVolumeBackupsPost represents the fields available for a new LXD volume backup
@@ -54,6 +60,12 @@ type StoragePoolVolumeBackupsPost struct {
 	// What compression algorithm to use
 	// Example: gzip
 	CompressionAlgorithm string `json:"compression_algorithm" yaml:"compression_algorithm"`
+
+	// Name of the parent backup to make an incremental backup against
+	// Example: backup0
+	//
+	// API extension: backup_incremental
+	Parent string `json:"parent" yaml:"parent"`
 }
 
 // StoragePoolVolumeBackupPost represents the fields available for the renaming of a volume backup
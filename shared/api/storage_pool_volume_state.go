@@ -1,5 +1,9 @@
 package api
 
+import (
+	"time"
+)
+
 // StorageVolumeState represents the live state of the volume
 //
 // swagger:model
@@ -8,6 +12,16 @@ package api
 type StorageVolumeState struct {
 	// Volume usage
 	Usage *StorageVolumeStateUsage `json:"usage" yaml:"usage"`
+
+	// Volume IO counters (only available for volumes backed by their own block device)
+	//
+	// API extension: storage_volume_limits_io
+	IO *StorageVolumeStateIO `json:"io,omitempty" yaml:"io,omitempty"`
+
+	// Volume replication status (only set for volumes with a replication target)
+	//
+	// API extension: storage_volume_replication
+	Replication *StorageVolumeStateReplication `json:"replication,omitempty" yaml:"replication,omitempty"`
 }
 
 // StorageVolumeStateUsage represents the disk usage of a volume
@@ -26,3 +40,45 @@ type StorageVolumeStateUsage struct {
 	// API extension: storage_volume_state_total
 	Total int64 `json:"total" yaml:"total"`
 }
+
+// StorageVolumeStateIO represents the IO counters of a volume
+//
+// swagger:model
+//
+// API extension: storage_volume_limits_io
+type StorageVolumeStateIO struct {
+	// Number of bytes read
+	// Example: 3267203072
+	ReadBytes uint64 `json:"read_bytes" yaml:"read_bytes"`
+
+	// Number of completed read operations
+	// Example: 84201
+	ReadsCompleted uint64 `json:"reads_completed" yaml:"reads_completed"`
+
+	// Number of bytes written
+	// Example: 1062371328
+	WrittenBytes uint64 `json:"written_bytes" yaml:"written_bytes"`
+
+	// Number of completed write operations
+	// Example: 20349
+	WritesCompleted uint64 `json:"writes_completed" yaml:"writes_completed"`
+}
+
+// StorageVolumeStateReplication represents the replication status of a volume
+//
+// swagger:model
+//
+// API extension: storage_volume_replication
+type StorageVolumeStateReplication struct {
+	// Replication target server
+	// Example: https://10.0.0.2:8443
+	Target string `json:"target" yaml:"target"`
+
+	// Start time of the last successful replication
+	// Example: 2021-03-23T17:38:37.753398689-04:00
+	LastSync time.Time `json:"last_sync" yaml:"last_sync"`
+
+	// Number of seconds since the start of the last successful replication (-1 if never replicated)
+	// Example: 342
+	Lag int64 `json:"lag" yaml:"lag"`
+}
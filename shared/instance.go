@@ -89,6 +89,17 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 
 	"cluster.evacuate": validate.Optional(validate.IsOneOf("auto", "migrate", "live-migrate", "stop")),
 
+	"limits.autoscale.cooldown":              validate.Optional(validate.IsUint32),
+	"limits.autoscale.cpu.max":               validate.Optional(validate.IsUint32),
+	"limits.autoscale.cpu.min":               validate.Optional(validate.IsUint32),
+	"limits.autoscale.cpu.threshold.high":    validate.Optional(validate.IsInRange(1, 100)),
+	"limits.autoscale.cpu.threshold.low":     validate.Optional(validate.IsInRange(0, 99)),
+	"limits.autoscale.memory.max":            validate.Optional(validate.IsSize),
+	"limits.autoscale.memory.min":            validate.Optional(validate.IsSize),
+	"limits.autoscale.memory.step":           validate.Optional(validate.IsSize),
+	"limits.autoscale.memory.threshold.high": validate.Optional(validate.IsInRange(1, 100)),
+	"limits.autoscale.memory.threshold.low":  validate.Optional(validate.IsInRange(0, 99)),
+
 	"limits.cpu": func(value string) error {
 		if value == "" {
 			return nil
@@ -150,6 +161,10 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	"security.devlxd":            validate.Optional(validate.IsBool),
 	"security.protection.delete": validate.Optional(validate.IsBool),
 
+	"schedule.restart": validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@never"})),
+	"schedule.start":   validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@never"})),
+	"schedule.stop":    validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@never"})),
+
 	"snapshots.schedule":         validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@startup", "@never"})),
 	"snapshots.schedule.stopped": validate.Optional(validate.IsBool),
 	"snapshots.pattern":          validate.IsAny,
@@ -158,6 +173,9 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 		_, err := GetSnapshotExpiry(time.Time{}, value)
 		return err
 	},
+	"snapshots.retention.hourly": validate.Optional(validate.IsUint32),
+	"snapshots.retention.daily":  validate.Optional(validate.IsUint32),
+	"snapshots.retention.weekly": validate.Optional(validate.IsUint32),
 
 	// Volatile keys.
 	"volatile.apply_template":         validate.IsAny,
@@ -270,7 +288,9 @@ var InstanceConfigKeysContainer = map[string]func(value string) error{
 
 // InstanceConfigKeysVM is a map of config key to validator. (keys applying to VM only)
 var InstanceConfigKeysVM = map[string]func(value string) error{
+	"limits.cpu.max":          validate.Optional(validate.IsUint32),
 	"limits.memory.hugepages": validate.Optional(validate.IsBool),
+	"limits.memory.max":       validate.Optional(validate.IsSize),
 
 	"migration.stateful": validate.Optional(validate.IsBool),
 
@@ -283,7 +303,9 @@ var InstanceConfigKeysVM = map[string]func(value string) error{
 
 	"agent.nic_config": validate.Optional(validate.IsBool),
 
-	"volatile.apply_nvram": validate.Optional(validate.IsBool),
+	"volatile.apply_nvram":       validate.Optional(validate.IsBool),
+	"volatile.memory.base":       validate.Optional(validate.IsInt64),
+	"volatile.memory.hotplugged": validate.Optional(validate.IsListOf(validate.IsInt64)),
 }
 
 // ConfigKeyChecker returns a function that will check whether or not
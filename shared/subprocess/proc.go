@@ -34,6 +34,10 @@ type Process struct {
 	Stdout   io.WriteCloser `yaml:"-"`
 	Stderr   io.WriteCloser `yaml:"-"`
 
+	// Env holds additional environment variables passed to the process (in KEY=value form).
+	// It isn't saved as it may contain secrets.
+	Env []string `yaml:"-"`
+
 	UID       uint32 `yaml:"uid"`
 	GID       uint32 `yaml:"gid"`
 	SetGroups bool   `yaml:"set_groups"`
@@ -126,6 +130,10 @@ func (p *Process) start(fds []*os.File) error {
 	cmd.Stdout = p.Stdout
 	cmd.Stderr = p.Stderr
 	cmd.Stdin = p.Stdin
+	if len(p.Env) > 0 {
+		cmd.Env = append(os.Environ(), p.Env...)
+	}
+
 	cmd.SysProcAttr = p.SysProcAttr
 	if cmd.SysProcAttr == nil {
 		cmd.SysProcAttr = &syscall.SysProcAttr{}
@@ -0,0 +1,42 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBitSizeString(t *testing.T) {
+	tests := []struct {
+		input     int64
+		precision uint
+		output    string
+	}{
+		{input: 0, precision: 2, output: "0bit"},
+		{input: 999, precision: 2, output: "999bit"},
+		{input: 1000, precision: 0, output: "1kbit"},
+		{input: 1000, precision: 2, output: "1.00kbit"},
+		{input: 1500000, precision: 1, output: "1.5Mbit"},
+		{input: 100000000, precision: 0, output: "100Mbit"},
+		{input: 1000000000, precision: 0, output: "1Gbit"},
+		{input: 2500000000, precision: 2, output: "2.50Gbit"},
+		{input: 1234567890123, precision: 3, output: "1.235Tbit"},
+		{input: 1000000000000000, precision: 0, output: "1Pbit"},
+		{input: 9223372036854775807, precision: 2, output: "9.22Ebit"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.output, func(t *testing.T) {
+			assert.Equal(t, test.output, GetBitSizeString(test.input, test.precision))
+		})
+	}
+}
+
+func TestGetBitSizeStringRoundTrip(t *testing.T) {
+	for _, input := range []int64{1, 999, 1000, 100000, 10000000, 1000000000, 40000000000, 1000000000000000} {
+		value, err := ParseBitSizeString(GetBitSizeString(input, 0))
+		require.NoError(t, err)
+		assert.Equal(t, input, value)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -36,6 +37,7 @@ const SnapshotDelimiter = "/"
 const HTTPSDefaultPort = 8443
 const HTTPDefaultPort = 8080
 const HTTPSMetricsDefaultPort = 9100
+const HTTPSStorageBucketsDefaultPort = 9000
 
 // URLEncode encodes a path and query parameters to a URL.
 func URLEncode(path string, query map[string]string) (string, error) {
@@ -1227,6 +1229,94 @@ func GetSnapshotExpiry(refDate time.Time, s string) (time.Time, error) {
 	return t, nil
 }
 
+// SnapshotRetention represents a grandfather-father-son snapshot retention policy.
+type SnapshotRetention struct {
+	Hourly int
+	Daily  int
+	Weekly int
+}
+
+// GetSnapshotRetention returns the snapshot retention policy set by the "snapshots.retention.*" keys of config.
+// Returns nil if no retention policy is set.
+func GetSnapshotRetention(config map[string]string) (*SnapshotRetention, error) {
+	retention := SnapshotRetention{}
+	found := false
+
+	for key, count := range map[string]*int{"hourly": &retention.Hourly, "daily": &retention.Daily, "weekly": &retention.Weekly} {
+		value := config[fmt.Sprintf("snapshots.retention.%s", key)]
+		if value == "" {
+			continue
+		}
+
+		var err error
+		*count, err = strconv.Atoi(value)
+		if err != nil || *count < 0 {
+			return nil, fmt.Errorf("Invalid snapshot retention %q for %q", value, key)
+		}
+
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return &retention, nil
+}
+
+// SnapshotsToPrune returns the indexes of the snapshots (given by their creation dates) that aren't kept by the
+// retention policy. The most recent snapshot of each of the last Hourly hours, Daily days and Weekly (ISO) weeks
+// that have snapshots is kept. Snapshots with an unknown (zero) creation date are always kept.
+func (r SnapshotRetention) SnapshotsToPrune(creationDates []time.Time) []int {
+	// Sort the snapshots from newest to oldest.
+	order := make([]int, 0, len(creationDates))
+	for i, date := range creationDates {
+		if date.IsZero() {
+			continue
+		}
+
+		order = append(order, i)
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return creationDates[order[a]].After(creationDates[order[b]])
+	})
+
+	keep := make(map[int]bool, len(order))
+	keepPeriods := func(count int, period func(date time.Time) string) {
+		seen := make(map[string]bool, count)
+		for _, i := range order {
+			key := period(creationDates[i].UTC())
+			if seen[key] {
+				continue
+			}
+
+			if len(seen) >= count {
+				break
+			}
+
+			seen[key] = true
+			keep[i] = true
+		}
+	}
+
+	keepPeriods(r.Hourly, func(date time.Time) string { return date.Format("2006-01-02T15") })
+	keepPeriods(r.Daily, func(date time.Time) string { return date.Format("2006-01-02") })
+	keepPeriods(r.Weekly, func(date time.Time) string {
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+
+	prune := []int{}
+	for i := len(order) - 1; i >= 0; i-- {
+		if !keep[order[i]] {
+			prune = append(prune, order[i])
+		}
+	}
+
+	return prune
+}
+
 // InSnap returns true if we're running inside the LXD snap.
 func InSnap() bool {
 	// Detect the snap.
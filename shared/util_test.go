@@ -191,6 +191,58 @@ func TestGetSnapshotExpiry(t *testing.T) {
 	require.Equal(t, time.Time{}, expiryDate)
 }
 
+func TestGetSnapshotRetention(t *testing.T) {
+	retention, err := GetSnapshotRetention(map[string]string{"snapshots.expiry": "1d"})
+	require.NoError(t, err)
+	require.Nil(t, retention)
+
+	retention, err = GetSnapshotRetention(map[string]string{"snapshots.retention.hourly": "24", "snapshots.retention.weekly": "4"})
+	require.NoError(t, err)
+	require.Equal(t, &SnapshotRetention{Hourly: 24, Weekly: 4}, retention)
+
+	_, err = GetSnapshotRetention(map[string]string{"snapshots.retention.daily": "-1"})
+	require.Error(t, err)
+}
+
+func TestSnapshotRetentionSnapshotsToPrune(t *testing.T) {
+	// One snapshot every 6 hours over 3 weeks, oldest first, starting on a Monday.
+	start := time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC)
+	dates := []time.Time{}
+	for i := 0; i < 21*4; i++ {
+		dates = append(dates, start.Add(time.Duration(i)*6*time.Hour))
+	}
+
+	// Nothing is kept without a policy.
+	prune := SnapshotRetention{}.SnapshotsToPrune(dates)
+	require.Len(t, prune, len(dates))
+	require.Equal(t, 0, prune[0]) // Oldest first.
+
+	// Last 2 snapshots by hour, last snapshot of the last 3 days and the last snapshot of the 3 weeks.
+	prune = SnapshotRetention{Hourly: 2, Daily: 3, Weekly: 3}.SnapshotsToPrune(dates)
+	kept := map[int]bool{}
+	for i := range dates {
+		kept[i] = true
+	}
+
+	for _, i := range prune {
+		delete(kept, i)
+	}
+
+	last := len(dates) - 1
+	require.Equal(t, map[int]bool{
+		last - 1: true, // Hourly.
+		last:     true, // Hourly, daily and weekly.
+		last - 4: true, // Daily.
+		last - 8: true, // Daily.
+		27:       true, // Weekly (last snapshot of the first week).
+		55:       true, // Weekly (last snapshot of the second week).
+	}, kept)
+
+	// Snapshots without a creation date are always kept.
+	prune = SnapshotRetention{Hourly: 1}.SnapshotsToPrune([]time.Time{{}, start, start.Add(time.Hour)})
+	require.Equal(t, []int{1}, prune)
+}
+
 func TestHasKey(t *testing.T) {
 	m1 := map[string]string{
 		"foo":   "bar",
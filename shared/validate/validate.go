@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"net"
+	"net/url"
 	"os/exec"
 	"path/filepath"
 	"regexp"
@@ -763,6 +764,28 @@ func IsAbsFilePath(value string) error {
 	return nil
 }
 
+// IsRequestURL checks value is a valid HTTP or HTTPS URL.
+func IsRequestURL(value string) error {
+	if value == "" {
+		return fmt.Errorf("Empty URL")
+	}
+
+	u, err := url.ParseRequestURI(value)
+	if err != nil {
+		return fmt.Errorf("Invalid URL: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("Unsupported URL scheme %q", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("Missing URL host")
+	}
+
+	return nil
+}
+
 // ParseNetworkVLANRange parses a VLAN range in the form "number" or "start-end".
 // Returns the start number and the number of items in the range.
 func ParseNetworkVLANRange(vlan string) (int, int, error) {
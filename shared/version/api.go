@@ -335,6 +335,31 @@ var APIExtensions = []string{
 	"clustering_evacuation_mode",
 	"resources_pci_vpd",
 	"qemu_raw_conf",
+	"storage_buckets",
+	"backup_incremental",
+	"storage_pool_check",
+	"storage_btrfs_compression_dedup",
+	"instance_pool_move_live",
+	"storage_volume_limits_io",
+	"snapshot_retention",
+	"storage_volume_replication",
+	"storage_images_chunks",
+	"storage_pool_overcommit",
+	"network_load_balancer",
+	"network_zones_dns_update",
+	"network_zones_dns_query",
+	"network_acl_state",
+	"network_bgp_import",
+	"network_type_wireguard",
+	"network_allocations",
+	"network_dhcp_options",
+	"network_bridge_qos",
+	"instance_nic_capture",
+	"vm_live_migration",
+	"vm_cpu_memory_hotplug",
+	"storage_dir_qcow2_snapshots",
+	"instance_autoscale",
+	"instance_schedule",
 }
 
 // APIExtensionsCount returns the number of available API extensions.